@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/mongodb/grip/recovery"
 )
 
@@ -109,6 +110,8 @@ func (c *envCache) Get(key string) (interface{}, bool) {
 	defer c.mu.RUnlock()
 
 	value, ok := c.cache[key]
+	metrics.ObserveCacheRequest(metrics.CacheEnv, ok)
+
 	return value, ok
 }
 
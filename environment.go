@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/evergreen-ci/cedar/tracing"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/management"
@@ -58,7 +59,7 @@ func NewEnvironment(ctx context.Context, name string, conf *Configuration) (Envi
 			SetConnectTimeout(conf.MongoDBDialTimeout).
 			SetSocketTimeout(conf.SocketTimeout).
 			SetServerSelectionTimeout(conf.SocketTimeout).
-			SetMonitor(apm.NewLoggingMonitor(ctx, time.Minute, apm.NewBasicMonitor(&apm.MonitorConfig{AllTags: true})).DriverAPM())
+			SetMonitor(tracing.NewMongoMonitor(apm.NewLoggingMonitor(ctx, time.Minute, apm.NewBasicMonitor(&apm.MonitorConfig{AllTags: true})).DriverAPM()))
 		if conf.HasAuth() {
 			credential := options.Credential{
 				Username: conf.DBUser,
@@ -211,7 +212,7 @@ func NewEnvironment(ctx context.Context, name string, conf *Configuration) (Envi
 		return nil
 	})
 
-	env.statsCacheRegistry = newStatsCacheRegistry(env.ctx)
+	env.statsCacheRegistry = newStatsCacheRegistry(env.ctx, env)
 
 	return env, nil
 }
@@ -241,6 +242,12 @@ type Environment interface {
 	GetServerCertVersion() int
 	SetServerCertVersion(i int)
 
+	// GetIngestionStatsTTL returns how long the ingestion stats flushed by
+	// the stats caches are kept, which defaults to
+	// DefaultIngestionStatsTTL.
+	GetIngestionStatsTTL() time.Duration
+	SetIngestionStatsTTL(time.Duration)
+
 	// GetStatsCache returns the cache corresponding to the string.
 	GetStatsCache(string) *statsCache
 
@@ -286,6 +293,7 @@ type envState struct {
 	jpm                jasper.Manager
 	statsCacheRegistry map[string]*statsCache
 	serverCertVersion  int
+	ingestionStatsTTL  time.Duration
 	closers            []closerOp
 	mutex              sync.RWMutex
 }
@@ -444,6 +452,23 @@ func (c *envState) Jasper() jasper.Manager {
 	return c.jpm
 }
 
+func (c *envState) GetIngestionStatsTTL() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	if c.ingestionStatsTTL <= 0 {
+		return DefaultIngestionStatsTTL
+	}
+	return c.ingestionStatsTTL
+}
+
+func (c *envState) SetIngestionStatsTTL(ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ingestionStatsTTL = ttl
+}
+
 func (c *envState) GetStatsCache(name string) *statsCache {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
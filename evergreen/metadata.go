@@ -0,0 +1,93 @@
+package evergreen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Task is the subset of an Evergreen task used by Cedar.
+type Task struct {
+	ID           string `json:"task_id"`
+	ProjectID    string `json:"project_id"`
+	VersionID    string `json:"version_id"`
+	BuildID      string `json:"build_id"`
+	BuildVariant string `json:"build_variant"`
+	DistroID     string `json:"distro_id"`
+	Execution    int    `json:"execution"`
+}
+
+// RepotrackerRequester is the requester of Evergreen versions created for
+// mainline commits.
+const RepotrackerRequester = "gitter_request"
+
+// Version is the subset of an Evergreen version used by Cedar.
+type Version struct {
+	ID          string    `json:"version_id"`
+	Author      string    `json:"author"`
+	AuthorEmail string    `json:"author_email"`
+	Revision    string    `json:"revision"`
+	Requester   string    `json:"requester"`
+	CreateTime  time.Time `json:"create_time"`
+}
+
+// CommitTime returns the commit time of the version's revision. Only versions
+// of mainline commits are created at commit time, so this is the zero time for
+// patches and other requesters.
+func (v *Version) CommitTime() time.Time {
+	if v.Requester != RepotrackerRequester {
+		return time.Time{}
+	}
+
+	return v.CreateTime
+}
+
+// Build is the subset of an Evergreen build used by Cedar.
+type Build struct {
+	ID           string `json:"_id"`
+	BuildVariant string `json:"build_variant"`
+	DisplayName  string `json:"display_name"`
+}
+
+// GetTask returns the Evergreen task with the given ID.
+func (c *Client) GetTask(ctx context.Context, id string) (*Task, error) {
+	task := &Task{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/tasks/%s", url.PathEscape(id)), task); err != nil {
+		return nil, errors.Wrapf(err, "getting task '%s'", id)
+	}
+
+	return task, nil
+}
+
+// GetVersion returns the Evergreen version with the given ID.
+func (c *Client) GetVersion(ctx context.Context, id string) (*Version, error) {
+	version := &Version{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/versions/%s", url.PathEscape(id)), version); err != nil {
+		return nil, errors.Wrapf(err, "getting version '%s'", id)
+	}
+
+	return version, nil
+}
+
+// GetBuild returns the Evergreen build with the given ID.
+func (c *Client) GetBuild(ctx context.Context, id string) (*Build, error) {
+	build := &Build{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/builds/%s", url.PathEscape(id)), build); err != nil {
+		return nil, errors.Wrapf(err, "getting build '%s'", id)
+	}
+
+	return build, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, out interface{}) error {
+	data, _, err := c.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(json.Unmarshal(data, out), "unmarshalling response")
+}
@@ -0,0 +1,69 @@
+package evergreen
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetadata(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/v2/tasks/task", func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "user", r.Header.Get("Api-User"))
+		assert.Equal(t, "key", r.Header.Get("Api-Key"))
+		_, _ = rw.Write([]byte(`{"task_id": "task", "project_id": "project", "version_id": "version", "build_id": "build", "distro_id": "distro", "execution": 1}`))
+	})
+	mux.HandleFunc("/rest/v2/versions/version", func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte(`{"version_id": "version", "author": "author", "author_email": "author@example.com", "revision": "abcdef", "requester": "gitter_request", "create_time": "2021-01-02T03:04:05Z"}`))
+	})
+	mux.HandleFunc("/rest/v2/builds/build", func(rw http.ResponseWriter, r *http.Request) {
+		_, _ = rw.Write([]byte(`{"_id": "build", "build_variant": "variant", "display_name": "Variant"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := NewClient(srv.Client(), ConnectionOptions{RootURL: srv.URL, User: "user", Key: "key"})
+
+	t.Run("Task", func(t *testing.T) {
+		task, err := client.GetTask(ctx, "task")
+		require.NoError(t, err)
+		assert.Equal(t, &Task{
+			ID:        "task",
+			ProjectID: "project",
+			VersionID: "version",
+			BuildID:   "build",
+			DistroID:  "distro",
+			Execution: 1,
+		}, task)
+	})
+	t.Run("Version", func(t *testing.T) {
+		version, err := client.GetVersion(ctx, "version")
+		require.NoError(t, err)
+		assert.Equal(t, "author", version.Author)
+		assert.Equal(t, "author@example.com", version.AuthorEmail)
+		assert.Equal(t, "abcdef", version.Revision)
+		assert.Equal(t, "gitter_request", version.Requester)
+		assert.True(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC).Equal(version.CreateTime))
+		assert.True(t, version.CreateTime.Equal(version.CommitTime()))
+
+		version.Requester = "patch_request"
+		assert.True(t, version.CommitTime().IsZero())
+	})
+	t.Run("Build", func(t *testing.T) {
+		build, err := client.GetBuild(ctx, "build")
+		require.NoError(t, err)
+		assert.Equal(t, "Variant", build.DisplayName)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := client.GetTask(ctx, "missing")
+		assert.Error(t, err)
+	})
+}
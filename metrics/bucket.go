@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/pail"
+)
+
+// InstrumentBucket wraps the bucket so that the latency and failures of its
+// read and write operations are recorded in the pail operation metrics.
+func InstrumentBucket(b pail.Bucket, bucketType string) pail.Bucket {
+	if b == nil {
+		return nil
+	}
+	if _, ok := b.(*instrumentedBucket); ok {
+		return b
+	}
+
+	return &instrumentedBucket{Bucket: b, bucketType: bucketType}
+}
+
+type instrumentedBucket struct {
+	pail.Bucket
+	bucketType string
+}
+
+func (b *instrumentedBucket) observe(operation string, start time.Time, err error) {
+	PailOperationDuration.WithLabelValues(b.bucketType, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		PailOperationErrors.WithLabelValues(b.bucketType, operation).Inc()
+	}
+}
+
+func (b *instrumentedBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	start := time.Now()
+	r, err := b.Bucket.Get(ctx, key)
+	b.observe("get", start, err)
+	return r, err
+}
+
+func (b *instrumentedBucket) Put(ctx context.Context, key string, r io.Reader) error {
+	start := time.Now()
+	err := b.Bucket.Put(ctx, key, r)
+	b.observe("put", start, err)
+	return err
+}
+
+func (b *instrumentedBucket) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	start := time.Now()
+	r, err := b.Bucket.Reader(ctx, key)
+	b.observe("reader", start, err)
+	return r, err
+}
+
+func (b *instrumentedBucket) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	start := time.Now()
+	w, err := b.Bucket.Writer(ctx, key)
+	b.observe("writer", start, err)
+	return w, err
+}
+
+func (b *instrumentedBucket) Upload(ctx context.Context, key, path string) error {
+	start := time.Now()
+	err := b.Bucket.Upload(ctx, key, path)
+	b.observe("upload", start, err)
+	return err
+}
+
+func (b *instrumentedBucket) Download(ctx context.Context, key, path string) error {
+	start := time.Now()
+	err := b.Bucket.Download(ctx, key, path)
+	b.observe("download", start, err)
+	return err
+}
+
+func (b *instrumentedBucket) Remove(ctx context.Context, key string) error {
+	start := time.Now()
+	err := b.Bucket.Remove(ctx, key)
+	b.observe("remove", start, err)
+	return err
+}
+
+func (b *instrumentedBucket) List(ctx context.Context, prefix string) (pail.BucketIterator, error) {
+	start := time.Now()
+	it, err := b.Bucket.List(ctx, prefix)
+	b.observe("list", start, err)
+	return it, err
+}
@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "cedar"
+
+// Ingestion service label values.
+const (
+	ServiceBuildlogger   = "buildlogger"
+	ServiceTestResults   = "test_results"
+	ServicePerf          = "perf"
+	ServiceSystemMetrics = "system_metrics"
+)
+
+// Cache label values.
+const (
+	CacheEnv        = "env"
+	CacheLogin      = "login"
+	CacheRevocation = "certificate_revocation"
+
+	CacheHit  = "hit"
+	CacheMiss = "miss"
+)
+
+// Cedar service metrics.
+var (
+	// IngestedItems counts the log lines, test results, performance
+	// results and system metrics chunks ingested, by service.
+	IngestedItems = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingested_items_total",
+		Help:      "Number of items (log lines, test results, performance results or system metrics chunks) ingested.",
+	}, []string{"service"})
+	// IngestionRequests counts the ingestion requests, by service.
+	IngestionRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ingestion_requests_total",
+		Help:      "Number of ingestion requests.",
+	}, []string{"service"})
+	// DroppedIngestionStats counts the ingestion stats that were dropped
+	// because the stats cache was full, by service.
+	DroppedIngestionStats = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dropped_ingestion_stats_total",
+		Help:      "Number of ingestion stats dropped because the stats cache was full.",
+	}, []string{"service"})
+
+	// GRPCRequests counts the handled gRPC requests by method and status
+	// code.
+	GRPCRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "Number of handled gRPC requests.",
+	}, []string{"method", "code"})
+	// GRPCRequestDuration tracks the latency of gRPC requests by method.
+	GRPCRequestDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "Latency of handled gRPC requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	// PailOperationDuration tracks the latency of bucket operations by
+	// bucket type and operation.
+	PailOperationDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pail_operation_duration_seconds",
+		Help:      "Latency of pail bucket operations.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"bucket_type", "operation"})
+	// PailOperationErrors counts the failed bucket operations by bucket
+	// type and operation.
+	PailOperationErrors = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pail_operation_errors_total",
+		Help:      "Number of failed pail bucket operations.",
+	}, []string{"bucket_type", "operation"})
+
+	// CacheRequests counts the lookups in Cedar's caches by cache and
+	// result.
+	CacheRequests = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of cache lookups.",
+	}, []string{"cache", "result"})
+
+	// QueueJobs reports the number of jobs in the amboy queues by state.
+	// It is updated by the amboy stats collector jobs.
+	QueueJobs = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_jobs",
+		Help:      "Number of jobs in the amboy queues.",
+	}, []string{"queue", "state"})
+
+	// DBCollectionStorageSize reports the storage size of the DB
+	// collections. It is updated by the DB collection size stats job.
+	DBCollectionStorageSize = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_collection_storage_size_bytes",
+		Help:      "Storage size of the DB collections.",
+	}, []string{"collection"})
+	// DBCollectionIndexSize reports the total index size of the DB
+	// collections. It is updated by the DB collection size stats job.
+	DBCollectionIndexSize = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_collection_index_size_bytes",
+		Help:      "Total index size of the DB collections.",
+	}, []string{"collection"})
+	// DBCollectionDocuments reports the number of documents in the DB
+	// collections. It is updated by the DB collection size stats job.
+	DBCollectionDocuments = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "db_collection_documents",
+		Help:      "Number of documents in the DB collections.",
+	}, []string{"collection"})
+)
+
+// ObserveCacheRequest records a cache lookup with the given result.
+func ObserveCacheRequest(cache string, hit bool) {
+	result := CacheMiss
+	if hit {
+		result = CacheHit
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
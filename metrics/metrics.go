@@ -0,0 +1,45 @@
+// Package metrics defines the Prometheus metrics of the Cedar service and
+// serves them in the OpenMetrics text format.
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry is the registry to which the Cedar service metrics are
+// registered, along with the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler returns an HTTP handler that serves the registered metrics, in the
+// OpenMetrics text format if the client accepts it.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		ErrorLog:          errorLogger{},
+		ErrorHandling:     promhttp.ContinueOnError,
+		EnableOpenMetrics: true,
+	})
+}
+
+// errorLogger logs the errors encountered while serving the metrics.
+type errorLogger struct{}
+
+func (errorLogger) Println(v ...interface{}) {
+	grip.Warning(message.Fields{
+		"message": "could not serve metrics",
+		"error":   fmt.Sprint(v...),
+	})
+}
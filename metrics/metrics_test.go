@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/evergreen-ci/pail"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	IngestionRequests.WithLabelValues("handler-test").Inc()
+
+	t.Run("OpenMetrics", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+		rw := httptest.NewRecorder()
+		Handler().ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Contains(t, rw.Header().Get("Content-Type"), "application/openmetrics-text")
+		assert.Contains(t, rw.Body.String(), `cedar_ingestion_requests_total{service="handler-test"} 1`)
+		assert.Contains(t, rw.Body.String(), "go_goroutines")
+		assert.True(t, strings.HasSuffix(rw.Body.String(), "# EOF\n"))
+	})
+	t.Run("Text", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		Handler().ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Contains(t, rw.Header().Get("Content-Type"), "text/plain")
+		assert.Contains(t, rw.Body.String(), `cedar_ingestion_requests_total{service="handler-test"} 1`)
+	})
+}
+
+func TestObserveCacheRequest(t *testing.T) {
+	ObserveCacheRequest("test", true)
+	ObserveCacheRequest("test", false)
+	ObserveCacheRequest("test", false)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(CacheRequests.WithLabelValues("test", CacheHit)))
+	assert.Equal(t, float64(2), testutil.ToFloat64(CacheRequests.WithLabelValues("test", CacheMiss)))
+}
+
+type failingBucket struct {
+	pail.Bucket
+}
+
+func (failingBucket) Get(context.Context, string) (io.ReadCloser, error) {
+	return nil, errors.New("get failed")
+}
+
+func (failingBucket) Put(context.Context, string, io.Reader) error { return nil }
+
+func TestInstrumentBucket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir, err := ioutil.TempDir("", "instrumented-bucket")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(dir))
+	}()
+	local, err := pail.NewLocalBucket(pail.LocalOptions{Path: dir})
+	require.NoError(t, err)
+
+	t.Run("Local", func(t *testing.T) {
+		b := InstrumentBucket(local, "test-local")
+		assert.Equal(t, b, InstrumentBucket(b, "test-local"))
+
+		require.NoError(t, b.Put(ctx, "key", strings.NewReader("data")))
+		r, err := b.Get(ctx, "key")
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		assert.Equal(t, "data", string(data))
+
+		assert.Equal(t, 2, testutil.CollectAndCount(PailOperationDuration, "cedar_pail_operation_duration_seconds"))
+		assert.Zero(t, testutil.ToFloat64(PailOperationErrors.WithLabelValues("test-local", "get")))
+	})
+	t.Run("Errors", func(t *testing.T) {
+		b := InstrumentBucket(failingBucket{Bucket: local}, "test-failing")
+		_, err := b.Get(ctx, "key")
+		assert.Error(t, err)
+		assert.NoError(t, b.Put(ctx, "key", strings.NewReader("data")))
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(PailOperationErrors.WithLabelValues("test-failing", "get")))
+		assert.Zero(t, testutil.ToFloat64(PailOperationErrors.WithLabelValues("test-failing", "put")))
+	})
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	auditEventCollection   = "audit_events"
+	defaultAuditEventLimit = 100
+)
+
+// Audited actions.
+const (
+	AuditActionAPIKeyCreate            = "api_key_create"
+	AuditActionAPIKeyRotate            = "api_key_rotate"
+	AuditActionAPIKeyExpire            = "api_key_expire"
+	AuditActionAPIKeyRevoke            = "api_key_revoke"
+	AuditActionSetServiceFlag          = "service_flag_set"
+	AuditActionRemovePerformanceResult = "perf_result_remove"
+	AuditActionLoadConfig              = "config_load"
+	AuditActionFetchUserCert           = "user_cert_fetch"
+	AuditActionRevokeUserCert          = "user_cert_revoke"
+	AuditActionRecalculateChangePoints = "change_points_recalculate"
+	AuditActionMigrateStorage          = "storage_migrate"
+	AuditActionAddRedactionSecrets     = "redaction_secrets_add"
+	AuditActionDeleteRedactionSecret   = "redaction_secret_delete"
+	AuditActionCreateWebhook           = "webhook_create"
+	AuditActionDeleteWebhook           = "webhook_delete"
+	AuditActionRecomputeHistoricalData = "historical_test_data_recompute"
+	AuditActionSetTestOwnership        = "test_ownership_set"
+	AuditActionAddTestQuarantine       = "test_quarantine_add"
+	AuditActionRemoveTestQuarantine    = "test_quarantine_remove"
+)
+
+// AuditEvent records an administrative or destructive action taken by a
+// user. Audit events expire after the retention period configured when they
+// are recorded, one year by default.
+type AuditEvent struct {
+	ID         string                 `bson:"_id" json:"id"`
+	Timestamp  time.Time              `bson:"timestamp" json:"timestamp"`
+	ExpiresAt  time.Time              `bson:"expires_at" json:"expires_at"`
+	User       string                 `bson:"user" json:"user"`
+	Action     string                 `bson:"action" json:"action"`
+	Target     string                 `bson:"target" json:"target"`
+	Route      string                 `bson:"route,omitempty" json:"route,omitempty"`
+	RequestID  string                 `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	StatusCode int                    `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Parameters map[string]interface{} `bson:"parameters,omitempty" json:"parameters,omitempty"`
+	Before     interface{}            `bson:"before,omitempty" json:"before,omitempty"`
+	After      interface{}            `bson:"after,omitempty" json:"after,omitempty"`
+
+	env       cedar.Environment
+	populated bool
+}
+
+var (
+	auditEventTimestampKey = bsonutil.MustHaveTag(AuditEvent{}, "Timestamp")
+	auditEventExpiresAtKey = bsonutil.MustHaveTag(AuditEvent{}, "ExpiresAt")
+	auditEventUserKey      = bsonutil.MustHaveTag(AuditEvent{}, "User")
+	auditEventActionKey    = bsonutil.MustHaveTag(AuditEvent{}, "Action")
+	auditEventTargetKey    = bsonutil.MustHaveTag(AuditEvent{}, "Target")
+	auditEventRequestIDKey = bsonutil.MustHaveTag(AuditEvent{}, "RequestID")
+)
+
+// NewAuditEvent returns a new audit event for the action taken by the given
+// user on the target.
+func NewAuditEvent(user, action, target string, params map[string]interface{}) *AuditEvent {
+	now := time.Now()
+	return &AuditEvent{
+		ID:         primitive.NewObjectID().Hex(),
+		Timestamp:  now,
+		ExpiresAt:  now.Add(time.Duration(defaultRetentionAuditDays) * 24 * time.Hour),
+		User:       user,
+		Action:     action,
+		Target:     target,
+		Parameters: params,
+		populated:  true,
+	}
+}
+
+// SetTTL sets how long the audit event is kept after it was recorded.
+func (e *AuditEvent) SetTTL(ttl time.Duration) { e.ExpiresAt = e.Timestamp.Add(ttl) }
+
+// Setup sets the environment. The environment is required for numerous
+// functions on AuditEvent.
+func (e *AuditEvent) Setup(env cedar.Environment) { e.env = env }
+
+// IsNil returns if the audit event is populated or not.
+func (e *AuditEvent) IsNil() bool { return !e.populated }
+
+// Save inserts the audit event into the DB. The audit event should be
+// populated and the environment should not be nil.
+func (e *AuditEvent) Save(ctx context.Context) error {
+	if !e.populated {
+		return errors.New("cannot save unpopulated audit event")
+	}
+	if e.env == nil {
+		return errors.New("cannot save with a nil environment")
+	}
+
+	insertResult, err := e.env.GetDB().Collection(auditEventCollection).InsertOne(ctx, e)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   auditEventCollection,
+		"id":           e.ID,
+		"action":       e.Action,
+		"insertResult": insertResult,
+		"op":           "save audit event",
+	})
+
+	return errors.Wrapf(err, "saving audit event '%s'", e.ID)
+}
+
+// AuditEventFindOptions allow for querying audit events. All of the fields
+// are optional.
+type AuditEventFindOptions struct {
+	User      string
+	Action    string
+	Target    string
+	RequestID string
+	StartAt   time.Time
+	EndAt     time.Time
+	Limit     int
+}
+
+// Validate ensures the find options are valid and sets defaults.
+func (opts *AuditEventFindOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.NewWhen(opts.Limit < 0, "limit cannot be negative")
+	catcher.NewWhen(!opts.StartAt.IsZero() && !opts.EndAt.IsZero() && opts.EndAt.Before(opts.StartAt), "end time cannot be before start time")
+
+	if opts.Limit == 0 {
+		opts.Limit = defaultAuditEventLimit
+	}
+
+	return catcher.Resolve()
+}
+
+// FindAuditEvents returns the audit events matching the given options, most
+// recent first.
+func FindAuditEvents(ctx context.Context, env cedar.Environment, opts AuditEventFindOptions) ([]AuditEvent, error) {
+	if env == nil {
+		return nil, errors.New("cannot find with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid find options")
+	}
+
+	query := bson.M{}
+	if opts.User != "" {
+		query[auditEventUserKey] = opts.User
+	}
+	if opts.Action != "" {
+		query[auditEventActionKey] = opts.Action
+	}
+	if opts.Target != "" {
+		query[auditEventTargetKey] = opts.Target
+	}
+	if opts.RequestID != "" {
+		query[auditEventRequestIDKey] = opts.RequestID
+	}
+	timestamp := bson.M{}
+	if !opts.StartAt.IsZero() {
+		timestamp["$gte"] = opts.StartAt
+	}
+	if !opts.EndAt.IsZero() {
+		timestamp["$lte"] = opts.EndAt
+	}
+	if len(timestamp) > 0 {
+		query[auditEventTimestampKey] = timestamp
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: auditEventTimestampKey, Value: -1}}).
+		SetLimit(int64(opts.Limit))
+	cur, err := env.GetDB().Collection(auditEventCollection).Find(ctx, query, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding audit events")
+	}
+
+	events := []AuditEvent{}
+	if err = cur.All(ctx, &events); err != nil {
+		return nil, errors.Wrap(err, "decoding audit events")
+	}
+	for i := range events {
+		events[i].env = env
+		events[i].populated = true
+	}
+
+	return events, nil
+}
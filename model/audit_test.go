@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditEventSave(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(auditEventCollection).Drop(ctx))
+	}()
+
+	t.Run("NoEnv", func(t *testing.T) {
+		event := NewAuditEvent("user", AuditActionSetServiceFlag, "flag", nil)
+		assert.Error(t, event.Save(ctx))
+	})
+	t.Run("Unpopulated", func(t *testing.T) {
+		event := &AuditEvent{ID: "id"}
+		event.Setup(env)
+		assert.Error(t, event.Save(ctx))
+	})
+	t.Run("Populated", func(t *testing.T) {
+		event := NewAuditEvent("user", AuditActionSetServiceFlag, "flag", map[string]interface{}{"flagName": "flag"})
+		event.Before = false
+		event.After = true
+		event.SetTTL(time.Hour)
+		event.Setup(env)
+		require.NoError(t, event.Save(ctx))
+
+		saved := &AuditEvent{}
+		require.NoError(t, db.Collection(auditEventCollection).FindOne(ctx, map[string]interface{}{"_id": event.ID}).Decode(saved))
+		assert.Equal(t, event.User, saved.User)
+		assert.Equal(t, event.Action, saved.Action)
+		assert.Equal(t, event.Target, saved.Target)
+		assert.Equal(t, event.Parameters, saved.Parameters)
+		assert.Equal(t, false, saved.Before)
+		assert.Equal(t, true, saved.After)
+		assert.WithinDuration(t, event.Timestamp.Add(time.Hour), saved.ExpiresAt, time.Second)
+	})
+}
+
+func TestFindAuditEvents(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(auditEventCollection).Drop(ctx))
+	}()
+
+	now := time.Now().Round(time.Millisecond)
+	events := []*AuditEvent{
+		NewAuditEvent("user0", AuditActionSetServiceFlag, "flag", nil),
+		NewAuditEvent("user1", AuditActionRemovePerformanceResult, "perf", nil),
+		NewAuditEvent("user0", AuditActionRemovePerformanceResult, "perf", nil),
+	}
+	for i, event := range events {
+		event.Timestamp = now.Add(-time.Duration(i) * time.Hour)
+		event.RequestID = event.ID
+		event.Setup(env)
+		require.NoError(t, event.Save(ctx))
+	}
+
+	t.Run("NoEnv", func(t *testing.T) {
+		_, err := FindAuditEvents(ctx, nil, AuditEventFindOptions{})
+		assert.Error(t, err)
+	})
+	t.Run("InvalidOptions", func(t *testing.T) {
+		_, err := FindAuditEvents(ctx, env, AuditEventFindOptions{Limit: -1})
+		assert.Error(t, err)
+		_, err = FindAuditEvents(ctx, env, AuditEventFindOptions{StartAt: now, EndAt: now.Add(-time.Hour)})
+		assert.Error(t, err)
+	})
+	t.Run("All", func(t *testing.T) {
+		found, err := FindAuditEvents(ctx, env, AuditEventFindOptions{})
+		require.NoError(t, err)
+		require.Len(t, found, 3)
+		for i := range found {
+			assert.Equal(t, events[i].ID, found[i].ID)
+		}
+	})
+	t.Run("User", func(t *testing.T) {
+		found, err := FindAuditEvents(ctx, env, AuditEventFindOptions{User: "user0"})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, events[0].ID, found[0].ID)
+		assert.Equal(t, events[2].ID, found[1].ID)
+	})
+	t.Run("ActionAndTarget", func(t *testing.T) {
+		found, err := FindAuditEvents(ctx, env, AuditEventFindOptions{
+			Action: AuditActionRemovePerformanceResult,
+			Target: "perf",
+		})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, events[1].ID, found[0].ID)
+		assert.Equal(t, events[2].ID, found[1].ID)
+	})
+	t.Run("RequestID", func(t *testing.T) {
+		found, err := FindAuditEvents(ctx, env, AuditEventFindOptions{RequestID: events[1].ID})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, events[1].ID, found[0].ID)
+	})
+	t.Run("TimeRange", func(t *testing.T) {
+		found, err := FindAuditEvents(ctx, env, AuditEventFindOptions{
+			StartAt: now.Add(-90 * time.Minute),
+			EndAt:   now.Add(-30 * time.Minute),
+		})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, events[1].ID, found[0].ID)
+	})
+	t.Run("Limit", func(t *testing.T) {
+		found, err := FindAuditEvents(ctx, env, AuditEventFindOptions{Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, found, 1)
+		assert.Equal(t, events[0].ID, found[0].ID)
+	})
+}
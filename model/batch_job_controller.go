@@ -9,6 +9,7 @@ import (
 	"github.com/mongodb/anser/db"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
@@ -27,6 +28,15 @@ type BatchJobController struct {
 	Iterations int           `bson:"iterations,omitempty"`
 	Timeout    time.Duration `bson:"timeout,omitempty"`
 	Version    int           `bson:"version"`
+
+	// StorageMigration, if set, configures the batch job as a migration
+	// of the collection's artifacts between pail backends.
+	StorageMigration *StorageMigrationOptions `bson:"storage_migration,omitempty"`
+	// CompletedAt is set once the batch job has nothing left to process.
+	CompletedAt time.Time `bson:"completed_at,omitempty"`
+	// RateLimitNextSlot is the time from which the next operation of a
+	// rate-limited batch job may run, shared by all of its jobs.
+	RateLimitNextSlot time.Time `bson:"rate_limit_next_slot,omitempty"`
 }
 
 var (
@@ -36,6 +46,10 @@ var (
 	batchJobControllerIterationsKey = bsonutil.MustHaveTag(BatchJobController{}, "Iterations")
 	batchJobControllerTimeoutKey    = bsonutil.MustHaveTag(BatchJobController{}, "Timeout")
 	batchJobControllerVersionKey    = bsonutil.MustHaveTag(BatchJobController{}, "Version")
+
+	batchJobControllerStorageMigrationKey  = bsonutil.MustHaveTag(BatchJobController{}, "StorageMigration")
+	batchJobControllerCompletedAtKey       = bsonutil.MustHaveTag(BatchJobController{}, "CompletedAt")
+	batchJobControllerRateLimitNextSlotKey = bsonutil.MustHaveTag(BatchJobController{}, "RateLimitNextSlot")
 )
 
 // FindBatchJobController searches the DB for the BatchJobController with
@@ -52,3 +66,55 @@ func FindBatchJobController(ctx context.Context, env cedar.Environment, id strin
 
 	return &controller, nil
 }
+
+// SaveBatchJobController upserts the BatchJobController, resetting its
+// completion so that the batch job resumes.
+func SaveBatchJobController(ctx context.Context, env cedar.Environment, controller *BatchJobController) error {
+	if controller.ID == "" {
+		return errors.New("batch job controller must have an ID")
+	}
+	controller.CompletedAt = time.Time{}
+
+	_, err := env.GetDB().Collection(BatchJobControllerCollection).ReplaceOne(
+		ctx,
+		bson.M{batchJobControllerIDKey: controller.ID},
+		controller,
+		options.Replace().SetUpsert(true),
+	)
+
+	return errors.Wrapf(err, "saving batch job controller '%s'", controller.ID)
+}
+
+// CompleteBatchJobController marks the BatchJobController with the given ID
+// as completed.
+func CompleteBatchJobController(ctx context.Context, env cedar.Environment, id string) error {
+	updateResult, err := env.GetDB().Collection(BatchJobControllerCollection).UpdateOne(
+		ctx,
+		bson.M{batchJobControllerIDKey: id},
+		bson.M{"$set": bson.M{batchJobControllerCompletedAtKey: time.Now()}},
+	)
+	if err == nil && updateResult.MatchedCount == 0 {
+		err = errors.New("not found")
+	}
+
+	return errors.Wrapf(err, "completing batch job controller '%s'", id)
+}
+
+// FindActiveStorageMigrationControllers returns the BatchJobControllers of
+// storage migrations that have not completed.
+func FindActiveStorageMigrationControllers(ctx context.Context, env cedar.Environment) ([]BatchJobController, error) {
+	cur, err := env.GetDB().Collection(BatchJobControllerCollection).Find(ctx, bson.M{
+		batchJobControllerStorageMigrationKey: bson.M{"$exists": true},
+		batchJobControllerCompletedAtKey:      bson.M{"$exists": false},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "finding storage migration batch job controllers")
+	}
+
+	controllers := []BatchJobController{}
+	if err = cur.All(ctx, &controllers); err != nil {
+		return nil, errors.Wrap(err, "decoding storage migration batch job controllers")
+	}
+
+	return controllers, nil
+}
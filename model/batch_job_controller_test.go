@@ -49,3 +49,50 @@ func TestFindBatchJobController(t *testing.T) {
 		assert.Equal(t, c1, controller)
 	})
 }
+
+func TestStorageMigrationControllers(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, db.Collection(BatchJobControllerCollection).Drop(ctx))
+	defer func() {
+		assert.NoError(t, db.Collection(BatchJobControllerCollection).Drop(ctx))
+	}()
+
+	migration := &BatchJobController{
+		ID:         "migration",
+		Collection: buildloggerCollection,
+		BatchSize:  10,
+		StorageMigration: &StorageMigrationOptions{
+			FromType: PailGridFS,
+			ToType:   PailS3,
+		},
+	}
+	require.NoError(t, SaveBatchJobController(ctx, env, migration))
+	require.NoError(t, SaveBatchJobController(ctx, env, &BatchJobController{ID: "other", BatchSize: 10}))
+
+	t.Run("FindActive", func(t *testing.T) {
+		controllers, err := FindActiveStorageMigrationControllers(ctx, env)
+		require.NoError(t, err)
+		require.Len(t, controllers, 1)
+		assert.Equal(t, *migration, controllers[0])
+	})
+	t.Run("Complete", func(t *testing.T) {
+		require.NoError(t, CompleteBatchJobController(ctx, env, migration.ID))
+		controllers, err := FindActiveStorageMigrationControllers(ctx, env)
+		require.NoError(t, err)
+		assert.Empty(t, controllers)
+
+		assert.Error(t, CompleteBatchJobController(ctx, env, "DNE"))
+	})
+	t.Run("SaveResumes", func(t *testing.T) {
+		require.NoError(t, SaveBatchJobController(ctx, env, migration))
+		controllers, err := FindActiveStorageMigrationControllers(ctx, env)
+		require.NoError(t, err)
+		assert.Len(t, controllers, 1)
+
+		assert.Error(t, SaveBatchJobController(ctx, env, &BatchJobController{}))
+	})
+}
@@ -47,6 +47,7 @@ type Log struct {
 	CreatedAt   time.Time       `bson:"created_at"`
 	CompletedAt time.Time       `bson:"completed_at"`
 	Artifact    LogArtifactInfo `bson:"artifact"`
+	Redactions  int             `bson:"redactions,omitempty"`
 
 	env       cedar.Environment
 	populated bool
@@ -58,6 +59,7 @@ var (
 	logCreatedAtKey   = bsonutil.MustHaveTag(Log{}, "CreatedAt")
 	logCompletedAtKey = bsonutil.MustHaveTag(Log{}, "CompletedAt")
 	logArtifactKey    = bsonutil.MustHaveTag(Log{}, "Artifact")
+	logRedactionsKey  = bsonutil.MustHaveTag(Log{}, "Redactions")
 )
 
 // Setup sets the environment for the log. The environment is required for
@@ -148,7 +150,19 @@ func (l *Log) Append(ctx context.Context, lines []LogLine) error {
 		return nil
 	}
 
+	conf := &CedarConfig{}
+	conf.Setup(l.env)
+	if err := conf.Find(); err != nil {
+		return errors.Wrap(err, "getting application configuration")
+	}
+	redactor, err := newLogRedactor(ctx, l.env, conf, l.Info.Project)
+	if err != nil {
+		return errors.Wrap(err, "creating log redactor")
+	}
+	lineRedactor := redactor.lines()
+
 	lineBuffer := &bytes.Buffer{}
+	redactions := 0
 	for _, line := range lines {
 		// unlikely scenario, but just in case priority is out of range.
 		if line.Priority > level.Emergency {
@@ -157,17 +171,15 @@ func (l *Log) Append(ctx context.Context, lines []LogLine) error {
 			line.Priority = level.Trace
 		}
 
-		_, err := lineBuffer.WriteString(prependPriorityAndTimestamp(line.Priority, line.Timestamp, line.Data))
+		data, count := lineRedactor.redact(line.Data)
+		redactions += count
+
+		_, err = lineBuffer.WriteString(prependPriorityAndTimestamp(line.Priority, line.Timestamp, data))
 		if err != nil {
 			return errors.Wrap(err, "buffering lines")
 		}
 	}
 
-	conf := &CedarConfig{}
-	conf.Setup(l.env)
-	if err := conf.Find(); err != nil {
-		return errors.Wrap(err, "getting application configuration")
-	}
 	bucket, err := l.Artifact.Type.Create(
 		ctx,
 		l.env,
@@ -179,17 +191,46 @@ func (l *Log) Append(ctx context.Context, lines []LogLine) error {
 	if err != nil {
 		return errors.Wrap(err, "creating bucket")
 	}
+	bucket, err = conf.Encryption.encryptBucket(bucket, l.Artifact.EncryptionKeyID)
+	if err != nil {
+		return errors.Wrap(err, "creating encrypted bucket")
+	}
 
 	key := createBuildloggerChunkKey(lines[0].Timestamp, lines[len(lines)-1].Timestamp, len(lines))
 	if err := bucket.Put(ctx, key, lineBuffer); err != nil {
 		return errors.Wrap(err, "uploading log lines to bucket")
 	}
+	// The lines are stored at this point, so failing here would make the
+	// caller retry and store the chunk twice.
+	grip.Warning(message.WrapError(l.addRedactions(ctx, redactions), message.Fields{
+		"message":    "could not record redactions",
+		"log_id":     l.ID,
+		"redactions": redactions,
+	}))
 
 	l.addToStatsCache(lines)
 
 	return nil
 }
 
+// addRedactions increments the number of secrets masked in the log.
+func (l *Log) addRedactions(ctx context.Context, count int) error {
+	if count == 0 {
+		return nil
+	}
+
+	_, err := l.env.GetDB().Collection(buildloggerCollection).UpdateOne(ctx,
+		bson.M{logIDKey: l.ID},
+		bson.M{"$inc": bson.M{logRedactionsKey: count}},
+	)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	l.Redactions += count
+
+	return nil
+}
+
 func (l *Log) addToStatsCache(lines []LogLine) {
 	linesSize := 0
 	for _, line := range lines {
@@ -201,6 +242,7 @@ func (l *Log) addToStatsCache(lines []LogLine) {
 
 	if err := l.env.GetStatsCache(cedar.StatsCacheBuildlogger).AddStat(cedar.Stat{
 		Count:   linesSize,
+		Lines:   len(lines),
 		Project: l.Info.Project,
 		Version: l.Info.Version,
 		TaskID:  l.Info.TaskID,
@@ -279,6 +321,10 @@ func (l *Log) Download(ctx context.Context, timeRange TimeRange) (LogIterator, e
 	if err != nil {
 		return nil, errors.Wrap(err, "creating bucket")
 	}
+	bucket, err = conf.Encryption.encryptBucket(bucket, l.Artifact.EncryptionKeyID)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating encrypted bucket")
+	}
 
 	chunks, err := l.getChunks(ctx, bucket)
 	if err != nil {
@@ -341,6 +387,9 @@ type LogInfo struct {
 	ExitCode    int               `bson:"exit_code, omitempty"`
 	Mainline    bool              `bson:"mainline"`
 	Schema      int               `bson:"schema,omitempty"`
+	// Evergreen is populated asynchronously after the log is created and
+	// is not part of the ID.
+	Evergreen *EvergreenMetadata `bson:"evergreen,omitempty"`
 }
 
 var (
@@ -359,6 +408,7 @@ var (
 	logInfoExitCodeKey    = bsonutil.MustHaveTag(LogInfo{}, "ExitCode")
 	logInfoMainlineKey    = bsonutil.MustHaveTag(LogInfo{}, "Mainline")
 	logInfoSchemaKey      = bsonutil.MustHaveTag(LogInfo{}, "Schema")
+	logInfoEvergreenKey   = bsonutil.MustHaveTag(LogInfo{}, "Evergreen")
 )
 
 // ID creates a unique hash for a buildlogger log.
@@ -43,13 +43,17 @@ type LogArtifactInfo struct {
 	// This field is part of the version 0 LogArtifactInfo model, we are
 	// keeping it for backwards compatibility.
 	Chunks []LogChunkInfo `bson:"chunks,omitempty"`
+	// EncryptionKeyID is the ID of the key that the log chunks are
+	// encrypted with, if any.
+	EncryptionKeyID string `bson:"encryption_key_id,omitempty"`
 }
 
 var (
-	logArtifactInfoTypeKey    = bsonutil.MustHaveTag(LogArtifactInfo{}, "Type")
-	logArtifactInfoPrefixKey  = bsonutil.MustHaveTag(LogArtifactInfo{}, "Prefix")
-	logArtifactInfoVersionKey = bsonutil.MustHaveTag(LogArtifactInfo{}, "Version")
-	logArtifactInfoChunksKey  = bsonutil.MustHaveTag(LogArtifactInfo{}, "Chunks")
+	logArtifactInfoTypeKey            = bsonutil.MustHaveTag(LogArtifactInfo{}, "Type")
+	logArtifactInfoPrefixKey          = bsonutil.MustHaveTag(LogArtifactInfo{}, "Prefix")
+	logArtifactInfoVersionKey         = bsonutil.MustHaveTag(LogArtifactInfo{}, "Version")
+	logArtifactInfoChunksKey          = bsonutil.MustHaveTag(LogArtifactInfo{}, "Chunks")
+	logArtifactInfoEncryptionKeyIDKey = bsonutil.MustHaveTag(LogArtifactInfo{}, "EncryptionKeyID")
 )
 
 // LogChunkInfo describes a chunk of log lines stored in pail-backed offline
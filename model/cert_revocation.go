@@ -0,0 +1,109 @@
+package model
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const certificateRevocationCollection = "certificate_revocations"
+
+// CertificateRevocation records a certificate issued by the Cedar CA that is
+// no longer trusted. Revocations are identified by the certificate's serial
+// number and are removed once the certificate expires, since an expired
+// certificate is rejected regardless.
+type CertificateRevocation struct {
+	SerialNumber string    `bson:"_id" json:"serial_number"`
+	CommonName   string    `bson:"common_name" json:"common_name"`
+	RevokedAt    time.Time `bson:"revoked_at" json:"revoked_at"`
+	RevokedBy    string    `bson:"revoked_by" json:"revoked_by"`
+	Reason       string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	ExpiresAt    time.Time `bson:"expires_at" json:"expires_at"`
+
+	env       cedar.Environment
+	populated bool
+}
+
+var (
+	certRevocationSerialNumberKey = bsonutil.MustHaveTag(CertificateRevocation{}, "SerialNumber")
+	certRevocationExpiresAtKey    = bsonutil.MustHaveTag(CertificateRevocation{}, "ExpiresAt")
+)
+
+// NewCertificateRevocation returns a new revocation of the given certificate
+// by the given user.
+func NewCertificateRevocation(crt *x509.Certificate, user, reason string) *CertificateRevocation {
+	return &CertificateRevocation{
+		SerialNumber: crt.SerialNumber.Text(16),
+		CommonName:   crt.Subject.CommonName,
+		RevokedAt:    time.Now(),
+		RevokedBy:    user,
+		Reason:       reason,
+		ExpiresAt:    crt.NotAfter,
+		populated:    true,
+	}
+}
+
+// Setup sets the environment. The environment is required for numerous
+// functions on CertificateRevocation.
+func (r *CertificateRevocation) Setup(env cedar.Environment) { r.env = env }
+
+// IsNil returns if the certificate revocation is populated or not.
+func (r *CertificateRevocation) IsNil() bool { return !r.populated }
+
+// Save inserts the certificate revocation into the DB. Revoking an already
+// revoked certificate is a no-op. The certificate revocation should be
+// populated and the environment should not be nil.
+func (r *CertificateRevocation) Save(ctx context.Context) error {
+	if !r.populated {
+		return errors.New("cannot save unpopulated certificate revocation")
+	}
+	if r.env == nil {
+		return errors.New("cannot save with a nil environment")
+	}
+
+	updateResult, err := r.env.GetDB().Collection(certificateRevocationCollection).UpdateOne(
+		ctx,
+		bson.M{certRevocationSerialNumberKey: r.SerialNumber},
+		bson.M{"$setOnInsert": r},
+		options.Update().SetUpsert(true),
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":   certificateRevocationCollection,
+		"serial":       r.SerialNumber,
+		"common_name":  r.CommonName,
+		"updateResult": updateResult,
+		"op":           "save certificate revocation",
+	})
+
+	return errors.Wrapf(err, "saving revocation of certificate '%s'", r.SerialNumber)
+}
+
+// IsCertificateRevoked returns whether the given certificate has been
+// revoked.
+func IsCertificateRevoked(ctx context.Context, env cedar.Environment, crt *x509.Certificate) (bool, error) {
+	if env == nil {
+		return false, errors.New("cannot check revocation with a nil environment")
+	}
+
+	err := env.GetDB().Collection(certificateRevocationCollection).FindOne(
+		ctx,
+		bson.M{certRevocationSerialNumberKey: crt.SerialNumber.Text(16)},
+	).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "finding revocation of certificate '%s'", crt.SerialNumber.Text(16))
+	}
+
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateRevocation(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(certificateRevocationCollection).Drop(ctx))
+	}()
+
+	crt := &x509.Certificate{
+		SerialNumber: big.NewInt(0xc0ffee),
+		Subject:      pkix.Name{CommonName: "user"},
+		NotAfter:     time.Now().Add(time.Hour).Round(time.Millisecond),
+	}
+	other := &x509.Certificate{
+		SerialNumber: big.NewInt(0xbeef),
+		Subject:      pkix.Name{CommonName: "user"},
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	t.Run("NoEnv", func(t *testing.T) {
+		revocation := NewCertificateRevocation(crt, "admin", "compromised")
+		assert.Error(t, revocation.Save(ctx))
+		_, err := IsCertificateRevoked(ctx, nil, crt)
+		assert.Error(t, err)
+	})
+	t.Run("Unpopulated", func(t *testing.T) {
+		revocation := &CertificateRevocation{SerialNumber: "serial"}
+		revocation.Setup(env)
+		assert.Error(t, revocation.Save(ctx))
+	})
+	t.Run("Revoke", func(t *testing.T) {
+		revoked, err := IsCertificateRevoked(ctx, env, crt)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		revocation := NewCertificateRevocation(crt, "admin", "compromised")
+		assert.Equal(t, "c0ffee", revocation.SerialNumber)
+		revocation.Setup(env)
+		require.NoError(t, revocation.Save(ctx))
+
+		revoked, err = IsCertificateRevoked(ctx, env, crt)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+		revoked, err = IsCertificateRevoked(ctx, env, other)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		saved := &CertificateRevocation{}
+		require.NoError(t, db.Collection(certificateRevocationCollection).FindOne(ctx, map[string]interface{}{"_id": revocation.SerialNumber}).Decode(saved))
+		assert.Equal(t, "user", saved.CommonName)
+		assert.Equal(t, "admin", saved.RevokedBy)
+		assert.Equal(t, "compromised", saved.Reason)
+		assert.Equal(t, crt.NotAfter.UTC(), saved.ExpiresAt.UTC())
+	})
+	t.Run("RevokeTwice", func(t *testing.T) {
+		revocation := NewCertificateRevocation(crt, "other-admin", "again")
+		revocation.Setup(env)
+		require.NoError(t, revocation.Save(ctx))
+
+		saved := &CertificateRevocation{}
+		require.NoError(t, db.Collection(certificateRevocationCollection).FindOne(ctx, map[string]interface{}{"_id": revocation.SerialNumber}).Decode(saved))
+		assert.Equal(t, "admin", saved.RevokedBy)
+	})
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/evergreen-ci/cedar"
@@ -32,11 +33,16 @@ type CedarConfig struct {
 	LDAP           LDAPConfig                `bson:"ldap" json:"ldap" yaml:"ldap"`
 	ServiceAuth    ServiceAuthConfig         `bson:"service_auth" json:"service_auth" yaml:"service_auth"`
 	NaiveAuth      NaiveAuthConfig           `bson:"naive_auth" json:"naive_auth" yaml:"naive_auth"`
+	OIDC           OIDCConfig                `bson:"oidc" json:"oidc" yaml:"oidc"`
+	Tracing        TracingConfig             `bson:"tracing" json:"tracing" yaml:"tracing"`
+	Encryption     EncryptionConfig          `bson:"encryption" json:"encryption" yaml:"encryption"`
+	Redaction      RedactionConfig           `bson:"redaction" json:"redaction" yaml:"redaction"`
 	CA             CAConfig                  `bson:"ca" json:"ca" yaml:"ca"`
 	Bucket         BucketConfig              `bson:"bucket" json:"bucket" yaml:"bucket"`
 	Flags          OperationalFlags          `bson:"flags" json:"flags" yaml:"flags"`
 	Service        ServiceConfig             `bson:"service" json:"service" yaml:"service"`
 	ChangeDetector ChangeDetectorConfig      `bson:"change_detector" json:"change_detector" yaml:"change_detector"`
+	Retention      RetentionConfig           `bson:"retention" json:"retention" yaml:"retention"`
 
 	populated bool
 	env       cedar.Environment
@@ -61,10 +67,15 @@ var (
 	cedarConfigurationLDAPKey           = bsonutil.MustHaveTag(CedarConfig{}, "LDAP")
 	cedarConfigurationServiceAuthKey    = bsonutil.MustHaveTag(CedarConfig{}, "ServiceAuth")
 	cedarConfigurationNaiveAuthKey      = bsonutil.MustHaveTag(CedarConfig{}, "NaiveAuth")
+	cedarConfigurationOIDCKey           = bsonutil.MustHaveTag(CedarConfig{}, "OIDC")
+	cedarConfigurationTracingKey        = bsonutil.MustHaveTag(CedarConfig{}, "Tracing")
+	cedarConfigurationEncryptionKey     = bsonutil.MustHaveTag(CedarConfig{}, "Encryption")
+	cedarConfigurationRedactionKey      = bsonutil.MustHaveTag(CedarConfig{}, "Redaction")
 	cedarConfigurationCAKey             = bsonutil.MustHaveTag(CedarConfig{}, "CA")
 	cedarConfigurationFlagsKey          = bsonutil.MustHaveTag(CedarConfig{}, "Flags")
 	cedarConfigurationServiceKey        = bsonutil.MustHaveTag(CedarConfig{}, "Service")
 	cedarConfigurationChangeDetectorKey = bsonutil.MustHaveTag(CedarConfig{}, "ChangeDetector")
+	cedarConfigurationRetentionKey      = bsonutil.MustHaveTag(CedarConfig{}, "Retention")
 )
 
 type EvergreenConfig struct {
@@ -158,6 +169,166 @@ var (
 	cedarNaiveAuthConfigUsersKey   = bsonutil.MustHaveTag(NaiveAuthConfig{}, "Users")
 )
 
+// OIDCConfig contains settings for authenticating users with JSON Web Tokens
+// issued by an OpenID Connect provider. The audience and either the JWKS URL
+// or the JWKS file must be set when the issuer is set. The user IDs of the
+// tokens are prefixed with the user ID namespace, which defaults to the
+// issuer's host, and the values of the roles claim only grant the roles they
+// are mapped to in the role mappings.
+type OIDCConfig struct {
+	Issuer          string            `bson:"issuer" json:"issuer" yaml:"issuer"`
+	Audience        string            `bson:"audience" json:"audience" yaml:"audience"`
+	JWKSURL         string            `bson:"jwks_url" json:"jwks_url" yaml:"jwks_url"`
+	JWKSFile        string            `bson:"jwks_file" json:"jwks_file" yaml:"jwks_file"`
+	UserIDNamespace string            `bson:"user_id_namespace" json:"user_id_namespace" yaml:"user_id_namespace"`
+	UserIDClaim     string            `bson:"user_id_claim" json:"user_id_claim" yaml:"user_id_claim"`
+	NameClaim       string            `bson:"name_claim" json:"name_claim" yaml:"name_claim"`
+	EmailClaim      string            `bson:"email_claim" json:"email_claim" yaml:"email_claim"`
+	RolesClaim      string            `bson:"roles_claim" json:"roles_claim" yaml:"roles_claim"`
+	RoleMappings    []OIDCRoleMapping `bson:"role_mappings" json:"role_mappings" yaml:"role_mappings"`
+	DefaultRoles    []string          `bson:"default_roles" json:"default_roles" yaml:"default_roles"`
+}
+
+var (
+	cedarOIDCConfigIssuerKey          = bsonutil.MustHaveTag(OIDCConfig{}, "Issuer")
+	cedarOIDCConfigAudienceKey        = bsonutil.MustHaveTag(OIDCConfig{}, "Audience")
+	cedarOIDCConfigJWKSURLKey         = bsonutil.MustHaveTag(OIDCConfig{}, "JWKSURL")
+	cedarOIDCConfigJWKSFileKey        = bsonutil.MustHaveTag(OIDCConfig{}, "JWKSFile")
+	cedarOIDCConfigUserIDNamespaceKey = bsonutil.MustHaveTag(OIDCConfig{}, "UserIDNamespace")
+	cedarOIDCConfigUserIDClaimKey     = bsonutil.MustHaveTag(OIDCConfig{}, "UserIDClaim")
+	cedarOIDCConfigNameClaimKey       = bsonutil.MustHaveTag(OIDCConfig{}, "NameClaim")
+	cedarOIDCConfigEmailClaimKey      = bsonutil.MustHaveTag(OIDCConfig{}, "EmailClaim")
+	cedarOIDCConfigRolesClaimKey      = bsonutil.MustHaveTag(OIDCConfig{}, "RolesClaim")
+	cedarOIDCConfigRoleMappingsKey    = bsonutil.MustHaveTag(OIDCConfig{}, "RoleMappings")
+	cedarOIDCConfigDefaultRolesKey    = bsonutil.MustHaveTag(OIDCConfig{}, "DefaultRoles")
+)
+
+// OIDCRoleMapping maps a value of the OIDC roles claim, such as a group name,
+// to the Cedar roles it grants.
+type OIDCRoleMapping struct {
+	Value string   `bson:"value" json:"value" yaml:"value"`
+	Roles []string `bson:"roles" json:"roles" yaml:"roles"`
+}
+
+// RoleMappingsByValue returns the role mappings keyed by the claim value.
+func (c *OIDCConfig) RoleMappingsByValue() map[string][]string {
+	mappings := map[string][]string{}
+	for _, mapping := range c.RoleMappings {
+		mappings[mapping.Value] = append(mappings[mapping.Value], mapping.Roles...)
+	}
+	return mappings
+}
+
+// TracingConfig contains settings for exporting trace spans. Spans are sent to
+// the OTLP endpoint if it is set, otherwise they are written as JSON lines to
+// the file path, which may be "stdout". Tracing is disabled if neither is set.
+type TracingConfig struct {
+	OTLPEndpoint string            `bson:"otlp_endpoint" json:"otlp_endpoint" yaml:"otlp_endpoint"`
+	OTLPHeaders  map[string]string `bson:"otlp_headers" json:"otlp_headers" yaml:"otlp_headers"`
+	FilePath     string            `bson:"file_path" json:"file_path" yaml:"file_path"`
+	ServiceName  string            `bson:"service_name" json:"service_name" yaml:"service_name"`
+	SampleRatio  float64           `bson:"sample_ratio" json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+var (
+	cedarTracingConfigOTLPEndpointKey = bsonutil.MustHaveTag(TracingConfig{}, "OTLPEndpoint")
+	cedarTracingConfigOTLPHeadersKey  = bsonutil.MustHaveTag(TracingConfig{}, "OTLPHeaders")
+	cedarTracingConfigFilePathKey     = bsonutil.MustHaveTag(TracingConfig{}, "FilePath")
+	cedarTracingConfigServiceNameKey  = bsonutil.MustHaveTag(TracingConfig{}, "ServiceName")
+	cedarTracingConfigSampleRatioKey  = bsonutil.MustHaveTag(TracingConfig{}, "SampleRatio")
+)
+
+// IsEnabled returns whether an exporter is configured.
+func (c *TracingConfig) IsEnabled() bool { return c.OTLPEndpoint != "" || c.FilePath != "" }
+
+// EncryptionConfig configures the envelope encryption of the log chunks and
+// system metrics chunks that Cedar writes to blob storage. Each object is
+// encrypted with its own data key, which is in turn encrypted with the key of
+// the artifact's project. Parquet test results are read directly by Presto, so
+// they are not encrypted.
+type EncryptionConfig struct {
+	// KeysFile is the path, on each application server, of the YAML file
+	// with all of the keys that artifacts may be encrypted with. The keys
+	// are kept out of the database that stores the artifact metadata. A
+	// key must stay in the file until no artifact is encrypted with it
+	// anymore.
+	KeysFile string `bson:"keys_file" json:"keys_file" yaml:"keys_file"`
+	// Projects sets the key that new artifacts of each project are
+	// encrypted with. Changing the key of a project rotates it: the
+	// re-encryption job re-encrypts the project's completed artifacts with
+	// the new key.
+	Projects []ProjectEncryptionKey `bson:"projects" json:"projects" yaml:"projects"`
+}
+
+var (
+	cedarEncryptionConfigKeysFileKey = bsonutil.MustHaveTag(EncryptionConfig{}, "KeysFile")
+	cedarEncryptionConfigProjectsKey = bsonutil.MustHaveTag(EncryptionConfig{}, "Projects")
+)
+
+// EncryptionKey is a key, in the keys file, used to encrypt the data keys of
+// artifacts.
+type EncryptionKey struct {
+	ID string `json:"id" yaml:"id"`
+	// Key is the base64 encoded 256-bit AES key.
+	Key string `json:"key" yaml:"key"`
+}
+
+// ProjectEncryptionKey sets the key that the artifacts of a project are
+// encrypted with.
+type ProjectEncryptionKey struct {
+	Project string `bson:"project" json:"project" yaml:"project"`
+	KeyID   string `bson:"key_id" json:"key_id" yaml:"key_id"`
+}
+
+var (
+	cedarProjectEncryptionKeyProjectKey = bsonutil.MustHaveTag(ProjectEncryptionKey{}, "Project")
+	cedarProjectEncryptionKeyKeyIDKey   = bsonutil.MustHaveTag(ProjectEncryptionKey{}, "KeyID")
+)
+
+// RedactionConfig configures the masking of secrets in buildlogger log lines
+// before they are stored. Known token formats, such as AWS access keys and
+// GitHub tokens, are masked for every project unless disabled. Literal secret
+// values are registered per project through the REST API and stored encrypted
+// with the key from the encryption keys file with the secrets key ID.
+type RedactionConfig struct {
+	DisableDefaultPatterns bool                     `bson:"disable_default_patterns" json:"disable_default_patterns" yaml:"disable_default_patterns"`
+	SecretsKeyID           string                   `bson:"secrets_key_id" json:"secrets_key_id" yaml:"secrets_key_id"`
+	Projects               []ProjectRedactionConfig `bson:"projects" json:"projects" yaml:"projects"`
+}
+
+var (
+	cedarRedactionConfigDisableDefaultPatternsKey = bsonutil.MustHaveTag(RedactionConfig{}, "DisableDefaultPatterns")
+	cedarRedactionConfigSecretsKeyIDKey           = bsonutil.MustHaveTag(RedactionConfig{}, "SecretsKeyID")
+	cedarRedactionConfigProjectsKey               = bsonutil.MustHaveTag(RedactionConfig{}, "Projects")
+)
+
+// Validate returns an error if any of the project patterns is not a valid
+// regular expression.
+func (c *RedactionConfig) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	for _, p := range c.Projects {
+		catcher.NewWhen(p.Project == "", "must specify a project for redaction patterns")
+		for _, pattern := range p.Patterns {
+			_, err := regexp.Compile(pattern)
+			catcher.Wrapf(err, "compiling redaction pattern '%s' for project '%s'", pattern, p.Project)
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+// ProjectRedactionConfig sets the additional regular expressions whose matches
+// are masked in the logs of a project.
+type ProjectRedactionConfig struct {
+	Project  string   `bson:"project" json:"project" yaml:"project"`
+	Patterns []string `bson:"patterns" json:"patterns" yaml:"patterns"`
+}
+
+var (
+	cedarProjectRedactionConfigProjectKey  = bsonutil.MustHaveTag(ProjectRedactionConfig{}, "Project")
+	cedarProjectRedactionConfigPatternsKey = bsonutil.MustHaveTag(ProjectRedactionConfig{}, "Patterns")
+)
+
 type NaiveUserConfig struct {
 	ID           string   `bson:"_id" json:"id" yaml:"id"`
 	Name         string   `bson:"name" json:"name" yaml:"name"`
@@ -186,11 +357,36 @@ type CAConfig struct {
 }
 
 var (
-	cedarCAConfigCertDepotKey        = bsonutil.MustHaveTag(CAConfig{}, "CertDepot")
-	cedarCAConfigSSLExpireAfterKey   = bsonutil.MustHaveTag(CAConfig{}, "SSLExpireAfter")
-	cedarCAConfigSSLRenewalBeforeKey = bsonutil.MustHaveTag(CAConfig{}, "SSLRenewalBefore")
+	cedarCAConfigCertDepotKey         = bsonutil.MustHaveTag(CAConfig{}, "CertDepot")
+	cedarCAConfigSSLExpireAfterKey    = bsonutil.MustHaveTag(CAConfig{}, "SSLExpireAfter")
+	cedarCAConfigSSLRenewalBeforeKey  = bsonutil.MustHaveTag(CAConfig{}, "SSLRenewalBefore")
+	cedarCAConfigServerCertVersionKey = bsonutil.MustHaveTag(CAConfig{}, "ServerCertVersion")
 )
 
+// IncrementServerCertVersion increments the version of the server
+// certificate, which records that the server certificate was renewed, and
+// returns the new version.
+func (c *CedarConfig) IncrementServerCertVersion(ctx context.Context) (int, error) {
+	if c.env == nil {
+		return 0, errors.New("cannot update configuration with a nil environment")
+	}
+
+	key := bsonutil.GetDottedKeyName(cedarConfigurationCAKey, cedarCAConfigServerCertVersionKey)
+	updated := &CedarConfig{}
+	err := c.env.GetDB().Collection(configurationCollection).FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": cedarConfigurationID},
+		bson.M{"$inc": bson.M{key: 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(updated)
+	if err != nil {
+		return 0, errors.Wrap(err, "incrementing server certificate version")
+	}
+	c.CA.ServerCertVersion = updated.CA.ServerCertVersion
+
+	return c.CA.ServerCertVersion, nil
+}
+
 // Credentials and other configuration information for pail Bucket usage.
 type BucketConfig struct {
 	AWSKey                  string   `bson:"aws_key" json:"aws_key" yaml:"aws_key"`
@@ -204,14 +400,130 @@ type BucketConfig struct {
 	PrestoRoleARN           string `bson:"presto_role_arn" json:"presto_role_arn" yaml:"presto_role_arn"`
 	PrestoBucket            string `bson:"presto_bucket" json:"presto_bucket" yaml:"presto_bucket"`
 	PrestoTestResultsPrefix string `bson:"presto_test_results_prefix" json:"presto_test_results_prefix" yaml:"presto_test_results_prefix"`
+
+	Tiered TieredStorageConfig `bson:"tiered" json:"tiered" yaml:"tiered"`
+
+	// S3 is the default configuration of S3 buckets, and S3Buckets
+	// overrides it for specific buckets.
+	S3        S3Config   `bson:"s3" json:"s3" yaml:"s3"`
+	S3Buckets []S3Config `bson:"s3_buckets" json:"s3_buckets" yaml:"s3_buckets"`
 }
 
 var (
 	cedarS3BucketConfigAWSKeyKey          = bsonutil.MustHaveTag(BucketConfig{}, "AWSKey")
 	cedarS3BucketConfigAWSSecretKey       = bsonutil.MustHaveTag(BucketConfig{}, "AWSSecret")
 	cedarS3BucketConfigBuildLogsBucketKey = bsonutil.MustHaveTag(BucketConfig{}, "BuildLogsBucket")
+	cedarS3BucketConfigTieredKey          = bsonutil.MustHaveTag(BucketConfig{}, "Tiered")
+	cedarS3BucketConfigS3Key              = bsonutil.MustHaveTag(BucketConfig{}, "S3")
+	cedarS3BucketConfigS3BucketsKey       = bsonutil.MustHaveTag(BucketConfig{}, "S3Buckets")
+)
+
+// TieredStorageConfig configures the tiered storage backend, which writes
+// data to GridFS as a hot tier and has it migrated to S3 once it has aged.
+// The hot tier is GridFS, rather than a local directory, so that every app
+// server can read data written by any other.
+type TieredStorageConfig struct {
+	// BuildLogs, when set, stores new build logs that request S3 in the
+	// tiered backend instead.
+	BuildLogs bool `bson:"build_logs" json:"build_logs" yaml:"build_logs"`
+	// TestResults, when set, stores new test results in the tiered backend
+	// when the test results bucket type is S3.
+	TestResults bool `bson:"test_results" json:"test_results" yaml:"test_results"`
+	// MigrateAfterDays is the number of days after completion that data
+	// is migrated from the hot tier to S3. Defaults to 7.
+	MigrateAfterDays int `bson:"migrate_after_days" json:"migrate_after_days" yaml:"migrate_after_days"`
+	// MigrationBatchSize is the maximum number of records migrated per
+	// collection by each run of the migration job. Defaults to 100.
+	MigrationBatchSize int `bson:"migration_batch_size" json:"migration_batch_size" yaml:"migration_batch_size"`
+}
+
+var (
+	cedarTieredStorageConfigBuildLogsKey          = bsonutil.MustHaveTag(TieredStorageConfig{}, "BuildLogs")
+	cedarTieredStorageConfigTestResultsKey        = bsonutil.MustHaveTag(TieredStorageConfig{}, "TestResults")
+	cedarTieredStorageConfigMigrateAfterDaysKey   = bsonutil.MustHaveTag(TieredStorageConfig{}, "MigrateAfterDays")
+	cedarTieredStorageConfigMigrationBatchSizeKey = bsonutil.MustHaveTag(TieredStorageConfig{}, "MigrationBatchSize")
+)
+
+const (
+	defaultTieredStorageMigrateAfterDays   = 7
+	defaultTieredStorageMigrationBatchSize = 100
+)
+
+// MigrationAge returns how long after completion data is migrated from the
+// hot tier to S3.
+func (c *TieredStorageConfig) MigrationAge() time.Duration {
+	days := c.MigrateAfterDays
+	if days <= 0 {
+		days = defaultTieredStorageMigrateAfterDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// IngestionStatsTTL returns how long ingestion stats are kept after the hour
+// that they aggregate.
+func (c *RetentionConfig) IngestionStatsTTL() time.Duration {
+	if c.IngestionStatsDays <= 0 {
+		return cedar.DefaultIngestionStatsTTL
+	}
+	return time.Duration(c.IngestionStatsDays) * 24 * time.Hour
+}
+
+// BatchSize returns the maximum number of records to migrate per collection
+// in a single pass.
+func (c *TieredStorageConfig) BatchSize() int {
+	if c.MigrationBatchSize <= 0 {
+		return defaultTieredStorageMigrationBatchSize
+	}
+	return c.MigrationBatchSize
+}
+
+// IsEnabled returns whether new data of any kind is stored in the tiered
+// backend.
+func (c *TieredStorageConfig) IsEnabled() bool { return c.BuildLogs || c.TestResults }
+
+// LogStorageType returns the storage type of a new build log that requested
+// the given storage type.
+func (c *BucketConfig) LogStorageType(requested PailType) PailType {
+	if requested == PailS3 && c.Tiered.BuildLogs {
+		return PailTiered
+	}
+	return requested
+}
+
+// TestResultsStorageType returns the storage type of new test results.
+func (c *BucketConfig) TestResultsStorageType() PailType {
+	if c.TestResultsBucketType == PailS3 && c.Tiered.TestResults {
+		return PailTiered
+	}
+	return c.TestResultsBucketType
+}
+
+// RetentionConfig configures how long administrative records are kept.
+type RetentionConfig struct {
+	// AuditDays is the number of days that audit events are kept.
+	// Defaults to 365.
+	AuditDays int `bson:"audit_days" json:"audit_days" yaml:"audit_days"`
+	// IngestionStatsDays is the number of days that the hourly ingestion
+	// stats are kept. Defaults to 365.
+	IngestionStatsDays int `bson:"ingestion_stats_days" json:"ingestion_stats_days" yaml:"ingestion_stats_days"`
+}
+
+var (
+	cedarRetentionConfigAuditDaysKey          = bsonutil.MustHaveTag(RetentionConfig{}, "AuditDays")
+	cedarRetentionConfigIngestionStatsDaysKey = bsonutil.MustHaveTag(RetentionConfig{}, "IngestionStatsDays")
 )
 
+const defaultRetentionAuditDays = 365
+
+// AuditTTL returns how long audit events are kept after they are recorded.
+func (c *RetentionConfig) AuditTTL() time.Duration {
+	days := c.AuditDays
+	if days <= 0 {
+		days = defaultRetentionAuditDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 type ServiceConfig struct {
 	AppServers  []string `bson:"app_servers" json:"app_servers" yaml:"app_servers"`
 	CORSOrigins []string `bson:"cors_origins" json:"cors_origins" yaml:"cors_origins"`
@@ -346,6 +658,9 @@ func (c *CedarConfig) Save() error {
 	if c.env == nil {
 		return errors.New("cannot save Cedar configuration with a nil environment")
 	}
+	if err := c.Redaction.Validate(); err != nil {
+		return errors.Wrap(err, "invalid redaction configuration")
+	}
 
 	ctx, cancel := c.env.Context()
 	defer cancel()
@@ -11,6 +11,9 @@ type OperationalFlags struct {
 	DisableInternalMetricsReporting bool `bson:"disable_internal_metrics_reporting" json:"disable_internal_metrics_reporting" yaml:"disable_internal_metrics_reporting"`
 	DisableSignalProcessing         bool `bson:"disable_signal_processing" json:"disable_signal_processing" yaml:"disable_signal_processing"`
 	DisableHistoricalTestData       bool `bson:"disable_historical_test_data" json:"disable_historical_test_data" yaml:"disable_historical_test_data"`
+	// EnforceProjectReadRoles requires a project read role on the REST
+	// read routes, which are otherwise open to anonymous users.
+	EnforceProjectReadRoles bool `bson:"enforce_project_read_roles" json:"enforce_project_read_roles" yaml:"enforce_project_read_roles"`
 
 	env cedar.Environment
 }
@@ -18,6 +21,7 @@ type OperationalFlags struct {
 var (
 	opsFlagsDisableInternalMetricsReporting = bsonutil.MustHaveTag(OperationalFlags{}, "DisableInternalMetricsReporting")
 	opsFlagsDisableSignalProcessing         = bsonutil.MustHaveTag(OperationalFlags{}, "DisableSignalProcessing")
+	opsFlagsEnforceProjectReadRoles         = bsonutil.MustHaveTag(OperationalFlags{}, "EnforceProjectReadRoles")
 )
 
 func (f *OperationalFlags) findAndSet(name string, v bool) error {
@@ -26,11 +30,27 @@ func (f *OperationalFlags) findAndSet(name string, v bool) error {
 		return f.SetDisableInternalMetricsReporting(v)
 	case "disable_signal_processing":
 		return f.SetDisableSignalProcessing(v)
+	case "enforce_project_read_roles":
+		return f.SetEnforceProjectReadRoles(v)
 	default:
 		return errors.Errorf("%s is not a known feature flag name", name)
 	}
 }
 
+// Value returns the value of the named feature flag.
+func (f *OperationalFlags) Value(name string) (bool, error) {
+	switch name {
+	case "disable_internal_metrics_reporting":
+		return f.DisableInternalMetricsReporting, nil
+	case "disable_signal_processing":
+		return f.DisableSignalProcessing, nil
+	case "enforce_project_read_roles":
+		return f.EnforceProjectReadRoles, nil
+	default:
+		return false, errors.Errorf("%s is not a known feature flag name", name)
+	}
+}
+
 func (f *OperationalFlags) SetTrue(name string) error {
 	return errors.WithStack(f.findAndSet(name, true))
 }
@@ -57,6 +77,14 @@ func (f *OperationalFlags) SetDisableSignalProcessing(v bool) error {
 
 }
 
+func (f *OperationalFlags) SetEnforceProjectReadRoles(v bool) error {
+	if err := f.update(opsFlagsEnforceProjectReadRoles, v); err != nil {
+		return errors.WithStack(err)
+	}
+	f.EnforceProjectReadRoles = v
+	return nil
+}
+
 func (f *OperationalFlags) update(key string, value bool) error {
 	conf, session, err := cedar.GetSessionWithConfig(f.env)
 	if err != nil {
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/caio/go-tdigest"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// durationDigestCompression bounds the number of centroids kept by a
+// DurationDigest. Higher values give more accurate quantiles at the cost of
+// larger documents.
+const durationDigestCompression = 100
+
+// DurationDigest is a mergeable t-digest sketch of test durations used to
+// estimate duration percentiles without storing every duration. It is stored
+// in the database as the binary encoding of the t-digest.
+type DurationDigest struct {
+	digest *tdigest.TDigest
+}
+
+// NewDurationDigest returns an empty DurationDigest.
+func NewDurationDigest() *DurationDigest {
+	// The compression option only fails for non-positive values.
+	digest, _ := tdigest.New(tdigest.Compression(durationDigestCompression))
+	return &DurationDigest{digest: digest}
+}
+
+// Add adds a single duration to the digest.
+func (d *DurationDigest) Add(dur time.Duration) error {
+	return errors.Wrap(d.digest.Add(float64(dur)), "adding duration to digest")
+}
+
+// Merge adds all of the durations summarized by the other digest to this
+// digest.
+func (d *DurationDigest) Merge(other *DurationDigest) error {
+	if other == nil {
+		return nil
+	}
+
+	return errors.Wrap(d.digest.Merge(other.digest), "merging duration digests")
+}
+
+// Count returns the number of durations summarized by the digest.
+func (d *DurationDigest) Count() uint64 {
+	if d == nil {
+		return 0
+	}
+
+	return d.digest.Count()
+}
+
+// Quantile returns the estimated duration at quantile q, which should be
+// between 0 and 1. An empty digest returns 0.
+func (d *DurationDigest) Quantile(q float64) time.Duration {
+	if d.Count() == 0 {
+		return 0
+	}
+	if q < 0 {
+		q = 0
+	} else if q > 1 {
+		q = 1
+	}
+
+	return time.Duration(d.digest.Quantile(q))
+}
+
+// Copy returns a deep copy of the digest.
+func (d *DurationDigest) Copy() *DurationDigest {
+	if d == nil {
+		return nil
+	}
+
+	return &DurationDigest{digest: d.digest.Clone()}
+}
+
+// MarshalBSONValue encodes the digest as BSON binary data.
+func (d *DurationDigest) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	data, err := d.digest.AsBytes()
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "encoding duration digest")
+	}
+
+	return bson.MarshalValue(data)
+}
+
+// UnmarshalBSONValue decodes the digest from BSON binary data.
+func (d *DurationDigest) UnmarshalBSONValue(t bsontype.Type, raw []byte) error {
+	var data []byte
+	if err := (bson.RawValue{Type: t, Value: raw}).Unmarshal(&data); err != nil {
+		return errors.Wrap(err, "reading duration digest")
+	}
+	digest, err := tdigest.FromBytes(bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "decoding duration digest")
+	}
+	d.digest = digest
+
+	return nil
+}
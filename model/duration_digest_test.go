@@ -0,0 +1,97 @@
+package model
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDurationDigest(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		d := NewDurationDigest()
+		assert.Zero(t, d.Quantile(0.5))
+
+		var nilDigest *DurationDigest
+		assert.Zero(t, nilDigest.Quantile(0.5))
+		assert.Nil(t, nilDigest.Copy())
+	})
+	t.Run("SingleDuration", func(t *testing.T) {
+		d := NewDurationDigest()
+		require.NoError(t, d.Add(time.Second))
+		assert.EqualValues(t, 1, d.Count())
+		assert.Equal(t, time.Second, d.Quantile(0))
+		assert.Equal(t, time.Second, d.Quantile(0.5))
+		assert.Equal(t, time.Second, d.Quantile(1))
+	})
+	t.Run("Quantiles", func(t *testing.T) {
+		d := NewDurationDigest()
+		for _, i := range rand.Perm(10000) {
+			require.NoError(t, d.Add(time.Duration(i+1)*time.Millisecond))
+		}
+		assert.EqualValues(t, 10000, d.Count())
+		assert.InDelta(t, 5*time.Second, d.Quantile(0.5), float64(100*time.Millisecond))
+		assert.InDelta(t, 9*time.Second, d.Quantile(0.9), float64(50*time.Millisecond))
+		assert.InDelta(t, 9900*time.Millisecond, d.Quantile(0.99), float64(10*time.Millisecond))
+		assert.Equal(t, time.Millisecond, d.Quantile(0))
+		assert.Equal(t, 10*time.Second, d.Quantile(1))
+	})
+	t.Run("Outlier", func(t *testing.T) {
+		d := NewDurationDigest()
+		for i := 0; i < 99; i++ {
+			require.NoError(t, d.Add(time.Second))
+		}
+		require.NoError(t, d.Add(time.Hour))
+		assert.Equal(t, time.Second, d.Quantile(0.5))
+		assert.Equal(t, time.Second, d.Quantile(0.9))
+		assert.Equal(t, time.Hour, d.Quantile(1))
+	})
+	t.Run("Merge", func(t *testing.T) {
+		low := NewDurationDigest()
+		high := NewDurationDigest()
+		for i := 1; i <= 1000; i++ {
+			require.NoError(t, low.Add(time.Duration(i)*time.Millisecond))
+			require.NoError(t, high.Add(time.Duration(i+1000)*time.Millisecond))
+		}
+
+		merged := NewDurationDigest()
+		require.NoError(t, merged.Merge(low))
+		require.NoError(t, merged.Merge(high))
+		require.NoError(t, merged.Merge(nil))
+		require.NoError(t, merged.Merge(NewDurationDigest()))
+		assert.EqualValues(t, 2000, merged.Count())
+		assert.InDelta(t, time.Second, merged.Quantile(0.5), float64(50*time.Millisecond))
+		assert.InDelta(t, 1800*time.Millisecond, merged.Quantile(0.9), float64(20*time.Millisecond))
+	})
+	t.Run("Copy", func(t *testing.T) {
+		d := NewDurationDigest()
+		require.NoError(t, d.Add(time.Second))
+		c := d.Copy()
+		require.NotNil(t, c)
+		require.NoError(t, c.Add(time.Minute))
+		assert.EqualValues(t, 1, d.Count())
+		assert.Equal(t, time.Second, d.Quantile(1))
+		assert.EqualValues(t, 2, c.Count())
+	})
+	t.Run("BSON", func(t *testing.T) {
+		d := NewDurationDigest()
+		for i := 1; i <= 100; i++ {
+			require.NoError(t, d.Add(time.Duration(i)*time.Second))
+		}
+		doc := struct {
+			Digest *DurationDigest `bson:"digest"`
+		}{Digest: d}
+		data, err := bson.Marshal(doc)
+		require.NoError(t, err)
+
+		doc.Digest = nil
+		require.NoError(t, bson.Unmarshal(data, &doc))
+		require.NotNil(t, doc.Digest)
+		assert.EqualValues(t, 100, doc.Digest.Count())
+		assert.Equal(t, d.Quantile(0.5), doc.Digest.Quantile(0.5))
+		assert.Equal(t, 100*time.Second, doc.Digest.Quantile(1))
+	})
+}
@@ -0,0 +1,571 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	encryptionKeySize = 32
+
+	// encryptedObjectMagic starts every encrypted object. It is followed by
+	// the format version, the ID of the key that encrypted the data key,
+	// the encrypted data key and, finally, the encrypted data.
+	encryptedObjectMagic   = "CEDARENC"
+	encryptedObjectVersion = 1
+)
+
+// ProjectKeyID returns the ID of the key that new artifacts of the given
+// project are encrypted with, or an empty string if they are not encrypted.
+func (c *EncryptionConfig) ProjectKeyID(project string) string {
+	for _, p := range c.Projects {
+		if p.Project == project {
+			return p.KeyID
+		}
+	}
+
+	return ""
+}
+
+// keyring returns the AEAD cipher of each key in the keys file by ID. Each
+// project must use one of the keys.
+func (c *EncryptionConfig) keyring() (map[string]cipher.AEAD, error) {
+	if c.KeysFile == "" {
+		if len(c.Projects) > 0 {
+			return nil, errors.New("invalid encryption configuration: must specify a keys file to encrypt the artifacts of projects")
+		}
+		return map[string]cipher.AEAD{}, nil
+	}
+
+	keyring, err := loadKeyring(c.KeysFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid encryption configuration")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	for _, p := range c.Projects {
+		_, ok := keyring[p.KeyID]
+		catcher.ErrorfWhen(!ok, "project '%s' uses unknown encryption key '%s'", p.Project, p.KeyID)
+	}
+	if catcher.HasErrors() {
+		return nil, errors.Wrap(catcher.Resolve(), "invalid encryption configuration")
+	}
+
+	return keyring, nil
+}
+
+// encryptionKeysFile is the format of the file of encryption keys.
+type encryptionKeysFile struct {
+	Keys []EncryptionKey `json:"keys" yaml:"keys"`
+}
+
+// keyringCache holds the keyring of the most recently read keys file, which
+// is read again only once it changes, since a keyring is needed for every
+// encrypted artifact that is read or written.
+var keyringCache struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	keyring map[string]cipher.AEAD
+}
+
+// loadKeyring returns the keyring of the keys file at the given path. The
+// returned keyring is shared and must not be modified.
+func loadKeyring(path string) (map[string]cipher.AEAD, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting info of encryption keys file '%s'", path)
+	}
+
+	keyringCache.mu.Lock()
+	defer keyringCache.mu.Unlock()
+	if keyringCache.keyring != nil && keyringCache.path == path && keyringCache.modTime.Equal(info.ModTime()) && keyringCache.size == info.Size() {
+		return keyringCache.keyring, nil
+	}
+
+	file := encryptionKeysFile{}
+	if err = utility.ReadYAMLFile(path, &file); err != nil {
+		return nil, errors.Wrapf(err, "reading encryption keys file '%s'", path)
+	}
+	keyring, err := newKeyring(file.Keys)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing encryption keys file '%s'", path)
+	}
+
+	keyringCache.path = path
+	keyringCache.modTime = info.ModTime()
+	keyringCache.size = info.Size()
+	keyringCache.keyring = keyring
+
+	return keyring, nil
+}
+
+// newKeyring returns the AEAD cipher of each key by ID. The keys must be
+// valid 256-bit AES keys with unique IDs.
+func newKeyring(keys []EncryptionKey) (map[string]cipher.AEAD, error) {
+	catcher := grip.NewBasicCatcher()
+	keyring := map[string]cipher.AEAD{}
+	for _, key := range keys {
+		if key.ID == "" {
+			catcher.New("must specify an ID for each encryption key")
+			continue
+		}
+		if len(key.ID) > 255 {
+			catcher.Errorf("encryption key ID '%s' is too long", key.ID)
+			continue
+		}
+		if _, ok := keyring[key.ID]; ok {
+			catcher.Errorf("duplicate encryption key ID '%s'", key.ID)
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(key.Key)
+		if err != nil {
+			catcher.Wrapf(err, "decoding encryption key '%s'", key.ID)
+			continue
+		}
+		if len(raw) != encryptionKeySize {
+			catcher.Errorf("encryption key '%s' must be %d bytes", key.ID, encryptionKeySize)
+			continue
+		}
+		aead, err := newAEAD(raw)
+		if err != nil {
+			catcher.Wrapf(err, "creating cipher for encryption key '%s'", key.ID)
+			continue
+		}
+		keyring[key.ID] = aead
+	}
+	if catcher.HasErrors() {
+		return nil, catcher.Resolve()
+	}
+
+	return keyring, nil
+}
+
+// encryptBucket returns the bucket wrapped so that objects written to it are
+// encrypted with the key with the given ID and objects read from it are
+// decrypted with whichever key encrypted them. If the key ID is empty, the
+// bucket is returned as is.
+func (c *EncryptionConfig) encryptBucket(b pail.Bucket, keyID string) (pail.Bucket, error) {
+	if keyID == "" {
+		return b, nil
+	}
+
+	keyring, err := c.keyring()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := keyring[keyID]; !ok {
+		return nil, errors.Errorf("unknown encryption key '%s'", keyID)
+	}
+
+	return &encryptedBucket{Bucket: b, keyring: keyring, keyID: keyID}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.WithStack(err)
+}
+
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "generating nonce")
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	return plaintext, errors.WithStack(err)
+}
+
+// encryptObject encrypts the data with a new data key, which is encrypted with
+// the key with the given ID.
+func encryptObject(keyring map[string]cipher.AEAD, keyID string, data []byte) ([]byte, error) {
+	kek, ok := keyring[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown encryption key '%s'", keyID)
+	}
+
+	dataKey := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, errors.Wrap(err, "generating data key")
+	}
+	wrappedKey, err := seal(kek, dataKey, []byte(keyID))
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting data key")
+	}
+
+	header := &bytes.Buffer{}
+	header.WriteString(encryptedObjectMagic)
+	header.WriteByte(encryptedObjectVersion)
+	header.WriteByte(byte(len(keyID)))
+	header.WriteString(keyID)
+	if err = binary.Write(header, binary.BigEndian, uint16(len(wrappedKey))); err != nil {
+		return nil, errors.Wrap(err, "writing header")
+	}
+	header.Write(wrappedKey)
+
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating data key cipher")
+	}
+	ciphertext, err := seal(dek, data, header.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting data")
+	}
+
+	return append(header.Bytes(), ciphertext...), nil
+}
+
+// decryptObject decrypts data encrypted by encryptObject. Data that is not
+// encrypted is returned as is.
+func decryptObject(keyring map[string]cipher.AEAD, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, []byte(encryptedObjectMagic)) {
+		return data, nil
+	}
+
+	r := bytes.NewReader(data[len(encryptedObjectMagic):])
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading version")
+	}
+	if version != encryptedObjectVersion {
+		return nil, errors.Errorf("unsupported encrypted object version %d", version)
+	}
+	keyIDLen, err := r.ReadByte()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading key ID length")
+	}
+	keyID := make([]byte, keyIDLen)
+	if _, err = io.ReadFull(r, keyID); err != nil {
+		return nil, errors.Wrap(err, "reading key ID")
+	}
+	var wrappedKeyLen uint16
+	if err = binary.Read(r, binary.BigEndian, &wrappedKeyLen); err != nil {
+		return nil, errors.Wrap(err, "reading data key length")
+	}
+	wrappedKey := make([]byte, wrappedKeyLen)
+	if _, err = io.ReadFull(r, wrappedKey); err != nil {
+		return nil, errors.Wrap(err, "reading data key")
+	}
+	headerLen := len(data) - r.Len()
+
+	kek, ok := keyring[string(keyID)]
+	if !ok {
+		return nil, errors.Errorf("unknown encryption key '%s'", keyID)
+	}
+	dataKey, err := open(kek, wrappedKey, keyID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting data key with key '%s'", keyID)
+	}
+	dek, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating data key cipher")
+	}
+	plaintext, err := open(dek, data[headerLen:], data[:headerLen])
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data")
+	}
+
+	return plaintext, nil
+}
+
+// encryptedBucket is a pail Bucket that encrypts the objects it writes and
+// decrypts the objects it reads. Objects are buffered in memory since they are
+// encrypted as a whole. Push, Pull and Copy operate on the encrypted objects.
+type encryptedBucket struct {
+	pail.Bucket
+	keyring map[string]cipher.AEAD
+	keyID   string
+}
+
+func (b *encryptedBucket) Put(ctx context.Context, key string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "reading data")
+	}
+	encrypted, err := encryptObject(b.keyring, b.keyID, data)
+	if err != nil {
+		return errors.Wrapf(err, "encrypting object '%s'", key)
+	}
+
+	return b.Bucket.Put(ctx, key, bytes.NewReader(encrypted))
+}
+
+func (b *encryptedBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := b.Bucket.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading object '%s'", key)
+	}
+	decrypted, err := decryptObject(b.keyring, data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting object '%s'", key)
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(decrypted)), nil
+}
+
+func (b *encryptedBucket) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	return b.Get(ctx, key)
+}
+
+func (b *encryptedBucket) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	return &encryptedWriter{ctx: ctx, bucket: b, key: key}, nil
+}
+
+func (b *encryptedBucket) Upload(ctx context.Context, key, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "opening file '%s'", path)
+	}
+	defer f.Close()
+
+	return b.Put(ctx, key, f)
+}
+
+func (b *encryptedBucket) Download(ctx context.Context, key, path string) error {
+	r, err := b.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.Wrapf(err, "creating directory for file '%s'", path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrapf(err, "creating file '%s'", path)
+	}
+	catcher := grip.NewBasicCatcher()
+	_, err = io.Copy(f, r)
+	catcher.Wrapf(err, "writing file '%s'", path)
+	catcher.Wrapf(f.Close(), "closing file '%s'", path)
+
+	return catcher.Resolve()
+}
+
+// encryptedWriter buffers the object and writes it encrypted on Close.
+type encryptedWriter struct {
+	ctx    context.Context
+	bucket *encryptedBucket
+	key    string
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (w *encryptedWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("writer is closed")
+	}
+	return w.buf.Write(p)
+}
+
+func (w *encryptedWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	return w.bucket.Put(w.ctx, w.key, &w.buf)
+}
+
+// ReencryptArtifacts re-encrypts the objects of up to limit completed log and
+// system metrics artifacts per collection that are
+// encrypted with a different key than the current key of their project, and
+// returns the number of re-encrypted artifacts. Artifacts that are not
+// encrypted are left as is.
+func ReencryptArtifacts(ctx context.Context, env cedar.Environment, limit int) (int, error) {
+	conf := &CedarConfig{}
+	conf.Setup(env)
+	if err := conf.Find(); err != nil {
+		return 0, errors.Wrap(err, "getting application configuration")
+	}
+	if len(conf.Encryption.Projects) == 0 {
+		return 0, nil
+	}
+	keyring, err := conf.Encryption.keyring()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	catcher := grip.NewBasicCatcher()
+	for _, project := range conf.Encryption.Projects {
+		count, err := reencryptLogs(ctx, env, conf, keyring, project, limit)
+		total += count
+		catcher.Wrapf(err, "re-encrypting logs of project '%s'", project.Project)
+
+		count, err = reencryptSystemMetrics(ctx, env, conf, keyring, project, limit)
+		total += count
+		catcher.Wrapf(err, "re-encrypting system metrics of project '%s'", project.Project)
+	}
+
+	return total, catcher.Resolve()
+}
+
+// reencryptionQuery returns the query for the completed artifacts of the
+// project that are encrypted with a different key.
+func reencryptionQuery(infoKey, projectKey, completedAtKey, keyIDKey, keyID, project string) bson.M {
+	return bson.M{
+		bsonutil.GetDottedKeyName(infoKey, projectKey): project,
+		completedAtKey: bson.M{"$gt": time.Time{}},
+		keyIDKey:       bson.M{"$exists": true, "$ne": keyID},
+	}
+}
+
+func reencryptLogs(ctx context.Context, env cedar.Environment, conf *CedarConfig, keyring map[string]cipher.AEAD, project ProjectEncryptionKey, limit int) (int, error) {
+	keyIDKey := bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoEncryptionKeyIDKey)
+	query := reencryptionQuery(logInfoKey, logInfoProjectKey, logCompletedAtKey, keyIDKey, project.KeyID, project.Project)
+	logs := []Log{}
+	if err := findForReencryption(ctx, env, buildloggerCollection, query, limit, &logs); err != nil {
+		return 0, err
+	}
+
+	for i, l := range logs {
+		bucket, err := l.Artifact.Type.Create(ctx, env, conf.Bucket.BuildLogsBucket, l.Artifact.Prefix, string(pail.S3PermissionsPrivate), true)
+		if err != nil {
+			return i, errors.Wrap(err, "creating bucket")
+		}
+		if err = reencryptBucket(ctx, bucket, keyring, project.KeyID); err != nil {
+			return i, errors.Wrapf(err, "re-encrypting log '%s'", l.ID)
+		}
+		if err = updateEncryptionKeyID(ctx, env, buildloggerCollection, l.ID, keyIDKey, l.Artifact.EncryptionKeyID, project.KeyID); err != nil {
+			return i, errors.Wrapf(err, "updating log '%s'", l.ID)
+		}
+	}
+
+	return len(logs), nil
+}
+
+func reencryptSystemMetrics(ctx context.Context, env cedar.Environment, conf *CedarConfig, keyring map[string]cipher.AEAD, project ProjectEncryptionKey, limit int) (int, error) {
+	keyIDKey := bsonutil.GetDottedKeyName(systemMetricsArtifactKey, metricsArtifactInfoEncryptionKeyIDKey)
+	query := reencryptionQuery(systemMetricsInfoKey, systemMetricsInfoProjectKey, systemMetricsCompletedAtKey, keyIDKey, project.KeyID, project.Project)
+	metrics := []SystemMetrics{}
+	if err := findForReencryption(ctx, env, systemMetricsCollection, query, limit, &metrics); err != nil {
+		return 0, err
+	}
+
+	for i, sm := range metrics {
+		bucket, err := sm.Artifact.Options.Type.Create(ctx, env, conf.Bucket.SystemMetricsBucket, sm.Artifact.Prefix, string(pail.S3PermissionsPrivate), true)
+		if err != nil {
+			return i, errors.Wrap(err, "creating bucket")
+		}
+		if err = reencryptBucket(ctx, bucket, keyring, project.KeyID); err != nil {
+			return i, errors.Wrapf(err, "re-encrypting system metrics '%s'", sm.ID)
+		}
+		if err = updateEncryptionKeyID(ctx, env, systemMetricsCollection, sm.ID, keyIDKey, sm.Artifact.EncryptionKeyID, project.KeyID); err != nil {
+			return i, errors.Wrapf(err, "updating system metrics '%s'", sm.ID)
+		}
+	}
+
+	return len(metrics), nil
+}
+
+func findForReencryption(ctx context.Context, env cedar.Environment, collection string, query bson.M, limit int, out interface{}) error {
+	cur, err := env.GetDB().Collection(collection).Find(ctx, query, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return errors.Wrapf(err, "finding artifacts to re-encrypt in '%s'", collection)
+	}
+
+	return errors.Wrapf(cur.All(ctx, out), "decoding artifacts to re-encrypt in '%s'", collection)
+}
+
+// reencryptBucket re-encrypts all of the objects in the bucket with the key
+// with the given ID.
+func reencryptBucket(ctx context.Context, bucket pail.Bucket, keyring map[string]cipher.AEAD, keyID string) error {
+	it, err := bucket.List(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "listing objects")
+	}
+	keys := []string{}
+	for it.Next(ctx) {
+		keys = append(keys, it.Item().Name())
+	}
+	if err = it.Err(); err != nil {
+		return errors.Wrap(err, "iterating objects")
+	}
+
+	for _, key := range keys {
+		if err := reencryptObject(ctx, bucket, key, keyring, keyID); err != nil {
+			return errors.Wrapf(err, "re-encrypting object '%s'", key)
+		}
+	}
+
+	return nil
+}
+
+func reencryptObject(ctx context.Context, bucket pail.Bucket, key string, keyring map[string]cipher.AEAD, keyID string) error {
+	r, err := bucket.Get(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "getting object")
+	}
+	data, err := ioutil.ReadAll(r)
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(err, "reading object")
+	catcher.Wrap(r.Close(), "closing object reader")
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	plaintext, err := decryptObject(keyring, data)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	encrypted, err := encryptObject(keyring, keyID, plaintext)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.Wrap(bucket.Put(ctx, key, bytes.NewReader(encrypted)), "putting object")
+}
+
+// updateEncryptionKeyID records the new encryption key of the artifact unless
+// it changed since the artifact was found.
+func updateEncryptionKeyID(ctx context.Context, env cedar.Environment, collection, id, keyIDKey, oldKeyID, newKeyID string) error {
+	_, err := env.GetDB().Collection(collection).UpdateOne(ctx,
+		bson.M{"_id": id, keyIDKey: oldKeyID},
+		bson.M{"$set": bson.M{keyIDKey: newKeyID}},
+	)
+
+	return errors.WithStack(err)
+}
@@ -0,0 +1,328 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func newTestEncryptionKey(t *testing.T, id string) EncryptionKey {
+	key := make([]byte, encryptionKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+
+	return EncryptionKey{ID: id, Key: base64.StdEncoding.EncodeToString(key)}
+}
+
+func newTestEncryptionConfig(t *testing.T, keys ...EncryptionKey) EncryptionConfig {
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	writeTestEncryptionKeysFile(t, path, keys...)
+
+	return EncryptionConfig{KeysFile: path}
+}
+
+func writeTestEncryptionKeysFile(t *testing.T, path string, keys ...EncryptionKey) {
+	data, err := yaml.Marshal(encryptionKeysFile{Keys: keys})
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(path, data, 0600))
+}
+
+func TestNewKeyring(t *testing.T) {
+	key := newTestEncryptionKey(t, "key")
+	for _, test := range []struct {
+		name   string
+		keys   []EncryptionKey
+		hasErr bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Valid",
+			keys: []EncryptionKey{key, newTestEncryptionKey(t, "other")},
+		},
+		{
+			name:   "MissingID",
+			keys:   []EncryptionKey{{Key: key.Key}},
+			hasErr: true,
+		},
+		{
+			name:   "DuplicateID",
+			keys:   []EncryptionKey{key, key},
+			hasErr: true,
+		},
+		{
+			name:   "InvalidBase64",
+			keys:   []EncryptionKey{{ID: "key", Key: "not base64!"}},
+			hasErr: true,
+		},
+		{
+			name:   "WrongSize",
+			keys:   []EncryptionKey{{ID: "key", Key: base64.StdEncoding.EncodeToString([]byte("short"))}},
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			keyring, err := newKeyring(test.keys)
+			if test.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, keyring, len(test.keys))
+		})
+	}
+}
+
+func TestEncryptionConfigKeyring(t *testing.T) {
+	key := newTestEncryptionKey(t, "key")
+
+	t.Run("NoKeysFile", func(t *testing.T) {
+		keyring, err := (&EncryptionConfig{}).keyring()
+		require.NoError(t, err)
+		assert.Empty(t, keyring)
+	})
+	t.Run("NoKeysFileWithProjects", func(t *testing.T) {
+		conf := EncryptionConfig{Projects: []ProjectEncryptionKey{{Project: "project", KeyID: "key"}}}
+		_, err := conf.keyring()
+		assert.Error(t, err)
+	})
+	t.Run("MissingKeysFile", func(t *testing.T) {
+		conf := EncryptionConfig{KeysFile: filepath.Join(t.TempDir(), "DNE")}
+		_, err := conf.keyring()
+		assert.Error(t, err)
+	})
+	t.Run("InvalidKeysFile", func(t *testing.T) {
+		conf := newTestEncryptionConfig(t, key, key)
+		_, err := conf.keyring()
+		assert.Error(t, err)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		conf := newTestEncryptionConfig(t, key, newTestEncryptionKey(t, "other"))
+		conf.Projects = []ProjectEncryptionKey{{Project: "project", KeyID: "key"}}
+		keyring, err := conf.keyring()
+		require.NoError(t, err)
+		assert.Len(t, keyring, 2)
+	})
+	t.Run("UnknownProjectKey", func(t *testing.T) {
+		conf := newTestEncryptionConfig(t, key)
+		conf.Projects = []ProjectEncryptionKey{{Project: "project", KeyID: "DNE"}}
+		_, err := conf.keyring()
+		assert.Error(t, err)
+	})
+	t.Run("CachesUntilKeysFileChanges", func(t *testing.T) {
+		conf := newTestEncryptionConfig(t, key)
+		keyring, err := conf.keyring()
+		require.NoError(t, err)
+		cached, err := conf.keyring()
+		require.NoError(t, err)
+		assert.Equal(t, reflect.ValueOf(keyring).Pointer(), reflect.ValueOf(cached).Pointer())
+
+		writeTestEncryptionKeysFile(t, conf.KeysFile, key, newTestEncryptionKey(t, "new"))
+		later := time.Now().Add(time.Minute)
+		require.NoError(t, os.Chtimes(conf.KeysFile, later, later))
+		reloaded, err := conf.keyring()
+		require.NoError(t, err)
+		assert.Len(t, reloaded, 2)
+		assert.Contains(t, reloaded, "new")
+	})
+}
+
+func TestEncryptionConfigProjectKeyID(t *testing.T) {
+	conf := EncryptionConfig{Projects: []ProjectEncryptionKey{{Project: "project", KeyID: "key"}}}
+	assert.Equal(t, "key", conf.ProjectKeyID("project"))
+	assert.Empty(t, conf.ProjectKeyID("other"))
+}
+
+func TestEncryptObject(t *testing.T) {
+	keyring, err := newKeyring([]EncryptionKey{newTestEncryptionKey(t, "old"), newTestEncryptionKey(t, "new")})
+	require.NoError(t, err)
+	data := []byte("some log lines")
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		encrypted, err := encryptObject(keyring, "old", data)
+		require.NoError(t, err)
+		assert.False(t, bytes.Contains(encrypted, data))
+
+		decrypted, err := decryptObject(keyring, encrypted)
+		require.NoError(t, err)
+		assert.Equal(t, data, decrypted)
+	})
+	t.Run("UnknownKey", func(t *testing.T) {
+		_, err := encryptObject(keyring, "DNE", data)
+		assert.Error(t, err)
+	})
+	t.Run("RemovedKey", func(t *testing.T) {
+		encrypted, err := encryptObject(keyring, "old", data)
+		require.NoError(t, err)
+
+		_, err = decryptObject(map[string]cipher.AEAD{"new": keyring["new"]}, encrypted)
+		assert.Error(t, err)
+	})
+	t.Run("Tampered", func(t *testing.T) {
+		encrypted, err := encryptObject(keyring, "old", data)
+		require.NoError(t, err)
+		encrypted[len(encrypted)-1] ^= 0xff
+
+		_, err = decryptObject(keyring, encrypted)
+		assert.Error(t, err)
+	})
+	t.Run("Plaintext", func(t *testing.T) {
+		decrypted, err := decryptObject(keyring, data)
+		require.NoError(t, err)
+		assert.Equal(t, data, decrypted)
+	})
+}
+
+func TestEncryptedBucket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	conf := newTestEncryptionConfig(t, newTestEncryptionKey(t, "old"), newTestEncryptionKey(t, "new"))
+	raw, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+	bucket, err := conf.encryptBucket(raw, "old")
+	require.NoError(t, err)
+	readObject := func(t *testing.T, b pail.Bucket, key string) []byte {
+		r, err := b.Get(ctx, key)
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("NoKeyID", func(t *testing.T) {
+		b, err := conf.encryptBucket(raw, "")
+		require.NoError(t, err)
+		assert.Equal(t, raw, b)
+	})
+	t.Run("UnknownKeyID", func(t *testing.T) {
+		_, err := conf.encryptBucket(raw, "DNE")
+		assert.Error(t, err)
+	})
+	t.Run("Put", func(t *testing.T) {
+		require.NoError(t, bucket.Put(ctx, "put", bytes.NewBufferString("data")))
+		assert.True(t, bytes.HasPrefix(readObject(t, raw, "put"), []byte(encryptedObjectMagic)))
+		assert.Equal(t, "data", string(readObject(t, bucket, "put")))
+	})
+	t.Run("Writer", func(t *testing.T) {
+		w, err := bucket.Writer(ctx, "writer")
+		require.NoError(t, err)
+		_, err = w.Write([]byte("da"))
+		require.NoError(t, err)
+		_, err = w.Write([]byte("ta"))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		assert.True(t, bytes.HasPrefix(readObject(t, raw, "writer"), []byte(encryptedObjectMagic)))
+		assert.Equal(t, "data", string(readObject(t, bucket, "writer")))
+	})
+	t.Run("UploadAndDownload", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "in"), []byte("data"), 0644))
+		require.NoError(t, bucket.Upload(ctx, "upload", filepath.Join(dir, "in")))
+		assert.True(t, bytes.HasPrefix(readObject(t, raw, "upload"), []byte(encryptedObjectMagic)))
+
+		require.NoError(t, bucket.Download(ctx, "upload", filepath.Join(dir, "out", "file")))
+		data, err := ioutil.ReadFile(filepath.Join(dir, "out", "file"))
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+	})
+	t.Run("ReadsObjectsEncryptedWithOtherKeys", func(t *testing.T) {
+		newBucket, err := conf.encryptBucket(raw, "new")
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(readObject(t, newBucket, "put")))
+	})
+	t.Run("ReadsPlaintextObjects", func(t *testing.T) {
+		require.NoError(t, raw.Put(ctx, "plaintext", bytes.NewBufferString("data")))
+		assert.Equal(t, "data", string(readObject(t, bucket, "plaintext")))
+	})
+}
+
+func TestReencryptArtifacts(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(buildloggerCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+	}()
+
+	dir := t.TempDir()
+	conf := NewCedarConfig(env)
+	conf.Bucket.BuildLogsBucket = dir
+	keys := []EncryptionKey{newTestEncryptionKey(t, "old"), newTestEncryptionKey(t, "new")}
+	conf.Encryption = newTestEncryptionConfig(t, keys...)
+	conf.Encryption.Projects = []ProjectEncryptionKey{{Project: "project", KeyID: "old"}}
+	require.NoError(t, conf.Save())
+
+	log := CreateLog(LogInfo{Project: "project", TaskID: "task"}, PailLocal)
+	log.Artifact.EncryptionKeyID = conf.Encryption.ProjectKeyID("project")
+	log.Setup(env)
+	require.NoError(t, log.SaveNew(ctx))
+	require.NoError(t, log.Append(ctx, []LogLine{{Timestamp: time.Now(), Data: "line"}}))
+	require.NoError(t, log.Close(ctx, 0))
+
+	t.Run("NothingToRotate", func(t *testing.T) {
+		count, err := ReencryptArtifacts(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+
+	conf.Encryption.Projects[0].KeyID = "new"
+	require.NoError(t, conf.Save())
+
+	t.Run("RotatesKey", func(t *testing.T) {
+		count, err := ReencryptArtifacts(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		rotated := &Log{ID: log.ID}
+		rotated.Setup(env)
+		require.NoError(t, rotated.Find(ctx))
+		assert.Equal(t, "new", rotated.Artifact.EncryptionKeyID)
+
+		keyring, err := newKeyring(keys[1:])
+		require.NoError(t, err)
+		raw, err := pail.NewLocalBucket(pail.LocalOptions{Path: dir, Prefix: log.Artifact.Prefix})
+		require.NoError(t, err)
+		it, err := raw.List(ctx, "")
+		require.NoError(t, err)
+		require.True(t, it.Next(ctx))
+		r, err := it.Item().Get(ctx)
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		plaintext, err := decryptObject(keyring, data)
+		require.NoError(t, err)
+		assert.Contains(t, string(plaintext), "line")
+
+		logIt, err := rotated.Download(ctx, TimeRange{EndAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+		defer logIt.Close()
+		require.True(t, logIt.Next(ctx))
+		assert.Contains(t, logIt.Item().Data, "line")
+	})
+	t.Run("AlreadyRotated", func(t *testing.T) {
+		count, err := ReencryptArtifacts(ctx, env, 10)
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+}
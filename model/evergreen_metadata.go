@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Record types that may be enriched with Evergreen metadata.
+const (
+	EvergreenMetadataRecordLog         = "log"
+	EvergreenMetadataRecordTestResults = "test_results"
+	EvergreenMetadataRecordPerf        = "perf"
+)
+
+// EvergreenMetadata is the metadata of the task, version and build of a
+// record fetched from Evergreen, so that records may be filtered without
+// clients having to send these fields. The commit time is only set for
+// mainline commits, since patches are not created at commit time.
+type EvergreenMetadata struct {
+	Author             string    `bson:"author,omitempty"`
+	AuthorEmail        string    `bson:"author_email,omitempty"`
+	Revision           string    `bson:"revision,omitempty"`
+	CommitTime         time.Time `bson:"commit_time,omitempty"`
+	Requester          string    `bson:"requester,omitempty"`
+	Distro             string    `bson:"distro,omitempty"`
+	VariantDisplayName string    `bson:"variant_display_name,omitempty"`
+	EnrichedAt         time.Time `bson:"enriched_at"`
+}
+
+var evergreenMetadataAuthorKey = bsonutil.MustHaveTag(EvergreenMetadata{}, "Author")
+
+type evergreenMetadataRecord struct {
+	collection   string
+	infoKey      string
+	taskIDKey    string
+	evergreenKey string
+}
+
+func getEvergreenMetadataRecord(recordType string) (evergreenMetadataRecord, error) {
+	switch recordType {
+	case EvergreenMetadataRecordLog:
+		return evergreenMetadataRecord{
+			collection:   buildloggerCollection,
+			infoKey:      logInfoKey,
+			taskIDKey:    logInfoTaskIDKey,
+			evergreenKey: logInfoEvergreenKey,
+		}, nil
+	case EvergreenMetadataRecordTestResults:
+		return evergreenMetadataRecord{
+			collection:   testResultsCollection,
+			infoKey:      testResultsInfoKey,
+			taskIDKey:    testResultsInfoTaskIDKey,
+			evergreenKey: testResultsInfoEvergreenKey,
+		}, nil
+	case EvergreenMetadataRecordPerf:
+		return evergreenMetadataRecord{
+			collection:   perfResultCollection,
+			infoKey:      perfInfoKey,
+			taskIDKey:    perfResultInfoTaskIDKey,
+			evergreenKey: perfResultInfoEvergreenKey,
+		}, nil
+	default:
+		return evergreenMetadataRecord{}, errors.Errorf("invalid record type '%s'", recordType)
+	}
+}
+
+// SetEvergreenMetadata sets the Evergreen metadata of the record of the given
+// type and ID.
+func SetEvergreenMetadata(ctx context.Context, env cedar.Environment, recordType, id string, metadata EvergreenMetadata) error {
+	if env == nil {
+		return errors.New("cannot set Evergreen metadata with a nil environment")
+	}
+	record, err := getEvergreenMetadataRecord(recordType)
+	if err != nil {
+		return err
+	}
+
+	updateResult, err := env.GetDB().Collection(record.collection).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{bsonutil.GetDottedKeyName(record.infoKey, record.evergreenKey): metadata}},
+	)
+	if err != nil {
+		return errors.Wrapf(err, "setting Evergreen metadata for %s record '%s'", recordType, id)
+	}
+	if updateResult.MatchedCount == 0 {
+		return errors.Errorf("%s record '%s' not found", recordType, id)
+	}
+
+	return nil
+}
+
+// FindEvergreenMetadata returns the Evergreen metadata already fetched for
+// another record of the given type with the same task ID, so that Evergreen
+// is only queried once per task. It returns nil if there is none.
+func FindEvergreenMetadata(ctx context.Context, env cedar.Environment, recordType, taskID string) (*EvergreenMetadata, error) {
+	if env == nil {
+		return nil, errors.New("cannot find Evergreen metadata with a nil environment")
+	}
+	record, err := getEvergreenMetadataRecord(recordType)
+	if err != nil {
+		return nil, err
+	}
+
+	evergreenKey := bsonutil.GetDottedKeyName(record.infoKey, record.evergreenKey)
+	out := struct {
+		Info struct {
+			Evergreen *EvergreenMetadata `bson:"evergreen"`
+		} `bson:"info"`
+	}{}
+	err = env.GetDB().Collection(record.collection).FindOne(ctx,
+		bson.M{
+			bsonutil.GetDottedKeyName(record.infoKey, record.taskIDKey): taskID,
+			evergreenKey: bson.M{"$exists": true},
+		},
+		options.FindOne().SetProjection(bson.M{evergreenKey: 1}),
+	).Decode(&out)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding Evergreen metadata for task '%s'", taskID)
+	}
+
+	return out.Info.Evergreen, nil
+}
@@ -0,0 +1,455 @@
+package model
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const historicalTaskDataCollection = "historical_task_data"
+
+// HistoricalTaskData describes aggregated task-level test results data for a
+// given day. Each test results record counts as one run of its task when it is
+// first closed, and the run passed if none of its tests failed. The runtime of
+// a run is taken from the test timestamps, as the time between the earliest
+// test start and the latest test end, since the test results record does not
+// know when the task itself started and ended.
+type HistoricalTaskData struct {
+	ID                string                 `bson:"_id"`
+	Info              HistoricalTaskDataInfo `bson:"info"`
+	NumPass           int                    `bson:"num_pass"`
+	NumFail           int                    `bson:"num_fail"`
+	NumTests          int                    `bson:"num_tests"`
+	TotalDurationPass time.Duration          `bson:"total_duration_pass"`
+	LastUpdate        time.Time              `bson:"last_update"`
+	// DisplayTaskName is the display task of the task, if any, so that
+	// the task data can be found by the display task name like the
+	// historical test data.
+	DisplayTaskName string `bson:"display_task_name,omitempty"`
+
+	env       cedar.Environment
+	populated bool
+}
+
+var (
+	historicalTaskDataIDKey                = bsonutil.MustHaveTag(HistoricalTaskData{}, "ID")
+	historicalTaskDataInfoKey              = bsonutil.MustHaveTag(HistoricalTaskData{}, "Info")
+	historicalTaskDataNumPassKey           = bsonutil.MustHaveTag(HistoricalTaskData{}, "NumPass")
+	historicalTaskDataNumFailKey           = bsonutil.MustHaveTag(HistoricalTaskData{}, "NumFail")
+	historicalTaskDataNumTestsKey          = bsonutil.MustHaveTag(HistoricalTaskData{}, "NumTests")
+	historicalTaskDataTotalDurationPassKey = bsonutil.MustHaveTag(HistoricalTaskData{}, "TotalDurationPass")
+	historicalTaskDataLastUpdateKey        = bsonutil.MustHaveTag(HistoricalTaskData{}, "LastUpdate")
+	historicalTaskDataDisplayTaskNameKey   = bsonutil.MustHaveTag(HistoricalTaskData{}, "DisplayTaskName")
+)
+
+// CreateHistoricalTaskData is an entry point for creating a new
+// HistoricalTaskData.
+func CreateHistoricalTaskData(info HistoricalTaskDataInfo) (*HistoricalTaskData, error) {
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+
+	info.Date = utility.GetUTCDay(info.Date)
+
+	return &HistoricalTaskData{
+		ID:        info.ID(),
+		Info:      info,
+		populated: true,
+	}, nil
+}
+
+// Setup sets the environment. The environment is required for numerous
+// functions on HistoricalTaskData.
+func (d *HistoricalTaskData) Setup(e cedar.Environment) { d.env = e }
+
+// IsNil returns if the HistoricalTaskData is populated or not.
+func (d *HistoricalTaskData) IsNil() bool { return !d.populated }
+
+// Find searches the DB for the HistoricalTaskData by ID. The environmemt
+// should not be nil.
+func (d *HistoricalTaskData) Find(ctx context.Context) error {
+	if d.env == nil {
+		return errors.New("cannot find with a nil environment")
+	}
+
+	if d.ID == "" {
+		d.ID = d.Info.ID()
+	}
+
+	d.populated = false
+	if err := d.env.GetDB().Collection(historicalTaskDataCollection).FindOne(ctx, bson.M{"_id": d.ID}).Decode(d); err != nil {
+		return errors.Wrapf(err, "finding historical task data record '%s'", d.ID)
+	}
+	d.populated = true
+
+	return nil
+}
+
+// Update adds a run of the task, with the given stats and runtime, to the
+// HistoricalTaskData. If the HistoricalTaskData does not exist, it is
+// created. The HistoricalTaskData should be populated and the environment
+// should not be nil.
+func (d *HistoricalTaskData) Update(ctx context.Context, stats TestResultsStats, runtime time.Duration) error {
+	if !d.populated {
+		return errors.New("cannot update unpopulated historical task data")
+	}
+	if d.env == nil {
+		return errors.New("cannot update with a nil environment")
+	}
+
+	if d.ID == "" {
+		d.ID = d.Info.ID()
+	}
+
+	inc := bson.M{historicalTaskDataNumTestsKey: stats.TotalCount}
+	if stats.FailedCount > 0 {
+		inc[historicalTaskDataNumFailKey] = 1
+	} else {
+		inc[historicalTaskDataNumPassKey] = 1
+		inc[historicalTaskDataTotalDurationPassKey] = runtime
+	}
+
+	update := bson.M{
+		"$inc":         inc,
+		"$currentDate": bson.M{historicalTaskDataLastUpdateKey: true},
+	}
+	if d.DisplayTaskName != "" {
+		update["$set"] = bson.M{historicalTaskDataDisplayTaskNameKey: d.DisplayTaskName}
+	}
+
+	d.populated = false
+	err := d.env.GetDB().Collection(historicalTaskDataCollection).FindOneAndUpdate(
+		ctx,
+		bson.M{
+			historicalTaskDataIDKey:   d.ID,
+			historicalTaskDataInfoKey: d.Info,
+		},
+		update,
+		options.FindOneAndUpdate().SetUpsert(true),
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+		options.FindOneAndUpdate().SetMaxTime(time.Minute),
+	).Decode(d)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection": historicalTaskDataCollection,
+		"id":         d.ID,
+		"op":         "update historical task data record",
+	})
+	if err != nil {
+		return errors.Wrapf(err, "updating historical task data '%s'", d.ID)
+	}
+
+	d.populated = true
+
+	return nil
+}
+
+// TestResultsRuntime returns the runtime of a task run with the given test
+// results, which is the time between the earliest test start and the latest
+// test end.
+func TestResultsRuntime(results []TestResult) time.Duration {
+	var start, end time.Time
+	for _, result := range results {
+		if start.IsZero() || result.TestStartTime.Before(start) {
+			start = result.TestStartTime
+		}
+		if result.TestEndTime.After(end) {
+			end = result.TestEndTime
+		}
+	}
+
+	return end.Sub(start)
+}
+
+// HistoricalTaskDataInfo describes information unique to a single task
+// statistics document.
+type HistoricalTaskDataInfo struct {
+	Project     string    `bson:"project"`
+	Variant     string    `bson:"variant"`
+	TaskName    string    `bson:"task_name"`
+	RequestType string    `bson:"request_type"`
+	Date        time.Time `bson:"date"`
+	Schema      int       `bson:"schema,omitempty"`
+}
+
+var (
+	historicalTaskDataInfoProjectKey     = bsonutil.MustHaveTag(HistoricalTaskDataInfo{}, "Project")
+	historicalTaskDataInfoVariantKey     = bsonutil.MustHaveTag(HistoricalTaskDataInfo{}, "Variant")
+	historicalTaskDataInfoTaskNameKey    = bsonutil.MustHaveTag(HistoricalTaskDataInfo{}, "TaskName")
+	historicalTaskDataInfoRequestTypeKey = bsonutil.MustHaveTag(HistoricalTaskDataInfo{}, "RequestType")
+	historicalTaskDataInfoDateKey        = bsonutil.MustHaveTag(HistoricalTaskDataInfo{}, "Date")
+)
+
+// NewHistoricalTaskDataInfo returns the info of the historical task data that
+// the closed test results record contributes to. Unlike historical test data,
+// execution tasks are not attributed to their display task so that each
+// record counts as exactly one task run.
+func NewHistoricalTaskDataInfo(record *TestResults) HistoricalTaskDataInfo {
+	date := record.CompletedAt
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	return HistoricalTaskDataInfo{
+		Project:     record.Info.Project,
+		Variant:     record.Info.Variant,
+		TaskName:    record.Info.TaskName,
+		RequestType: record.Info.RequestType,
+		Date:        date,
+	}
+}
+
+// ID creates a unique hash for a HistoricalTaskData record.
+func (id *HistoricalTaskDataInfo) ID() string {
+	var hash hash.Hash
+
+	if id.Schema == 0 {
+		hash = sha1.New()
+		_, _ = io.WriteString(hash, id.Project)
+		_, _ = io.WriteString(hash, id.Variant)
+		_, _ = io.WriteString(hash, id.TaskName)
+		_, _ = io.WriteString(hash, id.RequestType)
+		_, _ = io.WriteString(hash, id.Date.Format(HistoricalTestDataDateFormat))
+	} else {
+		panic("unsupported schema")
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func (i *HistoricalTaskDataInfo) validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(i.Project == "", "project field must not be empty")
+	catcher.NewWhen(i.Variant == "", "variant field must not be empty")
+	catcher.NewWhen(i.TaskName == "", "task name field must not be empty")
+	catcher.NewWhen(i.RequestType == "", "request type field must not be empty")
+	catcher.NewWhen(i.Date.IsZero(), "date field must not be zero")
+
+	return catcher.Resolve()
+}
+
+///////////////////
+// Find aggregation
+///////////////////
+
+// AggregatedHistoricalTaskData represents aggregated task execution data.
+type AggregatedHistoricalTaskData struct {
+	TaskName string    `bson:"task_name"`
+	Variant  string    `bson:"variant,omitempty"`
+	Date     time.Time `bson:"date"`
+
+	NumPass         int           `bson:"num_pass"`
+	NumFail         int           `bson:"num_fail"`
+	NumTests        int           `bson:"num_tests"`
+	AverageDuration time.Duration `bson:"avg_duration"`
+}
+
+var (
+	aggregatedHistoricalTaskDataTaskNameKey    = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "TaskName")
+	aggregatedHistoricalTaskDataVariantKey     = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "Variant")
+	aggregatedHistoricalTaskDataDateKey        = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "Date")
+	aggregatedHistoricalTaskDataNumPassKey     = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "NumPass")
+	aggregatedHistoricalTaskDataNumFailKey     = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "NumFail")
+	aggregatedHistoricalTaskDataNumTestsKey    = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "NumTests")
+	aggregatedHistoricalTaskDataAvgDurationKey = bsonutil.MustHaveTag(AggregatedHistoricalTaskData{}, "AverageDuration")
+)
+
+// AverageNumTests returns the average number of tests per task run.
+func (a AggregatedHistoricalTaskData) AverageNumTests() float64 {
+	if a.NumPass+a.NumFail == 0 {
+		return 0
+	}
+
+	return float64(a.NumTests) / float64(a.NumPass+a.NumFail)
+}
+
+// GetHistoricalTaskData queries the historical task data using a filter.
+func GetHistoricalTaskData(ctx context.Context, env cedar.Environment, filter HistoricalTaskDataFilter) ([]AggregatedHistoricalTaskData, error) {
+	err := filter.Validate()
+	if err != nil {
+		return nil, errors.Wrap(err, "the provided HistoricalTaskDataFilter is invalid")
+	}
+
+	var data []AggregatedHistoricalTaskData
+	cursor, err := env.GetDB().Collection(historicalTaskDataCollection).Aggregate(ctx, filter.queryPipeline())
+	if err != nil {
+		return nil, errors.Wrap(err, "aggregating task data")
+	}
+	if err = cursor.All(ctx, &data); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling aggregated task data")
+	}
+
+	return data, nil
+}
+
+// HistoricalTaskDataFilter represents search and aggregation parameters when
+// querying the historical task data. Only grouping by task or variant is
+// supported.
+type HistoricalTaskDataFilter struct {
+	Project    string
+	Requesters []string
+	AfterDate  time.Time
+	BeforeDate time.Time
+
+	Tasks    []string
+	Variants []string
+
+	GroupNumDays int
+	GroupBy      HTDGroupBy
+	StartAt      *HTDStartAt
+	Limit        int
+	Sort         HTDSort
+}
+
+// Validate ensures that the HistoricalTaskDataFilter is valid.
+func (f *HistoricalTaskDataFilter) Validate() error {
+	if f == nil {
+		return errors.New("historical task data filter should not be nil")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(len(f.Requesters) == 0, "missing Requesters values")
+	catcher.NewWhen(f.GroupNumDays <= 0, "invalid GroupNumDays value")
+	catcher.NewWhen(f.Limit > htdMaxQueryLimit || f.Limit <= 0, "invalid Limit value")
+	catcher.NewWhen(len(f.Tasks) == 0, "missing Tasks values")
+	catcher.NewWhen(f.GroupBy != HTDGroupByTask && f.GroupBy != HTDGroupByVariant, "invalid GroupBy value")
+	catcher.Add(f.Sort.validate())
+	if f.StartAt != nil {
+		catcher.NewWhen(!f.StartAt.Date.Equal(utility.GetUTCDay(f.StartAt.Date)), "invalid StartAt Date value")
+		catcher.NewWhen(len(f.StartAt.Task) == 0, "missing StartAt Task value")
+		catcher.NewWhen(f.GroupBy == HTDGroupByVariant && len(f.StartAt.Variant) == 0, "missing StartAt Variant value")
+	}
+	catcher.NewWhen(!f.AfterDate.Equal(utility.GetUTCDay(f.AfterDate)), "invalid AfterDate value")
+	catcher.NewWhen(!f.BeforeDate.Equal(utility.GetUTCDay(f.BeforeDate)), "invalid BeforeDate value")
+	catcher.NewWhen(!f.BeforeDate.After(f.AfterDate), "invalid AfterDate/BeforeDate values")
+
+	return catcher.Resolve()
+}
+
+// queryPipeline creates an aggregation pipeline to query historical task
+// data.
+func (f HistoricalTaskDataFilter) queryPipeline() []bson.M {
+	id := bson.M{
+		aggregatedHistoricalTaskDataDateKey:     "$" + historicalTaskDataInfoDateKey,
+		aggregatedHistoricalTaskDataTaskNameKey: "$" + bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoTaskNameKey),
+	}
+	if f.GroupBy == HTDGroupByVariant {
+		id[aggregatedHistoricalTaskDataVariantKey] = "$" + bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoVariantKey)
+	}
+
+	return []bson.M{
+		f.buildMatchStage(),
+		buildAddFieldsDateStage(
+			historicalTaskDataInfoDateKey,
+			bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey),
+			f.AfterDate,
+			f.BeforeDate,
+			f.GroupNumDays,
+		),
+		{"$group": bson.M{
+			"_id":                                   id,
+			aggregatedHistoricalTaskDataNumPassKey:  bson.M{"$sum": "$" + historicalTaskDataNumPassKey},
+			aggregatedHistoricalTaskDataNumFailKey:  bson.M{"$sum": "$" + historicalTaskDataNumFailKey},
+			aggregatedHistoricalTaskDataNumTestsKey: bson.M{"$sum": "$" + historicalTaskDataNumTestsKey},
+			"total_duration_pass":                   bson.M{"$sum": "$" + historicalTaskDataTotalDurationPassKey},
+		}},
+		{"$project": bson.M{
+			aggregatedHistoricalTaskDataTaskNameKey: "$_id." + aggregatedHistoricalTaskDataTaskNameKey,
+			aggregatedHistoricalTaskDataVariantKey:  "$_id." + aggregatedHistoricalTaskDataVariantKey,
+			aggregatedHistoricalTaskDataDateKey:     "$_id." + aggregatedHistoricalTaskDataDateKey,
+			aggregatedHistoricalTaskDataNumPassKey:  1,
+			aggregatedHistoricalTaskDataNumFailKey:  1,
+			aggregatedHistoricalTaskDataNumTestsKey: 1,
+			aggregatedHistoricalTaskDataAvgDurationKey: bson.M{"$toLong": bson.M{
+				"$cond": bson.M{
+					"if":   bson.M{"$ne": []interface{}{"$" + aggregatedHistoricalTaskDataNumPassKey, 0}},
+					"then": bson.M{"$divide": []interface{}{"$total_duration_pass", "$" + aggregatedHistoricalTaskDataNumPassKey}},
+					"else": nil,
+				},
+			}},
+		}},
+		{"$sort": bson.D{
+			{Key: aggregatedHistoricalTaskDataDateKey, Value: sortDateOrder(f.Sort)},
+			{Key: aggregatedHistoricalTaskDataVariantKey, Value: 1},
+			{Key: aggregatedHistoricalTaskDataTaskNameKey, Value: 1},
+		}},
+		{"$limit": f.Limit},
+	}
+}
+
+// buildMatchStage builds the match stage of the query pipeline based on the
+// filter options.
+func (f HistoricalTaskDataFilter) buildMatchStage() bson.M {
+	match := bson.M{
+		bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey): bson.M{
+			"$gte": f.AfterDate,
+			"$lt":  f.BeforeDate,
+		},
+		bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoProjectKey):     f.Project,
+		bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoRequestTypeKey): bson.M{"$in": f.Requesters},
+		// Match the display task name, like the historical test data,
+		// as well as the task name itself.
+		"$and": []bson.M{{"$or": []bson.M{
+			{bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoTaskNameKey): bson.M{"$in": f.Tasks}},
+			{historicalTaskDataDisplayTaskNameKey: bson.M{"$in": f.Tasks}},
+		}}},
+	}
+	if len(f.Variants) > 0 {
+		match[bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoVariantKey)] = bson.M{"$in": f.Variants}
+	}
+
+	if f.StartAt != nil {
+		match["$or"] = f.buildPaginationOrBranches()
+	}
+
+	return bson.M{"$match": match}
+}
+
+// buildPaginationOrBranches builds an expression for the conditions imposed
+// by the filter StartAt field.
+func (f HistoricalTaskDataFilter) buildPaginationOrBranches() []bson.M {
+	var nextDate interface{}
+	if f.GroupNumDays > 1 {
+		numDays := time.Duration(f.GroupNumDays) * 24 * time.Hour
+		if f.Sort == HTDSortLatestFirst {
+			nextDate = f.StartAt.Date.Add(-numDays)
+		} else {
+			nextDate = f.StartAt.Date.Add(numDays)
+		}
+	}
+
+	fields := []htdPaginationField{
+		{
+			field:      bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey),
+			descending: f.Sort == HTDSortLatestFirst,
+			strict:     true,
+			value:      f.StartAt.Date,
+			nextValue:  nextDate,
+		},
+	}
+	if f.GroupBy == HTDGroupByVariant {
+		fields = append(fields, htdPaginationField{
+			field:  bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoVariantKey),
+			strict: true,
+			value:  f.StartAt.Variant,
+		})
+	}
+	fields = append(fields, htdPaginationField{
+		field:  bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoTaskNameKey),
+		strict: false,
+		value:  f.StartAt.Task,
+	})
+
+	return buildPaginationOrBranches(fields)
+}
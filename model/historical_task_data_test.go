@@ -0,0 +1,219 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCreateHistoricalTaskData(t *testing.T) {
+	info := HistoricalTaskDataInfo{
+		Project:     "project",
+		Variant:     "variant",
+		TaskName:    "task_name",
+		RequestType: "request_type",
+		Date:        time.Now(),
+	}
+
+	t.Run("MissingFields", func(t *testing.T) {
+		for _, invalid := range []HistoricalTaskDataInfo{
+			{Variant: "variant", TaskName: "task_name", RequestType: "request_type", Date: info.Date},
+			{Project: "project", TaskName: "task_name", RequestType: "request_type", Date: info.Date},
+			{Project: "project", Variant: "variant", RequestType: "request_type", Date: info.Date},
+			{Project: "project", Variant: "variant", TaskName: "task_name", Date: info.Date},
+			{Project: "project", Variant: "variant", TaskName: "task_name", RequestType: "request_type"},
+		} {
+			htd, err := CreateHistoricalTaskData(invalid)
+			assert.Nil(t, htd)
+			assert.Error(t, err)
+		}
+	})
+	t.Run("ValidInfo", func(t *testing.T) {
+		htd, err := CreateHistoricalTaskData(info)
+		require.NoError(t, err)
+		assert.Equal(t, info.ID(), htd.ID)
+		assert.True(t, htd.Info.Date.Equal(utility.GetUTCDay(info.Date)))
+		assert.False(t, htd.IsNil())
+	})
+}
+
+func TestHistoricalTaskDataUpdate(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(historicalTaskDataCollection).Drop(ctx))
+	}()
+	info := HistoricalTaskDataInfo{
+		Project:     "project",
+		Variant:     "variant",
+		TaskName:    "task_name",
+		RequestType: "request_type",
+		Date:        time.Now(),
+	}
+
+	t.Run("NoEnv", func(t *testing.T) {
+		htd, err := CreateHistoricalTaskData(info)
+		require.NoError(t, err)
+
+		assert.Error(t, htd.Update(ctx, TestResultsStats{}, time.Minute))
+	})
+	t.Run("Unpopulated", func(t *testing.T) {
+		htd, err := CreateHistoricalTaskData(info)
+		require.NoError(t, err)
+		htd.Setup(env)
+		htd.populated = false
+
+		assert.Error(t, htd.Update(ctx, TestResultsStats{}, time.Minute))
+	})
+	t.Run("UpsertAndUpdate", func(t *testing.T) {
+		htd, err := CreateHistoricalTaskData(info)
+		require.NoError(t, err)
+		htd.Setup(env)
+		htd.DisplayTaskName = "display_task"
+
+		require.NoError(t, htd.Update(ctx, TestResultsStats{TotalCount: 10}, time.Minute))
+		require.NoError(t, htd.Update(ctx, TestResultsStats{TotalCount: 12, FailedCount: 2}, time.Hour))
+		require.NoError(t, htd.Update(ctx, TestResultsStats{TotalCount: 8}, 3*time.Minute))
+
+		actual := &HistoricalTaskData{}
+		require.NoError(t, db.Collection(historicalTaskDataCollection).FindOne(ctx, bson.M{"_id": htd.ID}).Decode(actual))
+		assert.Equal(t, 2, actual.NumPass)
+		assert.Equal(t, 1, actual.NumFail)
+		assert.Equal(t, 30, actual.NumTests)
+		assert.Equal(t, 4*time.Minute, actual.TotalDurationPass)
+		assert.Equal(t, "display_task", actual.DisplayTaskName)
+		assert.True(t, time.Since(actual.LastUpdate) <= time.Second)
+
+		found := &HistoricalTaskData{ID: htd.ID}
+		found.Setup(env)
+		require.NoError(t, found.Find(ctx))
+		assert.Equal(t, actual.NumTests, found.NumTests)
+	})
+}
+
+func TestGetHistoricalTaskData(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(historicalTaskDataCollection).Drop(ctx))
+	}()
+
+	for _, d := range []struct {
+		variant     string
+		task        string
+		displayTask string
+		date        time.Time
+		stats       TestResultsStats
+		duration    time.Duration
+	}{
+		{variant: "v1", task: "task1", date: day1, stats: TestResultsStats{TotalCount: 10}, duration: time.Minute},
+		{variant: "v1", task: "task1", date: day1, stats: TestResultsStats{TotalCount: 10, FailedCount: 5}, duration: time.Hour},
+		{variant: "v2", task: "task1", date: day1, stats: TestResultsStats{TotalCount: 20}, duration: 3 * time.Minute},
+		{variant: "v1", task: "task2", date: day1, stats: TestResultsStats{TotalCount: 4}, duration: time.Minute},
+		{variant: "v1", task: "task1", date: day2, stats: TestResultsStats{TotalCount: 10}, duration: time.Minute},
+		{variant: "v1", task: "task3", displayTask: "display", date: day2, stats: TestResultsStats{TotalCount: 10}, duration: time.Minute},
+	} {
+		htd, err := CreateHistoricalTaskData(HistoricalTaskDataInfo{
+			Project:     "p1",
+			Variant:     d.variant,
+			TaskName:    d.task,
+			RequestType: "r1",
+			Date:        d.date,
+		})
+		require.NoError(t, err)
+		htd.Setup(env)
+		htd.DisplayTaskName = d.displayTask
+		require.NoError(t, htd.Update(ctx, d.stats, d.duration))
+	}
+	getFilter := func() HistoricalTaskDataFilter {
+		return HistoricalTaskDataFilter{
+			Project:      "p1",
+			Requesters:   []string{"r1"},
+			AfterDate:    day1,
+			BeforeDate:   day8,
+			Tasks:        []string{"task1", "task2"},
+			GroupNumDays: 1,
+			GroupBy:      HTDGroupByTask,
+			Sort:         HTDSortEarliestFirst,
+			Limit:        htdMaxQueryLimit,
+		}
+	}
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		for _, modify := range []func(*HistoricalTaskDataFilter){
+			func(f *HistoricalTaskDataFilter) { f.Tasks = nil },
+			func(f *HistoricalTaskDataFilter) { f.Requesters = nil },
+			func(f *HistoricalTaskDataFilter) { f.GroupBy = HTDGroupByTest },
+			func(f *HistoricalTaskDataFilter) { f.Limit = 0 },
+			func(f *HistoricalTaskDataFilter) { f.BeforeDate = f.AfterDate },
+			func(f *HistoricalTaskDataFilter) { f.StartAt = &HTDStartAt{Date: day1} },
+		} {
+			filter := getFilter()
+			modify(&filter)
+			_, err := GetHistoricalTaskData(ctx, env, filter)
+			assert.Error(t, err)
+		}
+	})
+	t.Run("GroupByTask", func(t *testing.T) {
+		data, err := GetHistoricalTaskData(ctx, env, getFilter())
+		require.NoError(t, err)
+		assert.Equal(t, []AggregatedHistoricalTaskData{
+			{TaskName: "task1", Date: day1, NumPass: 2, NumFail: 1, NumTests: 40, AverageDuration: 2 * time.Minute},
+			{TaskName: "task2", Date: day1, NumPass: 1, NumTests: 4, AverageDuration: time.Minute},
+			{TaskName: "task1", Date: day2, NumPass: 1, NumTests: 10, AverageDuration: time.Minute},
+		}, data)
+		assert.Equal(t, float64(40)/3, data[0].AverageNumTests())
+	})
+	t.Run("GroupByVariant", func(t *testing.T) {
+		filter := getFilter()
+		filter.GroupBy = HTDGroupByVariant
+		filter.Variants = []string{"v1"}
+		filter.Tasks = []string{"task1"}
+		data, err := GetHistoricalTaskData(ctx, env, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []AggregatedHistoricalTaskData{
+			{TaskName: "task1", Variant: "v1", Date: day1, NumPass: 1, NumFail: 1, NumTests: 20, AverageDuration: time.Minute},
+			{TaskName: "task1", Variant: "v1", Date: day2, NumPass: 1, NumTests: 10, AverageDuration: time.Minute},
+		}, data)
+	})
+	t.Run("DisplayTaskName", func(t *testing.T) {
+		filter := getFilter()
+		filter.Tasks = []string{"display"}
+		data, err := GetHistoricalTaskData(ctx, env, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []AggregatedHistoricalTaskData{
+			{TaskName: "task3", Date: day2, NumPass: 1, NumTests: 10, AverageDuration: time.Minute},
+		}, data)
+	})
+	t.Run("GroupNumDays", func(t *testing.T) {
+		filter := getFilter()
+		filter.GroupNumDays = 7
+		filter.Sort = HTDSortLatestFirst
+		data, err := GetHistoricalTaskData(ctx, env, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []AggregatedHistoricalTaskData{
+			{TaskName: "task1", Date: day1, NumPass: 3, NumFail: 1, NumTests: 50, AverageDuration: 5 * time.Minute / 3},
+			{TaskName: "task2", Date: day1, NumPass: 1, NumTests: 4, AverageDuration: time.Minute},
+		}, data)
+	})
+	t.Run("Pagination", func(t *testing.T) {
+		filter := getFilter()
+		filter.StartAt = &HTDStartAt{Date: day1, Task: "task2"}
+		data, err := GetHistoricalTaskData(ctx, env, filter)
+		require.NoError(t, err)
+		assert.Equal(t, []AggregatedHistoricalTaskData{
+			{TaskName: "task2", Date: day1, NumPass: 1, NumTests: 4, AverageDuration: time.Minute},
+			{TaskName: "task1", Date: day2, NumPass: 1, NumTests: 10, AverageDuration: time.Minute},
+		}, data)
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -28,19 +29,27 @@ type HistoricalTestData struct {
 	NumPass         int                    `bson:"num_pass"`
 	NumFail         int                    `bson:"num_fail"`
 	AverageDuration time.Duration          `bson:"average_duration"`
-	LastUpdate      time.Time              `bson:"last_update"`
+	DurationDigest  *DurationDigest        `bson:"duration_digest,omitempty"`
+	// PendingDurations are the durations of passes not yet merged into
+	// the duration digest.
+	PendingDurations []time.Duration `bson:"pending_durations,omitempty"`
+	Team             string          `bson:"team,omitempty"`
+	LastUpdate       time.Time       `bson:"last_update"`
 
 	env       cedar.Environment
 	populated bool
 }
 
 var (
-	historicalTestDataIDKey              = bsonutil.MustHaveTag(HistoricalTestData{}, "ID")
-	historicalTestDataInfoKey            = bsonutil.MustHaveTag(HistoricalTestData{}, "Info")
-	historicalTestDataNumPassKey         = bsonutil.MustHaveTag(HistoricalTestData{}, "NumPass")
-	historicalTestDataNumFailKey         = bsonutil.MustHaveTag(HistoricalTestData{}, "NumFail")
-	historicalTestDataAverageDurationKey = bsonutil.MustHaveTag(HistoricalTestData{}, "AverageDuration")
-	historicalTestDataLastUpdateKey      = bsonutil.MustHaveTag(HistoricalTestData{}, "LastUpdate")
+	historicalTestDataIDKey               = bsonutil.MustHaveTag(HistoricalTestData{}, "ID")
+	historicalTestDataInfoKey             = bsonutil.MustHaveTag(HistoricalTestData{}, "Info")
+	historicalTestDataNumPassKey          = bsonutil.MustHaveTag(HistoricalTestData{}, "NumPass")
+	historicalTestDataNumFailKey          = bsonutil.MustHaveTag(HistoricalTestData{}, "NumFail")
+	historicalTestDataAverageDurationKey  = bsonutil.MustHaveTag(HistoricalTestData{}, "AverageDuration")
+	historicalTestDataDurationDigestKey   = bsonutil.MustHaveTag(HistoricalTestData{}, "DurationDigest")
+	historicalTestDataPendingDurationsKey = bsonutil.MustHaveTag(HistoricalTestData{}, "PendingDurations")
+	historicalTestDataTeamKey             = bsonutil.MustHaveTag(HistoricalTestData{}, "Team")
+	historicalTestDataLastUpdateKey       = bsonutil.MustHaveTag(HistoricalTestData{}, "LastUpdate")
 )
 
 // CreateHistoricalTestData is an entry point for creating a new
@@ -107,8 +116,15 @@ func (d *HistoricalTestData) Update(ctx context.Context, result TestResult) erro
 		historicalTestDataIDKey:   d.ID,
 		historicalTestDataInfoKey: d.Info,
 	}
+	var team interface{} = "$$REMOVE"
+	if d.Team != "" {
+		team = d.Team
+	}
 	pipeline := []bson.M{
-		{"$set": bson.M{historicalTestDataLastUpdateKey: "$$NOW"}},
+		{"$set": bson.M{
+			historicalTestDataLastUpdateKey: "$$NOW",
+			historicalTestDataTeamKey:       team,
+		}},
 	}
 	switch result.Status {
 	case "pass":
@@ -160,14 +176,31 @@ func (d *HistoricalTestData) Update(ctx context.Context, result TestResult) erro
 		)
 	}
 
-	err := d.env.GetDB().Collection(historicalTestDataCollection).FindOneAndUpdate(
-		ctx,
-		query,
-		pipeline,
-		options.FindOneAndUpdate().SetUpsert(true),
-		options.FindOneAndUpdate().SetReturnDocument(options.After),
-		options.FindOneAndUpdate().SetMaxTime(time.Minute),
-	).Decode(d)
+	if result.Status == "pass" {
+		// The duration digest cannot be merged by the database, so
+		// the duration is buffered in the same update as the counts
+		// and merged into the digest later.
+		pipeline = append(pipeline, bson.M{"$set": bson.M{
+			historicalTestDataPendingDurationsKey: bson.M{"$concatArrays": []interface{}{
+				bson.M{"$ifNull": []interface{}{fmt.Sprintf("$%s", historicalTestDataPendingDurationsKey), bson.A{}}},
+				bson.A{result.TestEndTime.Sub(result.TestStartTime)},
+			}},
+		}})
+	}
+
+	d.DurationDigest = nil
+	d.PendingDurations = nil
+	updateOpts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetMaxTime(time.Minute)
+	coll := d.env.GetDB().Collection(historicalTestDataCollection)
+	err := coll.FindOneAndUpdate(ctx, query, pipeline, updateOpts).Decode(d)
+	if mongo.IsDuplicateKeyError(err) {
+		// The document was concurrently created, so it exists now
+		// and the update can no longer conflict.
+		err = coll.FindOneAndUpdate(ctx, query, pipeline, updateOpts).Decode(d)
+	}
 	grip.DebugWhen(err == nil, message.Fields{
 		"collection": historicalTestDataCollection,
 		"id":         d.ID,
@@ -182,6 +215,96 @@ func (d *HistoricalTestData) Update(ctx context.Context, result TestResult) erro
 	return nil
 }
 
+// CompactHistoricalTestDataDigests merges the pending durations of the
+// historical test data dated on or after the given time into the duration
+// digests and returns the number of compacted documents. Documents
+// concurrently compacted by another caller are skipped.
+func CompactHistoricalTestDataDigests(ctx context.Context, env cedar.Environment, since time.Time) (int, error) {
+	coll := env.GetDB().Collection(historicalTestDataCollection)
+	cur, err := coll.Find(ctx,
+		bson.M{
+			bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoDateKey): bson.M{"$gte": utility.GetUTCDay(since)},
+			historicalTestDataPendingDurationsKey:                                               bson.M{"$exists": true, "$ne": bson.A{}},
+		},
+		options.Find().SetProjection(bson.M{
+			historicalTestDataDurationDigestKey:   1,
+			historicalTestDataPendingDurationsKey: 1,
+		}),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "finding historical test data with pending durations")
+	}
+	defer cur.Close(ctx)
+
+	count := 0
+	skipped := 0
+	for cur.Next(ctx) {
+		compacted, err := compactHistoricalTestDataDigest(ctx, coll, cur.Current)
+		if err != nil {
+			return count, err
+		}
+		if compacted {
+			count++
+		} else {
+			skipped++
+		}
+	}
+	if err = cur.Err(); err != nil {
+		return count, errors.Wrap(err, "iterating historical test data with pending durations")
+	}
+	grip.WarningWhen(skipped > 0, message.Fields{
+		"message":    "skipped concurrently compacted historical test data",
+		"collection": historicalTestDataCollection,
+		"skipped":    skipped,
+		"compacted":  count,
+	})
+
+	return count, nil
+}
+
+// compactHistoricalTestDataDigest merges the pending durations of the given
+// historical test data document into its duration digest. Durations added
+// after the document was read are kept pending. It returns false if the
+// digest was concurrently modified.
+func compactHistoricalTestDataDigest(ctx context.Context, coll *mongo.Collection, raw bson.Raw) (bool, error) {
+	doc := &HistoricalTestData{}
+	if err := bson.Unmarshal(raw, doc); err != nil {
+		return false, errors.Wrap(err, "decoding historical test data")
+	}
+
+	digest := NewDurationDigest()
+	if err := digest.Merge(doc.DurationDigest); err != nil {
+		return false, errors.Wrapf(err, "merging duration digest of historical test data '%s'", doc.ID)
+	}
+	for _, dur := range doc.PendingDurations {
+		if err := digest.Add(dur); err != nil {
+			return false, errors.Wrapf(err, "adding pending duration of historical test data '%s'", doc.ID)
+		}
+	}
+
+	query := bson.M{
+		historicalTestDataIDKey:             doc.ID,
+		historicalTestDataDurationDigestKey: bson.M{"$exists": false},
+	}
+	if stored, err := raw.LookupErr(historicalTestDataDurationDigestKey); err == nil {
+		query[historicalTestDataDurationDigestKey] = stored
+	}
+	pending := fmt.Sprintf("$%s", historicalTestDataPendingDurationsKey)
+	res, err := coll.UpdateOne(ctx, query, []bson.M{{"$set": bson.M{
+		historicalTestDataDurationDigestKey: bson.M{"$literal": digest},
+		historicalTestDataPendingDurationsKey: bson.M{"$slice": []interface{}{
+			pending,
+			len(doc.PendingDurations),
+			bson.M{"$max": []interface{}{bson.M{"$size": pending}, 1}},
+		}},
+	}}})
+	if err != nil {
+		return false, errors.Wrapf(err, "compacting duration digest of historical test data '%s'", doc.ID)
+	}
+
+	return res.MatchedCount > 0, nil
+}
+
 // Remove deletes the HistoricalTestData file from DB. The environment
 // should not be nil.
 func (d *HistoricalTestData) Remove(ctx context.Context) error {
@@ -204,6 +327,242 @@ func (d *HistoricalTestData) Remove(ctx context.Context) error {
 	return errors.Wrapf(err, "removing historical test data record '%s'", d.ID)
 }
 
+// NewHistoricalTestDataInfo returns the info of the historical test data that
+// the test result of the test results record contributes to. Tests of
+// execution tasks are attributed to their display task.
+func NewHistoricalTestDataInfo(record *TestResults, result TestResult) HistoricalTestDataInfo {
+	taskName := record.Info.DisplayTaskName
+	if taskName == "" {
+		taskName = record.Info.TaskName
+	}
+
+	return HistoricalTestDataInfo{
+		Project:     record.Info.Project,
+		Variant:     record.Info.Variant,
+		TaskName:    taskName,
+		TestName:    result.GetDisplayName(),
+		RequestType: record.Info.RequestType,
+		Date:        result.TestEndTime,
+	}
+}
+
+// RecomputeHistoricalTestDataOptions specify the project and date range of
+// the historical test data to recompute. The dates are rounded to UTC days
+// and the end day is exclusive unless the end time falls within it.
+type RecomputeHistoricalTestDataOptions struct {
+	Project string
+	StartAt time.Time
+	EndAt   time.Time
+}
+
+// Validate ensures the options specify a project and a valid date range.
+func (o *RecomputeHistoricalTestDataOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.Project == "", "must specify a project")
+	catcher.NewWhen(o.StartAt.IsZero(), "must specify a start time")
+	catcher.NewWhen(o.EndAt.IsZero(), "must specify an end time")
+	catcher.NewWhen(o.StartAt.After(o.EndAt), "start time cannot be after end time")
+
+	return catcher.Resolve()
+}
+
+// historicalTestDataRecomputeBuffer is how long before the start of the date
+// range test results records are searched for results that ended within the
+// range, since records are created before their tests end.
+const historicalTestDataRecomputeBuffer = 24 * time.Hour
+
+// RecomputeHistoricalTestData rebuilds the project's historical test and task
+// data for the days in the date range from the stored test results, one day at
+// a time, replacing the existing documents for those days. Test results records with
+// historical data disabled are skipped. Recomputing is idempotent, but updates
+// made concurrently by newly arriving test results for the same days may be
+// lost. It returns the number of historical test data documents written.
+func RecomputeHistoricalTestData(ctx context.Context, env cedar.Environment, opts RecomputeHistoricalTestDataOptions) (int, error) {
+	if env == nil {
+		return 0, errors.New("cannot recompute historical test data with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return 0, errors.Wrap(err, "invalid options")
+	}
+
+	startDay := utility.GetUTCDay(opts.StartAt)
+	endDay := utility.GetUTCDay(opts.EndAt)
+	if endDay.Before(opts.EndAt) {
+		endDay = endDay.Add(24 * time.Hour)
+	}
+
+	ownership, err := FindTestOwnership(ctx, env, opts.Project)
+	if err != nil {
+		return 0, errors.Wrap(err, "finding test ownership")
+	}
+
+	var count int
+	for day := startDay; day.Before(endDay); day = day.Add(24 * time.Hour) {
+		n, err := recomputeHistoricalTestDataDay(ctx, env, opts.Project, ownership, day)
+		if err != nil {
+			return count, errors.Wrapf(err, "recomputing historical test data for %s", day.Format(HistoricalTestDataDateFormat))
+		}
+		count += n
+	}
+
+	return count, nil
+}
+
+// recomputeHistoricalTestDataDay rebuilds the project's historical test and
+// task data for a single UTC day. The documents are upserted before the stale ones for
+// the day are removed, so the day's data is never missing while it is
+// recomputed.
+func recomputeHistoricalTestDataDay(ctx context.Context, env cedar.Environment, project string, ownership *TestOwnership, day time.Time) (int, error) {
+	end := day.Add(24 * time.Hour)
+	records, err := FindTestResultsByProject(ctx, env, FindTestResultsByProjectOptions{
+		Projects: []string{project},
+		StartAt:  day.Add(-historicalTestDataRecomputeBuffer),
+		EndAt:    end,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "finding test results")
+	}
+
+	type aggregate struct {
+		data          *HistoricalTestData
+		totalDuration time.Duration
+	}
+	aggregates := map[string]*aggregate{}
+	taskData := map[string]*HistoricalTaskData{}
+	for i := range records {
+		if records[i].Info.HistoricalDataDisabled {
+			continue
+		}
+		results, err := records[i].Download(ctx)
+		if err != nil {
+			return 0, errors.Wrapf(err, "downloading test results '%s'", records[i].ID)
+		}
+
+		if completedAt := records[i].CompletedAt; !completedAt.Before(day) && completedAt.Before(end) {
+			// Match the handling of HistoricalTaskData.Update.
+			data, err := CreateHistoricalTaskData(NewHistoricalTaskDataInfo(&records[i]))
+			if err != nil {
+				grip.Debug(message.WrapError(err, message.Fields{
+					"message":         "skipping invalid historical task data",
+					"test_results_id": records[i].ID,
+				}))
+			} else {
+				if existing, ok := taskData[data.ID]; ok {
+					data = existing
+				}
+				taskData[data.ID] = data
+				if records[i].Info.DisplayTaskName != "" {
+					data.DisplayTaskName = records[i].Info.DisplayTaskName
+				}
+				data.NumTests += records[i].Stats.TotalCount
+				if records[i].Stats.FailedCount > 0 {
+					data.NumFail++
+				} else {
+					data.NumPass++
+					data.TotalDurationPass += TestResultsRuntime(results)
+				}
+			}
+		}
+
+		for _, result := range results {
+			if result.TestEndTime.Before(day) || !result.TestEndTime.Before(end) {
+				continue
+			}
+			data, err := CreateHistoricalTestData(NewHistoricalTestDataInfo(&records[i], result))
+			if err != nil {
+				grip.Debug(message.WrapError(err, message.Fields{
+					"message":         "skipping invalid historical test data",
+					"test_results_id": records[i].ID,
+					"test_name":       result.GetDisplayName(),
+				}))
+				continue
+			}
+
+			agg, ok := aggregates[data.ID]
+			if !ok {
+				data.Team = ownership.TeamFor(data.Info.TestName)
+				agg = &aggregate{data: data}
+				aggregates[data.ID] = agg
+			}
+			// Match the status handling of Update.
+			switch result.Status {
+			case "pass":
+				agg.data.NumPass++
+				agg.totalDuration += result.TestEndTime.Sub(result.TestStartTime)
+				agg.data.AverageDuration = agg.totalDuration / time.Duration(agg.data.NumPass)
+				if agg.data.DurationDigest == nil {
+					agg.data.DurationDigest = NewDurationDigest()
+				}
+				if err = agg.data.DurationDigest.Add(result.TestEndTime.Sub(result.TestStartTime)); err != nil {
+					return 0, errors.Wrapf(err, "adding duration of test '%s'", result.GetDisplayName())
+				}
+			case "fail", "silentfail":
+				agg.data.NumFail++
+			}
+		}
+	}
+
+	now := time.Now()
+	testDocs := make(map[string]interface{}, len(aggregates))
+	for id, agg := range aggregates {
+		agg.data.LastUpdate = now
+		testDocs[id] = agg.data
+	}
+	if err = replaceHistoricalDataDay(ctx, env.GetDB().Collection(historicalTestDataCollection), project, day, testDocs); err != nil {
+		return 0, errors.Wrap(err, "replacing historical test data")
+	}
+	taskDocs := make(map[string]interface{}, len(taskData))
+	for id, data := range taskData {
+		data.LastUpdate = now
+		taskDocs[id] = data
+	}
+	if err = replaceHistoricalDataDay(ctx, env.GetDB().Collection(historicalTaskDataCollection), project, day, taskDocs); err != nil {
+		return 0, errors.Wrap(err, "replacing historical task data")
+	}
+	grip.Info(message.Fields{
+		"collection":     historicalTestDataCollection,
+		"project":        project,
+		"day":            day,
+		"records":        len(records),
+		"documents":      len(testDocs),
+		"task_documents": len(taskDocs),
+		"op":             "recompute historical test data",
+	})
+
+	return len(testDocs), nil
+}
+
+// replaceHistoricalDataDay upserts the historical data documents, keyed by
+// ID, into the collection and then removes the project's other documents for
+// the UTC day. Both historical test and task data documents have the same
+// info project and date fields.
+func replaceHistoricalDataDay(ctx context.Context, coll *mongo.Collection, project string, day time.Time, docs map[string]interface{}) error {
+	ids := make([]string, 0, len(docs))
+	models := make([]mongo.WriteModel, 0, len(docs))
+	for id, doc := range docs {
+		ids = append(ids, id)
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	if len(models) > 0 {
+		if _, err := coll.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return errors.Wrapf(err, "writing documents for project '%s'", project)
+		}
+	}
+	_, err := coll.DeleteMany(ctx, bson.M{
+		bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoProjectKey): project,
+		bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoDateKey): bson.M{
+			"$gte": day,
+			"$lt":  day.Add(24 * time.Hour),
+		},
+		"_id": bson.M{"$nin": ids},
+	})
+
+	return errors.Wrapf(err, "removing stale documents for project '%s'", project)
+}
+
 // HistoricalTestDataDateFormat represents the standard timestamp format for
 // historical test data, which is rounded to the nearest day (YYYY-MM-DD).
 const HistoricalTestDataDateFormat = "2006-01-02"
@@ -271,10 +630,17 @@ type AggregatedHistoricalTestData struct {
 	TaskName string    `bson:"task_name,omitempty"`
 	Variant  string    `bson:"variant,omitempty"`
 	Date     time.Time `bson:"date"`
+	Team     string    `bson:"team,omitempty"`
 
 	NumPass         int           `bson:"num_pass"`
 	NumFail         int           `bson:"num_fail"`
 	AverageDuration time.Duration `bson:"avg_duration"`
+
+	// The duration percentiles of the passing tests, estimated by
+	// merging the duration digests of the grouped documents.
+	P50Duration time.Duration `bson:"-"`
+	P90Duration time.Duration `bson:"-"`
+	P99Duration time.Duration `bson:"-"`
 }
 
 var (
@@ -282,6 +648,7 @@ var (
 	aggregatedHistoricalTestDataTaskNameKey    = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "TaskName")
 	aggregatedHistoricalTestDataVariantKey     = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "Variant")
 	aggregatedHistoricalTestDataDateKey        = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "Date")
+	aggregatedHistoricalTestDataTeamKey        = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "Team")
 	aggregatedHistoricalTestDataNumPassKey     = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "NumPass")
 	aggregatedHistoricalTestDataNumFailKey     = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "NumFail")
 	aggregatedHistoricalTestDataAvgDurationKey = bsonutil.MustHaveTag(AggregatedHistoricalTestData{}, "AverageDuration")
@@ -304,9 +671,118 @@ func GetHistoricalTestData(ctx context.Context, env cedar.Environment, filter Hi
 		return nil, errors.Wrap(err, "unmarshalling aggregated test data")
 	}
 
+	if err = filter.setDurationPercentiles(ctx, env, data); err != nil {
+		return nil, errors.Wrap(err, "estimating duration percentiles")
+	}
+
 	return data, nil
 }
 
+// htdGroupKey identifies the group of aggregated historical test data a
+// document belongs to.
+type htdGroupKey struct {
+	date     time.Time
+	variant  string
+	taskName string
+	testName string
+}
+
+// groupKey returns the key of the group the historical test data document
+// with the given info belongs to.
+func (f HistoricalTestDataFilter) groupKey(info HistoricalTestDataInfo, boundaries []time.Time) htdGroupKey {
+	key := htdGroupKey{date: info.Date}
+	if f.GroupNumDays > 1 {
+		for _, boundary := range boundaries {
+			if info.Date.Before(boundary) {
+				break
+			}
+			key.date = boundary
+		}
+	}
+	switch f.GroupBy {
+	case HTDGroupByVariant:
+		key.variant = info.Variant
+		fallthrough
+	case HTDGroupByTask:
+		key.taskName = info.TaskName
+		fallthrough
+	case HTDGroupByTest:
+		key.testName = info.TestName
+	}
+
+	return key
+}
+
+// setDurationPercentiles estimates the duration percentiles of the aggregated
+// historical test data by merging the duration digests of the documents in
+// each group, along with the durations not yet compacted into them. The
+// digests are merged here rather than in the aggregation so the size of a
+// group is not bounded by the maximum document size.
+func (f HistoricalTestDataFilter) setDurationPercentiles(ctx context.Context, env cedar.Environment, data []AggregatedHistoricalTestData) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	groups := map[htdGroupKey]int{}
+	testNames := map[string]bool{}
+	for i, d := range data {
+		groups[htdGroupKey{date: d.Date, variant: d.Variant, taskName: d.TaskName, testName: d.TestName}] = i
+		testNames[d.TestName] = true
+	}
+
+	match := f.buildMatchStage()["$match"].(bson.M)
+	tests := make([]string, 0, len(testNames))
+	for name := range testNames {
+		tests = append(tests, name)
+	}
+	match[bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoTestNameKey)] = bson.M{"$in": tests}
+
+	cur, err := env.GetDB().Collection(historicalTestDataCollection).Find(ctx, match, options.Find().SetProjection(bson.M{
+		historicalTestDataInfoKey:             1,
+		historicalTestDataDurationDigestKey:   1,
+		historicalTestDataPendingDurationsKey: 1,
+	}))
+	if err != nil {
+		return errors.Wrap(err, "finding duration digests")
+	}
+	defer cur.Close(ctx)
+
+	boundaries := dateBoundaries(f.AfterDate, f.BeforeDate, f.GroupNumDays)
+	digests := make([]*DurationDigest, len(data))
+	for cur.Next(ctx) {
+		var doc HistoricalTestData
+		if err = cur.Decode(&doc); err != nil {
+			return errors.Wrap(err, "decoding duration digest")
+		}
+		i, ok := groups[f.groupKey(doc.Info, boundaries)]
+		if !ok {
+			continue
+		}
+		if digests[i] == nil {
+			digests[i] = NewDurationDigest()
+		}
+		if err = digests[i].Merge(doc.DurationDigest); err != nil {
+			return errors.Wrapf(err, "merging duration digest of historical test data '%s'", doc.ID)
+		}
+		for _, dur := range doc.PendingDurations {
+			if err = digests[i].Add(dur); err != nil {
+				return errors.Wrapf(err, "adding pending duration of historical test data '%s'", doc.ID)
+			}
+		}
+	}
+	if err = cur.Err(); err != nil {
+		return errors.Wrap(err, "iterating duration digests")
+	}
+
+	for i, digest := range digests {
+		data[i].P50Duration = digest.Quantile(0.5)
+		data[i].P90Duration = digest.Quantile(0.9)
+		data[i].P99Duration = digest.Quantile(0.99)
+	}
+
+	return nil
+}
+
 const htdMaxQueryLimit = 1001
 
 // HTDGroupBy represents the possible groupings of historical test data.
@@ -384,6 +860,7 @@ type HistoricalTestDataFilter struct {
 	Tests    []string
 	Tasks    []string
 	Variants []string
+	Team     string
 
 	GroupNumDays int
 	GroupBy      HTDGroupBy
@@ -403,7 +880,7 @@ func (f *HistoricalTestDataFilter) Validate() error {
 	catcher.NewWhen(f.GroupNumDays <= 0, "invalid GroupNumDays value")
 	catcher.NewWhen(f.Limit > htdMaxQueryLimit || f.Limit <= 0, "invalid Limit value")
 	catcher.AddWhen(f.StartAt != nil, f.StartAt.validate(f.GroupBy))
-	catcher.NewWhen(len(f.Tests) == 0 && len(f.Tasks) == 0, "missing Tests or Tasks values")
+	catcher.NewWhen(len(f.Tests) == 0 && len(f.Tasks) == 0 && f.Team == "", "missing Tests, Tasks, or Team values")
 	catcher.Add(f.Sort.validate())
 	catcher.Add(f.GroupBy.validate())
 	catcher.Add(f.validateDates())
@@ -435,6 +912,7 @@ func (f HistoricalTestDataFilter) queryPipeline() []bson.M {
 			"_id":                                  buildHTDGroupId(f.GroupBy),
 			aggregatedHistoricalTestDataNumPassKey: bson.M{"$sum": "$" + historicalTestDataNumPassKey},
 			aggregatedHistoricalTestDataNumFailKey: bson.M{"$sum": "$" + historicalTestDataNumFailKey},
+			aggregatedHistoricalTestDataTeamKey:    bson.M{"$max": "$" + historicalTestDataTeamKey},
 			"total_duration_pass": bson.M{
 				"$sum": bson.M{
 					"$multiply": []interface{}{
@@ -451,6 +929,7 @@ func (f HistoricalTestDataFilter) queryPipeline() []bson.M {
 			aggregatedHistoricalTestDataDateKey:     "$_id." + aggregatedHistoricalTestDataDateKey,
 			aggregatedHistoricalTestDataNumPassKey:  1,
 			aggregatedHistoricalTestDataNumFailKey:  1,
+			aggregatedHistoricalTestDataTeamKey:     1,
 			aggregatedHistoricalTestDataAvgDurationKey: bson.M{"$toLong": bson.M{
 				"$cond": bson.M{
 					"if":   bson.M{"$ne": []interface{}{"$" + aggregatedHistoricalTestDataNumPassKey, 0}},
@@ -489,6 +968,9 @@ func (f HistoricalTestDataFilter) buildMatchStage() bson.M {
 	if len(f.Variants) > 0 {
 		match[bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoVariantKey)] = bson.M{"$in": f.Variants}
 	}
+	if f.Team != "" {
+		match[historicalTestDataTeamKey] = f.Team
+	}
 
 	if f.StartAt != nil {
 		match["$or"] = f.buildTestPaginationOrBranches()
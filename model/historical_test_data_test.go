@@ -2,7 +2,10 @@ package model
 
 import (
 	"context"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -186,6 +189,7 @@ func TestHistoricalTestDataUpdate(t *testing.T) {
 		assert.Equal(t, 0, actual.NumFail)
 		assert.Equal(t, 4*time.Minute, actual.AverageDuration)
 		assert.True(t, time.Since(actual.LastUpdate) <= time.Second)
+		assert.Equal(t, []time.Duration{2 * time.Minute, 6 * time.Minute}, actual.PendingDurations)
 
 		// fail
 		tr = TestResult{
@@ -216,6 +220,105 @@ func TestHistoricalTestDataUpdate(t *testing.T) {
 		assert.Equal(t, 2, actual.NumFail)
 		assert.Equal(t, 4*time.Minute, actual.AverageDuration)
 		assert.True(t, time.Since(actual.LastUpdate) <= time.Second)
+		assert.Len(t, actual.PendingDurations, 2)
+	})
+	t.Run("ConcurrentPasses", func(t *testing.T) {
+		concurrentInfo := info
+		concurrentInfo.TestName = "concurrent"
+		now := time.Now()
+		const numUpdates = 5
+
+		var wg sync.WaitGroup
+		errs := make(chan error, numUpdates)
+		for i := 0; i < numUpdates; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				hd, err := CreateHistoricalTestData(concurrentInfo)
+				if err != nil {
+					errs <- err
+					return
+				}
+				hd.Setup(env)
+				errs <- hd.Update(ctx, TestResult{
+					Status:        "pass",
+					TestStartTime: now,
+					TestEndTime:   now.Add(time.Minute),
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		actual := &HistoricalTestData{}
+		require.NoError(t, db.Collection(historicalTestDataCollection).FindOne(ctx, bson.M{"_id": concurrentInfo.ID()}).Decode(actual))
+		assert.Equal(t, numUpdates, actual.NumPass)
+		assert.Len(t, actual.PendingDurations, numUpdates)
+	})
+}
+
+func TestCompactHistoricalTestDataDigests(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(historicalTestDataCollection).Drop(ctx))
+	}()
+
+	info := getHistoricalTestData(t).Info
+	hd, err := CreateHistoricalTestData(info)
+	require.NoError(t, err)
+	hd.Setup(env)
+	now := time.Now()
+	for _, dur := range []time.Duration{time.Minute, 3 * time.Minute} {
+		require.NoError(t, hd.Update(ctx, TestResult{Status: "pass", TestStartTime: now, TestEndTime: now.Add(dur)}))
+	}
+
+	count, err := CompactHistoricalTestDataDigests(ctx, env, info.Date)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	actual := &HistoricalTestData{}
+	require.NoError(t, db.Collection(historicalTestDataCollection).FindOne(ctx, bson.M{"_id": hd.ID}).Decode(actual))
+	assert.Empty(t, actual.PendingDurations)
+	require.NotNil(t, actual.DurationDigest)
+	assert.EqualValues(t, 2, actual.DurationDigest.Count())
+	assert.Equal(t, time.Minute, actual.DurationDigest.Quantile(0))
+	assert.Equal(t, 3*time.Minute, actual.DurationDigest.Quantile(1))
+
+	t.Run("MergesIntoExistingDigest", func(t *testing.T) {
+		require.NoError(t, hd.Update(ctx, TestResult{Status: "pass", TestStartTime: now, TestEndTime: now.Add(5 * time.Minute)}))
+
+		count, err := CompactHistoricalTestDataDigests(ctx, env, info.Date)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+
+		actual := &HistoricalTestData{}
+		require.NoError(t, db.Collection(historicalTestDataCollection).FindOne(ctx, bson.M{"_id": hd.ID}).Decode(actual))
+		assert.Empty(t, actual.PendingDurations)
+		require.NotNil(t, actual.DurationDigest)
+		assert.EqualValues(t, 3, actual.DurationDigest.Count())
+		assert.Equal(t, 5*time.Minute, actual.DurationDigest.Quantile(1))
+	})
+	t.Run("BeforeDate", func(t *testing.T) {
+		require.NoError(t, hd.Update(ctx, TestResult{Status: "pass", TestStartTime: now, TestEndTime: now.Add(time.Minute)}))
+
+		count, err := CompactHistoricalTestDataDigests(ctx, env, info.Date.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, count)
+
+		count, err = CompactHistoricalTestDataDigests(ctx, env, info.Date)
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+	t.Run("NothingPending", func(t *testing.T) {
+		count, err := CompactHistoricalTestDataDigests(ctx, env, info.Date)
+		require.NoError(t, err)
+		assert.Zero(t, count)
 	})
 }
 
@@ -256,6 +359,152 @@ func TestHistoricalTestDataRemove(t *testing.T) {
 	})
 }
 
+func TestRecomputeHistoricalTestData(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "recompute-htd-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(historicalTestDataCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(historicalTaskDataCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		Bucket:    BucketConfig{TestResultsBucket: tmpDir},
+		populated: true,
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	day := utility.GetUTCDay(time.Now())
+	info := TestResultsInfo{
+		Project:     "project",
+		Variant:     "variant",
+		TaskName:    "task",
+		TaskID:      "task1",
+		RequestType: "patch",
+	}
+	record := CreateTestResults(info, PailLocal)
+	record.Setup(env)
+	require.NoError(t, record.SaveNew(ctx))
+	require.NoError(t, record.Append(ctx, []TestResult{
+		{TestName: "A", Status: "pass", TestStartTime: day.Add(time.Hour - 2*time.Second), TestEndTime: day.Add(time.Hour)},
+		{TestName: "A", Status: "pass", TestStartTime: day.Add(2*time.Hour - 4*time.Second), TestEndTime: day.Add(2 * time.Hour)},
+		{TestName: "B", Status: "fail", TestStartTime: day.Add(time.Hour - time.Second), TestEndTime: day.Add(time.Hour)},
+		{TestName: "C", Status: "pass", TestStartTime: day.Add(-time.Hour - time.Second), TestEndTime: day.Add(-time.Hour)},
+	}))
+	_, err = record.Close(ctx)
+	require.NoError(t, err)
+	staleTask, err := CreateHistoricalTaskData(NewHistoricalTaskDataInfo(record))
+	require.NoError(t, err)
+	staleTask.NumPass = 10
+	_, err = db.Collection(historicalTaskDataCollection).InsertOne(ctx, staleTask)
+	require.NoError(t, err)
+	info.TaskID = "task2"
+	info.HistoricalDataDisabled = true
+	disabled := CreateTestResults(info, PailLocal)
+	disabled.Setup(env)
+	require.NoError(t, disabled.SaveNew(ctx))
+	require.NoError(t, disabled.Append(ctx, []TestResult{
+		{TestName: "D", Status: "pass", TestStartTime: day.Add(time.Hour - time.Second), TestEndTime: day.Add(time.Hour)},
+	}))
+
+	newHTD := func(testName string, date time.Time, numPass int) *HistoricalTestData {
+		htd, err := CreateHistoricalTestData(HistoricalTestDataInfo{
+			Project:     "project",
+			Variant:     "variant",
+			TaskName:    "task",
+			TestName:    testName,
+			RequestType: "patch",
+			Date:        date,
+		})
+		require.NoError(t, err)
+		htd.NumPass = numPass
+		return htd
+	}
+	stale := newHTD("A", day, 100)
+	removed := newHTD("Z", day, 1)
+	untouched := newHTD("C", day.Add(-time.Hour), 5)
+	for _, htd := range []*HistoricalTestData{stale, removed, untouched} {
+		_, err = db.Collection(historicalTestDataCollection).InsertOne(ctx, htd)
+		require.NoError(t, err)
+	}
+	findHTD := func(t *testing.T, id string) *HistoricalTestData {
+		htd := &HistoricalTestData{ID: id}
+		htd.Setup(env)
+		require.NoError(t, htd.Find(ctx))
+		return htd
+	}
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		for _, opts := range []RecomputeHistoricalTestDataOptions{
+			{StartAt: day, EndAt: day.Add(time.Hour)},
+			{Project: "project", EndAt: day},
+			{Project: "project", StartAt: day},
+			{Project: "project", StartAt: day, EndAt: day.Add(-time.Hour)},
+		} {
+			_, err := RecomputeHistoricalTestData(ctx, env, opts)
+			assert.Error(t, err)
+		}
+	})
+	t.Run("NoEnv", func(t *testing.T) {
+		_, err := RecomputeHistoricalTestData(ctx, nil, RecomputeHistoricalTestDataOptions{Project: "project", StartAt: day, EndAt: day.Add(time.Hour)})
+		assert.Error(t, err)
+	})
+	t.Run("Idempotent", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			count, err := RecomputeHistoricalTestData(ctx, env, RecomputeHistoricalTestDataOptions{
+				Project: "project",
+				StartAt: day.Add(time.Hour),
+				EndAt:   day.Add(time.Hour),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			a := findHTD(t, stale.ID)
+			assert.Equal(t, 2, a.NumPass)
+			assert.Zero(t, a.NumFail)
+			assert.Equal(t, 3*time.Second, a.AverageDuration)
+			require.NotNil(t, a.DurationDigest)
+			assert.Equal(t, 2*time.Second, a.DurationDigest.Quantile(0))
+			assert.Equal(t, 4*time.Second, a.DurationDigest.Quantile(1))
+			b := findHTD(t, newHTD("B", day, 0).ID)
+			assert.Zero(t, b.NumPass)
+			assert.Equal(t, 1, b.NumFail)
+			assert.Equal(t, 5, findHTD(t, untouched.ID).NumPass)
+
+			htd := &HistoricalTestData{ID: removed.ID}
+			htd.Setup(env)
+			assert.Error(t, htd.Find(ctx))
+			htd = &HistoricalTestData{ID: newHTD("D", day, 0).ID}
+			htd.Setup(env)
+			assert.Error(t, htd.Find(ctx))
+
+			task := &HistoricalTaskData{ID: staleTask.ID}
+			task.Setup(env)
+			require.NoError(t, task.Find(ctx))
+			assert.Zero(t, task.NumPass)
+			assert.Equal(t, 1, task.NumFail)
+			assert.Equal(t, 4, task.NumTests)
+		}
+	})
+	t.Run("MultipleDays", func(t *testing.T) {
+		count, err := RecomputeHistoricalTestData(ctx, env, RecomputeHistoricalTestDataOptions{
+			Project: "project",
+			StartAt: day.Add(-time.Hour),
+			EndAt:   day.Add(time.Hour),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, 2, findHTD(t, stale.ID).NumPass)
+		assert.Equal(t, 1, findHTD(t, untouched.ID).NumPass)
+	})
+}
+
 func TestHTDGroupByValidate(t *testing.T) {
 	for _, test := range []struct {
 		name    string
@@ -1615,6 +1864,117 @@ func TestGetHistoricalTestData(t *testing.T) {
 	}
 }
 
+func TestGetHistoricalTestDataDurationPercentiles(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(historicalTestDataCollection).Drop(ctx))
+	}()
+
+	var data []interface{}
+	for i, date := range []time.Time{day1, day2} {
+		htd, err := CreateHistoricalTestData(HistoricalTestDataInfo{
+			Project:     "p1",
+			Variant:     "v1",
+			TaskName:    "task1",
+			TestName:    "test1",
+			RequestType: "r1",
+			Date:        date,
+		})
+		require.NoError(t, err)
+		htd.DurationDigest = NewDurationDigest()
+		for j := 1; j <= 50; j++ {
+			dur := time.Duration(i*50+j) * time.Second
+			// Durations not yet compacted into the digest should
+			// also count.
+			if j%2 == 0 {
+				htd.PendingDurations = append(htd.PendingDurations, dur)
+			} else {
+				require.NoError(t, htd.DurationDigest.Add(dur))
+			}
+			htd.NumPass++
+		}
+		data = append(data, htd)
+	}
+	// Documents created before duration digests were stored should
+	// not affect the percentiles.
+	old, err := CreateHistoricalTestData(HistoricalTestDataInfo{
+		Project:     "p1",
+		Variant:     "v1",
+		TaskName:    "task1",
+		TestName:    "test1",
+		RequestType: "r1",
+		Date:        day2.Add(24 * time.Hour),
+	})
+	require.NoError(t, err)
+	old.NumPass = 1
+	old.AverageDuration = time.Hour
+	data = append(data, old)
+	_, err = db.Collection(historicalTestDataCollection).InsertMany(ctx, data)
+	require.NoError(t, err)
+
+	filter := getBaseHTDFilter()
+	filter.GroupNumDays = 7
+	docs, err := GetHistoricalTestData(ctx, env, filter)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, 101, docs[0].NumPass)
+	assert.InDelta(t, 50*time.Second, docs[0].P50Duration, float64(time.Second))
+	assert.InDelta(t, 90*time.Second, docs[0].P90Duration, float64(time.Second))
+	assert.InDelta(t, 99*time.Second, docs[0].P99Duration, float64(time.Second))
+}
+
+func TestGetHistoricalTestDataByTeam(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(historicalTestDataCollection).Drop(ctx))
+	}()
+
+	for _, test := range []struct {
+		name string
+		team string
+	}{
+		{name: "test1", team: "team1"},
+		{name: "test2", team: "team2"},
+		{name: "test3"},
+	} {
+		htd, err := CreateHistoricalTestData(HistoricalTestDataInfo{
+			Project:     "p1",
+			Variant:     "v1",
+			TaskName:    "task1",
+			TestName:    test.name,
+			RequestType: "r1",
+			Date:        day1,
+		})
+		require.NoError(t, err)
+		htd.Setup(env)
+		htd.Team = test.team
+		require.NoError(t, htd.Update(ctx, TestResult{Status: "fail", TestEndTime: day1}))
+		assert.Equal(t, test.team, htd.Team)
+	}
+
+	filter := getBaseHTDFilter()
+	filter.GroupBy = HTDGroupByTest
+	filter.Tests = nil
+	filter.Tasks = nil
+	filter.Team = "team1"
+	docs, err := GetHistoricalTestData(ctx, env, filter)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "test1", docs[0].TestName)
+	assert.Equal(t, "team1", docs[0].Team)
+	assert.Equal(t, 1, docs[0].NumFail)
+
+	filter.Team = ""
+	_, err = GetHistoricalTestData(ctx, env, filter)
+	assert.Error(t, err)
+}
+
 func getHistoricalTestData(t *testing.T) *HistoricalTestData {
 	info := HistoricalTestDataInfo{
 		Project:     utility.RandomString(),
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/evergreen-ci/cedar"
 	"github.com/mongodb/anser/bsonutil"
 	"github.com/mongodb/grip"
 	"github.com/pkg/errors"
@@ -178,6 +179,38 @@ func GetRequiredIndexes() []SystemIndexes {
 			},
 			Collection: testResultsCollection,
 		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey), Value: 1},
+				{Key: testResultsCreatedAtKey, Value: -1},
+			},
+			Collection: testResultsCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoVariantKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoTaskNameKey), Value: 1},
+				{Key: testResultsCreatedAtKey, Value: -1},
+			},
+			Collection: testResultsCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoEvergreenKey, evergreenMetadataAuthorKey), Value: 1},
+				{Key: testResultsCreatedAtKey, Value: -1},
+			},
+			Options: bson.D{
+				{
+					Key: "partialFilterExpression",
+					Value: bson.M{
+						bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoEvergreenKey, evergreenMetadataAuthorKey): bson.M{"$exists": true},
+					},
+				},
+			},
+			Collection: testResultsCollection,
+		},
 		{
 			Keys: bson.D{
 				{Key: bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoDisplayTaskIDKey), Value: 1},
@@ -242,6 +275,15 @@ func GetRequiredIndexes() []SystemIndexes {
 			},
 			Collection: historicalTestDataCollection,
 		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoRequestTypeKey), Value: 1},
+				{Key: historicalTestDataTeamKey, Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoDateKey), Value: 1},
+			},
+			Collection: historicalTestDataCollection,
+		},
 		{
 			Keys: bson.D{
 				{Key: bsonutil.GetDottedKeyName(historicalTestDataInfoKey, historicalTestDataInfoDateKey), Value: 1},
@@ -249,6 +291,53 @@ func GetRequiredIndexes() []SystemIndexes {
 			Options:    bson.D{{Key: "expireAfterSeconds", Value: 15552000}},
 			Collection: historicalTestDataCollection,
 		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoRequestTypeKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoTaskNameKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoVariantKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey), Value: 1},
+			},
+			Collection: historicalTaskDataCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoRequestTypeKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoTaskNameKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey), Value: 1},
+			},
+			Collection: historicalTaskDataCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoProjectKey), Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoRequestTypeKey), Value: 1},
+				{Key: historicalTaskDataDisplayTaskNameKey, Value: 1},
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey), Value: 1},
+			},
+			Collection: historicalTaskDataCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(historicalTaskDataInfoKey, historicalTaskDataInfoDateKey), Value: 1},
+			},
+			Options:    bson.D{{Key: "expireAfterSeconds", Value: 15552000}},
+			Collection: historicalTaskDataCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: testQuarantineProjectKey, Value: 1},
+				{Key: testQuarantineTestNameKey, Value: 1},
+			},
+			Collection: testQuarantineCollection,
+		},
+		{
+			Keys:       bson.D{{Key: testQuarantineExpiresAtKey, Value: 1}},
+			Options:    bson.D{{Key: "expireAfterSeconds", Value: 0}},
+			Collection: testQuarantineCollection,
+		},
 		{
 			Keys:       bson.D{{Key: dbUserAPIKeyKey, Value: 1}},
 			Collection: userCollection,
@@ -257,6 +346,60 @@ func GetRequiredIndexes() []SystemIndexes {
 			Keys:       bson.D{{Key: bsonutil.GetDottedKeyName(dbUserLoginCacheKey, loginCacheTokenKey), Value: 1}},
 			Collection: userCollection,
 		},
+		{
+			Keys:       bson.D{{Key: auditEventExpiresAtKey, Value: 1}},
+			Options:    bson.D{{Key: "expireAfterSeconds", Value: 0}},
+			Collection: auditEventCollection,
+		},
+		{
+			Keys:       bson.D{{Key: auditEventUserKey, Value: 1}, {Key: auditEventTimestampKey, Value: -1}},
+			Collection: auditEventCollection,
+		},
+		{
+			Keys:       bson.D{{Key: auditEventActionKey, Value: 1}, {Key: auditEventTimestampKey, Value: -1}},
+			Collection: auditEventCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoTypeKey), Value: 1},
+				{Key: logCompletedAtKey, Value: 1},
+			},
+			Collection: buildloggerCollection,
+		},
+		{
+			Keys: bson.D{
+				{Key: bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoTypeKey), Value: 1},
+				{Key: testResultsCompletedAtKey, Value: 1},
+			},
+			Collection: testResultsCollection,
+		},
+		{
+			Keys:       bson.D{{Key: certRevocationExpiresAtKey, Value: 1}},
+			Options:    bson.D{{Key: "expireAfterSeconds", Value: 0}},
+			Collection: certificateRevocationCollection,
+		},
+		{
+			Keys:       bson.D{{Key: ingestionStatsExpiresAtKey, Value: 1}},
+			Options:    bson.D{{Key: "expireAfterSeconds", Value: 0}},
+			Collection: cedar.IngestionStatsCollection,
+		},
+		{
+			Keys:       bson.D{{Key: ingestionStatsProjectKey, Value: 1}, {Key: ingestionStatsBucketKey, Value: 1}},
+			Collection: cedar.IngestionStatsCollection,
+		},
+		{
+			Keys:       bson.D{{Key: webhookSubscriptionProjectKey, Value: 1}},
+			Collection: webhookSubscriptionCollection,
+		},
+		{
+			Keys:       bson.D{{Key: webhookDeliverySubscriptionIDKey, Value: 1}, {Key: webhookDeliveryCreatedAtKey, Value: -1}},
+			Collection: webhookDeliveryCollection,
+		},
+		{
+			Keys:       bson.D{{Key: webhookDeliveryCreatedAtKey, Value: 1}},
+			Options:    bson.D{{Key: "expireAfterSeconds", Value: 2592000}},
+			Collection: webhookDeliveryCollection,
+		},
 	}
 }
 
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const defaultIngestionStatsLimit = 100
+
+var (
+	ingestionStatsCacheKey     = bsonutil.MustHaveTag(cedar.IngestionStats{}, "Cache")
+	ingestionStatsProjectKey   = bsonutil.MustHaveTag(cedar.IngestionStats{}, "Project")
+	ingestionStatsBucketKey    = bsonutil.MustHaveTag(cedar.IngestionStats{}, "Bucket")
+	ingestionStatsCallsKey     = bsonutil.MustHaveTag(cedar.IngestionStats{}, "Calls")
+	ingestionStatsCountKey     = bsonutil.MustHaveTag(cedar.IngestionStats{}, "Count")
+	ingestionStatsLinesKey     = bsonutil.MustHaveTag(cedar.IngestionStats{}, "Lines")
+	ingestionStatsExpiresAtKey = bsonutil.MustHaveTag(cedar.IngestionStats{}, "ExpiresAt")
+)
+
+// IngestionStatsFindOptions allow for querying the ingestion stats flushed
+// by the stats caches. All of the fields are optional.
+type IngestionStatsFindOptions struct {
+	// Cache is the name of the stats cache, one of cedar.StatsCacheNames.
+	Cache   string
+	Project string
+	StartAt time.Time
+	EndAt   time.Time
+	// Limit only applies to the ingestion stats totals.
+	Limit int
+}
+
+// Validate ensures the find options are valid and sets defaults.
+func (opts *IngestionStatsFindOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.ErrorfWhen(opts.Cache != "" && !utility.StringSliceContains(cedar.StatsCacheNames, opts.Cache), "invalid stats cache '%s'", opts.Cache)
+	catcher.NewWhen(opts.Limit < 0, "limit cannot be negative")
+	catcher.NewWhen(!opts.StartAt.IsZero() && !opts.EndAt.IsZero() && opts.EndAt.Before(opts.StartAt), "end time cannot be before start time")
+
+	if opts.Limit == 0 {
+		opts.Limit = defaultIngestionStatsLimit
+	}
+
+	return catcher.Resolve()
+}
+
+func (opts *IngestionStatsFindOptions) query() bson.M {
+	query := bson.M{}
+	if opts.Cache != "" {
+		query[ingestionStatsCacheKey] = opts.Cache
+	}
+	if opts.Project != "" {
+		query[ingestionStatsProjectKey] = opts.Project
+	}
+	bucket := bson.M{}
+	if !opts.StartAt.IsZero() {
+		bucket["$gte"] = opts.StartAt.Truncate(cedar.IngestionStatsBucketSize)
+	}
+	if !opts.EndAt.IsZero() {
+		bucket["$lte"] = opts.EndAt
+	}
+	if len(bucket) > 0 {
+		query[ingestionStatsBucketKey] = bucket
+	}
+
+	return query
+}
+
+// FindIngestionStats returns the time bucketed ingestion stats matching the
+// given options, oldest first.
+func FindIngestionStats(ctx context.Context, env cedar.Environment, opts IngestionStatsFindOptions) ([]cedar.IngestionStats, error) {
+	if env == nil {
+		return nil, errors.New("cannot find with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid find options")
+	}
+
+	findOpts := options.Find().SetSort(bson.D{
+		{Key: ingestionStatsBucketKey, Value: 1},
+		{Key: ingestionStatsCacheKey, Value: 1},
+		{Key: ingestionStatsProjectKey, Value: 1},
+	})
+	cur, err := env.GetDB().Collection(cedar.IngestionStatsCollection).Find(ctx, opts.query(), findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding ingestion stats")
+	}
+
+	stats := []cedar.IngestionStats{}
+	if err = cur.All(ctx, &stats); err != nil {
+		return nil, errors.Wrap(err, "decoding ingestion stats")
+	}
+
+	return stats, nil
+}
+
+// IngestionStatsTotal is the sum of the ingestion stats of a stats cache for
+// a project over a period of time.
+type IngestionStatsTotal struct {
+	Cache   string `bson:"cache" json:"cache"`
+	Project string `bson:"project" json:"project"`
+	Calls   int    `bson:"calls" json:"calls"`
+	Count   int    `bson:"count" json:"count"`
+	Lines   int    `bson:"lines,omitempty" json:"lines,omitempty"`
+}
+
+// FindIngestionStatsTotals returns the ingestion stats matching the given
+// options summed per stats cache and project, noisiest first.
+func FindIngestionStatsTotals(ctx context.Context, env cedar.Environment, opts IngestionStatsFindOptions) ([]IngestionStatsTotal, error) {
+	if env == nil {
+		return nil, errors.New("cannot find with a nil environment")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid find options")
+	}
+
+	pipeline := []bson.M{
+		{"$match": opts.query()},
+		{"$group": bson.M{
+			"_id": bson.M{
+				ingestionStatsCacheKey:   "$" + ingestionStatsCacheKey,
+				ingestionStatsProjectKey: "$" + ingestionStatsProjectKey,
+			},
+			ingestionStatsCallsKey: bson.M{"$sum": "$" + ingestionStatsCallsKey},
+			ingestionStatsCountKey: bson.M{"$sum": "$" + ingestionStatsCountKey},
+			ingestionStatsLinesKey: bson.M{"$sum": "$" + ingestionStatsLinesKey},
+		}},
+		{"$project": bson.M{
+			"_id":                    0,
+			ingestionStatsCacheKey:   "$" + bsonutil.GetDottedKeyName("_id", ingestionStatsCacheKey),
+			ingestionStatsProjectKey: "$" + bsonutil.GetDottedKeyName("_id", ingestionStatsProjectKey),
+			ingestionStatsCallsKey:   1,
+			ingestionStatsCountKey:   1,
+			ingestionStatsLinesKey:   1,
+		}},
+		{"$sort": bson.D{
+			{Key: ingestionStatsCountKey, Value: -1},
+			{Key: ingestionStatsCacheKey, Value: 1},
+			{Key: ingestionStatsProjectKey, Value: 1},
+		}},
+		{"$limit": opts.Limit},
+	}
+	cur, err := env.GetDB().Collection(cedar.IngestionStatsCollection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrap(err, "aggregating ingestion stats")
+	}
+
+	totals := []IngestionStatsTotal{}
+	if err = cur.All(ctx, &totals); err != nil {
+		return nil, errors.Wrap(err, "decoding ingestion stats totals")
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindIngestionStats(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(cedar.IngestionStatsCollection).Drop(ctx))
+	}()
+
+	bucket := time.Date(2021, 1, 2, 3, 0, 0, 0, time.UTC)
+	stats := []cedar.IngestionStats{
+		{ID: "1", Cache: cedar.StatsCacheBuildlogger, Project: "p1", Bucket: bucket, Calls: 1, Count: 100, Lines: 10},
+		{ID: "2", Cache: cedar.StatsCacheBuildlogger, Project: "p1", Bucket: bucket.Add(time.Hour), Calls: 2, Count: 300, Lines: 30},
+		{ID: "3", Cache: cedar.StatsCacheBuildlogger, Project: "p2", Bucket: bucket, Calls: 1, Count: 50, Lines: 5},
+		{ID: "4", Cache: cedar.StatsCacheTestResults, Project: "p1", Bucket: bucket, Calls: 3, Count: 20},
+	}
+	for _, stat := range stats {
+		_, err := db.Collection(cedar.IngestionStatsCollection).InsertOne(ctx, stat)
+		require.NoError(t, err)
+	}
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		for _, opts := range []IngestionStatsFindOptions{
+			{Cache: "invalid"},
+			{Limit: -1},
+			{StartAt: bucket, EndAt: bucket.Add(-time.Hour)},
+		} {
+			_, err := FindIngestionStats(ctx, env, opts)
+			assert.Error(t, err)
+			_, err = FindIngestionStatsTotals(ctx, env, opts)
+			assert.Error(t, err)
+		}
+	})
+	t.Run("ByProject", func(t *testing.T) {
+		found, err := FindIngestionStats(ctx, env, IngestionStatsFindOptions{Project: "p1"})
+		require.NoError(t, err)
+		require.Len(t, found, 3)
+		assert.Equal(t, "1", found[0].ID)
+		assert.Equal(t, "4", found[1].ID)
+		assert.Equal(t, "2", found[2].ID)
+	})
+	t.Run("ByCacheAndTime", func(t *testing.T) {
+		found, err := FindIngestionStats(ctx, env, IngestionStatsFindOptions{
+			Cache:   cedar.StatsCacheBuildlogger,
+			StartAt: bucket.Add(30 * time.Minute),
+			EndAt:   bucket.Add(30 * time.Minute),
+		})
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "1", found[0].ID)
+		assert.Equal(t, "3", found[1].ID)
+	})
+	t.Run("Totals", func(t *testing.T) {
+		totals, err := FindIngestionStatsTotals(ctx, env, IngestionStatsFindOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, []IngestionStatsTotal{
+			{Cache: cedar.StatsCacheBuildlogger, Project: "p1", Calls: 3, Count: 400, Lines: 40},
+			{Cache: cedar.StatsCacheBuildlogger, Project: "p2", Calls: 1, Count: 50, Lines: 5},
+			{Cache: cedar.StatsCacheTestResults, Project: "p1", Calls: 3, Count: 20},
+		}, totals)
+	})
+	t.Run("TotalsWithLimit", func(t *testing.T) {
+		totals, err := FindIngestionStatsTotals(ctx, env, IngestionStatsFindOptions{Cache: cedar.StatsCacheBuildlogger, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, totals, 1)
+		assert.Equal(t, "p1", totals[0].Project)
+	})
+}
@@ -2,13 +2,12 @@ package model
 
 import (
 	"context"
-	"fmt"
 	"path/filepath"
-	"strings"
 
 	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/metrics"
+	"github.com/evergreen-ci/cedar/tracing"
 	"github.com/evergreen-ci/pail"
-	"github.com/evergreen-ci/utility"
 	"github.com/pkg/errors"
 )
 
@@ -21,6 +20,9 @@ const (
 	PailLegacyGridFS PailType = "gridfs-legacy"
 	PailGridFS       PailType = "gridfs"
 	PailLocal        PailType = "local"
+	// PailTiered stores data in GridFS until it is migrated to S3,
+	// reads fall through from the hot tier to S3.
+	PailTiered PailType = "tiered"
 
 	defaultS3Region     = "us-east-1"
 	defaultS3MaxRetries = 10
@@ -39,16 +41,8 @@ func (t PailType) Create(ctx context.Context, env cedar.Environment, bucket, pre
 			return nil, errors.Wrap(err, "getting application configuration")
 		}
 
-		opts := pail.S3Options{
-			Name:        bucket,
-			Prefix:      prefix,
-			Region:      defaultS3Region,
-			Permissions: pail.S3Permissions(permissions),
-			Credentials: pail.CreateAWSCredentials(conf.Bucket.AWSKey, conf.Bucket.AWSSecret, ""),
-			MaxRetries:  utility.ToIntPtr(defaultS3MaxRetries),
-			Compress:    compress,
-		}
-		b, err = pail.NewS3Bucket(opts)
+		s3Conf := conf.Bucket.S3ConfigFor(bucket)
+		b, err = s3Conf.createBucket(prefix, permissions, compress)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -74,6 +68,10 @@ func (t PailType) Create(ctx context.Context, env cedar.Environment, bucket, pre
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
+	case PailTiered:
+		// Each tier of the tiered bucket is already checked and
+		// instrumented on creation.
+		return newTieredBucket(ctx, env, bucket, prefix, permissions, compress)
 	default:
 		return nil, errors.New("not implemented")
 	}
@@ -81,7 +79,7 @@ func (t PailType) Create(ctx context.Context, env cedar.Environment, bucket, pre
 	if err = b.Check(ctx); err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return b, nil
+	return instrumentBucket(b, t), nil
 }
 
 // CreatePresto returns a Pail Bucket backed by PailType specifically for
@@ -94,18 +92,10 @@ func (t PailType) CreatePresto(ctx context.Context, env cedar.Environment, prefi
 	}
 
 	switch t {
-	case PailS3:
-		opts := pail.S3Options{
-			Name:          conf.Bucket.PrestoBucket,
-			Prefix:        prefix,
-			Region:        defaultS3Region,
-			Permissions:   pail.S3Permissions(permissions),
-			Credentials:   pail.CreateAWSCredentials(conf.Bucket.AWSKey, conf.Bucket.AWSSecret, ""),
-			AssumeRoleARN: conf.Bucket.PrestoRoleARN,
-			MaxRetries:    utility.ToIntPtr(defaultS3MaxRetries),
-			Compress:      compress,
-		}
-		b, err := pail.NewS3Bucket(opts)
+	case PailS3, PailTiered:
+		// Presto only reads from S3, so data is never tiered.
+		s3Conf := conf.Bucket.prestoS3Config()
+		b, err := s3Conf.createBucket(prefix, permissions, compress)
 		if err != nil {
 			return nil, errors.WithStack(err)
 		}
@@ -114,22 +104,25 @@ func (t PailType) CreatePresto(ctx context.Context, env cedar.Environment, prefi
 			return nil, errors.WithStack(err)
 		}
 
-		return b, nil
+		return instrumentBucket(b, PailS3), nil
 	default:
 		return t.Create(ctx, env, conf.Bucket.PrestoBucket, prefix, permissions, compress)
 	}
 }
 
+// instrumentBucket wraps the bucket so that its operations are recorded in the
+// service metrics and traces.
+func instrumentBucket(b pail.Bucket, t PailType) pail.Bucket {
+	return metrics.InstrumentBucket(tracing.InstrumentBucket(b, string(t)), string(t))
+}
+
 // GetDownloadURL returns, if applicable, the download URL for the object at
 // the given bucket/prefix/key location.
 func (t PailType) GetDownloadURL(bucket, prefix, key string) string {
 	switch t {
 	case PailS3:
-		return fmt.Sprintf(
-			"https://%s.s3.amazonaws.com/%s",
-			bucket,
-			strings.Replace(filepath.Join(prefix, key), "\\", "/", -1),
-		)
+		s3Conf := s3ConfigForDownload(bucket)
+		return s3Conf.ObjectURL(filepath.Join(prefix, key))
 	default:
 		return ""
 	}
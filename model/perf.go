@@ -348,6 +348,9 @@ type PerformanceResultInfo struct {
 	Arguments PerformanceArguments `bson:"args,omitempty"`
 	Mainline  bool                 `bson:"mainline"`
 	Schema    int                  `bson:"schema,omitempty"`
+	// Evergreen is populated asynchronously after the result is created
+	// and is not part of the ID.
+	Evergreen *EvergreenMetadata `bson:"evergreen,omitempty"`
 }
 
 var (
@@ -365,6 +368,7 @@ var (
 	perfResultInfoArgumentsKey = bsonutil.MustHaveTag(PerformanceResultInfo{}, "Arguments")
 	perfResultInfoMainlineKey  = bsonutil.MustHaveTag(PerformanceResultInfo{}, "Mainline")
 	perfResultInfoSchemaKey    = bsonutil.MustHaveTag(PerformanceResultInfo{}, "Schema")
+	perfResultInfoEvergreenKey = bsonutil.MustHaveTag(PerformanceResultInfo{}, "Evergreen")
 )
 
 // PerformanceArguments wraps map[string]int32 and implements the
@@ -27,6 +27,7 @@ type ArtifactInfo struct {
 
 var (
 	artifactInfoTypeKey        = bsonutil.MustHaveTag(ArtifactInfo{}, "Type")
+	artifactInfoBucketKey      = bsonutil.MustHaveTag(ArtifactInfo{}, "Bucket")
 	artifactInfoPathKey        = bsonutil.MustHaveTag(ArtifactInfo{}, "Path")
 	artifactInfoSchemaKey      = bsonutil.MustHaveTag(ArtifactInfo{}, "Schema")
 	artifactInfoFormatKey      = bsonutil.MustHaveTag(ArtifactInfo{}, "Format")
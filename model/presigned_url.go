@@ -0,0 +1,215 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPresignedURLExpiration is the lifetime of pre-signed URLs
+	// when the caller does not request one.
+	DefaultPresignedURLExpiration = 15 * time.Minute
+	// MaxPresignedURLExpiration is the longest lifetime of pre-signed
+	// URLs.
+	MaxPresignedURLExpiration = 12 * time.Hour
+)
+
+// PresignedURL is a time-limited URL to download an object directly from S3,
+// bypassing the Cedar service.
+type PresignedURL struct {
+	// Key is the key of the object relative to the prefix of its
+	// artifact.
+	Key       string
+	URL       string
+	ExpiresAt time.Time
+	// Error, if set, is the reason that the object cannot be downloaded
+	// with a pre-signed URL, in which case there is no URL.
+	Error string
+}
+
+// SupportsPresignedURLs returns whether objects stored in the pail type can be
+// downloaded with pre-signed URLs. Only S3 supports them.
+func (t PailType) SupportsPresignedURLs() bool {
+	return t == PailS3
+}
+
+// ValidatePresignedURLExpiration returns an error if the expiration is not a
+// valid lifetime of pre-signed URLs.
+func ValidatePresignedURLExpiration(expiration time.Duration) error {
+	if expiration <= 0 {
+		return errors.New("pre-signed URL expiration must be positive")
+	}
+	if expiration > MaxPresignedURLExpiration {
+		return errors.Errorf("pre-signed URL expiration cannot exceed %s", MaxPresignedURLExpiration)
+	}
+
+	return nil
+}
+
+// PresignDownloadURL returns a pre-signed URL to download the artifact. If the
+// artifact is not stored in S3, the returned PresignedURL only reports the
+// error. The environment should not be nil.
+func (a *ArtifactInfo) PresignDownloadURL(env cedar.Environment, expiration time.Duration) (*PresignedURL, error) {
+	if !a.Type.SupportsPresignedURLs() {
+		return &PresignedURL{
+			Key:   a.Path,
+			Error: fmt.Sprintf("cannot pre-sign URLs for artifacts stored in '%s'", a.Type),
+		}, nil
+	}
+
+	conf, err := findBucketConfig(env)
+	if err != nil {
+		return nil, err
+	}
+
+	urls, err := presignURLs(conf.S3ConfigFor(a.Bucket), a.Prefix, []string{a.Path}, expiration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &urls[0], nil
+}
+
+// PresignChunkURLs returns pre-signed URLs to download the chunks of the log,
+// in chronological order. The environment should not be nil.
+func (l *Log) PresignChunkURLs(ctx context.Context, expiration time.Duration) ([]PresignedURL, error) {
+	if l.env == nil {
+		return nil, errors.New("cannot pre-sign log URLs with a nil environment")
+	}
+	if !l.Artifact.Type.SupportsPresignedURLs() {
+		return nil, errors.Errorf("cannot pre-sign URLs for logs stored in '%s'", l.Artifact.Type)
+	}
+	if l.Artifact.EncryptionKeyID != "" {
+		return nil, errors.Errorf("cannot pre-sign URLs for encrypted log '%s'", l.ID)
+	}
+
+	conf, err := findBucketConfig(l.env)
+	if err != nil {
+		return nil, err
+	}
+
+	bucket, err := l.Artifact.Type.Create(
+		ctx,
+		l.env,
+		conf.BuildLogsBucket,
+		l.Artifact.Prefix,
+		string(pail.S3PermissionsPrivate),
+		false,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating bucket")
+	}
+	chunks, err := l.getChunks(ctx, bucket)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting chunks")
+	}
+
+	keys := make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		keys = append(keys, chunk.Key)
+	}
+
+	return presignURLs(conf.S3ConfigFor(conf.BuildLogsBucket), l.Artifact.Prefix, keys, expiration)
+}
+
+// SupportsPresignedParquetURL returns whether the test results are stored as
+// a Parquet file in the Presto bucket in S3.
+func (t *TestResults) SupportsPresignedParquetURL() bool {
+	return t.Artifact.Version == 1 && (t.Artifact.Type == PailS3 || t.Artifact.Type == PailTiered)
+}
+
+// PresignParquetURL returns a pre-signed URL to download the Parquet file of
+// the test results from the Presto bucket. The environment should not be nil.
+func (t *TestResults) PresignParquetURL(expiration time.Duration) (*PresignedURL, error) {
+	if t.env == nil {
+		return nil, errors.New("cannot pre-sign test results URLs with a nil environment")
+	}
+	if !t.SupportsPresignedParquetURL() {
+		return nil, errors.Errorf("test results '%s' are not stored as Parquet in S3", t.ID)
+	}
+
+	conf, err := findBucketConfig(t.env)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := t.prestoBucketPrefix
+	if prefix == "" {
+		prefix = conf.PrestoTestResultsPrefix
+	}
+
+	urls, err := presignURLs(conf.prestoS3Config(), prefix, []string{t.PrestoPartitionKey()}, expiration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &urls[0], nil
+}
+
+// PresignChunkURLs returns pre-signed URLs to download the chunks of the
+// given metric type, in order. The environment should not be nil.
+func (sm *SystemMetrics) PresignChunkURLs(metricType string, expiration time.Duration) ([]PresignedURL, error) {
+	if sm.env == nil {
+		return nil, errors.New("cannot pre-sign system metrics URLs with a nil environment")
+	}
+	if !sm.Artifact.Options.Type.SupportsPresignedURLs() {
+		return nil, errors.Errorf("cannot pre-sign URLs for system metrics stored in '%s'", sm.Artifact.Options.Type)
+	}
+	if sm.Artifact.EncryptionKeyID != "" {
+		return nil, errors.Errorf("cannot pre-sign URLs for encrypted system metrics record '%s'", sm.ID)
+	}
+	chunks, ok := sm.Artifact.MetricChunks[metricType]
+	if !ok {
+		return nil, errors.Errorf("invalid metric type '%s' for system metrics record '%s'", metricType, sm.ID)
+	}
+
+	conf, err := findBucketConfig(sm.env)
+	if err != nil {
+		return nil, err
+	}
+
+	return presignURLs(conf.S3ConfigFor(conf.SystemMetricsBucket), sm.Artifact.Prefix, chunks.Chunks, expiration)
+}
+
+func findBucketConfig(env cedar.Environment) (*BucketConfig, error) {
+	conf := &CedarConfig{}
+	conf.Setup(env)
+	if err := conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+
+	return &conf.Bucket, nil
+}
+
+func presignURLs(s3Conf S3Config, prefix string, keys []string, expiration time.Duration) ([]PresignedURL, error) {
+	if err := ValidatePresignedURLExpiration(expiration); err != nil {
+		return nil, err
+	}
+
+	fullKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fullKeys = append(fullKeys, path.Join(prefix, key))
+	}
+	expiresAt := time.Now().Add(expiration)
+	urls, err := s3Conf.PresignGetURLs(fullKeys, expiration)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pre-signing URLs in bucket '%s'", s3Conf.Bucket)
+	}
+
+	presigned := make([]PresignedURL, 0, len(keys))
+	for i, key := range keys {
+		presigned = append(presigned, PresignedURL{
+			Key:       key,
+			URL:       urls[i],
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	return presigned, nil
+}
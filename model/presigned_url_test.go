@@ -0,0 +1,45 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePresignedURLExpiration(t *testing.T) {
+	assert.NoError(t, ValidatePresignedURLExpiration(DefaultPresignedURLExpiration))
+	assert.NoError(t, ValidatePresignedURLExpiration(MaxPresignedURLExpiration))
+	assert.Error(t, ValidatePresignedURLExpiration(0))
+	assert.Error(t, ValidatePresignedURLExpiration(MaxPresignedURLExpiration+time.Second))
+}
+
+func TestPresignURLs(t *testing.T) {
+	conf := S3Config{Bucket: "bucket", Region: defaultS3Region, AWSKey: "key", AWSSecret: "secret"}
+
+	t.Run("JoinsPrefix", func(t *testing.T) {
+		before := time.Now()
+		urls, err := presignURLs(conf, "prefix", []string{"chunk0", "chunk1"}, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, urls, 2)
+		for i, url := range urls {
+			assert.Equal(t, []string{"chunk0", "chunk1"}[i], url.Key)
+			assert.True(t, strings.HasPrefix(url.URL, "https://bucket.s3.amazonaws.com/prefix/"+url.Key+"?"), url.URL)
+			assert.True(t, url.ExpiresAt.After(before.Add(time.Minute-time.Second)))
+		}
+	})
+	t.Run("InvalidExpiration", func(t *testing.T) {
+		_, err := presignURLs(conf, "prefix", []string{"chunk0"}, MaxPresignedURLExpiration+time.Second)
+		assert.Error(t, err)
+	})
+	t.Run("UnsupportedType", func(t *testing.T) {
+		artifact := ArtifactInfo{Type: PailLocal, Bucket: "bucket", Path: "key"}
+		url, err := artifact.PresignDownloadURL(nil, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, "key", url.Key)
+		assert.Empty(t, url.URL)
+		assert.NotEmpty(t, url.Error)
+	})
+}
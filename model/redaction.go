@@ -0,0 +1,447 @@
+package model
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	redactionSecretsCollection = "redaction_secrets"
+
+	// RedactedText replaces each secret masked in a log line.
+	RedactedText = "[REDACTED]"
+
+	// MinRedactionSecretLength is the minimum length of a literal secret
+	// value, so that registering a short value does not mask common text.
+	MinRedactionSecretLength = 6
+)
+
+// defaultRedactionPatterns match well-known token formats.
+var defaultRedactionPatterns = []*regexp.Regexp{
+	// AWS access key IDs.
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	// GitHub personal access, OAuth, app and refresh tokens.
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,255}\b`),
+	regexp.MustCompile(`\bgithub_pat_[A-Za-z0-9_]{22,255}\b`),
+	// Slack tokens.
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}\b`),
+	// JSON web tokens.
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	// Bearer tokens in authorization headers. The header name is required
+	// so that prose such as "Bearer authentication" is not masked.
+	regexp.MustCompile(`(?i)\bauthorization["']?\s*[:=]\s*["']?bearer\s+[A-Za-z0-9\-._~+/]{8,}=*`),
+}
+
+// The markers of PEM encoded private keys, which are masked along with every
+// line between them unless the default patterns are disabled.
+var (
+	privateKeyBeginPattern = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)
+	privateKeyEndPattern   = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// ProjectRedactionSecrets are the literal secret values registered for a
+// project, which are masked in the project's logs.
+type ProjectRedactionSecrets struct {
+	Project   string            `bson:"_id"`
+	Secrets   []RedactionSecret `bson:"secrets"`
+	UpdatedAt time.Time         `bson:"updated_at"`
+}
+
+var (
+	projectRedactionSecretsProjectKey   = bsonutil.MustHaveTag(ProjectRedactionSecrets{}, "Project")
+	projectRedactionSecretsSecretsKey   = bsonutil.MustHaveTag(ProjectRedactionSecrets{}, "Secrets")
+	projectRedactionSecretsUpdatedAtKey = bsonutil.MustHaveTag(ProjectRedactionSecrets{}, "UpdatedAt")
+)
+
+// RedactionSecret is a literal secret value registered for a project. The
+// value is encrypted with a key from the encryption keys file and is never
+// returned by the API.
+type RedactionSecret struct {
+	ID        string    `bson:"id"`
+	KeyID     string    `bson:"key_id"`
+	Value     []byte    `bson:"value"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+var (
+	redactionSecretIDKey = bsonutil.MustHaveTag(RedactionSecret{}, "ID")
+)
+
+// ValidateRedactionSecrets returns an error if there are no secrets or if a
+// secret is too short to be registered.
+func ValidateRedactionSecrets(secrets []string) error {
+	if len(secrets) == 0 {
+		return errors.New("must specify at least one secret")
+	}
+	for _, secret := range secrets {
+		if len(secret) < MinRedactionSecretLength {
+			return errors.Errorf("secrets must be at least %d characters long", MinRedactionSecretLength)
+		}
+	}
+
+	return nil
+}
+
+// AddRedactionSecrets registers literal secret values for the project and
+// returns the IDs of the given secrets, in order. Values that are already
+// registered keep their existing IDs.
+func AddRedactionSecrets(ctx context.Context, env cedar.Environment, project string, secrets []string) ([]string, error) {
+	if project == "" {
+		return nil, errors.New("must specify a project")
+	}
+	if err := ValidateRedactionSecrets(secrets); err != nil {
+		return nil, err
+	}
+
+	conf := &CedarConfig{}
+	conf.Setup(env)
+	if err := conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+	keyID := conf.Redaction.SecretsKeyID
+	if keyID == "" {
+		return nil, errors.New("cannot register secrets without a secrets encryption key")
+	}
+	keyring, err := conf.Encryption.keyring()
+	if err != nil {
+		return nil, err
+	}
+	kek, ok := keyring[keyID]
+	if !ok {
+		return nil, errors.Errorf("unknown secrets encryption key '%s'", keyID)
+	}
+
+	registered, err := findRedactionSecrets(ctx, env, &conf.Encryption, project)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(secrets))
+	toAdd := []RedactionSecret{}
+	for i, secret := range secrets {
+		if id, ok := registered[secret]; ok {
+			ids[i] = id
+			continue
+		}
+
+		id := primitive.NewObjectID().Hex()
+		value, err := seal(kek, []byte(secret), []byte(project))
+		if err != nil {
+			return nil, errors.Wrap(err, "encrypting secret")
+		}
+		toAdd = append(toAdd, RedactionSecret{
+			ID:        id,
+			KeyID:     keyID,
+			Value:     value,
+			CreatedAt: time.Now(),
+		})
+		registered[secret] = id
+		ids[i] = id
+	}
+	if len(toAdd) == 0 {
+		return ids, nil
+	}
+
+	_, err = env.GetDB().Collection(redactionSecretsCollection).UpdateOne(ctx,
+		bson.M{projectRedactionSecretsProjectKey: project},
+		bson.M{
+			"$push": bson.M{projectRedactionSecretsSecretsKey: bson.M{"$each": toAdd}},
+			"$set":  bson.M{projectRedactionSecretsUpdatedAtKey: time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "adding redaction secrets for project '%s'", project)
+	}
+
+	return ids, nil
+}
+
+// FindRedactionSecrets returns the secrets registered for the project. The
+// values remain encrypted.
+func FindRedactionSecrets(ctx context.Context, env cedar.Environment, project string) ([]RedactionSecret, error) {
+	secrets := &ProjectRedactionSecrets{}
+	err := env.GetDB().Collection(redactionSecretsCollection).FindOne(ctx, bson.M{projectRedactionSecretsProjectKey: project}).Decode(secrets)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding redaction secrets for project '%s'", project)
+	}
+
+	return secrets.Secrets, nil
+}
+
+// DeleteRedactionSecret removes the secret with the given ID from the
+// project. It returns false if the project has no such secret.
+func DeleteRedactionSecret(ctx context.Context, env cedar.Environment, project, id string) (bool, error) {
+	filter := bson.M{projectRedactionSecretsProjectKey: project}
+	filter[bsonutil.GetDottedKeyName(projectRedactionSecretsSecretsKey, redactionSecretIDKey)] = id
+	res, err := env.GetDB().Collection(redactionSecretsCollection).UpdateOne(ctx,
+		filter,
+		bson.M{
+			"$pull": bson.M{projectRedactionSecretsSecretsKey: bson.M{redactionSecretIDKey: id}},
+			"$set":  bson.M{projectRedactionSecretsUpdatedAtKey: time.Now()},
+		},
+	)
+	if err != nil {
+		return false, errors.Wrapf(err, "deleting redaction secret '%s' for project '%s'", id, project)
+	}
+
+	return res.ModifiedCount > 0, nil
+}
+
+// findRedactionSecrets returns the decrypted values of the secrets registered
+// for the project mapped to their IDs.
+func findRedactionSecrets(ctx context.Context, env cedar.Environment, conf *EncryptionConfig, project string) (map[string]string, error) {
+	secrets, err := FindRedactionSecrets(ctx, env, project)
+	if err != nil {
+		return nil, err
+	}
+	if len(secrets) == 0 {
+		return map[string]string{}, nil
+	}
+	keyring, err := conf.keyring()
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]string{}
+	for _, secret := range secrets {
+		kek, ok := keyring[secret.KeyID]
+		if !ok {
+			return nil, errors.Errorf("redaction secret '%s' for project '%s' uses unknown encryption key '%s'", secret.ID, project, secret.KeyID)
+		}
+		value, err := open(kek, secret.Value, []byte(project))
+		if err != nil {
+			return nil, errors.Wrapf(err, "decrypting redaction secret '%s' for project '%s'", secret.ID, project)
+		}
+		values[string(value)] = secret.ID
+	}
+
+	return values, nil
+}
+
+// findRedactionSecretsUpdatedAt returns when the secrets registered for the
+// project last changed, or the zero time if the project has no secrets.
+func findRedactionSecretsUpdatedAt(ctx context.Context, env cedar.Environment, project string) (time.Time, error) {
+	secrets := &ProjectRedactionSecrets{}
+	err := env.GetDB().Collection(redactionSecretsCollection).FindOne(ctx,
+		bson.M{projectRedactionSecretsProjectKey: project},
+		options.FindOne().SetProjection(bson.M{projectRedactionSecretsUpdatedAtKey: 1}),
+	).Decode(secrets)
+	if err == mongo.ErrNoDocuments {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "finding redaction secrets for project '%s'", project)
+	}
+
+	return secrets.UpdatedAt, nil
+}
+
+// logRedactor masks secrets in log lines.
+type logRedactor struct {
+	patterns    []*regexp.Regexp
+	secrets     []string
+	privateKeys bool
+
+	// The inputs the redactor was compiled from, which are compared to
+	// decide whether a cached redactor is still current.
+	disableDefaultPatterns bool
+	projectPatterns        []string
+	secretsUpdatedAt       time.Time
+}
+
+// newLogRedactor returns a redactor for the logs of the project with the
+// default patterns, unless disabled, and the project's patterns and secrets.
+// The redactor is cached and is only compiled again after the project's
+// redaction configuration or secrets change.
+func newLogRedactor(ctx context.Context, env cedar.Environment, conf *CedarConfig, project string) (*logRedactor, error) {
+	r := &logRedactor{disableDefaultPatterns: conf.Redaction.DisableDefaultPatterns}
+	if project != "" {
+		for _, p := range conf.Redaction.Projects {
+			if p.Project == project {
+				r.projectPatterns = append(r.projectPatterns, p.Patterns...)
+			}
+		}
+
+		var err error
+		r.secretsUpdatedAt, err = findRedactionSecretsUpdatedAt(ctx, env, project)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return compiledLogRedactors.get(ctx, env, &conf.Encryption, project, r)
+}
+
+// logRedactorCache caches the compiled log redactor of each project so the
+// patterns are compiled and the secrets are decrypted again only after they
+// change.
+type logRedactorCache struct {
+	mu        sync.RWMutex
+	byProject map[string]*logRedactor
+}
+
+var compiledLogRedactors = &logRedactorCache{byProject: map[string]*logRedactor{}}
+
+// get returns the compiled redactor with the same inputs as the given
+// uncompiled redactor, compiling and caching it if the project's cached
+// redactor was compiled from different inputs.
+func (c *logRedactorCache) get(ctx context.Context, env cedar.Environment, conf *EncryptionConfig, project string, r *logRedactor) (*logRedactor, error) {
+	c.mu.RLock()
+	cached, ok := c.byProject[project]
+	c.mu.RUnlock()
+	if ok && cached.hasInputs(r) {
+		return cached, nil
+	}
+
+	if err := r.compile(ctx, env, conf, project); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.byProject[project] = r
+	c.mu.Unlock()
+
+	return r, nil
+}
+
+// hasInputs returns whether the redactor was compiled from the same inputs as
+// the other redactor.
+func (r *logRedactor) hasInputs(other *logRedactor) bool {
+	if r.disableDefaultPatterns != other.disableDefaultPatterns || !r.secretsUpdatedAt.Equal(other.secretsUpdatedAt) {
+		return false
+	}
+	if len(r.projectPatterns) != len(other.projectPatterns) {
+		return false
+	}
+	for i := range r.projectPatterns {
+		if r.projectPatterns[i] != other.projectPatterns[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compile compiles the redactor's patterns and decrypts the project's secrets.
+// Invalid project patterns are skipped, since they are rejected when the
+// configuration is saved.
+func (r *logRedactor) compile(ctx context.Context, env cedar.Environment, conf *EncryptionConfig, project string) error {
+	if !r.disableDefaultPatterns {
+		r.patterns = append(r.patterns, defaultRedactionPatterns...)
+		r.privateKeys = true
+	}
+	if project == "" {
+		return nil
+	}
+
+	for _, pattern := range r.projectPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			grip.Warning(message.WrapError(err, message.Fields{
+				"message": "skipping invalid redaction pattern",
+				"project": project,
+				"pattern": pattern,
+			}))
+			continue
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	values, err := findRedactionSecrets(ctx, env, conf, project)
+	if err != nil {
+		return err
+	}
+	for secret := range values {
+		r.secrets = append(r.secrets, secret)
+	}
+	// Mask longer secrets first so that a secret containing another one
+	// is masked as a whole.
+	sort.Slice(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+
+	return nil
+}
+
+// redact returns the data with its secrets masked and the number of masked
+// secrets.
+func (r *logRedactor) redact(data string) (string, int) {
+	count := 0
+	for _, secret := range r.secrets {
+		if n := strings.Count(data, secret); n > 0 {
+			data = strings.ReplaceAll(data, secret, RedactedText)
+			count += n
+		}
+	}
+	for _, re := range r.patterns {
+		data = re.ReplaceAllStringFunc(data, func(string) string {
+			count++
+			return RedactedText
+		})
+	}
+
+	return data, count
+}
+
+// lines returns a redactor for consecutive lines of a log.
+func (r *logRedactor) lines() *logLinesRedactor {
+	return &logLinesRedactor{logRedactor: r}
+}
+
+// logLinesRedactor masks secrets in consecutive lines of a log. Private keys
+// span several lines, so every line from a private key's begin marker through
+// its end marker is masked.
+type logLinesRedactor struct {
+	*logRedactor
+	inPrivateKey bool
+}
+
+// redact returns the line with its secrets masked and the number of masked
+// secrets. A private key is counted once, on the line with its begin marker.
+func (r *logLinesRedactor) redact(line string) (string, int) {
+	if !r.privateKeys {
+		return r.logRedactor.redact(line)
+	}
+
+	var b strings.Builder
+	count := 0
+	for {
+		if r.inPrivateKey {
+			b.WriteString(RedactedText)
+			end := privateKeyEndPattern.FindStringIndex(line)
+			if end == nil {
+				return b.String(), count
+			}
+			line = line[end[1]:]
+			r.inPrivateKey = false
+		}
+
+		begin := privateKeyBeginPattern.FindStringIndex(line)
+		if begin == nil {
+			break
+		}
+		data, n := r.logRedactor.redact(line[:begin[0]])
+		b.WriteString(data)
+		count += n + 1
+		line = line[begin[0]:]
+		r.inPrivateKey = true
+	}
+	data, n := r.logRedactor.redact(line)
+	b.WriteString(data)
+
+	return b.String(), count + n
+}
@@ -0,0 +1,276 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/defaults"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/pkg/errors"
+)
+
+// S3 credential sources.
+const (
+	// S3CredentialsStatic uses the configured AWS key and secret.
+	S3CredentialsStatic = "static"
+	// S3CredentialsEnvironment uses the AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY environment variables.
+	S3CredentialsEnvironment = "environment"
+	// S3CredentialsShared uses the configured profile of the shared
+	// credentials file.
+	S3CredentialsShared = "shared"
+	// S3CredentialsDefault uses the default AWS credential chain: the
+	// environment, the shared credentials file and then the IAM role of
+	// the EC2 instance or ECS task.
+	S3CredentialsDefault = "default"
+)
+
+// S3Config configures access to a bucket in S3 or an S3-compatible store.
+type S3Config struct {
+	// Bucket is the name of the bucket that the configuration applies
+	// to. It is ignored for the default configuration.
+	Bucket string `bson:"bucket,omitempty" json:"bucket,omitempty" yaml:"bucket,omitempty"`
+	// Region defaults to us-east-1.
+	Region string `bson:"region" json:"region" yaml:"region"`
+	// Endpoint is the URL of an S3-compatible store, such as MinIO, to use
+	// instead of AWS. Pail cannot be configured with a custom endpoint, so
+	// it is only used to generate download and pre-signed URLs and buckets
+	// cannot be created for it.
+	Endpoint string `bson:"endpoint" json:"endpoint" yaml:"endpoint"`
+	// PathStyle addresses the bucket in the path of the URL rather than
+	// as a virtual host.
+	PathStyle bool `bson:"path_style" json:"path_style" yaml:"path_style"`
+	// CredentialSource is one of static, environment, shared or default.
+	// It defaults to static if an AWS key is set and default otherwise.
+	CredentialSource string `bson:"credential_source" json:"credential_source" yaml:"credential_source"`
+	AWSKey           string `bson:"aws_key" json:"aws_key" yaml:"aws_key"`
+	AWSSecret        string `bson:"aws_secret" json:"aws_secret" yaml:"aws_secret"`
+	// Profile is the shared credentials profile of the shared credential
+	// source.
+	Profile string `bson:"profile" json:"profile" yaml:"profile"`
+	// RoleARN, if set, is assumed using the credentials of the credential
+	// source.
+	RoleARN string `bson:"role_arn" json:"role_arn" yaml:"role_arn"`
+}
+
+var (
+	s3ConfigBucketKey           = bsonutil.MustHaveTag(S3Config{}, "Bucket")
+	s3ConfigRegionKey           = bsonutil.MustHaveTag(S3Config{}, "Region")
+	s3ConfigEndpointKey         = bsonutil.MustHaveTag(S3Config{}, "Endpoint")
+	s3ConfigPathStyleKey        = bsonutil.MustHaveTag(S3Config{}, "PathStyle")
+	s3ConfigCredentialSourceKey = bsonutil.MustHaveTag(S3Config{}, "CredentialSource")
+	s3ConfigAWSKeyKey           = bsonutil.MustHaveTag(S3Config{}, "AWSKey")
+	s3ConfigAWSSecretKey        = bsonutil.MustHaveTag(S3Config{}, "AWSSecret")
+	s3ConfigProfileKey          = bsonutil.MustHaveTag(S3Config{}, "Profile")
+	s3ConfigRoleARNKey          = bsonutil.MustHaveTag(S3Config{}, "RoleARN")
+)
+
+// S3ConfigFor returns the S3 configuration of the given bucket. A per-bucket
+// configuration replaces the default configuration rather than merging with
+// it. Configurations without credentials fall back to the legacy AWS key and
+// secret.
+func (c *BucketConfig) S3ConfigFor(bucket string) S3Config {
+	conf := c.S3
+	for _, override := range c.S3Buckets {
+		if override.Bucket == bucket {
+			conf = override
+			break
+		}
+	}
+	conf.Bucket = bucket
+
+	if conf.CredentialSource == "" && conf.AWSKey == "" {
+		conf.AWSKey = c.AWSKey
+		conf.AWSSecret = c.AWSSecret
+	}
+	if conf.Region == "" {
+		conf.Region = defaultS3Region
+	}
+
+	return conf
+}
+
+// prestoS3Config returns the S3 configuration of the Presto bucket, which
+// assumes the Presto role unless the bucket configures its own.
+func (c *BucketConfig) prestoS3Config() S3Config {
+	conf := c.S3ConfigFor(c.PrestoBucket)
+	if conf.RoleARN == "" {
+		conf.RoleARN = c.PrestoRoleARN
+	}
+
+	return conf
+}
+
+// downloadBucketConfigTTL is how long the bucket configuration used to
+// generate download URLs is cached.
+const downloadBucketConfigTTL = time.Minute
+
+var downloadBucketConfig struct {
+	mu        sync.Mutex
+	conf      BucketConfig
+	expiresAt time.Time
+}
+
+// s3ConfigForDownload returns the S3 configuration of the bucket from the
+// global environment's application configuration, falling back to the
+// default configuration if it cannot be found. The application configuration
+// is cached briefly, since a URL is generated for every artifact of a
+// response.
+func s3ConfigForDownload(bucket string) S3Config {
+	downloadBucketConfig.mu.Lock()
+	defer downloadBucketConfig.mu.Unlock()
+
+	if time.Now().After(downloadBucketConfig.expiresAt) {
+		conf := &CedarConfig{}
+		conf.Setup(cedar.GetEnvironment())
+		if err := conf.Find(); err != nil {
+			conf = &CedarConfig{}
+		}
+		downloadBucketConfig.conf = conf.Bucket
+		downloadBucketConfig.expiresAt = time.Now().Add(downloadBucketConfigTTL)
+	}
+
+	return downloadBucketConfig.conf.S3ConfigFor(bucket)
+}
+
+// ObjectURL returns the URL of the object with the given key in the bucket.
+func (c *S3Config) ObjectURL(key string) string {
+	key = strings.TrimPrefix(strings.Replace(key, "\\", "/", -1), "/")
+
+	if c.Endpoint != "" {
+		endpoint, err := url.Parse(c.Endpoint)
+		if err != nil {
+			return ""
+		}
+		base := strings.TrimSuffix(endpoint.Path, "/")
+		if c.PathStyle {
+			return fmt.Sprintf("%s://%s%s/%s/%s", endpoint.Scheme, endpoint.Host, base, c.Bucket, key)
+		}
+		return fmt.Sprintf("%s://%s.%s%s/%s", endpoint.Scheme, c.Bucket, endpoint.Host, base, key)
+	}
+
+	host := "s3.amazonaws.com"
+	if c.Region != "" && c.Region != defaultS3Region {
+		host = fmt.Sprintf("s3.%s.amazonaws.com", c.Region)
+	}
+	if c.PathStyle {
+		return fmt.Sprintf("https://%s/%s/%s", host, c.Bucket, key)
+	}
+	return fmt.Sprintf("https://%s.%s/%s", c.Bucket, host, key)
+}
+
+func (c *S3Config) credentials() (*credentials.Credentials, error) {
+	source := c.CredentialSource
+	if source == "" {
+		source = S3CredentialsDefault
+		if c.AWSKey != "" {
+			source = S3CredentialsStatic
+		}
+	}
+
+	var creds *credentials.Credentials
+	switch source {
+	case S3CredentialsStatic:
+		if c.AWSKey == "" || c.AWSSecret == "" {
+			return nil, errors.New("must specify an AWS key and secret for static credentials")
+		}
+		creds = pail.CreateAWSCredentials(c.AWSKey, c.AWSSecret, "")
+	case S3CredentialsEnvironment:
+		creds = credentials.NewEnvCredentials()
+	case S3CredentialsShared:
+		creds = credentials.NewSharedCredentials("", c.Profile)
+	case S3CredentialsDefault:
+		creds = defaults.CredChain(defaults.Config(), defaults.Handlers())
+	default:
+		return nil, errors.Errorf("invalid S3 credential source '%s'", source)
+	}
+
+	if c.RoleARN != "" {
+		sess, err := session.NewSession(&aws.Config{
+			Region:      aws.String(c.Region),
+			Credentials: creds,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "creating AWS session to assume role")
+		}
+		creds = stscreds.NewCredentials(sess, c.RoleARN)
+	}
+
+	return creds, nil
+}
+
+// PresignGetURLs returns pre-signed URLs to download the objects with the
+// given keys from the bucket, which expire after the given duration. Signing
+// happens locally, so no requests are made to S3.
+func (c *S3Config) PresignGetURLs(keys []string, expiration time.Duration) ([]string, error) {
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	awsConf := &aws.Config{
+		Region:           aws.String(c.Region),
+		Credentials:      creds,
+		S3ForcePathStyle: aws.Bool(c.PathStyle),
+	}
+	if c.Endpoint != "" {
+		awsConf.Endpoint = aws.String(c.Endpoint)
+	}
+	sess, err := session.NewSession(awsConf)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating AWS session")
+	}
+	svc := s3.New(sess)
+
+	urls := make([]string, 0, len(keys))
+	for _, key := range keys {
+		req, _ := svc.GetObjectRequest(&s3.GetObjectInput{
+			Bucket: aws.String(c.Bucket),
+			Key:    aws.String(strings.TrimPrefix(strings.Replace(key, "\\", "/", -1), "/")),
+		})
+		url, err := req.Presign(expiration)
+		if err != nil {
+			return nil, errors.Wrapf(err, "pre-signing URL for key '%s'", key)
+		}
+		urls = append(urls, url)
+	}
+
+	return urls, nil
+}
+
+// createBucket returns a pail S3 bucket with the given prefix in the
+// configured bucket. The AWS SDK chooses the addressing style of the
+// bucket's requests itself, so path-style addressing only applies to URLs.
+func (c *S3Config) createBucket(prefix, permissions string, compress bool) (pail.Bucket, error) {
+	if c.Endpoint != "" {
+		return nil, errors.Errorf("cannot store data in bucket '%s' at custom endpoint '%s': pail does not support custom S3 endpoints", c.Bucket, c.Endpoint)
+	}
+	creds, err := c.credentials()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	opts := pail.S3Options{
+		Name:        c.Bucket,
+		Prefix:      prefix,
+		Region:      c.Region,
+		Permissions: pail.S3Permissions(permissions),
+		Credentials: creds,
+		MaxRetries:  utility.ToIntPtr(defaultS3MaxRetries),
+		Compress:    compress,
+	}
+	b, err := pail.NewS3Bucket(opts)
+
+	return b, errors.WithStack(err)
+}
@@ -0,0 +1,194 @@
+package model
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestS3ConfigFor(t *testing.T) {
+	conf := BucketConfig{
+		AWSKey:    "legacy_key",
+		AWSSecret: "legacy_secret",
+		S3:        S3Config{Region: "eu-west-1"},
+		S3Buckets: []S3Config{
+			{
+				Bucket:           "minio",
+				Endpoint:         "http://localhost:9000",
+				PathStyle:        true,
+				CredentialSource: S3CredentialsEnvironment,
+			},
+			{
+				Bucket: "keyed",
+				AWSKey: "key",
+			},
+		},
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		s3Conf := conf.S3ConfigFor("bucket")
+		assert.Equal(t, "bucket", s3Conf.Bucket)
+		assert.Equal(t, "eu-west-1", s3Conf.Region)
+		assert.Equal(t, "legacy_key", s3Conf.AWSKey)
+		assert.Equal(t, "legacy_secret", s3Conf.AWSSecret)
+	})
+	t.Run("Override", func(t *testing.T) {
+		s3Conf := conf.S3ConfigFor("minio")
+		assert.Equal(t, defaultS3Region, s3Conf.Region)
+		assert.Equal(t, "http://localhost:9000", s3Conf.Endpoint)
+		assert.True(t, s3Conf.PathStyle)
+		assert.Empty(t, s3Conf.AWSKey)
+	})
+	t.Run("OverrideWithKey", func(t *testing.T) {
+		s3Conf := conf.S3ConfigFor("keyed")
+		assert.Equal(t, "key", s3Conf.AWSKey)
+		assert.Empty(t, s3Conf.AWSSecret)
+	})
+}
+
+func TestS3ConfigObjectURL(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		conf     S3Config
+		expected string
+	}{
+		{
+			name:     "DefaultRegion",
+			conf:     S3Config{Bucket: "bucket", Region: defaultS3Region},
+			expected: "https://bucket.s3.amazonaws.com/prefix/key",
+		},
+		{
+			name:     "Region",
+			conf:     S3Config{Bucket: "bucket", Region: "eu-west-1"},
+			expected: "https://bucket.s3.eu-west-1.amazonaws.com/prefix/key",
+		},
+		{
+			name:     "PathStyle",
+			conf:     S3Config{Bucket: "bucket", Region: "eu-west-1", PathStyle: true},
+			expected: "https://s3.eu-west-1.amazonaws.com/bucket/prefix/key",
+		},
+		{
+			name:     "Endpoint",
+			conf:     S3Config{Bucket: "bucket", Endpoint: "https://storage.example.com"},
+			expected: "https://bucket.storage.example.com/prefix/key",
+		},
+		{
+			name:     "EndpointPathStyle",
+			conf:     S3Config{Bucket: "bucket", Endpoint: "http://localhost:9000/", PathStyle: true},
+			expected: "http://localhost:9000/bucket/prefix/key",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.conf.ObjectURL("/prefix/key"))
+		})
+	}
+}
+
+func TestS3ConfigCredentials(t *testing.T) {
+	t.Run("Static", func(t *testing.T) {
+		conf := S3Config{AWSKey: "key", AWSSecret: "secret"}
+		creds, err := conf.credentials()
+		require.NoError(t, err)
+		value, err := creds.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "key", value.AccessKeyID)
+		assert.Equal(t, "secret", value.SecretAccessKey)
+	})
+	t.Run("StaticWithoutSecret", func(t *testing.T) {
+		conf := S3Config{CredentialSource: S3CredentialsStatic, AWSKey: "key"}
+		_, err := conf.credentials()
+		assert.Error(t, err)
+	})
+	t.Run("Environment", func(t *testing.T) {
+		for key, value := range map[string]string{
+			"AWS_ACCESS_KEY_ID":     "env_key",
+			"AWS_SECRET_ACCESS_KEY": "env_secret",
+		} {
+			original, ok := os.LookupEnv(key)
+			require.NoError(t, os.Setenv(key, value))
+			defer func(key string) {
+				if ok {
+					assert.NoError(t, os.Setenv(key, original))
+				} else {
+					assert.NoError(t, os.Unsetenv(key))
+				}
+			}(key)
+		}
+		conf := S3Config{CredentialSource: S3CredentialsEnvironment}
+		creds, err := conf.credentials()
+		require.NoError(t, err)
+		value, err := creds.Get()
+		require.NoError(t, err)
+		assert.Equal(t, "env_key", value.AccessKeyID)
+	})
+	t.Run("Default", func(t *testing.T) {
+		creds, err := (&S3Config{}).credentials()
+		require.NoError(t, err)
+		assert.NotNil(t, creds)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		conf := S3Config{CredentialSource: "invalid"}
+		_, err := conf.credentials()
+		assert.Error(t, err)
+	})
+}
+
+func TestS3ConfigCreateBucket(t *testing.T) {
+	t.Run("Endpoint", func(t *testing.T) {
+		conf := S3Config{Bucket: "bucket", Region: defaultS3Region, Endpoint: "http://localhost:9000", AWSKey: "key", AWSSecret: "secret"}
+		_, err := conf.createBucket("prefix", string(pail.S3PermissionsPrivate), false)
+		assert.Error(t, err)
+	})
+	t.Run("PathStyle", func(t *testing.T) {
+		conf := S3Config{Bucket: "bucket", Region: "eu-west-1", PathStyle: true, AWSKey: "key", AWSSecret: "secret"}
+		b, err := conf.createBucket("prefix", string(pail.S3PermissionsPrivate), false)
+		require.NoError(t, err)
+		assert.NotNil(t, b)
+	})
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		conf := S3Config{Bucket: "bucket", CredentialSource: "invalid"}
+		_, err := conf.createBucket("prefix", string(pail.S3PermissionsPrivate), false)
+		assert.Error(t, err)
+	})
+}
+
+func TestS3ConfigPresignGetURLs(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		conf   S3Config
+		prefix string
+	}{
+		{
+			name:   "AWS",
+			conf:   S3Config{Bucket: "bucket", Region: defaultS3Region},
+			prefix: "https://bucket.s3.amazonaws.com/prefix/key?",
+		},
+		{
+			name:   "EndpointPathStyle",
+			conf:   S3Config{Bucket: "bucket", Region: defaultS3Region, Endpoint: "http://localhost:9000", PathStyle: true},
+			prefix: "http://localhost:9000/bucket/prefix/key?",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			test.conf.AWSKey = "key"
+			test.conf.AWSSecret = "secret"
+			urls, err := test.conf.PresignGetURLs([]string{"/prefix/key"}, time.Hour)
+			require.NoError(t, err)
+			require.Len(t, urls, 1)
+			assert.True(t, strings.HasPrefix(urls[0], test.prefix), urls[0])
+			assert.Contains(t, urls[0], "X-Amz-Expires=3600")
+			assert.Contains(t, urls[0], "X-Amz-Credential=key%2F")
+			assert.Contains(t, urls[0], "X-Amz-Signature=")
+		})
+	}
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		conf := S3Config{Bucket: "bucket", CredentialSource: "invalid"}
+		_, err := conf.PresignGetURLs([]string{"key"}, time.Hour)
+		assert.Error(t, err)
+	})
+}
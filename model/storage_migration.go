@@ -0,0 +1,516 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// storageMigrationKey is the key of the temporary MigrationStats
+	// sub-document recorded on documents whose artifacts are migrated.
+	storageMigrationKey   = "storage_migration"
+	storageMigrationIDKey = "_id"
+
+	defaultStorageMigrationBatchSize = 100
+	defaultStorageMigrationTimeout   = 30 * time.Minute
+)
+
+// StorageMigrationCollections are the collections whose artifacts can be
+// migrated between pail backends.
+var StorageMigrationCollections = []string{
+	buildloggerCollection,
+	testResultsCollection,
+	systemMetricsCollection,
+	perfResultCollection,
+}
+
+// StorageMigrationOptions describes the migration of the artifacts of a
+// collection from one pail backend to another. Logs, test results and system
+// metrics are always read from their configured bucket, so only the type of
+// their backend can change; performance artifacts record their own bucket and
+// may additionally be moved between buckets.
+type StorageMigrationOptions struct {
+	FromType   PailType `bson:"from_type" json:"from_type" yaml:"from_type"`
+	FromBucket string   `bson:"from_bucket,omitempty" json:"from_bucket,omitempty" yaml:"from_bucket,omitempty"`
+	ToType     PailType `bson:"to_type" json:"to_type" yaml:"to_type"`
+	ToBucket   string   `bson:"to_bucket,omitempty" json:"to_bucket,omitempty" yaml:"to_bucket,omitempty"`
+	// ObjectsPerSecond limits the rate at which objects are copied. Zero
+	// means no limit.
+	ObjectsPerSecond float64 `bson:"objects_per_second,omitempty" json:"objects_per_second,omitempty" yaml:"objects_per_second,omitempty"`
+}
+
+// Validate ensures that the options are valid for migrating the given
+// collection.
+func (o *StorageMigrationOptions) Validate(collection string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(o.FromType == "", "must specify the type to migrate from")
+	catcher.NewWhen(o.ToType == "", "must specify the type to migrate to")
+	catcher.ErrorfWhen(o.ObjectsPerSecond < 0, "invalid rate limit %f", o.ObjectsPerSecond)
+
+	switch collection {
+	case buildloggerCollection, testResultsCollection, systemMetricsCollection:
+		catcher.NewWhen(o.FromBucket != "" || o.ToBucket != "", "can only migrate performance artifacts between buckets")
+		catcher.NewWhen(o.FromType == o.ToType, "must migrate to a different type")
+	case perfResultCollection:
+		catcher.NewWhen(o.FromType == o.ToType && (o.ToBucket == "" || o.FromBucket == o.ToBucket), "must migrate to a different type or bucket")
+	default:
+		catcher.Errorf("cannot migrate the artifacts of collection '%s'", collection)
+	}
+
+	return catcher.Resolve()
+}
+
+// StorageMigrator migrates the artifacts of the collection of a storage
+// migration batch job controller in batches. Each migrated document is
+// claimed by recording MigrationStats on it, so that concurrent migrators do
+// not process the same document and interrupted migrations resume where they
+// stopped; the MigrationStats are removed once the document is migrated. The
+// source data is left in place.
+type StorageMigrator struct {
+	controller *BatchJobController
+	env        cedar.Environment
+	conf       *CedarConfig
+	limiter    *rateLimiter
+}
+
+// NewStorageMigrator returns a StorageMigrator for the given storage
+// migration batch job controller.
+func NewStorageMigrator(env cedar.Environment, controller *BatchJobController) (*StorageMigrator, error) {
+	if env == nil {
+		return nil, errors.New("cannot migrate storage with a nil environment")
+	}
+	if controller == nil || controller.StorageMigration == nil {
+		return nil, errors.New("batch job controller is not a storage migration")
+	}
+	if err := controller.StorageMigration.Validate(controller.Collection); err != nil {
+		return nil, errors.Wrap(err, "invalid storage migration options")
+	}
+
+	conf := &CedarConfig{}
+	conf.Setup(env)
+	if err := conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+
+	return &StorageMigrator{
+		controller: controller,
+		env:        env,
+		conf:       conf,
+		limiter:    newRateLimiter(env, controller.ID, controller.StorageMigration.ObjectsPerSecond),
+	}, nil
+}
+
+// MigrateBatch migrates up to the controller's batch size of documents and
+// returns the number of documents migrated and the number of documents that
+// failed to migrate. Failed documents are logged and skipped, and are retried
+// once their claim has timed out. An error is only returned if the batch
+// could not continue.
+func (m *StorageMigrator) MigrateBatch(ctx context.Context) (int, int, error) {
+	batchSize := m.controller.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultStorageMigrationBatchSize
+	}
+
+	migrated, failed := 0, 0
+	for i := 0; i < batchSize; i++ {
+		if err := ctx.Err(); err != nil {
+			return migrated, failed, err
+		}
+
+		doc, err := m.claim(ctx)
+		if err != nil {
+			return migrated, failed, err
+		}
+		if doc == nil {
+			break
+		}
+
+		if err = m.migrate(ctx, doc); err != nil {
+			if ctx.Err() != nil {
+				return migrated, failed, errors.Wrapf(err, "migrating document '%v'", doc[storageMigrationIDKey])
+			}
+			grip.Warning(message.WrapError(err, message.Fields{
+				"message":    "skipping document that failed to migrate",
+				"collection": m.controller.Collection,
+				"id":         doc[storageMigrationIDKey],
+				"migrator":   m.controller.ID,
+			}))
+			failed++
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, failed, nil
+}
+
+// Remaining returns whether any documents remain to be migrated.
+func (m *StorageMigrator) Remaining(ctx context.Context) (bool, error) {
+	count, err := m.env.GetDB().Collection(m.controller.Collection).CountDocuments(ctx, m.filter(), options.Count().SetLimit(1))
+	if err != nil {
+		return false, errors.Wrap(err, "counting documents to migrate")
+	}
+
+	return count > 0, nil
+}
+
+func (m *StorageMigrator) typeKey() string {
+	switch m.controller.Collection {
+	case buildloggerCollection:
+		return bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoTypeKey)
+	case testResultsCollection:
+		return bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoTypeKey)
+	case systemMetricsCollection:
+		return bsonutil.GetDottedKeyName(systemMetricsArtifactKey, metricsArtifactInfoOptionsKey, metricsArtifactOptionsTypeKey)
+	default:
+		return ""
+	}
+}
+
+func (m *StorageMigrator) completedAtKey() string {
+	switch m.controller.Collection {
+	case buildloggerCollection:
+		return logCompletedAtKey
+	case testResultsCollection:
+		return testResultsCompletedAtKey
+	case systemMetricsCollection:
+		return systemMetricsCompletedAtKey
+	default:
+		return perfCompletedAtKey
+	}
+}
+
+// filter returns the query for completed documents that still have artifacts
+// to migrate.
+func (m *StorageMigrator) filter() bson.M {
+	opts := m.controller.StorageMigration
+	filter := bson.M{m.completedAtKey(): bson.M{"$gt": time.Time{}}}
+
+	if m.controller.Collection == perfResultCollection {
+		match := bson.M{artifactInfoTypeKey: opts.FromType}
+		if opts.FromBucket != "" {
+			match[artifactInfoBucketKey] = opts.FromBucket
+		}
+		filter[perfArtifactsKey] = bson.M{"$elemMatch": match}
+	} else {
+		filter[m.typeKey()] = opts.FromType
+	}
+
+	return filter
+}
+
+// claim atomically records the migrator on the next document to migrate that
+// is not already claimed by this migrator, or whose claim has timed out, and
+// returns it. It returns nil if there is no such document.
+func (m *StorageMigrator) claim(ctx context.Context) (bson.M, error) {
+	timeout := m.controller.Timeout
+	if timeout <= 0 {
+		timeout = defaultStorageMigrationTimeout
+	}
+	now := time.Now()
+
+	filter := m.filter()
+	filter["$or"] = []bson.M{
+		{bsonutil.GetDottedKeyName(storageMigrationKey, MigrationStatsMigratorIDKey): bson.M{"$ne": m.controller.ID}},
+		{bsonutil.GetDottedKeyName(storageMigrationKey, MigrationStatsStartedAtKey): bson.M{"$lt": now.Add(-timeout)}},
+	}
+
+	doc := bson.M{}
+	err := m.env.GetDB().Collection(m.controller.Collection).FindOneAndUpdate(
+		ctx,
+		filter,
+		bson.M{"$set": bson.M{storageMigrationKey: MigrationStats{
+			MigratorID: m.controller.ID,
+			StartedAt:  &now,
+			Version:    m.controller.Version,
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&doc)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "claiming document to migrate")
+	}
+
+	return doc, nil
+}
+
+func (m *StorageMigrator) migrate(ctx context.Context, doc bson.M) error {
+	if m.controller.Collection == perfResultCollection {
+		return m.migratePerformanceResult(ctx, doc)
+	}
+
+	var bucket, prefix string
+	var compress bool
+	switch m.controller.Collection {
+	case buildloggerCollection:
+		log := &Log{}
+		if err := decodeMigrationDocument(doc, log); err != nil {
+			return err
+		}
+		bucket, prefix, compress = m.conf.Bucket.BuildLogsBucket, log.Artifact.Prefix, true
+	case testResultsCollection:
+		results := &TestResults{}
+		if err := decodeMigrationDocument(doc, results); err != nil {
+			return err
+		}
+		bucket, prefix, compress = m.conf.Bucket.TestResultsBucket, results.Artifact.Prefix, true
+	case systemMetricsCollection:
+		sm := &SystemMetrics{}
+		if err := decodeMigrationDocument(doc, sm); err != nil {
+			return err
+		}
+		bucket, prefix, compress = m.conf.Bucket.SystemMetricsBucket, sm.Artifact.Prefix, true
+	}
+
+	opts := m.controller.StorageMigration
+	from, err := opts.FromType.Create(ctx, m.env, bucket, prefix, string(pail.S3PermissionsPrivate), compress)
+	if err != nil {
+		return errors.Wrap(err, "creating source bucket")
+	}
+	to, err := opts.ToType.Create(ctx, m.env, bucket, prefix, string(pail.S3PermissionsPrivate), compress)
+	if err != nil {
+		return errors.Wrap(err, "creating destination bucket")
+	}
+
+	it, err := from.List(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "listing source bucket")
+	}
+	count := 0
+	for it.Next(ctx) {
+		if err = m.limiter.wait(ctx); err != nil {
+			return errors.WithStack(err)
+		}
+		if err = copyVerified(ctx, from, to, it.Item().Name()); err != nil {
+			return errors.WithStack(err)
+		}
+		count++
+	}
+	if err = it.Err(); err != nil {
+		return errors.Wrap(err, "iterating source bucket")
+	}
+
+	return m.complete(ctx, doc, count, bson.M{m.typeKey(): opts.ToType}, bson.M{m.typeKey(): opts.FromType})
+}
+
+func (m *StorageMigrator) migratePerformanceResult(ctx context.Context, doc bson.M) error {
+	result := &PerformanceResult{}
+	if err := decodeMigrationDocument(doc, result); err != nil {
+		return err
+	}
+
+	opts := m.controller.StorageMigration
+	artifacts := make([]ArtifactInfo, len(result.Artifacts))
+	copy(artifacts, result.Artifacts)
+	count := 0
+	for i, artifact := range artifacts {
+		if artifact.Type != opts.FromType || (opts.FromBucket != "" && artifact.Bucket != opts.FromBucket) {
+			continue
+		}
+
+		toBucket := artifact.Bucket
+		if opts.ToBucket != "" {
+			toBucket = opts.ToBucket
+		}
+		from, err := artifact.Type.Create(ctx, m.env, artifact.Bucket, artifact.Prefix, string(pail.S3PermissionsPrivate), false)
+		if err != nil {
+			return errors.Wrap(err, "creating source bucket")
+		}
+		to, err := opts.ToType.Create(ctx, m.env, toBucket, artifact.Prefix, string(pail.S3PermissionsPrivate), false)
+		if err != nil {
+			return errors.Wrap(err, "creating destination bucket")
+		}
+
+		if err = m.limiter.wait(ctx); err != nil {
+			return errors.WithStack(err)
+		}
+		if err = copyVerified(ctx, from, to, artifact.Path); err != nil {
+			return errors.WithStack(err)
+		}
+
+		artifacts[i].Type = opts.ToType
+		artifacts[i].Bucket = toBucket
+		count++
+	}
+
+	// Matching on the original artifacts ensures that artifacts appended
+	// during the migration are not lost.
+	return m.complete(ctx, doc, count, bson.M{perfArtifactsKey: artifacts}, bson.M{perfArtifactsKey: result.Artifacts})
+}
+
+// complete atomically updates the artifact metadata of the document and
+// removes its claim, as long as the document is still claimed by this
+// migrator and has not otherwise changed.
+func (m *StorageMigrator) complete(ctx context.Context, doc bson.M, count int, update, match bson.M) error {
+	filter := bson.M{
+		storageMigrationIDKey: doc[storageMigrationIDKey],
+		bsonutil.GetDottedKeyName(storageMigrationKey, MigrationStatsMigratorIDKey): m.controller.ID,
+	}
+	for key, value := range match {
+		filter[key] = value
+	}
+
+	updateResult, err := m.env.GetDB().Collection(m.controller.Collection).UpdateOne(ctx, filter, bson.M{
+		"$set":   update,
+		"$unset": bson.M{storageMigrationKey: 1},
+	})
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":    m.controller.Collection,
+		"id":            doc[storageMigrationIDKey],
+		"migrator":      m.controller.ID,
+		"objects":       count,
+		"update_result": updateResult,
+		"op":            "migrate artifact storage",
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating artifact metadata")
+	}
+	if updateResult.MatchedCount == 0 {
+		return errors.New("document changed during migration")
+	}
+
+	return nil
+}
+
+func decodeMigrationDocument(doc bson.M, out interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return errors.Wrap(err, "marshalling document")
+	}
+
+	return errors.Wrap(bson.Unmarshal(raw, out), "unmarshalling document")
+}
+
+// copyVerified copies the object with the given key from one bucket to the
+// other and verifies that the SHA-256 checksum of the copy matches that of
+// the source.
+func copyVerified(ctx context.Context, from, to pail.Bucket, key string) error {
+	r, err := from.Get(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "getting object '%s'", key)
+	}
+	hash := sha256.New()
+	err = to.Put(ctx, key, io.TeeReader(r, hash))
+	grip.Warning(message.WrapError(r.Close(), message.Fields{
+		"message": "could not close reader",
+		"key":     key,
+	}))
+	if err != nil {
+		return errors.Wrapf(err, "putting object '%s'", key)
+	}
+	expected := hash.Sum(nil)
+
+	r, err = to.Get(ctx, key)
+	if err != nil {
+		return errors.Wrapf(err, "getting copied object '%s'", key)
+	}
+	defer func() {
+		grip.Warning(message.WrapError(r.Close(), message.Fields{
+			"message": "could not close reader",
+			"key":     key,
+		}))
+	}()
+	hash.Reset()
+	if _, err = io.Copy(hash, r); err != nil {
+		return errors.Wrapf(err, "reading copied object '%s'", key)
+	}
+	if !bytes.Equal(expected, hash.Sum(nil)) {
+		return errors.Errorf("checksum mismatch for copied object '%s'", key)
+	}
+
+	return nil
+}
+
+// rateLimiter spaces out operations to a maximum rate across all migrators
+// of a storage migration batch job controller, including those of other
+// application servers, by reserving time slots on the controller document. A
+// nil rateLimiter does not limit.
+type rateLimiter struct {
+	env          cedar.Environment
+	controllerID string
+	interval     time.Duration
+}
+
+func newRateLimiter(env cedar.Environment, controllerID string, perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		env:          env,
+		controllerID: controllerID,
+		interval:     time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	slot, err := l.reserve(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if wait := time.Until(slot); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return nil
+}
+
+// reserve atomically advances the controller's next free slot by the
+// limiter's interval and returns the reserved slot.
+func (l *rateLimiter) reserve(ctx context.Context) (time.Time, error) {
+	coll := l.env.GetDB().Collection(BatchJobControllerCollection)
+	for {
+		if err := ctx.Err(); err != nil {
+			return time.Time{}, err
+		}
+
+		controller := &BatchJobController{}
+		if err := coll.FindOne(ctx, bson.M{batchJobControllerIDKey: l.controllerID}).Decode(controller); err != nil {
+			return time.Time{}, errors.Wrapf(err, "finding batch job controller '%s'", l.controllerID)
+		}
+
+		filter := bson.M{batchJobControllerIDKey: l.controllerID}
+		if controller.RateLimitNextSlot.IsZero() {
+			filter[batchJobControllerRateLimitNextSlotKey] = bson.M{"$exists": false}
+		} else {
+			filter[batchJobControllerRateLimitNextSlotKey] = controller.RateLimitNextSlot
+		}
+		slot := controller.RateLimitNextSlot
+		if now := time.Now(); slot.Before(now) {
+			slot = now
+		}
+
+		updateResult, err := coll.UpdateOne(ctx, filter, bson.M{"$set": bson.M{batchJobControllerRateLimitNextSlotKey: slot.Add(l.interval)}})
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "reserving rate limit slot on batch job controller '%s'", l.controllerID)
+		}
+		if updateResult.MatchedCount > 0 {
+			return slot, nil
+		}
+		// Another migrator reserved the slot first, so try the next one.
+	}
+}
@@ -0,0 +1,280 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStorageMigrationOptionsValidate(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		collection string
+		opts       StorageMigrationOptions
+		hasErr     bool
+	}{
+		{
+			name:       "ValidLogs",
+			collection: buildloggerCollection,
+			opts:       StorageMigrationOptions{FromType: PailGridFS, ToType: PailS3},
+		},
+		{
+			name:       "ValidPerformanceBucketMove",
+			collection: perfResultCollection,
+			opts:       StorageMigrationOptions{FromType: PailS3, FromBucket: "old", ToType: PailS3, ToBucket: "new"},
+		},
+		{
+			name:       "MissingTypes",
+			collection: testResultsCollection,
+			hasErr:     true,
+		},
+		{
+			name:       "SameType",
+			collection: systemMetricsCollection,
+			opts:       StorageMigrationOptions{FromType: PailS3, ToType: PailS3},
+			hasErr:     true,
+		},
+		{
+			name:       "BucketMoveForLogs",
+			collection: buildloggerCollection,
+			opts:       StorageMigrationOptions{FromType: PailGridFS, ToType: PailS3, ToBucket: "new"},
+			hasErr:     true,
+		},
+		{
+			name:       "SamePerformanceBucket",
+			collection: perfResultCollection,
+			opts:       StorageMigrationOptions{FromType: PailS3, FromBucket: "bucket", ToType: PailS3, ToBucket: "bucket"},
+			hasErr:     true,
+		},
+		{
+			name:       "NegativeRate",
+			collection: buildloggerCollection,
+			opts:       StorageMigrationOptions{FromType: PailGridFS, ToType: PailS3, ObjectsPerSecond: -1},
+			hasErr:     true,
+		},
+		{
+			name:       "InvalidCollection",
+			collection: "users",
+			opts:       StorageMigrationOptions{FromType: PailGridFS, ToType: PailS3},
+			hasErr:     true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.opts.Validate(test.collection)
+			if test.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCopyVerified(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	from, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+	to, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+	require.NoError(t, err)
+	require.NoError(t, from.Put(ctx, "key", bytes.NewBufferString("data")))
+
+	t.Run("Copies", func(t *testing.T) {
+		require.NoError(t, copyVerified(ctx, from, to, "key"))
+		r, err := to.Get(ctx, "key")
+		require.NoError(t, err)
+		defer r.Close()
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+	})
+	t.Run("MissingKey", func(t *testing.T) {
+		assert.Error(t, copyVerified(ctx, from, to, "missing"))
+	})
+}
+
+func TestRateLimiter(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(BatchJobControllerCollection).Drop(ctx))
+	}()
+	require.NoError(t, SaveBatchJobController(ctx, env, &BatchJobController{ID: "limited"}))
+
+	t.Run("Unlimited", func(t *testing.T) {
+		limiter := newRateLimiter(env, "limited", 0)
+		assert.Nil(t, limiter)
+		assert.NoError(t, limiter.wait(ctx))
+	})
+	t.Run("SpacesOperationsAcrossLimiters", func(t *testing.T) {
+		limiters := []*rateLimiter{newRateLimiter(env, "limited", 100), newRateLimiter(env, "limited", 100)}
+		start := time.Now()
+		for i := 0; i < 4; i++ {
+			require.NoError(t, limiters[i%2].wait(ctx))
+		}
+		assert.True(t, time.Since(start) >= 30*time.Millisecond)
+
+		controller, err := FindBatchJobController(ctx, env, "limited")
+		require.NoError(t, err)
+		assert.False(t, controller.RateLimitNextSlot.IsZero())
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		limiter := newRateLimiter(env, "limited", 0.001)
+		require.NoError(t, limiter.wait(ctx))
+		cctx, ccancel := context.WithCancel(ctx)
+		ccancel()
+		assert.Error(t, limiter.wait(cctx))
+	})
+	t.Run("NoController", func(t *testing.T) {
+		limiter := newRateLimiter(env, "DNE", 100)
+		assert.Error(t, limiter.wait(ctx))
+	})
+}
+
+func TestStorageMigratorPerformanceResults(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(perfResultCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+	}()
+	conf := NewCedarConfig(env)
+	require.NoError(t, conf.Save())
+
+	fromDir := t.TempDir()
+	toDir := t.TempDir()
+	from, err := pail.NewLocalBucket(pail.LocalOptions{Path: fromDir, Prefix: "prefix"})
+	require.NoError(t, err)
+	require.NoError(t, from.Put(ctx, "data.ftdc", bytes.NewBufferString("ftdc data")))
+
+	completed := CreatePerformanceResult(PerformanceResultInfo{Project: "completed"}, []ArtifactInfo{
+		{Type: PailLocal, Bucket: fromDir, Prefix: "prefix", Path: "data.ftdc"},
+		{Type: PailS3, Bucket: "other", Prefix: "prefix", Path: "other.ftdc"},
+	}, nil)
+	completed.CompletedAt = time.Now()
+	running := CreatePerformanceResult(PerformanceResultInfo{Project: "running"}, []ArtifactInfo{
+		{Type: PailLocal, Bucket: fromDir, Prefix: "prefix", Path: "data.ftdc"},
+	}, nil)
+	_, err = db.Collection(perfResultCollection).InsertMany(ctx, []interface{}{completed, running})
+	require.NoError(t, err)
+
+	controller := &BatchJobController{
+		ID:         "migration",
+		Collection: perfResultCollection,
+		BatchSize:  10,
+		StorageMigration: &StorageMigrationOptions{
+			FromType: PailLocal,
+			ToType:   PailLocal,
+			ToBucket: toDir,
+		},
+	}
+	migrator, err := NewStorageMigrator(env, controller)
+	require.NoError(t, err)
+
+	migrated, failed, err := migrator.MigrateBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.Zero(t, failed)
+
+	result := &PerformanceResult{ID: completed.ID}
+	result.Setup(env)
+	require.NoError(t, result.Find(ctx))
+	require.Len(t, result.Artifacts, 2)
+	assert.Equal(t, PailLocal, result.Artifacts[0].Type)
+	assert.Equal(t, toDir, result.Artifacts[0].Bucket)
+	assert.Equal(t, "other", result.Artifacts[1].Bucket)
+
+	to, err := pail.NewLocalBucket(pail.LocalOptions{Path: toDir, Prefix: "prefix"})
+	require.NoError(t, err)
+	r, err := to.Get(ctx, "data.ftdc")
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "ftdc data", string(data))
+
+	count, err := db.Collection(perfResultCollection).CountDocuments(ctx, bson.M{storageMigrationKey: bson.M{"$exists": true}})
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	result = &PerformanceResult{ID: running.ID}
+	result.Setup(env)
+	require.NoError(t, result.Find(ctx))
+	assert.Equal(t, fromDir, result.Artifacts[0].Bucket)
+
+	migrated, failed, err = migrator.MigrateBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, migrated)
+	assert.Zero(t, failed)
+	remaining, err := migrator.Remaining(ctx)
+	require.NoError(t, err)
+	assert.False(t, remaining)
+}
+
+func TestStorageMigratorSkipsFailedDocuments(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(perfResultCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+	}()
+	conf := NewCedarConfig(env)
+	require.NoError(t, conf.Save())
+
+	fromDir := t.TempDir()
+	from, err := pail.NewLocalBucket(pail.LocalOptions{Path: fromDir, Prefix: "prefix"})
+	require.NoError(t, err)
+	require.NoError(t, from.Put(ctx, "data.ftdc", bytes.NewBufferString("ftdc data")))
+
+	good := CreatePerformanceResult(PerformanceResultInfo{Project: "good"}, []ArtifactInfo{
+		{Type: PailLocal, Bucket: fromDir, Prefix: "prefix", Path: "data.ftdc"},
+	}, nil)
+	good.CompletedAt = time.Now()
+	bad := CreatePerformanceResult(PerformanceResultInfo{Project: "bad"}, []ArtifactInfo{
+		{Type: PailLocal, Bucket: fromDir, Prefix: "prefix", Path: "missing.ftdc"},
+	}, nil)
+	bad.CompletedAt = time.Now()
+	_, err = db.Collection(perfResultCollection).InsertMany(ctx, []interface{}{good, bad})
+	require.NoError(t, err)
+
+	migrator, err := NewStorageMigrator(env, &BatchJobController{
+		ID:         "migration",
+		Collection: perfResultCollection,
+		BatchSize:  10,
+		StorageMigration: &StorageMigrationOptions{
+			FromType: PailLocal,
+			ToType:   PailLocal,
+			ToBucket: t.TempDir(),
+		},
+	})
+	require.NoError(t, err)
+
+	migrated, failed, err := migrator.MigrateBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, migrated)
+	assert.Equal(t, 1, failed)
+
+	migrated, failed, err = migrator.MigrateBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, migrated)
+	assert.Zero(t, failed)
+
+	remaining, err := migrator.Remaining(ctx)
+	require.NoError(t, err)
+	assert.True(t, remaining)
+}
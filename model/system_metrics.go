@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/evergreen-ci/pail"
 	"github.com/mongodb/anser/bsonutil"
 	"github.com/mongodb/grip"
@@ -253,9 +254,15 @@ func (sm *SystemMetrics) Append(ctx context.Context, metricType string, format F
 	if err != nil {
 		return errors.Wrap(err, "creating bucket")
 	}
+	bucket, err = conf.Encryption.encryptBucket(bucket, sm.Artifact.EncryptionKeyID)
+	if err != nil {
+		return errors.Wrap(err, "creating encrypted bucket")
+	}
 	if err := bucket.Put(ctx, key, bytes.NewReader(data)); err != nil {
 		return errors.Wrap(err, "uploading system metrics data to bucket")
 	}
+	metrics.IngestionRequests.WithLabelValues(metrics.ServiceSystemMetrics).Inc()
+	metrics.IngestedItems.WithLabelValues(metrics.ServiceSystemMetrics).Inc()
 
 	return errors.Wrap(sm.appendSystemMetricsChunkKey(ctx, metricType, format, key), "updating system metrics metadata during upload")
 }
@@ -396,6 +403,10 @@ func (sm *SystemMetrics) download(ctx context.Context, metricType string) (pail.
 	if err != nil {
 		return nil, MetricChunks{}, errors.Wrap(err, "creating bucket")
 	}
+	bucket, err = conf.Encryption.encryptBucket(bucket, sm.Artifact.EncryptionKeyID)
+	if err != nil {
+		return nil, MetricChunks{}, errors.Wrap(err, "creating encrypted bucket")
+	}
 
 	chunks, ok := sm.Artifact.MetricChunks[metricType]
 	if !ok {
@@ -18,6 +18,9 @@ type SystemMetricsArtifactInfo struct {
 	Prefix       string                       `bson:"prefix"`
 	MetricChunks map[string]MetricChunks      `bson:"metric_chunks"`
 	Options      SystemMetricsArtifactOptions `bson:"options"`
+	// EncryptionKeyID is the ID of the key that the system metrics chunks
+	// are encrypted with, if any.
+	EncryptionKeyID string `bson:"encryption_key_id,omitempty"`
 }
 
 // MetricChunks represents the chunks of data for a particular type of metric.
@@ -39,12 +42,13 @@ type SystemMetricsArtifactOptions struct {
 }
 
 var (
-	metricsArtifactInfoPrefixKey         = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "Prefix")
-	metricsArtifactInfoMetricChunksKey   = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "MetricChunks")
-	metricsArtifactInfoOptionsKey        = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "Options")
-	metricsArtifactOptionsTypeKey        = bsonutil.MustHaveTag(SystemMetricsArtifactOptions{}, "Type")
-	metricsArtifactOptionsSchemaKey      = bsonutil.MustHaveTag(SystemMetricsArtifactOptions{}, "Schema")
-	metricsArtifactOptionsCompressionKey = bsonutil.MustHaveTag(SystemMetricsArtifactOptions{}, "Compression")
-	metricsMetricChunksChunksKey         = bsonutil.MustHaveTag(MetricChunks{}, "Chunks")
-	metricsMetricChunksFormatKey         = bsonutil.MustHaveTag(MetricChunks{}, "Format")
+	metricsArtifactInfoPrefixKey          = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "Prefix")
+	metricsArtifactInfoMetricChunksKey    = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "MetricChunks")
+	metricsArtifactInfoOptionsKey         = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "Options")
+	metricsArtifactInfoEncryptionKeyIDKey = bsonutil.MustHaveTag(SystemMetricsArtifactInfo{}, "EncryptionKeyID")
+	metricsArtifactOptionsTypeKey         = bsonutil.MustHaveTag(SystemMetricsArtifactOptions{}, "Type")
+	metricsArtifactOptionsSchemaKey       = bsonutil.MustHaveTag(SystemMetricsArtifactOptions{}, "Schema")
+	metricsArtifactOptionsCompressionKey  = bsonutil.MustHaveTag(SystemMetricsArtifactOptions{}, "Compression")
+	metricsMetricChunksChunksKey          = bsonutil.MustHaveTag(MetricChunks{}, "Chunks")
+	metricsMetricChunksFormatKey          = bsonutil.MustHaveTag(MetricChunks{}, "Format")
 )
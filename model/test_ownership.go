@@ -0,0 +1,261 @@
+package model
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testOwnershipCollection = "test_ownership"
+
+// TestOwnershipRuleType is the kind of pattern of a test ownership rule.
+type TestOwnershipRuleType string
+
+const (
+	// TestOwnershipRuleGlob patterns match the whole test name, where '*'
+	// matches any sequence of characters and '?' matches any single
+	// character.
+	TestOwnershipRuleGlob TestOwnershipRuleType = "glob"
+	// TestOwnershipRuleRegex patterns are regular expressions matched
+	// anywhere in the test name.
+	TestOwnershipRuleRegex TestOwnershipRuleType = "regex"
+)
+
+// TestOwnershipRule maps the tests whose display names match the pattern to
+// the owning team.
+type TestOwnershipRule struct {
+	Pattern string                `bson:"pattern" json:"pattern"`
+	Type    TestOwnershipRuleType `bson:"type" json:"type"`
+	Team    string                `bson:"team" json:"team"`
+
+	regex *regexp.Regexp
+}
+
+func (r *TestOwnershipRule) compile() error {
+	if r.Pattern == "" {
+		return errors.New("pattern cannot be empty")
+	}
+	if r.Team == "" {
+		return errors.Errorf("team for pattern '%s' cannot be empty", r.Pattern)
+	}
+
+	expr := r.Pattern
+	switch r.Type {
+	case TestOwnershipRuleGlob, "":
+		r.Type = TestOwnershipRuleGlob
+		expr = regexp.QuoteMeta(expr)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		expr = "^" + expr + "$"
+	case TestOwnershipRuleRegex:
+	default:
+		return errors.Errorf("unrecognized pattern type '%s'", r.Type)
+	}
+
+	var err error
+	r.regex, err = regexp.Compile(expr)
+	return errors.Wrapf(err, "compiling pattern '%s'", r.Pattern)
+}
+
+// TestOwnership is the mapping of a project's tests to the teams that own
+// them. The rules are evaluated in order and the first matching rule
+// determines the owning team.
+type TestOwnership struct {
+	Project   string              `bson:"_id" json:"project"`
+	Rules     []TestOwnershipRule `bson:"rules" json:"rules"`
+	UpdatedBy string              `bson:"updated_by" json:"updated_by"`
+	UpdatedAt time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+var testOwnershipProjectKey = bsonutil.MustHaveTag(TestOwnership{}, "Project")
+
+// NewTestOwnership returns the test ownership of the project with the given
+// rules, validating the rules.
+func NewTestOwnership(project string, rules []TestOwnershipRule) (*TestOwnership, error) {
+	if project == "" {
+		return nil, errors.New("must specify a project")
+	}
+
+	o := &TestOwnership{
+		Project: project,
+		Rules:   rules,
+	}
+	if err := o.compile(); err != nil {
+		return nil, errors.Wrap(err, "invalid test ownership rules")
+	}
+
+	return o, nil
+}
+
+func (o *TestOwnership) compile() error {
+	catcher := grip.NewBasicCatcher()
+	for i := range o.Rules {
+		catcher.Add(o.Rules[i].compile())
+	}
+
+	return catcher.Resolve()
+}
+
+// TeamFor returns the team that owns the test with the given display name, or
+// an empty string if no rule matches. It is safe to call on a nil
+// TestOwnership.
+func (o *TestOwnership) TeamFor(testName string) string {
+	if o == nil {
+		return ""
+	}
+
+	for _, rule := range o.Rules {
+		if rule.regex != nil && rule.regex.MatchString(testName) {
+			return rule.Team
+		}
+	}
+
+	return ""
+}
+
+// AnnotateTestResults sets the owning team of each of the test results.
+func (o *TestOwnership) AnnotateTestResults(results []TestResult) {
+	if o == nil {
+		return
+	}
+
+	for i := range results {
+		results[i].Team = o.TeamFor(results[i].GetDisplayName())
+	}
+}
+
+// SetTestOwnership replaces the test ownership rules of the project. Setting
+// no rules removes the project's test ownership.
+func SetTestOwnership(ctx context.Context, env cedar.Environment, project, user string, rules []TestOwnershipRule) (*TestOwnership, error) {
+	if env == nil {
+		return nil, errors.New("cannot set test ownership with a nil environment")
+	}
+	o, err := NewTestOwnership(project, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	coll := env.GetDB().Collection(testOwnershipCollection)
+	if len(rules) == 0 {
+		_, err = coll.DeleteOne(ctx, bson.M{testOwnershipProjectKey: project})
+		grip.DebugWhen(err == nil, message.Fields{
+			"collection": testOwnershipCollection,
+			"project":    project,
+			"op":         "remove test ownership",
+		})
+		return o, errors.Wrapf(err, "removing test ownership of project '%s'", project)
+	}
+
+	o.UpdatedBy = user
+	o.UpdatedAt = time.Now()
+	_, err = coll.ReplaceOne(ctx, bson.M{testOwnershipProjectKey: project}, o, options.Replace().SetUpsert(true))
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection": testOwnershipCollection,
+		"project":    project,
+		"rules":      len(rules),
+		"op":         "set test ownership",
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "saving test ownership of project '%s'", project)
+	}
+
+	return o, nil
+}
+
+// FindTestOwnership returns the test ownership of the project, or nil if the
+// project has none.
+func FindTestOwnership(ctx context.Context, env cedar.Environment, project string) (*TestOwnership, error) {
+	ownerships, err := FindTestOwnerships(ctx, env, []string{project})
+	if err != nil {
+		return nil, err
+	}
+
+	return ownerships[project], nil
+}
+
+// FindTestOwnerships returns the test ownership of each of the projects that
+// has one, keyed by project.
+func FindTestOwnerships(ctx context.Context, env cedar.Environment, projects []string) (map[string]*TestOwnership, error) {
+	if env == nil {
+		return nil, errors.New("cannot find test ownership with a nil environment")
+	}
+
+	ownerships := map[string]*TestOwnership{}
+	if len(projects) == 0 {
+		return ownerships, nil
+	}
+
+	cur, err := env.GetDB().Collection(testOwnershipCollection).Find(ctx, bson.M{testOwnershipProjectKey: bson.M{"$in": projects}})
+	if err != nil {
+		return nil, errors.Wrap(err, "finding test ownership")
+	}
+	var docs []TestOwnership
+	if err = cur.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "decoding test ownership")
+	}
+
+	for i := range docs {
+		o, err := compiledTestOwnerships.get(&docs[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling test ownership of project '%s'", docs[i].Project)
+		}
+		ownerships[o.Project] = o
+	}
+
+	return ownerships, nil
+}
+
+// testOwnershipCache caches the compiled test ownership of each project so
+// the rules are only compiled again after they change.
+type testOwnershipCache struct {
+	mu        sync.RWMutex
+	byProject map[string]*TestOwnership
+}
+
+var compiledTestOwnerships = &testOwnershipCache{byProject: map[string]*TestOwnership{}}
+
+// get returns the compiled test ownership equivalent to the given stored test
+// ownership, compiling and caching it if the project's cached test ownership
+// is missing or has different rules. The returned test ownership is shared and
+// must not be modified.
+func (c *testOwnershipCache) get(o *TestOwnership) (*TestOwnership, error) {
+	c.mu.RLock()
+	cached, ok := c.byProject[o.Project]
+	c.mu.RUnlock()
+	if ok && cached.hasRules(o.Rules) {
+		return cached, nil
+	}
+
+	if err := o.compile(); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.byProject[o.Project] = o
+	c.mu.Unlock()
+
+	return o, nil
+}
+
+// hasRules returns whether the test ownership has the same rules.
+func (o *TestOwnership) hasRules(rules []TestOwnershipRule) bool {
+	if len(o.Rules) != len(rules) {
+		return false
+	}
+	for i := range rules {
+		if o.Rules[i].Pattern != rules[i].Pattern || o.Rules[i].Type != rules[i].Type || o.Rules[i].Team != rules[i].Team {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestOwnershipTeamFor(t *testing.T) {
+	t.Run("InvalidRules", func(t *testing.T) {
+		for _, test := range []struct {
+			name    string
+			project string
+			rules   []TestOwnershipRule
+		}{
+			{name: "NoProject", rules: []TestOwnershipRule{{Pattern: "*", Team: "team"}}},
+			{name: "NoPattern", project: "project", rules: []TestOwnershipRule{{Team: "team"}}},
+			{name: "NoTeam", project: "project", rules: []TestOwnershipRule{{Pattern: "*"}}},
+			{name: "InvalidType", project: "project", rules: []TestOwnershipRule{{Pattern: "*", Type: "invalid", Team: "team"}}},
+			{name: "InvalidRegex", project: "project", rules: []TestOwnershipRule{{Pattern: "*", Type: TestOwnershipRuleRegex, Team: "team"}}},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				o, err := NewTestOwnership(test.project, test.rules)
+				assert.Error(t, err)
+				assert.Nil(t, o)
+			})
+		}
+	})
+	t.Run("Nil", func(t *testing.T) {
+		var o *TestOwnership
+		assert.Empty(t, o.TeamFor("test"))
+	})
+
+	o, err := NewTestOwnership("project", []TestOwnershipRule{
+		{Pattern: "jstests/core/txns/*", Team: "replication"},
+		{Pattern: `^jstests/(core|aggregation)/.+\.js$`, Type: TestOwnershipRuleRegex, Team: "query"},
+		{Pattern: "jstests/?ore/*", Team: "core"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TestOwnershipRuleGlob, o.Rules[0].Type)
+
+	for _, test := range []struct {
+		name     string
+		testName string
+		team     string
+	}{
+		{name: "GlobMatch", testName: "jstests/core/txns/abort.js", team: "replication"},
+		{name: "FirstMatchWins", testName: "jstests/core/find.js", team: "query"},
+		{name: "GlobSingleCharacter", testName: "jstests/more/find", team: "core"},
+		{name: "GlobMatchesWholeName", testName: "src/jstests/core/txns/abort.js", team: ""},
+		{name: "FallsThroughToLaterRule", testName: "jstests/core/find.py", team: "core"},
+		{name: "NoMatch", testName: "unittests/find", team: ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.team, o.TeamFor(test.testName))
+		})
+	}
+	t.Run("AnnotateTestResults", func(t *testing.T) {
+		results := []TestResult{
+			{TestName: "jstests/core/txns/abort.js"},
+			{TestName: "test0", DisplayTestName: "jstests/core/find.js"},
+			{TestName: "unittests/find"},
+		}
+		o.AnnotateTestResults(results)
+		assert.Equal(t, "replication", results[0].Team)
+		assert.Equal(t, "query", results[1].Team)
+		assert.Empty(t, results[2].Team)
+	})
+}
+
+func TestSetTestOwnership(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(testOwnershipCollection).Drop(ctx))
+	}()
+
+	t.Run("NoEnv", func(t *testing.T) {
+		_, err := SetTestOwnership(ctx, nil, "project", "user", []TestOwnershipRule{{Pattern: "*", Team: "team"}})
+		assert.Error(t, err)
+		_, err = FindTestOwnership(ctx, nil, "project")
+		assert.Error(t, err)
+	})
+	t.Run("InvalidRules", func(t *testing.T) {
+		_, err := SetTestOwnership(ctx, env, "project", "user", []TestOwnershipRule{{Pattern: "*"}})
+		assert.Error(t, err)
+		o, err := FindTestOwnership(ctx, env, "project")
+		require.NoError(t, err)
+		assert.Nil(t, o)
+	})
+	t.Run("SetAndReplace", func(t *testing.T) {
+		_, err := SetTestOwnership(ctx, env, "project", "user", []TestOwnershipRule{{Pattern: "a*", Team: "team_a"}})
+		require.NoError(t, err)
+		_, err = SetTestOwnership(ctx, env, "other", "user", []TestOwnershipRule{{Pattern: "*", Team: "team_other"}})
+		require.NoError(t, err)
+		_, err = SetTestOwnership(ctx, env, "project", "user2", []TestOwnershipRule{
+			{Pattern: "b", Type: TestOwnershipRuleRegex, Team: "team_b"},
+			{Pattern: "a*", Team: "team_a"},
+		})
+		require.NoError(t, err)
+
+		o, err := FindTestOwnership(ctx, env, "project")
+		require.NoError(t, err)
+		require.NotNil(t, o)
+		assert.Len(t, o.Rules, 2)
+		assert.Equal(t, "user2", o.UpdatedBy)
+		assert.False(t, o.UpdatedAt.IsZero())
+		assert.Equal(t, "team_b", o.TeamFor("ab"))
+		assert.Equal(t, "team_a", o.TeamFor("aa"))
+
+		ownerships, err := FindTestOwnerships(ctx, env, []string{"project", "other", "DNE"})
+		require.NoError(t, err)
+		assert.Len(t, ownerships, 2)
+		assert.Equal(t, "team_other", ownerships["other"].TeamFor("aa"))
+	})
+	t.Run("CachesCompiledRules", func(t *testing.T) {
+		o1, err := FindTestOwnership(ctx, env, "project")
+		require.NoError(t, err)
+		o2, err := FindTestOwnership(ctx, env, "project")
+		require.NoError(t, err)
+		assert.True(t, o1 == o2)
+
+		_, err = SetTestOwnership(ctx, env, "project", "user", []TestOwnershipRule{{Pattern: "a*", Team: "team_c"}})
+		require.NoError(t, err)
+		o3, err := FindTestOwnership(ctx, env, "project")
+		require.NoError(t, err)
+		assert.False(t, o1 == o3)
+		assert.Equal(t, "team_c", o3.TeamFor("ab"))
+	})
+	t.Run("RemoveWithNoRules", func(t *testing.T) {
+		_, err := SetTestOwnership(ctx, env, "project", "user", nil)
+		require.NoError(t, err)
+		o, err := FindTestOwnership(ctx, env, "project")
+		require.NoError(t, err)
+		assert.Nil(t, o)
+	})
+}
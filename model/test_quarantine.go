@@ -0,0 +1,320 @@
+package model
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const testQuarantineCollection = "test_quarantine"
+
+// TestQuarantine marks a test of a project as quarantined until it expires.
+// Failures of quarantined tests are reported separately from other failures.
+type TestQuarantine struct {
+	ID        string    `bson:"_id" json:"id"`
+	Project   string    `bson:"project" json:"project"`
+	TestName  string    `bson:"test_name" json:"test_name"`
+	Reason    string    `bson:"reason" json:"reason"`
+	Author    string    `bson:"author" json:"author"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
+var (
+	testQuarantineIDKey        = bsonutil.MustHaveTag(TestQuarantine{}, "ID")
+	testQuarantineProjectKey   = bsonutil.MustHaveTag(TestQuarantine{}, "Project")
+	testQuarantineTestNameKey  = bsonutil.MustHaveTag(TestQuarantine{}, "TestName")
+	testQuarantineExpiresAtKey = bsonutil.MustHaveTag(TestQuarantine{}, "ExpiresAt")
+)
+
+// NewTestQuarantine returns a quarantine of the project's test, validating
+// that it has a reason, an author, and an expiration in the future.
+func NewTestQuarantine(project, testName, reason, author string, expiresAt time.Time) (*TestQuarantine, error) {
+	now := time.Now()
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(project == "", "must specify a project")
+	catcher.NewWhen(testName == "", "must specify a test name")
+	catcher.NewWhen(reason == "", "must specify a reason")
+	catcher.NewWhen(author == "", "must specify an author")
+	catcher.NewWhen(!expiresAt.After(now), "expiration must be in the future")
+	if catcher.HasErrors() {
+		return nil, errors.Wrap(catcher.Resolve(), "invalid test quarantine")
+	}
+
+	return &TestQuarantine{
+		ID:        testQuarantineID(project, testName),
+		Project:   project,
+		TestName:  testName,
+		Reason:    reason,
+		Author:    author,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func testQuarantineID(project, testName string) string {
+	hash := sha1.New()
+	_, _ = io.WriteString(hash, project)
+	// Separate the project from the test name so that different pairs
+	// cannot hash the same.
+	_, _ = io.WriteString(hash, "\x00")
+	_, _ = io.WriteString(hash, testName)
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// Save inserts the quarantine, replacing any existing quarantine of the same
+// test. It returns the replaced quarantine, or nil if the test was not
+// quarantined.
+func (q *TestQuarantine) Save(ctx context.Context, env cedar.Environment) (*TestQuarantine, error) {
+	if env == nil {
+		return nil, errors.New("cannot save test quarantine with a nil environment")
+	}
+
+	before := &TestQuarantine{}
+	err := env.GetDB().Collection(testQuarantineCollection).FindOneAndReplace(
+		ctx,
+		bson.M{testQuarantineIDKey: q.ID},
+		q,
+		options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.Before),
+	).Decode(before)
+	if err == mongo.ErrNoDocuments {
+		before, err = nil, nil
+	}
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection": testQuarantineCollection,
+		"project":    q.Project,
+		"test_name":  q.TestName,
+		"expires_at": q.ExpiresAt,
+		"op":         "save test quarantine",
+	})
+
+	return before, errors.Wrapf(err, "saving quarantine of test '%s' in project '%s'", q.TestName, q.Project)
+}
+
+// RemoveTestQuarantine removes the quarantine of the project's test. It
+// returns the removed quarantine, or nil if the test was not quarantined.
+func RemoveTestQuarantine(ctx context.Context, env cedar.Environment, project, testName string) (*TestQuarantine, error) {
+	if env == nil {
+		return nil, errors.New("cannot remove test quarantine with a nil environment")
+	}
+
+	removed := &TestQuarantine{}
+	err := env.GetDB().Collection(testQuarantineCollection).FindOneAndDelete(ctx, bson.M{testQuarantineIDKey: testQuarantineID(project, testName)}).Decode(removed)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection": testQuarantineCollection,
+		"project":    project,
+		"test_name":  testName,
+		"op":         "remove test quarantine",
+	})
+
+	return removed, errors.Wrapf(err, "removing quarantine of test '%s' in project '%s'", testName, project)
+}
+
+// FindTestQuarantines returns the active quarantines of the project, sorted
+// by test name.
+func FindTestQuarantines(ctx context.Context, env cedar.Environment, project string) ([]TestQuarantine, error) {
+	if env == nil {
+		return nil, errors.New("cannot find test quarantines with a nil environment")
+	}
+
+	return findActiveTestQuarantines(ctx, env, []string{project})
+}
+
+func findActiveTestQuarantines(ctx context.Context, env cedar.Environment, projects []string) ([]TestQuarantine, error) {
+	quarantines := []TestQuarantine{}
+	if len(projects) == 0 {
+		return quarantines, nil
+	}
+
+	// Expired quarantines are removed by a TTL index, but removal is not
+	// immediate.
+	cur, err := env.GetDB().Collection(testQuarantineCollection).Find(
+		ctx,
+		bson.M{
+			testQuarantineProjectKey:   bson.M{"$in": projects},
+			testQuarantineExpiresAtKey: bson.M{"$gt": time.Now()},
+		},
+		options.Find().SetSort(bson.D{{Key: testQuarantineTestNameKey, Value: 1}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding test quarantines")
+	}
+	if err = cur.All(ctx, &quarantines); err != nil {
+		return nil, errors.Wrap(err, "decoding test quarantines")
+	}
+
+	return quarantines, nil
+}
+
+// TestQuarantineAuditTarget returns the target of the audit events recording
+// the changes to the quarantined tests of the project.
+func TestQuarantineAuditTarget(project string) string {
+	return fmt.Sprintf("project/%s/test_quarantine", project)
+}
+
+// FindTestQuarantineHistory returns the audit events recording the changes to
+// the quarantined tests of the project, most recent first.
+func FindTestQuarantineHistory(ctx context.Context, env cedar.Environment, project string, limit int) ([]AuditEvent, error) {
+	return FindAuditEvents(ctx, env, AuditEventFindOptions{
+		Target: TestQuarantineAuditTarget(project),
+		Limit:  limit,
+	})
+}
+
+// TestQuarantineSet is the set of quarantined test names of projects.
+type TestQuarantineSet map[string]map[string]bool
+
+// FindTestQuarantineSet returns the set of actively quarantined tests of the
+// projects.
+func FindTestQuarantineSet(ctx context.Context, env cedar.Environment, projects []string) (TestQuarantineSet, error) {
+	if env == nil {
+		return nil, errors.New("cannot find test quarantines with a nil environment")
+	}
+
+	quarantines, err := findActiveTestQuarantines(ctx, env, projects)
+	if err != nil {
+		return nil, err
+	}
+
+	set := TestQuarantineSet{}
+	for _, q := range quarantines {
+		if set[q.Project] == nil {
+			set[q.Project] = map[string]bool{}
+		}
+		set[q.Project][q.TestName] = true
+	}
+
+	return set, nil
+}
+
+// IsQuarantined returns whether the project's test is quarantined.
+func (s TestQuarantineSet) IsQuarantined(project, testName string) bool {
+	return s[project][testName]
+}
+
+// Separate splits the test names of the project into those that are not
+// quarantined and those that are, preserving their order.
+func (s TestQuarantineSet) Separate(project string, testNames []string) ([]string, []string) {
+	if len(s[project]) == 0 {
+		return testNames, nil
+	}
+
+	var names, quarantined []string
+	for _, name := range testNames {
+		if s.IsQuarantined(project, name) {
+			quarantined = append(quarantined, name)
+		} else {
+			names = append(names, name)
+		}
+	}
+
+	return names, quarantined
+}
+
+// applyTestQuarantines moves the failures of quarantined tests of the test
+// results records from the failed count of the stats to the quarantined
+// failed count. The failed tests samples of the records are checked when they
+// hold every failure, and only the records of projects with quarantined tests
+// whose samples are incomplete are downloaded.
+func applyTestQuarantines(ctx context.Context, env cedar.Environment, records []TestResults, stats *TestResultsStats) error {
+	if stats.FailedCount == 0 {
+		return nil
+	}
+
+	quarantines, err := FindTestQuarantineSet(ctx, env, testResultsProjects(records))
+	if err != nil {
+		return err
+	}
+	if len(quarantines) == 0 {
+		return nil
+	}
+
+	var count int
+	for i := range records {
+		project := records[i].Info.Project
+		if records[i].Stats.FailedCount == 0 || len(quarantines[project]) == 0 {
+			continue
+		}
+
+		names, err := records[i].failedTestNames(ctx)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if quarantines.IsQuarantined(project, name) {
+				count++
+			}
+		}
+	}
+
+	stats.FailedCount -= count
+	stats.QuarantinedFailedCount += count
+
+	return nil
+}
+
+// FailedTestResultsSample returns the combined failed tests sample of the
+// test results records, either without the quarantined tests or, if
+// quarantined is set, with only the quarantined tests. The quarantined tests
+// are separated from all of a record's failures before the sample is capped,
+// so the records of projects with quarantined tests whose samples are
+// incomplete are downloaded.
+func FailedTestResultsSample(ctx context.Context, quarantines TestQuarantineSet, quarantined bool, records ...TestResults) ([]string, error) {
+	var sample []string
+	for i := 0; i < len(records) && len(sample) < FailedTestsSampleSize; i++ {
+		project := records[i].Info.Project
+		if len(quarantines[project]) == 0 {
+			if !quarantined {
+				sample = append(sample, records[i].FailedTestsSample...)
+			}
+			continue
+		}
+
+		failed, err := records[i].failedTestNames(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names, quarantinedNames := quarantines.Separate(project, failed)
+		if quarantined {
+			names = quarantinedNames
+		}
+		// Like the stored samples, take at most a sample's worth of
+		// failures from each record.
+		if len(names) > FailedTestsSampleSize {
+			names = names[:FailedTestsSampleSize]
+		}
+		sample = append(sample, names...)
+	}
+
+	return sample, nil
+}
+
+// testResultsProjects returns the distinct projects of the test results
+// records.
+func testResultsProjects(records []TestResults) []string {
+	projectSet := map[string]bool{}
+	projects := []string{}
+	for _, record := range records {
+		if !projectSet[record.Info.Project] {
+			projectSet[record.Info.Project] = true
+			projects = append(projects, record.Info.Project)
+		}
+	}
+
+	return projects
+}
@@ -0,0 +1,265 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTestQuarantine(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	for _, test := range []struct {
+		name      string
+		project   string
+		testName  string
+		reason    string
+		author    string
+		expiresAt time.Time
+	}{
+		{name: "NoProject", testName: "test", reason: "flaky", author: "user", expiresAt: expiresAt},
+		{name: "NoTestName", project: "project", reason: "flaky", author: "user", expiresAt: expiresAt},
+		{name: "NoReason", project: "project", testName: "test", author: "user", expiresAt: expiresAt},
+		{name: "NoAuthor", project: "project", testName: "test", reason: "flaky", expiresAt: expiresAt},
+		{name: "ExpiresInPast", project: "project", testName: "test", reason: "flaky", author: "user", expiresAt: time.Now().Add(-time.Hour)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			q, err := NewTestQuarantine(test.project, test.testName, test.reason, test.author, test.expiresAt)
+			assert.Error(t, err)
+			assert.Nil(t, q)
+		})
+	}
+	t.Run("Valid", func(t *testing.T) {
+		q, err := NewTestQuarantine("project", "test", "flaky", "user", expiresAt)
+		require.NoError(t, err)
+		assert.NotEmpty(t, q.ID)
+		assert.False(t, q.CreatedAt.IsZero())
+		assert.NotEqual(t, testQuarantineID("project", "test"), testQuarantineID("projec", "ttest"))
+	})
+}
+
+func TestTestQuarantine(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(testQuarantineCollection).Drop(ctx))
+	}()
+
+	t.Run("NoEnv", func(t *testing.T) {
+		q, err := NewTestQuarantine("project", "test", "flaky", "user", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		_, err = q.Save(ctx, nil)
+		assert.Error(t, err)
+		_, err = RemoveTestQuarantine(ctx, nil, "project", "test")
+		assert.Error(t, err)
+		_, err = FindTestQuarantines(ctx, nil, "project")
+		assert.Error(t, err)
+		_, err = FindTestQuarantineSet(ctx, nil, []string{"project"})
+		assert.Error(t, err)
+	})
+	t.Run("SaveAndReplace", func(t *testing.T) {
+		q, err := NewTestQuarantine("project", "test0", "flaky", "user", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		before, err := q.Save(ctx, env)
+		require.NoError(t, err)
+		assert.Nil(t, before)
+
+		replacement, err := NewTestQuarantine("project", "test0", "still flaky", "user2", time.Now().Add(2*time.Hour))
+		require.NoError(t, err)
+		before, err = replacement.Save(ctx, env)
+		require.NoError(t, err)
+		require.NotNil(t, before)
+		assert.Equal(t, "flaky", before.Reason)
+
+		quarantines, err := FindTestQuarantines(ctx, env, "project")
+		require.NoError(t, err)
+		require.Len(t, quarantines, 1)
+		assert.Equal(t, "still flaky", quarantines[0].Reason)
+		assert.Equal(t, "user2", quarantines[0].Author)
+	})
+	t.Run("FindExcludesExpired", func(t *testing.T) {
+		q, err := NewTestQuarantine("project", "expired", "flaky", "user", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		q.ExpiresAt = time.Now().Add(-time.Minute)
+		_, err = q.Save(ctx, env)
+		require.NoError(t, err)
+		q, err = NewTestQuarantine("other", "test1", "flaky", "user", time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		_, err = q.Save(ctx, env)
+		require.NoError(t, err)
+
+		quarantines, err := FindTestQuarantines(ctx, env, "project")
+		require.NoError(t, err)
+		require.Len(t, quarantines, 1)
+		assert.Equal(t, "test0", quarantines[0].TestName)
+
+		set, err := FindTestQuarantineSet(ctx, env, []string{"project", "other", "DNE"})
+		require.NoError(t, err)
+		assert.True(t, set.IsQuarantined("project", "test0"))
+		assert.False(t, set.IsQuarantined("project", "expired"))
+		assert.True(t, set.IsQuarantined("other", "test1"))
+		assert.False(t, set.IsQuarantined("other", "test0"))
+		assert.False(t, set.IsQuarantined("DNE", "test0"))
+	})
+	t.Run("Remove", func(t *testing.T) {
+		removed, err := RemoveTestQuarantine(ctx, env, "project", "test0")
+		require.NoError(t, err)
+		require.NotNil(t, removed)
+		assert.Equal(t, "test0", removed.TestName)
+
+		removed, err = RemoveTestQuarantine(ctx, env, "project", "test0")
+		require.NoError(t, err)
+		assert.Nil(t, removed)
+
+		quarantines, err := FindTestQuarantines(ctx, env, "project")
+		require.NoError(t, err)
+		assert.Empty(t, quarantines)
+	})
+}
+
+func TestTestQuarantineSetSeparate(t *testing.T) {
+	set := TestQuarantineSet{"project": {"b": true, "d": true}}
+
+	names, quarantined := set.Separate("project", []string{"a", "b", "c", "d"})
+	assert.Equal(t, []string{"a", "c"}, names)
+	assert.Equal(t, []string{"b", "d"}, quarantined)
+
+	names, quarantined = set.Separate("other", []string{"a", "b"})
+	assert.Equal(t, []string{"a", "b"}, names)
+	assert.Empty(t, quarantined)
+}
+
+func TestApplyTestQuarantines(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testQuarantineCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+	}()
+
+	q, err := NewTestQuarantine("project", "flaky", "flaky", "user", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	_, err = q.Save(ctx, env)
+	require.NoError(t, err)
+
+	records := []TestResults{
+		{
+			Info:              TestResultsInfo{Project: "project"},
+			Stats:             TestResultsStats{TotalCount: 5, FailedCount: 2},
+			FailedTestsSample: []string{"flaky", "broken"},
+		},
+		{
+			Info:              TestResultsInfo{Project: "other"},
+			Stats:             TestResultsStats{TotalCount: 5, FailedCount: 1},
+			FailedTestsSample: []string{"flaky"},
+		},
+		*saveTestResultsWithIncompleteFailedSample(ctx, t, env),
+	}
+	stats := TestResultsStats{TotalCount: 22, FailedCount: 14}
+	require.NoError(t, applyTestQuarantines(ctx, env, records, &stats))
+	assert.Equal(t, 22, stats.TotalCount)
+	assert.Equal(t, 12, stats.FailedCount)
+	assert.Equal(t, 2, stats.QuarantinedFailedCount)
+}
+
+func TestFailedTestResultsSample(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("SeparatesBeforeCapping", func(t *testing.T) {
+		var flaky []string
+		for i := 0; i < FailedTestsSampleSize; i++ {
+			flaky = append(flaky, fmt.Sprintf("flaky%d", i))
+		}
+		quarantines := TestQuarantineSet{"project": {}}
+		for _, name := range flaky {
+			quarantines["project"][name] = true
+		}
+		records := []TestResults{
+			{
+				Info:              TestResultsInfo{Project: "project"},
+				Stats:             TestResultsStats{FailedCount: len(flaky)},
+				FailedTestsSample: flaky,
+			},
+			{
+				Info:              TestResultsInfo{Project: "project"},
+				Stats:             TestResultsStats{FailedCount: 1},
+				FailedTestsSample: []string{"broken"},
+			},
+		}
+
+		sample, err := FailedTestResultsSample(ctx, quarantines, false, records...)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"broken"}, sample)
+		sample, err = FailedTestResultsSample(ctx, quarantines, true, records...)
+		require.NoError(t, err)
+		assert.Equal(t, flaky, sample)
+	})
+	t.Run("IncompleteSample", func(t *testing.T) {
+		defer func() {
+			assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+			assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+		}()
+		record := saveTestResultsWithIncompleteFailedSample(ctx, t, env)
+		quarantines := TestQuarantineSet{"project": {"flaky": true}}
+
+		sample, err := FailedTestResultsSample(ctx, quarantines, false, *record)
+		require.NoError(t, err)
+		assert.Equal(t, record.FailedTestsSample, sample)
+		sample, err = FailedTestResultsSample(ctx, quarantines, true, *record)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"flaky"}, sample)
+	})
+}
+
+// saveTestResultsWithIncompleteFailedSample saves a test results record of
+// the project "project" with a full failed tests sample of other tests
+// followed by a failure of the test "flaky" and a pass.
+func saveTestResultsWithIncompleteFailedSample(ctx context.Context, t *testing.T, env cedar.Environment) *TestResults {
+	conf := &CedarConfig{
+		Bucket: BucketConfig{
+			TestResultsBucket:       t.TempDir(),
+			PrestoBucket:            t.TempDir(),
+			PrestoTestResultsPrefix: "presto-test-results",
+		},
+		populated: true,
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	record := getTestResults()
+	record.Info.Project = "project"
+	_, err := env.GetDB().Collection(testResultsCollection).InsertOne(ctx, record)
+	require.NoError(t, err)
+	record.Setup(env)
+	record.populated = true
+
+	var results []TestResult
+	for i := 0; i < FailedTestsSampleSize; i++ {
+		results = append(results, TestResult{TestName: fmt.Sprintf("broken%d", i), Status: "fail"})
+	}
+	results = append(results, TestResult{TestName: "flaky", Status: "fail"}, TestResult{TestName: "passing", Status: "pass"})
+	now := time.Now().UTC().Round(time.Millisecond)
+	for i := range results {
+		results[i].TaskID = record.Info.TaskID
+		results[i].Execution = record.Info.Execution
+		results[i].TestStartTime = now
+		results[i].TestEndTime = now
+	}
+	require.NoError(t, record.Append(ctx, results))
+	require.Len(t, record.FailedTestsSample, FailedTestsSampleSize)
+	require.NotContains(t, record.FailedTestsSample, "flaky")
+
+	return record
+}
@@ -200,14 +200,19 @@ func (t *TestResults) Append(ctx context.Context, results []TestResult) error {
 	if err != nil && !pail.IsKeyNotFoundError(err) {
 		return errors.Wrap(err, "getting uploaded test results")
 	}
-	allResults = append(allResults, results...)
 
-	if err = t.uploadParquet(ctx, t.convertToParquet(allResults)); err != nil {
+	return t.replace(ctx, append(allResults, results...), results)
+}
+
+// replace uploads all of the test results in place of the ones already stored
+// and adds the newly added results to the stats.
+func (t *TestResults) replace(ctx context.Context, allResults, added []TestResult) error {
+	if err := t.uploadParquet(ctx, t.convertToParquet(allResults)); err != nil {
 		return errors.Wrap(err, "appending Parquet test results")
 	}
 
-	if err = t.env.GetStatsCache(cedar.StatsCacheTestResults).AddStat(cedar.Stat{
-		Count:   len(results),
+	if err := t.env.GetStatsCache(cedar.StatsCacheTestResults).AddStat(cedar.Stat{
+		Count:   len(added),
 		Project: t.Info.Project,
 		Version: t.Info.Version,
 		TaskID:  t.Info.TaskID,
@@ -218,16 +223,10 @@ func (t *TestResults) Append(ctx context.Context, results []TestResult) error {
 		}))
 	}
 
-	return t.updateStatsAndFailedSample(ctx, results)
+	return t.updateStatsAndFailedSample(ctx, added)
 }
 
 func (t *TestResults) uploadParquet(ctx context.Context, results *ParquetTestResults) error {
-	conf := &CedarConfig{}
-	conf.Setup(t.env)
-	if err := conf.Find(); err != nil {
-		return errors.Wrap(err, "getting application configuration")
-	}
-
 	bucket, err := t.GetPrestoBucket(ctx)
 	if err != nil {
 		return err
@@ -236,28 +235,22 @@ func (t *TestResults) uploadParquet(ctx context.Context, results *ParquetTestRes
 	if err != nil {
 		return errors.Wrap(err, "creating Presto bucket writer")
 	}
-	defer func() {
-		err = w.Close()
-		grip.WarningWhen(err != nil, message.WrapError(err, message.Fields{
-			"message": "closing test results bucket writer",
-		}))
-	}()
 
+	// The bucket writer may only upload the file once it is closed, so
+	// closing errors mean that the test results were not stored.
 	pw := floor.NewWriter(goparquet.NewFileWriter(w, goparquet.WithSchemaDefinition(parquetTestResultsSchemaDef)))
-	defer func() {
-		err = pw.Close()
-		grip.WarningWhen(err != nil, message.WrapError(err, message.Fields{
-			"message": "closing Parquet test results writer",
-		}))
-	}()
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(pw.Write(results), "writing Parquet test results")
+	catcher.Wrap(pw.Close(), "closing Parquet test results writer")
+	catcher.Wrap(w.Close(), "closing Presto bucket writer")
 
-	return errors.Wrap(pw.Write(results), "writing Parquet test results")
+	return catcher.Resolve()
 }
 
 func (t *TestResults) updateStatsAndFailedSample(ctx context.Context, results []TestResult) error {
 	var failedCount int
 	for i := 0; i < len(results); i++ {
-		if strings.Contains(strings.ToLower(results[i].Status), "fail") {
+		if results[i].failed() {
 			if len(t.FailedTestsSample) < FailedTestsSampleSize {
 				t.FailedTestsSample = append(t.FailedTestsSample, results[i].GetDisplayName())
 			}
@@ -419,11 +412,13 @@ func (t *TestResults) convertToParquet(results []TestResult) *ParquetTestResults
 	return parquetResults
 }
 
-// Close "closes out" by populating the completed_at field. The environment
-// should not be nil.
-func (t *TestResults) Close(ctx context.Context) error {
+// Close "closes out" by populating the completed_at field. It returns whether
+// this call closed the record, which is false if the record was already
+// closed, in which case the completed_at field is not changed. The
+// environment should not be nil.
+func (t *TestResults) Close(ctx context.Context) (bool, error) {
 	if t.env == nil {
-		return errors.New("cannot close log with a nil environment")
+		return false, errors.New("cannot close log with a nil environment")
 	}
 
 	if t.ID == "" {
@@ -433,7 +428,10 @@ func (t *TestResults) Close(ctx context.Context) error {
 	completedAt := time.Now()
 	updateResult, err := t.env.GetDB().Collection(testResultsCollection).UpdateOne(
 		ctx,
-		bson.M{"_id": t.ID},
+		bson.M{
+			"_id":                     t.ID,
+			testResultsCompletedAtKey: bson.M{"$not": bson.M{"$gt": time.Time{}}},
+		},
 		bson.M{
 			"$set": bson.M{
 				testResultsCompletedAtKey: completedAt,
@@ -447,11 +445,89 @@ func (t *TestResults) Close(ctx context.Context) error {
 		"update_result": updateResult,
 		"op":            "close test results record",
 	})
-	if err == nil && updateResult.MatchedCount == 0 {
-		err = errors.Errorf("could not find test results record '%s'", t.ID)
+	if err != nil {
+		return false, errors.Wrapf(err, "closing test result record '%s'", t.ID)
+	}
+	if updateResult.ModifiedCount > 0 {
+		t.CompletedAt = completedAt
+		return true, nil
+	}
+
+	count, err := t.env.GetDB().Collection(testResultsCollection).CountDocuments(ctx, bson.M{"_id": t.ID})
+	if err != nil {
+		return false, errors.Wrapf(err, "finding test result record '%s'", t.ID)
+	}
+	if count == 0 {
+		return false, errors.Errorf("could not find test results record '%s'", t.ID)
 	}
 
-	return errors.Wrapf(err, "closing test result record '%s'", t.ID)
+	return false, nil
+}
+
+// FindNewlyFailingTests returns the display names of the tests that failed in
+// the test results but passed in the base test results, which are the most
+// recent completed mainline test results of the same task created before
+// these. It returns nil if there are no base test results. The TestResults
+// should be populated and the environment should not be nil.
+func (t *TestResults) FindNewlyFailingTests(ctx context.Context) ([]string, error) {
+	if !t.populated {
+		return nil, errors.New("cannot find newly failing tests of unpopulated test results")
+	}
+	if t.env == nil {
+		return nil, errors.New("cannot find newly failing tests with a nil environment")
+	}
+	if t.Stats.FailedCount == 0 {
+		return nil, nil
+	}
+
+	base := &TestResults{}
+	err := t.env.GetDB().Collection(testResultsCollection).FindOne(ctx,
+		bson.M{
+			bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey):         t.Info.Project,
+			bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoVariantKey):         t.Info.Variant,
+			bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoTaskNameKey):        t.Info.TaskName,
+			bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoDisplayTaskNameKey): t.Info.DisplayTaskName,
+			bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoMainlineKey):        true,
+			testResultsIDKey:          bson.M{"$ne": t.ID},
+			testResultsCreatedAtKey:   bson.M{"$lt": t.CreatedAt},
+			testResultsCompletedAtKey: bson.M{"$gt": time.Time{}},
+		},
+		options.FindOne().SetSort(bson.D{{Key: testResultsCreatedAtKey, Value: -1}}),
+	).Decode(base)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding base test results for '%s'", t.ID)
+	}
+	base.env = t.env
+	base.populated = true
+
+	baseResults, err := base.Download(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "downloading base test results '%s'", base.ID)
+	}
+	baseStatuses := make(map[string]string, len(baseResults))
+	for _, result := range baseResults {
+		baseStatuses[result.GetDisplayName()] = result.Status
+	}
+
+	results, err := t.Download(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "downloading test results '%s'", t.ID)
+	}
+	var newlyFailing []string
+	for _, result := range results {
+		if !result.failed() {
+			continue
+		}
+		baseStatus, ok := baseStatuses[result.GetDisplayName()]
+		if ok && !strings.Contains(strings.ToLower(baseStatus), "fail") {
+			newlyFailing = append(newlyFailing, result.GetDisplayName())
+		}
+	}
+
+	return newlyFailing, nil
 }
 
 // GetBucket returns a bucket of all test results specified by the TestResults
@@ -483,7 +559,8 @@ func (t *TestResults) GetBucket(ctx context.Context) (pail.Bucket, error) {
 }
 
 // GetPrestoBucket returns an S3 bucket of all test results specified by the
-// TestResults metadata object it's called on to be used with Presto. The
+// TestResults metadata object it's called on to be used with Presto. Presto
+// reads the Parquet files directly, so they are never envelope encrypted. The
 // environment should not be nil.
 func (t *TestResults) GetPrestoBucket(ctx context.Context) (pail.Bucket, error) {
 	if t.prestoBucketPrefix == "" {
@@ -524,6 +601,9 @@ type TestResultsInfo struct {
 	Mainline               bool   `bson:"mainline"`
 	HistoricalDataDisabled bool   `bson:"historical_data_disabled"`
 	Schema                 int    `bson:"schema"`
+	// Evergreen is populated asynchronously after the test results
+	// record is created and is not part of the ID.
+	Evergreen *EvergreenMetadata `bson:"evergreen,omitempty"`
 }
 
 var (
@@ -539,6 +619,7 @@ var (
 	testResultsInfoMainlineKey            = bsonutil.MustHaveTag(TestResultsInfo{}, "Mainline")
 	testResultsInfoHistoricalDataDisabled = bsonutil.MustHaveTag(TestResultsInfo{}, "HistoricalDataDisabled")
 	testResultsInfoSchemaKey              = bsonutil.MustHaveTag(TestResultsInfo{}, "Schema")
+	testResultsInfoEvergreenKey           = bsonutil.MustHaveTag(TestResultsInfo{}, "Evergreen")
 )
 
 // ID creates a unique hash for a TestResults record.
@@ -567,6 +648,11 @@ func (id *TestResultsInfo) ID() string {
 type TestResultsStats struct {
 	TotalCount  int `bson:"total_count"`
 	FailedCount int `bson:"failed_count"`
+	// QuarantinedFailedCount is the number of failed tests that are
+	// quarantined, which are not included in the FailedCount. It is only
+	// set when querying stats and only counts the failed tests in the
+	// failed tests samples.
+	QuarantinedFailedCount int `bson:"-"`
 }
 
 // TestResultsSample contains test names culled from a test result's FailedTestsSample.
@@ -575,6 +661,13 @@ type TestResultsSample struct {
 	Execution               int
 	MatchingFailedTestNames []string
 	TotalFailedTestNames    int
+	// QuarantinedFailedTestNames are the matching failed test names of
+	// quarantined tests, which are not included in the
+	// MatchingFailedTestNames.
+	QuarantinedFailedTestNames []string
+	// Teams maps the matching failed test names to their owning teams,
+	// for tests owned by a team.
+	Teams map[string]string
 }
 
 var (
@@ -593,6 +686,7 @@ type TestResult struct {
 	Trial           int       `bson:"trial"`
 	Status          string    `bson:"status"`
 	BaseStatus      string    `bson:"-"`
+	Team            string    `bson:"-"`
 	LogTestName     string    `bson:"log_test_name,omitempty"`
 	LogURL          string    `bson:"log_url,omitempty"`
 	RawLogURL       string    `bson:"raw_log_url,omitempty"`
@@ -600,6 +694,13 @@ type TestResult struct {
 	TaskCreateTime  time.Time `bson:"task_create_time"`
 	TestStartTime   time.Time `bson:"test_start_time"`
 	TestEndTime     time.Time `bson:"test_end_time"`
+	// FailureMessage and StackTrace describe why the test failed.
+	FailureMessage string `bson:"failure_message,omitempty"`
+	StackTrace     string `bson:"stack_trace,omitempty"`
+	// Tags and Attributes are custom metadata reported for the test by
+	// its harness.
+	Tags       []string          `bson:"tags,omitempty"`
+	Attributes map[string]string `bson:"attributes,omitempty"`
 }
 
 // GetDisplayName returns the human-readable name of the test.
@@ -610,6 +711,34 @@ func (t TestResult) GetDisplayName() string {
 	return t.TestName
 }
 
+// failed returns whether the test failed, including silent failures.
+func (t TestResult) failed() bool {
+	return strings.Contains(strings.ToLower(t.Status), "fail")
+}
+
+// LogLineRange returns the 1-indexed start and exclusive end line numbers of
+// the test's lines in its task-level log, where the test's lines end at the
+// next line number of the given test results from the same task execution
+// that also logged to the task-level log. The end is 0 if no later test
+// result exists, and both are 0 if the test has no line number.
+func (t TestResult) LogLineRange(results []TestResult) (int, int) {
+	if t.LineNum <= 0 || t.LogTestName != "" {
+		return 0, 0
+	}
+
+	var end int
+	for _, result := range results {
+		if result.TaskID != t.TaskID || result.Execution != t.Execution || result.LogTestName != "" {
+			continue
+		}
+		if result.LineNum > t.LineNum && (end == 0 || result.LineNum < end) {
+			end = result.LineNum
+		}
+	}
+
+	return t.LineNum, end
+}
+
 func (t TestResult) getDuration() time.Duration {
 	return t.TestEndTime.Sub(t.TestStartTime)
 }
@@ -641,6 +770,23 @@ func (t TestResult) convertToParquet() ParquetTestResult {
 	if t.LogTestName != "" || t.LogURL != "" || t.RawLogURL != "" {
 		result.LineNum = utility.ToInt32Ptr(int32(t.LineNum))
 	}
+	if t.FailureMessage != "" {
+		result.FailureMessage = utility.ToStringPtr(t.FailureMessage)
+	}
+	if t.StackTrace != "" {
+		result.StackTrace = utility.ToStringPtr(t.StackTrace)
+	}
+	result.Tags = t.Tags
+	if len(t.Attributes) > 0 {
+		keys := make([]string, 0, len(t.Attributes))
+		for key := range t.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			result.Attributes = append(result.Attributes, ParquetTestResultAttribute{Key: key, Value: t.Attributes[key]})
+		}
+	}
 
 	return result
 }
@@ -778,6 +924,7 @@ func (opts *FindTestSamplesOptions) createFindOptions() *options.FindOptions {
 		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoTaskIDKey):        1,
 		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoDisplayTaskIDKey): 1,
 		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoExecutionKey):     1,
+		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey):       1,
 		testResultsFailedTestsSampleKey:                                                1,
 	})
 }
@@ -885,7 +1032,65 @@ func GetTestResultsFilteredSamples(ctx context.Context, env cedar.Environment, o
 		return nil, errors.Wrap(err, "decoding test results record(s)")
 	}
 
-	return opts.makeTestSamples(results)
+	samples, err := opts.makeTestSamples(results)
+	if err != nil {
+		return nil, err
+	}
+
+	projects := testSampleProjects(results)
+	quarantines, err := FindTestQuarantineSet(ctx, env, testResultsProjects(results))
+	if err != nil {
+		return nil, err
+	}
+	for i := range samples {
+		samples[i].MatchingFailedTestNames, samples[i].QuarantinedFailedTestNames = quarantines.Separate(projects[samples[i].TaskID], samples[i].MatchingFailedTestNames)
+	}
+
+	ownerships, err := findTestOwnershipsForRecords(ctx, env, results)
+	if err != nil {
+		return nil, err
+	}
+	annotateTestSampleTeams(samples, projects, ownerships)
+
+	return samples, nil
+}
+
+// testSampleProjects maps both the execution and display task IDs of the test
+// results, by which samples are keyed, to their projects.
+func testSampleProjects(results []TestResults) map[string]string {
+	projects := map[string]string{}
+	for _, result := range results {
+		projects[result.Info.TaskID] = result.Info.Project
+		if result.Info.DisplayTaskID != "" {
+			projects[result.Info.DisplayTaskID] = result.Info.Project
+		}
+	}
+
+	return projects
+}
+
+// annotateTestSampleTeams sets the owning teams of the matching and
+// quarantined failed test names of the samples.
+func annotateTestSampleTeams(samples []TestResultsSample, projects map[string]string, ownerships map[string]*TestOwnership) {
+	if len(ownerships) == 0 {
+		return
+	}
+
+	for i := range samples {
+		ownership := ownerships[projects[samples[i].TaskID]]
+		if ownership == nil {
+			continue
+		}
+		names := append(append([]string{}, samples[i].MatchingFailedTestNames...), samples[i].QuarantinedFailedTestNames...)
+		for _, name := range names {
+			if team := ownership.TeamFor(name); team != "" {
+				if samples[i].Teams == nil {
+					samples[i].Teams = map[string]string{}
+				}
+				samples[i].Teams[name] = team
+			}
+		}
+	}
 }
 
 // TestResultsSortBy describes the property by which to sort a set of test
@@ -913,17 +1118,22 @@ func (s TestResultsSortBy) validate() error {
 // FilterAndSortTestResultsOptions allow for filtering, sorting, and paginating
 // a set of test results.
 type FilterAndSortTestResultsOptions struct {
-	TestName     string
-	Statuses     []string
-	GroupID      string
-	SortBy       TestResultsSortBy
-	SortOrderDSC bool
-	Limit        int
-	Page         int
-	BaseResults  *FindTestResultsOptions
-
-	testNameRegex *regexp.Regexp
-	baseStatusMap map[string]string
+	TestName       string
+	Statuses       []string
+	GroupID        string
+	FailureMessage string
+	Tags           []string
+	Attributes     map[string]string
+	Team           string
+	SortBy         TestResultsSortBy
+	SortOrderDSC   bool
+	Limit          int
+	Page           int
+	BaseResults    *FindTestResultsOptions
+
+	testNameRegex       *regexp.Regexp
+	failureMessageRegex *regexp.Regexp
+	baseStatusMap       map[string]string
 }
 
 func (o *FilterAndSortTestResultsOptions) validate() error {
@@ -940,6 +1150,11 @@ func (o *FilterAndSortTestResultsOptions) validate() error {
 		o.testNameRegex, err = regexp.Compile(o.TestName)
 		catcher.Wrapf(err, "compiling test name regex")
 	}
+	if o.FailureMessage != "" {
+		var err error
+		o.failureMessageRegex, err = regexp.Compile(o.FailureMessage)
+		catcher.Wrapf(err, "compiling failure message regex")
+	}
 
 	o.baseStatusMap = map[string]string{}
 
@@ -963,6 +1178,50 @@ func FindAndDownloadTestResults(ctx context.Context, env cedar.Environment, opts
 		return nil, 0, err
 	}
 
+	combinedResults, err := downloadTestResults(ctx, env, testResults)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return filterAndSortTestResults(ctx, env, combinedResults, opts.FilterAndSort)
+}
+
+// FindAndDownloadTestResultsForTest searches the DB for the TestResults
+// associated with the provided options and returns the downloaded test
+// results of the first one with a result of the given test, matched by test
+// name or display name. The TestResults are downloaded one at a time and the
+// search stops at the first match, so finding a single test does not
+// download every test result of the task. It returns nil if no TestResults
+// have a result of the test. The environment should not be nil.
+func FindAndDownloadTestResultsForTest(ctx context.Context, env cedar.Environment, opts FindTestResultsOptions, testName string) ([]TestResult, error) {
+	testResults, err := FindTestResults(ctx, env, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range testResults {
+		results, err := testResults[i].Download(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if result.TestName == testName || result.GetDisplayName() == testName {
+				return results, nil
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// downloadTestResults concurrently downloads and combines the results of the
+// given TestResults, annotating each test result with its owning team.
+func downloadTestResults(ctx context.Context, env cedar.Environment, testResults []TestResults) ([]TestResult, error) {
+	ownerships, err := findTestOwnershipsForRecords(ctx, env, testResults)
+	if err != nil {
+		return nil, err
+	}
+
 	testResultsChan := make(chan TestResults, len(testResults))
 	for i := range testResults {
 		testResultsChan <- testResults[i]
@@ -1002,6 +1261,7 @@ func FindAndDownloadTestResults(ctx context.Context, env cedar.Environment, opts
 					catcher.Add(err)
 					return
 				}
+				ownerships[trs.Info.Project].AnnotateTestResults(results)
 
 				select {
 				case <-ctx.Done():
@@ -1017,10 +1277,10 @@ func FindAndDownloadTestResults(ctx context.Context, env cedar.Environment, opts
 	cwg.Wait()
 
 	if catcher.HasErrors() {
-		return nil, 0, catcher.Resolve()
+		return nil, catcher.Resolve()
 	}
 
-	return filterAndSortTestResults(ctx, env, combinedResults, opts.FilterAndSort)
+	return combinedResults, nil
 }
 
 // filterAndSortCedarTestResults takes a slice of TestResult objects and
@@ -1048,17 +1308,7 @@ func filterAndSortTestResults(ctx context.Context, env cedar.Environment, result
 	sortTestResults(results, opts)
 
 	totalCount := len(results)
-	if opts.Limit > 0 {
-		offset := opts.Limit * opts.Page
-		end := offset + opts.Limit
-		if offset > totalCount {
-			offset = totalCount
-		}
-		if end > totalCount {
-			end = totalCount
-		}
-		results = results[offset:end]
-	}
+	results = paginateTestResults(results, opts)
 
 	if len(opts.baseStatusMap) > 0 {
 		for i := range results {
@@ -1069,8 +1319,27 @@ func filterAndSortTestResults(ctx context.Context, env cedar.Environment, result
 	return results, totalCount, nil
 }
 
+// paginateTestResults returns the page of the test results specified by the
+// options.
+func paginateTestResults(results []TestResult, opts *FilterAndSortTestResultsOptions) []TestResult {
+	if opts.Limit <= 0 {
+		return results
+	}
+
+	offset := opts.Limit * opts.Page
+	end := offset + opts.Limit
+	if offset > len(results) {
+		offset = len(results)
+	}
+	if end > len(results) {
+		end = len(results)
+	}
+
+	return results[offset:end]
+}
+
 func filterTestResults(results []TestResult, opts *FilterAndSortTestResultsOptions) []TestResult {
-	if opts.testNameRegex == nil && len(opts.Statuses) == 0 && opts.GroupID == "" {
+	if opts.testNameRegex == nil && len(opts.Statuses) == 0 && opts.GroupID == "" && opts.failureMessageRegex == nil && len(opts.Tags) == 0 && len(opts.Attributes) == 0 && opts.Team == "" {
 		return results
 	}
 
@@ -1085,6 +1354,18 @@ func filterTestResults(results []TestResult, opts *FilterAndSortTestResultsOptio
 		if opts.GroupID != "" && opts.GroupID != result.GroupID {
 			continue
 		}
+		if opts.failureMessageRegex != nil && !opts.failureMessageRegex.MatchString(result.FailureMessage) {
+			continue
+		}
+		if !hasAllTags(result.Tags, opts.Tags) {
+			continue
+		}
+		if !hasAllAttributes(result.Attributes, opts.Attributes) {
+			continue
+		}
+		if opts.Team != "" && opts.Team != result.Team {
+			continue
+		}
 
 		filteredResults = append(filteredResults, result)
 	}
@@ -1092,6 +1373,28 @@ func filterTestResults(results []TestResult, opts *FilterAndSortTestResultsOptio
 	return filteredResults
 }
 
+// hasAllTags returns whether the test result tags contain all of the given
+// tags.
+func hasAllTags(resultTags, tags []string) bool {
+	for _, tag := range tags {
+		if !utility.StringSliceContains(resultTags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAllAttributes returns whether the test result attributes have all of the
+// given key/value pairs.
+func hasAllAttributes(resultAttrs, attrs map[string]string) bool {
+	for key, value := range attrs {
+		if resultValue, ok := resultAttrs[key]; !ok || resultValue != value {
+			return false
+		}
+	}
+	return true
+}
+
 func sortTestResults(results []TestResult, opts *FilterAndSortTestResultsOptions) {
 	switch opts.SortBy {
 	case TestResultsSortByStart:
@@ -1132,58 +1435,64 @@ func sortTestResults(results []TestResult, opts *FilterAndSortTestResultsOptions
 	}
 }
 
-// GetTestResultsStats fetches basic stats for the test results associated with
-// the provided options. The environment should not be nil. If execution is
-// nil, it will default to the most recent execution.
-func GetTestResultsStats(ctx context.Context, env cedar.Environment, opts FindTestResultsOptions) (TestResultsStats, error) {
-	var stats TestResultsStats
-
-	if !opts.DisplayTask {
-		testResultsRecords, err := FindTestResults(ctx, env, opts)
-		if err != nil {
-			return stats, err
-		}
-
-		return testResultsRecords[0].Stats, nil
+// failedTestNames returns the names of the failed tests of the record, in
+// order. The failed tests sample is used when it holds every failure, and the
+// test results are downloaded otherwise.
+func (t *TestResults) failedTestNames(ctx context.Context) ([]string, error) {
+	if t.Stats.FailedCount <= len(t.FailedTestsSample) {
+		return t.FailedTestsSample, nil
 	}
 
-	if env == nil {
-		return stats, errors.New("cannot find with a nil environment")
+	results, err := t.Download(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "downloading test results '%s'", t.ID)
 	}
-	if err := opts.validate(); err != nil {
-		return stats, errors.Wrap(err, "invalid find options")
+	names := make([]string, 0, t.Stats.FailedCount)
+	for i := range results {
+		if results[i].failed() {
+			names = append(names, results[i].GetDisplayName())
+		}
 	}
 
-	pipeline := opts.createPipelineForDisplayTasks()
-	pipeline = append(pipeline, bson.M{
-		"$group": bson.M{
-			"_id": nil,
-			testResultsStatsTotalCountKey: bson.M{
-				"$sum": "$" + bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsTotalCountKey),
-			},
-			testResultsStatsFailedCountKey: bson.M{
-				"$sum": "$" + bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsFailedCountKey),
-			},
-		},
-	})
+	return names, nil
+}
 
-	cur, err := env.GetDB().Collection(testResultsCollection).Aggregate(ctx, pipeline)
+// GetTestResultsStats fetches basic stats for the test results associated with
+// the provided options. The environment should not be nil. If execution is
+// nil, it will default to the most recent execution.
+func GetTestResultsStats(ctx context.Context, env cedar.Environment, opts FindTestResultsOptions) (TestResultsStats, error) {
+	var stats TestResultsStats
+
+	testResultsRecords, err := FindTestResults(ctx, env, opts)
 	if err != nil {
-		return stats, errors.Wrap(err, "aggregating test results stats")
+		return stats, err
 	}
-	if !cur.Next(ctx) {
-		return stats, errors.Wrap(mongo.ErrNoDocuments, opts.createErrorMessage())
+	for _, record := range testResultsRecords {
+		stats.TotalCount += record.Stats.TotalCount
+		stats.FailedCount += record.Stats.FailedCount
 	}
 
-	return stats, errors.Wrap(cur.Decode(&stats), "decoding aggregated test results stats")
+	return stats, errors.Wrap(applyTestQuarantines(ctx, env, testResultsRecords, &stats), "applying test quarantines")
 }
 
 // FindTestResultsByProjectOptions represent the set of options for finding
 // test results by project name.
 type FindTestResultsByProjectOptions struct {
-	Projects []string
-	StartAt  time.Time
-	EndAt    time.Time
+	Projects    []string
+	StartAt     time.Time
+	EndAt       time.Time
+	Variant     string
+	TaskName    string
+	RequestType string
+	// Author filters on the Evergreen metadata of the test results, so
+	// test results that have not been enriched yet are not matched.
+	Author string
+	// FailedOnly limits the search to test results with at least one
+	// failed test.
+	FailedOnly bool
+	// Limit is the maximum number of test results returned, most recent
+	// first. If zero, all matching test results are returned.
+	Limit int
 }
 
 func (o FindTestResultsByProjectOptions) validate() error {
@@ -1192,26 +1501,50 @@ func (o FindTestResultsByProjectOptions) validate() error {
 	catcher.NewWhen(len(o.Projects) == 0, "must specify at least one project")
 	catcher.NewWhen(o.StartAt.After(o.EndAt), "the start date cannot be greater than the end date")
 	catcher.NewWhen(o.EndAt.IsZero(), "end date cannot be zero")
+	catcher.NewWhen(o.Limit < 0, "limit cannot be negative")
 
 	return catcher.Resolve()
 }
 
-// FindTestResultsByProject returns all test results with the
-// given set of project names and within the given date interval.
+func (o FindTestResultsByProjectOptions) createFindQuery() bson.M {
+	query := bson.M{
+		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey): bson.M{"$in": o.Projects},
+		testResultsCreatedAtKey: bson.M{
+			"$gte": o.StartAt,
+			"$lt":  o.EndAt,
+		},
+	}
+	if o.Variant != "" {
+		query[bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoVariantKey)] = o.Variant
+	}
+	if o.TaskName != "" {
+		query[bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoTaskNameKey)] = o.TaskName
+	}
+	if o.RequestType != "" {
+		query[bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoRequestTypeKey)] = o.RequestType
+	}
+	if o.Author != "" {
+		query[bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoEvergreenKey, evergreenMetadataAuthorKey)] = o.Author
+	}
+	if o.FailedOnly {
+		query[bsonutil.GetDottedKeyName(testResultsStatsKey, testResultsStatsFailedCountKey)] = bson.M{"$gt": 0}
+	}
+
+	return query
+}
+
+// FindTestResultsByProject returns all test results with the given set of
+// project names and within the given date interval, most recent first.
 func FindTestResultsByProject(ctx context.Context, env cedar.Environment, opts FindTestResultsByProjectOptions) ([]TestResults, error) {
 	if err := opts.validate(); err != nil {
 		return nil, errors.Wrap(err, "invalid options")
 	}
 
-	filter := bson.M{
-		bsonutil.GetDottedKeyName(testResultsInfoKey, testResultsInfoProjectKey): bson.M{"$in": opts.Projects},
-		testResultsCreatedAtKey: bson.M{
-			"$gte": opts.StartAt,
-			"$lt":  opts.EndAt,
-		},
+	findOpts := options.Find().SetSort(bson.D{{Key: testResultsCreatedAtKey, Value: -1}})
+	if opts.Limit > 0 {
+		findOpts.SetLimit(int64(opts.Limit))
 	}
-
-	cur, err := env.GetDB().Collection(testResultsCollection).Find(ctx, filter)
+	cur, err := env.GetDB().Collection(testResultsCollection).Find(ctx, opts.createFindQuery(), findOpts)
 	if err != nil {
 		return nil, errors.Wrap(err, "finding test results")
 	}
@@ -1229,6 +1562,164 @@ func FindTestResultsByProject(ctx context.Context, env cedar.Environment, opts F
 	return results, nil
 }
 
+// testResultsByProjectBatchSize is the number of test results records
+// downloaded at a time when finding test results by project.
+const testResultsByProjectBatchSize = 50
+
+// FindAndDownloadTestResultsByProjectOptions allow for finding, downloading,
+// filtering, sorting, and paginating test results across the task executions
+// of projects.
+type FindAndDownloadTestResultsByProjectOptions struct {
+	Find          FindTestResultsByProjectOptions
+	FilterAndSort *FilterAndSortTestResultsOptions
+	// MaxResults is the maximum number of test results downloaded,
+	// counted from the stats of the matching TestResults, most recent
+	// first. The most recent TestResults is always downloaded. If zero,
+	// the number of test results is not limited.
+	MaxResults int
+	// DownloadTimeout is the maximum time spent downloading test results.
+	// TestResults are downloaded in batches, most recent first, and a
+	// batch not downloaded in time is dropped. If zero, the download time
+	// is not limited.
+	DownloadTimeout time.Duration
+}
+
+// TestResultsByProject is the result of finding and downloading test results
+// by project.
+type TestResultsByProject struct {
+	// Results are the filtered, sorted, and paginated test results.
+	Results []TestResult
+	// Stats are the summed stats of the downloaded TestResults.
+	Stats TestResultsStats
+	// FilteredCount is the number of downloaded test results that matched
+	// the filters.
+	FilteredCount int
+	// Truncated is true when not all of the matching TestResults were
+	// downloaded because of the record limit, MaxResults, or
+	// DownloadTimeout.
+	Truncated bool
+}
+
+// FindAndDownloadTestResultsByProject searches the DB for the TestResults of
+// the projects matching the provided options and returns their downloaded
+// test results filtered, sorted, and paginated. Unless sorted otherwise, test
+// results are ordered by most recent task first. The TestResults are
+// downloaded most recent first until the limits of the options are reached,
+// and each batch is filtered as soon as it is downloaded. The environment
+// should not be nil.
+func FindAndDownloadTestResultsByProject(ctx context.Context, env cedar.Environment, opts FindAndDownloadTestResultsByProjectOptions) (*TestResultsByProject, error) {
+	if opts.FilterAndSort != nil {
+		if opts.FilterAndSort.BaseResults != nil {
+			return nil, errors.New("cannot specify base results when finding test results by project")
+		}
+		if err := opts.FilterAndSort.validate(); err != nil {
+			return nil, errors.Wrap(err, "validating filter and sort test results options")
+		}
+		if len(opts.FilterAndSort.Statuses) > 0 {
+			// Only test results with failed tests need to be
+			// downloaded when filtering exclusively on failed
+			// statuses.
+			opts.Find.FailedOnly = true
+			for _, status := range opts.FilterAndSort.Statuses {
+				if !strings.Contains(strings.ToLower(status), "fail") {
+					opts.Find.FailedOnly = false
+					break
+				}
+			}
+		}
+	}
+
+	out := &TestResultsByProject{}
+	limit := opts.Find.Limit
+	if limit > 0 {
+		// Find one more record than the limit to know whether the
+		// records are truncated.
+		opts.Find.Limit++
+	}
+	testResults, err := FindTestResultsByProject(ctx, env, opts.Find)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(testResults) > limit {
+		testResults = testResults[:limit]
+		out.Truncated = true
+	}
+	if opts.MaxResults > 0 {
+		var count int
+		for i, record := range testResults {
+			count += record.Stats.TotalCount
+			if i > 0 && count > opts.MaxResults {
+				testResults = testResults[:i]
+				out.Truncated = true
+				break
+			}
+		}
+	}
+
+	downloadCtx := ctx
+	if opts.DownloadTimeout > 0 {
+		var cancel context.CancelFunc
+		downloadCtx, cancel = context.WithTimeout(ctx, opts.DownloadTimeout)
+		defer cancel()
+	}
+
+	var combinedResults []TestResult
+	createdAt := make(map[string]time.Time, len(testResults))
+	for start := 0; start < len(testResults); start += testResultsByProjectBatchSize {
+		end := start + testResultsByProjectBatchSize
+		if end > len(testResults) {
+			end = len(testResults)
+		}
+		batch := testResults[start:end]
+
+		results, err := downloadTestResults(downloadCtx, env, batch)
+		if err != nil {
+			if downloadCtx.Err() != nil && ctx.Err() == nil {
+				out.Truncated = true
+				break
+			}
+			return nil, err
+		}
+		if opts.FilterAndSort != nil {
+			results = filterTestResults(results, opts.FilterAndSort)
+		}
+		combinedResults = append(combinedResults, results...)
+
+		for _, record := range batch {
+			out.Stats.TotalCount += record.Stats.TotalCount
+			out.Stats.FailedCount += record.Stats.FailedCount
+			createdAt[fmt.Sprintf("%s.%d", record.Info.TaskID, record.Info.Execution)] = record.CreatedAt
+		}
+	}
+
+	// Results are downloaded concurrently, so order them deterministically
+	// before any sorting and pagination.
+	sort.SliceStable(combinedResults, func(i, j int) bool {
+		ri, rj := combinedResults[i], combinedResults[j]
+		ci := createdAt[fmt.Sprintf("%s.%d", ri.TaskID, ri.Execution)]
+		cj := createdAt[fmt.Sprintf("%s.%d", rj.TaskID, rj.Execution)]
+		if !ci.Equal(cj) {
+			return ci.After(cj)
+		}
+		if ri.TaskID != rj.TaskID {
+			return ri.TaskID < rj.TaskID
+		}
+		if ri.Execution != rj.Execution {
+			return ri.Execution > rj.Execution
+		}
+		return ri.GetDisplayName() < rj.GetDisplayName()
+	})
+
+	out.FilteredCount = len(combinedResults)
+	out.Results = combinedResults
+	if opts.FilterAndSort != nil {
+		sortTestResults(combinedResults, opts.FilterAndSort)
+		out.Results = paginateTestResults(combinedResults, opts.FilterAndSort)
+	}
+
+	return out, nil
+}
+
 // ParquetTestResults describes a set of test results from a task execution to
 // be stored in Apache Parquet format.
 type ParquetTestResults struct {
@@ -1262,6 +1753,17 @@ func (r ParquetTestResults) convertToTestResultSlice() []TestResult {
 			TaskCreateTime:  r.Results[i].TaskCreateTime,
 			TestStartTime:   r.Results[i].TestStartTime,
 			TestEndTime:     r.Results[i].TestEndTime,
+			FailureMessage:  utility.FromStringPtr(r.Results[i].FailureMessage),
+			StackTrace:      utility.FromStringPtr(r.Results[i].StackTrace),
+		}
+		if len(r.Results[i].Tags) > 0 {
+			results[i].Tags = r.Results[i].Tags
+		}
+		if len(r.Results[i].Attributes) > 0 {
+			results[i].Attributes = make(map[string]string, len(r.Results[i].Attributes))
+			for _, attr := range r.Results[i].Attributes {
+				results[i].Attributes[attr.Key] = attr.Value
+			}
 		}
 	}
 
@@ -1269,7 +1771,8 @@ func (r ParquetTestResults) convertToTestResultSlice() []TestResult {
 }
 
 // ParquetTestResult describes a single test result to be stored in Apache
-// Parquet file format.
+// Parquet file format. Fields added after the initial schema must be optional
+// so that files written with an earlier schema can still be read.
 type ParquetTestResult struct {
 	TestName        string    `parquet:"name=test_name"`
 	DisplayTestName *string   `parquet:"name=display_test_name"`
@@ -1283,4 +1786,16 @@ type ParquetTestResult struct {
 	TaskCreateTime  time.Time `parquet:"name=task_create_time, timeunit=MILLIS"`
 	TestStartTime   time.Time `parquet:"name=test_start_time, timeunit=MILLIS"`
 	TestEndTime     time.Time `parquet:"name=test_end_time, timeunit=MILLIS"`
+
+	FailureMessage *string                      `parquet:"name=failure_message"`
+	StackTrace     *string                      `parquet:"name=stack_trace"`
+	Tags           []string                     `parquet:"name=tags"`
+	Attributes     []ParquetTestResultAttribute `parquet:"name=attributes"`
+}
+
+// ParquetTestResultAttribute is a custom key/value attribute of a test result
+// stored in Apache Parquet file format.
+type ParquetTestResultAttribute struct {
+	Key   string `parquet:"name=key"`
+	Value string `parquet:"name=value"`
 }
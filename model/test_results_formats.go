@@ -0,0 +1,607 @@
+package model
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// TestResultsFormat is the format of a file of test results produced by a
+// test harness.
+type TestResultsFormat string
+
+const (
+	TestResultsFormatJUnit  TestResultsFormat = "junit"
+	TestResultsFormatGoTest TestResultsFormat = "gotest"
+	TestResultsFormatTAP    TestResultsFormat = "tap"
+)
+
+// Validate returns an error if the test results format is not supported.
+func (f TestResultsFormat) Validate() error {
+	switch f {
+	case TestResultsFormatJUnit, TestResultsFormatGoTest, TestResultsFormatTAP:
+		return nil
+	default:
+		return errors.Errorf("unsupported test results format '%s'", f)
+	}
+}
+
+// Statuses of parsed test results.
+const (
+	testResultStatusPass = "pass"
+	testResultStatusFail = "fail"
+	testResultStatusSkip = "skip"
+)
+
+// ParseTestResults parses the test results file in the given format. The task
+// ID and execution of the parsed test results are not set.
+func ParseTestResults(format TestResultsFormat, r io.Reader) ([]TestResult, error) {
+	switch format {
+	case TestResultsFormatJUnit:
+		return parseJUnitTestResults(r, time.Now())
+	case TestResultsFormatGoTest:
+		return parseGoTestResults(r)
+	case TestResultsFormatTAP:
+		return parseTAPTestResults(r, time.Now())
+	default:
+		return nil, errors.Errorf("unsupported test results format '%s'", format)
+	}
+}
+
+// IngestTestResults creates a test results record for the task execution,
+// stores the test results for it and closes it. If the test results cannot be
+// stored or the record cannot be closed, the record is removed so that the
+// ingestion can be retried.
+func IngestTestResults(ctx context.Context, env cedar.Environment, info TestResultsInfo, results []TestResult) (*TestResults, error) {
+	conf := NewCedarConfig(env)
+	if err := conf.Find(); err != nil {
+		return nil, errors.Wrap(err, "getting application configuration")
+	}
+	if conf.Bucket.TestResultsBucketType == "" {
+		return nil, errors.New("bucket type not specified")
+	}
+
+	record := CreateTestResults(info, conf.Bucket.TestResultsStorageType())
+	record.Setup(env)
+	if err := record.SaveNew(ctx); err != nil {
+		return nil, errors.Wrap(err, "saving test results record")
+	}
+
+	for i := range results {
+		results[i].TaskID = info.TaskID
+		results[i].Execution = info.Execution
+	}
+	// The record is new, so any stored test results were left by a failed
+	// attempt and are replaced rather than appended to.
+	var err error
+	if len(results) > 0 {
+		err = errors.Wrapf(record.replace(ctx, results, results), "storing test results for '%s'", record.ID)
+	}
+	if err == nil {
+		_, err = record.Close(ctx)
+	}
+	if err != nil {
+		removeCtx, cancel := env.Context()
+		defer cancel()
+		grip.Error(message.WrapError(record.Remove(removeCtx), message.Fields{
+			"message": "could not remove test results record after failed ingestion",
+			"id":      record.ID,
+		}))
+		return nil, err
+	}
+
+	return record, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// JUnit XML
+
+type junitTestSuites struct {
+	Suites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Timestamp string           `xml:"timestamp,attr"`
+	Suites    []junitTestSuite `xml:"testsuite"`
+	Cases     []junitTestCase  `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string          `xml:"name,attr"`
+	ClassName  string          `xml:"classname,attr"`
+	Time       string          `xml:"time,attr"`
+	Failure    *junitFailure   `xml:"failure"`
+	Error      *junitFailure   `xml:"error"`
+	Skipped    *struct{}       `xml:"skipped"`
+	Properties []junitProperty `xml:"properties>property"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// parseJUnitTestResults parses a JUnit XML report with either a testsuites or
+// a testsuite root element. The group ID of each test result is the name of
+// the innermost suite containing it. Test cases of a suite without a
+// timestamp are considered to have started at the given time. The message and
+// body of a failure or error are the failure message and stack trace of the
+// test result and test case properties are its attributes.
+func parseJUnitTestResults(r io.Reader, now time.Time) ([]TestResult, error) {
+	dec := xml.NewDecoder(r)
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil, errors.New("JUnit report has no testsuites or testsuite element")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading JUnit report")
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		var suites []junitTestSuite
+		switch start.Name.Local {
+		case "testsuites":
+			root := junitTestSuites{}
+			if err := dec.DecodeElement(&root, &start); err != nil {
+				return nil, errors.Wrap(err, "decoding JUnit testsuites")
+			}
+			suites = root.Suites
+		case "testsuite":
+			suite := junitTestSuite{}
+			if err := dec.DecodeElement(&suite, &start); err != nil {
+				return nil, errors.Wrap(err, "decoding JUnit testsuite")
+			}
+			suites = []junitTestSuite{suite}
+		default:
+			return nil, errors.Errorf("unexpected JUnit root element '%s'", start.Name.Local)
+		}
+
+		var results []TestResult
+		for _, suite := range suites {
+			suiteResults, err := suite.export(now)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, suiteResults...)
+		}
+		return results, nil
+	}
+}
+
+func (s junitTestSuite) export(now time.Time) ([]TestResult, error) {
+	start := now
+	if s.Timestamp != "" {
+		var err error
+		start, err = parseJUnitTimestamp(s.Timestamp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing timestamp of JUnit testsuite '%s'", s.Name)
+		}
+	}
+
+	var results []TestResult
+	for _, tc := range s.Cases {
+		duration, err := parseJUnitDuration(tc.Time)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing time of JUnit testcase '%s'", tc.Name)
+		}
+
+		name := tc.Name
+		if tc.ClassName != "" {
+			name = fmt.Sprintf("%s.%s", tc.ClassName, tc.Name)
+		}
+		result := TestResult{
+			TestName:      name,
+			GroupID:       s.Name,
+			Status:        testResultStatusPass,
+			TestStartTime: start,
+			TestEndTime:   start.Add(duration),
+		}
+		failure := tc.Failure
+		if failure == nil {
+			failure = tc.Error
+		}
+		if failure != nil {
+			result.Status = testResultStatusFail
+			result.FailureMessage = failure.Message
+			result.StackTrace = strings.TrimSpace(failure.Text)
+		} else if tc.Skipped != nil {
+			result.Status = testResultStatusSkip
+		}
+		for _, prop := range tc.Properties {
+			if result.Attributes == nil {
+				result.Attributes = map[string]string{}
+			}
+			result.Attributes[prop.Name] = prop.Value
+		}
+
+		results = append(results, result)
+		start = start.Add(duration)
+	}
+	for _, child := range s.Suites {
+		childResults, err := child.export(start)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, childResults...)
+	}
+
+	return results, nil
+}
+
+func parseJUnitTimestamp(timestamp string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, timestamp); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid timestamp '%s'", timestamp)
+}
+
+// parseJUnitDuration parses a duration in seconds, which some harnesses
+// format with thousands separators.
+func parseJUnitDuration(seconds string) (time.Duration, error) {
+	seconds = strings.ReplaceAll(strings.TrimSpace(seconds), ",", "")
+	if seconds == "" {
+		return 0, nil
+	}
+	secs, err := strconv.ParseFloat(seconds, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid duration '%s'", seconds)
+	}
+	if secs < 0 {
+		return 0, errors.Errorf("negative duration '%s'", seconds)
+	}
+
+	return time.Duration(secs * float64(time.Second)), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// go test -json
+
+type goTestEvent struct {
+	Time    time.Time `json:"Time"`
+	Action  string    `json:"Action"`
+	Package string    `json:"Package"`
+	Test    string    `json:"Test"`
+	Output  string    `json:"Output"`
+	Elapsed float64   `json:"Elapsed"`
+}
+
+// maxGoTestFailureMessageSize is the largest failure message, in bytes, taken
+// from the output of a failed test. Longer output is truncated from the
+// start, since the cause of a failure is usually reported last.
+const maxGoTestFailureMessageSize = 16 * 1024
+
+// goTestFramingLine matches the lines that go test prints around the output
+// of tests and packages.
+var goTestFramingLine = regexp.MustCompile(`^\s*(?:=== (?:RUN|PAUSE|CONT|NAME)\b|--- (?:PASS|FAIL|SKIP):|(?:PASS|FAIL)\s*$|(?:ok  |FAIL)\t|coverage:)`)
+
+// parseGoTestResults parses the output of `go test -json`. The group ID of
+// each test result is its package. Tests that never finish, for example
+// because the test binary panicked or timed out, fail at the time of the last
+// event of their package. A package that fails without any failing test, for
+// example because it did not build or TestMain failed, is reported as a failed
+// test result named after the package. The failure message of a failed test
+// is its output and, for tests that never finish and failed packages, the
+// output of its package. Lines that are not JSON, such as build errors, are
+// ignored.
+func parseGoTestResults(r io.Reader) ([]TestResult, error) {
+	var (
+		results        []TestResult
+		index          = map[string]int{}
+		done           = map[string]bool{}
+		first          = map[string]time.Time{}
+		last           = map[string]time.Time{}
+		output         = map[string]*strings.Builder{}
+		failedPackages []string
+	)
+	addOutput := func(key, line string) {
+		if goTestFramingLine.MatchString(line) {
+			return
+		}
+		if output[key] == nil {
+			output[key] = &strings.Builder{}
+		}
+		output[key].WriteString(line)
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+
+		event := goTestEvent{}
+		if err := json.Unmarshal(line, &event); err != nil {
+			return nil, errors.Wrapf(err, "decoding go test event on line %d", lineNum)
+		}
+		if !event.Time.IsZero() {
+			if _, ok := first[event.Package]; !ok {
+				first[event.Package] = event.Time
+			}
+			last[event.Package] = event.Time
+		}
+		if event.Test == "" {
+			switch event.Action {
+			case "output":
+				addOutput(event.Package, event.Output)
+			case "fail":
+				failedPackages = append(failedPackages, event.Package)
+			}
+			continue
+		}
+
+		key := event.Package + "\x00" + event.Test
+		if event.Action == "output" {
+			addOutput(key, event.Output)
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(results)
+			index[key] = i
+			results = append(results, TestResult{
+				TestName:      event.Test,
+				GroupID:       event.Package,
+				TestStartTime: event.Time,
+			})
+		}
+
+		var status string
+		switch event.Action {
+		case "pass":
+			status = testResultStatusPass
+		case "fail":
+			status = testResultStatusFail
+		case "skip":
+			status = testResultStatusSkip
+		default:
+			continue
+		}
+		results[i].Status = status
+		results[i].TestEndTime = event.Time
+		if !ok {
+			results[i].TestStartTime = event.Time.Add(-time.Duration(event.Elapsed * float64(time.Second)))
+		}
+		done[key] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading go test output")
+	}
+
+	for key, i := range index {
+		if !done[key] {
+			results[i].Status = testResultStatusFail
+			results[i].TestEndTime = last[results[i].GroupID]
+			results[i].FailureMessage = goTestFailureMessage(output[key], output[results[i].GroupID])
+		} else if results[i].Status == testResultStatusFail {
+			results[i].FailureMessage = goTestFailureMessage(output[key])
+		}
+	}
+
+	hasFailedTest := map[string]bool{}
+	for _, result := range results {
+		if result.Status == testResultStatusFail {
+			hasFailedTest[result.GroupID] = true
+		}
+	}
+	for _, pkg := range failedPackages {
+		if hasFailedTest[pkg] {
+			continue
+		}
+		results = append(results, TestResult{
+			TestName:       pkg,
+			GroupID:        pkg,
+			Status:         testResultStatusFail,
+			FailureMessage: goTestFailureMessage(output[pkg]),
+			TestStartTime:  first[pkg],
+			TestEndTime:    last[pkg],
+		})
+		hasFailedTest[pkg] = true
+	}
+
+	return results, nil
+}
+
+// goTestFailureMessage joins the given output, keeping at most the last
+// maxGoTestFailureMessageSize bytes.
+func goTestFailureMessage(outputs ...*strings.Builder) string {
+	var parts []string
+	for _, out := range outputs {
+		if out == nil {
+			continue
+		}
+		if part := strings.TrimSpace(out.String()); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	msg := strings.Join(parts, "\n")
+	if len(msg) > maxGoTestFailureMessageSize {
+		start := len(msg) - maxGoTestFailureMessageSize
+		for start < len(msg) && !utf8.RuneStart(msg[start]) {
+			start++
+		}
+		msg = msg[start:]
+	}
+
+	return msg
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// TAP
+
+var (
+	tapTestLine    = regexp.MustCompile(`^(\s*)(not ok|ok)\b\s*(\d+)?\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(.*))?$`)
+	tapSubtestLine = regexp.MustCompile(`^(\s*)#\s*Subtest:\s*(.*?)\s*$`)
+	tapBailOutLine = regexp.MustCompile(`^\s*Bail out!`)
+)
+
+// parseTAPTestResults parses a TAP stream. Test points of a subtest have the
+// name of the subtest as their group ID. Since TAP does not report timing, all
+// test results start and end at the given time. A SKIP directive skips the
+// test, as does a TODO directive on a failing test. The failure message and
+// stack trace of a failed test come from the message and stack of the YAML
+// diagnostic block following it or, without one, from the diagnostic comments
+// following it.
+func parseTAPTestResults(r io.Reader, now time.Time) ([]TestResult, error) {
+	var results []TestResult
+	subtests := map[int]string{}
+	counts := map[int]int{}
+
+	// failed is the index of the failed test point whose diagnostics
+	// are being read, if any.
+	failed := -1
+	var (
+		inYAML   bool
+		yamlDiag []string
+		comments []string
+	)
+	finishDiagnostics := func() {
+		if failed >= 0 {
+			results[failed].FailureMessage, results[failed].StackTrace = tapFailureDiagnostics(yamlDiag, comments)
+		}
+		failed = -1
+		inYAML = false
+		yamlDiag = nil
+		comments = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if failed >= 0 {
+			trimmed := strings.TrimSpace(line)
+			if inYAML {
+				if trimmed == "..." {
+					inYAML = false
+				} else {
+					yamlDiag = append(yamlDiag, line)
+				}
+				continue
+			}
+			if trimmed == "---" && yamlDiag == nil {
+				inYAML = true
+				continue
+			}
+			if strings.HasPrefix(trimmed, "#") && !tapSubtestLine.MatchString(line) {
+				comments = append(comments, strings.TrimSpace(strings.TrimPrefix(trimmed, "#")))
+				continue
+			}
+		}
+		if tapBailOutLine.MatchString(line) {
+			break
+		}
+		if match := tapSubtestLine.FindStringSubmatch(line); match != nil {
+			finishDiagnostics()
+			subtests[len(match[1])] = match[2]
+			counts[len(match[1])] = 0
+			continue
+		}
+		match := tapTestLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		finishDiagnostics()
+
+		indent := len(match[1])
+		counts[indent]++
+		name := match[4]
+		if name == "" {
+			// Test points are numbered implicitly by their position
+			// when the number is omitted.
+			number := match[3]
+			if number == "" {
+				number = strconv.Itoa(counts[indent])
+			}
+			name = fmt.Sprintf("test %s", number)
+		}
+		status := testResultStatusPass
+		if match[2] == "not ok" {
+			status = testResultStatusFail
+		}
+		directive := strings.ToLower(match[5])
+		if strings.HasPrefix(directive, "skip") || (strings.HasPrefix(directive, "todo") && status == testResultStatusFail) {
+			status = testResultStatusSkip
+		}
+
+		var group string
+		if indent > 0 {
+			group = subtests[indent]
+		}
+		results = append(results, TestResult{
+			TestName:      name,
+			GroupID:       group,
+			Status:        status,
+			TestStartTime: now,
+			TestEndTime:   now,
+		})
+		if status == testResultStatusFail {
+			failed = len(results) - 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading TAP stream")
+	}
+	finishDiagnostics()
+
+	return results, nil
+}
+
+// tapFailureDiagnostics returns the failure message and stack trace from the
+// lines of a YAML diagnostic block and the diagnostic comments following a
+// failed test point. If the block has no message, the block itself is the
+// failure message.
+func tapFailureDiagnostics(yamlDiag, comments []string) (string, string) {
+	if len(yamlDiag) > 0 {
+		indent := len(yamlDiag[0]) - len(strings.TrimLeft(yamlDiag[0], " \t"))
+		lines := make([]string, len(yamlDiag))
+		for i, line := range yamlDiag {
+			if len(line) >= indent && strings.TrimSpace(line[:indent]) == "" {
+				line = line[indent:]
+			}
+			lines[i] = line
+		}
+		block := strings.Join(lines, "\n")
+
+		diag := struct {
+			Message string `yaml:"message"`
+			Stack   string `yaml:"stack"`
+		}{}
+		if err := yaml.Unmarshal([]byte(block), &diag); err == nil && diag.Message != "" {
+			return strings.TrimSpace(diag.Message), strings.TrimSpace(diag.Stack)
+		}
+		return strings.TrimSpace(block), ""
+	}
+
+	return strings.TrimSpace(strings.Join(comments, "\n")), ""
+}
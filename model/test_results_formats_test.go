@@ -0,0 +1,341 @@
+package model
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJUnitTestResults(t *testing.T) {
+	now := time.Now().UTC().Round(time.Second)
+	start := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("TestSuites", func(t *testing.T) {
+		report := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="suite1" timestamp="2021-01-02T03:04:05">
+    <testcase classname="pkg.Class" name="testPass" time="1.5"/>
+    <testcase classname="pkg.Class" name="testFail" time="2">
+      <properties><property name="owner" value="team"/></properties>
+      <failure message="boom">
+        trace
+      </failure>
+    </testcase>
+    <testcase name="testError" time="1,000"><error/></testcase>
+    <testcase name="testSkip"><skipped/></testcase>
+  </testsuite>
+  <testsuite name="suite2">
+    <testsuite name="nested">
+      <testcase name="testNested" time="0.25"/>
+    </testsuite>
+  </testsuite>
+</testsuites>`
+		results, err := parseJUnitTestResults(strings.NewReader(report), now)
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+
+		assert.Equal(t, "pkg.Class.testPass", results[0].TestName)
+		assert.Equal(t, "suite1", results[0].GroupID)
+		assert.Equal(t, testResultStatusPass, results[0].Status)
+		assert.Equal(t, start, results[0].TestStartTime)
+		assert.Equal(t, start.Add(1500*time.Millisecond), results[0].TestEndTime)
+
+		assert.Equal(t, "pkg.Class.testFail", results[1].TestName)
+		assert.Equal(t, testResultStatusFail, results[1].Status)
+		assert.Equal(t, results[0].TestEndTime, results[1].TestStartTime)
+		assert.Equal(t, 2*time.Second, results[1].getDuration())
+		assert.Equal(t, "boom", results[1].FailureMessage)
+		assert.Equal(t, "trace", results[1].StackTrace)
+		assert.Equal(t, map[string]string{"owner": "team"}, results[1].Attributes)
+
+		assert.Equal(t, "testError", results[2].TestName)
+		assert.Equal(t, testResultStatusFail, results[2].Status)
+		assert.Equal(t, 1000*time.Second, results[2].getDuration())
+
+		assert.Equal(t, "testSkip", results[3].TestName)
+		assert.Equal(t, testResultStatusSkip, results[3].Status)
+
+		assert.Equal(t, "testNested", results[4].TestName)
+		assert.Equal(t, "nested", results[4].GroupID)
+		assert.Equal(t, now, results[4].TestStartTime)
+	})
+	t.Run("TestSuite", func(t *testing.T) {
+		report := `<testsuite name="suite"><testcase name="test"/></testsuite>`
+		results, err := parseJUnitTestResults(strings.NewReader(report), now)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "test", results[0].TestName)
+		assert.Equal(t, "suite", results[0].GroupID)
+	})
+	t.Run("UnexpectedRoot", func(t *testing.T) {
+		_, err := parseJUnitTestResults(strings.NewReader("<html></html>"), now)
+		assert.Error(t, err)
+	})
+	t.Run("Empty", func(t *testing.T) {
+		_, err := parseJUnitTestResults(strings.NewReader(""), now)
+		assert.Error(t, err)
+	})
+	t.Run("InvalidTime", func(t *testing.T) {
+		report := `<testsuite name="suite"><testcase name="test" time="soon"/></testsuite>`
+		_, err := parseJUnitTestResults(strings.NewReader(report), now)
+		assert.Error(t, err)
+	})
+	t.Run("InvalidTimestamp", func(t *testing.T) {
+		report := `<testsuite name="suite" timestamp="yesterday"><testcase name="test"/></testsuite>`
+		_, err := parseJUnitTestResults(strings.NewReader(report), now)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseGoTestResults(t *testing.T) {
+	t.Run("Events", func(t *testing.T) {
+		output := `{"Time":"2021-01-02T03:04:05Z","Action":"run","Package":"example.com/pkg","Test":"TestPass"}
+{"Time":"2021-01-02T03:04:05Z","Action":"output","Package":"example.com/pkg","Test":"TestPass","Output":"=== RUN   TestPass\n"}
+{"Time":"2021-01-02T03:04:06Z","Action":"pass","Package":"example.com/pkg","Test":"TestPass","Elapsed":1}
+{"Time":"2021-01-02T03:04:06Z","Action":"run","Package":"example.com/pkg","Test":"TestFail"}
+{"Time":"2021-01-02T03:04:06Z","Action":"run","Package":"example.com/pkg","Test":"TestFail/Subtest"}
+{"Time":"2021-01-02T03:04:07Z","Action":"skip","Package":"example.com/pkg","Test":"TestFail/Subtest","Elapsed":1}
+{"Time":"2021-01-02T03:04:08Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"    pkg_test.go:10: expected 1, got 2\n"}
+{"Time":"2021-01-02T03:04:08Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"--- FAIL: TestFail (2.00s)\n"}
+{"Time":"2021-01-02T03:04:08Z","Action":"fail","Package":"example.com/pkg","Test":"TestFail","Elapsed":2}
+FAIL	example.com/other [build failed]
+{"Time":"2021-01-02T03:04:08Z","Action":"run","Package":"example.com/other","Test":"TestPanic"}
+{"Time":"2021-01-02T03:04:10Z","Action":"output","Package":"example.com/other","Output":"panic: oops\n"}
+{"Time":"2021-01-02T03:04:10Z","Action":"fail","Package":"example.com/pkg","Elapsed":5}
+`
+		results, err := parseGoTestResults(strings.NewReader(output))
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		start := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		assert.Equal(t, "TestPass", results[0].TestName)
+		assert.Equal(t, "example.com/pkg", results[0].GroupID)
+		assert.Equal(t, testResultStatusPass, results[0].Status)
+		assert.True(t, start.Equal(results[0].TestStartTime))
+		assert.True(t, start.Add(time.Second).Equal(results[0].TestEndTime))
+
+		assert.Equal(t, "TestFail", results[1].TestName)
+		assert.Equal(t, testResultStatusFail, results[1].Status)
+		assert.Equal(t, 2*time.Second, results[1].getDuration())
+		assert.Equal(t, "pkg_test.go:10: expected 1, got 2", results[1].FailureMessage)
+		assert.Empty(t, results[0].FailureMessage)
+
+		assert.Equal(t, "TestFail/Subtest", results[2].TestName)
+		assert.Equal(t, testResultStatusSkip, results[2].Status)
+
+		assert.Equal(t, "TestPanic", results[3].TestName)
+		assert.Equal(t, "example.com/other", results[3].GroupID)
+		assert.Equal(t, testResultStatusFail, results[3].Status)
+		assert.Equal(t, 2*time.Second, results[3].getDuration())
+		assert.Equal(t, "panic: oops", results[3].FailureMessage)
+	})
+	t.Run("PackageFailures", func(t *testing.T) {
+		output := `{"Time":"2021-01-02T03:04:05Z","Action":"start","Package":"example.com/build"}
+{"Time":"2021-01-02T03:04:06Z","Action":"fail","Package":"example.com/build","Elapsed":1}
+{"Time":"2021-01-02T03:04:05Z","Action":"run","Package":"example.com/main","Test":"TestPass"}
+{"Time":"2021-01-02T03:04:06Z","Action":"pass","Package":"example.com/main","Test":"TestPass","Elapsed":1}
+{"Time":"2021-01-02T03:04:08Z","Action":"output","Package":"example.com/main","Output":"TestMain failed\n"}
+{"Time":"2021-01-02T03:04:08Z","Action":"fail","Package":"example.com/main","Elapsed":3}
+{"Time":"2021-01-02T03:04:05Z","Action":"run","Package":"example.com/pkg","Test":"TestFail"}
+{"Time":"2021-01-02T03:04:06Z","Action":"fail","Package":"example.com/pkg","Test":"TestFail","Elapsed":1}
+{"Time":"2021-01-02T03:04:06Z","Action":"fail","Package":"example.com/pkg","Elapsed":1}
+`
+		results, err := parseGoTestResults(strings.NewReader(output))
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+
+		assert.Equal(t, "TestPass", results[0].TestName)
+		assert.Equal(t, "TestFail", results[1].TestName)
+
+		assert.Equal(t, "example.com/build", results[2].TestName)
+		assert.Equal(t, "example.com/build", results[2].GroupID)
+		assert.Equal(t, testResultStatusFail, results[2].Status)
+		assert.Equal(t, time.Second, results[2].getDuration())
+
+		assert.Equal(t, "example.com/main", results[3].TestName)
+		assert.Equal(t, "example.com/main", results[3].GroupID)
+		assert.Equal(t, testResultStatusFail, results[3].Status)
+		assert.Equal(t, 3*time.Second, results[3].getDuration())
+		assert.Equal(t, "TestMain failed", results[3].FailureMessage)
+	})
+	t.Run("TruncatesLongFailureMessage", func(t *testing.T) {
+		output := `{"Time":"2021-01-02T03:04:05Z","Action":"run","Package":"example.com/pkg","Test":"TestFail"}
+{"Time":"2021-01-02T03:04:05Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"` + strings.Repeat("a", maxGoTestFailureMessageSize) + `\n"}
+{"Time":"2021-01-02T03:04:05Z","Action":"output","Package":"example.com/pkg","Test":"TestFail","Output":"cause\n"}
+{"Time":"2021-01-02T03:04:06Z","Action":"fail","Package":"example.com/pkg","Test":"TestFail","Elapsed":1}
+`
+		results, err := parseGoTestResults(strings.NewReader(output))
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Len(t, results[0].FailureMessage, maxGoTestFailureMessageSize)
+		assert.True(t, strings.HasSuffix(results[0].FailureMessage, "\ncause"))
+	})
+	t.Run("FinishedWithoutRun", func(t *testing.T) {
+		output := `{"Time":"2021-01-02T03:04:05Z","Action":"pass","Package":"example.com/pkg","Test":"TestPass","Elapsed":1.5}`
+		results, err := parseGoTestResults(strings.NewReader(output))
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, 1500*time.Millisecond, results[0].getDuration())
+	})
+	t.Run("InvalidEvent", func(t *testing.T) {
+		_, err := parseGoTestResults(strings.NewReader(`{"Action": 1}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestParseTAPTestResults(t *testing.T) {
+	now := time.Now()
+
+	t.Run("Stream", func(t *testing.T) {
+		stream := `TAP version 14
+1..8
+ok 1 - passes
+not ok 2 - fails
+  ---
+  message: boom
+  stack: |
+    at foo.js:1
+    at bar.js:2
+  ok: false
+  ...
+ok 3 - skipped # SKIP not supported
+not ok 4 - expected failure # TODO not implemented
+ok
+    # Subtest: group
+    not ok 1 - nested
+    # expected 1
+    # got 2
+    1..1
+not ok 6 - group
+  ---
+  severity: fail
+  ...
+not ok 7 - no diagnostics
+Bail out! Database unavailable
+ok 7 - after bail out
+`
+		results, err := parseTAPTestResults(strings.NewReader(stream), now)
+		require.NoError(t, err)
+		require.Len(t, results, 8)
+
+		for i, expected := range []struct {
+			name    string
+			group   string
+			status  string
+			message string
+			stack   string
+		}{
+			{name: "passes", status: testResultStatusPass},
+			{name: "fails", status: testResultStatusFail, message: "boom", stack: "at foo.js:1\nat bar.js:2"},
+			{name: "skipped", status: testResultStatusSkip},
+			{name: "expected failure", status: testResultStatusSkip},
+			{name: "test 5", status: testResultStatusPass},
+			{name: "nested", group: "group", status: testResultStatusFail, message: "expected 1\ngot 2"},
+			{name: "group", status: testResultStatusFail, message: "severity: fail"},
+			{name: "no diagnostics", status: testResultStatusFail},
+		} {
+			assert.Equal(t, expected.name, results[i].TestName)
+			assert.Equal(t, expected.group, results[i].GroupID)
+			assert.Equal(t, expected.status, results[i].Status)
+			assert.Equal(t, expected.message, results[i].FailureMessage, expected.name)
+			assert.Equal(t, expected.stack, results[i].StackTrace, expected.name)
+			assert.Equal(t, now, results[i].TestStartTime)
+			assert.Equal(t, now, results[i].TestEndTime)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		results, err := parseTAPTestResults(strings.NewReader(""), now)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
+
+func TestParseTestResults(t *testing.T) {
+	t.Run("InvalidFormat", func(t *testing.T) {
+		_, err := ParseTestResults(TestResultsFormat("csv"), strings.NewReader(""))
+		assert.Error(t, err)
+	})
+	t.Run("ValidFormat", func(t *testing.T) {
+		results, err := ParseTestResults(TestResultsFormatTAP, strings.NewReader("ok 1 - test"))
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+}
+
+func TestIngestTestResults(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+	}()
+
+	info := TestResultsInfo{Project: "project", TaskID: "task", Execution: 1}
+	results := []TestResult{
+		{TestName: "pass", Status: testResultStatusPass},
+		{TestName: "fail", Status: testResultStatusFail},
+	}
+
+	t.Run("NoBucketType", func(t *testing.T) {
+		conf := NewCedarConfig(env)
+		require.NoError(t, conf.Save())
+
+		_, err := IngestTestResults(ctx, env, info, results)
+		assert.Error(t, err)
+	})
+	t.Run("CreatesRecord", func(t *testing.T) {
+		conf := NewCedarConfig(env)
+		conf.Bucket.TestResultsBucket = t.TempDir()
+		conf.Bucket.TestResultsBucketType = PailLocal
+		require.NoError(t, conf.Save())
+
+		record, err := IngestTestResults(ctx, env, info, results)
+		require.NoError(t, err)
+		assert.Equal(t, info.ID(), record.ID)
+
+		stored := &TestResults{ID: record.ID}
+		stored.Setup(env)
+		require.NoError(t, stored.Find(ctx))
+		assert.Equal(t, 2, stored.Stats.TotalCount)
+		assert.Equal(t, 1, stored.Stats.FailedCount)
+		assert.Equal(t, []string{"fail"}, stored.FailedTestsSample)
+		assert.False(t, stored.CompletedAt.IsZero())
+
+		downloaded, err := stored.Download(ctx)
+		require.NoError(t, err)
+		require.Len(t, downloaded, 2)
+		for _, result := range downloaded {
+			assert.Equal(t, info.TaskID, result.TaskID)
+			assert.Equal(t, info.Execution, result.Execution)
+		}
+	})
+	t.Run("RemovesRecordOnFailure", func(t *testing.T) {
+		info := TestResultsInfo{Project: "project", TaskID: "task", Execution: 2}
+		conf := NewCedarConfig(env)
+		conf.Bucket.TestResultsBucket = filepath.Join(t.TempDir(), "file")
+		require.NoError(t, ioutil.WriteFile(conf.Bucket.TestResultsBucket, nil, 0600))
+		conf.Bucket.TestResultsBucketType = PailLocal
+		require.NoError(t, conf.Save())
+
+		_, err := IngestTestResults(ctx, env, info, results)
+		require.Error(t, err)
+		stored := &TestResults{ID: info.ID()}
+		stored.Setup(env)
+		assert.Error(t, stored.Find(ctx))
+
+		conf.Bucket.TestResultsBucket = t.TempDir()
+		require.NoError(t, conf.Save())
+		record, err := IngestTestResults(ctx, env, info, results)
+		require.NoError(t, err)
+		assert.Equal(t, 2, record.Stats.TotalCount)
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/evergreen-ci/utility"
 	goparquet "github.com/fraugster/parquet-go"
 	"github.com/fraugster/parquet-go/floor"
+	"github.com/fraugster/parquet-go/parquetschema/autoschema"
 	"github.com/mongodb/grip/sometimes"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -316,6 +317,12 @@ func TestTestResultsAppend(t *testing.T) {
 				expectedParquet.Results[len(expectedParquet.Results)-1].LogURL = utility.ToStringPtr(result.LogURL)
 				expectedParquet.Results[len(expectedParquet.Results)-1].RawLogURL = utility.ToStringPtr(result.RawLogURL)
 				expectedParquet.Results[len(expectedParquet.Results)-1].LineNum = utility.ToInt32Ptr(int32(result.LineNum))
+				expectedParquet.Results[len(expectedParquet.Results)-1].FailureMessage = utility.ToStringPtr(result.FailureMessage)
+				expectedParquet.Results[len(expectedParquet.Results)-1].StackTrace = utility.ToStringPtr(result.StackTrace)
+				expectedParquet.Results[len(expectedParquet.Results)-1].Tags = result.Tags
+				for key, value := range result.Attributes {
+					expectedParquet.Results[len(expectedParquet.Results)-1].Attributes = append(expectedParquet.Results[len(expectedParquet.Results)-1].Attributes, ParquetTestResultAttribute{Key: key, Value: value})
+				}
 			}
 		}
 		assert.Equal(t, expectedParquet, parquetResults[0])
@@ -374,6 +381,12 @@ func TestTestResultsAppend(t *testing.T) {
 				expectedParquet.Results[len(expectedParquet.Results)-1].LogURL = utility.ToStringPtr(result.LogURL)
 				expectedParquet.Results[len(expectedParquet.Results)-1].RawLogURL = utility.ToStringPtr(result.RawLogURL)
 				expectedParquet.Results[len(expectedParquet.Results)-1].LineNum = utility.ToInt32Ptr(int32(result.LineNum))
+				expectedParquet.Results[len(expectedParquet.Results)-1].FailureMessage = utility.ToStringPtr(result.FailureMessage)
+				expectedParquet.Results[len(expectedParquet.Results)-1].StackTrace = utility.ToStringPtr(result.StackTrace)
+				expectedParquet.Results[len(expectedParquet.Results)-1].Tags = result.Tags
+				for key, value := range result.Attributes {
+					expectedParquet.Results[len(expectedParquet.Results)-1].Attributes = append(expectedParquet.Results[len(expectedParquet.Results)-1].Attributes, ParquetTestResultAttribute{Key: key, Value: value})
+				}
 			}
 		}
 		assert.Equal(t, expectedParquet, parquetResults[0])
@@ -476,6 +489,12 @@ func TestTestResultsDownload(t *testing.T) {
 				savedParquet.Results[i].LogURL = utility.ToStringPtr(result.LogURL)
 				savedParquet.Results[i].RawLogURL = utility.ToStringPtr(result.RawLogURL)
 				savedParquet.Results[i].LineNum = utility.ToInt32Ptr(int32(result.LineNum))
+				savedParquet.Results[i].FailureMessage = utility.ToStringPtr(result.FailureMessage)
+				savedParquet.Results[i].StackTrace = utility.ToStringPtr(result.StackTrace)
+				savedParquet.Results[i].Tags = result.Tags
+				for key, value := range result.Attributes {
+					savedParquet.Results[i].Attributes = append(savedParquet.Results[i].Attributes, ParquetTestResultAttribute{Key: key, Value: value})
+				}
 			}
 		}
 		w, err := testBucket.Writer(ctx, fmt.Sprintf("%s/%s", conf.Bucket.PrestoTestResultsPrefix, tr.PrestoPartitionKey()))
@@ -491,6 +510,68 @@ func TestTestResultsDownload(t *testing.T) {
 		require.NoError(t, err)
 		assert.Equal(t, expectedResults, results)
 	})
+	t.Run("DownloadFromBucketVersion1WithPreviousSchema", func(t *testing.T) {
+		// Files written before failure messages, stack traces, tags
+		// and attributes were added to the schema must still be
+		// readable.
+		type previousParquetTestResult struct {
+			TestName       string    `parquet:"name=test_name"`
+			Trial          int32     `parquet:"name=trial"`
+			Status         string    `parquet:"name=status"`
+			TaskCreateTime time.Time `parquet:"name=task_create_time, timeunit=MILLIS"`
+			TestStartTime  time.Time `parquet:"name=test_start_time, timeunit=MILLIS"`
+			TestEndTime    time.Time `parquet:"name=test_end_time, timeunit=MILLIS"`
+		}
+		type previousParquetTestResults struct {
+			Version     string                      `parquet:"name=version"`
+			Variant     string                      `parquet:"name=variant"`
+			TaskName    string                      `parquet:"name=task_name"`
+			TaskID      string                      `parquet:"name=task_id"`
+			Execution   int32                       `parquet:"name=execution"`
+			RequestType string                      `parquet:"name=request_type"`
+			CreatedAt   time.Time                   `parquet:"name=created_at, timeunit=MILLIS"`
+			Results     []previousParquetTestResult `parquet:"name=results"`
+		}
+		previousSchemaDef, err := autoschema.GenerateSchema(new(previousParquetTestResults))
+		require.NoError(t, err)
+
+		trPrevious := getTestResults()
+		trPrevious.populated = true
+		now := time.Now().UTC().Round(time.Millisecond)
+		saved := previousParquetTestResults{
+			Version:   trPrevious.Info.Version,
+			TaskID:    trPrevious.Info.TaskID,
+			Execution: int32(trPrevious.Info.Execution),
+			CreatedAt: trPrevious.CreatedAt.UTC(),
+			Results: []previousParquetTestResult{{
+				TestName:       "test",
+				Status:         "fail",
+				TaskCreateTime: now,
+				TestStartTime:  now,
+				TestEndTime:    now,
+			}},
+		}
+		w, err := testBucket.Writer(ctx, fmt.Sprintf("%s/%s", conf.Bucket.PrestoTestResultsPrefix, trPrevious.PrestoPartitionKey()))
+		require.NoError(t, err)
+		pw := floor.NewWriter(goparquet.NewFileWriter(w, goparquet.WithSchemaDefinition(previousSchemaDef)))
+		require.NoError(t, pw.Write(saved))
+		require.NoError(t, pw.Close())
+		require.NoError(t, w.Close())
+
+		trPrevious.Setup(env)
+		results, err := trPrevious.Download(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, TestResult{
+			TaskID:         trPrevious.Info.TaskID,
+			Execution:      trPrevious.Info.Execution,
+			TestName:       "test",
+			Status:         "fail",
+			TaskCreateTime: now,
+			TestStartTime:  now,
+			TestEndTime:    now,
+		}, results[0])
+	})
 	t.Run("DownloadFromBucketVersion0", func(t *testing.T) {
 		tr0 := getTestResults()
 		tr0.populated = true
@@ -537,17 +618,21 @@ func TestTestResultsClose(t *testing.T) {
 
 	t.Run("NoEnv", func(t *testing.T) {
 		tr := &TestResults{ID: tr1.ID, populated: true}
-		assert.Error(t, tr.Close(ctx))
+		_, err := tr.Close(ctx)
+		assert.Error(t, err)
 	})
 	t.Run("DNE", func(t *testing.T) {
 		tr := &TestResults{ID: "DNE"}
 		tr.Setup(env)
-		assert.Error(t, tr.Close(ctx))
+		_, err := tr.Close(ctx)
+		assert.Error(t, err)
 	})
 	t.Run("WithID", func(t *testing.T) {
 		tr := &TestResults{ID: tr1.ID, populated: true}
 		tr.Setup(env)
-		require.NoError(t, tr.Close(ctx))
+		closed, err := tr.Close(ctx)
+		require.NoError(t, err)
+		assert.True(t, closed)
 
 		updated := &TestResults{}
 		require.NoError(t, db.Collection(testResultsCollection).FindOne(ctx, bson.M{"_id": tr1.ID}).Decode(updated))
@@ -561,7 +646,8 @@ func TestTestResultsClose(t *testing.T) {
 	t.Run("WithoutID", func(t *testing.T) {
 		tr := &TestResults{Info: tr2.Info, populated: true}
 		tr.Setup(env)
-		require.NoError(t, tr.Close(ctx))
+		_, err := tr.Close(ctx)
+		require.NoError(t, err)
 
 		updated := &TestResults{}
 		require.NoError(t, db.Collection(testResultsCollection).FindOne(ctx, bson.M{"_id": tr1.ID}).Decode(updated))
@@ -572,6 +658,52 @@ func TestTestResultsClose(t *testing.T) {
 		assert.Equal(t, tr1.Info, updated.Info)
 		assert.Equal(t, tr1.Artifact, updated.Artifact)
 	})
+	t.Run("AlreadyClosed", func(t *testing.T) {
+		closedAt := &TestResults{}
+		require.NoError(t, db.Collection(testResultsCollection).FindOne(ctx, bson.M{"_id": tr1.ID}).Decode(closedAt))
+		require.False(t, closedAt.CompletedAt.IsZero())
+
+		tr := &TestResults{ID: tr1.ID, populated: true}
+		tr.Setup(env)
+		closed, err := tr.Close(ctx)
+		require.NoError(t, err)
+		assert.False(t, closed)
+
+		updated := &TestResults{}
+		require.NoError(t, db.Collection(testResultsCollection).FindOne(ctx, bson.M{"_id": tr1.ID}).Decode(updated))
+		assert.Equal(t, closedAt.CompletedAt, updated.CompletedAt)
+	})
+}
+
+func TestTestResultLogLineRange(t *testing.T) {
+	results := []TestResult{
+		{TaskID: "task", TestName: "test0", LineNum: 10},
+		{TaskID: "task", TestName: "test1", LineNum: 50},
+		{TaskID: "task", TestName: "test2", LineNum: 30},
+		{TaskID: "task", TestName: "test3", LineNum: 20, LogTestName: "test3"},
+		{TaskID: "task", TestName: "test4", LineNum: 15, Execution: 1},
+		{TaskID: "other", TestName: "test5", LineNum: 12},
+		{TaskID: "task", TestName: "test6"},
+	}
+
+	for _, test := range []struct {
+		name  string
+		index int
+		start int
+		end   int
+	}{
+		{name: "EndsAtNextTest", index: 0, start: 10, end: 30},
+		{name: "LastTest", index: 1, start: 50, end: 0},
+		{name: "OutOfOrder", index: 2, start: 30, end: 50},
+		{name: "OwnLog", index: 3, start: 0, end: 0},
+		{name: "NoLineNum", index: 6, start: 0, end: 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			start, end := results[test.index].LogLineRange(results)
+			assert.Equal(t, test.start, start)
+			assert.Equal(t, test.end, end)
+		})
+	}
 }
 
 func TestFindTestResults(t *testing.T) {
@@ -849,6 +981,23 @@ func TestFindAndDownloadTestResults(t *testing.T) {
 			assert.Equal(t, savedResults1[i], result)
 		}
 	})
+	t.Run("ForTest", func(t *testing.T) {
+		opts := FindTestResultsOptions{
+			TaskID:      "display",
+			DisplayTask: true,
+		}
+		results, err := FindAndDownloadTestResultsForTest(ctx, env, opts, savedResults2[3].TestName)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, savedResults2, results)
+
+		results, err = FindAndDownloadTestResultsForTest(ctx, env, opts, savedResults1[5].GetDisplayName())
+		require.NoError(t, err)
+		assert.ElementsMatch(t, savedResults1, results)
+
+		results, err = FindAndDownloadTestResultsForTest(ctx, env, opts, "nonexistent")
+		require.NoError(t, err)
+		assert.Nil(t, results)
+	})
 }
 
 func TestGetTestResultsStats(t *testing.T) {
@@ -1030,6 +1179,23 @@ func TestFindTestResultsByProject(t *testing.T) {
 	_, err = db.Collection(testResultsCollection).InsertOne(ctx, tr4)
 	require.NoError(t, err)
 
+	tr5 := getTestResults()
+	tr5.CreatedAt = time.Date(2022, 2, 6, 0, 0, 0, 0, time.UTC)
+	tr5.Info.Project = "p1"
+	tr5.Info.Variant = "other_variant"
+	tr5.Info.TaskName = "other_task"
+	tr5.Info.RequestType = "other_request"
+	tr5.Stats = TestResultsStats{TotalCount: 2, FailedCount: 1}
+	_, err = db.Collection(testResultsCollection).InsertOne(ctx, tr5)
+	require.NoError(t, err)
+
+	tr6 := getTestResults()
+	tr6.CreatedAt = time.Date(2022, 2, 7, 0, 0, 0, 0, time.UTC)
+	tr6.Info.Project = "p2"
+	tr6.Info.Evergreen = &EvergreenMetadata{Author: "author"}
+	_, err = db.Collection(testResultsCollection).InsertOne(ctx, tr6)
+	require.NoError(t, err)
+
 	for _, test := range []struct {
 		name     string
 		opts     FindTestResultsByProjectOptions
@@ -1073,7 +1239,62 @@ func TestFindTestResultsByProject(t *testing.T) {
 				Projects: []string{"p1", "p2"},
 				EndAt:    time.Now(),
 			},
-			expected: map[string]bool{tr1.ID: true, tr2.ID: true, tr3.ID: true, tr4.ID: true},
+			expected: map[string]bool{tr1.ID: true, tr2.ID: true, tr3.ID: true, tr4.ID: true, tr5.ID: true, tr6.ID: true},
+		},
+		{
+			name: "NegativeLimit",
+			opts: FindTestResultsByProjectOptions{
+				Projects: []string{"p1"},
+				EndAt:    time.Now(),
+				Limit:    -1,
+			},
+			hasErr: true,
+		},
+		{
+			name: "FiltersByVariant",
+			opts: FindTestResultsByProjectOptions{
+				Projects: []string{"p1"},
+				EndAt:    time.Now(),
+				Variant:  "other_variant",
+			},
+			expected: map[string]bool{tr5.ID: true},
+		},
+		{
+			name: "FiltersByTaskNameAndRequestType",
+			opts: FindTestResultsByProjectOptions{
+				Projects:    []string{"p1", "p2"},
+				EndAt:       time.Now(),
+				TaskName:    "other_task",
+				RequestType: "other_request",
+			},
+			expected: map[string]bool{tr5.ID: true},
+		},
+		{
+			name: "FiltersFailedOnly",
+			opts: FindTestResultsByProjectOptions{
+				Projects:   []string{"p1"},
+				EndAt:      time.Now(),
+				FailedOnly: true,
+			},
+			expected: map[string]bool{tr5.ID: true},
+		},
+		{
+			name: "FiltersByAuthor",
+			opts: FindTestResultsByProjectOptions{
+				Projects: []string{"p1", "p2"},
+				EndAt:    time.Now(),
+				Author:   "author",
+			},
+			expected: map[string]bool{tr6.ID: true},
+		},
+		{
+			name: "LimitsToMostRecent",
+			opts: FindTestResultsByProjectOptions{
+				Projects: []string{"p1", "p2"},
+				EndAt:    time.Now(),
+				Limit:    2,
+			},
+			expected: map[string]bool{tr3.ID: true, tr5.ID: true},
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -1083,6 +1304,10 @@ func TestFindTestResultsByProject(t *testing.T) {
 				assert.Error(t, err)
 			} else {
 				require.NoError(t, err)
+				assert.Len(t, results, len(test.expected))
+				for i := 1; i < len(results); i++ {
+					assert.False(t, results[i].CreatedAt.After(results[i-1].CreatedAt))
+				}
 				for _, tr := range results {
 					assert.True(t, test.expected[tr.ID])
 				}
@@ -1091,6 +1316,104 @@ func TestFindTestResultsByProject(t *testing.T) {
 	}
 }
 
+func TestFindAndDownloadTestResultsByProject(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tmpDir, err := ioutil.TempDir(".", "find-and-download-by-project-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+		assert.NoError(t, db.Collection(configurationCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(testResultsCollection).Drop(ctx))
+	}()
+	conf := &CedarConfig{
+		Bucket: BucketConfig{
+			TestResultsBucket:       tmpDir,
+			PrestoBucket:            tmpDir,
+			PrestoTestResultsPrefix: "presto-test-results",
+		},
+		populated: true,
+	}
+	conf.Setup(env)
+	require.NoError(t, conf.Save())
+
+	records := make([]*TestResults, 3)
+	for i := range records {
+		records[i] = getTestResults()
+		records[i].Info.Project = "project"
+		records[i].CreatedAt = time.Now().Add(-time.Duration(i) * time.Hour)
+		records[i].populated = true
+		_, err = db.Collection(testResultsCollection).InsertOne(ctx, records[i])
+		require.NoError(t, err)
+
+		results := make([]TestResult, 5)
+		for j := range results {
+			results[j] = getTestResult()
+			results[j].TaskID = records[i].Info.TaskID
+			results[j].Execution = records[i].Info.Execution
+		}
+		results[0].Status = "fail"
+		records[i].Setup(env)
+		require.NoError(t, records[i].Append(ctx, results))
+	}
+	find := FindTestResultsByProjectOptions{
+		Projects: []string{"project"},
+		StartAt:  time.Now().Add(-24 * time.Hour),
+		EndAt:    time.Now().Add(time.Hour),
+	}
+
+	t.Run("AllRecords", func(t *testing.T) {
+		out, err := FindAndDownloadTestResultsByProject(ctx, env, FindAndDownloadTestResultsByProjectOptions{Find: find})
+		require.NoError(t, err)
+		assert.False(t, out.Truncated)
+		assert.Len(t, out.Results, 15)
+		assert.Equal(t, 15, out.FilteredCount)
+		assert.Equal(t, 15, out.Stats.TotalCount)
+		assert.Equal(t, records[0].Info.TaskID, out.Results[0].TaskID)
+		assert.Equal(t, records[2].Info.TaskID, out.Results[14].TaskID)
+	})
+	t.Run("FiltersEachRecord", func(t *testing.T) {
+		out, err := FindAndDownloadTestResultsByProject(ctx, env, FindAndDownloadTestResultsByProjectOptions{
+			Find:          find,
+			FilterAndSort: &FilterAndSortTestResultsOptions{Statuses: []string{"fail"}, Limit: 2},
+		})
+		require.NoError(t, err)
+		assert.False(t, out.Truncated)
+		assert.Len(t, out.Results, 2)
+		assert.Equal(t, 3, out.FilteredCount)
+	})
+	t.Run("RecordLimit", func(t *testing.T) {
+		limitedFind := find
+		limitedFind.Limit = 2
+		out, err := FindAndDownloadTestResultsByProject(ctx, env, FindAndDownloadTestResultsByProjectOptions{Find: limitedFind})
+		require.NoError(t, err)
+		assert.True(t, out.Truncated)
+		assert.Len(t, out.Results, 10)
+		assert.Equal(t, 10, out.Stats.TotalCount)
+	})
+	t.Run("MaxResults", func(t *testing.T) {
+		out, err := FindAndDownloadTestResultsByProject(ctx, env, FindAndDownloadTestResultsByProjectOptions{
+			Find:       find,
+			MaxResults: 7,
+		})
+		require.NoError(t, err)
+		assert.True(t, out.Truncated)
+		require.Len(t, out.Results, 5)
+		assert.Equal(t, records[0].Info.TaskID, out.Results[0].TaskID)
+	})
+	t.Run("ExceedsDownloadTimeout", func(t *testing.T) {
+		out, err := FindAndDownloadTestResultsByProject(ctx, env, FindAndDownloadTestResultsByProjectOptions{
+			Find:            find,
+			DownloadTimeout: time.Nanosecond,
+		})
+		require.NoError(t, err)
+		assert.True(t, out.Truncated)
+		assert.Empty(t, out.Results)
+	})
+}
+
 func TestFilterAndSortTestResults(t *testing.T) {
 	env := cedar.GetEnvironment()
 	db := env.GetDB()
@@ -1128,12 +1451,18 @@ func TestFilterAndSortTestResults(t *testing.T) {
 				Status:          "Fail",
 				TestStartTime:   time.Date(1996, time.August, 31, 12, 5, 10, 3, time.UTC),
 				TestEndTime:     time.Date(1996, time.August, 31, 12, 5, 16, 0, time.UTC),
+				FailureMessage:  "assertion failed",
+				Tags:            []string{"flaky"},
+				Attributes:      map[string]string{"os": "windows"},
 			},
 			{
-				TestName:      "C test",
-				Status:        "Fail",
-				TestStartTime: time.Date(1996, time.August, 31, 12, 5, 10, 2, time.UTC),
-				TestEndTime:   time.Date(1996, time.August, 31, 12, 5, 15, 0, time.UTC),
+				TestName:       "C test",
+				Status:         "Fail",
+				TestStartTime:  time.Date(1996, time.August, 31, 12, 5, 10, 2, time.UTC),
+				TestEndTime:    time.Date(1996, time.August, 31, 12, 5, 15, 0, time.UTC),
+				FailureMessage: "timed out",
+				Tags:           []string{"flaky", "slow"},
+				Attributes:     map[string]string{"os": "linux", "arch": "arm64"},
 			},
 			{
 				TestName:      "D test",
@@ -1141,6 +1470,7 @@ func TestFilterAndSortTestResults(t *testing.T) {
 				TestStartTime: time.Date(1996, time.August, 31, 12, 5, 10, 4, time.UTC),
 				TestEndTime:   time.Date(1996, time.August, 31, 12, 5, 11, 0, time.UTC),
 				GroupID:       "llama",
+				Team:          "storage",
 			},
 		}
 	}
@@ -1218,6 +1548,11 @@ func TestFilterAndSortTestResults(t *testing.T) {
 			opts:   &FilterAndSortTestResultsOptions{TestName: "*"},
 			hasErr: true,
 		},
+		{
+			name:   "InvalidFailureMessageRegex",
+			opts:   &FilterAndSortTestResultsOptions{FailureMessage: "*"},
+			hasErr: true,
+		},
 		{
 			name:            "EmptyOptions",
 			expectedResults: results,
@@ -1256,6 +1591,41 @@ func TestFilterAndSortTestResults(t *testing.T) {
 			expectedResults: results[3:4],
 			expectedCount:   1,
 		},
+		{
+			name:            "FailureMessageFilter",
+			opts:            &FilterAndSortTestResultsOptions{FailureMessage: "^timed"},
+			expectedResults: results[2:3],
+			expectedCount:   1,
+		},
+		{
+			name:            "TagFilter",
+			opts:            &FilterAndSortTestResultsOptions{Tags: []string{"flaky"}},
+			expectedResults: results[1:3],
+			expectedCount:   2,
+		},
+		{
+			name:            "MultipleTagsFilter",
+			opts:            &FilterAndSortTestResultsOptions{Tags: []string{"slow", "flaky"}},
+			expectedResults: results[2:3],
+			expectedCount:   1,
+		},
+		{
+			name:            "AttributesFilter",
+			opts:            &FilterAndSortTestResultsOptions{Attributes: map[string]string{"os": "linux", "arch": "arm64"}},
+			expectedResults: results[2:3],
+			expectedCount:   1,
+		},
+		{
+			name:          "AttributesFilterNoMatch",
+			opts:          &FilterAndSortTestResultsOptions{Attributes: map[string]string{"os": "linux", "arch": "amd64"}},
+			expectedCount: 0,
+		},
+		{
+			name:            "TeamFilter",
+			opts:            &FilterAndSortTestResultsOptions{Team: "storage"},
+			expectedResults: results[3:4],
+			expectedCount:   1,
+		},
 		{
 			name: "SortByDurationASC",
 			opts: &FilterAndSortTestResultsOptions{SortBy: TestResultsSortByDuration},
@@ -1852,6 +2222,10 @@ func getTestResult() TestResult {
 		result.LogURL = utility.RandomString()
 		result.RawLogURL = utility.RandomString()
 		result.LineNum = rand.Intn(1000)
+		result.FailureMessage = utility.RandomString()
+		result.StackTrace = utility.RandomString()
+		result.Tags = []string{utility.RandomString(), utility.RandomString()}
+		result.Attributes = map[string]string{utility.RandomString(): utility.RandomString()}
 	}
 
 	return result
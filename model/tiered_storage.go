@@ -0,0 +1,411 @@
+package model
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/pail"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// tieredBucket is a pail Bucket that writes to a fast hot tier and reads
+// from the hot tier first, falling through to the S3 cold tier for objects
+// that have already been migrated.
+type tieredBucket struct {
+	pail.Bucket
+	cold pail.Bucket
+}
+
+// newTieredBucket returns the tiered bucket for the given bucket and prefix.
+// Each tier is instrumented individually so that metrics and traces reflect
+// the backend that actually served the request.
+func newTieredBucket(ctx context.Context, env cedar.Environment, bucket, prefix, permissions string, compress bool) (*tieredBucket, error) {
+	hot, err := PailGridFS.Create(ctx, env, bucket, prefix, permissions, compress)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating hot tier bucket")
+	}
+	cold, err := PailS3.Create(ctx, env, bucket, prefix, permissions, compress)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cold tier bucket")
+	}
+
+	return &tieredBucket{Bucket: hot, cold: cold}, nil
+}
+
+// coldReadError returns the error to report when a read fails on both tiers.
+// A missing key in the cold tier should not mask a real failure in the hot
+// tier.
+func coldReadError(hotErr, coldErr error) error {
+	if pail.IsKeyNotFoundError(coldErr) && !pail.IsKeyNotFoundError(hotErr) {
+		return hotErr
+	}
+	return coldErr
+}
+
+func (b *tieredBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := b.Bucket.Get(ctx, key)
+	if err == nil {
+		return r, nil
+	}
+
+	r, coldErr := b.cold.Get(ctx, key)
+	if coldErr != nil {
+		return nil, coldReadError(err, coldErr)
+	}
+	return r, nil
+}
+
+func (b *tieredBucket) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := b.Bucket.Reader(ctx, key)
+	if err == nil {
+		return r, nil
+	}
+
+	r, coldErr := b.cold.Reader(ctx, key)
+	if coldErr != nil {
+		return nil, coldReadError(err, coldErr)
+	}
+	return r, nil
+}
+
+func (b *tieredBucket) Download(ctx context.Context, key, path string) error {
+	err := b.Bucket.Download(ctx, key, path)
+	if err == nil {
+		return nil
+	}
+
+	if coldErr := b.cold.Download(ctx, key, path); coldErr != nil {
+		return coldReadError(err, coldErr)
+	}
+	return nil
+}
+
+func (b *tieredBucket) List(ctx context.Context, prefix string) (pail.BucketIterator, error) {
+	hot, err := b.Bucket.List(ctx, prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing hot tier")
+	}
+	cold, err := b.cold.List(ctx, prefix)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing cold tier")
+	}
+
+	return &tieredIterator{iters: []pail.BucketIterator{hot, cold}, seen: map[string]bool{}}, nil
+}
+
+func (b *tieredBucket) Remove(ctx context.Context, key string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(b.Bucket.Remove(ctx, key), "removing from hot tier")
+	catcher.Wrap(b.cold.Remove(ctx, key), "removing from cold tier")
+	return catcher.Resolve()
+}
+
+func (b *tieredBucket) RemoveMany(ctx context.Context, keys ...string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(b.Bucket.RemoveMany(ctx, keys...), "removing from hot tier")
+	catcher.Wrap(b.cold.RemoveMany(ctx, keys...), "removing from cold tier")
+	return catcher.Resolve()
+}
+
+func (b *tieredBucket) RemovePrefix(ctx context.Context, prefix string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(b.Bucket.RemovePrefix(ctx, prefix), "removing from hot tier")
+	catcher.Wrap(b.cold.RemovePrefix(ctx, prefix), "removing from cold tier")
+	return catcher.Resolve()
+}
+
+func (b *tieredBucket) RemoveMatching(ctx context.Context, expression string) error {
+	catcher := grip.NewBasicCatcher()
+	catcher.Wrap(b.Bucket.RemoveMatching(ctx, expression), "removing from hot tier")
+	catcher.Wrap(b.cold.RemoveMatching(ctx, expression), "removing from cold tier")
+	return catcher.Resolve()
+}
+
+// copyToColdTier copies every object in the hot tier to the cold tier and
+// returns the number of objects copied. The hot tier is left intact so that
+// reads keep working until the artifact metadata has been updated.
+func (b *tieredBucket) copyToColdTier(ctx context.Context) (int, error) {
+	return copyBucketContents(ctx, b.Bucket, b.cold)
+}
+
+// copyBucketContents copies every object in the source bucket to the
+// destination bucket, preserving keys, and returns the number of objects
+// copied.
+func copyBucketContents(ctx context.Context, from, to pail.Bucket) (int, error) {
+	it, err := from.List(ctx, "")
+	if err != nil {
+		return 0, errors.Wrap(err, "listing source bucket")
+	}
+
+	count := 0
+	for it.Next(ctx) {
+		key := it.Item().Name()
+		r, err := from.Get(ctx, key)
+		if err != nil {
+			return count, errors.Wrapf(err, "getting object '%s'", key)
+		}
+		err = to.Put(ctx, key, r)
+		grip.Warning(message.WrapError(r.Close(), message.Fields{
+			"message": "could not close reader",
+			"key":     key,
+		}))
+		if err != nil {
+			return count, errors.Wrapf(err, "putting object '%s'", key)
+		}
+		count++
+	}
+	if err = it.Err(); err != nil {
+		return count, errors.Wrap(err, "iterating source bucket")
+	}
+
+	return count, nil
+}
+
+// tieredIterator iterates over the contents of each tier in order, skipping
+// keys that were already returned by an earlier tier.
+type tieredIterator struct {
+	iters []pail.BucketIterator
+	idx   int
+	seen  map[string]bool
+	item  pail.BucketItem
+	err   error
+}
+
+func (it *tieredIterator) Next(ctx context.Context) bool {
+	for it.idx < len(it.iters) {
+		iter := it.iters[it.idx]
+		if iter.Next(ctx) {
+			item := iter.Item()
+			if it.seen[item.Name()] {
+				continue
+			}
+			it.seen[item.Name()] = true
+			it.item = item
+			return true
+		}
+		if err := iter.Err(); err != nil {
+			it.err = err
+			return false
+		}
+		it.idx++
+	}
+
+	return false
+}
+
+func (it *tieredIterator) Err() error            { return it.err }
+func (it *tieredIterator) Item() pail.BucketItem { return it.item }
+
+// migrateTieredArtifact moves the objects under the prefix from the hot tier
+// to S3, switches the artifact type of the record in the collection from
+// tiered to S3 and finally removes the objects from the hot tier. The type is
+// only switched while it is still tiered, so concurrent migrations of the
+// same record are safe. Objects are copied byte for byte, without
+// decompressing or decrypting them, so that they are stored in S3 exactly as
+// they were written to the hot tier.
+func migrateTieredArtifact(ctx context.Context, env cedar.Environment, collection, id, artifactTypeKey, bucket, prefix string) (int, error) {
+	b, err := newTieredBucket(ctx, env, bucket, prefix, string(pail.S3PermissionsPrivate), false)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	count, err := b.copyToColdTier(ctx)
+	if err != nil {
+		return count, errors.Wrap(err, "copying to cold tier")
+	}
+
+	updateResult, err := env.GetDB().Collection(collection).UpdateOne(
+		ctx,
+		bson.M{
+			"_id":           id,
+			artifactTypeKey: PailTiered,
+		},
+		bson.M{"$set": bson.M{artifactTypeKey: PailS3}},
+	)
+	grip.DebugWhen(err == nil, message.Fields{
+		"collection":    collection,
+		"id":            id,
+		"objects":       count,
+		"update_result": updateResult,
+		"op":            "migrate tiered artifact to cold tier",
+	})
+	if err != nil {
+		return count, errors.Wrap(err, "updating artifact type")
+	}
+	if updateResult.MatchedCount == 0 {
+		return count, errors.Errorf("could not find tiered record '%s'", id)
+	}
+
+	return count, errors.Wrap(b.Bucket.RemovePrefix(ctx, ""), "removing migrated objects from hot tier")
+}
+
+// findTieredArtifactsToMigrate decodes into out up to limit records of the
+// collection that are stored in the tiered backend and were completed before
+// the given time.
+func findTieredArtifactsToMigrate(ctx context.Context, env cedar.Environment, collection, artifactTypeKey, completedAtKey string, completedBefore time.Time, limit int, out interface{}) error {
+	opts := options.Find().SetSort(bson.D{{Key: completedAtKey, Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	cur, err := env.GetDB().Collection(collection).Find(ctx, bson.M{
+		artifactTypeKey: PailTiered,
+		completedAtKey: bson.M{
+			"$gt":  time.Time{},
+			"$lte": completedBefore,
+		},
+	}, opts)
+	if err != nil {
+		return errors.Wrap(err, "finding tiered records")
+	}
+
+	return errors.Wrap(cur.All(ctx, out), "decoding tiered records")
+}
+
+// HasTieredArtifacts returns whether any log or test results record is still
+// stored in the tiered backend, regardless of whether it has aged enough to be
+// migrated.
+func HasTieredArtifacts(ctx context.Context, env cedar.Environment) (bool, error) {
+	for collection, artifactTypeKey := range map[string]string{
+		buildloggerCollection: bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoTypeKey),
+		testResultsCollection: bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoTypeKey),
+	} {
+		count, err := env.GetDB().Collection(collection).CountDocuments(ctx, bson.M{artifactTypeKey: PailTiered}, options.Count().SetLimit(1))
+		if err != nil {
+			return false, errors.Wrapf(err, "counting tiered records in '%s'", collection)
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// FindLogsToMigrate returns up to limit completed logs stored in the tiered
+// backend that were completed before the given time.
+func FindLogsToMigrate(ctx context.Context, env cedar.Environment, completedBefore time.Time, limit int) ([]Log, error) {
+	logs := []Log{}
+	err := findTieredArtifactsToMigrate(
+		ctx,
+		env,
+		buildloggerCollection,
+		bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoTypeKey),
+		logCompletedAtKey,
+		completedBefore,
+		limit,
+		&logs,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for i := range logs {
+		logs[i].env = env
+		logs[i].populated = true
+	}
+
+	return logs, nil
+}
+
+// MigrateToColdTier moves the log's chunks from the hot tier of the tiered
+// storage backend to S3 and returns the number of chunks moved. The
+// environment should not be nil.
+func (l *Log) MigrateToColdTier(ctx context.Context) (int, error) {
+	if l.env == nil {
+		return 0, errors.New("cannot migrate log with a nil environment")
+	}
+	if l.Artifact.Type != PailTiered {
+		return 0, errors.Errorf("log '%s' is not stored in the tiered backend", l.ID)
+	}
+
+	conf := &CedarConfig{}
+	conf.Setup(l.env)
+	if err := conf.Find(); err != nil {
+		return 0, errors.Wrap(err, "getting application configuration")
+	}
+
+	count, err := migrateTieredArtifact(
+		ctx,
+		l.env,
+		buildloggerCollection,
+		l.ID,
+		bsonutil.GetDottedKeyName(logArtifactKey, logArtifactInfoTypeKey),
+		conf.Bucket.BuildLogsBucket,
+		l.Artifact.Prefix,
+	)
+	if err != nil {
+		return count, errors.Wrapf(err, "migrating log '%s'", l.ID)
+	}
+	l.Artifact.Type = PailS3
+
+	return count, nil
+}
+
+// FindTestResultsToMigrate returns up to limit completed test results records
+// stored in the tiered backend that were completed before the given time.
+func FindTestResultsToMigrate(ctx context.Context, env cedar.Environment, completedBefore time.Time, limit int) ([]TestResults, error) {
+	results := []TestResults{}
+	err := findTieredArtifactsToMigrate(
+		ctx,
+		env,
+		testResultsCollection,
+		bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoTypeKey),
+		testResultsCompletedAtKey,
+		completedBefore,
+		limit,
+		&results,
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for i := range results {
+		results[i].env = env
+		results[i].populated = true
+	}
+
+	return results, nil
+}
+
+// MigrateToColdTier moves the test results from the hot tier of the tiered
+// storage backend to S3 and returns the number of objects moved. The
+// environment should not be nil.
+func (t *TestResults) MigrateToColdTier(ctx context.Context) (int, error) {
+	if t.env == nil {
+		return 0, errors.New("cannot migrate test results with a nil environment")
+	}
+	if t.Artifact.Type != PailTiered {
+		return 0, errors.Errorf("test results record '%s' is not stored in the tiered backend", t.ID)
+	}
+
+	if t.bucket == "" {
+		conf := &CedarConfig{}
+		conf.Setup(t.env)
+		if err := conf.Find(); err != nil {
+			return 0, errors.Wrap(err, "getting application configuration")
+		}
+		t.bucket = conf.Bucket.TestResultsBucket
+	}
+
+	count, err := migrateTieredArtifact(
+		ctx,
+		t.env,
+		testResultsCollection,
+		t.ID,
+		bsonutil.GetDottedKeyName(testResultsArtifactKey, testResultsArtifactInfoTypeKey),
+		t.bucket,
+		t.Artifact.Prefix,
+	)
+	if err != nil {
+		return count, errors.Wrapf(err, "migrating test results record '%s'", t.ID)
+	}
+	t.Artifact.Type = PailS3
+
+	return count, nil
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/pail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredBucket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setup := func(t *testing.T) *tieredBucket {
+		hot, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+		require.NoError(t, err)
+		cold, err := pail.NewLocalBucket(pail.LocalOptions{Path: t.TempDir()})
+		require.NoError(t, err)
+
+		require.NoError(t, hot.Put(ctx, "hot", bytes.NewBufferString("hot data")))
+		require.NoError(t, hot.Put(ctx, "both", bytes.NewBufferString("hot copy")))
+		require.NoError(t, cold.Put(ctx, "both", bytes.NewBufferString("cold copy")))
+		require.NoError(t, cold.Put(ctx, "cold", bytes.NewBufferString("cold data")))
+
+		return &tieredBucket{Bucket: hot, cold: cold}
+	}
+	readKey := func(t *testing.T, b pail.Bucket, key string) string {
+		r, err := b.Get(ctx, key)
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, r.Close())
+		}()
+		data, err := ioutil.ReadAll(r)
+		require.NoError(t, err)
+		return string(data)
+	}
+	listKeys := func(t *testing.T, b pail.Bucket) []string {
+		it, err := b.List(ctx, "")
+		require.NoError(t, err)
+		keys := []string{}
+		for it.Next(ctx) {
+			keys = append(keys, it.Item().Name())
+		}
+		require.NoError(t, it.Err())
+		sort.Strings(keys)
+		return keys
+	}
+
+	t.Run("GetFallsThroughToColdTier", func(t *testing.T) {
+		b := setup(t)
+		assert.Equal(t, "hot data", readKey(t, b, "hot"))
+		assert.Equal(t, "hot copy", readKey(t, b, "both"))
+		assert.Equal(t, "cold data", readKey(t, b, "cold"))
+
+		_, err := b.Get(ctx, "missing")
+		assert.Error(t, err)
+	})
+	t.Run("PutWritesToHotTier", func(t *testing.T) {
+		b := setup(t)
+		require.NoError(t, b.Put(ctx, "new", bytes.NewBufferString("new data")))
+		assert.Equal(t, "new data", readKey(t, b.Bucket, "new"))
+		_, err := b.cold.Get(ctx, "new")
+		assert.Error(t, err)
+	})
+	t.Run("ListMergesTiers", func(t *testing.T) {
+		b := setup(t)
+		assert.Equal(t, []string{"both", "cold", "hot"}, listKeys(t, b))
+	})
+	t.Run("RemoveFromBothTiers", func(t *testing.T) {
+		b := setup(t)
+		require.NoError(t, b.Remove(ctx, "both"))
+		assert.Equal(t, []string{"hot"}, listKeys(t, b.Bucket))
+		assert.Equal(t, []string{"cold"}, listKeys(t, b.cold))
+	})
+	t.Run("CopyToColdTier", func(t *testing.T) {
+		b := setup(t)
+		count, err := b.copyToColdTier(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, []string{"both", "cold", "hot"}, listKeys(t, b.cold))
+		assert.Equal(t, "hot copy", readKey(t, b.cold, "both"))
+		assert.Equal(t, []string{"both", "hot"}, listKeys(t, b.Bucket))
+	})
+}
+
+func TestTieredStorageConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		conf := &TieredStorageConfig{}
+		assert.Equal(t, defaultTieredStorageMigrateAfterDays*24*time.Hour, conf.MigrationAge())
+		assert.Equal(t, defaultTieredStorageMigrationBatchSize, conf.BatchSize())
+	})
+	t.Run("Configured", func(t *testing.T) {
+		conf := &TieredStorageConfig{MigrateAfterDays: 2, MigrationBatchSize: 10}
+		assert.Equal(t, 48*time.Hour, conf.MigrationAge())
+		assert.Equal(t, 10, conf.BatchSize())
+	})
+	t.Run("IsEnabled", func(t *testing.T) {
+		assert.False(t, (&TieredStorageConfig{}).IsEnabled())
+		assert.True(t, (&TieredStorageConfig{BuildLogs: true}).IsEnabled())
+		assert.True(t, (&TieredStorageConfig{TestResults: true}).IsEnabled())
+	})
+	t.Run("LogStorageType", func(t *testing.T) {
+		conf := &BucketConfig{}
+		assert.Equal(t, PailS3, conf.LogStorageType(PailS3))
+		conf.Tiered.BuildLogs = true
+		assert.Equal(t, PailTiered, conf.LogStorageType(PailS3))
+		assert.Equal(t, PailGridFS, conf.LogStorageType(PailGridFS))
+	})
+	t.Run("TestResultsStorageType", func(t *testing.T) {
+		conf := &BucketConfig{TestResultsBucketType: PailS3}
+		assert.Equal(t, PailS3, conf.TestResultsStorageType())
+		conf.Tiered.TestResults = true
+		assert.Equal(t, PailTiered, conf.TestResultsStorageType())
+		conf.TestResultsBucketType = PailLocal
+		assert.Equal(t, PailLocal, conf.TestResultsStorageType())
+	})
+}
@@ -0,0 +1,273 @@
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// APIKey is a named API key belonging to a user. Unlike the user's legacy API
+// key, only a hash of the key is stored; the key itself is returned only when
+// it is created or rotated.
+type APIKey struct {
+	Name      string    `bson:"name" json:"name"`
+	Hash      string    `bson:"hash" json:"-"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	RotatedAt time.Time `bson:"rotated_at" json:"rotated_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	RevokedAt time.Time `bson:"revoked_at" json:"revoked_at"`
+
+	// PreviousHash is the hash of the key replaced by the last rotation.
+	// It remains valid until PreviousExpiresAt so that clients, such as
+	// CI agents using service accounts, can switch over to the new key.
+	PreviousHash      string    `bson:"previous_hash,omitempty" json:"-"`
+	PreviousExpiresAt time.Time `bson:"previous_expires_at" json:"previous_expires_at"`
+}
+
+var (
+	apiKeyNameKey              = bsonutil.MustHaveTag(APIKey{}, "Name")
+	apiKeyHashKey              = bsonutil.MustHaveTag(APIKey{}, "Hash")
+	apiKeyRotatedAtKey         = bsonutil.MustHaveTag(APIKey{}, "RotatedAt")
+	apiKeyExpiresAtKey         = bsonutil.MustHaveTag(APIKey{}, "ExpiresAt")
+	apiKeyRevokedAtKey         = bsonutil.MustHaveTag(APIKey{}, "RevokedAt")
+	apiKeyPreviousHashKey      = bsonutil.MustHaveTag(APIKey{}, "PreviousHash")
+	apiKeyPreviousExpiresAtKey = bsonutil.MustHaveTag(APIKey{}, "PreviousExpiresAt")
+)
+
+// IsActive returns whether the key is neither revoked nor expired at the
+// given time. A zero expiration time means the key never expires.
+func (k *APIKey) IsActive(now time.Time) bool {
+	if !k.RevokedAt.IsZero() {
+		return false
+	}
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// IsValid returns whether the given key matches this named key and may be
+// used to authenticate at the given time.
+func (k *APIKey) IsValid(key string, now time.Time) bool {
+	if !k.IsActive(now) {
+		return false
+	}
+
+	hash := hashAPIKey(key)
+	if compareHashes(hash, k.Hash) {
+		return true
+	}
+
+	return k.PreviousHash != "" && compareHashes(hash, k.PreviousHash) && now.Before(k.PreviousExpiresAt)
+}
+
+func (k *APIKey) matches(key string) bool {
+	hash := hashAPIKey(key)
+	return compareHashes(hash, k.Hash) || (k.PreviousHash != "" && compareHashes(hash, k.PreviousHash))
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func compareHashes(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// GetNamedAPIKey returns the user's API key with the given name, or nil if it
+// does not exist.
+func (u *User) GetNamedAPIKey(name string) *APIKey {
+	for i := range u.APIKeys {
+		if u.APIKeys[i].Name == name {
+			return &u.APIKeys[i]
+		}
+	}
+
+	return nil
+}
+
+// MatchNamedAPIKey returns the user's named API key that matches the given
+// key, or nil if none match. The returned key is not necessarily valid, use
+// IsValid to check whether it may be used to authenticate.
+func (u *User) MatchNamedAPIKey(key string) *APIKey {
+	for i := range u.APIKeys {
+		if u.APIKeys[i].matches(key) {
+			return &u.APIKeys[i]
+		}
+	}
+
+	return nil
+}
+
+// FindUserByNamedAPIKey finds the user with the given ID and returns it along
+// with their named API key that matches the given key. The returned key is nil
+// if none of the user's named keys match and is not necessarily valid, use
+// IsValid to check whether it may be used to authenticate.
+func FindUserByNamedAPIKey(env cedar.Environment, userID, key string) (*User, *APIKey, error) {
+	u := &User{ID: userID}
+	u.Setup(env)
+	if err := u.Find(); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+
+	return u, u.MatchNamedAPIKey(key), nil
+}
+
+// CreateNamedAPIKey generates a new API key with the given name and saves its
+// hash to the DB, returning the generated key. A zero expiration time creates
+// a key that never expires. Key names are unique per user, including revoked
+// keys.
+func (u *User) CreateNamedAPIKey(name string, expiresAt time.Time) (string, error) {
+	if name == "" {
+		return "", errors.New("must specify an API key name")
+	}
+	if u.GetNamedAPIKey(name) != nil {
+		return "", errors.Errorf("API key '%s' already exists for user '%s'", name, u.ID)
+	}
+
+	conf, session, err := cedar.GetSessionWithConfig(u.env)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	defer session.Close()
+
+	key := utility.RandomString()
+	apiKey := APIKey{
+		Name:      name,
+		Hash:      hashAPIKey(key),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+
+	query := bson.M{
+		dbUserIDKey: u.ID,
+		bsonutil.GetDottedKeyName(dbUserAPIKeysKey, apiKeyNameKey): bson.M{"$ne": name},
+	}
+	err = session.DB(conf.DatabaseName).C(userCollection).Update(query, bson.M{
+		"$push": bson.M{dbUserAPIKeysKey: apiKey},
+	})
+	if db.ResultsNotFound(err) {
+		return "", errors.Errorf("API key '%s' already exists for user '%s'", name, u.ID)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "adding API key '%s' to user '%s'", name, u.ID)
+	}
+
+	u.APIKeys = append(u.APIKeys, apiKey)
+
+	return key, nil
+}
+
+// RotateNamedAPIKey replaces the named API key with a newly generated key and
+// returns it. The replaced key remains valid for the given grace period. A
+// zero expiration time means the new key never expires.
+func (u *User) RotateNamedAPIKey(name string, expiresAt time.Time, gracePeriod time.Duration) (string, error) {
+	apiKey := u.GetNamedAPIKey(name)
+	if apiKey == nil {
+		return "", errors.Errorf("API key '%s' does not exist for user '%s'", name, u.ID)
+	}
+	if !apiKey.RevokedAt.IsZero() {
+		return "", errors.Errorf("cannot rotate revoked API key '%s'", name)
+	}
+
+	now := time.Now()
+	key := utility.RandomString()
+	updated := *apiKey
+	updated.PreviousHash = apiKey.Hash
+	updated.PreviousExpiresAt = now.Add(gracePeriod)
+	if !apiKey.IsActive(now) {
+		// An expired key should not gain a grace period.
+		updated.PreviousHash = ""
+		updated.PreviousExpiresAt = time.Time{}
+	}
+	updated.Hash = hashAPIKey(key)
+	updated.RotatedAt = now
+	updated.ExpiresAt = expiresAt
+
+	if err := u.updateNamedAPIKey(name, bson.M{
+		apiKeyHashKey:              updated.Hash,
+		apiKeyRotatedAtKey:         updated.RotatedAt,
+		apiKeyExpiresAtKey:         updated.ExpiresAt,
+		apiKeyPreviousHashKey:      updated.PreviousHash,
+		apiKeyPreviousExpiresAtKey: updated.PreviousExpiresAt,
+	}); err != nil {
+		return "", errors.Wrapf(err, "rotating API key '%s'", name)
+	}
+
+	*apiKey = updated
+
+	return key, nil
+}
+
+// ExpireNamedAPIKey sets the expiration time of the named API key. Any key
+// replaced by a previous rotation expires no later than the given time.
+func (u *User) ExpireNamedAPIKey(name string, expiresAt time.Time) error {
+	apiKey := u.GetNamedAPIKey(name)
+	if apiKey == nil {
+		return errors.Errorf("API key '%s' does not exist for user '%s'", name, u.ID)
+	}
+
+	previousExpiresAt := apiKey.PreviousExpiresAt
+	if expiresAt.Before(previousExpiresAt) {
+		previousExpiresAt = expiresAt
+	}
+
+	if err := u.updateNamedAPIKey(name, bson.M{
+		apiKeyExpiresAtKey:         expiresAt,
+		apiKeyPreviousExpiresAtKey: previousExpiresAt,
+	}); err != nil {
+		return errors.Wrapf(err, "expiring API key '%s'", name)
+	}
+
+	apiKey.ExpiresAt = expiresAt
+	apiKey.PreviousExpiresAt = previousExpiresAt
+
+	return nil
+}
+
+// RevokeNamedAPIKey permanently revokes the named API key. Revoked keys are
+// kept so that they show up when listing the user's keys.
+func (u *User) RevokeNamedAPIKey(name string) error {
+	apiKey := u.GetNamedAPIKey(name)
+	if apiKey == nil {
+		return errors.Errorf("API key '%s' does not exist for user '%s'", name, u.ID)
+	}
+	if !apiKey.RevokedAt.IsZero() {
+		return nil
+	}
+
+	revokedAt := time.Now()
+	if err := u.updateNamedAPIKey(name, bson.M{apiKeyRevokedAtKey: revokedAt}); err != nil {
+		return errors.Wrapf(err, "revoking API key '%s'", name)
+	}
+
+	apiKey.RevokedAt = revokedAt
+
+	return nil
+}
+
+func (u *User) updateNamedAPIKey(name string, fields bson.M) error {
+	conf, session, err := cedar.GetSessionWithConfig(u.env)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer session.Close()
+
+	set := bson.M{}
+	for key, value := range fields {
+		set[bsonutil.GetDottedKeyName(dbUserAPIKeysKey, "$", key)] = value
+	}
+
+	query := bson.M{
+		dbUserIDKey: u.ID,
+		bsonutil.GetDottedKeyName(dbUserAPIKeysKey, apiKeyNameKey): name,
+	}
+
+	return errors.Wrapf(session.DB(conf.DatabaseName).C(userCollection).Update(query, bson.M{"$set": set}), "updating user '%s'", u.ID)
+}
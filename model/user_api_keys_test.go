@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyIsValid(t *testing.T) {
+	now := time.Now()
+	current := "current"
+	previous := "previous"
+
+	for _, test := range []struct {
+		name     string
+		apiKey   APIKey
+		key      string
+		expected bool
+	}{
+		{
+			name:     "NoExpiration",
+			apiKey:   APIKey{Hash: hashAPIKey(current)},
+			key:      current,
+			expected: true,
+		},
+		{
+			name:     "NotExpired",
+			apiKey:   APIKey{Hash: hashAPIKey(current), ExpiresAt: now.Add(time.Hour)},
+			key:      current,
+			expected: true,
+		},
+		{
+			name:   "Expired",
+			apiKey: APIKey{Hash: hashAPIKey(current), ExpiresAt: now.Add(-time.Hour)},
+			key:    current,
+		},
+		{
+			name:   "Revoked",
+			apiKey: APIKey{Hash: hashAPIKey(current), RevokedAt: now.Add(-time.Hour)},
+			key:    current,
+		},
+		{
+			name:   "WrongKey",
+			apiKey: APIKey{Hash: hashAPIKey(current)},
+			key:    "wrong",
+		},
+		{
+			name: "PreviousKeyInGracePeriod",
+			apiKey: APIKey{
+				Hash:              hashAPIKey(current),
+				PreviousHash:      hashAPIKey(previous),
+				PreviousExpiresAt: now.Add(time.Hour),
+			},
+			key:      previous,
+			expected: true,
+		},
+		{
+			name: "PreviousKeyAfterGracePeriod",
+			apiKey: APIKey{
+				Hash:              hashAPIKey(current),
+				PreviousHash:      hashAPIKey(previous),
+				PreviousExpiresAt: now.Add(-time.Hour),
+			},
+			key: previous,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.apiKey.IsValid(test.key, now))
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Cedar user roles. The admin role is global, while the project writer and
+// read only roles are scoped to a single project (or all projects using the
+// AllProjects wildcard) and stored as "<role>:<project>" in the user's system
+// roles.
+const (
+	RoleAdmin         = "admin"
+	RoleProjectWriter = "project_writer"
+	RoleReadOnly      = "read_only"
+
+	// AllProjects scopes a project role to every project.
+	AllProjects = "*"
+)
+
+// Cedar permissions checked via gimlet.PermissionOpts. The resource of the
+// permission options is the project for project permissions and ignored for
+// the admin permission.
+const (
+	PermissionAdmin        = "cedar_admin"
+	PermissionProjectWrite = "project_write"
+	PermissionProjectRead  = "project_read"
+
+	PermissionResourceTypeSystem  = "system"
+	PermissionResourceTypeProject = "project"
+)
+
+// ValidateRole returns an error if the role and project combination is not a
+// valid Cedar role.
+func ValidateRole(role, project string) error {
+	switch role {
+	case RoleAdmin:
+		if project != "" {
+			return errors.New("the admin role cannot be scoped to a project")
+		}
+		return nil
+	case RoleProjectWriter, RoleReadOnly:
+		if project == "" {
+			return errors.Errorf("the %s role must be scoped to a project", role)
+		}
+		return nil
+	default:
+		return errors.Errorf("unrecognized role '%s'", role)
+	}
+}
+
+// FormatRole returns the role as it is stored in a user's system roles.
+func FormatRole(role, project string) string {
+	if project == "" {
+		return role
+	}
+	return fmt.Sprintf("%s:%s", role, project)
+}
+
+// parseRole splits a stored role into its role name and project scope.
+func parseRole(role string) (string, string) {
+	parts := strings.SplitN(role, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// UserHasPermission returns whether the roles of the given user grant the
+// requested permission. This works for any gimlet.User implementation so that
+// users from all configured user managers are evaluated the same way.
+func UserHasPermission(u gimlet.User, opts gimlet.PermissionOpts) bool {
+	if u == nil {
+		return false
+	}
+
+	for _, stored := range u.Roles() {
+		role, project := parseRole(stored)
+		if role == RoleAdmin {
+			// The admin role is only valid unscoped, so a stored
+			// "admin:<project>" role grants nothing rather than
+			// global admin.
+			if project == "" {
+				return true
+			}
+			continue
+		}
+		if project != AllProjects && project != opts.Resource {
+			continue
+		}
+
+		switch opts.Permission {
+		case PermissionProjectWrite:
+			if role == RoleProjectWriter {
+				return true
+			}
+		case PermissionProjectRead:
+			if role == RoleProjectWriter || role == RoleReadOnly {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AddRole grants the given role to the user and saves it to the DB.
+func (u *User) AddRole(role, project string) error {
+	if err := ValidateRole(role, project); err != nil {
+		return errors.Wrap(err, "invalid role")
+	}
+
+	conf, session, err := cedar.GetSessionWithConfig(u.env)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer session.Close()
+
+	formatted := FormatRole(role, project)
+	err = session.DB(conf.DatabaseName).C(userCollection).UpdateId(u.ID, bson.M{
+		"$addToSet": bson.M{dbUserSystemRolesKey: formatted},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "adding role '%s' to user '%s'", formatted, u.ID)
+	}
+
+	for _, existing := range u.SystemRoles {
+		if existing == formatted {
+			return nil
+		}
+	}
+	u.SystemRoles = append(u.SystemRoles, formatted)
+
+	return nil
+}
+
+// RemoveRole revokes the given role from the user and saves it to the DB.
+func (u *User) RemoveRole(role, project string) error {
+	conf, session, err := cedar.GetSessionWithConfig(u.env)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer session.Close()
+
+	formatted := FormatRole(role, project)
+	err = session.DB(conf.DatabaseName).C(userCollection).UpdateId(u.ID, bson.M{
+		"$pull": bson.M{dbUserSystemRolesKey: formatted},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "removing role '%s' from user '%s'", formatted, u.ID)
+	}
+
+	roles := []string{}
+	for _, existing := range u.SystemRoles {
+		if existing != formatted {
+			roles = append(roles, existing)
+		}
+	}
+	u.SystemRoles = roles
+
+	return nil
+}
+
+// GrantRoleToAllUsers grants the given role to every existing user and returns
+// the number of users that did not already have it. This is used to migrate
+// existing users before enforcing a role that they did not previously need.
+func GrantRoleToAllUsers(env cedar.Environment, role, project string) (int, error) {
+	if err := ValidateRole(role, project); err != nil {
+		return 0, errors.Wrap(err, "invalid role")
+	}
+
+	conf, session, err := cedar.GetSessionWithConfig(env)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer session.Close()
+
+	formatted := FormatRole(role, project)
+	info, err := session.DB(conf.DatabaseName).C(userCollection).UpdateAll(
+		bson.M{dbUserSystemRolesKey: bson.M{"$ne": formatted}},
+		bson.M{"$addToSet": bson.M{dbUserSystemRolesKey: formatted}},
+	)
+	if err != nil {
+		return 0, errors.Wrapf(err, "granting role '%s' to all users", formatted)
+	}
+
+	return info.Updated, nil
+}
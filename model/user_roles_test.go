@@ -0,0 +1,108 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/gimlet"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserHasPermission(t *testing.T) {
+	projectWrite := gimlet.PermissionOpts{
+		Resource:     "project",
+		ResourceType: PermissionResourceTypeProject,
+		Permission:   PermissionProjectWrite,
+	}
+	projectRead := gimlet.PermissionOpts{
+		Resource:     "project",
+		ResourceType: PermissionResourceTypeProject,
+		Permission:   PermissionProjectRead,
+	}
+	admin := gimlet.PermissionOpts{
+		ResourceType: PermissionResourceTypeSystem,
+		Permission:   PermissionAdmin,
+	}
+
+	for _, test := range []struct {
+		name     string
+		roles    []string
+		opts     gimlet.PermissionOpts
+		expected bool
+	}{
+		{
+			name:  "NoRoles",
+			opts:  projectRead,
+			roles: nil,
+		},
+		{
+			name:     "AdminHasAdmin",
+			roles:    []string{RoleAdmin},
+			opts:     admin,
+			expected: true,
+		},
+		{
+			name:     "AdminCanWriteProject",
+			roles:    []string{RoleAdmin},
+			opts:     projectWrite,
+			expected: true,
+		},
+		{
+			name:  "ScopedAdminIsNotAdmin",
+			roles: []string{FormatRole(RoleAdmin, "project")},
+			opts:  admin,
+		},
+		{
+			name:  "ScopedAdminCannotWriteProject",
+			roles: []string{FormatRole(RoleAdmin, "project")},
+			opts:  projectWrite,
+		},
+		{
+			name:  "WriterIsNotAdmin",
+			roles: []string{FormatRole(RoleProjectWriter, AllProjects)},
+			opts:  admin,
+		},
+		{
+			name:     "WriterCanWriteProject",
+			roles:    []string{FormatRole(RoleProjectWriter, "project")},
+			opts:     projectWrite,
+			expected: true,
+		},
+		{
+			name:     "WriterCanReadProject",
+			roles:    []string{FormatRole(RoleProjectWriter, "project")},
+			opts:     projectRead,
+			expected: true,
+		},
+		{
+			name:  "WriterCannotWriteOtherProject",
+			roles: []string{FormatRole(RoleProjectWriter, "other")},
+			opts:  projectWrite,
+		},
+		{
+			name:     "WildcardWriterCanWriteProject",
+			roles:    []string{FormatRole(RoleProjectWriter, AllProjects)},
+			opts:     projectWrite,
+			expected: true,
+		},
+		{
+			name:     "ReadOnlyCanReadProject",
+			roles:    []string{FormatRole(RoleReadOnly, "project")},
+			opts:     projectRead,
+			expected: true,
+		},
+		{
+			name:  "ReadOnlyCannotWriteProject",
+			roles: []string{FormatRole(RoleReadOnly, "project")},
+			opts:  projectWrite,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			u := &User{ID: "user", SystemRoles: test.roles}
+			assert.Equal(t, test.expected, u.HasPermission(test.opts))
+			assert.Equal(t, test.expected, UserHasPermission(&gimlet.BasicUser{ID: "user", AccessRoles: test.roles}, test.opts))
+		})
+	}
+	t.Run("NilUser", func(t *testing.T) {
+		assert.False(t, UserHasPermission(nil, admin))
+	})
+}
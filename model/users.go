@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/anser/bsonutil"
@@ -22,20 +23,30 @@ type User struct {
 	EmailAddress string     `bson:"email"`
 	CreatedAt    time.Time  `bson:"created_at"`
 	APIKey       string     `bson:"apikey"`
+	APIKeys      []APIKey   `bson:"api_keys,omitempty"`
 	SystemRoles  []string   `bson:"roles,omitempty"`
 	LoginCache   LoginCache `bson:"login_cache,omitempty"`
 
+	// APIKeyExpiresAt and APIKeyRevokedAt control whether the legacy API
+	// key may be used to authenticate. A zero expiration time means the key
+	// never expires.
+	APIKeyExpiresAt time.Time `bson:"apikey_expires_at,omitempty"`
+	APIKeyRevokedAt time.Time `bson:"apikey_revoked_at,omitempty"`
+
 	env       cedar.Environment
 	populated bool
 }
 
 var (
-	dbUserIDKey           = bsonutil.MustHaveTag(User{}, "ID")
-	dbUserDisplayNameKey  = bsonutil.MustHaveTag(User{}, "Display")
-	dbUserEmailAddressKey = bsonutil.MustHaveTag(User{}, "EmailAddress")
-	dbUserAPIKeyKey       = bsonutil.MustHaveTag(User{}, "APIKey")
-	dbUserSystemRolesKey  = bsonutil.MustHaveTag(User{}, "SystemRoles")
-	dbUserLoginCacheKey   = bsonutil.MustHaveTag(User{}, "LoginCache")
+	dbUserIDKey              = bsonutil.MustHaveTag(User{}, "ID")
+	dbUserDisplayNameKey     = bsonutil.MustHaveTag(User{}, "Display")
+	dbUserEmailAddressKey    = bsonutil.MustHaveTag(User{}, "EmailAddress")
+	dbUserAPIKeyKey          = bsonutil.MustHaveTag(User{}, "APIKey")
+	dbUserAPIKeysKey         = bsonutil.MustHaveTag(User{}, "APIKeys")
+	dbUserAPIKeyExpiresAtKey = bsonutil.MustHaveTag(User{}, "APIKeyExpiresAt")
+	dbUserAPIKeyRevokedAtKey = bsonutil.MustHaveTag(User{}, "APIKeyRevokedAt")
+	dbUserSystemRolesKey     = bsonutil.MustHaveTag(User{}, "SystemRoles")
+	dbUserLoginCacheKey      = bsonutil.MustHaveTag(User{}, "LoginCache")
 )
 
 type LoginCache struct {
@@ -81,13 +92,16 @@ func (u *User) Save() error {
 	return errors.WithStack(err)
 }
 
-func (u *User) Email() string                              { return u.EmailAddress }
-func (u *User) Username() string                           { return u.ID }
-func (u *User) GetAPIKey() string                          { return u.APIKey }
-func (u *User) Roles() []string                            { return u.SystemRoles }
-func (u *User) GetAccessToken() string                     { return "" }
-func (u *User) GetRefreshToken() string                    { return "" }
-func (u *User) HasPermission(_ gimlet.PermissionOpts) bool { return false }
+func (u *User) Email() string           { return u.EmailAddress }
+func (u *User) Username() string        { return u.ID }
+func (u *User) Roles() []string         { return u.SystemRoles }
+func (u *User) GetAccessToken() string  { return "" }
+func (u *User) GetRefreshToken() string { return "" }
+
+// HasPermission returns whether the user's roles grant the given permission.
+func (u *User) HasPermission(opts gimlet.PermissionOpts) bool {
+	return UserHasPermission(u, opts)
+}
 
 func (u *User) DisplayName() string {
 	if u.Display != "" {
@@ -96,6 +110,26 @@ func (u *User) DisplayName() string {
 	return u.ID
 }
 
+// GetAPIKey returns the user's legacy API key. An expired or revoked key is
+// returned as an empty string so that it cannot be used to authenticate.
+func (u *User) GetAPIKey() string {
+	if !u.LegacyAPIKeyIsActive(time.Now()) {
+		return ""
+	}
+	return u.APIKey
+}
+
+// LegacyAPIKeyIsActive returns whether the user's legacy API key exists and
+// is neither revoked nor expired at the given time.
+func (u *User) LegacyAPIKeyIsActive(now time.Time) bool {
+	if u.APIKey == "" || !u.APIKeyRevokedAt.IsZero() {
+		return false
+	}
+	return u.APIKeyExpiresAt.IsZero() || now.Before(u.APIKeyExpiresAt)
+}
+
+// CreateAPIKey generates a new legacy API key for the user, replacing any
+// existing, expired, or revoked legacy key.
 func (u *User) CreateAPIKey() (string, error) {
 	conf, session, err := cedar.GetSessionWithConfig(u.env)
 	if err != nil {
@@ -106,16 +140,66 @@ func (u *User) CreateAPIKey() (string, error) {
 	k := utility.RandomString()
 
 	err = session.DB(conf.DatabaseName).C(userCollection).UpdateId(u.ID, bson.M{
-		dbUserAPIKeyKey: k,
+		"$set": bson.M{dbUserAPIKeyKey: k},
+		"$unset": bson.M{
+			dbUserAPIKeyExpiresAtKey: 1,
+			dbUserAPIKeyRevokedAtKey: 1,
+		},
 	})
 	if err != nil {
 		return "", errors.Wrapf(err, "updating user '%s'", u.ID)
 	}
 
 	u.APIKey = k
+	u.APIKeyExpiresAt = time.Time{}
+	u.APIKeyRevokedAt = time.Time{}
 	return k, nil
 }
 
+// ExpireLegacyAPIKey sets the expiration time of the user's legacy API key.
+func (u *User) ExpireLegacyAPIKey(expiresAt time.Time) error {
+	if u.APIKey == "" {
+		return errors.Errorf("user '%s' does not have a legacy API key", u.ID)
+	}
+
+	if err := u.updateLegacyAPIKey(bson.M{"$set": bson.M{dbUserAPIKeyExpiresAtKey: expiresAt}}); err != nil {
+		return errors.Wrap(err, "expiring legacy API key")
+	}
+
+	u.APIKeyExpiresAt = expiresAt
+	return nil
+}
+
+// RevokeLegacyAPIKey permanently revokes the user's legacy API key. The key
+// itself is removed, a new legacy key must be created to replace it.
+func (u *User) RevokeLegacyAPIKey() error {
+	if u.APIKey == "" {
+		return nil
+	}
+
+	revokedAt := time.Now()
+	if err := u.updateLegacyAPIKey(bson.M{
+		"$set":   bson.M{dbUserAPIKeyRevokedAtKey: revokedAt},
+		"$unset": bson.M{dbUserAPIKeyKey: 1},
+	}); err != nil {
+		return errors.Wrap(err, "revoking legacy API key")
+	}
+
+	u.APIKey = ""
+	u.APIKeyRevokedAt = revokedAt
+	return nil
+}
+
+func (u *User) updateLegacyAPIKey(update bson.M) error {
+	conf, session, err := cedar.GetSessionWithConfig(u.env)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer session.Close()
+
+	return errors.Wrapf(session.DB(conf.DatabaseName).C(userCollection).UpdateId(u.ID, update), "updating user '%s'", u.ID)
+}
+
 func (u *User) UpdateLoginCache() (string, error) {
 	conf, session, err := cedar.GetSessionWithConfig(u.env)
 	if err != nil {
@@ -189,13 +273,16 @@ func GetLoginCache(token string) (gimlet.User, bool, error) {
 	query := bson.M{bsonutil.GetDottedKeyName(dbUserLoginCacheKey, loginCacheTokenKey): token}
 	err = session.DB(conf.DatabaseName).C(userCollection).Find(query).One(user)
 	if db.ResultsNotFound(err) {
+		metrics.ObserveCacheRequest(metrics.CacheLogin, false)
 		return nil, false, nil
 	} else if err != nil {
 		return nil, false, errors.Wrap(err, "getting user from cache")
 	}
 	if time.Since(user.LoginCache.TTL) > cedar.TokenExpireAfter {
+		metrics.ObserveCacheRequest(metrics.CacheLogin, false)
 		return user, false, nil
 	}
+	metrics.ObserveCacheRequest(metrics.CacheLogin, true)
 	return user, true, nil
 }
 
@@ -118,6 +118,57 @@ func (s *UserTestSuite) TestGetOrAddUser() {
 	s.NotEqual(time.Time{}, fromDB.LoginCache.TTL)
 }
 
+func (s *UserTestSuite) TestLegacyAPIKey() {
+	u := s.users[0]
+	s.Empty(u.GetAPIKey())
+	s.Error(u.ExpireLegacyAPIKey(time.Now()))
+
+	key, err := u.CreateAPIKey()
+	s.Require().NoError(err)
+	s.Equal(key, u.GetAPIKey())
+
+	s.Require().NoError(u.ExpireLegacyAPIKey(time.Now().Add(-time.Minute)))
+	fromDB := &User{ID: u.ID}
+	fromDB.Setup(u.env)
+	s.Require().NoError(fromDB.Find())
+	s.Equal(key, fromDB.APIKey)
+	s.Empty(fromDB.GetAPIKey())
+	s.Equal("1234", fromDB.LoginCache.Token)
+
+	key, err = u.CreateAPIKey()
+	s.Require().NoError(err)
+	s.Require().NoError(fromDB.Find())
+	s.Equal(key, fromDB.GetAPIKey())
+
+	s.Require().NoError(u.RevokeLegacyAPIKey())
+	s.Empty(u.GetAPIKey())
+	s.Require().NoError(fromDB.Find())
+	s.Empty(fromDB.APIKey)
+	s.False(fromDB.APIKeyRevokedAt.IsZero())
+	s.NoError(u.RevokeLegacyAPIKey())
+}
+
+func (s *UserTestSuite) TestFindUserByNamedAPIKey() {
+	key, err := s.users[0].CreateNamedAPIKey("ci", time.Time{})
+	s.Require().NoError(err)
+
+	u, apiKey, err := FindUserByNamedAPIKey(s.users[0].env, s.users[0].ID, key)
+	s.Require().NoError(err)
+	s.Equal(s.users[0].ID, u.ID)
+	s.Require().NotNil(apiKey)
+	s.Equal("ci", apiKey.Name)
+
+	u, apiKey, err = FindUserByNamedAPIKey(s.users[0].env, s.users[0].ID, "wrong")
+	s.Require().NoError(err)
+	s.NotNil(u)
+	s.Nil(apiKey)
+
+	u, apiKey, err = FindUserByNamedAPIKey(s.users[0].env, "DNE", key)
+	s.Error(err)
+	s.Nil(u)
+	s.Nil(apiKey)
+}
+
 func (s *UserTestSuite) TestPutLoginCache() {
 	token1, err := PutLoginCache(s.users[0])
 	s.NoError(err)
@@ -226,3 +277,92 @@ func (s *UserTestSuite) TestClearLoginCacheAllUsers() {
 		s.Nil(u)
 	}
 }
+
+func (s *UserTestSuite) TestAddAndRemoveRole() {
+	u := s.users[0]
+	s.Error(u.AddRole("invalid", ""))
+	s.Error(u.AddRole(RoleProjectWriter, ""))
+	s.Error(u.AddRole(RoleAdmin, "project"))
+
+	s.Require().NoError(u.AddRole(RoleProjectWriter, "project"))
+	s.Require().NoError(u.AddRole(RoleProjectWriter, "project"))
+	s.Equal([]string{"project_writer:project"}, u.Roles())
+	fromDB := &User{}
+	s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+	s.Equal([]string{"project_writer:project"}, fromDB.SystemRoles)
+
+	s.Require().NoError(u.RemoveRole(RoleProjectWriter, "project"))
+	s.Empty(u.Roles())
+	fromDB = &User{}
+	s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+	s.Empty(fromDB.SystemRoles)
+}
+
+func (s *UserTestSuite) TestGrantRoleToAllUsers() {
+	env := cedar.GetEnvironment()
+	_, err := GrantRoleToAllUsers(env, RoleAdmin, "project")
+	s.Error(err)
+
+	s.Require().NoError(s.users[0].AddRole(RoleReadOnly, AllProjects))
+	updated, err := GrantRoleToAllUsers(env, RoleReadOnly, AllProjects)
+	s.Require().NoError(err)
+	s.Equal(len(s.users)-1, updated)
+	for _, u := range s.users {
+		fromDB := &User{}
+		s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+		s.Equal([]string{"read_only:*"}, fromDB.SystemRoles)
+	}
+
+	updated, err = GrantRoleToAllUsers(env, RoleReadOnly, AllProjects)
+	s.Require().NoError(err)
+	s.Zero(updated)
+}
+
+func (s *UserTestSuite) TestNamedAPIKeyLifecycle() {
+	u := s.users[0]
+	_, err := u.CreateNamedAPIKey("", time.Time{})
+	s.Error(err)
+
+	key, err := u.CreateNamedAPIKey("ci", time.Now().Add(time.Hour))
+	s.Require().NoError(err)
+	s.NotEmpty(key)
+	_, err = u.CreateNamedAPIKey("ci", time.Time{})
+	s.Error(err)
+	fromDB := &User{}
+	s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+	s.Require().Len(fromDB.APIKeys, 1)
+	s.NotEqual(key, fromDB.APIKeys[0].Hash)
+	s.Require().NotNil(fromDB.MatchNamedAPIKey(key))
+	s.True(fromDB.MatchNamedAPIKey(key).IsValid(key, time.Now()))
+
+	newKey, err := u.RotateNamedAPIKey("ci", time.Time{}, time.Minute)
+	s.Require().NoError(err)
+	s.NotEqual(key, newKey)
+	fromDB = &User{}
+	s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+	s.Require().Len(fromDB.APIKeys, 1)
+	apiKey := fromDB.GetNamedAPIKey("ci")
+	s.Require().NotNil(apiKey)
+	s.True(apiKey.IsValid(newKey, time.Now()))
+	s.True(apiKey.IsValid(key, time.Now()))
+	s.False(apiKey.IsValid(key, time.Now().Add(2*time.Minute)))
+
+	s.Require().NoError(u.ExpireNamedAPIKey("ci", time.Now()))
+	fromDB = &User{}
+	s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+	s.False(fromDB.GetNamedAPIKey("ci").IsValid(newKey, time.Now()))
+	s.False(fromDB.GetNamedAPIKey("ci").IsValid(key, time.Now()))
+
+	_, err = u.CreateNamedAPIKey("service", time.Time{})
+	s.Require().NoError(err)
+	s.Require().NoError(u.RevokeNamedAPIKey("service"))
+	fromDB = &User{}
+	s.Require().NoError(s.c.FindId(u.ID).One(fromDB))
+	s.Require().Len(fromDB.APIKeys, 2)
+	s.False(fromDB.GetNamedAPIKey("service").IsActive(time.Now()))
+	_, err = u.RotateNamedAPIKey("service", time.Time{}, 0)
+	s.Error(err)
+
+	s.Error(u.RevokeNamedAPIKey("DNE"))
+	s.Error(u.ExpireNamedAPIKey("DNE", time.Now()))
+}
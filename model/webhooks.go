@@ -0,0 +1,432 @@
+package model
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/bsonutil"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhookSubscriptionCollection = "webhook_subscriptions"
+	webhookDeliveryCollection     = "webhook_deliveries"
+	defaultWebhookDeliveryLimit   = 100
+)
+
+// WebhookEvent is an event that a project may subscribe a webhook to.
+type WebhookEvent string
+
+const (
+	// WebhookEventTestResultsFailed is sent when a test results record
+	// with at least one failed test is closed.
+	WebhookEventTestResultsFailed WebhookEvent = "test_results_failed"
+	// WebhookEventTestNewlyFailing is sent when a closed test results
+	// record has tests that failed but passed in its base record.
+	WebhookEventTestNewlyFailing WebhookEvent = "test_newly_failing"
+	// WebhookEventPerfChangePoint is sent when the performance analysis
+	// service reports a change point.
+	WebhookEventPerfChangePoint WebhookEvent = "perf_change_point"
+	// WebhookEventLogFailed is sent when a log is closed with a non-zero
+	// exit code.
+	WebhookEventLogFailed WebhookEvent = "log_failed"
+)
+
+// Validate the webhook event.
+func (e WebhookEvent) Validate() error {
+	switch e {
+	case WebhookEventTestResultsFailed, WebhookEventTestNewlyFailing, WebhookEventPerfChangePoint, WebhookEventLogFailed:
+		return nil
+	default:
+		return errors.Errorf("invalid webhook event '%s'", e)
+	}
+}
+
+// WebhookSubscription is a project's subscription of an HTTPS webhook URL to
+// a set of events. Deliveries are signed with the secret, which is only
+// returned when the subscription is created.
+type WebhookSubscription struct {
+	ID        string         `bson:"_id" json:"id"`
+	Project   string         `bson:"project" json:"project"`
+	URL       string         `bson:"url" json:"url"`
+	Events    []WebhookEvent `bson:"events" json:"events"`
+	Secret    string         `bson:"secret" json:"-"`
+	CreatedBy string         `bson:"created_by,omitempty" json:"created_by,omitempty"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+}
+
+var (
+	webhookSubscriptionIDKey      = bsonutil.MustHaveTag(WebhookSubscription{}, "ID")
+	webhookSubscriptionProjectKey = bsonutil.MustHaveTag(WebhookSubscription{}, "Project")
+	webhookSubscriptionEventsKey  = bsonutil.MustHaveTag(WebhookSubscription{}, "Events")
+)
+
+// CreateWebhookSubscription validates and saves a new webhook subscription
+// for the project with a generated secret.
+func CreateWebhookSubscription(ctx context.Context, env cedar.Environment, project, user, webhookURL string, events []WebhookEvent) (*WebhookSubscription, error) {
+	if env == nil {
+		return nil, errors.New("cannot create webhook subscription with a nil environment")
+	}
+
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(project == "", "must specify a project")
+	catcher.NewWhen(len(events) == 0, "must specify at least one event")
+	for _, event := range events {
+		catcher.Add(event.Validate())
+	}
+	if parsed, err := url.Parse(webhookURL); err != nil {
+		catcher.Wrapf(err, "parsing webhook URL '%s'", webhookURL)
+	} else {
+		catcher.ErrorfWhen(parsed.Scheme != "https", "webhook URL '%s' must use HTTPS", webhookURL)
+		catcher.ErrorfWhen(parsed.Host == "", "webhook URL '%s' must have a host", webhookURL)
+	}
+	if catcher.HasErrors() {
+		return nil, errors.Wrap(catcher.Resolve(), "invalid webhook subscription")
+	}
+
+	sub := &WebhookSubscription{
+		ID:        primitive.NewObjectID().Hex(),
+		Project:   project,
+		URL:       webhookURL,
+		Events:    events,
+		Secret:    utility.RandomString(),
+		CreatedBy: user,
+		CreatedAt: time.Now(),
+	}
+	if _, err := env.GetDB().Collection(webhookSubscriptionCollection).InsertOne(ctx, sub); err != nil {
+		return nil, errors.Wrapf(err, "saving webhook subscription for project '%s'", project)
+	}
+
+	return sub, nil
+}
+
+// FindWebhookSubscriptions returns the project's webhook subscriptions. If
+// the event is not empty, only subscriptions to that event are returned.
+func FindWebhookSubscriptions(ctx context.Context, env cedar.Environment, project string, event WebhookEvent) ([]WebhookSubscription, error) {
+	if env == nil {
+		return nil, errors.New("cannot find webhook subscriptions with a nil environment")
+	}
+
+	query := bson.M{webhookSubscriptionProjectKey: project}
+	if event != "" {
+		query[webhookSubscriptionEventsKey] = event
+	}
+	cur, err := env.GetDB().Collection(webhookSubscriptionCollection).Find(ctx, query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding webhook subscriptions for project '%s'", project)
+	}
+
+	subs := []WebhookSubscription{}
+	if err = cur.All(ctx, &subs); err != nil {
+		return nil, errors.Wrap(err, "decoding webhook subscriptions")
+	}
+
+	return subs, nil
+}
+
+// FindWebhookSubscription returns the webhook subscription with the given ID,
+// or nil if it does not exist.
+func FindWebhookSubscription(ctx context.Context, env cedar.Environment, id string) (*WebhookSubscription, error) {
+	if env == nil {
+		return nil, errors.New("cannot find webhook subscription with a nil environment")
+	}
+
+	sub := &WebhookSubscription{}
+	err := env.GetDB().Collection(webhookSubscriptionCollection).FindOne(ctx, bson.M{webhookSubscriptionIDKey: id}).Decode(sub)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	return sub, errors.Wrapf(err, "finding webhook subscription '%s'", id)
+}
+
+// DeleteWebhookSubscription deletes the project's webhook subscription with
+// the given ID. Pending deliveries to it are dropped.
+func DeleteWebhookSubscription(ctx context.Context, env cedar.Environment, project, id string) error {
+	if env == nil {
+		return errors.New("cannot delete webhook subscription with a nil environment")
+	}
+
+	res, err := env.GetDB().Collection(webhookSubscriptionCollection).DeleteOne(ctx, bson.M{
+		webhookSubscriptionIDKey:      id,
+		webhookSubscriptionProjectKey: project,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "deleting webhook subscription '%s'", id)
+	}
+	if res.DeletedCount == 0 {
+		return errors.Errorf("webhook subscription '%s' not found for project '%s'", id, project)
+	}
+
+	return nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 of the payload with
+// the subscription secret, which receivers use to verify deliveries.
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookPayload is the body of a webhook delivery.
+type WebhookPayload struct {
+	Event     WebhookEvent `json:"event"`
+	Project   string       `json:"project"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      interface{}  `json:"data"`
+}
+
+// TestResultsWebhookData is the payload data of the test results events.
+type TestResultsWebhookData struct {
+	ID          string   `json:"id"`
+	Version     string   `json:"version"`
+	Variant     string   `json:"variant"`
+	TaskName    string   `json:"task_name"`
+	TaskID      string   `json:"task_id"`
+	Execution   int      `json:"execution"`
+	Mainline    bool     `json:"mainline"`
+	TotalCount  int      `json:"total_count"`
+	FailedCount int      `json:"failed_count"`
+	FailedTests []string `json:"failed_tests"`
+}
+
+// NewTestResultsWebhookData returns the payload data for the test results
+// record with the given failed tests.
+func NewTestResultsWebhookData(record *TestResults, failedTests []string) TestResultsWebhookData {
+	return TestResultsWebhookData{
+		ID:          record.ID,
+		Version:     record.Info.Version,
+		Variant:     record.Info.Variant,
+		TaskName:    record.Info.TaskName,
+		TaskID:      record.Info.TaskID,
+		Execution:   record.Info.Execution,
+		Mainline:    record.Info.Mainline,
+		TotalCount:  record.Stats.TotalCount,
+		FailedCount: record.Stats.FailedCount,
+		FailedTests: failedTests,
+	}
+}
+
+// LogWebhookData is the payload data of the log events.
+type LogWebhookData struct {
+	ID          string `json:"id"`
+	Version     string `json:"version"`
+	Variant     string `json:"variant"`
+	TaskName    string `json:"task_name"`
+	TaskID      string `json:"task_id"`
+	Execution   int    `json:"execution"`
+	TestName    string `json:"test_name,omitempty"`
+	ProcessName string `json:"process_name,omitempty"`
+	ExitCode    int    `json:"exit_code"`
+}
+
+// NewLogWebhookData returns the payload data for the log.
+func NewLogWebhookData(log *Log) LogWebhookData {
+	return LogWebhookData{
+		ID:          log.ID,
+		Version:     log.Info.Version,
+		Variant:     log.Info.Variant,
+		TaskName:    log.Info.TaskName,
+		TaskID:      log.Info.TaskID,
+		Execution:   log.Info.Execution,
+		TestName:    log.Info.TestName,
+		ProcessName: log.Info.ProcessName,
+		ExitCode:    log.Info.ExitCode,
+	}
+}
+
+// PerfChangePointWebhookData is the payload data of the perf change point
+// event, as reported by the performance analysis service.
+type PerfChangePointWebhookData struct {
+	Variant       string           `json:"variant"`
+	Task          string           `json:"task"`
+	Test          string           `json:"test"`
+	Measurement   string           `json:"measurement"`
+	Arguments     map[string]int32 `json:"args,omitempty"`
+	Version       string           `json:"version"`
+	Order         int              `json:"order"`
+	PercentChange float64          `json:"percent_change"`
+}
+
+// Validate ensures the change point identifies a time series.
+func (d *PerfChangePointWebhookData) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(d.Variant == "", "must specify a variant")
+	catcher.NewWhen(d.Task == "", "must specify a task")
+	catcher.NewWhen(d.Test == "", "must specify a test")
+	catcher.NewWhen(d.Measurement == "", "must specify a measurement")
+	catcher.NewWhen(d.Version == "", "must specify a version")
+	return catcher.Resolve()
+}
+
+// WebhookDelivery records the delivery of an event to a webhook subscription
+// and the result of its latest attempt. Deliveries expire thirty days after
+// they are created.
+type WebhookDelivery struct {
+	ID             string       `bson:"_id" json:"id"`
+	SubscriptionID string       `bson:"subscription_id" json:"subscription_id"`
+	Project        string       `bson:"project" json:"project"`
+	Event          WebhookEvent `bson:"event" json:"event"`
+	Payload        string       `bson:"payload" json:"payload"`
+	CreatedAt      time.Time    `bson:"created_at" json:"created_at"`
+	Attempts       int          `bson:"attempts" json:"attempts"`
+	LastAttemptAt  time.Time    `bson:"last_attempt_at,omitempty" json:"last_attempt_at,omitempty"`
+	StatusCode     int          `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error          string       `bson:"error,omitempty" json:"error,omitempty"`
+	DeliveredAt    time.Time    `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+}
+
+var (
+	webhookDeliveryIDKey             = bsonutil.MustHaveTag(WebhookDelivery{}, "ID")
+	webhookDeliverySubscriptionIDKey = bsonutil.MustHaveTag(WebhookDelivery{}, "SubscriptionID")
+	webhookDeliveryCreatedAtKey      = bsonutil.MustHaveTag(WebhookDelivery{}, "CreatedAt")
+	webhookDeliveryAttemptsKey       = bsonutil.MustHaveTag(WebhookDelivery{}, "Attempts")
+	webhookDeliveryLastAttemptAtKey  = bsonutil.MustHaveTag(WebhookDelivery{}, "LastAttemptAt")
+	webhookDeliveryStatusCodeKey     = bsonutil.MustHaveTag(WebhookDelivery{}, "StatusCode")
+	webhookDeliveryErrorKey          = bsonutil.MustHaveTag(WebhookDelivery{}, "Error")
+	webhookDeliveryDeliveredAtKey    = bsonutil.MustHaveTag(WebhookDelivery{}, "DeliveredAt")
+)
+
+// CreateWebhookDeliveries creates a pending delivery of the event for each of
+// the project's subscriptions to it and returns them.
+func CreateWebhookDeliveries(ctx context.Context, env cedar.Environment, project string, event WebhookEvent, data interface{}) ([]WebhookDelivery, error) {
+	if err := event.Validate(); err != nil {
+		return nil, err
+	}
+	subs, err := FindWebhookSubscriptions(ctx, env, project, event)
+	if err != nil {
+		return nil, err
+	}
+	if len(subs) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{
+		Event:     event,
+		Project:   project,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling webhook payload")
+	}
+
+	deliveries := make([]WebhookDelivery, len(subs))
+	docs := make([]interface{}, len(subs))
+	for i, sub := range subs {
+		deliveries[i] = WebhookDelivery{
+			ID:             primitive.NewObjectID().Hex(),
+			SubscriptionID: sub.ID,
+			Project:        project,
+			Event:          event,
+			Payload:        string(payload),
+			CreatedAt:      now,
+		}
+		docs[i] = deliveries[i]
+	}
+	if _, err = env.GetDB().Collection(webhookDeliveryCollection).InsertMany(ctx, docs); err != nil {
+		return nil, errors.Wrapf(err, "saving webhook deliveries for project '%s'", project)
+	}
+
+	return deliveries, nil
+}
+
+// FindWebhookDelivery returns the webhook delivery with the given ID.
+func FindWebhookDelivery(ctx context.Context, env cedar.Environment, id string) (*WebhookDelivery, error) {
+	if env == nil {
+		return nil, errors.New("cannot find webhook delivery with a nil environment")
+	}
+
+	delivery := &WebhookDelivery{}
+	err := env.GetDB().Collection(webhookDeliveryCollection).FindOne(ctx, bson.M{webhookDeliveryIDKey: id}).Decode(delivery)
+
+	return delivery, errors.Wrapf(err, "finding webhook delivery '%s'", id)
+}
+
+// FindWebhookDeliveries returns the latest deliveries to the webhook
+// subscription, most recent first.
+func FindWebhookDeliveries(ctx context.Context, env cedar.Environment, subscriptionID string, limit int) ([]WebhookDelivery, error) {
+	if env == nil {
+		return nil, errors.New("cannot find webhook deliveries with a nil environment")
+	}
+	if limit <= 0 {
+		limit = defaultWebhookDeliveryLimit
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.D{{Key: webhookDeliveryCreatedAtKey, Value: -1}}).
+		SetLimit(int64(limit))
+	cur, err := env.GetDB().Collection(webhookDeliveryCollection).Find(ctx, bson.M{webhookDeliverySubscriptionIDKey: subscriptionID}, findOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding webhook deliveries for subscription '%s'", subscriptionID)
+	}
+
+	deliveries := []WebhookDelivery{}
+	if err = cur.All(ctx, &deliveries); err != nil {
+		return nil, errors.Wrap(err, "decoding webhook deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// RecordAttempt records the result of an attempt to deliver the payload. A
+// nil error marks the delivery as delivered.
+func (d *WebhookDelivery) RecordAttempt(ctx context.Context, env cedar.Environment, statusCode int, attemptErr error) error {
+	if env == nil {
+		return errors.New("cannot record webhook delivery attempt with a nil environment")
+	}
+
+	now := time.Now()
+	set := bson.M{
+		webhookDeliveryLastAttemptAtKey: now,
+		webhookDeliveryStatusCodeKey:    statusCode,
+	}
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{webhookDeliveryAttemptsKey: 1},
+	}
+	if attemptErr != nil {
+		set[webhookDeliveryErrorKey] = attemptErr.Error()
+	} else {
+		set[webhookDeliveryDeliveredAtKey] = now
+		update["$unset"] = bson.M{webhookDeliveryErrorKey: 1}
+	}
+
+	_, err := env.GetDB().Collection(webhookDeliveryCollection).UpdateOne(ctx, bson.M{webhookDeliveryIDKey: d.ID}, update)
+	if err != nil {
+		return errors.Wrapf(err, "recording attempt for webhook delivery '%s'", d.ID)
+	}
+	grip.Debug(message.Fields{
+		"collection":  webhookDeliveryCollection,
+		"id":          d.ID,
+		"status_code": statusCode,
+		"op":          "record webhook delivery attempt",
+	})
+
+	d.Attempts++
+	d.LastAttemptAt = now
+	d.StatusCode = statusCode
+	if attemptErr != nil {
+		d.Error = attemptErr.Error()
+	} else {
+		d.Error = ""
+		d.DeliveredAt = now
+	}
+
+	return nil
+}
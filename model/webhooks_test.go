@@ -0,0 +1,145 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSubscriptions(t *testing.T) {
+	env := cedar.GetEnvironment()
+	db := env.GetDB()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, db.Collection(webhookSubscriptionCollection).Drop(ctx))
+		assert.NoError(t, db.Collection(webhookDeliveryCollection).Drop(ctx))
+	}()
+
+	t.Run("InvalidSubscription", func(t *testing.T) {
+		for _, test := range []struct {
+			name    string
+			project string
+			url     string
+			events  []WebhookEvent
+		}{
+			{name: "NoProject", url: "https://example.com", events: []WebhookEvent{WebhookEventLogFailed}},
+			{name: "NoEvents", project: "project", url: "https://example.com"},
+			{name: "InvalidEvent", project: "project", url: "https://example.com", events: []WebhookEvent{"invalid"}},
+			{name: "InvalidScheme", project: "project", url: "ftp://example.com", events: []WebhookEvent{WebhookEventLogFailed}},
+			{name: "HTTPScheme", project: "project", url: "http://example.com", events: []WebhookEvent{WebhookEventLogFailed}},
+			{name: "NoHost", project: "project", url: "/hook", events: []WebhookEvent{WebhookEventLogFailed}},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				sub, err := CreateWebhookSubscription(ctx, env, test.project, "user", test.url, test.events)
+				assert.Error(t, err)
+				assert.Nil(t, sub)
+			})
+		}
+	})
+
+	logSub, err := CreateWebhookSubscription(ctx, env, "project", "user", "https://example.com/log", []WebhookEvent{WebhookEventLogFailed})
+	require.NoError(t, err)
+	assert.NotEmpty(t, logSub.Secret)
+	testSub, err := CreateWebhookSubscription(ctx, env, "project", "user", "https://example.com/test", []WebhookEvent{WebhookEventTestResultsFailed, WebhookEventTestNewlyFailing})
+	require.NoError(t, err)
+	assert.NotEqual(t, logSub.Secret, testSub.Secret)
+	_, err = CreateWebhookSubscription(ctx, env, "other", "user", "https://example.com/other", []WebhookEvent{WebhookEventLogFailed})
+	require.NoError(t, err)
+
+	t.Run("FindByProject", func(t *testing.T) {
+		subs, err := FindWebhookSubscriptions(ctx, env, "project", "")
+		require.NoError(t, err)
+		assert.Len(t, subs, 2)
+	})
+	t.Run("FindByEvent", func(t *testing.T) {
+		subs, err := FindWebhookSubscriptions(ctx, env, "project", WebhookEventTestNewlyFailing)
+		require.NoError(t, err)
+		require.Len(t, subs, 1)
+		assert.Equal(t, testSub.ID, subs[0].ID)
+		assert.Equal(t, testSub.Secret, subs[0].Secret)
+	})
+	t.Run("FindByID", func(t *testing.T) {
+		sub, err := FindWebhookSubscription(ctx, env, logSub.ID)
+		require.NoError(t, err)
+		require.NotNil(t, sub)
+		assert.Equal(t, logSub.URL, sub.URL)
+
+		sub, err = FindWebhookSubscription(ctx, env, "DNE")
+		assert.NoError(t, err)
+		assert.Nil(t, sub)
+	})
+	t.Run("SecretNotMarshalled", func(t *testing.T) {
+		data, err := json.Marshal(logSub)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), logSub.Secret)
+	})
+	t.Run("Deliveries", func(t *testing.T) {
+		deliveries, err := CreateWebhookDeliveries(ctx, env, "project", WebhookEventLogFailed, LogWebhookData{ID: "log", ExitCode: 2})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		assert.Equal(t, logSub.ID, deliveries[0].SubscriptionID)
+		payload := struct {
+			Event WebhookEvent   `json:"event"`
+			Data  LogWebhookData `json:"data"`
+		}{}
+		require.NoError(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+		assert.Equal(t, WebhookEventLogFailed, payload.Event)
+		assert.Equal(t, 2, payload.Data.ExitCode)
+
+		deliveries, err = CreateWebhookDeliveries(ctx, env, "project", WebhookEventPerfChangePoint, PerfChangePointWebhookData{})
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+		_, err = CreateWebhookDeliveries(ctx, env, "project", "invalid", nil)
+		assert.Error(t, err)
+	})
+	t.Run("RecordAttempt", func(t *testing.T) {
+		deliveries, err := CreateWebhookDeliveries(ctx, env, "project", WebhookEventLogFailed, LogWebhookData{ID: "log"})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		delivery := deliveries[0]
+
+		require.NoError(t, delivery.RecordAttempt(ctx, env, 500, errors.New("server error")))
+		found, err := FindWebhookDelivery(ctx, env, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.Attempts)
+		assert.Equal(t, 500, found.StatusCode)
+		assert.Equal(t, "server error", found.Error)
+		assert.True(t, found.DeliveredAt.IsZero())
+
+		require.NoError(t, delivery.RecordAttempt(ctx, env, 200, nil))
+		found, err = FindWebhookDelivery(ctx, env, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, found.Attempts)
+		assert.Equal(t, 200, found.StatusCode)
+		assert.Empty(t, found.Error)
+		assert.False(t, found.DeliveredAt.IsZero())
+
+		logged, err := FindWebhookDeliveries(ctx, env, logSub.ID, 0)
+		require.NoError(t, err)
+		assert.Len(t, logged, 2)
+		logged, err = FindWebhookDeliveries(ctx, env, logSub.ID, 1)
+		require.NoError(t, err)
+		assert.Len(t, logged, 1)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		assert.Error(t, DeleteWebhookSubscription(ctx, env, "other", logSub.ID))
+		require.NoError(t, DeleteWebhookSubscription(ctx, env, "project", logSub.ID))
+		sub, err := FindWebhookSubscription(ctx, env, logSub.ID)
+		require.NoError(t, err)
+		assert.Nil(t, sub)
+		assert.Error(t, DeleteWebhookSubscription(ctx, env, "project", logSub.ID))
+	})
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	// Known HMAC-SHA256 test vector from RFC 4231.
+	assert.Equal(t, "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		SignWebhookPayload("Jefe", []byte("what do ya want for nothing?")))
+	assert.NotEqual(t, SignWebhookPayload("secret", []byte("payload")), SignWebhookPayload("other", []byte("payload")))
+}
@@ -0,0 +1,7 @@
+/*
+Package oidc provides a gimlet user manager that authenticates users with JSON
+Web Tokens (JWTs) issued by an OpenID Connect provider. Tokens are verified
+with github.com/coreos/go-oidc against the provider's JSON Web Key Set (JWKS),
+which is either fetched from the provider or read from a local file.
+*/
+package oidc
@@ -0,0 +1,105 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// Claims are the claims of a verified token.
+type Claims map[string]interface{}
+
+// String returns the string value of the claim, or an empty string if the
+// claim does not exist or is not a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// Strings returns the value of the claim as a list of strings. A single string
+// is returned as a list with one element.
+func (c Claims) Strings(name string) []string {
+	switch v := c[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := []string{}
+		for _, elem := range v {
+			if s, ok := elem.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// fileKeySet is an implementation of gooidc.KeySet that verifies signatures
+// with the signing keys of a JSON Web Key Set read from a local file.
+type fileKeySet struct {
+	keys []jose.JSONWebKey
+}
+
+func newFileKeySet(file string) (*fileKeySet, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading key set file '%s'", file)
+	}
+
+	set := jose.JSONWebKeySet{}
+	if err = json.Unmarshal(data, &set); err != nil {
+		return nil, errors.Wrapf(err, "unmarshalling key set file '%s'", file)
+	}
+
+	keys := &fileKeySet{}
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		keys.keys = append(keys.keys, key)
+	}
+	if len(keys.keys) == 0 {
+		return nil, errors.Errorf("key set file '%s' does not contain any signing keys", file)
+	}
+
+	return keys, nil
+}
+
+func (s *fileKeySet) VerifySignature(_ context.Context, token string) ([]byte, error) {
+	jws, err := jose.ParseSigned(token)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing token")
+	}
+	if len(jws.Signatures) != 1 {
+		return nil, errors.New("token must have exactly one signature")
+	}
+
+	kid := jws.Signatures[0].Header.KeyID
+	for _, key := range s.keys {
+		if kid != "" && key.KeyID != kid {
+			continue
+		}
+		if payload, err := jws.Verify(&key); err == nil {
+			return payload, nil
+		}
+	}
+
+	return nil, errors.New("token signature does not match any key in the key set")
+}
+
+// ParseBearerToken returns the token of an "Authorization" header value using
+// the bearer scheme.
+func ParseBearerToken(authorization string) (string, bool) {
+	const prefix = "bearer "
+	if len(authorization) <= len(prefix) || !strings.EqualFold(authorization[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	token := strings.TrimSpace(authorization[len(prefix):])
+	return token, token != ""
+}
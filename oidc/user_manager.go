@@ -0,0 +1,248 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	gooidc "github.com/coreos/go-oidc"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/grip"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultUserIDClaim = "sub"
+	defaultNameClaim   = "name"
+	defaultEmailClaim  = "email"
+)
+
+// supportedSigningAlgorithms are the asymmetric algorithms that tokens may be
+// signed with. In particular, "none" and HMAC based algorithms are rejected.
+var supportedSigningAlgorithms = []string{
+	gooidc.RS256, gooidc.RS384, gooidc.RS512,
+	gooidc.ES256, gooidc.ES384, gooidc.ES512,
+}
+
+// UserManagerOptions configure the OIDC user manager.
+type UserManagerOptions struct {
+	// Issuer is the expected "iss" claim of the tokens.
+	Issuer string
+	// Audience must be one of the "aud" claims of the tokens.
+	Audience string
+	// JWKSURL is the URL of the provider's JSON Web Key Set. It is
+	// ignored if JWKSFile is set. The key set is cached for as long as
+	// the provider's cache headers allow.
+	JWKSURL string
+	// JWKSFile is the path to a local JSON Web Key Set file, which is
+	// read once when the user manager is created.
+	JWKSFile string
+
+	// UserIDNamespace is prepended to the user IDs of the tokens so that
+	// users of this issuer cannot be confused with users of other
+	// issuers or user managers. Defaults to the issuer's host.
+	UserIDNamespace string
+	// UserIDClaim is the claim used as the user's ID. Defaults to "sub".
+	UserIDClaim string
+	// NameClaim is the claim used as the user's display name. Defaults
+	// to "name".
+	NameClaim string
+	// EmailClaim is the claim used as the user's email address. Defaults
+	// to "email".
+	EmailClaim string
+	// RolesClaim, if set, is the claim containing the user's roles. Only
+	// the values of the claim in RoleMappings grant roles, and the mapped
+	// roles take precedence over the stored roles of the user.
+	RolesClaim string
+	// RoleMappings maps values of the roles claim to the roles they
+	// grant. Values without a mapping are ignored. Must be set if
+	// RolesClaim is set.
+	RoleMappings map[string][]string
+	// DefaultRoles are the roles given to users that are created on
+	// their first login when the token does not grant any roles.
+	DefaultRoles []string
+
+	// GetUserByID returns the stored user with the given ID.
+	GetUserByID func(string) (gimlet.User, bool, error)
+	// GetOrCreateUser returns the stored user, creating it if it does not
+	// exist yet.
+	GetOrCreateUser func(gimlet.User) (gimlet.User, error)
+}
+
+// Validate ensures the options are valid and sets defaults.
+func (opts *UserManagerOptions) Validate() error {
+	catcher := grip.NewBasicCatcher()
+
+	catcher.NewWhen(opts.Issuer == "", "must specify an issuer")
+	catcher.NewWhen(opts.Audience == "", "must specify an audience")
+	catcher.NewWhen(opts.JWKSURL == "" && opts.JWKSFile == "", "must specify a JWKS URL or file")
+	catcher.NewWhen(opts.RolesClaim != "" && len(opts.RoleMappings) == 0, "must specify role mappings when using a roles claim")
+	catcher.NewWhen(opts.GetUserByID == nil, "must specify a function to get users by ID")
+	catcher.NewWhen(opts.GetOrCreateUser == nil, "must specify a function to get or create users")
+
+	if opts.UserIDNamespace == "" {
+		issuer, err := url.Parse(opts.Issuer)
+		if err != nil || issuer.Host == "" {
+			catcher.Errorf("must specify a user ID namespace for issuer '%s'", opts.Issuer)
+		} else {
+			opts.UserIDNamespace = issuer.Host
+		}
+	}
+	if opts.UserIDClaim == "" {
+		opts.UserIDClaim = defaultUserIDClaim
+	}
+	if opts.NameClaim == "" {
+		opts.NameClaim = defaultNameClaim
+	}
+	if opts.EmailClaim == "" {
+		opts.EmailClaim = defaultEmailClaim
+	}
+
+	return catcher.Resolve()
+}
+
+type userManager struct {
+	opts     UserManagerOptions
+	verifier *gooidc.IDTokenVerifier
+}
+
+// NewUserManager returns a gimlet.UserManager that authenticates users with
+// bearer tokens signed by the configured OpenID Connect provider. Users are
+// created on their first successful authentication. The user manager does not
+// support interactive login: tokens are obtained directly from the provider.
+func NewUserManager(opts UserManagerOptions) (gimlet.UserManager, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid options")
+	}
+
+	var keySet gooidc.KeySet
+	if opts.JWKSFile != "" {
+		fileKeys, err := newFileKeySet(opts.JWKSFile)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		keySet = fileKeys
+	} else {
+		keySet = gooidc.NewRemoteKeySet(context.Background(), opts.JWKSURL)
+	}
+
+	return &userManager{
+		opts: opts,
+		verifier: gooidc.NewVerifier(opts.Issuer, keySet, &gooidc.Config{
+			ClientID:             opts.Audience,
+			SupportedSigningAlgs: supportedSigningAlgorithms,
+		}),
+	}, nil
+}
+
+// verify checks the token's signature and claims and returns its claims.
+func (um *userManager) verify(ctx context.Context, token string) (Claims, error) {
+	idToken, err := um.verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, errors.Wrap(err, "verifying token")
+	}
+
+	claims := Claims{}
+	if err = idToken.Claims(&claims); err != nil {
+		return nil, errors.Wrap(err, "decoding token claims")
+	}
+
+	return claims, nil
+}
+
+// roles returns the roles granted by the values of the token's roles claim.
+func (um *userManager) roles(claims Claims) []string {
+	roles := []string{}
+	seen := map[string]bool{}
+	for _, value := range claims.Strings(um.opts.RolesClaim) {
+		for _, role := range um.opts.RoleMappings[value] {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	return roles
+}
+
+// GetUserByToken verifies the token and returns the user it identifies.
+func (um *userManager) GetUserByToken(ctx context.Context, token string) (gimlet.User, error) {
+	claims, err := um.verify(ctx, token)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	subject := claims.String(um.opts.UserIDClaim)
+	if subject == "" {
+		return nil, errors.Errorf("token does not have a '%s' claim", um.opts.UserIDClaim)
+	}
+	id := fmt.Sprintf("%s:%s", um.opts.UserIDNamespace, subject)
+
+	var roles []string
+	if um.opts.RolesClaim != "" {
+		roles = um.roles(claims)
+	}
+	newUserRoles := roles
+	if len(newUserRoles) == 0 {
+		newUserRoles = um.opts.DefaultRoles
+	}
+
+	user, err := um.opts.GetOrCreateUser(&gimlet.BasicUser{
+		ID:           id,
+		Name:         claims.String(um.opts.NameClaim),
+		EmailAddress: claims.String(um.opts.EmailClaim),
+		AccessRoles:  newUserRoles,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting or creating user '%s'", id)
+	}
+	if um.opts.RolesClaim != "" {
+		return &tokenUser{User: user, roles: roles}, nil
+	}
+
+	return user, nil
+}
+
+// tokenUser overrides the stored roles of a user with the roles in its token.
+type tokenUser struct {
+	gimlet.User
+	roles []string
+}
+
+func (u *tokenUser) Roles() []string { return u.roles }
+
+func (um *userManager) CreateUserToken(string, string) (string, error) {
+	return "", errors.New("tokens must be obtained from the OIDC provider")
+}
+
+func (um *userManager) GetLoginHandler(string) http.HandlerFunc   { return nil }
+func (um *userManager) GetLoginCallbackHandler() http.HandlerFunc { return nil }
+func (um *userManager) IsRedirect() bool                          { return false }
+
+// ReauthorizeUser is a no-op since tokens are short lived and reauthorized by
+// the provider.
+func (um *userManager) ReauthorizeUser(gimlet.User) error { return nil }
+
+func (um *userManager) GetUserByID(id string) (gimlet.User, error) {
+	user, _, err := um.opts.GetUserByID(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding user '%s'", id)
+	}
+
+	return user, nil
+}
+
+func (um *userManager) GetOrCreateUser(u gimlet.User) (gimlet.User, error) {
+	user, err := um.opts.GetOrCreateUser(u)
+	return user, errors.Wrapf(err, "getting or creating user '%s'", u.Username())
+}
+
+func (um *userManager) ClearUser(gimlet.User, bool) error {
+	return errors.New("cannot clear users authenticated with OIDC tokens")
+}
+
+func (um *userManager) GetGroupsForUser(string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
@@ -0,0 +1,337 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/gimlet"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// testIssuer is an in-process stand-in for an OIDC provider that signs tokens
+// with an RSA and an EC key and serves its JWKS.
+type testIssuer struct {
+	url      string
+	rsaKey   *rsa.PrivateKey
+	ecKey    *ecdsa.PrivateKey
+	requests int32
+}
+
+func newTestIssuer(t *testing.T) (*testIssuer, func()) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&issuer.requests, 1)
+		rw.Header().Set("Cache-Control", "max-age=3600")
+		_, _ = rw.Write(issuer.keySet(t))
+	}))
+	issuer.url = srv.URL
+
+	return issuer, srv.Close
+}
+
+func (i *testIssuer) keySet(t *testing.T) []byte {
+	set := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{Key: &i.rsaKey.PublicKey, KeyID: "rsa", Use: "sig", Algorithm: "RS256"},
+		{Key: &i.ecKey.PublicKey, KeyID: "ec", Use: "sig", Algorithm: "ES256"},
+	}}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	return data
+}
+
+func (i *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	var (
+		method jwt.SigningMethod
+		key    interface{}
+	)
+	switch alg {
+	case "RS256":
+		method, key = jwt.SigningMethodRS256, i.rsaKey
+	case "ES256":
+		method, key = jwt.SigningMethodES256, i.ecKey
+	case "none":
+		method, key = jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType
+	}
+	token := jwt.NewWithClaims(method, jwt.MapClaims(claims))
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+// userID returns the ID of the user with the given subject when the user
+// manager uses the default user ID namespace.
+func (i *testIssuer) userID(sub string) string {
+	return strings.TrimPrefix(i.url, "http://") + ":" + sub
+}
+
+func (i *testIssuer) claims(sub string) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   i.url,
+		"aud":   []string{"cedar", "other"},
+		"sub":   sub,
+		"name":  "Test User",
+		"email": sub + "@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+	}
+}
+
+type testUserStore map[string]gimlet.User
+
+func (s testUserStore) getUserByID(id string) (gimlet.User, bool, error) {
+	u, ok := s[id]
+	if !ok {
+		return nil, false, nil
+	}
+	return u, true, nil
+}
+
+func (s testUserStore) getOrCreateUser(u gimlet.User) (gimlet.User, error) {
+	if existing, ok := s[u.Username()]; ok {
+		return existing, nil
+	}
+	s[u.Username()] = u
+	return u, nil
+}
+
+func TestUserManager(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	issuer, closeIssuer := newTestIssuer(t)
+	defer closeIssuer()
+
+	newUserManager := func(t *testing.T, opts UserManagerOptions) (*userManager, testUserStore) {
+		store := testUserStore{}
+		opts.Issuer = issuer.url
+		if opts.Audience == "" {
+			opts.Audience = "cedar"
+		}
+		opts.GetUserByID = store.getUserByID
+		opts.GetOrCreateUser = store.getOrCreateUser
+		if opts.JWKSFile == "" {
+			opts.JWKSURL = issuer.url
+		}
+		um, err := NewUserManager(opts)
+		require.NoError(t, err)
+		return um.(*userManager), store
+	}
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		for _, opts := range []UserManagerOptions{
+			{},
+			{Issuer: issuer.url},
+			{Issuer: issuer.url, JWKSURL: issuer.url},
+			{Issuer: issuer.url, JWKSURL: issuer.url, GetUserByID: testUserStore{}.getUserByID, GetOrCreateUser: testUserStore{}.getOrCreateUser},
+			{Issuer: issuer.url, Audience: "cedar", JWKSURL: issuer.url, RolesClaim: "groups", GetUserByID: testUserStore{}.getUserByID, GetOrCreateUser: testUserStore{}.getOrCreateUser},
+			{Issuer: "issuer", Audience: "cedar", JWKSURL: issuer.url, GetUserByID: testUserStore{}.getUserByID, GetOrCreateUser: testUserStore{}.getOrCreateUser},
+			{Issuer: issuer.url, Audience: "cedar", JWKSFile: filepath.Join(os.TempDir(), "does-not-exist.json"), GetUserByID: testUserStore{}.getUserByID, GetOrCreateUser: testUserStore{}.getOrCreateUser},
+			{Audience: "cedar", JWKSURL: issuer.url, GetUserByID: testUserStore{}.getUserByID, GetOrCreateUser: testUserStore{}.getOrCreateUser},
+		} {
+			_, err := NewUserManager(opts)
+			assert.Error(t, err)
+		}
+	})
+	t.Run("ValidTokens", func(t *testing.T) {
+		um, store := newUserManager(t, UserManagerOptions{})
+		for _, alg := range []string{"RS256", "ES256"} {
+			kid := "rsa"
+			if alg == "ES256" {
+				kid = "ec"
+			}
+			u, err := um.GetUserByToken(ctx, issuer.sign(t, alg, kid, issuer.claims("user-"+kid)))
+			require.NoError(t, err)
+			assert.Equal(t, issuer.userID("user-"+kid), u.Username())
+			assert.Equal(t, "Test User", u.DisplayName())
+			assert.Equal(t, "user-"+kid+"@example.com", u.Email())
+			assert.Contains(t, store, issuer.userID("user-"+kid))
+		}
+		assert.EqualValues(t, 1, atomic.LoadInt32(&issuer.requests))
+	})
+	t.Run("InvalidTokens", func(t *testing.T) {
+		um, store := newUserManager(t, UserManagerOptions{})
+		for testName, makeToken := range map[string]func() string{
+			"Malformed": func() string { return "not.a-token" },
+			"WrongIssuer": func() string {
+				claims := issuer.claims("user")
+				claims["iss"] = "https://issuer.example.com"
+				return issuer.sign(t, "RS256", "rsa", claims)
+			},
+			"WrongAudience": func() string {
+				claims := issuer.claims("user")
+				claims["aud"] = "other"
+				return issuer.sign(t, "RS256", "rsa", claims)
+			},
+			"Expired": func() string {
+				claims := issuer.claims("user")
+				claims["exp"] = time.Now().Add(-time.Hour).Unix()
+				return issuer.sign(t, "RS256", "rsa", claims)
+			},
+			"NoExpiration": func() string {
+				claims := issuer.claims("user")
+				delete(claims, "exp")
+				return issuer.sign(t, "RS256", "rsa", claims)
+			},
+			"NotYetValid": func() string {
+				claims := issuer.claims("user")
+				claims["nbf"] = time.Now().Add(time.Hour).Unix()
+				return issuer.sign(t, "RS256", "rsa", claims)
+			},
+			"NoSubject": func() string {
+				claims := issuer.claims("user")
+				delete(claims, "sub")
+				return issuer.sign(t, "RS256", "rsa", claims)
+			},
+			"UnsignedAlgorithm": func() string {
+				return issuer.sign(t, "none", "rsa", issuer.claims("user"))
+			},
+			"WrongKeyType": func() string {
+				return issuer.sign(t, "RS256", "ec", issuer.claims("user"))
+			},
+			"UnknownKey": func() string {
+				return issuer.sign(t, "RS256", "unknown", issuer.claims("user"))
+			},
+			"TamperedClaims": func() string {
+				token := issuer.sign(t, "RS256", "rsa", issuer.claims("user"))
+				other := issuer.sign(t, "RS256", "rsa", issuer.claims("admin"))
+				return token[:strings.LastIndex(token, ".")] + other[strings.LastIndex(other, "."):]
+			},
+		} {
+			t.Run(testName, func(t *testing.T) {
+				u, err := um.GetUserByToken(ctx, makeToken())
+				assert.Error(t, err)
+				assert.Nil(t, u)
+			})
+		}
+		assert.Empty(t, store)
+	})
+	t.Run("RolesClaim", func(t *testing.T) {
+		um, store := newUserManager(t, UserManagerOptions{
+			UserIDNamespace: "test",
+			RolesClaim:      "groups",
+			RoleMappings: map[string][]string{
+				"admins":  {"admin"},
+				"writers": {"project_writer:project"},
+				"leads":   {"admin", "project_writer:project"},
+			},
+			DefaultRoles: []string{"read_only:*"},
+		})
+
+		claims := issuer.claims("user")
+		claims["groups"] = []string{"admins", "admin", "unknown"}
+		u, err := um.GetUserByToken(ctx, issuer.sign(t, "RS256", "rsa", claims))
+		require.NoError(t, err)
+		assert.Equal(t, "test:user", u.Username())
+		assert.Equal(t, []string{"admin"}, u.Roles())
+		assert.Equal(t, []string{"admin"}, store["test:user"].Roles())
+
+		claims["groups"] = []string{"writers", "leads"}
+		u, err = um.GetUserByToken(ctx, issuer.sign(t, "RS256", "rsa", claims))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"project_writer:project", "admin"}, u.Roles())
+
+		claims["groups"] = "project_writer:project"
+		u, err = um.GetUserByToken(ctx, issuer.sign(t, "RS256", "rsa", claims))
+		require.NoError(t, err)
+		assert.Empty(t, u.Roles())
+
+		claims = issuer.claims("new-user")
+		u, err = um.GetUserByToken(ctx, issuer.sign(t, "ES256", "ec", claims))
+		require.NoError(t, err)
+		assert.Empty(t, u.Roles())
+		assert.Equal(t, []string{"read_only:*"}, store["test:new-user"].Roles())
+	})
+	t.Run("CustomClaims", func(t *testing.T) {
+		um, _ := newUserManager(t, UserManagerOptions{
+			UserIDClaim: "preferred_username",
+			NameClaim:   "nickname",
+		})
+
+		claims := issuer.claims("subject")
+		claims["preferred_username"] = "username"
+		claims["nickname"] = "Nick"
+		u, err := um.GetUserByToken(ctx, issuer.sign(t, "RS256", "rsa", claims))
+		require.NoError(t, err)
+		assert.Equal(t, issuer.userID("username"), u.Username())
+		assert.Equal(t, "Nick", u.DisplayName())
+	})
+	t.Run("JWKSFile", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "oidc")
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, os.RemoveAll(dir))
+		}()
+		file := filepath.Join(dir, "jwks.json")
+		require.NoError(t, ioutil.WriteFile(file, issuer.keySet(t), 0600))
+
+		before := atomic.LoadInt32(&issuer.requests)
+		um, _ := newUserManager(t, UserManagerOptions{JWKSFile: file})
+		u, err := um.GetUserByToken(ctx, issuer.sign(t, "ES256", "ec", issuer.claims("user")))
+		require.NoError(t, err)
+		assert.Equal(t, issuer.userID("user"), u.Username())
+		_, err = um.GetUserByToken(ctx, issuer.sign(t, "RS256", "unknown", issuer.claims("user")))
+		assert.Error(t, err)
+		assert.Equal(t, before, atomic.LoadInt32(&issuer.requests))
+	})
+	t.Run("UnknownKeyRefreshIsRateLimited", func(t *testing.T) {
+		um, _ := newUserManager(t, UserManagerOptions{})
+		before := atomic.LoadInt32(&issuer.requests)
+		for i := 0; i < 3; i++ {
+			_, err := um.GetUserByToken(ctx, issuer.sign(t, "RS256", "unknown", issuer.claims("user")))
+			assert.Error(t, err)
+		}
+		assert.EqualValues(t, 1, atomic.LoadInt32(&issuer.requests)-before)
+	})
+	t.Run("UnsupportedOperations", func(t *testing.T) {
+		um, store := newUserManager(t, UserManagerOptions{})
+		_, err := um.CreateUserToken("user", "password")
+		assert.Error(t, err)
+		assert.Nil(t, um.GetLoginHandler(""))
+		assert.False(t, um.IsRedirect())
+
+		store["user"] = &gimlet.BasicUser{ID: "user"}
+		u, err := um.GetUserByID("user")
+		require.NoError(t, err)
+		assert.Equal(t, "user", u.Username())
+	})
+}
+
+func TestParseBearerToken(t *testing.T) {
+	for header, expected := range map[string]string{
+		"Bearer token":   "token",
+		"bearer token":   "token",
+		"BEARER  token ": "token",
+		"Bearer ":        "",
+		"Basic token":    "",
+		"token":          "",
+		"":               "",
+	} {
+		token, ok := ParseBearerToken(header)
+		assert.Equal(t, expected, token, header)
+		assert.Equal(t, expected != "", ok, header)
+	}
+}
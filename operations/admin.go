@@ -4,7 +4,12 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"os/user"
+	"strings"
+	"time"
 
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest"
 	"github.com/evergreen-ci/certdepot"
 	"github.com/evergreen-ci/utility"
@@ -41,6 +46,48 @@ func Admin() cli.Command {
 				Subcommands: []cli.Command{
 					getUserCert(),
 					uploadCerts(),
+					{
+						Name:  "roles",
+						Usage: "manage user roles",
+						Subcommands: []cli.Command{
+							grantUserRole(),
+							revokeUserRole(),
+							grantRoleToAllUsers(),
+						},
+					},
+					{
+						Name:  "keys",
+						Usage: "manage user API keys",
+						Subcommands: []cli.Command{
+							listAPIKeys(),
+							createAPIKey(),
+							rotateAPIKey(),
+							expireAPIKey(),
+							revokeAPIKey(),
+							expireLegacyAPIKey(),
+							revokeLegacyAPIKey(),
+						},
+					},
+				},
+			},
+			migrateStorage(),
+			recomputeHistoricalTestData(),
+			{
+				Name:  "test-ownership",
+				Usage: "manage the teams owning the tests of a project",
+				Subcommands: []cli.Command{
+					getTestOwnership(),
+					setTestOwnership(),
+				},
+			},
+			{
+				Name:  "test-quarantine",
+				Usage: "manage the quarantined tests of a project",
+				Subcommands: []cli.Command{
+					listTestQuarantines(),
+					addTestQuarantine(),
+					removeTestQuarantine(),
+					testQuarantineHistory(),
 				},
 			},
 		},
@@ -278,3 +325,1058 @@ func uploadCerts() cli.Command {
 		},
 	}
 }
+
+const (
+	roleUserFlag    = "user"
+	roleNameFlag    = "role"
+	roleProjectFlag = "project"
+)
+
+func userRoleFlags(flags ...cli.Flag) []cli.Flag {
+	return dbFlags(append(flags,
+		cli.StringFlag{
+			Name:  roleUserFlag,
+			Usage: "specify the ID of the user",
+		},
+		cli.StringFlag{
+			Name:  roleNameFlag,
+			Usage: fmt.Sprintf("specify the role (%s, %s, or %s)", model.RoleAdmin, model.RoleProjectWriter, model.RoleReadOnly),
+		},
+		cli.StringFlag{
+			Name:  roleProjectFlag,
+			Usage: fmt.Sprintf("specify the project scope of the role, or '%s' for all projects", model.AllProjects),
+		},
+	)...)
+}
+
+func grantUserRole() cli.Command {
+	return cli.Command{
+		Name:   "grant",
+		Usage:  "grant a role to a user",
+		Flags:  userRoleFlags(),
+		Before: mergeBeforeFuncs(requireStringFlag(roleUserFlag), requireStringFlag(roleNameFlag)),
+		Action: func(c *cli.Context) error {
+			return modifyUserRole(c, true)
+		},
+	}
+}
+
+func revokeUserRole() cli.Command {
+	return cli.Command{
+		Name:   "revoke",
+		Usage:  "revoke a role from a user",
+		Flags:  userRoleFlags(),
+		Before: mergeBeforeFuncs(requireStringFlag(roleUserFlag), requireStringFlag(roleNameFlag)),
+		Action: func(c *cli.Context) error {
+			return modifyUserRole(c, false)
+		},
+	}
+}
+
+func grantRoleToAllUsers() cli.Command {
+	return cli.Command{
+		Name: "grant-all",
+		Usage: fmt.Sprintf("grant a role to every existing user, such as '%s' on '%s' before enabling the enforce_project_read_roles flag or '%s' on '%s' before enabling rpc user auth",
+			model.RoleReadOnly, model.AllProjects, model.RoleProjectWriter, model.AllProjects),
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  roleNameFlag,
+				Usage: fmt.Sprintf("specify the role (%s, %s, or %s)", model.RoleAdmin, model.RoleProjectWriter, model.RoleReadOnly),
+			},
+			cli.StringFlag{
+				Name:  roleProjectFlag,
+				Usage: fmt.Sprintf("specify the project scope of the role, or '%s' for all projects", model.AllProjects),
+			},
+		),
+		Before: requireStringFlag(roleNameFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			role := c.String(roleNameFlag)
+			project := c.String(roleProjectFlag)
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			updated, err := model.GrantRoleToAllUsers(cedar.GetEnvironment(), role, project)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			grip.Notice(message.Fields{
+				"op":      "granted role to all users",
+				"role":    model.FormatRole(role, project),
+				"updated": updated,
+			})
+
+			return nil
+		},
+	}
+}
+
+func modifyUserRole(c *cli.Context, grant bool) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	userID := c.String(roleUserFlag)
+	role := c.String(roleNameFlag)
+	project := c.String(roleProjectFlag)
+
+	sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+	sc.interactive = true
+	if err := sc.setup(ctx); err != nil {
+		return errors.WithStack(err)
+	}
+
+	u := &model.User{ID: userID}
+	u.Setup(cedar.GetEnvironment())
+	if err := u.Find(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	if grant {
+		if err := u.AddRole(role, project); err != nil {
+			return errors.WithStack(err)
+		}
+	} else {
+		if err := u.RemoveRole(role, project); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	grip.Notice(message.Fields{
+		"op":      "modified user role",
+		"user":    userID,
+		"role":    model.FormatRole(role, project),
+		"granted": grant,
+		"roles":   u.Roles(),
+	})
+
+	return nil
+}
+
+const (
+	apiKeyUserFlag        = "user"
+	apiKeyNameFlag        = "name"
+	apiKeyExpiresFlag     = "expires"
+	apiKeyGracePeriodFlag = "grace"
+)
+
+func apiKeyFlags(flags ...cli.Flag) []cli.Flag {
+	return dbFlags(append(flags,
+		cli.StringFlag{
+			Name:  apiKeyUserFlag,
+			Usage: "specify the ID of the user",
+		},
+		cli.StringFlag{
+			Name:  apiKeyNameFlag,
+			Usage: "specify the name of the API key",
+		},
+	)...)
+}
+
+func listAPIKeys() cli.Command {
+	return cli.Command{
+		Name:   "list",
+		Usage:  "list the named API keys of a user",
+		Flags:  dbFlags(cli.StringFlag{Name: apiKeyUserFlag, Usage: "specify the ID of the user"}),
+		Before: requireStringFlag(apiKeyUserFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			now := time.Now()
+			for _, key := range u.APIKeys {
+				grip.Notice(message.Fields{
+					"name":       key.Name,
+					"active":     key.IsActive(now),
+					"created_at": key.CreatedAt,
+					"rotated_at": key.RotatedAt,
+					"expires_at": key.ExpiresAt,
+					"revoked_at": key.RevokedAt,
+				})
+			}
+
+			return nil
+		},
+	}
+}
+
+func createAPIKey() cli.Command {
+	return cli.Command{
+		Name:  "create",
+		Usage: "create a named API key for a user and print it",
+		Flags: apiKeyFlags(
+			cli.DurationFlag{
+				Name:  apiKeyExpiresFlag,
+				Usage: "specify how long the key is valid, a zero value never expires",
+			},
+		),
+		Before: mergeBeforeFuncs(requireStringFlag(apiKeyUserFlag), requireStringFlag(apiKeyNameFlag)),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			name := c.String(apiKeyNameFlag)
+			expiresAt := apiKeyExpiration(c.Duration(apiKeyExpiresFlag))
+			key, err := u.CreateNamedAPIKey(name, expiresAt)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			recordAPIKeyAuditEvent(ctx, model.AuditActionAPIKeyCreate, u.ID, name, map[string]interface{}{
+				"expires_at": expiresAt,
+			})
+			fmt.Println(key)
+
+			return nil
+		},
+	}
+}
+
+func rotateAPIKey() cli.Command {
+	return cli.Command{
+		Name:  "rotate",
+		Usage: "replace a named API key of a user with a new key and print it",
+		Flags: apiKeyFlags(
+			cli.DurationFlag{
+				Name:  apiKeyExpiresFlag,
+				Usage: "specify how long the new key is valid, a zero value never expires",
+			},
+			cli.DurationFlag{
+				Name:  apiKeyGracePeriodFlag,
+				Usage: "specify how long the replaced key remains valid",
+			},
+		),
+		Before: mergeBeforeFuncs(requireStringFlag(apiKeyUserFlag), requireStringFlag(apiKeyNameFlag)),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			name := c.String(apiKeyNameFlag)
+			expiresAt := apiKeyExpiration(c.Duration(apiKeyExpiresFlag))
+			gracePeriod := c.Duration(apiKeyGracePeriodFlag)
+			key, err := u.RotateNamedAPIKey(name, expiresAt, gracePeriod)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			recordAPIKeyAuditEvent(ctx, model.AuditActionAPIKeyRotate, u.ID, name, map[string]interface{}{
+				"expires_at":   expiresAt,
+				"grace_period": gracePeriod.String(),
+			})
+			fmt.Println(key)
+
+			return nil
+		},
+	}
+}
+
+func expireAPIKey() cli.Command {
+	return cli.Command{
+		Name:  "expire",
+		Usage: "set the expiration of a named API key of a user",
+		Flags: apiKeyFlags(
+			cli.DurationFlag{
+				Name:  apiKeyExpiresFlag,
+				Usage: "specify how long until the key expires, a zero value expires the key immediately",
+			},
+		),
+		Before: mergeBeforeFuncs(requireStringFlag(apiKeyUserFlag), requireStringFlag(apiKeyNameFlag)),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			name := c.String(apiKeyNameFlag)
+			expiresAt := time.Now().Add(c.Duration(apiKeyExpiresFlag))
+			if err = u.ExpireNamedAPIKey(name, expiresAt); err != nil {
+				return errors.WithStack(err)
+			}
+
+			recordAPIKeyAuditEvent(ctx, model.AuditActionAPIKeyExpire, u.ID, name, map[string]interface{}{
+				"expires_at": expiresAt,
+			})
+			grip.Notice(message.Fields{
+				"op":         "expired API key",
+				"user":       u.ID,
+				"name":       name,
+				"expires_at": expiresAt,
+			})
+
+			return nil
+		},
+	}
+}
+
+func revokeAPIKey() cli.Command {
+	return cli.Command{
+		Name:   "revoke",
+		Usage:  "permanently revoke a named API key of a user",
+		Flags:  apiKeyFlags(),
+		Before: mergeBeforeFuncs(requireStringFlag(apiKeyUserFlag), requireStringFlag(apiKeyNameFlag)),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			name := c.String(apiKeyNameFlag)
+			if err = u.RevokeNamedAPIKey(name); err != nil {
+				return errors.WithStack(err)
+			}
+
+			recordAPIKeyAuditEvent(ctx, model.AuditActionAPIKeyRevoke, u.ID, name, nil)
+			grip.Notice(message.Fields{
+				"op":   "revoked API key",
+				"user": u.ID,
+				"name": name,
+			})
+
+			return nil
+		},
+	}
+}
+
+func expireLegacyAPIKey() cli.Command {
+	return cli.Command{
+		Name:  "expire-legacy",
+		Usage: "set the expiration of the legacy API key of a user",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  apiKeyUserFlag,
+				Usage: "specify the ID of the user",
+			},
+			cli.DurationFlag{
+				Name:  apiKeyExpiresFlag,
+				Usage: "specify how long until the key expires, a zero value expires the key immediately",
+			},
+		),
+		Before: requireStringFlag(apiKeyUserFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			expiresAt := time.Now().Add(c.Duration(apiKeyExpiresFlag))
+			if err = u.ExpireLegacyAPIKey(expiresAt); err != nil {
+				return errors.WithStack(err)
+			}
+
+			recordLegacyAPIKeyAuditEvent(ctx, model.AuditActionAPIKeyExpire, u.ID, map[string]interface{}{
+				"expires_at": expiresAt,
+			})
+			grip.Notice(message.Fields{
+				"op":         "expired legacy API key",
+				"user":       u.ID,
+				"expires_at": expiresAt,
+			})
+
+			return nil
+		},
+	}
+}
+
+func revokeLegacyAPIKey() cli.Command {
+	return cli.Command{
+		Name:   "revoke-legacy",
+		Usage:  "permanently revoke the legacy API key of a user",
+		Flags:  dbFlags(cli.StringFlag{Name: apiKeyUserFlag, Usage: "specify the ID of the user"}),
+		Before: requireStringFlag(apiKeyUserFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			u, err := findAPIKeyUser(ctx, c)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			if err = u.RevokeLegacyAPIKey(); err != nil {
+				return errors.WithStack(err)
+			}
+
+			recordLegacyAPIKeyAuditEvent(ctx, model.AuditActionAPIKeyRevoke, u.ID, nil)
+			grip.Notice(message.Fields{
+				"op":   "revoked legacy API key",
+				"user": u.ID,
+			})
+
+			return nil
+		},
+	}
+}
+
+func findAPIKeyUser(ctx context.Context, c *cli.Context) (*model.User, error) {
+	sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+	sc.interactive = true
+	if err := sc.setup(ctx); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	u := &model.User{ID: c.String(apiKeyUserFlag)}
+	u.Setup(cedar.GetEnvironment())
+	if err := u.Find(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return u, nil
+}
+
+func apiKeyExpiration(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+const (
+	migrateStorageIDFlag         = "id"
+	migrateStorageCollectionFlag = "collection"
+	migrateStorageFromFlag       = "from"
+	migrateStorageFromBucketFlag = "from-bucket"
+	migrateStorageToFlag         = "to"
+	migrateStorageToBucketFlag   = "to-bucket"
+	migrateStorageBatchSizeFlag  = "batch-size"
+	migrateStorageIterationsFlag = "iterations"
+	migrateStorageTimeoutFlag    = "timeout"
+	migrateStorageRateFlag       = "rate"
+	migrateStorageForegroundFlag = "foreground"
+)
+
+func migrateStorage() cli.Command {
+	return cli.Command{
+		Name:  "migrate-storage",
+		Usage: "migrate the artifacts of a collection from one pail backend to another",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  migrateStorageIDFlag,
+				Usage: "specify the ID of the migration, defaults to one derived from the collection and types",
+			},
+			cli.StringFlag{
+				Name:  migrateStorageCollectionFlag,
+				Usage: fmt.Sprintf("specify the collection to migrate (%s)", strings.Join(model.StorageMigrationCollections, ", ")),
+			},
+			cli.StringFlag{
+				Name:  migrateStorageFromFlag,
+				Usage: "specify the pail type to migrate from",
+			},
+			cli.StringFlag{
+				Name:  migrateStorageFromBucketFlag,
+				Usage: "specify the bucket to migrate performance artifacts from, defaults to any bucket",
+			},
+			cli.StringFlag{
+				Name:  migrateStorageToFlag,
+				Usage: "specify the pail type to migrate to",
+			},
+			cli.StringFlag{
+				Name:  migrateStorageToBucketFlag,
+				Usage: "specify the bucket to migrate performance artifacts to, defaults to their current bucket",
+			},
+			cli.IntFlag{
+				Name:  migrateStorageBatchSizeFlag,
+				Usage: "specify the number of documents migrated per batch",
+				Value: 100,
+			},
+			cli.IntFlag{
+				Name:  migrateStorageIterationsFlag,
+				Usage: "specify the number of batches migrated per job",
+				Value: 10,
+			},
+			cli.DurationFlag{
+				Name:  migrateStorageTimeoutFlag,
+				Usage: "specify the time limit of a migration job and of a claim on a document",
+				Value: 30 * time.Minute,
+			},
+			cli.Float64Flag{
+				Name:  migrateStorageRateFlag,
+				Usage: "specify the maximum number of objects copied per second, zero means no limit",
+			},
+			cli.BoolFlag{
+				Name:  migrateStorageForegroundFlag,
+				Usage: "run the migration in this process until it completes instead of in the service's background jobs",
+			},
+		),
+		Before: mergeBeforeFuncs(
+			requireStringFlag(migrateStorageCollectionFlag),
+			requireStringFlag(migrateStorageFromFlag),
+			requireStringFlag(migrateStorageToFlag),
+		),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			controller := &model.BatchJobController{
+				ID:         c.String(migrateStorageIDFlag),
+				Collection: c.String(migrateStorageCollectionFlag),
+				BatchSize:  c.Int(migrateStorageBatchSizeFlag),
+				Iterations: c.Int(migrateStorageIterationsFlag),
+				Timeout:    c.Duration(migrateStorageTimeoutFlag),
+				StorageMigration: &model.StorageMigrationOptions{
+					FromType:         model.PailType(c.String(migrateStorageFromFlag)),
+					FromBucket:       c.String(migrateStorageFromBucketFlag),
+					ToType:           model.PailType(c.String(migrateStorageToFlag)),
+					ToBucket:         c.String(migrateStorageToBucketFlag),
+					ObjectsPerSecond: c.Float64(migrateStorageRateFlag),
+				},
+			}
+			if controller.ID == "" {
+				controller.ID = fmt.Sprintf("storage-migration.%s.%s-%s", controller.Collection, controller.StorageMigration.FromType, controller.StorageMigration.ToType)
+			}
+			if err := controller.StorageMigration.Validate(controller.Collection); err != nil {
+				return errors.Wrap(err, "invalid storage migration")
+			}
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+			env := cedar.GetEnvironment()
+
+			if err := model.SaveBatchJobController(ctx, env, controller); err != nil {
+				return errors.WithStack(err)
+			}
+			recordCLIAuditEvent(ctx, model.NewAuditEvent(cliAuditUser(), model.AuditActionMigrateStorage, "storage/"+controller.ID, map[string]interface{}{
+				"collection": controller.Collection,
+				"migration":  controller.StorageMigration,
+			}))
+
+			if !c.Bool(migrateStorageForegroundFlag) {
+				grip.Notice(message.Fields{
+					"op":         "scheduled storage migration",
+					"controller": controller.ID,
+				})
+				return nil
+			}
+
+			return errors.WithStack(runStorageMigration(ctx, env, controller))
+		},
+	}
+}
+
+const (
+	recomputeHTDProjectFlag = "project"
+	recomputeHTDStartFlag   = "start"
+	recomputeHTDEndFlag     = "end"
+)
+
+func recomputeHistoricalTestData() cli.Command {
+	return cli.Command{
+		Name:  "recompute-historical-test-data",
+		Usage: "rebuild the historical test and task data of a project for a date range from the stored test results",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  recomputeHTDProjectFlag,
+				Usage: "specify the project to recompute",
+			},
+			cli.StringFlag{
+				Name:  recomputeHTDStartFlag,
+				Usage: "specify the first day to recompute (YYYY-MM-DD)",
+			},
+			cli.StringFlag{
+				Name:  recomputeHTDEndFlag,
+				Usage: "specify the last day to recompute (YYYY-MM-DD), defaults to today",
+			},
+		),
+		Before: mergeBeforeFuncs(
+			requireStringFlag(recomputeHTDProjectFlag),
+			requireStringFlag(recomputeHTDStartFlag),
+		),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			opts := model.RecomputeHistoricalTestDataOptions{Project: c.String(recomputeHTDProjectFlag)}
+			var err error
+			if opts.StartAt, err = time.ParseInLocation(model.HistoricalTestDataDateFormat, c.String(recomputeHTDStartFlag), time.UTC); err != nil {
+				return errors.Wrap(err, "parsing start date")
+			}
+			opts.EndAt = time.Now()
+			if end := c.String(recomputeHTDEndFlag); end != "" {
+				if opts.EndAt, err = time.ParseInLocation(model.HistoricalTestDataDateFormat, end, time.UTC); err != nil {
+					return errors.Wrap(err, "parsing end date")
+				}
+				opts.EndAt = opts.EndAt.Add(24 * time.Hour)
+			}
+			if err = opts.Validate(); err != nil {
+				return errors.Wrap(err, "invalid recompute options")
+			}
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err = sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+			env := cedar.GetEnvironment()
+
+			count, err := model.RecomputeHistoricalTestData(ctx, env, opts)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			recordCLIAuditEvent(ctx, model.NewAuditEvent(cliAuditUser(), model.AuditActionRecomputeHistoricalData, "project/"+opts.Project+"/historical_test_data", map[string]interface{}{
+				"start": opts.StartAt,
+				"end":   opts.EndAt,
+			}))
+			grip.Notice(message.Fields{
+				"op":        "recomputed historical test data",
+				"project":   opts.Project,
+				"start":     opts.StartAt,
+				"end":       opts.EndAt,
+				"documents": count,
+			})
+
+			return nil
+		},
+	}
+}
+
+const (
+	testOwnershipProjectFlag = "project"
+	testOwnershipRuleFlag    = "rule"
+)
+
+func getTestOwnership() cli.Command {
+	return cli.Command{
+		Name:   "get",
+		Usage:  "list the test ownership rules of a project",
+		Flags:  dbFlags(cli.StringFlag{Name: testOwnershipProjectFlag, Usage: "specify the project"}),
+		Before: requireStringFlag(testOwnershipProjectFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			project := c.String(testOwnershipProjectFlag)
+			ownership, err := model.FindTestOwnership(ctx, cedar.GetEnvironment(), project)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if ownership == nil {
+				grip.Noticef("project '%s' has no test ownership", project)
+				return nil
+			}
+
+			for _, rule := range ownership.Rules {
+				grip.Notice(message.Fields{
+					"pattern": rule.Pattern,
+					"type":    rule.Type,
+					"team":    rule.Team,
+				})
+			}
+			grip.Notice(message.Fields{
+				"project":    ownership.Project,
+				"updated_by": ownership.UpdatedBy,
+				"updated_at": ownership.UpdatedAt,
+			})
+
+			return nil
+		},
+	}
+}
+
+func setTestOwnership() cli.Command {
+	return cli.Command{
+		Name:  "set",
+		Usage: "replace the test ownership rules of a project, setting no rules removes them",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  testOwnershipProjectFlag,
+				Usage: "specify the project",
+			},
+			cli.StringSliceFlag{
+				Name:  testOwnershipRuleFlag,
+				Usage: "specify a rule as '[glob:|regex:]<pattern>=<team>', rules are matched in the order given",
+			},
+		),
+		Before: requireStringFlag(testOwnershipProjectFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			project := c.String(testOwnershipProjectFlag)
+			var rules []model.TestOwnershipRule
+			for _, val := range c.StringSlice(testOwnershipRuleFlag) {
+				rule, err := parseTestOwnershipRule(val)
+				if err != nil {
+					return errors.WithStack(err)
+				}
+				rules = append(rules, rule)
+			}
+			if _, err := model.NewTestOwnership(project, rules); err != nil {
+				return errors.WithStack(err)
+			}
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			author := cliAuditUser()
+			if _, err := model.SetTestOwnership(ctx, cedar.GetEnvironment(), project, author, rules); err != nil {
+				return errors.WithStack(err)
+			}
+			recordCLIAuditEvent(ctx, model.NewAuditEvent(author, model.AuditActionSetTestOwnership, "project/"+project+"/test_ownership", map[string]interface{}{
+				"rules": rules,
+			}))
+			grip.Notice(message.Fields{
+				"op":      "set test ownership",
+				"project": project,
+				"rules":   len(rules),
+			})
+
+			return nil
+		},
+	}
+}
+
+// parseTestOwnershipRule parses a rule of the form
+// '[glob:|regex:]<pattern>=<team>'. The pattern is split from the team at the
+// last '=', so patterns may contain '=' but team names may not.
+func parseTestOwnershipRule(val string) (model.TestOwnershipRule, error) {
+	idx := strings.LastIndex(val, "=")
+	if idx <= 0 || idx == len(val)-1 {
+		return model.TestOwnershipRule{}, errors.Errorf("rule '%s' must be of the form '[glob:|regex:]<pattern>=<team>'", val)
+	}
+
+	rule := model.TestOwnershipRule{
+		Pattern: val[:idx],
+		Type:    model.TestOwnershipRuleGlob,
+		Team:    val[idx+1:],
+	}
+	for _, ruleType := range []model.TestOwnershipRuleType{model.TestOwnershipRuleGlob, model.TestOwnershipRuleRegex} {
+		if prefix := string(ruleType) + ":"; strings.HasPrefix(rule.Pattern, prefix) {
+			rule.Pattern = strings.TrimPrefix(rule.Pattern, prefix)
+			rule.Type = ruleType
+			break
+		}
+	}
+
+	return rule, nil
+}
+
+const (
+	testQuarantineProjectFlag = "project"
+	testQuarantineTestFlag    = "test"
+	testQuarantineReasonFlag  = "reason"
+	testQuarantineExpiresFlag = "expires"
+	testQuarantineLimitFlag   = "limit"
+)
+
+func listTestQuarantines() cli.Command {
+	return cli.Command{
+		Name:   "list",
+		Usage:  "list the actively quarantined tests of a project",
+		Flags:  dbFlags(cli.StringFlag{Name: testQuarantineProjectFlag, Usage: "specify the project"}),
+		Before: requireStringFlag(testQuarantineProjectFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			quarantines, err := model.FindTestQuarantines(ctx, cedar.GetEnvironment(), c.String(testQuarantineProjectFlag))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			for _, q := range quarantines {
+				grip.Notice(message.Fields{
+					"test_name":  q.TestName,
+					"reason":     q.Reason,
+					"author":     q.Author,
+					"created_at": q.CreatedAt,
+					"expires_at": q.ExpiresAt,
+				})
+			}
+
+			return nil
+		},
+	}
+}
+
+func addTestQuarantine() cli.Command {
+	return cli.Command{
+		Name:  "add",
+		Usage: "quarantine a test of a project, replacing any existing quarantine of the test",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  testQuarantineProjectFlag,
+				Usage: "specify the project",
+			},
+			cli.StringFlag{
+				Name:  testQuarantineTestFlag,
+				Usage: "specify the display name of the test",
+			},
+			cli.StringFlag{
+				Name:  testQuarantineReasonFlag,
+				Usage: "specify why the test is quarantined",
+			},
+			cli.DurationFlag{
+				Name:  testQuarantineExpiresFlag,
+				Usage: "specify how long the test is quarantined",
+				Value: 14 * 24 * time.Hour,
+			},
+		),
+		Before: mergeBeforeFuncs(
+			requireStringFlag(testQuarantineProjectFlag),
+			requireStringFlag(testQuarantineTestFlag),
+			requireStringFlag(testQuarantineReasonFlag),
+		),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			author := cliAuditUser()
+			quarantine, err := model.NewTestQuarantine(
+				c.String(testQuarantineProjectFlag),
+				c.String(testQuarantineTestFlag),
+				c.String(testQuarantineReasonFlag),
+				author,
+				time.Now().Add(c.Duration(testQuarantineExpiresFlag)),
+			)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err = sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			before, err := quarantine.Save(ctx, cedar.GetEnvironment())
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			event := model.NewAuditEvent(author, model.AuditActionAddTestQuarantine, model.TestQuarantineAuditTarget(quarantine.Project), map[string]interface{}{
+				"test_name":  quarantine.TestName,
+				"reason":     quarantine.Reason,
+				"expires_at": quarantine.ExpiresAt,
+			})
+			if before != nil {
+				event.Before = before
+			}
+			event.After = quarantine
+			recordCLIAuditEvent(ctx, event)
+			grip.Notice(message.Fields{
+				"op":         "quarantined test",
+				"project":    quarantine.Project,
+				"test_name":  quarantine.TestName,
+				"expires_at": quarantine.ExpiresAt,
+			})
+
+			return nil
+		},
+	}
+}
+
+func removeTestQuarantine() cli.Command {
+	return cli.Command{
+		Name:  "remove",
+		Usage: "remove the quarantine of a test of a project",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  testQuarantineProjectFlag,
+				Usage: "specify the project",
+			},
+			cli.StringFlag{
+				Name:  testQuarantineTestFlag,
+				Usage: "specify the display name of the test",
+			},
+		),
+		Before: mergeBeforeFuncs(
+			requireStringFlag(testQuarantineProjectFlag),
+			requireStringFlag(testQuarantineTestFlag),
+		),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			project := c.String(testQuarantineProjectFlag)
+			testName := c.String(testQuarantineTestFlag)
+			removed, err := model.RemoveTestQuarantine(ctx, cedar.GetEnvironment(), project, testName)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if removed == nil {
+				return errors.Errorf("test '%s' is not quarantined in project '%s'", testName, project)
+			}
+			event := model.NewAuditEvent(cliAuditUser(), model.AuditActionRemoveTestQuarantine, model.TestQuarantineAuditTarget(project), map[string]interface{}{
+				"test_name": testName,
+			})
+			event.Before = removed
+			recordCLIAuditEvent(ctx, event)
+			grip.Notice(message.Fields{
+				"op":        "removed test quarantine",
+				"project":   project,
+				"test_name": testName,
+			})
+
+			return nil
+		},
+	}
+}
+
+func testQuarantineHistory() cli.Command {
+	return cli.Command{
+		Name:  "history",
+		Usage: "list the changes to the quarantined tests of a project, most recent first",
+		Flags: dbFlags(
+			cli.StringFlag{
+				Name:  testQuarantineProjectFlag,
+				Usage: "specify the project",
+			},
+			cli.IntFlag{
+				Name:  testQuarantineLimitFlag,
+				Usage: "specify the maximum number of changes to list",
+			},
+		),
+		Before: requireStringFlag(testQuarantineProjectFlag),
+		Action: func(c *cli.Context) error {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			sc := newServiceConf(2, true, c.String(dbURIFlag), "", c.String(dbNameFlag), c.String(dbCredsFileFlag))
+			sc.interactive = true
+			if err := sc.setup(ctx); err != nil {
+				return errors.WithStack(err)
+			}
+
+			events, err := model.FindTestQuarantineHistory(ctx, cedar.GetEnvironment(), c.String(testQuarantineProjectFlag), c.Int(testQuarantineLimitFlag))
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			for _, event := range events {
+				grip.Notice(message.Fields{
+					"timestamp":  event.Timestamp,
+					"user":       event.User,
+					"action":     event.Action,
+					"parameters": event.Parameters,
+				})
+			}
+
+			return nil
+		},
+	}
+}
+
+// runStorageMigration migrates batches until no documents remain to migrate
+// and then marks the controller as completed. Documents that fail to migrate
+// are logged and skipped.
+func runStorageMigration(ctx context.Context, env cedar.Environment, controller *model.BatchJobController) error {
+	migrator, err := model.NewStorageMigrator(env, controller)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	total, totalFailed := 0, 0
+	for {
+		migrated, failed, err := migrator.MigrateBatch(ctx)
+		total += migrated
+		totalFailed += failed
+		grip.Info(message.Fields{
+			"op":           "migrated storage batch",
+			"controller":   controller.ID,
+			"documents":    migrated,
+			"failed":       failed,
+			"total":        total,
+			"total_failed": totalFailed,
+		})
+		if err != nil {
+			return errors.Wrap(err, "migrating batch")
+		}
+		if migrated+failed == 0 {
+			break
+		}
+	}
+
+	remaining, err := migrator.Remaining(ctx)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if remaining {
+		return errors.Errorf("documents remain to migrate (%d failed), rerun the migration once the claims on them time out after %s", totalFailed, controller.Timeout)
+	}
+
+	return errors.WithStack(model.CompleteBatchJobController(ctx, env, controller.ID))
+}
+
+func recordAPIKeyAuditEvent(ctx context.Context, action, userID, name string, params map[string]interface{}) {
+	recordCLIAuditEvent(ctx, model.NewAuditEvent(cliAuditUser(), action, fmt.Sprintf("user/%s/keys/%s", userID, name), params))
+}
+
+func recordLegacyAPIKeyAuditEvent(ctx context.Context, action, userID string, params map[string]interface{}) {
+	recordCLIAuditEvent(ctx, model.NewAuditEvent(cliAuditUser(), action, fmt.Sprintf("user/%s/legacy_key", userID), params))
+}
+
+// cliAuditUser returns the user recorded in the audit events of changes made
+// from the command line, which bypass user authentication.
+func cliAuditUser() string {
+	if u, err := user.Current(); err == nil {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+// recordCLIAuditEvent saves the audit event, keeping it for the configured
+// audit retention period. Failing to save the event is logged but does not
+// fail the command, since the action has already been taken.
+func recordCLIAuditEvent(ctx context.Context, event *model.AuditEvent) {
+	env := cedar.GetEnvironment()
+	conf := model.NewCedarConfig(env)
+	if err := conf.Find(); err == nil {
+		event.SetTTL(conf.Retention.AuditTTL())
+	}
+	event.Setup(env)
+	grip.Warning(message.WrapError(event.Save(ctx), message.Fields{
+		"message": "could not record audit event",
+		"action":  event.Action,
+		"target":  event.Target,
+	}))
+}
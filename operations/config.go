@@ -1,7 +1,11 @@
 package operations
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
@@ -79,13 +83,58 @@ func loadCedarConfig() cli.Command {
 			}
 			env := cedar.GetEnvironment()
 
+			existing := &model.CedarConfig{}
+			existing.Setup(env)
+			if err = existing.Find(); err != nil {
+				existing = nil
+			}
+
 			conf.Setup(env)
 			if err = conf.Save(); err != nil {
 				return errors.WithStack(err)
 			}
 
+			event := model.NewAuditEvent(cliAuditUser(), model.AuditActionLoadConfig, "config", map[string]interface{}{
+				"file": fileName,
+			})
+			event.After = map[string]interface{}{"changed_sections": changedConfigSections(existing, conf)}
+			recordCLIAuditEvent(ctx, event)
+
 			grip.Infoln("successfully application configuration to DB at:", mongodbURI)
 			return nil
 		},
 	}
 }
+
+// changedConfigSections returns the names of the top-level sections that
+// differ between the two configurations. Only the names are returned since
+// the configuration contains credentials. If there is no existing
+// configuration, all sections are considered changed.
+func changedConfigSections(before, after *model.CedarConfig) []string {
+	changed := []string{}
+
+	afterVal := reflect.ValueOf(after).Elem()
+	configType := afterVal.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.PkgPath != "" || name == "" || name == "id" {
+			continue
+		}
+
+		if before == nil {
+			changed = append(changed, name)
+			continue
+		}
+
+		// Compare the serialized sections so that unexported state,
+		// such as the environment, is ignored.
+		beforeSection, beforeErr := json.Marshal(reflect.ValueOf(before).Elem().Field(i).Interface())
+		afterSection, afterErr := json.Marshal(afterVal.Field(i).Interface())
+		if beforeErr != nil || afterErr != nil || !bytes.Equal(beforeSection, afterSection) {
+			changed = append(changed, name)
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,23 @@
+package operations
+
+import (
+	"testing"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedConfigSections(t *testing.T) {
+	before := &model.CedarConfig{URL: "https://cedar.example.com"}
+	after := &model.CedarConfig{URL: "https://cedar.example.com"}
+	assert.Empty(t, changedConfigSections(before, after))
+
+	after.Bucket.AWSSecret = "secret"
+	after.Flags.DisableSignalProcessing = true
+	assert.Equal(t, []string{"bucket", "flags"}, changedConfigSections(before, after))
+
+	sections := changedConfigSections(nil, after)
+	assert.Contains(t, sections, "url")
+	assert.Contains(t, sections, "bucket")
+	assert.NotContains(t, sections, "id")
+}
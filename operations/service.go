@@ -2,15 +2,18 @@ package operations
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest"
 	"github.com/evergreen-ci/cedar/rpc"
+	"github.com/evergreen-ci/cedar/tracing"
 	"github.com/evergreen-ci/cedar/units"
 	"github.com/evergreen-ci/certdepot"
 	"github.com/evergreen-ci/gimlet"
@@ -49,7 +52,7 @@ func Service() cli.Command {
 				},
 				cli.BoolFlag{
 					Name:  rpcUserAuthFlag,
-					Usage: "specify whether to enable user auth over rpc",
+					Usage: "specify whether to enable user auth over rpc, which requires the project_writer role on the project of each record written",
 				},
 				cli.BoolFlag{
 					Name:  localQueueFlag,
@@ -107,6 +110,11 @@ func Service() cli.Command {
 				return errors.Wrap(err, "getting application configuration")
 			}
 
+			if err := setupTracing(ctx, env, conf.Tracing); err != nil {
+				return errors.WithStack(err)
+			}
+			env.SetIngestionStatsTTL(conf.Retention.IngestionStatsTTL())
+
 			var d certdepot.Depot
 			var err error
 			if rpcTLS {
@@ -158,6 +166,11 @@ func Service() cli.Command {
 				CAName:      conf.CA.CertDepot.CAName,
 				ServiceName: conf.CA.CertDepot.ServiceName,
 				UserManager: service.UserManager,
+				IsRevoked: func(crt *x509.Certificate) (bool, error) {
+					ctx, cancel := env.Context()
+					defer cancel()
+					return model.IsCertificateRevoked(ctx, env, crt)
+				},
 			})
 			if err != nil {
 				return errors.WithStack(err)
@@ -195,6 +208,40 @@ func Service() cli.Command {
 	}
 }
 
+// setupTracing configures the trace exporter from the application
+// configuration and registers its shutdown with the environment so that the
+// remaining spans are exported on exit.
+func setupTracing(ctx context.Context, env cedar.Environment, conf model.TracingConfig) error {
+	if !conf.IsEnabled() {
+		return nil
+	}
+
+	var exporter tracing.Exporter
+	var err error
+	if conf.OTLPEndpoint != "" {
+		exporter, err = tracing.NewOTLPExporter(ctx, tracing.OTLPExporterOptions{
+			Endpoint: conf.OTLPEndpoint,
+			Headers:  conf.OTLPHeaders,
+		})
+	} else {
+		exporter, err = tracing.NewFileExporter(conf.FilePath)
+	}
+	if err != nil {
+		return errors.Wrap(err, "creating trace exporter")
+	}
+
+	if err = tracing.Configure(tracing.Options{
+		Exporter:    exporter,
+		ServiceName: conf.ServiceName,
+		SampleRatio: conf.SampleRatio,
+	}); err != nil {
+		return errors.Wrap(err, "configuring tracing")
+	}
+	env.RegisterCloser("tracing", tracing.Shutdown)
+
+	return nil
+}
+
 func getAdminService(env cedar.Environment) (*gimlet.APIApp, error) {
 	app := gimlet.NewApp()
 
@@ -204,6 +251,7 @@ func getAdminService(env cedar.Environment) (*gimlet.APIApp, error) {
 	app.NoVersions = true
 
 	app.AddMiddleware(gimlet.MakeRecoveryLogger())
+	app.AddRoute("/metrics").Version(1).Get().Handler(metrics.Handler().ServeHTTP)
 
 	err := app.Merge(gimlet.GetPProfApp(), amboyRest.NewManagementService(env.GetRemoteManager()).App())
 	if err != nil {
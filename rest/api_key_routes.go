@@ -0,0 +1,333 @@
+package rest
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+)
+
+// APIKeyRequest is the payload for creating, rotating, and expiring named
+// API keys.
+type APIKeyRequest struct {
+	// Name is the name of the key to create.
+	Name string `json:"name,omitempty"`
+	// ExpiresAt is the expiration time of the created or rotated key. When
+	// expiring a key, a zero value expires the key immediately. Otherwise, a
+	// zero value means the key never expires.
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	// GracePeriodSecs is the number of seconds the replaced key remains
+	// valid after a rotation.
+	GracePeriodSecs int `json:"grace_period_secs,omitempty"`
+}
+
+// APIKeyResponse is the response for modifying a named API key. The key is
+// only returned when it is created or rotated.
+type APIKeyResponse struct {
+	User   string       `json:"user"`
+	Key    string       `json:"key,omitempty"`
+	APIKey model.APIKey `json:"api_key"`
+}
+
+// APIKeyListResponse is the response for listing a user's named API keys,
+// including expired and revoked keys.
+type APIKeyListResponse struct {
+	User    string         `json:"user"`
+	APIKeys []model.APIKey `json:"api_keys"`
+}
+
+// LegacyAPIKeyResponse is the response for expiring or revoking a user's
+// legacy API key.
+type LegacyAPIKeyResponse struct {
+	User      string    `json:"user"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /admin/users/{user_id}/keys
+
+func (s *Service) listAPIKeys(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	out := &APIKeyListResponse{
+		User:    u.ID,
+		APIKeys: u.APIKeys,
+	}
+	if out.APIKeys == nil {
+		out.APIKeys = []model.APIKey{}
+	}
+
+	gimlet.WriteJSON(rw, out)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/keys
+
+func (s *Service) createAPIKey(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	req, err := readAPIKeyRequest(r)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+
+	setAuditTarget(r.Context(), apiKeyAuditTarget(u.ID, req.Name))
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"name":       req.Name,
+		"expires_at": req.ExpiresAt,
+	})
+
+	key, err := u.CreateNamedAPIKey(req.Name, req.ExpiresAt)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, APIKeyResponse{
+		User:   u.ID,
+		Key:    key,
+		APIKey: *u.GetNamedAPIKey(req.Name),
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/keys/{name}/rotate
+
+func (s *Service) rotateAPIKey(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	req, err := readAPIKeyRequest(r)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+
+	name := gimlet.GetVars(r)["name"]
+	gracePeriod := time.Duration(req.GracePeriodSecs) * time.Second
+	setAuditTarget(r.Context(), apiKeyAuditTarget(u.ID, name))
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"expires_at":   req.ExpiresAt,
+		"grace_period": gracePeriod.String(),
+	})
+
+	key, err := u.RotateNamedAPIKey(name, req.ExpiresAt, gracePeriod)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, APIKeyResponse{
+		User:   u.ID,
+		Key:    key,
+		APIKey: *u.GetNamedAPIKey(name),
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/keys/{name}/expire
+
+func (s *Service) expireAPIKey(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	req, err := readAPIKeyRequest(r)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+	if req.ExpiresAt.IsZero() {
+		req.ExpiresAt = time.Now()
+	}
+
+	name := gimlet.GetVars(r)["name"]
+	setAuditTarget(r.Context(), apiKeyAuditTarget(u.ID, name))
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"expires_at": req.ExpiresAt,
+	})
+
+	if err = u.ExpireNamedAPIKey(name, req.ExpiresAt); err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, APIKeyResponse{
+		User:   u.ID,
+		APIKey: *u.GetNamedAPIKey(name),
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/keys/{name}/revoke
+
+func (s *Service) revokeAPIKey(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	name := gimlet.GetVars(r)["name"]
+	setAuditTarget(r.Context(), apiKeyAuditTarget(u.ID, name))
+
+	if err := u.RevokeNamedAPIKey(name); err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, APIKeyResponse{
+		User:   u.ID,
+		APIKey: *u.GetNamedAPIKey(name),
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/legacy_key/expire
+
+func (s *Service) expireLegacyAPIKey(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	req, err := readAPIKeyRequest(r)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+	if req.ExpiresAt.IsZero() {
+		req.ExpiresAt = time.Now()
+	}
+
+	setAuditTarget(r.Context(), legacyAPIKeyAuditTarget(u.ID))
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"expires_at": req.ExpiresAt,
+	})
+
+	if err = u.ExpireLegacyAPIKey(req.ExpiresAt); err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, LegacyAPIKeyResponse{
+		User:      u.ID,
+		ExpiresAt: u.APIKeyExpiresAt,
+		RevokedAt: u.APIKeyRevokedAt,
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/legacy_key/revoke
+
+func (s *Service) revokeLegacyAPIKey(rw http.ResponseWriter, r *http.Request) {
+	u, resp := s.findAPIKeyUser(r)
+	if resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	setAuditTarget(r.Context(), legacyAPIKeyAuditTarget(u.ID))
+
+	if err := u.RevokeLegacyAPIKey(); err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, LegacyAPIKeyResponse{
+		User:      u.ID,
+		ExpiresAt: u.APIKeyExpiresAt,
+		RevokedAt: u.APIKeyRevokedAt,
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// helper functions
+
+func (s *Service) findAPIKeyUser(r *http.Request) (*model.User, gimlet.Responder) {
+	userID := gimlet.GetVars(r)["user_id"]
+
+	u := &model.User{ID: userID}
+	u.Setup(s.Environment)
+	if err := u.Find(); err != nil {
+		if db.ResultsNotFound(err) {
+			return nil, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusNotFound,
+				Message:    fmt.Sprintf("user '%s' not found", userID),
+			})
+		}
+		return nil, gimlet.MakeJSONInternalErrorResponder(errors.Wrapf(err, "finding user '%s'", userID))
+	}
+
+	return u, nil
+}
+
+func readAPIKeyRequest(r *http.Request) (*APIKeyRequest, error) {
+	req := &APIKeyRequest{}
+	if err := gimlet.GetJSON(r.Body, req); err != nil && errors.Cause(err) != io.EOF {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading API key request").Error(),
+		}
+	}
+
+	return req, nil
+}
+
+func apiKeyAuditTarget(userID, name string) string {
+	return fmt.Sprintf("user/%s/keys/%s", userID, name)
+}
+
+func legacyAPIKeyAuditTarget(userID string) string {
+	return fmt.Sprintf("user/%s/legacy_key", userID)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/evergreen-ci/cedar/rest/data"
 	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
@@ -23,6 +24,7 @@ const (
 	printPriority = "print_priority"
 	limit         = "limit"
 	paginate      = "paginate"
+	byLine        = "by_line"
 	trueString    = "true"
 	softSizeLimit = 10 * 1024 * 1024
 )
@@ -577,6 +579,89 @@ func (h *logGroupByTestNameHandler) Run(ctx context.Context) gimlet.Responder {
 	return newBuildloggerResponder(h.sc.GetBaseURL(), data, h.opts.TimeRange.StartAt, next, paginated)
 }
 
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/test_result/{task_id}/{test_name}
+//
+// Returns the lines of the task-level logs belonging to the test result,
+// either those logged between the test's start and end times or, if by_line
+// is true, those between the test's line number and the next test's line
+// number. Line numbers are counted over a single task-level log, so proc_name
+// is required in by_line mode when the task has more than one task-level log.
+
+type logGetByTestResultHandler struct {
+	opts data.TestResultLogOptions
+	sc   data.Connector
+}
+
+func makeGetLogByTestResult(sc data.Connector) gimlet.RouteHandler {
+	return &logGetByTestResultHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logGetByTestResultHandler.
+func (h *logGetByTestResultHandler) Factory() gimlet.RouteHandler {
+	return &logGetByTestResultHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID, test name, execution, and parameters from the
+// HTTP request.
+func (h *logGetByTestResultHandler) Parse(_ context.Context, r *http.Request) error {
+	h.opts.TaskID = gimlet.GetVars(r)["task_id"]
+	h.opts.TestName = gimlet.GetVars(r)["test_name"]
+	vals := r.URL.Query()
+	h.opts.ProcessName = vals.Get(procName)
+	h.opts.ByLine = vals.Get(byLine) == trueString
+	h.opts.PrintTime = vals.Get(printTime) == trueString
+	h.opts.PrintPriority = vals.Get(printPriority) == trueString
+	if len(vals[execution]) > 0 {
+		exec, err := strconv.Atoi(vals[execution][0])
+		if err != nil {
+			return gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing execution '%s'", vals[execution][0]).Error(),
+			}
+		}
+		h.opts.Execution = utility.ToIntPtr(exec)
+	}
+	if start := vals.Get(logStartAt); start != "" {
+		startAt, err := time.ParseInLocation(time.RFC3339Nano, start, time.UTC)
+		if err != nil {
+			return gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing start time '%s'", start).Error(),
+			}
+		}
+		h.opts.StartAt = startAt
+	}
+	if vals.Get(paginate) == trueString {
+		h.opts.SoftSizeLimit = softSizeLimit
+	}
+
+	return nil
+}
+
+// Run calls FindTestResultLog and returns the log lines.
+func (h *logGetByTestResultHandler) Run(ctx context.Context) gimlet.Responder {
+	data, next, paginated, err := h.sc.FindTestResultLog(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting log by test result '%s'", h.opts.TestName)
+		logFindError(err, message.Fields{
+			"request":   gimlet.GetRequestID(ctx),
+			"method":    "GET",
+			"route":     "/buildlogger/test_result/{task_id}/{test_name}",
+			"task_id":   h.opts.TaskID,
+			"test_name": h.opts.TestName,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return newBuildloggerResponder(h.sc.GetBaseURL(), data, h.opts.StartAt, next, paginated)
+}
+
 func newBuildloggerResponder(baseURL string, data []byte, last, next time.Time, paginated bool) gimlet.Responder {
 	resp := gimlet.NewTextResponse(data)
 
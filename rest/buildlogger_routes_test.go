@@ -14,6 +14,7 @@ import (
 	"github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/pail"
+	"github.com/evergreen-ci/utility"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
@@ -133,6 +134,21 @@ func (s *LogHandlerSuite) setup(tempDir string) {
 					Prefix: "pqr",
 				},
 			},
+			"stu": {
+				ID: "stu",
+				Info: dbModel.LogInfo{
+					Project:     "project",
+					TaskID:      "task_id3",
+					ProcessName: "task",
+					Mainline:    true,
+				},
+				CreatedAt:   time.Now().Add(-2 * time.Hour),
+				CompletedAt: time.Now(),
+				Artifact: dbModel.LogArtifactInfo{
+					Type:   dbModel.PailLocal,
+					Prefix: "stu",
+				},
+			},
 		},
 	}
 	s.rh = map[string]gimlet.RouteHandler{
@@ -144,6 +160,7 @@ func (s *LogHandlerSuite) setup(tempDir string) {
 		"test_name":       makeGetLogByTestName(&s.sc),
 		"meta_test_name":  makeGetLogMetaByTestName(&s.sc),
 		"group_test_name": makeGetLogGroupByTestName(&s.sc),
+		"test_result":     makeGetLogByTestResult(&s.sc),
 	}
 	s.apiResults = map[string]model.APILog{}
 	s.buckets = map[string]pail.Bucket{}
@@ -163,6 +180,22 @@ func (s *LogHandlerSuite) setup(tempDir string) {
 		s.Require().NoError(apiResult.Import(val))
 		s.apiResults[key] = apiResult
 	}
+	s.sc.CachedTestResultsData = map[string][]dbModel.TestResult{
+		"task_id3": {
+			{
+				TaskID:        "task_id3",
+				TestName:      "test1",
+				LineNum:       5,
+				TestStartTime: s.sc.CachedLogs["stu"].Artifact.Chunks[0].Start,
+				TestEndTime:   s.sc.CachedLogs["stu"].Artifact.Chunks[1].End,
+			},
+			{
+				TaskID:   "task_id3",
+				TestName: "test2",
+				LineNum:  15,
+			},
+		},
+	}
 }
 
 func TestLogHandlerSuite(t *testing.T) {
@@ -1079,6 +1112,222 @@ func (s *LogHandlerSuite) TestLogGroupByTestNameHandlerCtxErr() {
 	s.NotEqual(http.StatusOK, resp.Status())
 }
 
+func (s *LogHandlerSuite) TestLogGetByTestResultHandlerFound() {
+	for _, printTime := range []bool{true, false} {
+		rh := s.rh["test_result"].Factory()
+		rh.(*logGetByTestResultHandler).opts.TaskID = "task_id3"
+		rh.(*logGetByTestResultHandler).opts.TestName = "test1"
+		rh.(*logGetByTestResultHandler).opts.PrintTime = printTime
+		rh.(*logGetByTestResultHandler).opts.PrintPriority = !printTime
+		if printTime {
+			rh.(*logGetByTestResultHandler).opts.SoftSizeLimit = softSizeLimit
+		}
+		chunks := s.sc.CachedLogs["stu"].Artifact.Chunks
+		r := dbModel.NewLogIteratorReader(
+			context.TODO(),
+			dbModel.NewBatchedLogIterator(
+				s.buckets["stu"],
+				chunks,
+				batchSize,
+				dbModel.TimeRange{StartAt: chunks[0].Start, EndAt: chunks[1].End},
+			),
+			dbModel.LogIteratorReaderOptions{
+				PrintTime:     printTime,
+				PrintPriority: !printTime,
+			},
+		)
+		expected, err := ioutil.ReadAll(r)
+		s.Require().NoError(err)
+
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Equal(http.StatusOK, resp.Status())
+		s.Equal(expected, resp.Data())
+		pages := resp.Pages()
+		if rh.(*logGetByTestResultHandler).opts.SoftSizeLimit > 0 {
+			s.Require().NotNil(pages)
+			s.Nil(pages.Next)
+		} else {
+			s.Nil(pages)
+		}
+
+		// by line
+		rh.(*logGetByTestResultHandler).opts.ByLine = true
+		it := dbModel.NewBatchedLogIterator(
+			s.buckets["stu"],
+			chunks,
+			batchSize,
+			dbModel.TimeRange{StartAt: chunks[0].Start, EndAt: chunks[len(chunks)-1].End},
+		)
+		for i := 1; i < 5; i++ {
+			s.Require().True(it.Next(context.TODO()))
+		}
+		r = dbModel.NewLogIteratorReader(
+			context.TODO(),
+			it,
+			dbModel.LogIteratorReaderOptions{
+				PrintTime:     printTime,
+				PrintPriority: !printTime,
+				Limit:         10,
+			},
+		)
+		expected, err = ioutil.ReadAll(r)
+		s.Require().NoError(err)
+
+		resp = rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Equal(http.StatusOK, resp.Status())
+		s.Equal(expected, resp.Data())
+		pages = resp.Pages()
+		if rh.(*logGetByTestResultHandler).opts.SoftSizeLimit > 0 {
+			s.Require().NotNil(pages)
+			s.Nil(pages.Next)
+		} else {
+			s.Nil(pages)
+		}
+	}
+}
+
+func (s *LogHandlerSuite) TestLogGetByTestResultHandlerPaginated() {
+	for _, testByLine := range []bool{true, false} {
+		rh := s.rh["test_result"].Factory()
+		rh.(*logGetByTestResultHandler).opts.TaskID = "task_id3"
+		rh.(*logGetByTestResultHandler).opts.TestName = "test1"
+		rh.(*logGetByTestResultHandler).opts.ByLine = testByLine
+		// Each generated line is 101 bytes, so each page has 5 lines.
+		rh.(*logGetByTestResultHandler).opts.SoftSizeLimit = 500
+
+		var data []byte
+		for {
+			resp := rh.Run(context.TODO())
+			s.Require().NotNil(resp)
+			s.Require().Equal(http.StatusOK, resp.Status())
+			data = append(data, resp.Data().([]byte)...)
+			pages := resp.Pages()
+			s.Require().NotNil(pages)
+			if pages.Next == nil {
+				break
+			}
+
+			next, err := time.Parse(time.RFC3339Nano, pages.Next.Key)
+			s.Require().NoError(err)
+			s.Require().True(next.After(rh.(*logGetByTestResultHandler).opts.StartAt))
+			rh.(*logGetByTestResultHandler).opts.StartAt = next
+		}
+
+		expectedLines := 20
+		if testByLine {
+			expectedLines = 10
+		}
+		s.Len(data, expectedLines*101)
+	}
+}
+
+func (s *LogHandlerSuite) TestLogGetByTestResultHandlerNotFound() {
+	for _, test := range []struct {
+		name     string
+		taskID   string
+		testName string
+		procName string
+	}{
+		{
+			name:     "TaskDNE",
+			taskID:   "DNE",
+			testName: "test1",
+		},
+		{
+			name:     "TestDNE",
+			taskID:   "task_id3",
+			testName: "DNE",
+		},
+		{
+			name:     "LogDNE",
+			taskID:   "task_id3",
+			testName: "test1",
+			procName: "DNE",
+		},
+	} {
+		s.T().Run(test.name, func(t *testing.T) {
+			rh := s.rh["test_result"].Factory()
+			rh.(*logGetByTestResultHandler).opts.TaskID = test.taskID
+			rh.(*logGetByTestResultHandler).opts.TestName = test.testName
+			rh.(*logGetByTestResultHandler).opts.ProcessName = test.procName
+
+			resp := rh.Run(context.TODO())
+			s.Require().NotNil(resp)
+			s.Equal(http.StatusNotFound, resp.Status())
+		})
+	}
+}
+
+func (s *LogHandlerSuite) TestLogGetByTestResultHandlerBadRequest() {
+	s.T().Run("NoTimes", func(t *testing.T) {
+		rh := s.rh["test_result"].Factory()
+		rh.(*logGetByTestResultHandler).opts.TaskID = "task_id3"
+		rh.(*logGetByTestResultHandler).opts.TestName = "test2"
+
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Equal(http.StatusBadRequest, resp.Status())
+	})
+	s.T().Run("MultipleLogsByLine", func(t *testing.T) {
+		s.sc.CachedTestResultsData["task_id1"] = []dbModel.TestResult{{TaskID: "task_id1", TestName: "test1", LineNum: 1}}
+		defer delete(s.sc.CachedTestResultsData, "task_id1")
+		rh := s.rh["test_result"].Factory()
+		rh.(*logGetByTestResultHandler).opts.TaskID = "task_id1"
+		rh.(*logGetByTestResultHandler).opts.TestName = "test1"
+		rh.(*logGetByTestResultHandler).opts.ByLine = true
+
+		resp := rh.Run(context.TODO())
+		s.Require().NotNil(resp)
+		s.Equal(http.StatusBadRequest, resp.Status())
+	})
+}
+
+func (s *LogHandlerSuite) TestLogGetByTestResultHandlerParse() {
+	ctx := context.Background()
+	urlString := "http://cedar.mongodb.com/buildlogger/test_result/task_id3/test1"
+
+	s.T().Run("Valid", func(t *testing.T) {
+		req := &http.Request{Method: http.MethodGet}
+		req.URL, _ = url.Parse(urlString + "?execution=1&start=2012-11-01T22:08:00%2B00:00&proc_name=task&by_line=true&paginate=true")
+		rh := s.rh["test_result"].Factory()
+
+		s.Require().NoError(rh.Parse(ctx, req))
+		opts := rh.(*logGetByTestResultHandler).opts
+		s.Equal(1, utility.FromIntPtr(opts.Execution))
+		s.Equal(time.Date(2012, time.November, 1, 22, 8, 0, 0, time.UTC), opts.StartAt)
+		s.Equal("task", opts.ProcessName)
+		s.True(opts.ByLine)
+		s.Equal(softSizeLimit, opts.SoftSizeLimit)
+	})
+	s.T().Run("Defaults", func(t *testing.T) {
+		req := &http.Request{Method: http.MethodGet}
+		req.URL, _ = url.Parse(urlString)
+		rh := s.rh["test_result"].Factory()
+
+		s.Require().NoError(rh.Parse(ctx, req))
+		opts := rh.(*logGetByTestResultHandler).opts
+		s.Nil(opts.Execution)
+		s.Zero(opts.StartAt)
+		s.False(opts.ByLine)
+		s.Zero(opts.SoftSizeLimit)
+	})
+	for _, query := range []string{"?execution=hello", "?start=hello"} {
+		s.T().Run(query, func(t *testing.T) {
+			req := &http.Request{Method: http.MethodGet}
+			req.URL, _ = url.Parse(urlString + query)
+			rh := s.rh["test_result"].Factory()
+
+			err := rh.Parse(ctx, req)
+			s.Require().Error(err)
+			errResp, ok := err.(gimlet.ErrorResponse)
+			s.Require().True(ok)
+			s.Equal(http.StatusBadRequest, errResp.StatusCode)
+		})
+	}
+}
+
 func (s *LogHandlerSuite) TestParse() {
 	for _, test := range []struct {
 		urlString string
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/evergreen-ci/cedar"
+	dbmodel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/mongodb/grip"
@@ -589,6 +590,130 @@ func (c *Client) GetUserCertificateKey(ctx context.Context, username, password,
 	return c.authCredRequest(ctx, c.getURL("/v1/admin/users/certificate/key"), username, password, apiKey)
 }
 
+// ListAPIKeys returns the named API keys of the given user.
+// RevokeUserCertificate revokes the given user's certificate. A new
+// certificate is issued the next time the user requests one.
+func (c *Client) RevokeUserCertificate(ctx context.Context, user, reason string) (*dbmodel.CertificateRevocation, error) {
+	data, err := json.Marshal(CertificateRevocationRequest{Reason: reason})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling certificate revocation request")
+	}
+
+	req, err := c.makeRequest(ctx, http.MethodPost, c.getURL(fmt.Sprintf("/v1/admin/users/%s/certificate/revoke", user)), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "building request")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "making request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		srverr := gimlet.ErrorResponse{}
+		if err = gimlet.GetJSON(resp.Body, &srverr); err != nil {
+			return nil, errors.Wrap(err, "parsing error message")
+		}
+
+		return nil, srverr
+	}
+
+	out := &dbmodel.CertificateRevocation{}
+	if err = gimlet.GetJSON(resp.Body, out); err != nil {
+		return nil, errors.Wrap(err, "reading certificate revocation response")
+	}
+
+	return out, nil
+}
+
+func (c *Client) ListAPIKeys(ctx context.Context, user string) (*APIKeyListResponse, error) {
+	out := &APIKeyListResponse{}
+	if err := c.doAPIKeyRequest(ctx, http.MethodGet, fmt.Sprintf("/v1/admin/users/%s/keys", user), nil, out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+// CreateAPIKey creates a new named API key for the given user. The returned
+// response contains the key, which cannot be retrieved again.
+func (c *Client) CreateAPIKey(ctx context.Context, user string, opts APIKeyRequest) (*APIKeyResponse, error) {
+	out := &APIKeyResponse{}
+	if err := c.doAPIKeyRequest(ctx, http.MethodPost, fmt.Sprintf("/v1/admin/users/%s/keys", user), &opts, out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+// RotateAPIKey replaces the named API key of the given user with a new key.
+// The returned response contains the new key, which cannot be retrieved
+// again.
+func (c *Client) RotateAPIKey(ctx context.Context, user, name string, opts APIKeyRequest) (*APIKeyResponse, error) {
+	out := &APIKeyResponse{}
+	if err := c.doAPIKeyRequest(ctx, http.MethodPost, fmt.Sprintf("/v1/admin/users/%s/keys/%s/rotate", user, name), &opts, out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+// ExpireAPIKey sets the expiration time of the named API key of the given
+// user. A zero time expires the key immediately.
+func (c *Client) ExpireAPIKey(ctx context.Context, user, name string, expiresAt time.Time) (*APIKeyResponse, error) {
+	out := &APIKeyResponse{}
+	opts := &APIKeyRequest{ExpiresAt: expiresAt}
+	if err := c.doAPIKeyRequest(ctx, http.MethodPost, fmt.Sprintf("/v1/admin/users/%s/keys/%s/expire", user, name), opts, out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+// RevokeAPIKey permanently revokes the named API key of the given user.
+func (c *Client) RevokeAPIKey(ctx context.Context, user, name string) (*APIKeyResponse, error) {
+	out := &APIKeyResponse{}
+	if err := c.doAPIKeyRequest(ctx, http.MethodPost, fmt.Sprintf("/v1/admin/users/%s/keys/%s/revoke", user, name), nil, out); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return out, nil
+}
+
+func (c *Client) doAPIKeyRequest(ctx context.Context, method, url string, payload *APIKeyRequest, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrap(err, "marshalling API key request")
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := c.makeRequest(ctx, method, url, body)
+	if err != nil {
+		return errors.Wrap(err, "building request")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "making request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		srverr := gimlet.ErrorResponse{}
+		if err = gimlet.GetJSON(resp.Body, &srverr); err != nil {
+			return errors.Wrap(err, "parsing error message")
+		}
+
+		return srverr
+	}
+
+	return errors.Wrap(gimlet.GetJSON(resp.Body, out), "reading API key response")
+}
+
 func (c *Client) FindPerformanceResultById(ctx context.Context, id string) (*model.APIPerformanceResult, error) {
 	url := c.getURL(fmt.Sprintf("/v1/perf/%s", id))
 
@@ -38,6 +38,20 @@ func (dbc *DBConnector) GetHistoricalTestData(ctx context.Context, f dbModel.His
 	return apiData, nil
 }
 
+// GetHistoricalTaskData queries the service backend to retrieve the aggregated
+// historical task data that match the given filter.
+func (dbc *DBConnector) GetHistoricalTaskData(ctx context.Context, f dbModel.HistoricalTaskDataFilter) ([]model.APIAggregatedHistoricalTaskData, error) {
+	data, err := dbModel.GetHistoricalTaskData(ctx, dbc.env, f)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "fetching historical task data").Error(),
+		}
+	}
+
+	return importHistoricalTaskData(data)
+}
+
 ///////////////////////////////
 // MockConnector Implementation
 ///////////////////////////////
@@ -64,3 +78,28 @@ func (mc *MockConnector) GetHistoricalTestData(ctx context.Context, f dbModel.Hi
 
 	return apiData, nil
 }
+
+// GetHistoricalTaskData returns the cached historical task data, only
+// enforcing the Limit field of the filter.
+func (mc *MockConnector) GetHistoricalTaskData(ctx context.Context, f dbModel.HistoricalTaskDataFilter) ([]model.APIAggregatedHistoricalTaskData, error) {
+	data := mc.CachedHistoricalTaskData
+	if f.Limit <= len(data) && f.Limit > 0 {
+		data = data[:f.Limit]
+	}
+
+	return importHistoricalTaskData(data)
+}
+
+func importHistoricalTaskData(data []dbModel.AggregatedHistoricalTaskData) ([]model.APIAggregatedHistoricalTaskData, error) {
+	apiData := make([]model.APIAggregatedHistoricalTaskData, len(data))
+	for i, d := range data {
+		if err := apiData[i].Import(d); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrap(err, "corrupt data for historical task data").Error(),
+			}
+		}
+	}
+
+	return apiData, nil
+}
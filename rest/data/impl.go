@@ -36,7 +36,10 @@ type MockConnector struct {
 	ChildMap                 map[string][]string
 	CachedLogs               map[string]model.Log
 	CachedHistoricalTestData []model.AggregatedHistoricalTestData
+	CachedHistoricalTaskData []model.AggregatedHistoricalTaskData
 	CachedSystemMetrics      map[string]model.SystemMetrics
+	CachedTestResults        map[string]model.TestResults
+	CachedTestResultsData    map[string][]model.TestResult
 	Users                    map[string]bool
 	Bucket                   string
 
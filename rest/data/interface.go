@@ -35,6 +35,11 @@ type Connector interface {
 	// processing recalculation job has been scheduled for each type of
 	// test (project/variant/task/test combo).
 	ScheduleSignalProcessingRecalculateJobs(context.Context) error
+	// PresignPerformanceResultArtifacts returns pre-signed URLs, which
+	// expire after the given duration, to download the artifacts of the
+	// performance result with the given ID. Artifacts that are not stored
+	// in S3 are reported with an error instead of a URL.
+	PresignPerformanceResultArtifacts(context.Context, string, time.Duration) ([]model.APIPresignedURL, error)
 
 	//////////////////
 	// Buildlogger Log
@@ -82,6 +87,10 @@ type Connector interface {
 	// PrintPriority, Limit, and SoftSizeLimit are respected from
 	// BuildloggerOptions.
 	FindGroupedLogs(context.Context, BuildloggerOptions) ([]byte, time.Time, bool, error)
+	// PresignLogByID returns pre-signed URLs, which expire after the
+	// given duration, to download the chunks of the buildlogger log with
+	// the given ID in chronological order.
+	PresignLogByID(context.Context, string, time.Duration) ([]model.APIPresignedURL, error)
 
 	///////////////
 	// Test Results
@@ -96,13 +105,38 @@ type Connector interface {
 	// GetFailedTestResultsSample queries the DB to find all the
 	// sample of failed test results for the given options. If the
 	// execution is nil, this will return the sample from the most recent
-	// execution. Filtering, sorting, and paginating is not supported.
-	GetFailedTestResultsSample(context.Context, TestResultsOptions) ([]string, error)
+	// execution. Failed tests that are quarantined are excluded from the
+	// sample, unless Quarantined is set, in which case only they are
+	// returned. The failed tests are annotated with their owning teams.
+	// Filtering, sorting, and paginating is not supported.
+	GetFailedTestResultsSample(context.Context, TestResultsOptions) (*model.APIFailedTestResultsSample, error)
 	// GetTestResultsStats queries the DB to aggregate basic stats
 	// of test results for the given options. If the execution is nil, this
-	// will return stats for the most recent execution. Filtering, sorting,
-	// and paginating is not supported.
+	// will return stats for the most recent execution. Failed tests that
+	// are quarantined are counted separately from the other failed tests.
+	// Filtering, sorting, and paginating is not supported.
 	GetTestResultsStats(context.Context, TestResultsOptions) (*model.APITestResultsStats, error)
+	// FindTestResultsByProject queries the DB to find the test results
+	// of a project across task executions with the given options, most
+	// recent task first unless sorted otherwise. Base results are not
+	// supported.
+	FindTestResultsByProject(context.Context, TestResultsByProjectOptions) (*model.APITestResults, error)
+	// FindTestResultsProject returns the project of the test results for
+	// the given options.
+	FindTestResultsProject(context.Context, TestResultsOptions) (string, error)
+	// PresignTestResults returns pre-signed URLs, which expire after the
+	// given duration, to download the Parquet files of the test results
+	// for the given options. Filtering, sorting, and paginating is not
+	// supported.
+	PresignTestResults(context.Context, TestResultsOptions, time.Duration) ([]model.APIPresignedURL, error)
+	// FindTestResultLog returns the lines of the task-level buildlogger
+	// logs of the task that belong to the test result with the given
+	// options, either those logged between the test's start and end times
+	// or, if ByLine is set, those between the test's line number and the
+	// next test's line number in a single task-level log. If the execution
+	// is nil, the most recent execution is used. The time of the next line
+	// to read and whether the data was paginated are also returned.
+	FindTestResultLog(context.Context, TestResultLogOptions) ([]byte, time.Time, bool, error)
 
 	///////////////////////
 	// Historical Test Data
@@ -110,6 +144,9 @@ type Connector interface {
 	// GetHistoricalTestData queries the historical test data using a
 	// filter.
 	GetHistoricalTestData(context.Context, dbModel.HistoricalTestDataFilter) ([]model.APIAggregatedHistoricalTestData, error)
+	// GetHistoricalTaskData queries the historical task data using a
+	// filter.
+	GetHistoricalTaskData(context.Context, dbModel.HistoricalTaskDataFilter) ([]model.APIAggregatedHistoricalTaskData, error)
 
 	/////////////////
 	// System Metrics
@@ -118,6 +155,28 @@ type Connector interface {
 	// execution, and metric type combination. It also returns the next
 	// index to use for pagination.
 	FindSystemMetricsByType(context.Context, dbModel.SystemMetricsFindOptions, dbModel.SystemMetricsDownloadOptions) ([]byte, int, error)
+	// FindSystemMetricsProject returns the project of the system metrics
+	// for the given task id and execution.
+	FindSystemMetricsProject(context.Context, dbModel.SystemMetricsFindOptions) (string, error)
+	// PresignSystemMetricsByType returns pre-signed URLs, which expire
+	// after the given duration, to download the chunks of the given
+	// metric type for the given task id and execution, in order.
+	PresignSystemMetricsByType(context.Context, dbModel.SystemMetricsFindOptions, string, time.Duration) ([]model.APIPresignedURL, error)
+}
+
+// TestResultLogOptions holds all values required to find the lines of a task's
+// buildlogger logs belonging to a TestResult object using connector
+// functions.
+type TestResultLogOptions struct {
+	TaskID        string
+	Execution     *int
+	TestName      string
+	ProcessName   string
+	ByLine        bool
+	StartAt       time.Time
+	PrintTime     bool
+	PrintPriority bool
+	SoftSizeLimit int
 }
 
 // BuildloggerOptions contains arguments for buildlogger related Connector
@@ -146,20 +205,39 @@ type TestResultsOptions struct {
 	TaskID        string
 	Execution     *int
 	DisplayTask   bool
+	Quarantined   bool
+	FilterAndSort *TestResultsFilterAndSortOptions
+}
+
+// TestResultsByProjectOptions holds all values required to find the
+// TestResult objects of a project across task executions using connector
+// functions.
+type TestResultsByProjectOptions struct {
+	Project       string
+	Variant       string
+	TaskName      string
+	RequestType   string
+	Author        string
+	StartAt       time.Time
+	EndAt         time.Time
 	FilterAndSort *TestResultsFilterAndSortOptions
 }
 
 // TestResultsFilterAndSortOptions holds all values required for filtering,
 // sorting, and paginating TestResult objects using connector functions.
 type TestResultsFilterAndSortOptions struct {
-	TestName     string
-	Statuses     []string
-	GroupID      string
-	SortBy       string
-	SortOrderDSC bool
-	Limit        int
-	Page         int
-	BaseResults  *TestResultsOptions
+	TestName       string
+	Statuses       []string
+	GroupID        string
+	FailureMessage string
+	Tags           []string
+	Attributes     map[string]string
+	Team           string
+	SortBy         string
+	SortOrderDSC   bool
+	Limit          int
+	Page           int
+	BaseResults    *TestResultsOptions
 }
 
 // TestSampleOptions specifies the tasks to get the sample for
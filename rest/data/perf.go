@@ -176,7 +176,7 @@ func (dbc *DBConnector) ScheduleSignalProcessingRecalculateJobs(ctx context.Cont
 
 	for _, series := range allSeries {
 		job := units.NewUpdateTimeSeriesJob(series)
-		if err := amboy.EnqueueUniqueJob(ctx, queue, job); err != nil {
+		if err := amboy.EnqueueUniqueJob(ctx, queue, units.WithTraceContext(ctx, job)); err != nil {
 			catcher.Add(message.WrapError(err, message.Fields{
 				"message": "unable to enqueue recalculation job for metric",
 				"project": series.Project,
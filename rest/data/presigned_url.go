@@ -0,0 +1,377 @@
+package data
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/anser/db"
+	"github.com/pkg/errors"
+)
+
+/////////////////////////////
+// DBConnector Implementation
+/////////////////////////////
+
+func (dbc *DBConnector) PresignPerformanceResultArtifacts(ctx context.Context, id string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	result := dbModel.PerformanceResult{ID: id}
+	result.Setup(dbc.env)
+	if err := result.Find(ctx); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("performance result '%s' not found", id),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding performance result '%s'", id).Error(),
+		}
+	}
+
+	urls := []dbModel.PresignedURL{}
+	for _, artifact := range result.Artifacts {
+		url, err := artifact.PresignDownloadURL(dbc.env, expiration)
+		if err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "pre-signing URL for artifact '%s' of performance result '%s'", artifact.Path, id).Error(),
+			}
+		}
+		urls = append(urls, *url)
+	}
+
+	return importPresignedURLs(urls)
+}
+
+func (dbc *DBConnector) PresignLogByID(ctx context.Context, id string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	log := dbModel.Log{ID: id}
+	log.Setup(dbc.env)
+	if err := log.Find(ctx); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("log '%s' not found", id),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding log '%s'", id).Error(),
+		}
+	}
+	if !log.Artifact.Type.SupportsPresignedURLs() {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("log '%s' is not stored in S3", id),
+		}
+	}
+	if log.Artifact.EncryptionKeyID != "" {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("log '%s' is encrypted", id),
+		}
+	}
+
+	urls, err := log.PresignChunkURLs(ctx, expiration)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "pre-signing URLs for log '%s'", id).Error(),
+		}
+	}
+
+	return importPresignedURLs(urls)
+}
+
+func (dbc *DBConnector) FindTestResultsProject(ctx context.Context, opts TestResultsOptions) (string, error) {
+	results, err := dbc.findTestResults(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	return results[0].Info.Project, nil
+}
+
+func (dbc *DBConnector) PresignTestResults(ctx context.Context, opts TestResultsOptions, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	results, err := dbc.findTestResults(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []dbModel.PresignedURL{}
+	for i := range results {
+		if !results[i].SupportsPresignedParquetURL() {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("test results for task ID '%s' are not stored as Parquet in S3", results[i].Info.TaskID),
+			}
+		}
+
+		url, err := results[i].PresignParquetURL(expiration)
+		if err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrapf(err, "pre-signing URL for test results for task ID '%s'", results[i].Info.TaskID).Error(),
+			}
+		}
+		urls = append(urls, *url)
+	}
+
+	return importPresignedURLs(urls)
+}
+
+func (dbc *DBConnector) findTestResults(ctx context.Context, opts TestResultsOptions) ([]dbModel.TestResults, error) {
+	results, err := dbModel.FindTestResults(ctx, dbc.env, convertToDBFindTestResultsOptions(opts))
+	if db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "test results not found",
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "retrieving test results").Error(),
+		}
+	}
+
+	return results, nil
+}
+
+func (dbc *DBConnector) FindSystemMetricsProject(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions) (string, error) {
+	sm, err := dbc.findSystemMetrics(ctx, findOpts)
+	if err != nil {
+		return "", err
+	}
+
+	return sm.Info.Project, nil
+}
+
+func (dbc *DBConnector) PresignSystemMetricsByType(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions, metricType string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	sm, err := dbc.findSystemMetrics(ctx, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sm.Artifact.MetricChunks[metricType]; !ok {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("metric type '%s' for task ID '%s' not found", metricType, findOpts.TaskID),
+		}
+	}
+	if !sm.Artifact.Options.Type.SupportsPresignedURLs() {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("system metrics for task ID '%s' are not stored in S3", findOpts.TaskID),
+		}
+	}
+	if sm.Artifact.EncryptionKeyID != "" {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("system metrics for task ID '%s' are encrypted", findOpts.TaskID),
+		}
+	}
+
+	urls, err := sm.PresignChunkURLs(metricType, expiration)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "pre-signing URLs for system metrics for task ID '%s'", findOpts.TaskID).Error(),
+		}
+	}
+
+	return importPresignedURLs(urls)
+}
+
+func (dbc *DBConnector) findSystemMetrics(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions) (*dbModel.SystemMetrics, error) {
+	sm := &dbModel.SystemMetrics{}
+	sm.Setup(dbc.env)
+	if err := sm.FindByTaskID(ctx, findOpts); db.ResultsNotFound(err) {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("system metrics for task ID '%s' not found", findOpts.TaskID),
+		}
+	} else if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "retrieving system metrics for task ID '%s'", findOpts.TaskID).Error(),
+		}
+	}
+
+	return sm, nil
+}
+
+///////////////////////////////
+// MockConnector Implementation
+///////////////////////////////
+
+// The mock connector stores everything in a local bucket, so instead of
+// pre-signed URLs it returns the paths of the objects in the local bucket.
+
+func (mc *MockConnector) PresignPerformanceResultArtifacts(ctx context.Context, id string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	result, ok := mc.CachedPerformanceResults[id]
+	if !ok {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("performance result '%s' not found", id),
+		}
+	}
+
+	urls := []dbModel.PresignedURL{}
+	for _, artifact := range result.Artifacts {
+		if !artifact.Type.SupportsPresignedURLs() {
+			urls = append(urls, dbModel.PresignedURL{
+				Key:   artifact.Path,
+				Error: fmt.Sprintf("cannot pre-sign URLs for artifacts stored in '%s'", artifact.Type),
+			})
+			continue
+		}
+		urls = append(urls, mc.presignURLs(artifact.Prefix, []string{artifact.Path}, expiration)...)
+	}
+
+	return importPresignedURLs(urls)
+}
+
+func (mc *MockConnector) PresignLogByID(ctx context.Context, id string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	log, ok := mc.CachedLogs[id]
+	if !ok {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("log '%s' not found", id),
+		}
+	}
+
+	keys := []string{}
+	for _, chunk := range log.Artifact.Chunks {
+		keys = append(keys, chunk.Key)
+	}
+
+	return importPresignedURLs(mc.presignURLs(log.Artifact.Prefix, keys, expiration))
+}
+
+func (mc *MockConnector) FindTestResultsProject(ctx context.Context, opts TestResultsOptions) (string, error) {
+	results, err := mc.findTestResults(opts)
+	if err != nil {
+		return "", err
+	}
+
+	return results[0].Info.Project, nil
+}
+
+func (mc *MockConnector) PresignTestResults(ctx context.Context, opts TestResultsOptions, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	results, err := mc.findTestResults(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []dbModel.PresignedURL{}
+	for i := range results {
+		if !results[i].SupportsPresignedParquetURL() {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("test results for task ID '%s' are not stored as Parquet in S3", results[i].Info.TaskID),
+			}
+		}
+		urls = append(urls, mc.presignURLs("", []string{results[i].PrestoPartitionKey()}, expiration)...)
+	}
+
+	return importPresignedURLs(urls)
+}
+
+// findTestResults returns the cached test results of the task, or of the
+// execution tasks of the display task, for the requested execution,
+// defaulting to the latest.
+func (mc *MockConnector) findTestResults(opts TestResultsOptions) ([]dbModel.TestResults, error) {
+	results := []dbModel.TestResults{}
+	execution := -1
+	for _, result := range mc.CachedTestResults {
+		taskID := result.Info.TaskID
+		if opts.DisplayTask {
+			taskID = result.Info.DisplayTaskID
+		}
+		if taskID != opts.TaskID {
+			continue
+		}
+		if opts.Execution != nil && result.Info.Execution != *opts.Execution {
+			continue
+		}
+
+		if result.Info.Execution > execution {
+			execution = result.Info.Execution
+			results = results[:0]
+		}
+		if result.Info.Execution == execution {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "test results not found",
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Info.TaskID < results[j].Info.TaskID })
+
+	return results, nil
+}
+
+func (mc *MockConnector) FindSystemMetricsProject(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions) (string, error) {
+	sm, err := mc.findSystemMetrics(findOpts)
+	if err != nil {
+		return "", err
+	}
+
+	return sm.Info.Project, nil
+}
+
+func (mc *MockConnector) PresignSystemMetricsByType(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions, metricType string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	sm, err := mc.findSystemMetrics(findOpts)
+	if err != nil {
+		return nil, err
+	}
+	chunks, ok := sm.Artifact.MetricChunks[metricType]
+	if !ok {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("metric type '%s' for task ID '%s' not found", metricType, findOpts.TaskID),
+		}
+	}
+
+	return importPresignedURLs(mc.presignURLs(sm.Artifact.Prefix, chunks.Chunks, expiration))
+}
+
+func (mc *MockConnector) presignURLs(prefix string, keys []string, expiration time.Duration) []dbModel.PresignedURL {
+	expiresAt := time.Now().Add(expiration)
+	urls := []dbModel.PresignedURL{}
+	for _, key := range keys {
+		urls = append(urls, dbModel.PresignedURL{
+			Key:       key,
+			URL:       filepath.Join(mc.Bucket, prefix, key),
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	return urls
+}
+
+///////////////////
+// Helper Functions
+///////////////////
+
+func importPresignedURLs(urls []dbModel.PresignedURL) ([]model.APIPresignedURL, error) {
+	apiURLs := make([]model.APIPresignedURL, 0, len(urls))
+	for _, url := range urls {
+		apiURL := model.APIPresignedURL{}
+		if err := apiURL.Import(url); err != nil {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusInternalServerError,
+				Message:    errors.Wrap(err, "importing pre-signed URL into APIPresignedURL struct").Error(),
+			}
+		}
+		apiURLs = append(apiURLs, apiURL)
+	}
+
+	return apiURLs, nil
+}
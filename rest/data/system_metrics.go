@@ -60,23 +60,9 @@ func (dbc *DBConnector) FindSystemMetricsByType(ctx context.Context, findOpts db
 ///////////////////////////////
 
 func (mc *MockConnector) FindSystemMetricsByType(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions, downloadOpts dbModel.SystemMetricsDownloadOptions) ([]byte, int, error) {
-	var sm *dbModel.SystemMetrics
-	for key := range mc.CachedSystemMetrics {
-		val := mc.CachedSystemMetrics[key]
-		if findOpts.EmptyExecution {
-			if val.Info.TaskID == findOpts.TaskID && (sm == nil || val.Info.Execution > sm.Info.Execution) {
-				sm = &val
-			}
-		} else if val.Info.TaskID == findOpts.TaskID && val.Info.Execution == findOpts.Execution {
-			sm = &val
-			break
-		}
-	}
-	if sm == nil {
-		return nil, 0, gimlet.ErrorResponse{
-			StatusCode: http.StatusNotFound,
-			Message:    fmt.Sprintf("system metrics for task ID '%s' not found", findOpts.TaskID),
-		}
+	sm, err := mc.findSystemMetrics(findOpts)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// check that the metric is valid so we can return the appropriate
@@ -140,3 +126,26 @@ func (mc *MockConnector) FindSystemMetricsByType(ctx context.Context, findOpts d
 
 	return data, idx, nil
 }
+
+func (mc *MockConnector) findSystemMetrics(findOpts dbModel.SystemMetricsFindOptions) (*dbModel.SystemMetrics, error) {
+	var sm *dbModel.SystemMetrics
+	for key := range mc.CachedSystemMetrics {
+		val := mc.CachedSystemMetrics[key]
+		if findOpts.EmptyExecution {
+			if val.Info.TaskID == findOpts.TaskID && (sm == nil || val.Info.Execution > sm.Info.Execution) {
+				sm = &val
+			}
+		} else if val.Info.TaskID == findOpts.TaskID && val.Info.Execution == findOpts.Execution {
+			sm = &val
+			break
+		}
+	}
+	if sm == nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("system metrics for task ID '%s' not found", findOpts.TaskID),
+		}
+	}
+
+	return sm, nil
+}
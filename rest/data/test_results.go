@@ -2,7 +2,9 @@ package data
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	dbModel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/model"
@@ -12,6 +14,20 @@ import (
 	"github.com/pkg/errors"
 )
 
+// Limits on the test results downloaded when finding test results by project.
+// Responses note when they are truncated by these limits.
+const (
+	// maxTestResultsByProjectRecords is the maximum number of test
+	// results records, each being a task execution, downloaded.
+	maxTestResultsByProjectRecords = 1000
+	// maxTestResultsByProjectResults is the maximum number of individual
+	// test results downloaded.
+	maxTestResultsByProjectResults = 100000
+	// testResultsByProjectDownloadTimeout is the maximum time spent
+	// downloading test results.
+	testResultsByProjectDownloadTimeout = 20 * time.Second
+)
+
 /////////////////////////////
 // DBConnector Implementation
 /////////////////////////////
@@ -53,6 +69,41 @@ func (dbc *DBConnector) FindTestResults(ctx context.Context, opts TestResultsOpt
 	}, nil
 }
 
+func (dbc *DBConnector) FindTestResultsByProject(ctx context.Context, opts TestResultsByProjectOptions) (*model.APITestResults, error) {
+	byProject, err := dbModel.FindAndDownloadTestResultsByProject(ctx, dbc.env, convertToDBFindAndDownloadTestResultsByProjectOptions(opts))
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "retrieving test results for project '%s'", opts.Project).Error(),
+		}
+	}
+
+	apiStats := &model.APITestResultsStats{}
+	if err = apiStats.Import(byProject.Stats); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "importing stats into APITestResultsStats struct").Error(),
+		}
+	}
+	if err = apiStats.Import(byProject.FilteredCount); err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "importing stats into APITestResultsStats struct").Error(),
+		}
+	}
+
+	apiResults, err := importTestResults(ctx, byProject.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.APITestResults{
+		Stats:     *apiStats,
+		Results:   apiResults,
+		Truncated: byProject.Truncated,
+	}, nil
+}
+
 // GetTestResultsFilteredSamples returns test names for the specified test results, filtered by the provided regexes.
 func (dbc *DBConnector) GetTestResultsFilteredSamples(ctx context.Context, opts TestSampleOptions) ([]model.APITestResultsSample, error) {
 	samples, err := dbModel.GetTestResultsFilteredSamples(ctx, dbc.env, convertToDBFindTestSampleOptions(opts))
@@ -66,7 +117,7 @@ func (dbc *DBConnector) GetTestResultsFilteredSamples(ctx context.Context, opts
 	return importTestResultsSamples(samples)
 }
 
-func (dbc *DBConnector) GetFailedTestResultsSample(ctx context.Context, opts TestResultsOptions) ([]string, error) {
+func (dbc *DBConnector) GetFailedTestResultsSample(ctx context.Context, opts TestResultsOptions) (*model.APIFailedTestResultsSample, error) {
 	resultDocs, err := dbModel.FindTestResults(ctx, dbc.env, convertToDBFindTestResultsOptions(opts))
 	if db.ResultsNotFound(err) {
 		return nil, gimlet.ErrorResponse{
@@ -80,7 +131,106 @@ func (dbc *DBConnector) GetFailedTestResultsSample(ctx context.Context, opts Tes
 		}
 	}
 
-	return extractFailedTestResultsSample(resultDocs...), nil
+	quarantines, err := dbModel.FindTestQuarantineSet(ctx, dbc.env, []string{resultDocs[0].Info.Project})
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "retrieving test quarantines").Error(),
+		}
+	}
+	sample, err := dbModel.FailedTestResultsSample(ctx, quarantines, opts.Quarantined, resultDocs...)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "separating quarantined failed tests").Error(),
+		}
+	}
+
+	ownership, err := dbModel.FindTestOwnership(ctx, dbc.env, resultDocs[0].Info.Project)
+	if err != nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "retrieving test ownership").Error(),
+		}
+	}
+	apiSample := &model.APIFailedTestResultsSample{TestNames: sample}
+	for _, name := range sample {
+		if team := ownership.TeamFor(name); team != "" {
+			if apiSample.Teams == nil {
+				apiSample.Teams = map[string]string{}
+			}
+			apiSample.Teams[name] = team
+		}
+	}
+
+	return apiSample, nil
+}
+
+func (dbc *DBConnector) FindTestResultLog(ctx context.Context, opts TestResultLogOptions) ([]byte, time.Time, bool, error) {
+	// Only the record with the test is downloaded, rather than every test
+	// result of the task.
+	results, err := dbModel.FindAndDownloadTestResultsForTest(ctx, dbc.env, dbModel.FindTestResultsOptions{
+		TaskID:    opts.TaskID,
+		Execution: opts.Execution,
+	}, opts.TestName)
+	if db.ResultsNotFound(err) {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "test results not found",
+		}
+	} else if err != nil {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrap(err, "retrieving test results").Error(),
+		}
+	}
+
+	logRange, err := findTestResultLogRange(results, opts)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	logs := dbModel.Logs{}
+	logs.Setup(dbc.env)
+	if err = logs.Find(ctx, dbModel.LogFindOptions{
+		TimeRange: logRange.timeRange,
+		Info: dbModel.LogInfo{
+			TaskID:      logRange.result.TaskID,
+			Execution:   logRange.result.Execution,
+			ProcessName: opts.ProcessName,
+		},
+		EmptyTestName: true,
+	}); db.ResultsNotFound(err) {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task-level logs with task ID '%s' not found", logRange.result.TaskID),
+		}
+	} else if err != nil {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "finding task-level logs with task ID '%s'", logRange.result.TaskID).Error(),
+		}
+	}
+
+	var it dbModel.LogIterator
+	if opts.ByLine {
+		if err = checkSingleTestResultLog(len(logs.Logs), logRange.result.TaskID); err != nil {
+			return nil, time.Time{}, false, err
+		}
+		logs.Logs[0].Setup(dbc.env)
+		it, err = logs.Logs[0].Download(ctx, logRange.timeRange)
+	} else {
+		logs.Setup(dbc.env)
+		it, err = logs.Merge(ctx)
+	}
+	if err != nil {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "downloading task-level logs with task ID '%s'", logRange.result.TaskID).Error(),
+		}
+	}
+
+	return readTestResultLog(ctx, it, opts, logRange)
 }
 
 func (dbc *DBConnector) GetTestResultsStats(ctx context.Context, opts TestResultsOptions) (*model.APITestResultsStats, error) {
@@ -116,11 +266,15 @@ func (mc *MockConnector) FindTestResults(ctx context.Context, opts TestResultsOp
 	return nil, errors.New("not implemented")
 }
 
+func (mc *MockConnector) FindTestResultsByProject(ctx context.Context, opts TestResultsByProjectOptions) (*model.APITestResults, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (mc *MockConnector) GetTestResultsFilteredSamples(ctx context.Context, opts TestSampleOptions) ([]model.APITestResultsSample, error) {
 	return nil, errors.New("not implemented")
 }
 
-func (mc *MockConnector) GetFailedTestResultsSample(ctx context.Context, opts TestResultsOptions) ([]string, error) {
+func (mc *MockConnector) GetFailedTestResultsSample(ctx context.Context, opts TestResultsOptions) (*model.APIFailedTestResultsSample, error) {
 	return nil, errors.New("not implemented")
 }
 
@@ -128,10 +282,219 @@ func (mc *MockConnector) GetTestResultsStats(ctx context.Context, opts TestResul
 	return nil, errors.New("not implemented")
 }
 
+func (mc *MockConnector) FindTestResultLog(ctx context.Context, opts TestResultLogOptions) ([]byte, time.Time, bool, error) {
+	var results []dbModel.TestResult
+	execution := -1
+	for _, result := range mc.CachedTestResultsData[opts.TaskID] {
+		if opts.Execution != nil && result.Execution != *opts.Execution {
+			continue
+		}
+		if result.Execution > execution {
+			results = nil
+			execution = result.Execution
+		}
+		if result.Execution == execution {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    "test results not found",
+		}
+	}
+
+	logRange, err := findTestResultLogRange(results, opts)
+	if err != nil {
+		return nil, time.Time{}, false, err
+	}
+
+	logs := []dbModel.Log{}
+	for _, log := range mc.CachedLogs {
+		if log.Info.TaskID != logRange.result.TaskID || log.Info.Execution != logRange.result.Execution || log.Info.TestName != "" {
+			continue
+		}
+		if opts.ProcessName != "" && opts.ProcessName != log.Info.ProcessName {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	if len(logs) == 0 {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("task-level logs with task ID '%s' not found", logRange.result.TaskID),
+		}
+	}
+	if opts.ByLine {
+		if err = checkSingleTestResultLog(len(logs), logRange.result.TaskID); err != nil {
+			return nil, time.Time{}, false, err
+		}
+	}
+
+	its := []dbModel.LogIterator{}
+	for _, log := range logs {
+		bucket, err := mc.getBucket(ctx, log.Artifact.Prefix)
+		if err != nil {
+			return nil, time.Time{}, false, err
+		}
+		its = append(its, dbModel.NewBatchedLogIterator(bucket, log.Artifact.Chunks, 2, logRange.timeRange))
+	}
+
+	return readTestResultLog(ctx, dbModel.NewMergingIterator(its...), opts, logRange)
+}
+
 ///////////////////
 // Helper Functions
 ///////////////////
 
+// testResultLogRange describes the lines of the task-level logs that belong
+// to a test result.
+type testResultLogRange struct {
+	result    *dbModel.TestResult
+	timeRange dbModel.TimeRange
+	startLine int
+	endLine   int
+}
+
+// findTestResultLogRange returns the range of the task-level log lines that
+// belong to the latest trial of the test result with the given options.
+func findTestResultLogRange(results []dbModel.TestResult, opts TestResultLogOptions) (*testResultLogRange, error) {
+	// Retried tests have a result for each trial, so use the latest.
+	var result *dbModel.TestResult
+	for i := range results {
+		if results[i].TestName != opts.TestName && results[i].GetDisplayName() != opts.TestName {
+			continue
+		}
+		if result == nil || results[i].Trial > result.Trial {
+			result = &results[i]
+		}
+	}
+	if result == nil {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("test result '%s' not found", opts.TestName),
+		}
+	}
+
+	logRange := &testResultLogRange{result: result}
+	if opts.ByLine {
+		logRange.startLine, logRange.endLine = result.LogLineRange(results)
+		if logRange.startLine == 0 {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("test result '%s' has no line number in the task-level logs", opts.TestName),
+			}
+		}
+		// Lines are counted from the start of the log, so the whole
+		// log is read regardless of the line timestamps.
+		logRange.timeRange = dbModel.TimeRange{EndAt: time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)}
+
+		return logRange, nil
+	}
+
+	logRange.timeRange = dbModel.TimeRange{StartAt: result.TestStartTime, EndAt: result.TestEndTime}
+	if result.TestStartTime.IsZero() || result.TestEndTime.IsZero() || !logRange.timeRange.IsValid() {
+		return nil, gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("test result '%s' has no valid start and end times", opts.TestName),
+		}
+	}
+	if opts.StartAt.After(logRange.timeRange.StartAt) {
+		logRange.timeRange.StartAt = opts.StartAt
+		if !logRange.timeRange.IsValid() {
+			return nil, gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("start time is after the end of test result '%s'", opts.TestName),
+			}
+		}
+	}
+
+	return logRange, nil
+}
+
+// checkSingleTestResultLog returns an error if the number of task-level logs
+// found is not exactly one, since line numbers are only meaningful within a
+// single log.
+func checkSingleTestResultLog(numLogs int, taskID string) error {
+	if numLogs > 1 {
+		return gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("found %d task-level logs with task ID '%s', specify a process name to count lines in a single log", numLogs, taskID),
+		}
+	}
+
+	return nil
+}
+
+// readTestResultLog reads the test result's lines from the task-level log
+// iterator and returns them along with the time of the next line to read and
+// whether the data was paginated.
+func readTestResultLog(ctx context.Context, it dbModel.LogIterator, opts TestResultLogOptions, logRange *testResultLogRange) ([]byte, time.Time, bool, error) {
+	if opts.ByLine {
+		it = &lineRangeIterator{
+			LogIterator: it,
+			startLine:   logRange.startLine,
+			endLine:     logRange.endLine,
+			startAt:     opts.StartAt,
+		}
+	}
+
+	data, paginated, err := paginateData(ctx, it, BuildloggerOptions{
+		PrintTime:     opts.PrintTime,
+		PrintPriority: opts.PrintPriority,
+		SoftSizeLimit: opts.SoftSizeLimit,
+	})
+	if err != nil {
+		return nil, time.Time{}, false, gimlet.ErrorResponse{
+			StatusCode: http.StatusInternalServerError,
+			Message:    errors.Wrapf(err, "reading task-level logs with task ID '%s'", logRange.result.TaskID).Error(),
+		}
+	}
+
+	next := it.Item().Timestamp
+	if it.Exhausted() {
+		next = time.Time{}
+	}
+	return data, next, paginated, ctx.Err()
+}
+
+// lineRangeIterator wraps a log iterator to only return the lines between the
+// 1-indexed start line and the exclusive end line, skipping those before the
+// start time. An end line of 0 returns all of the lines after the start line.
+// Lines are counted from the start of the wrapped iterator, so it should not be
+// reversed.
+type lineRangeIterator struct {
+	dbModel.LogIterator
+	startLine int
+	endLine   int
+	startAt   time.Time
+	lineNum   int
+	exhausted bool
+}
+
+func (i *lineRangeIterator) Next(ctx context.Context) bool {
+	if i.exhausted {
+		return false
+	}
+
+	for i.LogIterator.Next(ctx) {
+		i.lineNum++
+		if i.endLine > 0 && i.lineNum >= i.endLine {
+			i.exhausted = true
+			return false
+		}
+		if i.lineNum < i.startLine || i.LogIterator.Item().Timestamp.Before(i.startAt) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (i *lineRangeIterator) Exhausted() bool { return i.exhausted || i.LogIterator.Exhausted() }
+
 func importTestResults(ctx context.Context, results []dbModel.TestResult) ([]model.APITestResult, error) {
 	apiResults := []model.APITestResult{}
 
@@ -172,15 +535,6 @@ func importTestResultsSamples(results []dbModel.TestResultsSample) ([]model.APIT
 	return samples, nil
 }
 
-func extractFailedTestResultsSample(results ...dbModel.TestResults) []string {
-	var sample []string
-	for i := 0; i < len(results) && len(sample) < dbModel.FailedTestsSampleSize; i++ {
-		sample = append(sample, results[i].FailedTestsSample...)
-	}
-
-	return sample
-}
-
 func convertToDBFindTestSampleOptions(opts TestSampleOptions) dbModel.FindTestSamplesOptions {
 	dbOptions := dbModel.FindTestSamplesOptions{TestNameRegexes: opts.RegexFilters}
 	for _, t := range opts.Tasks {
@@ -202,25 +556,51 @@ func convertToDBFindTestResultsOptions(opts TestResultsOptions) dbModel.FindTest
 }
 
 func convertToDBFindAndDownloadTestResultsOptions(opts TestResultsOptions) dbModel.FindAndDownloadTestResultsOptions {
+	return dbModel.FindAndDownloadTestResultsOptions{
+		Find:          convertToDBFindTestResultsOptions(opts),
+		FilterAndSort: convertToDBFilterAndSortTestResultsOptions(opts.FilterAndSort),
+	}
+}
+
+func convertToDBFindAndDownloadTestResultsByProjectOptions(opts TestResultsByProjectOptions) dbModel.FindAndDownloadTestResultsByProjectOptions {
+	return dbModel.FindAndDownloadTestResultsByProjectOptions{
+		Find: dbModel.FindTestResultsByProjectOptions{
+			Projects:    []string{opts.Project},
+			StartAt:     opts.StartAt,
+			EndAt:       opts.EndAt,
+			Variant:     opts.Variant,
+			TaskName:    opts.TaskName,
+			RequestType: opts.RequestType,
+			Author:      opts.Author,
+			Limit:       maxTestResultsByProjectRecords,
+		},
+		FilterAndSort:   convertToDBFilterAndSortTestResultsOptions(opts.FilterAndSort),
+		MaxResults:      maxTestResultsByProjectResults,
+		DownloadTimeout: testResultsByProjectDownloadTimeout,
+	}
+}
+
+func convertToDBFilterAndSortTestResultsOptions(opts *TestResultsFilterAndSortOptions) *dbModel.FilterAndSortTestResultsOptions {
 	var filterAndSort *dbModel.FilterAndSortTestResultsOptions
-	if opts.FilterAndSort != nil {
+	if opts != nil {
 		filterAndSort = &dbModel.FilterAndSortTestResultsOptions{
-			TestName:     opts.FilterAndSort.TestName,
-			Statuses:     opts.FilterAndSort.Statuses,
-			GroupID:      opts.FilterAndSort.GroupID,
-			SortBy:       dbModel.TestResultsSortBy(opts.FilterAndSort.SortBy),
-			SortOrderDSC: opts.FilterAndSort.SortOrderDSC,
-			Limit:        opts.FilterAndSort.Limit,
-			Page:         opts.FilterAndSort.Page,
-		}
-		if opts.FilterAndSort.BaseResults != nil {
-			baseOpts := convertToDBFindTestResultsOptions(*opts.FilterAndSort.BaseResults)
+			TestName:       opts.TestName,
+			Statuses:       opts.Statuses,
+			GroupID:        opts.GroupID,
+			FailureMessage: opts.FailureMessage,
+			Tags:           opts.Tags,
+			Attributes:     opts.Attributes,
+			Team:           opts.Team,
+			SortBy:         dbModel.TestResultsSortBy(opts.SortBy),
+			SortOrderDSC:   opts.SortOrderDSC,
+			Limit:          opts.Limit,
+			Page:           opts.Page,
+		}
+		if opts.BaseResults != nil {
+			baseOpts := convertToDBFindTestResultsOptions(*opts.BaseResults)
 			filterAndSort.BaseResults = &baseOpts
 		}
 	}
 
-	return dbModel.FindAndDownloadTestResultsOptions{
-		Find:          convertToDBFindTestResultsOptions(opts),
-		FilterAndSort: filterAndSort,
-	}
+	return filterAndSort
 }
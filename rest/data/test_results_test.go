@@ -11,6 +11,7 @@ import (
 	dbModel "github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -364,13 +365,27 @@ func (s *testResultsConnectorSuite) TestGetFailedTestResultsSample() {
 			} else {
 				s.Require().NoError(err)
 
-				s.Require().Len(actualResult, len(test.expectedResult))
-				for i := range actualResult {
-					s.Equal(test.expectedResult[i], actualResult[i])
+				s.Require().Len(actualResult.TestNames, len(test.expectedResult))
+				for i := range actualResult.TestNames {
+					s.Equal(test.expectedResult[i], actualResult.TestNames[i])
 				}
+				s.Nil(actualResult.Teams)
 			}
 		})
 	}
+	s.T().Run("Teams", func(t *testing.T) {
+		_, err := dbModel.SetTestOwnership(s.ctx, s.env, "test", "user", []dbModel.TestOwnershipRule{{Pattern: "test1", Team: "team"}})
+		s.Require().NoError(err)
+		defer func() {
+			_, err = dbModel.SetTestOwnership(s.ctx, s.env, "test", "user", nil)
+			s.NoError(err)
+		}()
+
+		actualResult, err := s.sc.GetFailedTestResultsSample(s.ctx, TestResultsOptions{TaskID: "task1"})
+		s.Require().NoError(err)
+		s.Equal([]string{"test0", "test1", "test2"}, actualResult.TestNames)
+		s.Equal(map[string]string{"test1": "team"}, actualResult.Teams)
+	})
 }
 
 func (s *testResultsConnectorSuite) TestGetTestResultsStats() {
@@ -0,0 +1,234 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/cedar/tracing"
+)
+
+// tracingConnector is a Connector that records a span for each call to the
+// wrapped Connector.
+type tracingConnector struct {
+	Connector
+}
+
+// NewTracingConnector wraps the given Connector so that each of its methods is
+// recorded as a span in the caller's trace.
+func NewTracingConnector(c Connector) Connector {
+	return &tracingConnector{Connector: c}
+}
+
+func startConnectorSpan(ctx context.Context, method string) (context.Context, *tracing.Span) {
+	return tracing.StartSpan(ctx, "data.Connector."+method, tracing.SpanKindInternal)
+}
+
+////////////////////
+// PerformanceResult
+////////////////////
+
+func (c *tracingConnector) FindPerformanceResultById(ctx context.Context, id string) (*model.APIPerformanceResult, error) {
+	ctx, span := startConnectorSpan(ctx, "FindPerformanceResultById")
+	result, err := c.Connector.FindPerformanceResultById(ctx, id)
+	span.Finish(err)
+	return result, err
+}
+
+func (c *tracingConnector) RemovePerformanceResultById(ctx context.Context, id string) (int, error) {
+	ctx, span := startConnectorSpan(ctx, "RemovePerformanceResultById")
+	n, err := c.Connector.RemovePerformanceResultById(ctx, id)
+	span.Finish(err)
+	return n, err
+}
+
+func (c *tracingConnector) FindPerformanceResults(ctx context.Context, opts PerformanceOptions) ([]model.APIPerformanceResult, error) {
+	ctx, span := startConnectorSpan(ctx, "FindPerformanceResults")
+	results, err := c.Connector.FindPerformanceResults(ctx, opts)
+	span.Finish(err)
+	return results, err
+}
+
+func (c *tracingConnector) FindPerformanceResultWithChildren(ctx context.Context, id string, maxDepth int, tags ...string) ([]model.APIPerformanceResult, error) {
+	ctx, span := startConnectorSpan(ctx, "FindPerformanceResultWithChildren")
+	results, err := c.Connector.FindPerformanceResultWithChildren(ctx, id, maxDepth, tags...)
+	span.Finish(err)
+	return results, err
+}
+
+func (c *tracingConnector) ScheduleSignalProcessingRecalculateJobs(ctx context.Context) error {
+	ctx, span := startConnectorSpan(ctx, "ScheduleSignalProcessingRecalculateJobs")
+	err := c.Connector.ScheduleSignalProcessingRecalculateJobs(ctx)
+	span.Finish(err)
+	return err
+}
+
+func (c *tracingConnector) PresignPerformanceResultArtifacts(ctx context.Context, id string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	ctx, span := startConnectorSpan(ctx, "PresignPerformanceResultArtifacts")
+	urls, err := c.Connector.PresignPerformanceResultArtifacts(ctx, id, expiration)
+	span.Finish(err)
+	return urls, err
+}
+
+//////////////////
+// Buildlogger Log
+//////////////////
+
+func (c *tracingConnector) FindLogByID(ctx context.Context, opts BuildloggerOptions) ([]byte, time.Time, bool, error) {
+	ctx, span := startConnectorSpan(ctx, "FindLogByID")
+	data, next, paginated, err := c.Connector.FindLogByID(ctx, opts)
+	span.Finish(err)
+	return data, next, paginated, err
+}
+
+func (c *tracingConnector) FindLogMetadataByID(ctx context.Context, id string) (*model.APILog, error) {
+	ctx, span := startConnectorSpan(ctx, "FindLogMetadataByID")
+	log, err := c.Connector.FindLogMetadataByID(ctx, id)
+	span.Finish(err)
+	return log, err
+}
+
+func (c *tracingConnector) FindLogsByTaskID(ctx context.Context, opts BuildloggerOptions) ([]byte, time.Time, bool, error) {
+	ctx, span := startConnectorSpan(ctx, "FindLogsByTaskID")
+	data, next, paginated, err := c.Connector.FindLogsByTaskID(ctx, opts)
+	span.Finish(err)
+	return data, next, paginated, err
+}
+
+func (c *tracingConnector) FindLogMetadataByTaskID(ctx context.Context, opts BuildloggerOptions) ([]model.APILog, error) {
+	ctx, span := startConnectorSpan(ctx, "FindLogMetadataByTaskID")
+	logs, err := c.Connector.FindLogMetadataByTaskID(ctx, opts)
+	span.Finish(err)
+	return logs, err
+}
+
+func (c *tracingConnector) FindLogsByTestName(ctx context.Context, opts BuildloggerOptions) ([]byte, time.Time, bool, error) {
+	ctx, span := startConnectorSpan(ctx, "FindLogsByTestName")
+	data, next, paginated, err := c.Connector.FindLogsByTestName(ctx, opts)
+	span.Finish(err)
+	return data, next, paginated, err
+}
+
+func (c *tracingConnector) FindLogMetadataByTestName(ctx context.Context, opts BuildloggerOptions) ([]model.APILog, error) {
+	ctx, span := startConnectorSpan(ctx, "FindLogMetadataByTestName")
+	logs, err := c.Connector.FindLogMetadataByTestName(ctx, opts)
+	span.Finish(err)
+	return logs, err
+}
+
+func (c *tracingConnector) FindGroupedLogs(ctx context.Context, opts BuildloggerOptions) ([]byte, time.Time, bool, error) {
+	ctx, span := startConnectorSpan(ctx, "FindGroupedLogs")
+	data, next, paginated, err := c.Connector.FindGroupedLogs(ctx, opts)
+	span.Finish(err)
+	return data, next, paginated, err
+}
+
+func (c *tracingConnector) PresignLogByID(ctx context.Context, id string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	ctx, span := startConnectorSpan(ctx, "PresignLogByID")
+	urls, err := c.Connector.PresignLogByID(ctx, id, expiration)
+	span.Finish(err)
+	return urls, err
+}
+
+///////////////
+// Test Results
+///////////////
+
+func (c *tracingConnector) FindTestResults(ctx context.Context, opts TestResultsOptions) (*model.APITestResults, error) {
+	ctx, span := startConnectorSpan(ctx, "FindTestResults")
+	span.SetAttribute("cedar.task_id", opts.TaskID)
+	results, err := c.Connector.FindTestResults(ctx, opts)
+	span.Finish(err)
+	return results, err
+}
+
+func (c *tracingConnector) GetTestResultsFilteredSamples(ctx context.Context, opts TestSampleOptions) ([]model.APITestResultsSample, error) {
+	ctx, span := startConnectorSpan(ctx, "GetTestResultsFilteredSamples")
+	samples, err := c.Connector.GetTestResultsFilteredSamples(ctx, opts)
+	span.Finish(err)
+	return samples, err
+}
+
+func (c *tracingConnector) GetFailedTestResultsSample(ctx context.Context, opts TestResultsOptions) (*model.APIFailedTestResultsSample, error) {
+	ctx, span := startConnectorSpan(ctx, "GetFailedTestResultsSample")
+	span.SetAttribute("cedar.task_id", opts.TaskID)
+	sample, err := c.Connector.GetFailedTestResultsSample(ctx, opts)
+	span.Finish(err)
+	return sample, err
+}
+
+func (c *tracingConnector) GetTestResultsStats(ctx context.Context, opts TestResultsOptions) (*model.APITestResultsStats, error) {
+	ctx, span := startConnectorSpan(ctx, "GetTestResultsStats")
+	span.SetAttribute("cedar.task_id", opts.TaskID)
+	stats, err := c.Connector.GetTestResultsStats(ctx, opts)
+	span.Finish(err)
+	return stats, err
+}
+
+func (c *tracingConnector) FindTestResultsProject(ctx context.Context, opts TestResultsOptions) (string, error) {
+	ctx, span := startConnectorSpan(ctx, "FindTestResultsProject")
+	span.SetAttribute("cedar.task_id", opts.TaskID)
+	project, err := c.Connector.FindTestResultsProject(ctx, opts)
+	span.Finish(err)
+	return project, err
+}
+
+func (c *tracingConnector) PresignTestResults(ctx context.Context, opts TestResultsOptions, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	ctx, span := startConnectorSpan(ctx, "PresignTestResults")
+	span.SetAttribute("cedar.task_id", opts.TaskID)
+	urls, err := c.Connector.PresignTestResults(ctx, opts, expiration)
+	span.Finish(err)
+	return urls, err
+}
+
+func (c *tracingConnector) FindTestResultLog(ctx context.Context, opts TestResultLogOptions) ([]byte, time.Time, bool, error) {
+	ctx, span := startConnectorSpan(ctx, "FindTestResultLog")
+	span.SetAttribute("cedar.task_id", opts.TaskID)
+	data, next, paginated, err := c.Connector.FindTestResultLog(ctx, opts)
+	span.Finish(err)
+	return data, next, paginated, err
+}
+
+///////////////////////
+// Historical Test Data
+///////////////////////
+
+func (c *tracingConnector) GetHistoricalTestData(ctx context.Context, filter dbModel.HistoricalTestDataFilter) ([]model.APIAggregatedHistoricalTestData, error) {
+	ctx, span := startConnectorSpan(ctx, "GetHistoricalTestData")
+	data, err := c.Connector.GetHistoricalTestData(ctx, filter)
+	span.Finish(err)
+	return data, err
+}
+
+func (c *tracingConnector) GetHistoricalTaskData(ctx context.Context, filter dbModel.HistoricalTaskDataFilter) ([]model.APIAggregatedHistoricalTaskData, error) {
+	ctx, span := startConnectorSpan(ctx, "GetHistoricalTaskData")
+	data, err := c.Connector.GetHistoricalTaskData(ctx, filter)
+	span.Finish(err)
+	return data, err
+}
+
+/////////////////
+// System Metrics
+/////////////////
+
+func (c *tracingConnector) FindSystemMetricsByType(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions, downloadOpts dbModel.SystemMetricsDownloadOptions) ([]byte, int, error) {
+	ctx, span := startConnectorSpan(ctx, "FindSystemMetricsByType")
+	data, next, err := c.Connector.FindSystemMetricsByType(ctx, findOpts, downloadOpts)
+	span.Finish(err)
+	return data, next, err
+}
+
+func (c *tracingConnector) FindSystemMetricsProject(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions) (string, error) {
+	ctx, span := startConnectorSpan(ctx, "FindSystemMetricsProject")
+	project, err := c.Connector.FindSystemMetricsProject(ctx, findOpts)
+	span.Finish(err)
+	return project, err
+}
+
+func (c *tracingConnector) PresignSystemMetricsByType(ctx context.Context, findOpts dbModel.SystemMetricsFindOptions, metricType string, expiration time.Duration) ([]model.APIPresignedURL, error) {
+	ctx, span := startConnectorSpan(ctx, "PresignSystemMetricsByType")
+	urls, err := c.Connector.PresignSystemMetricsByType(ctx, findOpts, metricType, expiration)
+	span.Finish(err)
+	return urls, err
+}
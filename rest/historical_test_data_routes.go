@@ -11,7 +11,9 @@ import (
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
 	"github.com/evergreen-ci/cedar/rest/data"
+	"github.com/evergreen-ci/cedar/units"
 	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/amboy"
 	"github.com/pkg/errors"
 )
 
@@ -52,8 +54,13 @@ const (
 //
 // GET /historical_test_data/{project_id}
 
+//
+// Returns test-level historical data, or task-level historical data if
+// group_by is one of the task values.
+
 type historicalTestDataHandler struct {
-	sc data.Connector
+	sc         data.Connector
+	taskFilter model.HistoricalTaskDataFilter
 	htdFilterHandler
 }
 
@@ -69,7 +76,12 @@ func (h *historicalTestDataHandler) Parse(ctx context.Context, r *http.Request)
 		return errors.Wrap(err, "invalid query parameters")
 	}
 
-	err = h.filter.Validate()
+	if h.taskLevel {
+		h.taskFilter = h.makeTaskFilter()
+		err = h.taskFilter.Validate()
+	} else {
+		err = h.filter.Validate()
+	}
 	if err != nil {
 		return gimlet.ErrorResponse{
 			Message:    err.Error(),
@@ -81,11 +93,32 @@ func (h *historicalTestDataHandler) Parse(ctx context.Context, r *http.Request)
 }
 
 func (h *historicalTestDataHandler) Run(ctx context.Context) gimlet.Responder {
+	if h.taskLevel {
+		data, err := h.sc.GetHistoricalTaskData(ctx, h.taskFilter)
+		if err != nil {
+			return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "fetching historical task data"))
+		}
+
+		return h.makeResponse(len(data), h.taskFilter.Limit,
+			func(i int) string { return data[i].StartAtKey() },
+			func(n int) interface{} { return data[:n] })
+	}
+
 	data, err := h.sc.GetHistoricalTestData(ctx, h.filter)
 	if err != nil {
 		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "fetching historical test data"))
 	}
-	if len(data) == 0 {
+
+	return h.makeResponse(len(data), h.filter.Limit,
+		func(i int) string { return data[i].StartAtKey() },
+		func(n int) interface{} { return data[:n] })
+}
+
+// makeResponse creates the response for the numResults results of a query
+// with the given limit, which is one more than the requested limit so that
+// the start_at key of the next page, if any, is known.
+func (h *historicalTestDataHandler) makeResponse(numResults, limit int, startAtKey func(int) string, results func(int) interface{}) gimlet.Responder {
+	if numResults == 0 {
 		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
 			Message:    "No historical test data found",
 			StatusCode: http.StatusNotFound,
@@ -93,18 +126,18 @@ func (h *historicalTestDataHandler) Run(ctx context.Context) gimlet.Responder {
 	}
 
 	resp := gimlet.NewJSONResponse(nil)
-	requestLimit := h.filter.Limit - 1
-	lastIndex := len(data)
-	if len(data) > requestLimit {
+	requestLimit := limit - 1
+	lastIndex := numResults
+	if numResults > requestLimit {
 		lastIndex = requestLimit
 
-		err = resp.SetPages(&gimlet.ResponsePages{
+		err := resp.SetPages(&gimlet.ResponsePages{
 			Next: &gimlet.Page{
 				Relation:        "next",
 				LimitQueryParam: "limit",
 				KeyQueryParam:   "start_at",
 				BaseURL:         h.sc.GetBaseURL(),
-				Key:             data[requestLimit].StartAtKey(),
+				Key:             startAtKey(requestLimit),
 				Limit:           requestLimit,
 			},
 		})
@@ -112,7 +145,7 @@ func (h *historicalTestDataHandler) Run(ctx context.Context) gimlet.Responder {
 			return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "paginating response"))
 		}
 	}
-	if err = resp.AddData(data[:lastIndex]); err != nil {
+	if err := resp.AddData(results(lastIndex)); err != nil {
 		return gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "creating response"))
 	}
 
@@ -124,9 +157,29 @@ func makeGetHistoricalTestData(sc data.Connector) gimlet.RouteHandler {
 }
 
 // htdFilterHandler handles parsing the url query and populating the
-// HistoricalTestDataFilter for the request.
+// HistoricalTestDataFilter for the request. The taskLevel flag is set when
+// task-level data is requested.
 type htdFilterHandler struct {
-	filter model.HistoricalTestDataFilter
+	filter    model.HistoricalTestDataFilter
+	taskLevel bool
+}
+
+// makeTaskFilter returns the HistoricalTaskDataFilter equivalent to the parsed
+// filter. Tests are ignored.
+func (h *htdFilterHandler) makeTaskFilter() model.HistoricalTaskDataFilter {
+	return model.HistoricalTaskDataFilter{
+		Project:      h.filter.Project,
+		Requesters:   h.filter.Requesters,
+		AfterDate:    h.filter.AfterDate,
+		BeforeDate:   h.filter.BeforeDate,
+		Tasks:        h.filter.Tasks,
+		Variants:     h.filter.Variants,
+		GroupNumDays: h.filter.GroupNumDays,
+		GroupBy:      h.filter.GroupBy,
+		StartAt:      h.filter.StartAt,
+		Limit:        h.filter.Limit,
+		Sort:         h.filter.Sort,
+	}
 }
 
 // parse parses the query parameter values and fills the struct filter field.
@@ -222,6 +275,14 @@ func (h *htdFilterHandler) parse(vals url.Values) error {
 		return err
 	}
 
+	h.filter.Team = vals.Get("team")
+	if h.filter.Team != "" && h.taskLevel {
+		return gimlet.ErrorResponse{
+			Message:    "cannot filter task-level historical data by team",
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
 	return err
 }
 
@@ -300,9 +361,17 @@ func (h *htdFilterHandler) readSort(sortValue string) (model.HTDSort, error) {
 }
 
 // readGroupBy parses a group_by parameter value and returns the corresponding
-// HTDGroupBy struct.
+// HTDGroupBy struct. Task group_by values request task-level data.
 func (h *htdFilterHandler) readGroupBy(groupByValue string) (model.HTDGroupBy, error) {
 	switch groupByValue {
+	// We no longer store distro, so task-level data grouped by distro is
+	// grouped by variant, the same as test-level data.
+	case htdAPITaskGroupByVariant, htdAPITaskGroupByDistro:
+		h.taskLevel = true
+		return model.HTDGroupByVariant, nil
+	case htdAPITaskGroupByTask:
+		h.taskLevel = true
+		return model.HTDGroupByTask, nil
 	case htdAPITestGroupByVariant:
 		return model.HTDGroupByVariant, nil
 	case htdAPITestGroupByTask:
@@ -351,3 +420,82 @@ func (h *htdFilterHandler) readStartAt(startAtValue string) (*model.HTDStartAt,
 		Test:    elements[3],
 	}, nil
 }
+
+// HistoricalTestDataRecomputeRequest is the payload for recomputing the
+// historical test data of a project. The dates are of the form YYYY-MM-DD and
+// the end date is inclusive.
+type HistoricalTestDataRecomputeRequest struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// HistoricalTestDataRecomputeResponse is the response for recomputing the
+// historical test data of a project.
+type HistoricalTestDataRecomputeResponse struct {
+	JobID string `json:"job_id"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/historical_test_data/{project_id}/recompute
+//
+// Enqueues a job that rebuilds the historical test and task data of the
+// project for the date range from the stored test results. Requests for a project and date
+// range that was already requested return the ID of the existing job.
+
+func (s *Service) recomputeHistoricalTestData(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+	setAuditTarget(r.Context(), "project/"+project+"/historical_test_data")
+
+	req := &HistoricalTestDataRecomputeRequest{}
+	if err := gimlet.GetJSON(r.Body, req); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading recompute request").Error(),
+		}))
+		return
+	}
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"start": req.Start,
+		"end":   req.End,
+	})
+
+	opts, err := parseHistoricalTestDataRecomputeRequest(project, req)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	q := s.Environment.GetRemoteQueue()
+	if q == nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(errors.New("remote queue is not configured")))
+		return
+	}
+	j := units.NewHistoricalTestDataRecomputeJob(s.Environment, opts)
+	if err = amboy.EnqueueUniqueJob(r.Context(), q, units.WithTraceContext(r.Context(), j)); err != nil && !amboy.IsDuplicateJobError(err) {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "enqueueing historical test data recompute job")))
+		return
+	}
+
+	gimlet.WriteJSON(rw, HistoricalTestDataRecomputeResponse{JobID: j.ID()})
+}
+
+func parseHistoricalTestDataRecomputeRequest(project string, req *HistoricalTestDataRecomputeRequest) (model.RecomputeHistoricalTestDataOptions, error) {
+	opts := model.RecomputeHistoricalTestDataOptions{Project: project}
+
+	var err error
+	if opts.StartAt, err = time.ParseInLocation(htdAPIDateFormat, req.Start, time.UTC); err != nil {
+		return opts, errors.Wrapf(err, "parsing start date '%s'", req.Start)
+	}
+	end, err := time.ParseInLocation(htdAPIDateFormat, req.End, time.UTC)
+	if err != nil {
+		return opts, errors.Wrapf(err, "parsing end date '%s'", req.End)
+	}
+	opts.EndAt = end.Add(24 * time.Hour)
+
+	return opts, opts.Validate()
+}
@@ -97,6 +97,79 @@ func TestHTDDataHandlerRun(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, resp.Status())
 }
 
+func TestHTDFilterHandlerParseTaskLevel(t *testing.T) {
+	for groupBy, expected := range map[string]dbModel.HTDGroupBy{
+		htdAPITaskGroupByTask:    dbModel.HTDGroupByTask,
+		htdAPITaskGroupByVariant: dbModel.HTDGroupByVariant,
+		htdAPITaskGroupByDistro:  dbModel.HTDGroupByVariant,
+	} {
+		t.Run(groupBy, func(t *testing.T) {
+			values := url.Values{
+				"after_date":  []string{"1998-07-12"},
+				"before_date": []string{"2018-07-15"},
+				"tasks":       []string{"task1", "task2"},
+				"group_by":    []string{groupBy},
+			}
+			handler := htdFilterHandler{}
+			require.NoError(t, handler.parse(values))
+			assert.True(t, handler.taskLevel)
+
+			filter := handler.makeTaskFilter()
+			assert.Equal(t, expected, filter.GroupBy)
+			assert.Equal(t, values["tasks"], filter.Tasks)
+			assert.Equal(t, []string{cedar.RepotrackerVersionRequester}, filter.Requesters)
+			assert.NoError(t, filter.Validate())
+		})
+	}
+	t.Run("TestGroupBy", func(t *testing.T) {
+		handler := htdFilterHandler{}
+		require.NoError(t, handler.parse(url.Values{
+			"after_date":  []string{"1998-07-12"},
+			"before_date": []string{"2018-07-15"},
+			"group_by":    []string{htdAPITestGroupByTask},
+		}))
+		assert.False(t, handler.taskLevel)
+		assert.Equal(t, dbModel.HTDGroupByTask, handler.filter.GroupBy)
+	})
+}
+
+func TestHTDTaskDataHandlerRun(t *testing.T) {
+	sc := &data.MockConnector{
+		CachedHistoricalTaskData: []dbModel.AggregatedHistoricalTaskData{
+			{TaskName: "task1", Variant: "v1", Date: utility.GetUTCDay(time.Now()), NumPass: 1},
+			{TaskName: "task2", Variant: "v1", Date: utility.GetUTCDay(time.Now()), NumFail: 1},
+			{TaskName: "task3", Variant: "v1", Date: utility.GetUTCDay(time.Now()), NumPass: 2},
+		},
+	}
+	handler := makeGetHistoricalTestData(sc).(*historicalTestDataHandler)
+	handler.taskLevel = true
+
+	handler.taskFilter = dbModel.HistoricalTaskDataFilter{Limit: 4}
+	resp := handler.Run(context.Background())
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusOK, resp.Status())
+	assert.Nil(t, resp.Pages())
+	data, ok := resp.Data().([]model.APIAggregatedHistoricalTaskData)
+	require.True(t, ok)
+	assert.Len(t, data, 3)
+
+	// pagination
+	handler.taskFilter = dbModel.HistoricalTaskDataFilter{Limit: 3}
+	resp = handler.Run(context.Background())
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusOK, resp.Status())
+	require.NotNil(t, resp.Pages())
+	lastDoc := model.APIAggregatedHistoricalTaskData{}
+	require.NoError(t, lastDoc.Import(sc.CachedHistoricalTaskData[2]))
+	assert.Equal(t, lastDoc.StartAtKey(), resp.Pages().Next.Key)
+
+	// no data
+	sc.CachedHistoricalTaskData = []dbModel.AggregatedHistoricalTaskData{}
+	resp = handler.Run(context.Background())
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusNotFound, resp.Status())
+}
+
 func TestHTDReadStartAt(t *testing.T) {
 
 	// 5 values
@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+func parseIngestionStatsFindOptions(vals url.Values) (model.IngestionStatsFindOptions, error) {
+	opts := model.IngestionStatsFindOptions{
+		Cache:   vals.Get("cache"),
+		Project: vals.Get("project"),
+	}
+
+	var err error
+	if start := vals.Get("start"); start != "" {
+		if opts.StartAt, err = time.Parse(time.RFC3339, start); err != nil {
+			return opts, errors.Wrapf(err, "parsing start time '%s'", start)
+		}
+	}
+	if end := vals.Get("end"); end != "" {
+		if opts.EndAt, err = time.Parse(time.RFC3339, end); err != nil {
+			return opts, errors.Wrapf(err, "parsing end time '%s'", end)
+		}
+	}
+	if limit := vals.Get("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil {
+			return opts, errors.Wrapf(err, "parsing limit '%s'", limit)
+		}
+	}
+
+	return opts, opts.Validate()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /admin/stats/ingestion
+//
+// Returns the ingestion stats summed per stats cache and project, noisiest
+// first. The count is in bytes for buildlogger, results for test results and
+// artifacts for perf.
+
+func (s *Service) getIngestionStatsTotals(rw http.ResponseWriter, r *http.Request) {
+	opts, err := parseIngestionStatsFindOptions(r.URL.Query())
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	totals, err := model.FindIngestionStatsTotals(r.Context(), s.Environment, opts)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, totals)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /admin/stats/ingestion/{project_id}
+//
+// Returns the hourly ingestion stats of the project, oldest first.
+
+func (s *Service) getProjectIngestionStats(rw http.ResponseWriter, r *http.Request) {
+	opts, err := parseIngestionStatsFindOptions(r.URL.Query())
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+	opts.Project = gimlet.GetVars(r)["project_id"]
+
+	stats, err := model.FindIngestionStats(r.Context(), s.Environment, opts)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, stats)
+}
@@ -1,19 +1,646 @@
 package rest
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"time"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/oidc"
 	"github.com/evergreen-ci/cedar/rest/data"
+	"github.com/evergreen-ci/cedar/tracing"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/db"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
 )
 
+type requireAdminMiddleware struct{}
+
+// newRequireAdminMiddleware returns an implementation of gimlet.Middleware
+// that returns an error if the requesting user does not have the Cedar admin
+// role.
+func newRequireAdminMiddleware() *requireAdminMiddleware {
+	return &requireAdminMiddleware{}
+}
+
+func (m *requireAdminMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	opts := gimlet.PermissionOpts{
+		ResourceType: model.PermissionResourceTypeSystem,
+		Permission:   model.PermissionAdmin,
+	}
+	if resp := checkUserPermission(r.Context(), opts); resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	next(rw, r)
+}
+
+type requirePerfProjectPermissionMiddleware struct {
+	sc         data.Connector
+	permission string
+}
+
+// newRequirePerfProjectPermissionMiddleware returns an implementation of
+// gimlet.Middleware that returns an error if the requesting user does not
+// have the given permission on the project of the performance result with the
+// ID in the request.
+func newRequirePerfProjectPermissionMiddleware(sc data.Connector, permission string) *requirePerfProjectPermissionMiddleware {
+	return &requirePerfProjectPermissionMiddleware{
+		sc:         sc,
+		permission: permission,
+	}
+}
+
+func (m *requirePerfProjectPermissionMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+
+	if gimlet.GetUser(ctx) == nil {
+		gimlet.WriteResponse(rw, unauthorizedUserResponder())
+		return
+	}
+
+	id := gimlet.GetVars(r)["id"]
+	result, err := m.sc.FindPerformanceResultById(ctx, id)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+
+	opts := gimlet.PermissionOpts{
+		Resource:     utility.FromStringPtr(result.Info.Project),
+		ResourceType: model.PermissionResourceTypeProject,
+		Permission:   m.permission,
+	}
+	if resp := checkUserPermission(ctx, opts); resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	next(rw, r)
+}
+
+type requireTaskProjectPermissionMiddleware struct {
+	permission  string
+	findProject func(context.Context, *http.Request) (string, error)
+}
+
+// newRequireTestResultsProjectPermissionMiddleware returns an implementation
+// of gimlet.Middleware that returns an error if the requesting user does not
+// have the given permission on the project of the test results with the task
+// ID in the request.
+func newRequireTestResultsProjectPermissionMiddleware(sc data.Connector, permission string) *requireTaskProjectPermissionMiddleware {
+	return &requireTaskProjectPermissionMiddleware{
+		permission: permission,
+		findProject: func(ctx context.Context, r *http.Request) (string, error) {
+			h := &testResultsBaseHandler{}
+			if err := h.Parse(ctx, r); err != nil {
+				return "", gimlet.ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    errors.Wrap(err, "parsing request").Error(),
+				}
+			}
+
+			return sc.FindTestResultsProject(ctx, h.opts)
+		},
+	}
+}
+
+// newRequireSystemMetricsProjectPermissionMiddleware returns an
+// implementation of gimlet.Middleware that returns an error if the requesting
+// user does not have the given permission on the project of the system
+// metrics with the task ID in the request.
+func newRequireSystemMetricsProjectPermissionMiddleware(sc data.Connector, permission string) *requireTaskProjectPermissionMiddleware {
+	return &requireTaskProjectPermissionMiddleware{
+		permission: permission,
+		findProject: func(ctx context.Context, r *http.Request) (string, error) {
+			findOpts, err := parseSystemMetricsFindOptions(r)
+			if err != nil {
+				return "", gimlet.ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    errors.Wrap(err, "parsing request").Error(),
+				}
+			}
+
+			return sc.FindSystemMetricsProject(ctx, findOpts)
+		},
+	}
+}
+
+// newRequireDisplayTaskTestResultsProjectPermissionMiddleware returns an
+// implementation of gimlet.Middleware that returns an error if the requesting
+// user does not have the given permission on the project of the test results
+// with the display task ID in the request.
+func newRequireDisplayTaskTestResultsProjectPermissionMiddleware(sc data.Connector, permission string) *requireTaskProjectPermissionMiddleware {
+	return &requireTaskProjectPermissionMiddleware{
+		permission: permission,
+		findProject: func(ctx context.Context, r *http.Request) (string, error) {
+			h := &testResultsGetByDisplayTaskIDHandler{}
+			if err := h.Parse(ctx, r); err != nil {
+				return "", gimlet.ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    errors.Wrap(err, "parsing request").Error(),
+				}
+			}
+
+			return sc.FindTestResultsProject(ctx, h.opts)
+		},
+	}
+}
+
+// newRequireProjectPermissionMiddleware returns an implementation of
+// gimlet.Middleware that returns an error if the requesting user does not
+// have the given permission on the project with the ID in the request.
+func newRequireProjectPermissionMiddleware(permission string) *requireTaskProjectPermissionMiddleware {
+	return &requireTaskProjectPermissionMiddleware{
+		permission: permission,
+		findProject: func(_ context.Context, r *http.Request) (string, error) {
+			return gimlet.GetVars(r)["project_id"], nil
+		},
+	}
+}
+
+func (m *requireTaskProjectPermissionMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+
+	if gimlet.GetUser(ctx) == nil {
+		gimlet.WriteResponse(rw, unauthorizedUserResponder())
+		return
+	}
+
+	project, err := m.findProject(ctx, r)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+
+	opts := gimlet.PermissionOpts{
+		Resource:     project,
+		ResourceType: model.PermissionResourceTypeProject,
+		Permission:   m.permission,
+	}
+	if resp := checkUserPermission(ctx, opts); resp != nil {
+		gimlet.WriteResponse(rw, resp)
+		return
+	}
+
+	next(rw, r)
+}
+
+type requireProjectsPermissionMiddleware struct {
+	permission   string
+	findProjects func(context.Context, *http.Request) ([]string, error)
+}
+
+// newRequirePerfResultsProjectPermissionMiddleware returns an implementation
+// of gimlet.Middleware that returns an error if the requesting user does not
+// have the given permission on the projects of all of the performance results
+// matching the request, as parsed by the given function.
+func newRequirePerfResultsProjectPermissionMiddleware(sc data.Connector, permission string, parse func(context.Context, *http.Request) (data.PerformanceOptions, error)) *requireProjectsPermissionMiddleware {
+	return &requireProjectsPermissionMiddleware{
+		permission: permission,
+		findProjects: func(ctx context.Context, r *http.Request) ([]string, error) {
+			opts, err := parse(ctx, r)
+			if err != nil {
+				return nil, gimlet.ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    errors.Wrap(err, "parsing request").Error(),
+				}
+			}
+
+			results, err := sc.FindPerformanceResults(ctx, opts)
+			if errResp, ok := err.(gimlet.ErrorResponse); ok && errResp.StatusCode == http.StatusNotFound {
+				// There is nothing to protect, let the route handle
+				// the request.
+				return nil, nil
+			} else if err != nil {
+				return nil, err
+			}
+
+			projects := make([]string, 0, len(results))
+			for _, result := range results {
+				projects = append(projects, utility.FromStringPtr(result.Info.Project))
+			}
+
+			return projects, nil
+		},
+	}
+}
+
+// newRequireTestResultsTasksProjectPermissionMiddleware returns an
+// implementation of gimlet.Middleware that returns an error if the requesting
+// user does not have the given permission on the projects of the test results
+// of all the tasks in the JSON request body.
+func newRequireTestResultsTasksProjectPermissionMiddleware(sc data.Connector, permission string) *requireProjectsPermissionMiddleware {
+	return &requireProjectsPermissionMiddleware{
+		permission: permission,
+		findProjects: func(ctx context.Context, r *http.Request) ([]string, error) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				return nil, gimlet.ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    errors.Wrap(err, "reading request body").Error(),
+				}
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+			opts := data.TestSampleOptions{}
+			if err = json.Unmarshal(body, &opts); err != nil {
+				return nil, gimlet.ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    errors.Wrap(err, "parsing request body").Error(),
+				}
+			}
+
+			projects := []string{}
+			for _, task := range opts.Tasks {
+				project, err := sc.FindTestResultsProject(ctx, data.TestResultsOptions{
+					TaskID:      task.TaskID,
+					Execution:   utility.ToIntPtr(task.Execution),
+					DisplayTask: task.DisplayTask,
+				})
+				if errResp, ok := err.(gimlet.ErrorResponse); ok && errResp.StatusCode == http.StatusNotFound {
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				projects = append(projects, project)
+			}
+
+			return projects, nil
+		},
+	}
+}
+
+func (m *requireProjectsPermissionMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+
+	if gimlet.GetUser(ctx) == nil {
+		gimlet.WriteResponse(rw, unauthorizedUserResponder())
+		return
+	}
+
+	projects, err := m.findProjects(ctx, r)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(err))
+		return
+	}
+
+	checked := map[string]bool{}
+	for _, project := range projects {
+		if checked[project] {
+			continue
+		}
+		checked[project] = true
+
+		opts := gimlet.PermissionOpts{
+			Resource:     project,
+			ResourceType: model.PermissionResourceTypeProject,
+			Permission:   m.permission,
+		}
+		if resp := checkUserPermission(ctx, opts); resp != nil {
+			gimlet.WriteResponse(rw, resp)
+			return
+		}
+	}
+
+	next(rw, r)
+}
+
+type optionalProjectReadMiddleware struct {
+	env   cedar.Environment
+	check gimlet.Middleware
+}
+
+// newOptionalProjectReadMiddleware returns an implementation of
+// gimlet.Middleware that only applies the given project read permission check
+// when the enforce_project_read_roles operational flag is set. Otherwise, the
+// read routes stay open to anonymous users.
+func newOptionalProjectReadMiddleware(env cedar.Environment, check gimlet.Middleware) *optionalProjectReadMiddleware {
+	return &optionalProjectReadMiddleware{
+		env:   env,
+		check: check,
+	}
+}
+
+func (m *optionalProjectReadMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	conf := model.NewCedarConfig(m.env)
+	if err := conf.Find(); err != nil && !db.ResultsNotFound(err) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "finding Cedar configuration")))
+		return
+	}
+	if !conf.Flags.EnforceProjectReadRoles {
+		next(rw, r)
+		return
+	}
+
+	m.check.ServeHTTP(rw, r, next)
+}
+
+// checkUserPermission returns an error responder if there is no user attached
+// to the context or if the user does not have the given permission.
+func checkUserPermission(ctx context.Context, opts gimlet.PermissionOpts) gimlet.Responder {
+	u := gimlet.GetUser(ctx)
+	if u == nil {
+		return unauthorizedUserResponder()
+	}
+
+	if !model.UserHasPermission(u, opts) {
+		return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusForbidden,
+			Message:    fmt.Sprintf("user '%s' does not have permission '%s'", u.Username(), opts.Permission),
+		})
+	}
+
+	return nil
+}
+
+func unauthorizedUserResponder() gimlet.Responder {
+	return gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+		StatusCode: http.StatusUnauthorized,
+		Message:    "unauthorized user",
+	})
+}
+
+type requireSelfOrAdminMiddleware struct{}
+
+// newRequireSelfOrAdminMiddleware returns an implementation of
+// gimlet.Middleware that returns an error if the requesting user is neither
+// the user with the ID in the request nor a Cedar admin.
+func newRequireSelfOrAdminMiddleware() *requireSelfOrAdminMiddleware {
+	return &requireSelfOrAdminMiddleware{}
+}
+
+func (m *requireSelfOrAdminMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+
+	u := gimlet.GetUser(ctx)
+	if u == nil {
+		gimlet.WriteResponse(rw, unauthorizedUserResponder())
+		return
+	}
+
+	if u.Username() != gimlet.GetVars(r)["user_id"] {
+		opts := gimlet.PermissionOpts{
+			ResourceType: model.PermissionResourceTypeSystem,
+			Permission:   model.PermissionAdmin,
+		}
+		if resp := checkUserPermission(ctx, opts); resp != nil {
+			gimlet.WriteResponse(rw, resp)
+			return
+		}
+	}
+
+	next(rw, r)
+}
+
+type namedAPIKeyMiddleware struct {
+	env cedar.Environment
+}
+
+// newNamedAPIKeyMiddleware returns an implementation of gimlet.Middleware
+// that authenticates requests using one of the user's named API keys. This
+// must run before the gimlet user middleware, which only knows about the
+// user's legacy API key. Requests with a named key that is expired or
+// revoked are rejected, all other requests are passed through untouched.
+func newNamedAPIKeyMiddleware(env cedar.Environment) *namedAPIKeyMiddleware {
+	return &namedAPIKeyMiddleware{env: env}
+}
+
+func (m *namedAPIKeyMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	userID := r.Header.Get(cedar.APIUserHeader)
+	key := r.Header.Get(cedar.APIKeyHeader)
+	if userID == "" || key == "" {
+		next(rw, r)
+		return
+	}
+
+	u, apiKey, err := model.FindUserByNamedAPIKey(m.env, userID, key)
+	if err != nil || apiKey == nil {
+		next(rw, r)
+		return
+	}
+	if !apiKey.IsValid(key, time.Now()) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusUnauthorized,
+			Message:    fmt.Sprintf("API key '%s' is expired or revoked", apiKey.Name),
+		}))
+		return
+	}
+
+	// Remove the credentials so that the gimlet user middleware does not
+	// try to validate them against the user's legacy API key.
+	r.Header.Del(cedar.APIUserHeader)
+	r.Header.Del(cedar.APIKeyHeader)
+
+	next(rw, r.WithContext(gimlet.AttachUser(r.Context(), u)))
+}
+
+type bearerTokenMiddleware struct {
+	um gimlet.UserManager
+}
+
+// newBearerTokenMiddleware returns an implementation of gimlet.Middleware
+// that authenticates requests with an "Authorization: Bearer" header, such as
+// OIDC tokens, using the given user manager. Requests with an invalid token
+// are rejected, requests without a bearer token are passed through untouched.
+func newBearerTokenMiddleware(um gimlet.UserManager) *bearerTokenMiddleware {
+	return &bearerTokenMiddleware{um: um}
+}
+
+func (m *bearerTokenMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	token, ok := oidc.ParseBearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		next(rw, r)
+		return
+	}
+
+	ctx := r.Context()
+	u, err := m.um.GetUserByToken(ctx, token)
+	if err != nil || u == nil {
+		grip.Debug(message.WrapError(err, message.Fields{
+			"message": "rejecting invalid bearer token",
+			"request": gimlet.GetRequestID(ctx),
+		}))
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusUnauthorized,
+			Message:    "invalid bearer token",
+		}))
+		return
+	}
+
+	next(rw, r.WithContext(gimlet.AttachUser(ctx, u)))
+}
+
+type auditContextKey int
+
+const auditRecordKey auditContextKey = 0
+
+// auditRecord holds the values of an audited request that are only known to
+// the route handler.
+type auditRecord struct {
+	user   string
+	target string
+	params map[string]interface{}
+	before interface{}
+	after  interface{}
+}
+
+func getAuditRecord(ctx context.Context) *auditRecord {
+	record, _ := ctx.Value(auditRecordKey).(*auditRecord)
+	return record
+}
+
+// setAuditUser sets the user of the audited request for routes that do not
+// require an authenticated user. This is a no-op if the request is not
+// audited.
+func setAuditUser(ctx context.Context, user string) {
+	if record := getAuditRecord(ctx); record != nil {
+		record.user = user
+	}
+}
+
+// setAuditTarget sets the target of the audited request. This is a no-op if
+// the request is not audited.
+func setAuditTarget(ctx context.Context, target string) {
+	if record := getAuditRecord(ctx); record != nil {
+		record.target = target
+	}
+}
+
+// addAuditParameters adds parameters from the request body to the audited
+// request. This is a no-op if the request is not audited.
+func addAuditParameters(ctx context.Context, params map[string]interface{}) {
+	if record := getAuditRecord(ctx); record != nil {
+		for key, value := range params {
+			record.params[key] = value
+		}
+	}
+}
+
+// setAuditChange sets the values of the target before and after the audited
+// request. This is a no-op if the request is not audited.
+func setAuditChange(ctx context.Context, before, after interface{}) {
+	if record := getAuditRecord(ctx); record != nil {
+		record.before = before
+		record.after = after
+	}
+}
+
+type auditMiddleware struct {
+	env    cedar.Environment
+	action string
+	ttl    time.Duration
+}
+
+// newAuditMiddleware returns an implementation of gimlet.Middleware that
+// records an audit event for the given action once the request completes,
+// regardless of whether it succeeded, and keeps it for the given TTL. The
+// route and query parameters are recorded automatically, route handlers may
+// add the target, body parameters and before and after values. This should
+// run before any authorization middleware so that denied requests are also
+// recorded.
+func newAuditMiddleware(env cedar.Environment, action string, ttl time.Duration) *auditMiddleware {
+	return &auditMiddleware{
+		env:    env,
+		action: action,
+		ttl:    ttl,
+	}
+}
+
+func (m *auditMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	record := &auditRecord{params: map[string]interface{}{}}
+	if u := gimlet.GetUser(r.Context()); u != nil {
+		record.user = u.Username()
+	}
+	for key, value := range gimlet.GetVars(r) {
+		record.params[key] = value
+	}
+	for key, values := range r.URL.Query() {
+		if len(values) == 1 {
+			record.params[key] = values[0]
+		} else {
+			record.params[key] = values
+		}
+	}
+
+	srw := &statusResponseWriter{ResponseWriter: rw, status: http.StatusOK}
+	next(srw, r.WithContext(context.WithValue(r.Context(), auditRecordKey, record)))
+
+	event := model.NewAuditEvent(record.user, m.action, record.target, record.params)
+	event.Route = fmt.Sprintf("%s %s", r.Method, r.URL.Path)
+	event.RequestID = gimlet.GetRequestID(r.Context())
+	event.StatusCode = srw.status
+	event.Before = record.before
+	event.After = record.after
+	event.SetTTL(m.ttl)
+	event.Setup(m.env)
+
+	// The request context may already be canceled if the client has gone
+	// away, but the action should still be recorded.
+	ctx, cancel := m.env.Context()
+	defer cancel()
+	logRequestError(r, errors.Wrapf(event.Save(ctx), "recording audit event for action '%s'", m.action))
+}
+
+// statusResponseWriter records the status code written to the wrapped
+// response writer.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Flush implements http.Flusher so that wrapping the response writer does not
+// prevent handlers from streaming their response.
+func (w *statusResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+type tracingMiddleware struct{}
+
+// newTracingMiddleware returns an implementation of gimlet.Middleware that
+// records a server span for each request, continuing the caller's trace if the
+// request has a traceparent header.
+func newTracingMiddleware() *tracingMiddleware {
+	return &tracingMiddleware{}
+}
+
+func (m *tracingMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx, span := tracing.StartSpan(tracing.ExtractHTTP(r.Context(), r.Header), "HTTP "+r.Method, tracing.SpanKindServer)
+	if span == nil {
+		next(rw, r)
+		return
+	}
+	defer span.End()
+
+	span.SetAttribute("http.method", r.Method)
+	span.SetAttribute("http.target", r.URL.Path)
+	span.SetAttribute("http.request_id", gimlet.GetRequestID(ctx))
+
+	srw := &statusResponseWriter{ResponseWriter: rw, status: http.StatusOK}
+	next(srw, r.WithContext(ctx))
+
+	span.SetAttribute("http.status_code", srw.status)
+	if srw.status >= http.StatusInternalServerError {
+		span.RecordError(errors.Errorf("request failed with status %d", srw.status))
+	}
+}
+
 type certCheckDepotMiddleware struct {
 	depotDisabled bool
 }
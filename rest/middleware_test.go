@@ -6,9 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -130,3 +134,105 @@ func (h *evgAuthMockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	_, _ = w.Write([]byte(fmt.Sprintf("%v", h.returnTrue)))
 }
+
+func TestRequireProjectsPermissionMiddleware(t *testing.T) {
+	reader := &model.User{ID: "reader", SystemRoles: []string{model.FormatRole(model.RoleReadOnly, "project")}}
+	m := &requireProjectsPermissionMiddleware{
+		permission: model.PermissionProjectRead,
+		findProjects: func(_ context.Context, r *http.Request) ([]string, error) {
+			return r.URL.Query()["project"], nil
+		},
+	}
+	serve := func(u *model.User, query string) int {
+		req := httptest.NewRequest(http.MethodGet, "/route?"+query, nil)
+		if u != nil {
+			req = req.WithContext(gimlet.AttachUser(req.Context(), u))
+		}
+		rw := httptest.NewRecorder()
+		m.ServeHTTP(rw, req, func(rw http.ResponseWriter, _ *http.Request) { rw.WriteHeader(http.StatusOK) })
+		return rw.Code
+	}
+
+	t.Run("NoUser", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(nil, "project=project"))
+	})
+	t.Run("ReadableProject", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(reader, "project=project&project=project"))
+	})
+	t.Run("NoProjects", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(reader, ""))
+	})
+	t.Run("UnreadableProject", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(reader, "project=project&project=other"))
+	})
+}
+
+func TestOptionalProjectReadMiddleware(t *testing.T) {
+	env := cedar.GetEnvironment()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, env.GetDB().Collection("configuration").Drop(ctx))
+	}()
+
+	reader := &model.User{ID: "reader", SystemRoles: []string{model.FormatRole(model.RoleReadOnly, "project")}}
+	m := newOptionalProjectReadMiddleware(env, newRequireProjectPermissionMiddleware(model.PermissionProjectRead))
+	serve := func(u *model.User, project string) int {
+		req := httptest.NewRequest(http.MethodGet, "/route", nil)
+		req = gimlet.SetURLVars(req, map[string]string{"project_id": project})
+		if u != nil {
+			req = req.WithContext(gimlet.AttachUser(req.Context(), u))
+		}
+		rw := httptest.NewRecorder()
+		m.ServeHTTP(rw, req, func(rw http.ResponseWriter, _ *http.Request) { rw.WriteHeader(http.StatusOK) })
+		return rw.Code
+	}
+
+	conf := model.NewCedarConfig(env)
+	require.NoError(t, conf.Save())
+	t.Run("NotEnforced", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(nil, "project"))
+		assert.Equal(t, http.StatusOK, serve(reader, "other"))
+	})
+
+	require.NoError(t, conf.Flags.SetEnforceProjectReadRoles(true))
+	t.Run("Enforced", func(t *testing.T) {
+		// The configuration may be cached, so wait for the update.
+		retryOp := func() (bool, error) {
+			if code := serve(nil, "project"); code != http.StatusUnauthorized {
+				return true, errors.Errorf("unexpected status %d", code)
+			}
+			return false, nil
+		}
+		require.NoError(t, utility.Retry(ctx, retryOp, utility.RetryOptions{MaxAttempts: 5}))
+		assert.Equal(t, http.StatusOK, serve(reader, "project"))
+		assert.Equal(t, http.StatusForbidden, serve(reader, "other"))
+	})
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	env := cedar.GetEnvironment()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer func() {
+		assert.NoError(t, env.GetDB().Collection("audit_events").Drop(ctx))
+	}()
+
+	m := newAuditMiddleware(env, model.AuditActionSetServiceFlag, time.Hour)
+	req := httptest.NewRequest(http.MethodPost, "/admin/service/flag/flag/enabled", nil)
+	req = req.WithContext(gimlet.AttachUser(req.Context(), &model.User{ID: "user"}))
+	rw := httptest.NewRecorder()
+	m.ServeHTTP(rw, req, func(rw http.ResponseWriter, _ *http.Request) {
+		// A denied request should still be recorded.
+		rw.WriteHeader(http.StatusForbidden)
+		rw.(http.Flusher).Flush()
+	})
+	assert.True(t, rw.Flushed)
+
+	events, err := model.FindAuditEvents(ctx, env, model.AuditEventFindOptions{Action: model.AuditActionSetServiceFlag})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "user", events[0].User)
+	assert.Equal(t, http.StatusForbidden, events[0].StatusCode)
+	assert.WithinDuration(t, events[0].Timestamp.Add(time.Hour), events[0].ExpiresAt, time.Second)
+}
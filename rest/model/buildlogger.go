@@ -14,6 +14,7 @@ type APILog struct {
 	CompletedAt APITime            `json:"completed_at"`
 	Duration    float64            `json:"duration_secs"`
 	Artifact    APILogArtifactInfo `json:"artifact"`
+	Redactions  int                `json:"redactions"`
 }
 
 // Import transforms a Log object into an APILog object.
@@ -26,6 +27,7 @@ func (apiResult *APILog) Import(i interface{}) error {
 		apiResult.CompletedAt = NewTime(l.CompletedAt)
 		apiResult.Duration = l.CompletedAt.Sub(l.CreatedAt).Seconds()
 		apiResult.Artifact = getLogArtifactInfo(l.Artifact)
+		apiResult.Redactions = l.Redactions
 	default:
 		return errors.New("incorrect type when fetching converting Log type")
 	}
@@ -47,6 +49,9 @@ type APILogInfo struct {
 	Tags        []string          `json:"tags,omitempty"`
 	Arguments   map[string]string `json:"args,omitempty"`
 	ExitCode    int               `json:"exit_code,omitempty"`
+	// Evergreen is not set until the log has been enriched with
+	// the metadata of its Evergreen task.
+	Evergreen *APIEvergreenMetadata `json:"evergreen,omitempty"`
 }
 
 func getLogInfo(l dbmodel.LogInfo) APILogInfo {
@@ -64,6 +69,7 @@ func getLogInfo(l dbmodel.LogInfo) APILogInfo {
 		Tags:        l.Tags,
 		Arguments:   l.Arguments,
 		ExitCode:    l.ExitCode,
+		Evergreen:   getEvergreenMetadata(l.Evergreen),
 	}
 }
 
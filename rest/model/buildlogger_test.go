@@ -32,6 +32,13 @@ func TestBuildloggerImport(t *testing.T) {
 				ExitCode:    2,
 				Mainline:    true,
 				Schema:      0,
+				Evergreen: &dbmodel.EvergreenMetadata{
+					Author:     "author",
+					Revision:   "abcdef",
+					CommitTime: time.Now().Add(-2 * time.Hour),
+					Requester:  "gitter_request",
+					EnrichedAt: time.Now(),
+				},
 			},
 			CreatedAt:   time.Now().Add(-1 * time.Hour),
 			CompletedAt: time.Now(),
@@ -72,6 +79,16 @@ func TestBuildloggerImport(t *testing.T) {
 				Tags:        log.Info.Tags,
 				Arguments:   log.Info.Arguments,
 				ExitCode:    2,
+				Evergreen: &APIEvergreenMetadata{
+					Author:             utility.ToStringPtr("author"),
+					AuthorEmail:        utility.ToStringPtr(""),
+					Revision:           utility.ToStringPtr("abcdef"),
+					CommitTime:         NewTime(log.Info.Evergreen.CommitTime),
+					Requester:          utility.ToStringPtr("gitter_request"),
+					Distro:             utility.ToStringPtr(""),
+					VariantDisplayName: utility.ToStringPtr(""),
+					EnrichedAt:         NewTime(log.Info.Evergreen.EnrichedAt),
+				},
 			},
 			CreatedAt:   NewTime(log.CreatedAt),
 			CompletedAt: NewTime(log.CompletedAt),
@@ -0,0 +1,38 @@
+package model
+
+import (
+	dbmodel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/utility"
+)
+
+// APIEvergreenMetadata describes the Evergreen task, version and build
+// metadata of a record.
+type APIEvergreenMetadata struct {
+	Author             *string `json:"author,omitempty"`
+	AuthorEmail        *string `json:"author_email,omitempty"`
+	Revision           *string `json:"revision,omitempty"`
+	CommitTime         APITime `json:"commit_time"`
+	Requester          *string `json:"requester,omitempty"`
+	Distro             *string `json:"distro,omitempty"`
+	VariantDisplayName *string `json:"variant_display_name,omitempty"`
+	EnrichedAt         APITime `json:"enriched_at"`
+}
+
+// getEvergreenMetadata returns the API model of the Evergreen metadata or nil
+// if the record has not been enriched yet.
+func getEvergreenMetadata(m *dbmodel.EvergreenMetadata) *APIEvergreenMetadata {
+	if m == nil {
+		return nil
+	}
+
+	return &APIEvergreenMetadata{
+		Author:             utility.ToStringPtr(m.Author),
+		AuthorEmail:        utility.ToStringPtr(m.AuthorEmail),
+		Revision:           utility.ToStringPtr(m.Revision),
+		CommitTime:         NewTime(m.CommitTime),
+		Requester:          utility.ToStringPtr(m.Requester),
+		Distro:             utility.ToStringPtr(m.Distro),
+		VariantDisplayName: utility.ToStringPtr(m.VariantDisplayName),
+		EnrichedAt:         NewTime(m.EnrichedAt),
+	}
+}
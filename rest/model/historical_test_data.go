@@ -21,10 +21,14 @@ type APIAggregatedHistoricalTestData struct {
 	TaskName *string `json:"task_name,omitempty"`
 	Variant  *string `json:"variant,omitempty"`
 	Date     APITime `json:"date"`
+	Team     *string `json:"team,omitempty"`
 
 	NumPass         int     `json:"num_pass"`
 	NumFail         int     `json:"num_fail"`
 	AverageDuration float64 `json:"avg_duration_pass"`
+	P50Duration     float64 `json:"p50_duration_pass"`
+	P90Duration     float64 `json:"p90_duration_pass"`
+	P99Duration     float64 `json:"p99_duration_pass"`
 }
 
 // Import transforms an AggregatedHistoricalTestData object into an
@@ -36,9 +40,15 @@ func (a *APIAggregatedHistoricalTestData) Import(i interface{}) error {
 		a.TaskName = utility.ToStringPtr(hd.TaskName)
 		a.Variant = utility.ToStringPtr(hd.Variant)
 		a.Date = NewTime(hd.Date)
+		if hd.Team != "" {
+			a.Team = utility.ToStringPtr(hd.Team)
+		}
 		a.NumPass = hd.NumPass
 		a.NumFail = hd.NumFail
 		a.AverageDuration = hd.AverageDuration.Seconds()
+		a.P50Duration = hd.P50Duration.Seconds()
+		a.P90Duration = hd.P90Duration.Seconds()
+		a.P99Duration = hd.P99Duration.Seconds()
 	default:
 		return errors.Errorf("incorrect type %T when converting to APIHistoricalTestData type", i)
 	}
@@ -56,6 +66,51 @@ func (a *APIAggregatedHistoricalTestData) StartAtKey() string {
 	}.String()
 }
 
+// APIAggregatedHistoricalTaskData describes aggregated task-level historical
+// data.
+type APIAggregatedHistoricalTaskData struct {
+	// In order to maintain backwards compatibility with Evergreen's
+	// historical task stats, the json tags must match those in Evergreen.
+	TaskName *string `json:"task_name"`
+	Variant  *string `json:"variant,omitempty"`
+	Date     APITime `json:"date"`
+
+	NumSuccess      int     `json:"num_success"`
+	NumFailed       int     `json:"num_failed"`
+	NumTotal        int     `json:"num_total"`
+	AverageDuration float64 `json:"avg_duration_success"`
+	AverageNumTests float64 `json:"avg_num_tests"`
+}
+
+// Import transforms an AggregatedHistoricalTaskData object into an
+// APIAggregatedHistoricalTaskData object.
+func (a *APIAggregatedHistoricalTaskData) Import(i interface{}) error {
+	switch hd := i.(type) {
+	case dbmodel.AggregatedHistoricalTaskData:
+		a.TaskName = utility.ToStringPtr(hd.TaskName)
+		a.Variant = utility.ToStringPtr(hd.Variant)
+		a.Date = NewTime(hd.Date)
+		a.NumSuccess = hd.NumPass
+		a.NumFailed = hd.NumFail
+		a.NumTotal = hd.NumPass + hd.NumFail
+		a.AverageDuration = hd.AverageDuration.Seconds()
+		a.AverageNumTests = hd.AverageNumTests()
+	default:
+		return errors.Errorf("incorrect type %T when converting to APIAggregatedHistoricalTaskData type", i)
+	}
+	return nil
+}
+
+// StartAtKey returns the start_at key parameter that can be used to paginate
+// and start at this element.
+func (a *APIAggregatedHistoricalTaskData) StartAtKey() string {
+	return HTDStartAtKey{
+		date:     time.Time(a.Date).Format(htdAPIDateFormat),
+		variant:  utility.FromStringPtr(a.Variant),
+		taskName: utility.FromStringPtr(a.TaskName),
+	}.String()
+}
+
 // HTDStartAtKey is a struct used to build the start_at key parameter for
 // pagination.
 type HTDStartAtKey struct {
@@ -23,6 +23,9 @@ func TestHistoricalTestDataImport(t *testing.T) {
 			NumPass:         2,
 			NumFail:         2,
 			AverageDuration: 30 * time.Second,
+			P50Duration:     20 * time.Second,
+			P90Duration:     50 * time.Second,
+			P99Duration:     time.Minute,
 		}
 		expected := &APIAggregatedHistoricalTestData{
 			TestName:        utility.ToStringPtr(tr.TestName),
@@ -32,9 +35,43 @@ func TestHistoricalTestDataImport(t *testing.T) {
 			NumPass:         tr.NumPass,
 			NumFail:         tr.NumFail,
 			AverageDuration: tr.AverageDuration.Seconds(),
+			P50Duration:     tr.P50Duration.Seconds(),
+			P90Duration:     tr.P90Duration.Seconds(),
+			P99Duration:     tr.P99Duration.Seconds(),
 		}
 		api := &APIAggregatedHistoricalTestData{}
 		assert.NoError(t, api.Import(tr))
 		assert.Equal(t, expected, api)
 	})
 }
+
+func TestHistoricalTaskDataImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		api := &APIAggregatedHistoricalTaskData{}
+		assert.Error(t, api.Import(dbmodel.AggregatedHistoricalTestData{}))
+	})
+	t.Run("ValidHistoricalTaskData", func(t *testing.T) {
+		td := dbmodel.AggregatedHistoricalTaskData{
+			TaskName:        "task_name",
+			Variant:         "variant",
+			Date:            time.Now(),
+			NumPass:         3,
+			NumFail:         1,
+			NumTests:        40,
+			AverageDuration: time.Minute,
+		}
+		expected := &APIAggregatedHistoricalTaskData{
+			TaskName:        utility.ToStringPtr(td.TaskName),
+			Variant:         utility.ToStringPtr(td.Variant),
+			Date:            NewTime(td.Date),
+			NumSuccess:      3,
+			NumFailed:       1,
+			NumTotal:        4,
+			AverageDuration: 60,
+			AverageNumTests: 10,
+		}
+		api := &APIAggregatedHistoricalTaskData{}
+		assert.NoError(t, api.Import(td))
+		assert.Equal(t, expected, api)
+	})
+}
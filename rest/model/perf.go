@@ -71,6 +71,9 @@ type APIPerformanceResultInfo struct {
 	Parent    *string          `json:"parent"`
 	Tags      []string         `json:"tags"`
 	Arguments map[string]int32 `json:"args"`
+	// Evergreen is not set until the result has been enriched with the
+	// metadata of its Evergreen task.
+	Evergreen *APIEvergreenMetadata `json:"evergreen,omitempty"`
 }
 
 func getPerformanceResultInfo(r dbmodel.PerformanceResultInfo) APIPerformanceResultInfo {
@@ -87,6 +90,7 @@ func getPerformanceResultInfo(r dbmodel.PerformanceResultInfo) APIPerformanceRes
 		Parent:    utility.ToStringPtr(r.Parent),
 		Tags:      r.Tags,
 		Arguments: r.Arguments,
+		Evergreen: getEvergreenMetadata(r.Evergreen),
 	}
 }
 
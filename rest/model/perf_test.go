@@ -36,6 +36,16 @@ func TestImportHelperFunctions(t *testing.T) {
 					"argument2": 2,
 				},
 				Schema: 1,
+				Evergreen: &dbmodel.EvergreenMetadata{
+					Author:             "author",
+					AuthorEmail:        "author@example.com",
+					Revision:           "abcdef",
+					CommitTime:         time.Date(2018, time.December, 31, 23, 0, 0, 0, time.UTC),
+					Requester:          "gitter_request",
+					Distro:             "distro",
+					VariantDisplayName: "Foo",
+					EnrichedAt:         time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC),
+				},
 			},
 			expectedOutput: APIPerformanceResultInfo{
 				Project:   utility.ToStringPtr("project"),
@@ -54,6 +64,16 @@ func TestImportHelperFunctions(t *testing.T) {
 					"argument1": 1,
 					"argument2": 2,
 				},
+				Evergreen: &APIEvergreenMetadata{
+					Author:             utility.ToStringPtr("author"),
+					AuthorEmail:        utility.ToStringPtr("author@example.com"),
+					Revision:           utility.ToStringPtr("abcdef"),
+					CommitTime:         NewTime(time.Date(2018, time.December, 31, 23, 0, 0, 0, time.UTC)),
+					Requester:          utility.ToStringPtr("gitter_request"),
+					Distro:             utility.ToStringPtr("distro"),
+					VariantDisplayName: utility.ToStringPtr("Foo"),
+					EnrichedAt:         NewTime(time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)),
+				},
 			},
 		},
 		{
@@ -0,0 +1,35 @@
+package model
+
+import (
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/pkg/errors"
+)
+
+// APIPresignedURL describes a time-limited URL to download an object directly
+// from S3. Objects that cannot be downloaded directly have an error instead of
+// a URL.
+type APIPresignedURL struct {
+	Key       *string `json:"key"`
+	URL       *string `json:"url"`
+	ExpiresAt APITime `json:"expires_at"`
+	Error     *string `json:"error,omitempty"`
+}
+
+// Import transforms a PresignedURL object into an APIPresignedURL object.
+func (a *APIPresignedURL) Import(i interface{}) error {
+	switch url := i.(type) {
+	case dbModel.PresignedURL:
+		a.Key = utility.ToStringPtr(url.Key)
+		if url.Error != "" {
+			a.Error = utility.ToStringPtr(url.Error)
+			break
+		}
+		a.URL = utility.ToStringPtr(url.URL)
+		a.ExpiresAt = NewTime(url.ExpiresAt)
+	default:
+		return errors.Errorf("incorrect type %T when converting to APIPresignedURL type", i)
+	}
+
+	return nil
+}
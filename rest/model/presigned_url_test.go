@@ -0,0 +1,45 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	dbmodel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresignedURLImport(t *testing.T) {
+	t.Run("InvalidType", func(t *testing.T) {
+		apiURL := &APIPresignedURL{}
+		assert.Error(t, apiURL.Import(dbmodel.ArtifactInfo{}))
+	})
+	t.Run("ValidPresignedURL", func(t *testing.T) {
+		url := dbmodel.PresignedURL{
+			Key:       "key",
+			URL:       "https://bucket.s3.amazonaws.com/prefix/key?X-Amz-Signature=signature",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		expected := &APIPresignedURL{
+			Key:       utility.ToStringPtr(url.Key),
+			URL:       utility.ToStringPtr(url.URL),
+			ExpiresAt: NewTime(url.ExpiresAt),
+		}
+		apiURL := &APIPresignedURL{}
+		assert.NoError(t, apiURL.Import(url))
+		assert.Equal(t, expected, apiURL)
+	})
+	t.Run("Error", func(t *testing.T) {
+		url := dbmodel.PresignedURL{
+			Key:   "key",
+			Error: "cannot pre-sign URLs for artifacts stored in 'local'",
+		}
+		expected := &APIPresignedURL{
+			Key:   utility.ToStringPtr(url.Key),
+			Error: utility.ToStringPtr(url.Error),
+		}
+		apiURL := &APIPresignedURL{}
+		assert.NoError(t, apiURL.Import(url))
+		assert.Equal(t, expected, apiURL)
+	})
+}
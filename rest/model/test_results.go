@@ -10,25 +10,33 @@ import (
 type APITestResults struct {
 	Stats   APITestResultsStats `json:"stats"`
 	Results []APITestResult     `json:"results"`
+	// Truncated is true when only the most recent of the matching test
+	// results were searched.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // APITestResult describes a single test result.
 type APITestResult struct {
-	TaskID          *string `json:"task_id"`
-	Execution       int     `json:"execution"`
-	TestName        *string `json:"test_name"`
-	DisplayTestName *string `json:"display_test_name,omitempty"`
-	GroupID         *string `json:"group_id,omitempty"`
-	Trial           int     `json:"trial"`
-	Status          *string `json:"status"`
-	BaseStatus      *string `json:"base_status,omitempty"`
-	LogTestName     *string `json:"log_test_name,omitempty"`
-	LogURL          *string `json:"log_url,omitempty"`
-	RawLogURL       *string `json:"raw_log_url,omitempty"`
-	LineNum         int     `json:"line_num"`
-	TaskCreateTime  APITime `json:"task_create_time"`
-	TestStartTime   APITime `json:"test_start_time"`
-	TestEndTime     APITime `json:"test_end_time"`
+	TaskID          *string           `json:"task_id"`
+	Execution       int               `json:"execution"`
+	TestName        *string           `json:"test_name"`
+	DisplayTestName *string           `json:"display_test_name,omitempty"`
+	GroupID         *string           `json:"group_id,omitempty"`
+	Trial           int               `json:"trial"`
+	Status          *string           `json:"status"`
+	BaseStatus      *string           `json:"base_status,omitempty"`
+	Team            *string           `json:"team,omitempty"`
+	LogTestName     *string           `json:"log_test_name,omitempty"`
+	LogURL          *string           `json:"log_url,omitempty"`
+	RawLogURL       *string           `json:"raw_log_url,omitempty"`
+	LineNum         int               `json:"line_num"`
+	TaskCreateTime  APITime           `json:"task_create_time"`
+	TestStartTime   APITime           `json:"test_start_time"`
+	TestEndTime     APITime           `json:"test_end_time"`
+	FailureMessage  *string           `json:"failure_message,omitempty"`
+	StackTrace      *string           `json:"stack_trace,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	Attributes      map[string]string `json:"attributes,omitempty"`
 }
 
 // Import transforms a TestResult object into an APITestResult object.
@@ -49,6 +57,9 @@ func (a *APITestResult) Import(i interface{}) error {
 		if tr.BaseStatus != "" {
 			a.BaseStatus = utility.ToStringPtr(tr.BaseStatus)
 		}
+		if tr.Team != "" {
+			a.Team = utility.ToStringPtr(tr.Team)
+		}
 		if tr.LogTestName != "" {
 			a.LogTestName = utility.ToStringPtr(tr.LogTestName)
 		}
@@ -62,6 +73,14 @@ func (a *APITestResult) Import(i interface{}) error {
 		a.TaskCreateTime = NewTime(tr.TaskCreateTime)
 		a.TestStartTime = NewTime(tr.TestStartTime)
 		a.TestEndTime = NewTime(tr.TestEndTime)
+		if tr.FailureMessage != "" {
+			a.FailureMessage = utility.ToStringPtr(tr.FailureMessage)
+		}
+		if tr.StackTrace != "" {
+			a.StackTrace = utility.ToStringPtr(tr.StackTrace)
+		}
+		a.Tags = tr.Tags
+		a.Attributes = tr.Attributes
 	default:
 		return errors.Errorf("incorrect type %T when converting to APITestResult type", i)
 	}
@@ -71,9 +90,10 @@ func (a *APITestResult) Import(i interface{}) error {
 
 // APITTestResultsStats describes basic stats for a group of test results.
 type APITestResultsStats struct {
-	TotalCount    int  `json:"total_count"`
-	FailedCount   int  `json:"failed_count"`
-	FilteredCount *int `json:"filtered_count,omitempty"`
+	TotalCount             int  `json:"total_count"`
+	FailedCount            int  `json:"failed_count"`
+	QuarantinedFailedCount int  `json:"quarantined_failed_count"`
+	FilteredCount          *int `json:"filtered_count,omitempty"`
 }
 
 // Import transforms a TestResultsStats object into an APITestResultsStats
@@ -83,6 +103,7 @@ func (a *APITestResultsStats) Import(i interface{}) error {
 	case dbModel.TestResultsStats:
 		a.TotalCount = stats.TotalCount
 		a.FailedCount = stats.FailedCount
+		a.QuarantinedFailedCount = stats.QuarantinedFailedCount
 	case int:
 		a.FilteredCount = utility.ToIntPtr(stats)
 	default:
@@ -94,10 +115,19 @@ func (a *APITestResultsStats) Import(i interface{}) error {
 
 // APITestResultsSample is a sample of test names for a given task and execution.
 type APITestResultsSample struct {
-	TaskID                  *string  `json:"task_id"`
-	Execution               int      `json:"execution"`
-	MatchingFailedTestNames []string `json:"matching_failed_test_names"`
-	TotalFailedNames        int      `json:"total_failed_names"`
+	TaskID                  *string           `json:"task_id"`
+	Execution               int               `json:"execution"`
+	MatchingFailedTestNames []string          `json:"matching_failed_test_names"`
+	TotalFailedNames        int               `json:"total_failed_names"`
+	QuarantinedFailedNames  []string          `json:"quarantined_failed_test_names,omitempty"`
+	Teams                   map[string]string `json:"teams,omitempty"`
+}
+
+// APIFailedTestResultsSample is the sample of failed test names of a task and
+// execution, with the owning teams of the tests owned by a team.
+type APIFailedTestResultsSample struct {
+	TestNames []string          `json:"test_names"`
+	Teams     map[string]string `json:"teams,omitempty"`
 }
 
 // Import transforms a TestResultsSample object into an APITestResultsSample
@@ -109,6 +139,8 @@ func (a *APITestResultsSample) Import(i interface{}) error {
 		a.Execution = sample.Execution
 		a.MatchingFailedTestNames = sample.MatchingFailedTestNames
 		a.TotalFailedNames = sample.TotalFailedTestNames
+		a.QuarantinedFailedNames = sample.QuarantinedFailedTestNames
+		a.Teams = sample.Teams
 	default:
 		return errors.Errorf("incorrect type %T when converting to APITestResultsSample type", i)
 	}
@@ -28,6 +28,10 @@ func TestTestResultImport(t *testing.T) {
 			LogURL:          "url",
 			RawLogURL:       "raw_url",
 			LineNum:         102,
+			FailureMessage:  "failure",
+			StackTrace:      "trace",
+			Tags:            []string{"flaky"},
+			Attributes:      map[string]string{"owner": "team"},
 			TaskCreateTime:  time.Now().Add(-time.Hour),
 			TestStartTime:   time.Now().Add(-30 * time.Minute),
 			TestEndTime:     time.Now(),
@@ -45,6 +49,10 @@ func TestTestResultImport(t *testing.T) {
 			LogURL:          utility.ToStringPtr(tr.LogURL),
 			RawLogURL:       utility.ToStringPtr(tr.RawLogURL),
 			LineNum:         tr.LineNum,
+			FailureMessage:  utility.ToStringPtr(tr.FailureMessage),
+			StackTrace:      utility.ToStringPtr(tr.StackTrace),
+			Tags:            tr.Tags,
+			Attributes:      tr.Attributes,
 			TaskCreateTime:  NewTime(tr.TaskCreateTime),
 			TestStartTime:   NewTime(tr.TestStartTime),
 			TestEndTime:     NewTime(tr.TestEndTime),
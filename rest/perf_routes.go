@@ -101,6 +101,16 @@ func (h *perfRemoveByIdHandler) Parse(_ context.Context, r *http.Request) error
 // Run calls the data RemovePerformanceResultById function and returns the
 // error.
 func (h *perfRemoveByIdHandler) Run(ctx context.Context) gimlet.Responder {
+	var before interface{}
+	if getAuditRecord(ctx) != nil {
+		// The result is only looked up to record it in the audit log, so
+		// a missing result is not an error.
+		setAuditTarget(ctx, h.id)
+		if result, err := h.sc.FindPerformanceResultById(ctx, h.id); err == nil {
+			before = result
+		}
+	}
+
 	numRemoved, err := h.sc.RemovePerformanceResultById(ctx, h.id)
 	if err != nil {
 		err = errors.Wrapf(err, "removing performance result by ID '%s'", h.id)
@@ -112,6 +122,8 @@ func (h *perfRemoveByIdHandler) Run(ctx context.Context) gimlet.Responder {
 		}))
 		return gimlet.MakeJSONErrorResponder(err)
 	}
+	setAuditChange(ctx, before, map[string]interface{}{"num_removed": numRemoved})
+
 	return gimlet.NewJSONResponse(fmt.Sprintf("Delete operation removed %d performance results", numRemoved))
 }
 
@@ -480,3 +492,27 @@ func paginatePerfResults(baseURL string, data []model.APIPerformanceResult, limi
 
 	return resp
 }
+
+// parsePerfOptions returns a function that parses the performance options of
+// the requests to the route handler returned by makeHandler.
+func parsePerfOptions(makeHandler func(data.Connector) gimlet.RouteHandler) func(context.Context, *http.Request) (data.PerformanceOptions, error) {
+	return func(ctx context.Context, r *http.Request) (data.PerformanceOptions, error) {
+		h := makeHandler(nil)
+		if err := h.Parse(ctx, r); err != nil {
+			return data.PerformanceOptions{}, err
+		}
+
+		switch h := h.(type) {
+		case *perfGetByTaskIdHandler:
+			return h.opts, nil
+		case *perfCountByTaskIdHandler:
+			return h.opts, nil
+		case *perfGetByTaskNameHandler:
+			return h.opts, nil
+		case *perfGetByVersionHandler:
+			return h.opts, nil
+		default:
+			return data.PerformanceOptions{}, errors.Errorf("programmer error: unexpected performance route handler %T", h)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/data"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+// presignedURLExpiration is the query parameter for the lifetime, in seconds,
+// of pre-signed URLs.
+const presignedURLExpiration = "expires_in"
+
+type presignedURLBaseHandler struct {
+	expiration time.Duration
+}
+
+// Parse fetches the lifetime of the pre-signed URLs from the HTTP request,
+// defaulting to model.DefaultPresignedURLExpiration.
+func (h *presignedURLBaseHandler) Parse(_ context.Context, r *http.Request) error {
+	h.expiration = model.DefaultPresignedURLExpiration
+
+	val := r.URL.Query().Get(presignedURLExpiration)
+	if val == "" {
+		return nil
+	}
+	seconds, err := strconv.Atoi(val)
+	if err != nil {
+		return errors.Wrapf(err, "parsing expiration '%s'", val)
+	}
+	h.expiration = time.Duration(seconds) * time.Second
+
+	return model.ValidatePresignedURLExpiration(h.expiration)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /perf/{id}/presigned_urls
+
+type perfPresignByIdHandler struct {
+	id string
+	sc data.Connector
+	presignedURLBaseHandler
+}
+
+func makePresignPerfById(sc data.Connector) gimlet.RouteHandler {
+	return &perfPresignByIdHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new perfPresignByIdHandler.
+func (h *perfPresignByIdHandler) Factory() gimlet.RouteHandler {
+	return &perfPresignByIdHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the ID and the expiration from the HTTP request.
+func (h *perfPresignByIdHandler) Parse(ctx context.Context, r *http.Request) error {
+	h.id = gimlet.GetVars(r)["id"]
+	return h.presignedURLBaseHandler.Parse(ctx, r)
+}
+
+// Run returns pre-signed URLs to download the artifacts of the performance
+// result, reporting the artifacts that are not stored in S3 with an error.
+func (h *perfPresignByIdHandler) Run(ctx context.Context) gimlet.Responder {
+	urls, err := h.sc.PresignPerformanceResultArtifacts(ctx, h.id, h.expiration)
+	if err != nil {
+		err = errors.Wrapf(err, "pre-signing artifact URLs for performance result '%s'", h.id)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/perf/{id}/presigned_urls",
+			"id":      h.id,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(urls)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/{id}/presigned_urls
+
+type logPresignByIDHandler struct {
+	id string
+	sc data.Connector
+	presignedURLBaseHandler
+}
+
+func makePresignLogByID(sc data.Connector) gimlet.RouteHandler {
+	return &logPresignByIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new logPresignByIDHandler.
+func (h *logPresignByIDHandler) Factory() gimlet.RouteHandler {
+	return &logPresignByIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the ID and the expiration from the HTTP request.
+func (h *logPresignByIDHandler) Parse(ctx context.Context, r *http.Request) error {
+	h.id = gimlet.GetVars(r)["id"]
+	return h.presignedURLBaseHandler.Parse(ctx, r)
+}
+
+// Run returns pre-signed URLs to download the chunks of the log.
+func (h *logPresignByIDHandler) Run(ctx context.Context) gimlet.Responder {
+	urls, err := h.sc.PresignLogByID(ctx, h.id, h.expiration)
+	if err != nil {
+		err = errors.Wrapf(err, "pre-signing chunk URLs for log '%s'", h.id)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/buildlogger/{id}/presigned_urls",
+			"id":      h.id,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(urls)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/task_id/{task_id}/presigned_urls
+
+type testResultsPresignByTaskIDHandler struct {
+	sc data.Connector
+	testResultsBaseHandler
+	presignedURLBaseHandler
+}
+
+func makePresignTestResultsByTaskID(sc data.Connector) gimlet.RouteHandler {
+	return &testResultsPresignByTaskIDHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new testResultsPresignByTaskIDHandler.
+func (h *testResultsPresignByTaskIDHandler) Factory() gimlet.RouteHandler {
+	return &testResultsPresignByTaskIDHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID and the expiration from the HTTP request.
+func (h *testResultsPresignByTaskIDHandler) Parse(ctx context.Context, r *http.Request) error {
+	if err := h.testResultsBaseHandler.Parse(ctx, r); err != nil {
+		return err
+	}
+	return h.presignedURLBaseHandler.Parse(ctx, r)
+}
+
+// Run returns pre-signed URLs to download the Parquet files of the test
+// results.
+func (h *testResultsPresignByTaskIDHandler) Run(ctx context.Context) gimlet.Responder {
+	urls, err := h.sc.PresignTestResults(ctx, h.opts, h.expiration)
+	if err != nil {
+		err = errors.Wrapf(err, "pre-signing URLs for test results by task ID '%s'", h.opts.TaskID)
+		logFindError(err, message.Fields{
+			"request":      gimlet.GetRequestID(ctx),
+			"method":       "GET",
+			"route":        "/test_results/task_id/{task_id}/presigned_urls",
+			"task_id":      h.opts.TaskID,
+			"display_task": h.opts.DisplayTask,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(urls)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /system_metrics/type/{task_id}/{type}/presigned_urls
+
+type systemMetricsPresignByTypeHandler struct {
+	metricType string
+	findOpts   model.SystemMetricsFindOptions
+	sc         data.Connector
+	presignedURLBaseHandler
+}
+
+func makePresignSystemMetricsByType(sc data.Connector) gimlet.RouteHandler {
+	return &systemMetricsPresignByTypeHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new systemMetricsPresignByTypeHandler.
+func (h *systemMetricsPresignByTypeHandler) Factory() gimlet.RouteHandler {
+	return &systemMetricsPresignByTypeHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the task ID, metric type and expiration from the HTTP request.
+func (h *systemMetricsPresignByTypeHandler) Parse(ctx context.Context, r *http.Request) error {
+	var err error
+	h.findOpts, err = parseSystemMetricsFindOptions(r)
+	if err != nil {
+		return err
+	}
+	h.metricType = gimlet.GetVars(r)["type"]
+
+	return h.presignedURLBaseHandler.Parse(ctx, r)
+}
+
+// Run returns pre-signed URLs to download the chunks of the metric type.
+func (h *systemMetricsPresignByTypeHandler) Run(ctx context.Context) gimlet.Responder {
+	urls, err := h.sc.PresignSystemMetricsByType(ctx, h.findOpts, h.metricType, h.expiration)
+	if err != nil {
+		err = errors.Wrapf(err, "pre-signing URLs for metric type '%s' for task ID '%s'", h.metricType, h.findOpts.TaskID)
+		logFindError(err, message.Fields{
+			"request":     gimlet.GetRequestID(ctx),
+			"method":      "GET",
+			"route":       "/system_metrics/type/{task_id}/{type}/presigned_urls",
+			"task_id":     h.findOpts.TaskID,
+			"metric_type": h.metricType,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	return gimlet.NewJSONResponse(urls)
+}
@@ -0,0 +1,207 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dbModel "github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rest/data"
+	"github.com/evergreen-ci/cedar/rest/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresignedURLBaseHandlerParse(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		query      string
+		expiration time.Duration
+		hasErr     bool
+	}{
+		{
+			name:       "Default",
+			expiration: dbModel.DefaultPresignedURLExpiration,
+		},
+		{
+			name:       "Seconds",
+			query:      "?expires_in=60",
+			expiration: time.Minute,
+		},
+		{
+			name:   "NotANumber",
+			query:  "?expires_in=1h",
+			hasErr: true,
+		},
+		{
+			name:   "Zero",
+			query:  "?expires_in=0",
+			hasErr: true,
+		},
+		{
+			name:   "TooLong",
+			query:  "?expires_in=604800",
+			hasErr: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, "https://example.com/rest/v1/perf/id/presigned_urls"+test.query, nil)
+			require.NoError(t, err)
+
+			h := &presignedURLBaseHandler{}
+			err = h.Parse(context.TODO(), req)
+			if test.hasErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expiration, h.expiration)
+		})
+	}
+}
+
+func TestPresignedURLHandlers(t *testing.T) {
+	sc := &data.MockConnector{
+		Bucket: "bucket",
+		CachedPerformanceResults: map[string]dbModel.PerformanceResult{
+			"perf": {
+				ID: "perf",
+				Artifacts: []dbModel.ArtifactInfo{
+					{Type: dbModel.PailS3, Prefix: "prefix", Path: "data.ftdc"},
+					{Type: dbModel.PailLocal, Prefix: "prefix", Path: "local.ftdc"},
+				},
+			},
+		},
+		CachedLogs: map[string]dbModel.Log{
+			"log": {
+				ID: "log",
+				Artifact: dbModel.LogArtifactInfo{
+					Type:   dbModel.PailS3,
+					Prefix: "log",
+					Chunks: []dbModel.LogChunkInfo{{Key: "chunk0"}, {Key: "chunk1"}},
+				},
+			},
+		},
+		CachedTestResults: map[string]dbModel.TestResults{
+			"results0": {
+				ID:       "results0",
+				Info:     dbModel.TestResultsInfo{Project: "project", TaskID: "task", Execution: 0},
+				Artifact: dbModel.TestResultsArtifactInfo{Type: dbModel.PailS3, Prefix: "results0", Version: 1},
+			},
+			"results1": {
+				ID:       "results1",
+				Info:     dbModel.TestResultsInfo{Project: "project", TaskID: "task", Execution: 1},
+				Artifact: dbModel.TestResultsArtifactInfo{Type: dbModel.PailS3, Prefix: "results1", Version: 1},
+			},
+			"exec0": {
+				ID:       "exec0",
+				Info:     dbModel.TestResultsInfo{Project: "project", TaskID: "exec0", DisplayTaskID: "display"},
+				Artifact: dbModel.TestResultsArtifactInfo{Type: dbModel.PailS3, Prefix: "exec0", Version: 1},
+			},
+			"exec1": {
+				ID:       "exec1",
+				Info:     dbModel.TestResultsInfo{Project: "project", TaskID: "exec1", DisplayTaskID: "display"},
+				Artifact: dbModel.TestResultsArtifactInfo{Type: dbModel.PailS3, Prefix: "exec1", Version: 1},
+			},
+			"gridfs": {
+				ID:       "gridfs",
+				Info:     dbModel.TestResultsInfo{Project: "project", TaskID: "gridfs"},
+				Artifact: dbModel.TestResultsArtifactInfo{Type: dbModel.PailGridFS, Prefix: "gridfs", Version: 1},
+			},
+		},
+		CachedSystemMetrics: map[string]dbModel.SystemMetrics{
+			"metrics": {
+				ID:   "metrics",
+				Info: dbModel.SystemMetricsInfo{Project: "project", TaskID: "task"},
+				Artifact: dbModel.SystemMetricsArtifactInfo{
+					Prefix: "metrics",
+					MetricChunks: map[string]dbModel.MetricChunks{
+						"uptime": {Chunks: []string{"chunk0"}},
+					},
+				},
+			},
+		},
+	}
+	checkURLs := func(t *testing.T, resp interface{}, expected ...string) {
+		urls, ok := resp.([]model.APIPresignedURL)
+		require.True(t, ok)
+		require.Len(t, urls, len(expected))
+		for i, url := range urls {
+			assert.Equal(t, expected[i], utility.FromStringPtr(url.URL))
+			assert.True(t, time.Time(url.ExpiresAt).After(time.Now()))
+		}
+	}
+
+	t.Run("PerformanceResult", func(t *testing.T) {
+		rh := makePresignPerfById(sc).(*perfPresignByIdHandler)
+		rh.id = "perf"
+		rh.expiration = time.Minute
+		resp := rh.Run(context.TODO())
+		require.Equal(t, http.StatusOK, resp.Status())
+		urls, ok := resp.Data().([]model.APIPresignedURL)
+		require.True(t, ok)
+		require.Len(t, urls, 2)
+		checkURLs(t, urls[:1], filepath.Join("bucket", "prefix", "data.ftdc"))
+		assert.Equal(t, "local.ftdc", utility.FromStringPtr(urls[1].Key))
+		assert.Nil(t, urls[1].URL)
+		assert.NotEmpty(t, utility.FromStringPtr(urls[1].Error))
+
+		rh.id = "DNE"
+		assert.Equal(t, http.StatusNotFound, rh.Run(context.TODO()).Status())
+	})
+	t.Run("Log", func(t *testing.T) {
+		rh := makePresignLogByID(sc).(*logPresignByIDHandler)
+		rh.id = "log"
+		rh.expiration = time.Minute
+		resp := rh.Run(context.TODO())
+		require.Equal(t, http.StatusOK, resp.Status())
+		checkURLs(t, resp.Data(), filepath.Join("bucket", "log", "chunk0"), filepath.Join("bucket", "log", "chunk1"))
+
+		rh.id = "DNE"
+		assert.Equal(t, http.StatusNotFound, rh.Run(context.TODO()).Status())
+	})
+	t.Run("TestResults", func(t *testing.T) {
+		rh := makePresignTestResultsByTaskID(sc).(*testResultsPresignByTaskIDHandler)
+		rh.expiration = time.Minute
+		latest := sc.CachedTestResults["results1"]
+		previous := sc.CachedTestResults["results0"]
+		exec0 := sc.CachedTestResults["exec0"]
+		exec1 := sc.CachedTestResults["exec1"]
+
+		rh.opts = data.TestResultsOptions{TaskID: "task"}
+		resp := rh.Run(context.TODO())
+		require.Equal(t, http.StatusOK, resp.Status())
+		checkURLs(t, resp.Data(), filepath.Join("bucket", latest.PrestoPartitionKey()))
+
+		rh.opts.Execution = utility.ToIntPtr(0)
+		resp = rh.Run(context.TODO())
+		require.Equal(t, http.StatusOK, resp.Status())
+		checkURLs(t, resp.Data(), filepath.Join("bucket", previous.PrestoPartitionKey()))
+
+		rh.opts = data.TestResultsOptions{TaskID: "display", DisplayTask: true}
+		resp = rh.Run(context.TODO())
+		require.Equal(t, http.StatusOK, resp.Status())
+		checkURLs(t, resp.Data(), filepath.Join("bucket", exec0.PrestoPartitionKey()), filepath.Join("bucket", exec1.PrestoPartitionKey()))
+
+		rh.opts = data.TestResultsOptions{TaskID: "gridfs"}
+		assert.Equal(t, http.StatusBadRequest, rh.Run(context.TODO()).Status())
+
+		rh.opts = data.TestResultsOptions{TaskID: "DNE"}
+		assert.Equal(t, http.StatusNotFound, rh.Run(context.TODO()).Status())
+	})
+	t.Run("SystemMetrics", func(t *testing.T) {
+		rh := makePresignSystemMetricsByType(sc).(*systemMetricsPresignByTypeHandler)
+		rh.findOpts = dbModel.SystemMetricsFindOptions{TaskID: "task", EmptyExecution: true}
+		rh.metricType = "uptime"
+		rh.expiration = time.Minute
+		resp := rh.Run(context.TODO())
+		require.Equal(t, http.StatusOK, resp.Status())
+		checkURLs(t, resp.Data(), filepath.Join("bucket", "metrics", "chunk0"))
+
+		rh.metricType = "DNE"
+		assert.Equal(t, http.StatusNotFound, rh.Run(context.TODO()).Status())
+	})
+}
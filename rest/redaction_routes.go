@@ -0,0 +1,125 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+// RedactionSecretsRequest is the payload for registering literal secret
+// values that are masked in the logs of a project.
+type RedactionSecretsRequest struct {
+	Secrets []string `json:"secrets"`
+}
+
+// RedactionSecretsResponse is the response for registering secret values. The
+// secrets themselves are never returned.
+type RedactionSecretsResponse struct {
+	Project string `json:"project"`
+	// IDs are the IDs of the registered secrets, in the order of the
+	// request, which are used to delete them.
+	IDs []string `json:"ids"`
+}
+
+// RedactionSecretInfo describes a secret registered for a project without
+// its value.
+type RedactionSecretInfo struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /buildlogger/redaction/{project_id}/secrets
+
+func (s *Service) addRedactionSecrets(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+	setAuditTarget(r.Context(), fmt.Sprintf("project/%s/redaction", project))
+
+	req := &RedactionSecretsRequest{}
+	if err := gimlet.GetJSON(r.Body, req); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading redaction secrets request").Error(),
+		}))
+		return
+	}
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"secrets": len(req.Secrets),
+	})
+	if err := model.ValidateRedactionSecrets(req.Secrets); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	ids, err := model.AddRedactionSecrets(r.Context(), s.Environment, project, req.Secrets)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, RedactionSecretsResponse{
+		Project: project,
+		IDs:     ids,
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /buildlogger/redaction/{project_id}/secrets
+
+func (s *Service) getRedactionSecrets(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+
+	secrets, err := model.FindRedactionSecrets(r.Context(), s.Environment, project)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	infos := make([]RedactionSecretInfo, 0, len(secrets))
+	for _, secret := range secrets {
+		infos = append(infos, RedactionSecretInfo{
+			ID:        secret.ID,
+			CreatedAt: secret.CreatedAt,
+		})
+	}
+
+	gimlet.WriteJSON(rw, infos)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// DELETE /buildlogger/redaction/{project_id}/secrets/{secret_id}
+
+func (s *Service) deleteRedactionSecret(rw http.ResponseWriter, r *http.Request) {
+	vars := gimlet.GetVars(r)
+	project := vars["project_id"]
+	id := vars["secret_id"]
+	setAuditTarget(r.Context(), fmt.Sprintf("project/%s/redaction/%s", project, id))
+
+	deleted, err := model.DeleteRedactionSecret(r.Context(), s.Environment, project, id)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	if !deleted {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("redaction secret '%s' not found", id),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, struct{}{})
+}
@@ -1,7 +1,10 @@
 package rest
 
 import (
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
@@ -204,7 +207,7 @@ func (s *Service) simpleLogIngestion(w http.ResponseWriter, r *http.Request) {
 	j := units.MakeSaveSimpleLogJob(s.Environment, resp.LogID, req.Content, req.Time, req.Increment)
 	resp.JobID = j.ID()
 
-	if err := s.queue.Put(ctx, j); err != nil {
+	if err := s.queue.Put(ctx, units.WithTraceContext(r.Context(), j)); err != nil {
 		grip.Error(err)
 		resp.Errors = append(resp.Errors, err.Error())
 		gimlet.WriteJSONInternalError(w, resp)
@@ -435,6 +438,9 @@ func (s *Service) setServiceFlagEnabled(w http.ResponseWriter, r *http.Request)
 		Name: flag,
 	}
 
+	setAuditTarget(r.Context(), flag)
+	before := s.getServiceFlagValue(flag)
+
 	conf := model.NewCedarConfig(s.Environment)
 
 	if err := conf.Flags.SetTrue(flag); err != nil {
@@ -442,6 +448,7 @@ func (s *Service) setServiceFlagEnabled(w http.ResponseWriter, r *http.Request)
 		gimlet.WriteJSONError(w, resp)
 		return
 	}
+	setAuditChange(r.Context(), before, true)
 
 	resp.State = true
 	gimlet.WriteJSON(w, &resp)
@@ -454,17 +461,96 @@ func (s *Service) setServiceFlagDisabled(w http.ResponseWriter, r *http.Request)
 		Name: flag,
 	}
 
+	setAuditTarget(r.Context(), flag)
+	before := s.getServiceFlagValue(flag)
+
 	conf := model.NewCedarConfig(s.Environment)
 	if err := conf.Flags.SetFalse(flag); err != nil {
 		resp.Error = err.Error()
 		gimlet.WriteJSONError(w, resp)
 		return
 	}
+	setAuditChange(r.Context(), before, false)
 
 	resp.State = true
 	gimlet.WriteJSON(w, &resp)
 }
 
+// getServiceFlagValue returns the current value of the named flag, or nil if
+// it cannot be determined.
+func (s *Service) getServiceFlagValue(flag string) interface{} {
+	conf := model.NewCedarConfig(s.Environment)
+	if err := conf.Find(); err != nil {
+		return nil
+	}
+
+	value, err := conf.Flags.Value(flag)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /admin/audit
+
+func (s *Service) getAuditEvents(rw http.ResponseWriter, r *http.Request) {
+	vals := r.URL.Query()
+	opts := model.AuditEventFindOptions{
+		User:      vals.Get("user"),
+		Action:    vals.Get("action"),
+		Target:    vals.Get("target"),
+		RequestID: vals.Get("request_id"),
+	}
+
+	var err error
+	if start := vals.Get("start"); start != "" {
+		if opts.StartAt, err = time.Parse(time.RFC3339, start); err != nil {
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing start time '%s'", start).Error(),
+			}))
+			return
+		}
+	}
+	if end := vals.Get("end"); end != "" {
+		if opts.EndAt, err = time.Parse(time.RFC3339, end); err != nil {
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing end time '%s'", end).Error(),
+			}))
+			return
+		}
+	}
+	if limit := vals.Get("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil {
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing limit '%s'", limit).Error(),
+			}))
+			return
+		}
+	}
+	if err = opts.Validate(); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	events, err := model.FindAuditEvents(r.Context(), s.Environment, opts)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, events)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //
 // GET /admin/ca
@@ -513,6 +599,9 @@ func (s *Service) fetchUserCert(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	setAuditUser(r.Context(), usr)
+	setAuditTarget(r.Context(), usr)
+
 	opts := certdepot.CertificateOptions{
 		CommonName: usr,
 		Domain:     []string{usr},
@@ -520,12 +609,13 @@ func (s *Service) fetchUserCert(rw http.ResponseWriter, r *http.Request) {
 		Host:       usr,
 		Expires:    s.Conf.CA.SSLExpireAfter,
 	}
-	_, err = opts.CreateCertificateOnExpiration(s.Depot, s.Conf.CA.SSLRenewalBefore)
+	issued, err := opts.CreateCertificateOnExpiration(s.Depot, s.Conf.CA.SSLRenewalBefore)
 	if err != nil {
 		err = errors.Wrapf(err, "updating certificate for '%s'", usr)
 		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
 		return
 	}
+	setAuditChange(r.Context(), nil, map[string]interface{}{"issued": issued})
 
 	crt, err := certdepot.GetCertificate(s.Depot, usr)
 	if err != nil {
@@ -566,6 +656,9 @@ func (s *Service) fetchUserCertKey(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	setAuditUser(r.Context(), usr)
+	setAuditTarget(r.Context(), usr)
+
 	if exists, err := certdepot.CheckPrivateKeyWithError(s.Depot, usr); err != nil {
 		err = errors.Wrap(err, "checking user's private cert key")
 		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
@@ -583,6 +676,7 @@ func (s *Service) fetchUserCertKey(rw http.ResponseWriter, r *http.Request) {
 			gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
 			return
 		}
+		setAuditChange(r.Context(), nil, map[string]interface{}{"issued": true})
 	}
 
 	key, err := certdepot.GetPrivateKey(s.Depot, usr)
@@ -601,6 +695,87 @@ func (s *Service) fetchUserCertKey(rw http.ResponseWriter, r *http.Request) {
 	gimlet.WriteBinary(rw, payload)
 }
 
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /admin/users/{user_id}/certificate/revoke
+
+// CertificateRevocationRequest is the optional body of a certificate
+// revocation request.
+type CertificateRevocationRequest struct {
+	Reason string `json:"reason"`
+}
+
+func (s *Service) revokeUserCert(rw http.ResponseWriter, r *http.Request) {
+	var err error
+	defer func() {
+		logRequestError(r, err)
+	}()
+
+	ctx := r.Context()
+	usr := gimlet.GetVars(r)["user_id"]
+	setAuditTarget(ctx, usr)
+
+	req := &CertificateRevocationRequest{}
+	if err = gimlet.GetJSON(r.Body, req); err != nil {
+		if errors.Cause(err) != io.EOF {
+			err = errors.Wrap(err, "reading certificate revocation request")
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    err.Error(),
+			}))
+			return
+		}
+		// The request body is optional.
+		err = nil
+	}
+	addAuditParameters(ctx, map[string]interface{}{"reason": req.Reason})
+
+	if !s.Depot.Check(certdepot.CrtTag(usr)) {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("no certificate found for user '%s'", usr),
+		}))
+		return
+	}
+	crt, err := getDepotCertificate(s.Depot, usr)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	var revokedBy string
+	if u := gimlet.GetUser(ctx); u != nil {
+		revokedBy = u.Username()
+	}
+	revocation := model.NewCertificateRevocation(crt, revokedBy, req.Reason)
+	revocation.Setup(s.Environment)
+	if err = revocation.Save(ctx); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	// Remove the revoked certificate and its key from the depot so that a
+	// new certificate is issued the next time the user requests one.
+	catcher := grip.NewBasicCatcher()
+	if s.Depot.Check(certdepot.CrtTag(usr)) {
+		catcher.Add(s.Depot.Delete(certdepot.CrtTag(usr)))
+	}
+	if s.Depot.Check(certdepot.PrivKeyTag(usr)) {
+		catcher.Add(s.Depot.Delete(certdepot.PrivKeyTag(usr)))
+	}
+	if s.Depot.Check(certdepot.CsrTag(usr)) {
+		catcher.Add(s.Depot.Delete(certdepot.CsrTag(usr)))
+	}
+	if err = catcher.Resolve(); err != nil {
+		err = errors.Wrapf(err, "removing revoked certificate for user '%s' from the depot", usr)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	setAuditChange(ctx, nil, map[string]interface{}{"serial_number": revocation.SerialNumber})
+
+	gimlet.WriteJSON(rw, revocation)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //
 // helper functions
@@ -642,6 +817,29 @@ func (s *Service) checkPayloadCreds(rw http.ResponseWriter, r *http.Request) (st
 	return creds.Username, nil
 }
 
+// getDepotCertificate returns the parsed certificate with the given name from
+// the depot.
+func getDepotCertificate(d certdepot.Depot, name string) (*x509.Certificate, error) {
+	crt, err := certdepot.GetCertificate(d, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting certificate '%s'", name)
+	}
+	payload, err := crt.Export()
+	if err != nil {
+		return nil, errors.Wrapf(err, "exporting certificate '%s'", name)
+	}
+	block, _ := pem.Decode(payload)
+	if block == nil {
+		return nil, errors.Errorf("decoding certificate '%s'", name)
+	}
+	parsed, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing certificate '%s'", name)
+	}
+
+	return parsed, nil
+}
+
 func logRequestError(r *http.Request, err error) {
 	grip.Error(message.WrapError(err, message.Fields{
 		"method":  r.Method,
@@ -6,6 +6,7 @@ import (
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/oidc"
 	"github.com/evergreen-ci/cedar/rest/data"
 	"github.com/evergreen-ci/certdepot"
 	"github.com/evergreen-ci/gimlet"
@@ -87,7 +88,7 @@ func (s *Service) Validate() error {
 	}
 
 	if s.sc == nil {
-		s.sc = data.CreateNewDBConnector(s.Environment, s.Conf.URL)
+		s.sc = data.NewTracingConnector(data.CreateNewDBConnector(s.Environment, s.Conf.URL))
 	}
 
 	if s.Port == 0 {
@@ -145,6 +146,14 @@ func (s *Service) setupUserAuth() error {
 		readOnly = append(readOnly, usrMngr)
 	}
 
+	if s.Conf.OIDC.Issuer != "" {
+		usrMngr, err := s.setupOIDCAuth()
+		if err != nil {
+			return errors.Wrap(err, "setting up OIDC user auth")
+		}
+		readOnly = append(readOnly, usrMngr)
+	}
+
 	if len(readOnly)+len(readWrite) == 0 {
 		return errors.New("no user authentication method could be set up")
 	}
@@ -219,6 +228,28 @@ func (s *Service) setupLDAPAuth() (gimlet.UserManager, error) {
 	return usrMngr, nil
 }
 
+func (s *Service) setupOIDCAuth() (gimlet.UserManager, error) {
+	usrMngr, err := oidc.NewUserManager(oidc.UserManagerOptions{
+		Issuer:          s.Conf.OIDC.Issuer,
+		Audience:        s.Conf.OIDC.Audience,
+		JWKSURL:         s.Conf.OIDC.JWKSURL,
+		JWKSFile:        s.Conf.OIDC.JWKSFile,
+		UserIDNamespace: s.Conf.OIDC.UserIDNamespace,
+		UserIDClaim:     s.Conf.OIDC.UserIDClaim,
+		NameClaim:       s.Conf.OIDC.NameClaim,
+		EmailClaim:      s.Conf.OIDC.EmailClaim,
+		RolesClaim:      s.Conf.OIDC.RolesClaim,
+		RoleMappings:    s.Conf.OIDC.RoleMappingsByValue(),
+		DefaultRoles:    s.Conf.OIDC.DefaultRoles,
+		GetUserByID:     model.GetUser,
+		GetOrCreateUser: model.GetOrAddUser,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up OIDC user manager")
+	}
+	return usrMngr, nil
+}
+
 func (s *Service) setupNaiveAuth() (gimlet.UserManager, error) {
 	users := []gimlet.BasicUser{}
 	for _, user := range s.Conf.NaiveAuth.Users {
@@ -267,6 +298,11 @@ func (s *Service) Start(ctx context.Context) (gimlet.WaitFunc, error) {
 
 func (s *Service) addMiddleware() {
 	s.app.AddMiddleware(gimlet.MakeRecoveryLogger())
+	s.app.AddMiddleware(newTracingMiddleware())
+	s.app.AddMiddleware(newNamedAPIKeyMiddleware(s.Environment))
+	if s.Conf.OIDC.Issuer != "" {
+		s.app.AddMiddleware(newBearerTokenMiddleware(s.UserManager))
+	}
 	s.app.AddMiddleware(gimlet.UserMiddleware(s.UserManager, s.umConf))
 	s.app.AddMiddleware(gimlet.NewAuthenticationHandler(gimlet.NewBasicAuthenticator(nil, nil), s.UserManager))
 
@@ -278,8 +314,35 @@ func (s *Service) addMiddleware() {
 	}
 }
 
+// audit returns a middleware that records an audit event for the given action
+// on each request to the route. It must be the first middleware of the route
+// so that requests denied by the route's authorization are also recorded.
+func (s *Service) audit(action string) gimlet.Middleware {
+	return newAuditMiddleware(s.Environment, action, s.Conf.Retention.AuditTTL())
+}
+
+// optionalProjectRead wraps a project read permission check so that it is only
+// enforced when the enforce_project_read_roles operational flag is set.
+func (s *Service) optionalProjectRead(check gimlet.Middleware) gimlet.Middleware {
+	return newOptionalProjectReadMiddleware(s.Environment, check)
+}
+
 func (s *Service) addRoutes() {
 	checkUser := gimlet.NewRequireAuthHandler()
+	checkAdmin := newRequireAdminMiddleware()
+	checkSelfOrAdmin := newRequireSelfOrAdminMiddleware()
+	checkPerfProjectRead := s.optionalProjectRead(newRequirePerfProjectPermissionMiddleware(s.sc, model.PermissionProjectRead))
+	checkPerfProjectWrite := newRequirePerfProjectPermissionMiddleware(s.sc, model.PermissionProjectWrite)
+	checkPerfTaskIDRead := s.optionalProjectRead(newRequirePerfResultsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead, parsePerfOptions(makeGetPerfByTaskId)))
+	checkPerfTaskIDCountRead := s.optionalProjectRead(newRequirePerfResultsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead, parsePerfOptions(makeCountPerfByTaskId)))
+	checkPerfTaskNameRead := s.optionalProjectRead(newRequirePerfResultsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead, parsePerfOptions(makeGetPerfByTaskName)))
+	checkPerfVersionRead := s.optionalProjectRead(newRequirePerfResultsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead, parsePerfOptions(makeGetPerfByVersion)))
+	checkTestResultsProjectRead := s.optionalProjectRead(newRequireTestResultsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead))
+	checkDisplayTaskTestResultsProjectRead := s.optionalProjectRead(newRequireDisplayTaskTestResultsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead))
+	checkTestResultsTasksProjectRead := s.optionalProjectRead(newRequireTestResultsTasksProjectPermissionMiddleware(s.sc, model.PermissionProjectRead))
+	checkSystemMetricsProjectRead := s.optionalProjectRead(newRequireSystemMetricsProjectPermissionMiddleware(s.sc, model.PermissionProjectRead))
+	checkProjectRead := s.optionalProjectRead(newRequireProjectPermissionMiddleware(model.PermissionProjectRead))
+	checkProjectWrite := newRequireProjectPermissionMiddleware(model.PermissionProjectWrite)
 	checkDepot := newCertCheckDepotMiddleware(s.Depot == nil)
 	evgAuthReadLogByID := newEvgAuthReadLogByIDMiddleware(s.sc, &s.Conf.Evergreen)
 	evgAuthReadLogByTaskID := newEvgAuthReadLogByTaskIDMiddleware(s.sc, &s.Conf.Evergreen)
@@ -288,12 +351,24 @@ func (s *Service) addRoutes() {
 	s.app.AddRoute("/admin/status/event/{id}").Version(1).Get().Wrap(checkUser).Handler(s.getSystemEvent)
 	s.app.AddRoute("/admin/status/event/{id}/acknowledge").Version(1).Get().Wrap(checkUser).Handler(s.acknowledgeSystemEvent)
 	s.app.AddRoute("/admin/status/events/{level}").Version(1).Get().Wrap(checkUser).Handler(s.getSystemEvents)
-	s.app.AddRoute("/admin/service/flag/{flagName}/enabled").Version(1).Post().Wrap(checkUser).Handler(s.setServiceFlagEnabled)
-	s.app.AddRoute("/admin/service/flag/{flagName}/disabled").Version(1).Post().Wrap(checkUser).Handler(s.setServiceFlagDisabled)
+	s.app.AddRoute("/admin/service/flag/{flagName}/enabled").Version(1).Post().Wrap(s.audit(model.AuditActionSetServiceFlag), checkAdmin).Handler(s.setServiceFlagEnabled)
+	s.app.AddRoute("/admin/service/flag/{flagName}/disabled").Version(1).Post().Wrap(s.audit(model.AuditActionSetServiceFlag), checkAdmin).Handler(s.setServiceFlagDisabled)
+	s.app.AddRoute("/admin/audit").Version(1).Get().Wrap(checkAdmin).Handler(s.getAuditEvents)
+	s.app.AddRoute("/admin/stats/ingestion").Version(1).Get().Wrap(checkAdmin).Handler(s.getIngestionStatsTotals)
+	s.app.AddRoute("/admin/stats/ingestion/{project_id}").Version(1).Get().Wrap(checkAdmin).Handler(s.getProjectIngestionStats)
+	s.app.AddRoute("/admin/historical_test_data/{project_id}/recompute").Version(1).Post().Wrap(s.audit(model.AuditActionRecomputeHistoricalData), checkAdmin).Handler(s.recomputeHistoricalTestData)
 	s.app.AddRoute("/admin/ca").Version(1).Get().Wrap(checkDepot).Handler(s.fetchRootCert)
-	s.app.AddRoute("/admin/users/certificate").Version(1).Post().Get().Wrap(checkDepot).Handler(s.fetchUserCert)
-	s.app.AddRoute("/admin/users/certificate/key").Version(1).Post().Get().Wrap(checkDepot).Handler(s.fetchUserCertKey)
-	s.app.AddRoute("/admin/perf/change_points").Version(1).Post().Wrap(checkUser).RouteHandler(makePerfSignalProcessingRecalculate(s.sc))
+	s.app.AddRoute("/admin/users/certificate").Version(1).Post().Get().Wrap(s.audit(model.AuditActionFetchUserCert), checkDepot).Handler(s.fetchUserCert)
+	s.app.AddRoute("/admin/users/certificate/key").Version(1).Post().Get().Wrap(s.audit(model.AuditActionFetchUserCert), checkDepot).Handler(s.fetchUserCertKey)
+	s.app.AddRoute("/admin/users/{user_id}/certificate/revoke").Version(1).Post().Wrap(s.audit(model.AuditActionRevokeUserCert), checkDepot, checkAdmin).Handler(s.revokeUserCert)
+	s.app.AddRoute("/admin/users/{user_id}/keys").Version(1).Get().Wrap(checkSelfOrAdmin).Handler(s.listAPIKeys)
+	s.app.AddRoute("/admin/users/{user_id}/keys").Version(1).Post().Wrap(s.audit(model.AuditActionAPIKeyCreate), checkSelfOrAdmin).Handler(s.createAPIKey)
+	s.app.AddRoute("/admin/users/{user_id}/keys/{name}/rotate").Version(1).Post().Wrap(s.audit(model.AuditActionAPIKeyRotate), checkSelfOrAdmin).Handler(s.rotateAPIKey)
+	s.app.AddRoute("/admin/users/{user_id}/keys/{name}/expire").Version(1).Post().Wrap(s.audit(model.AuditActionAPIKeyExpire), checkSelfOrAdmin).Handler(s.expireAPIKey)
+	s.app.AddRoute("/admin/users/{user_id}/keys/{name}/revoke").Version(1).Post().Wrap(s.audit(model.AuditActionAPIKeyRevoke), checkSelfOrAdmin).Handler(s.revokeAPIKey)
+	s.app.AddRoute("/admin/users/{user_id}/legacy_key/expire").Version(1).Post().Wrap(s.audit(model.AuditActionAPIKeyExpire), checkSelfOrAdmin).Handler(s.expireLegacyAPIKey)
+	s.app.AddRoute("/admin/users/{user_id}/legacy_key/revoke").Version(1).Post().Wrap(s.audit(model.AuditActionAPIKeyRevoke), checkSelfOrAdmin).Handler(s.revokeLegacyAPIKey)
+	s.app.AddRoute("/admin/perf/change_points").Version(1).Post().Wrap(s.audit(model.AuditActionRecalculateChangePoints), checkAdmin).RouteHandler(makePerfSignalProcessingRecalculate(s.sc))
 
 	s.app.AddRoute("/simple_log/{id}").Version(1).Post().Wrap(checkUser).Handler(s.simpleLogIngestion)
 	s.app.AddRoute("/simple_log/{id}").Version(1).Get().Handler(s.simpleLogRetrieval)
@@ -301,33 +376,55 @@ func (s *Service) addRoutes() {
 	s.app.AddRoute("/system_info").Version(1).Post().Wrap(checkUser).Handler(s.recieveSystemInfo)
 	s.app.AddRoute("/system_info/host/{host}").Version(1).Post().Wrap(checkUser).Handler(s.fetchSystemInfo)
 
-	s.app.AddRoute("/perf/{id}").Version(1).Get().RouteHandler(makeGetPerfById(s.sc))
-	s.app.AddRoute("/perf/{id}").Version(1).Delete().Wrap(checkUser).RouteHandler(makeRemovePerfById(s.sc))
-	s.app.AddRoute("/perf/children/{id}").Version(1).Get().RouteHandler(makeGetPerfChildren(s.sc))
-	s.app.AddRoute("/perf/task_id/{task_id}").Version(1).Get().RouteHandler(makeGetPerfByTaskId(s.sc))
-	s.app.AddRoute("/perf/task_id/{task_id}/count").Version(1).Get().RouteHandler(makeCountPerfByTaskId(s.sc))
-	s.app.AddRoute("/perf/task_name/{task_name}").Version(1).Get().RouteHandler(makeGetPerfByTaskName(s.sc))
-	s.app.AddRoute("/perf/version/{version}").Version(1).Get().RouteHandler(makeGetPerfByVersion(s.sc))
+	s.app.AddRoute("/perf/{id}").Version(1).Get().Wrap(checkPerfProjectRead).RouteHandler(makeGetPerfById(s.sc))
+	s.app.AddRoute("/perf/{id}").Version(1).Delete().Wrap(s.audit(model.AuditActionRemovePerformanceResult), checkPerfProjectWrite).RouteHandler(makeRemovePerfById(s.sc))
+	s.app.AddRoute("/perf/{id}/presigned_urls").Version(1).Get().Wrap(checkPerfProjectRead).RouteHandler(makePresignPerfById(s.sc))
+	s.app.AddRoute("/perf/children/{id}").Version(1).Get().Wrap(checkPerfProjectRead).RouteHandler(makeGetPerfChildren(s.sc))
+	s.app.AddRoute("/perf/task_id/{task_id}").Version(1).Get().Wrap(checkPerfTaskIDRead).RouteHandler(makeGetPerfByTaskId(s.sc))
+	s.app.AddRoute("/perf/task_id/{task_id}/count").Version(1).Get().Wrap(checkPerfTaskIDCountRead).RouteHandler(makeCountPerfByTaskId(s.sc))
+	s.app.AddRoute("/perf/task_name/{task_name}").Version(1).Get().Wrap(checkPerfTaskNameRead).RouteHandler(makeGetPerfByTaskName(s.sc))
+	s.app.AddRoute("/perf/version/{version}").Version(1).Get().Wrap(checkPerfVersionRead).RouteHandler(makeGetPerfByVersion(s.sc))
 
 	s.app.AddRoute("/buildlogger/{id}").Version(1).Get().Wrap(evgAuthReadLogByID).RouteHandler(makeGetLogByID(s.sc))
 	s.app.AddRoute("/buildlogger/{id}/meta").Version(1).Get().Wrap(evgAuthReadLogByID).RouteHandler(makeGetLogMetaByID(s.sc))
+	s.app.AddRoute("/buildlogger/{id}/presigned_urls").Version(1).Get().Wrap(evgAuthReadLogByID).RouteHandler(makePresignLogByID(s.sc))
+	s.app.AddRoute("/buildlogger/redaction/{project_id}/secrets").Version(1).Get().Wrap(checkProjectWrite).Handler(s.getRedactionSecrets)
+	s.app.AddRoute("/buildlogger/redaction/{project_id}/secrets").Version(1).Post().Wrap(s.audit(model.AuditActionAddRedactionSecrets), checkProjectWrite).Handler(s.addRedactionSecrets)
+	s.app.AddRoute("/buildlogger/redaction/{project_id}/secrets/{secret_id}").Version(1).Delete().Wrap(s.audit(model.AuditActionDeleteRedactionSecret), checkProjectWrite).Handler(s.deleteRedactionSecret)
 	s.app.AddRoute("/buildlogger/task_id/{task_id}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogByTaskID(s.sc))
 	s.app.AddRoute("/buildlogger/task_id/{task_id}/meta").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogMetaByTaskID(s.sc))
 	s.app.AddRoute("/buildlogger/task_id/{task_id}/group/{group_id}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogGroupByTaskID(s.sc))
 	s.app.AddRoute("/buildlogger/test_name/{task_id}/{test_name}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogByTestName(s.sc))
 	s.app.AddRoute("/buildlogger/test_name/{task_id}/{test_name}/meta").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogMetaByTestName(s.sc))
 	s.app.AddRoute("/buildlogger/test_name/{task_id}/{test_name}/group/{group_id}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogGroupByTestName(s.sc))
-
-	s.app.AddRoute("/test_results/filtered_samples").Version(1).Get().RouteHandler(makeGetTestResultsFilteredSamples(s.sc))
-	s.app.AddRoute("/test_results/task_id/{task_id}").Version(1).Get().RouteHandler(makeGetTestResultsByTaskID(s.sc))
-	s.app.AddRoute("/test_results/task_id/{task_id}/failed_sample").Version(1).Get().RouteHandler(makeGetTestResultsFailedSample(s.sc))
-	s.app.AddRoute("/test_results/task_id/{task_id}/stats").Version(1).Get().RouteHandler(makeGetTestResultsStats(s.sc))
+	s.app.AddRoute("/buildlogger/test_result/{task_id}/{test_name}").Version(1).Get().Wrap(evgAuthReadLogByTaskID).RouteHandler(makeGetLogByTestResult(s.sc))
+
+	s.app.AddRoute("/test_results/filtered_samples").Version(1).Get().Wrap(checkTestResultsTasksProjectRead).RouteHandler(makeGetTestResultsFilteredSamples(s.sc))
+	s.app.AddRoute("/test_results/project/{project_id}").Version(1).Get().Wrap(checkProjectRead).RouteHandler(makeGetTestResultsByProject(s.sc))
+	s.app.AddRoute("/test_results/project/{project_id}/ingest/{format}").Version(1).Post().Wrap(checkProjectWrite).Handler(s.ingestTestResults)
+	s.app.AddRoute("/test_results/project/{project_id}/ownership").Version(1).Get().Wrap(checkProjectRead).Handler(s.getTestOwnership)
+	s.app.AddRoute("/test_results/project/{project_id}/ownership").Version(1).Put().Wrap(s.audit(model.AuditActionSetTestOwnership), checkProjectWrite).Handler(s.setTestOwnership)
+	s.app.AddRoute("/test_results/project/{project_id}/quarantine").Version(1).Get().Wrap(checkProjectRead).Handler(s.getTestQuarantines)
+	s.app.AddRoute("/test_results/project/{project_id}/quarantine").Version(1).Post().Wrap(s.audit(model.AuditActionAddTestQuarantine), checkProjectWrite).Handler(s.addTestQuarantine)
+	s.app.AddRoute("/test_results/project/{project_id}/quarantine").Version(1).Delete().Wrap(s.audit(model.AuditActionRemoveTestQuarantine), checkProjectWrite).Handler(s.removeTestQuarantine)
+	s.app.AddRoute("/test_results/project/{project_id}/quarantine/history").Version(1).Get().Wrap(checkProjectRead).Handler(s.getTestQuarantineHistory)
+	s.app.AddRoute("/test_results/task_id/{task_id}").Version(1).Get().Wrap(checkTestResultsProjectRead).RouteHandler(makeGetTestResultsByTaskID(s.sc))
+	s.app.AddRoute("/test_results/task_id/{task_id}/failed_sample").Version(1).Get().Wrap(checkTestResultsProjectRead).RouteHandler(makeGetTestResultsFailedSample(s.sc))
+	s.app.AddRoute("/test_results/task_id/{task_id}/stats").Version(1).Get().Wrap(checkTestResultsProjectRead).RouteHandler(makeGetTestResultsStats(s.sc))
+	s.app.AddRoute("/test_results/task_id/{task_id}/presigned_urls").Version(1).Get().Wrap(checkTestResultsProjectRead).RouteHandler(makePresignTestResultsByTaskID(s.sc))
 	// TODO: (EVG-15299) Remove these two routes once we are sure no one is
 	// using them. Keeping temporarily for backwards compatibility.
-	s.app.AddRoute("/test_results/display_task_id/{display_task_id}").Version(1).Get().RouteHandler(makeGetTestResultsByDisplayTaskID(s.sc))
-	s.app.AddRoute("/test_results/test_name/{task_id}/{test_name}").Version(1).Get().RouteHandler(makeGetTestResultByTestName(s.sc))
+	s.app.AddRoute("/test_results/display_task_id/{display_task_id}").Version(1).Get().Wrap(checkDisplayTaskTestResultsProjectRead).RouteHandler(makeGetTestResultsByDisplayTaskID(s.sc))
+	s.app.AddRoute("/test_results/test_name/{task_id}/{test_name}").Version(1).Get().Wrap(checkTestResultsProjectRead).RouteHandler(makeGetTestResultByTestName(s.sc))
+
+	s.app.AddRoute("/webhooks/project/{project_id}").Version(1).Post().Wrap(s.audit(model.AuditActionCreateWebhook), checkProjectWrite).Handler(s.createWebhookSubscription)
+	s.app.AddRoute("/webhooks/project/{project_id}").Version(1).Get().Wrap(checkProjectWrite).Handler(s.getWebhookSubscriptions)
+	s.app.AddRoute("/webhooks/project/{project_id}/{subscription_id}").Version(1).Delete().Wrap(s.audit(model.AuditActionDeleteWebhook), checkProjectWrite).Handler(s.deleteWebhookSubscription)
+	s.app.AddRoute("/webhooks/project/{project_id}/{subscription_id}/deliveries").Version(1).Get().Wrap(checkProjectWrite).Handler(s.getWebhookDeliveries)
+	s.app.AddRoute("/perf/project/{project_id}/change_points").Version(1).Post().Wrap(checkProjectWrite).Handler(s.reportPerfChangePoint)
 
-	s.app.AddRoute("/historical_test_data/{project_id}").Version(1).Get().RouteHandler(makeGetHistoricalTestData(s.sc))
+	s.app.AddRoute("/historical_test_data/{project_id}").Version(1).Get().Wrap(checkProjectRead).RouteHandler(makeGetHistoricalTestData(s.sc))
 
-	s.app.AddRoute("/system_metrics/type/{task_id}/{type}").Version(1).Get().RouteHandler(makeGetSystemMetricsByType(s.sc))
+	s.app.AddRoute("/system_metrics/type/{task_id}/{type}").Version(1).Get().Wrap(checkSystemMetricsProjectRead).RouteHandler(makeGetSystemMetricsByType(s.sc))
+	s.app.AddRoute("/system_metrics/type/{task_id}/{type}/presigned_urls").Version(1).Get().Wrap(checkSystemMetricsProjectRead).RouteHandler(makePresignSystemMetricsByType(s.sc))
 }
@@ -43,18 +43,13 @@ func (h *systemMetricsGetByTypeHandler) Factory() gimlet.RouteHandler {
 func (h *systemMetricsGetByTypeHandler) Parse(_ context.Context, r *http.Request) error {
 	var err error
 
-	h.findOpts.TaskID = gimlet.GetVars(r)["task_id"]
+	h.findOpts, err = parseSystemMetricsFindOptions(r)
+	if err != nil {
+		return err
+	}
 	h.downloadOpts.MetricType = gimlet.GetVars(r)["type"]
 	vals := r.URL.Query()
 	h.downloadOpts.PageSize = softSizeLimit
-	if len(vals[execution]) > 0 {
-		h.findOpts.Execution, err = strconv.Atoi(vals[execution][0])
-		if err != nil {
-			return err
-		}
-	} else {
-		h.findOpts.EmptyExecution = true
-	}
 	if len(vals[startIndex]) > 0 {
 		h.downloadOpts.StartIndex, err = strconv.Atoi(vals[startIndex][0])
 		if err != nil {
@@ -84,6 +79,24 @@ func (h *systemMetricsGetByTypeHandler) Run(ctx context.Context) gimlet.Responde
 	return newSystemMetricsResponder(h.sc.GetBaseURL(), data, h.downloadOpts.StartIndex, nextIdx)
 }
 
+// parseSystemMetricsFindOptions returns the options to find the system metrics
+// for the task ID and optional execution in the HTTP request.
+func parseSystemMetricsFindOptions(r *http.Request) (model.SystemMetricsFindOptions, error) {
+	findOpts := model.SystemMetricsFindOptions{TaskID: gimlet.GetVars(r)["task_id"]}
+	vals := r.URL.Query()
+	if len(vals[execution]) > 0 {
+		var err error
+		findOpts.Execution, err = strconv.Atoi(vals[execution][0])
+		if err != nil {
+			return model.SystemMetricsFindOptions{}, err
+		}
+	} else {
+		findOpts.EmptyExecution = true
+	}
+
+	return findOpts, nil
+}
+
 func newSystemMetricsResponder(baseURL string, data []byte, startIdx, nextIdx int) gimlet.Responder {
 	resp := gimlet.NewTextResponse(data)
 
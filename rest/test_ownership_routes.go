@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+// TestOwnershipRequest is the payload for replacing the test ownership rules
+// of a project.
+type TestOwnershipRequest struct {
+	Rules []model.TestOwnershipRule `json:"rules"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/project/{project_id}/ownership
+
+func (s *Service) getTestOwnership(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+
+	ownership, err := model.FindTestOwnership(r.Context(), s.Environment, project)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	if ownership == nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("project '%s' has no test ownership", project),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, ownership)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// PUT /test_results/project/{project_id}/ownership
+//
+// Replaces the ordered test ownership rules of the project. The first rule
+// whose pattern matches a test name determines the owning team. Setting no
+// rules removes the project's test ownership.
+
+func (s *Service) setTestOwnership(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+	setAuditTarget(r.Context(), fmt.Sprintf("project/%s/test_ownership", project))
+
+	req := &TestOwnershipRequest{}
+	if err := gimlet.GetJSON(r.Body, req); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading test ownership request").Error(),
+		}))
+		return
+	}
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"rules": req.Rules,
+	})
+
+	var user string
+	if u := gimlet.GetUser(r.Context()); u != nil {
+		user = u.Username()
+	}
+	if _, err := model.NewTestOwnership(project, req.Rules); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+	ownership, err := model.SetTestOwnership(r.Context(), s.Environment, project, user, req.Rules)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, ownership)
+}
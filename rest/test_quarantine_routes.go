@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+// TestQuarantineRequest is the payload for quarantining a test of a project.
+type TestQuarantineRequest struct {
+	TestName  string    `json:"test_name"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/project/{project_id}/quarantine
+//
+// Returns the actively quarantined tests of the project.
+
+func (s *Service) getTestQuarantines(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+
+	quarantines, err := model.FindTestQuarantines(r.Context(), s.Environment, project)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, quarantines)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /test_results/project/{project_id}/quarantine
+//
+// Quarantines a test of the project until the given expiration, replacing any
+// existing quarantine of the test.
+
+func (s *Service) addTestQuarantine(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+	setAuditTarget(r.Context(), model.TestQuarantineAuditTarget(project))
+
+	req := &TestQuarantineRequest{}
+	if err := gimlet.GetJSON(r.Body, req); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading test quarantine request").Error(),
+		}))
+		return
+	}
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"test_name":  req.TestName,
+		"reason":     req.Reason,
+		"expires_at": req.ExpiresAt,
+	})
+
+	var user string
+	if u := gimlet.GetUser(r.Context()); u != nil {
+		user = u.Username()
+	}
+	quarantine, err := model.NewTestQuarantine(project, req.TestName, req.Reason, user, req.ExpiresAt)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+	before, err := quarantine.Save(r.Context(), s.Environment)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	setAuditChange(r.Context(), before, quarantine)
+
+	gimlet.WriteJSON(rw, quarantine)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// DELETE /test_results/project/{project_id}/quarantine?test_name={test_name}
+//
+// Removes the quarantine of a test of the project.
+
+func (s *Service) removeTestQuarantine(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+	testName := r.URL.Query().Get("test_name")
+	setAuditTarget(r.Context(), model.TestQuarantineAuditTarget(project))
+	addAuditParameters(r.Context(), map[string]interface{}{"test_name": testName})
+
+	if testName == "" {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "must specify a test name",
+		}))
+		return
+	}
+
+	removed, err := model.RemoveTestQuarantine(r.Context(), s.Environment, project, testName)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	if removed == nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("test '%s' is not quarantined in project '%s'", testName, project),
+		}))
+		return
+	}
+	setAuditChange(r.Context(), removed, nil)
+
+	gimlet.WriteJSON(rw, struct{}{})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/project/{project_id}/quarantine/history
+//
+// Returns the audit events recording the changes to the quarantined tests of
+// the project, most recent first. The number of events returned may be set
+// with the limit query parameter.
+
+func (s *Service) getTestQuarantineHistory(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+
+	var limit int
+	if val := r.URL.Query().Get("limit"); val != "" {
+		var err error
+		if limit, err = strconv.Atoi(val); err != nil {
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing limit '%s'", val).Error(),
+			}))
+			return
+		}
+	}
+
+	events, err := model.FindTestQuarantineHistory(r.Context(), s.Environment, project, limit)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, events)
+}
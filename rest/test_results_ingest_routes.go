@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/units"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+// maxTestResultsIngestSize is the largest file of test results, in bytes,
+// that may be ingested.
+const maxTestResultsIngestSize = 128 * 1024 * 1024
+
+// TestResultsIngestResponse is the response for ingesting a file of test
+// results.
+type TestResultsIngestResponse struct {
+	ID          string `json:"id"`
+	TotalCount  int    `json:"total_count"`
+	FailedCount int    `json:"failed_count"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /test_results/project/{project_id}/ingest/{format}
+//
+// The request body is the raw JUnit XML, go test -json or TAP output, which may
+// be at most 128MB, and the task execution is described by the query
+// parameters. As with the gRPC API, the results are added to the historical
+// test data unless historical_data_disabled is true.
+
+func (s *Service) ingestTestResults(rw http.ResponseWriter, r *http.Request) {
+	vars := gimlet.GetVars(r)
+	format := model.TestResultsFormat(vars["format"])
+	if err := format.Validate(); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	vals := r.URL.Query()
+	info := model.TestResultsInfo{
+		Project:                vars["project_id"],
+		Version:                vals.Get("version"),
+		Variant:                vals.Get("variant"),
+		TaskName:               vals.Get("task_name"),
+		DisplayTaskName:        vals.Get("display_task_name"),
+		TaskID:                 vals.Get("task_id"),
+		DisplayTaskID:          vals.Get("display_task_id"),
+		RequestType:            vals.Get("request_type"),
+		Mainline:               vals.Get("mainline") == trueString,
+		HistoricalDataDisabled: vals.Get("historical_data_disabled") == trueString,
+	}
+	if info.TaskID == "" {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "must specify a task ID",
+		}))
+		return
+	}
+	if len(vals[execution]) > 0 {
+		var err error
+		info.Execution, err = strconv.Atoi(vals[execution][0])
+		if err != nil {
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing execution '%s'", vals[execution][0]).Error(),
+			}))
+			return
+		}
+	}
+
+	if r.ContentLength > maxTestResultsIngestSize {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    fmt.Sprintf("test results file must be at most %d bytes", maxTestResultsIngestSize),
+		}))
+		return
+	}
+
+	results, err := model.ParseTestResults(format, http.MaxBytesReader(rw, r.Body, maxTestResultsIngestSize))
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "parsing test results").Error(),
+		}))
+		return
+	}
+
+	record, err := model.IngestTestResults(r.Context(), s.Environment, info, results)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(errors.Wrap(err, "ingesting test results")))
+		return
+	}
+	units.EnqueueEvergreenEnrichment(r.Context(), s.Environment, model.EvergreenMetadataRecordTestResults, record.ID, record.Info.TaskID)
+	units.EnqueueTestResultsWebhookEvents(r.Context(), s.Environment, record)
+	units.StartHistoricalTestDataUpdate(s.Environment, record, results)
+	units.StartHistoricalTaskDataUpdate(s.Environment, record, results)
+
+	gimlet.WriteJSON(rw, TestResultsIngestResponse{
+		ID:          record.ID,
+		TotalCount:  record.Stats.TotalCount,
+		FailedCount: record.Stats.FailedCount,
+	})
+}
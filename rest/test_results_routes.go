@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/evergreen-ci/cedar/rest/data"
+	"github.com/evergreen-ci/cedar/rest/model"
 	"github.com/evergreen-ci/gimlet"
 	"github.com/evergreen-ci/utility"
 	"github.com/mongodb/grip"
@@ -19,11 +23,25 @@ const (
 	testResultsTestName   = "test_name"
 	testResultsStatus     = "status"
 	testResultsGroupID    = "group_id"
+	testResultsFailureMsg = "failure_message"
+	testResultsTag        = "tag"
+	testResultsAttribute  = "attribute"
+	testResultsTeam       = "team"
+	testResultsQuarantine = "quarantined"
+	testResultsTeams      = "teams"
 	testResultsSortBy     = "sort_by"
 	testResultsSortDSC    = "sort_order_dsc"
 	testResultsLimit      = "limit"
 	testResultsPage       = "page"
 	testResultsBaseTaskID = "base_task_id"
+	testResultsVariant    = "variant"
+	testResultsTaskName   = "task_name"
+	testResultsRequester  = "requester"
+	testResultsAuthor     = "author"
+	testResultsStart      = "start"
+	testResultsEnd        = "end"
+
+	defaultTestResultsByProjectInterval = 7 * 24 * time.Hour
 )
 
 type testResultsBaseHandler struct {
@@ -71,11 +89,52 @@ func (h *testResultsGetByTaskIDHandler) Parse(ctx context.Context, r *http.Reque
 
 	catcher.Add(h.testResultsBaseHandler.Parse(ctx, r))
 	vals := r.URL.Query()
+	filterAndSort, err := parseTestResultsFilterAndSortOptions(vals)
+	catcher.Add(err)
+	baseTaskID := vals.Get(testResultsBaseTaskID)
+	if filterAndSort == nil && baseTaskID == "" {
+		return catcher.Resolve()
+	}
+
+	if filterAndSort == nil {
+		filterAndSort = &data.TestResultsFilterAndSortOptions{}
+	}
+	h.opts.FilterAndSort = filterAndSort
+	if baseTaskID != "" {
+		h.opts.FilterAndSort.BaseResults = &data.TestResultsOptions{
+			TaskID:      baseTaskID,
+			DisplayTask: h.opts.DisplayTask,
+		}
+	}
+
+	return catcher.Resolve()
+}
+
+// parseTestResultsFilterAndSortOptions parses the filter, sort, and
+// pagination query parameters of test results routes. It returns nil options
+// if none are specified.
+func parseTestResultsFilterAndSortOptions(vals url.Values) (*data.TestResultsFilterAndSortOptions, error) {
+	catcher := grip.NewBasicCatcher()
+
 	testName := vals.Get(testResultsTestName)
 	statuses := vals[testResultsStatus]
 	groupID := vals.Get(testResultsGroupID)
+	failureMessage := vals.Get(testResultsFailureMsg)
+	tags := vals[testResultsTag]
+	team := vals.Get(testResultsTeam)
+	var attributes map[string]string
+	for _, attr := range vals[testResultsAttribute] {
+		kv := strings.SplitN(attr, ":", 2)
+		if len(kv) != 2 {
+			catcher.Errorf("attribute '%s' must be of the form 'key:value'", attr)
+			continue
+		}
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+		attributes[kv[0]] = kv[1]
+	}
 	sortBy := vals.Get(testResultsSortBy)
-	baseTaskID := vals.Get(testResultsBaseTaskID)
 	var limit, page int
 	if len(vals[testResultsLimit]) > 0 {
 		var err error
@@ -88,29 +147,54 @@ func (h *testResultsGetByTaskIDHandler) Parse(ctx context.Context, r *http.Reque
 		catcher.Add(err)
 	}
 
-	if testName == "" && len(statuses) == 0 && groupID == "" && sortBy == "" && baseTaskID == "" && limit <= 0 && page <= 0 {
-		return catcher.Resolve()
+	if testName == "" && len(statuses) == 0 && groupID == "" && failureMessage == "" && len(tags) == 0 && len(attributes) == 0 && team == "" && sortBy == "" && limit <= 0 && page <= 0 {
+		return nil, catcher.Resolve()
 	}
 
-	h.opts.FilterAndSort = &data.TestResultsFilterAndSortOptions{
-		TestName: testName,
-		Statuses: statuses,
-		GroupID:  groupID,
-		SortBy:   sortBy,
-		Limit:    limit,
-		Page:     page,
-	}
-	if vals.Get(testResultsSortDSC) == trueString {
-		h.opts.FilterAndSort.SortOrderDSC = true
-	}
-	if baseTaskID != "" {
-		h.opts.FilterAndSort.BaseResults = &data.TestResultsOptions{
-			TaskID:      baseTaskID,
-			DisplayTask: h.opts.DisplayTask,
+	return &data.TestResultsFilterAndSortOptions{
+		TestName:       testName,
+		Statuses:       statuses,
+		GroupID:        groupID,
+		FailureMessage: failureMessage,
+		Tags:           tags,
+		Attributes:     attributes,
+		Team:           team,
+		SortBy:         sortBy,
+		SortOrderDSC:   vals.Get(testResultsSortDSC) == trueString,
+		Limit:          limit,
+		Page:           page,
+	}, catcher.Resolve()
+}
+
+// setTestResultsPages sets the previous and next pages of a paginated test
+// results response.
+func setTestResultsPages(resp gimlet.Responder, baseURL string, opts *data.TestResultsFilterAndSortOptions, testResults *model.APITestResults) error {
+	if opts == nil || opts.Limit <= 0 {
+		return nil
+	}
+
+	pages := &gimlet.ResponsePages{
+		Prev: &gimlet.Page{
+			BaseURL:         baseURL,
+			KeyQueryParam:   testResultsPage,
+			LimitQueryParam: testResultsLimit,
+			Key:             fmt.Sprintf("%d", opts.Page),
+			Limit:           opts.Limit,
+			Relation:        "prev",
+		},
+	}
+	if len(testResults.Results) > 0 {
+		pages.Next = &gimlet.Page{
+			BaseURL:         baseURL,
+			KeyQueryParam:   testResultsPage,
+			LimitQueryParam: testResultsLimit,
+			Key:             fmt.Sprintf("%d", opts.Page+1),
+			Limit:           opts.Limit,
+			Relation:        "next",
 		}
 	}
 
-	return catcher.Resolve()
+	return errors.Wrap(resp.SetPages(pages), "setting response pages")
 }
 
 // Factory returns a pointer to a new testResultsGetByTaskIDHandler.
@@ -135,33 +219,90 @@ func (h *testResultsGetByTaskIDHandler) Run(ctx context.Context) gimlet.Responde
 		return gimlet.MakeJSONErrorResponder(err)
 	}
 
-	var resp gimlet.Responder
-	resp = gimlet.NewJSONResponse(testResults)
-	if h.opts.FilterAndSort != nil && h.opts.FilterAndSort.Limit > 0 {
-		pages := &gimlet.ResponsePages{
-			Prev: &gimlet.Page{
-				BaseURL:         h.sc.GetBaseURL(),
-				KeyQueryParam:   testResultsPage,
-				LimitQueryParam: testResultsLimit,
-				Key:             fmt.Sprintf("%d", h.opts.FilterAndSort.Page),
-				Limit:           h.opts.FilterAndSort.Limit,
-				Relation:        "prev",
-			},
-		}
-		if len(testResults.Results) > 0 {
-			pages.Next = &gimlet.Page{
-				BaseURL:         h.sc.GetBaseURL(),
-				KeyQueryParam:   testResultsPage,
-				LimitQueryParam: testResultsLimit,
-				Key:             fmt.Sprintf("%d", h.opts.FilterAndSort.Page+1),
-				Limit:           h.opts.FilterAndSort.Limit,
-				Relation:        "next",
-			}
-		}
+	resp := gimlet.NewJSONResponse(testResults)
+	if err = setTestResultsPages(resp, h.sc.GetBaseURL(), h.opts.FilterAndSort, testResults); err != nil {
+		return gimlet.MakeJSONErrorResponder(err)
+	}
 
-		if err := resp.SetPages(pages); err != nil {
-			return gimlet.MakeJSONErrorResponder(errors.Wrap(err, "setting response pages"))
-		}
+	return resp
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /test_results/project/{project_id}
+//
+// Returns the test results of the project across task executions, most
+// recent task first unless sorted otherwise. The date range defaults to the
+// past week.
+
+type testResultsGetByProjectHandler struct {
+	sc   data.Connector
+	opts data.TestResultsByProjectOptions
+}
+
+func makeGetTestResultsByProject(sc data.Connector) gimlet.RouteHandler {
+	return &testResultsGetByProjectHandler{
+		sc: sc,
+	}
+}
+
+// Factory returns a pointer to a new testResultsGetByProjectHandler.
+func (h *testResultsGetByProjectHandler) Factory() gimlet.RouteHandler {
+	return &testResultsGetByProjectHandler{
+		sc: h.sc,
+	}
+}
+
+// Parse fetches the project ID from the HTTP request and any task, date
+// range, filter, sort, or pagination options.
+func (h *testResultsGetByProjectHandler) Parse(_ context.Context, r *http.Request) error {
+	catcher := grip.NewBasicCatcher()
+
+	h.opts.Project = gimlet.GetVars(r)["project_id"]
+	vals := r.URL.Query()
+	h.opts.Variant = vals.Get(testResultsVariant)
+	h.opts.TaskName = vals.Get(testResultsTaskName)
+	h.opts.RequestType = vals.Get(testResultsRequester)
+	h.opts.Author = vals.Get(testResultsAuthor)
+
+	h.opts.EndAt = time.Now()
+	if end := vals.Get(testResultsEnd); end != "" {
+		var err error
+		h.opts.EndAt, err = time.Parse(time.RFC3339, end)
+		catcher.Wrapf(err, "parsing end time '%s'", end)
+	}
+	h.opts.StartAt = h.opts.EndAt.Add(-defaultTestResultsByProjectInterval)
+	if start := vals.Get(testResultsStart); start != "" {
+		var err error
+		h.opts.StartAt, err = time.Parse(time.RFC3339, start)
+		catcher.Wrapf(err, "parsing start time '%s'", start)
+	}
+	catcher.ErrorfWhen(h.opts.StartAt.After(h.opts.EndAt), "start time cannot be after end time")
+
+	filterAndSort, err := parseTestResultsFilterAndSortOptions(vals)
+	catcher.Add(err)
+	h.opts.FilterAndSort = filterAndSort
+
+	return catcher.Resolve()
+}
+
+// Run finds and returns the test results of the project.
+func (h *testResultsGetByProjectHandler) Run(ctx context.Context) gimlet.Responder {
+	testResults, err := h.sc.FindTestResultsByProject(ctx, h.opts)
+	if err != nil {
+		err = errors.Wrapf(err, "getting test results by project '%s'", h.opts.Project)
+		logFindError(err, message.Fields{
+			"request": gimlet.GetRequestID(ctx),
+			"method":  "GET",
+			"route":   "/test_results/project/{project_id}",
+			"project": h.opts.Project,
+		})
+		return gimlet.MakeJSONErrorResponder(err)
+	}
+
+	resp := gimlet.NewJSONResponse(testResults)
+	if err = setTestResultsPages(resp, h.sc.GetBaseURL(), h.opts.FilterAndSort, testResults); err != nil {
+		return gimlet.MakeJSONErrorResponder(err)
 	}
 
 	return resp
@@ -219,9 +360,13 @@ func (h *testResultsGetFilteredSamplesHandler) Run(ctx context.Context) gimlet.R
 ///////////////////////////////////////////////////////////////////////////////
 //
 // GET /test_results/task_id/{task_id}/failed_sample
+//
+// Returns the failed test names, or, if teams is set, an object with the
+// failed test names and the owning teams of the tests owned by a team.
 
 type testResultsGetFailedSampleHandler struct {
-	sc data.Connector
+	sc    data.Connector
+	teams bool
 	testResultsBaseHandler
 }
 
@@ -238,6 +383,19 @@ func (h *testResultsGetFailedSampleHandler) Factory() gimlet.RouteHandler {
 	}
 }
 
+// Parse fetches the task ID from the HTTP request, whether to return the
+// sample of quarantined failed tests instead, and whether to return the owning
+// teams.
+func (h *testResultsGetFailedSampleHandler) Parse(ctx context.Context, r *http.Request) error {
+	if err := h.testResultsBaseHandler.Parse(ctx, r); err != nil {
+		return err
+	}
+	h.opts.Quarantined = r.URL.Query().Get(testResultsQuarantine) == trueString
+	h.teams = r.URL.Query().Get(testResultsTeams) == trueString
+
+	return nil
+}
+
 // Run finds and returns the desired failed test results sample.
 func (h *testResultsGetFailedSampleHandler) Run(ctx context.Context) gimlet.Responder {
 	sample, err := h.sc.GetFailedTestResultsSample(ctx, h.opts)
@@ -252,8 +410,11 @@ func (h *testResultsGetFailedSampleHandler) Run(ctx context.Context) gimlet.Resp
 		})
 		return gimlet.MakeJSONInternalErrorResponder(err)
 	}
+	if h.teams {
+		return gimlet.NewJSONResponse(sample)
+	}
 
-	return gimlet.NewJSONResponse(sample)
+	return gimlet.NewJSONResponse(sample.TestNames)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
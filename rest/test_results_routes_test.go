@@ -146,6 +146,7 @@ func (s *TestResultsHandlerSuite) setup(tempDir string) {
 
 	s.rh = map[string]gimlet.RouteHandler{
 		"task_id":             makeGetTestResultsByTaskID(s.sc),
+		"project":             makeGetTestResultsByProject(s.sc),
 		"failed_tests_sample": makeGetTestResultsFailedSample(s.sc),
 		"stats":               makeGetTestResultsStats(s.sc),
 		"display_task_id":     makeGetTestResultsByDisplayTaskID(s.sc),
@@ -330,6 +331,147 @@ func (s *TestResultsHandlerSuite) TestTestResultsGetByTaskIDHandler() {
 	}
 }
 
+func (s *TestResultsHandlerSuite) TestTestResultsGetByProjectHandler() {
+	rh := s.rh["project"].(*testResultsGetByProjectHandler)
+	now := time.Now()
+
+	for _, test := range []struct {
+		name          string
+		opts          data.TestResultsByProjectOptions
+		totalCount    int
+		filteredCount int
+		resultCount   int
+	}{
+		{
+			name: "AllTasks",
+			opts: data.TestResultsByProjectOptions{
+				Project: "test",
+				StartAt: now.Add(-time.Hour),
+				EndAt:   now.Add(time.Hour),
+			},
+			totalCount:    9,
+			filteredCount: 9,
+			resultCount:   9,
+		},
+		{
+			name: "FilterByVariantAndRequester",
+			opts: data.TestResultsByProjectOptions{
+				Project:     "test",
+				Variant:     "linux",
+				RequestType: "requesttype",
+				StartAt:     now.Add(-time.Hour),
+				EndAt:       now.Add(time.Hour),
+			},
+			totalCount:    9,
+			filteredCount: 9,
+			resultCount:   9,
+		},
+		{
+			name: "NoMatchingVariant",
+			opts: data.TestResultsByProjectOptions{
+				Project: "test",
+				Variant: "windows",
+				StartAt: now.Add(-time.Hour),
+				EndAt:   now.Add(time.Hour),
+			},
+		},
+		{
+			name: "NoMatchingAuthor",
+			opts: data.TestResultsByProjectOptions{
+				Project: "test",
+				Author:  "user",
+				StartAt: now.Add(-time.Hour),
+				EndAt:   now.Add(time.Hour),
+			},
+		},
+		{
+			name: "OutsideDateRange",
+			opts: data.TestResultsByProjectOptions{
+				Project: "test",
+				StartAt: now.Add(-2 * time.Hour),
+				EndAt:   now.Add(-time.Hour),
+			},
+		},
+		{
+			name: "FilterAndPaginate",
+			opts: data.TestResultsByProjectOptions{
+				Project: "test",
+				StartAt: now.Add(-time.Hour),
+				EndAt:   now.Add(time.Hour),
+				FilterAndSort: &data.TestResultsFilterAndSortOptions{
+					TestName: "test1",
+					Statuses: []string{"teststatus-fail"},
+					Limit:    2,
+				},
+			},
+			totalCount:    9,
+			filteredCount: 3,
+			resultCount:   2,
+		},
+	} {
+		s.T().Run(test.name, func(t *testing.T) {
+			rh.opts = test.opts
+			resp := rh.Run(context.TODO())
+
+			s.Require().NotNil(resp)
+			s.Require().Equal(http.StatusOK, resp.Status())
+			actualResult, ok := resp.Data().(*model.APITestResults)
+			s.Require().True(ok)
+			s.Equal(test.totalCount, actualResult.Stats.TotalCount)
+			s.Equal(test.totalCount, actualResult.Stats.FailedCount)
+			s.Equal(utility.ToIntPtr(test.filteredCount), actualResult.Stats.FilteredCount)
+			s.Len(actualResult.Results, test.resultCount)
+			for _, result := range actualResult.Results {
+				if test.opts.FilterAndSort != nil {
+					s.Equal("test1", utility.FromStringPtr(result.TestName))
+				}
+			}
+		})
+	}
+}
+
+func (s *TestResultsHandlerSuite) TestProjectParse() {
+	rh := s.rh["project"].Factory().(*testResultsGetByProjectHandler)
+	urlString := "http://cedar.mongodb.com/rest/v1/test_results/project/project1"
+	req := &http.Request{Method: http.MethodGet}
+	req.URL, _ = url.Parse(urlString)
+	req = gimlet.SetURLVars(req, map[string]string{"project_id": "project1"})
+
+	s.Require().NoError(rh.Parse(context.TODO(), req))
+	s.Equal("project1", rh.opts.Project)
+	s.Equal(defaultTestResultsByProjectInterval, rh.opts.EndAt.Sub(rh.opts.StartAt))
+	s.Nil(rh.opts.FilterAndSort)
+
+	rh = rh.Factory().(*testResultsGetByProjectHandler)
+	req.URL, _ = url.Parse(urlString + "?variant=linux&task_name=compile&requester=gitter_request&author=user&start=2022-01-01T00:00:00Z&end=2022-01-02T00:00:00Z&test_name=test&status=fail&limit=5")
+	s.Require().NoError(rh.Parse(context.TODO(), req))
+	s.Equal(data.TestResultsByProjectOptions{
+		Project:     "project1",
+		Variant:     "linux",
+		TaskName:    "compile",
+		RequestType: "gitter_request",
+		Author:      "user",
+		StartAt:     time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndAt:       time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC),
+		FilterAndSort: &data.TestResultsFilterAndSortOptions{
+			TestName: "test",
+			Statuses: []string{"fail"},
+			Limit:    5,
+		},
+	}, rh.opts)
+
+	for _, query := range []string{
+		"?start=yesterday",
+		"?end=tomorrow",
+		"?start=2022-01-02T00:00:00Z&end=2022-01-01T00:00:00Z",
+		"?limit=five",
+	} {
+		rh = rh.Factory().(*testResultsGetByProjectHandler)
+		req.URL, _ = url.Parse(urlString + query)
+		s.Error(rh.Parse(context.TODO(), req))
+	}
+}
+
 func (s *TestResultsHandlerSuite) TestTestResultsGetFailedSample() {
 	canceledCtx, cancel := context.WithCancel(context.Background())
 	cancel()
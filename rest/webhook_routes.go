@@ -0,0 +1,202 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/units"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/pkg/errors"
+)
+
+// WebhookSubscriptionRequest is the payload for subscribing a webhook URL to
+// events of a project.
+type WebhookSubscriptionRequest struct {
+	URL    string               `json:"url"`
+	Events []model.WebhookEvent `json:"events"`
+}
+
+// WebhookSubscriptionResponse is the response for creating a webhook
+// subscription. This is the only time the signing secret is returned.
+type WebhookSubscriptionResponse struct {
+	model.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /webhooks/project/{project_id}
+//
+// Subscribes a webhook URL to events of the project. Each delivery is signed
+// with the returned secret in the X-Cedar-Signature header.
+
+func (s *Service) createWebhookSubscription(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+	setAuditTarget(r.Context(), fmt.Sprintf("project/%s/webhooks", project))
+
+	req := &WebhookSubscriptionRequest{}
+	if err := gimlet.GetJSON(r.Body, req); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading webhook subscription request").Error(),
+		}))
+		return
+	}
+	addAuditParameters(r.Context(), map[string]interface{}{
+		"url":    req.URL,
+		"events": req.Events,
+	})
+
+	var user string
+	if u := gimlet.GetUser(r.Context()); u != nil {
+		user = u.Username()
+	}
+	sub, err := model.CreateWebhookSubscription(r.Context(), s.Environment, project, user, req.URL, req.Events)
+	if err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}))
+		return
+	}
+
+	gimlet.WriteJSON(rw, WebhookSubscriptionResponse{
+		WebhookSubscription: *sub,
+		Secret:              sub.Secret,
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /webhooks/project/{project_id}
+
+func (s *Service) getWebhookSubscriptions(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+
+	subs, err := model.FindWebhookSubscriptions(r.Context(), s.Environment, project, "")
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, subs)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// DELETE /webhooks/project/{project_id}/{subscription_id}
+
+func (s *Service) deleteWebhookSubscription(rw http.ResponseWriter, r *http.Request) {
+	vars := gimlet.GetVars(r)
+	project := vars["project_id"]
+	id := vars["subscription_id"]
+	setAuditTarget(r.Context(), fmt.Sprintf("project/%s/webhooks/%s", project, id))
+
+	sub, err := model.FindWebhookSubscription(r.Context(), s.Environment, id)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	if sub == nil || sub.Project != project {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("webhook subscription '%s' not found", id),
+		}))
+		return
+	}
+
+	if err = model.DeleteWebhookSubscription(r.Context(), s.Environment, project, id); err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, struct{}{})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// GET /webhooks/project/{project_id}/{subscription_id}/deliveries
+//
+// Returns the delivery log of the subscription, most recent first. The number
+// of deliveries returned may be set with the limit query parameter.
+
+func (s *Service) getWebhookDeliveries(rw http.ResponseWriter, r *http.Request) {
+	vars := gimlet.GetVars(r)
+	project := vars["project_id"]
+	id := vars["subscription_id"]
+
+	var limit int
+	if val := r.URL.Query().Get("limit"); val != "" {
+		var err error
+		if limit, err = strconv.Atoi(val); err != nil {
+			gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+				StatusCode: http.StatusBadRequest,
+				Message:    errors.Wrapf(err, "parsing limit '%s'", val).Error(),
+			}))
+			return
+		}
+	}
+
+	sub, err := model.FindWebhookSubscription(r.Context(), s.Environment, id)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+	if sub == nil || sub.Project != project {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("webhook subscription '%s' not found", id),
+		}))
+		return
+	}
+
+	deliveries, err := model.FindWebhookDeliveries(r.Context(), s.Environment, id, limit)
+	if err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, deliveries)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// POST /perf/project/{project_id}/change_points
+//
+// Called by the performance analysis service when it detects a change point
+// in one of the project's time series, to notify the project's webhooks.
+
+func (s *Service) reportPerfChangePoint(rw http.ResponseWriter, r *http.Request) {
+	project := gimlet.GetVars(r)["project_id"]
+
+	changePoint := &model.PerfChangePointWebhookData{}
+	if err := gimlet.GetJSON(r.Body, changePoint); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "reading change point").Error(),
+		}))
+		return
+	}
+	if err := changePoint.Validate(); err != nil {
+		gimlet.WriteResponse(rw, gimlet.MakeJSONErrorResponder(gimlet.ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "invalid change point").Error(),
+		}))
+		return
+	}
+
+	if err := units.NotifyWebhooks(r.Context(), s.Environment, project, model.WebhookEventPerfChangePoint, changePoint); err != nil {
+		logRequestError(r, err)
+		gimlet.WriteResponse(rw, gimlet.MakeJSONInternalErrorResponder(err))
+		return
+	}
+
+	gimlet.WriteJSON(rw, struct{}{})
+}
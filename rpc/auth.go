@@ -0,0 +1,341 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/aviation"
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/oidc"
+	"github.com/evergreen-ci/cedar/rpc/internal"
+	"github.com/evergreen-ci/gimlet"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/anser/db"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// makeAuthenticationUnaryInterceptor returns a unary interceptor that
+// authenticates requests with a bearer token in the "authorization" metadata,
+// such as OIDC tokens, or with one of the user's named API keys, and falls
+// back to the aviation authentication interceptor, which only knows about the
+// user's legacy API key, for all other requests.
+func makeAuthenticationUnaryInterceptor(env cedar.Environment, um gimlet.UserManager, umConf gimlet.UserMiddlewareConfiguration, ignore ...string) grpc.UnaryServerInterceptor {
+	fallback := aviation.MakeAuthenticationRequiredUnaryInterceptor(um, umConf, ignore...)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if utility.StringSliceContains(ignore, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		token, ok := bearerToken(ctx)
+		if !ok {
+			u, err := namedAPIKeyUser(ctx, env, umConf)
+			if err != nil {
+				return nil, err
+			}
+			if u == nil {
+				return fallback(ctx, req, info, handler)
+			}
+
+			return handler(gimlet.AttachUser(ctx, u), req)
+		}
+
+		u, err := um.GetUserByToken(ctx, token)
+		if err != nil || u == nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+
+		return handler(gimlet.AttachUser(ctx, u), req)
+	}
+}
+
+// makeAuthenticationStreamInterceptor is the stream equivalent of
+// makeAuthenticationUnaryInterceptor.
+func makeAuthenticationStreamInterceptor(env cedar.Environment, um gimlet.UserManager, umConf gimlet.UserMiddlewareConfiguration, ignore ...string) grpc.StreamServerInterceptor {
+	fallback := aviation.MakeAuthenticationRequiredStreamInterceptor(um, umConf, ignore...)
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if utility.StringSliceContains(ignore, info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		ctx := stream.Context()
+		token, ok := bearerToken(ctx)
+		if !ok {
+			u, err := namedAPIKeyUser(ctx, env, umConf)
+			if err != nil {
+				return err
+			}
+			if u == nil {
+				return fallback(srv, stream, info, handler)
+			}
+
+			return handler(srv, &authenticatedServerStream{
+				ServerStream: stream,
+				ctx:          gimlet.AttachUser(ctx, u),
+			})
+		}
+
+		u, err := um.GetUserByToken(ctx, token)
+		if err != nil || u == nil {
+			return status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+
+		return handler(srv, &authenticatedServerStream{
+			ServerStream: stream,
+			ctx:          gimlet.AttachUser(ctx, u),
+		})
+	}
+}
+
+// bearerToken returns the bearer token in the incoming "authorization"
+// metadata, if any.
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, value := range md.Get("authorization") {
+		if token, ok := oidc.ParseBearerToken(value); ok {
+			return token, true
+		}
+	}
+
+	return "", false
+}
+
+// namedAPIKeyUser returns the user authenticated by one of their named API
+// keys in the incoming metadata. A nil user is returned when the metadata
+// does not contain credentials matching a named API key, such as the user's
+// legacy API key, and an error is returned when the matching named key is
+// expired or revoked.
+func namedAPIKeyUser(ctx context.Context, env cedar.Environment, umConf gimlet.UserMiddlewareConfiguration) (gimlet.User, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	userIDs := md.Get(umConf.HeaderUserName)
+	keys := md.Get(umConf.HeaderKeyName)
+	if len(userIDs) == 0 || len(keys) == 0 || userIDs[0] == "" || keys[0] == "" {
+		return nil, nil
+	}
+
+	u, apiKey, err := model.FindUserByNamedAPIKey(env, userIDs[0], keys[0])
+	if err != nil || apiKey == nil {
+		return nil, nil
+	}
+	if !apiKey.IsValid(keys[0], time.Now()) {
+		return nil, status.Errorf(codes.Unauthenticated, "API key '%s' is expired or revoked", apiKey.Name)
+	}
+
+	return u, nil
+}
+
+// authenticatedServerStream overrides the context of a server stream with one
+// that has the authenticated user attached.
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedServerStream) Context() context.Context { return s.ctx }
+
+// makeAuthorizationUnaryInterceptor returns a unary interceptor that checks
+// that the authenticated user has write access to the project of the record
+// that the request creates or modifies. Existing records are looked up by ID
+// to find their project and requests of unrecognized types are denied. This
+// must run after the authentication interceptors, which attach the user to the
+// context.
+func makeAuthorizationUnaryInterceptor(env cedar.Environment, ignore ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if utility.StringSliceContains(ignore, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if err := authorizeRequest(ctx, env, gimlet.GetUser(ctx), req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// makeAuthorizationStreamInterceptor returns a stream interceptor that checks
+// each message received on the stream the same way as
+// makeAuthorizationUnaryInterceptor checks unary requests.
+func makeAuthorizationStreamInterceptor(env cedar.Environment, ignore ...string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if utility.StringSliceContains(ignore, info.FullMethod) {
+			return handler(srv, stream)
+		}
+
+		u := gimlet.GetUser(stream.Context())
+		if u == nil {
+			return status.Error(codes.Unauthenticated, "user not authenticated")
+		}
+
+		return handler(srv, &authorizedServerStream{
+			ServerStream: stream,
+			env:          env,
+			user:         u,
+			authorized:   map[string]bool{},
+		})
+	}
+}
+
+// authorizedServerStream authorizes each message received on a server stream.
+// Since the messages of a stream usually modify the same record, the records
+// that the user is authorized to modify are cached for the lifetime of the
+// stream.
+type authorizedServerStream struct {
+	grpc.ServerStream
+	env        cedar.Environment
+	user       gimlet.User
+	authorized map[string]bool
+}
+
+func (s *authorizedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("%T/%s", m, streamRecordID(m))
+	if s.authorized[key] {
+		return nil
+	}
+	if err := authorizeRequest(s.Context(), s.env, s.user, m); err != nil {
+		return err
+	}
+	s.authorized[key] = true
+
+	return nil
+}
+
+// streamRecordID returns the ID of the existing record that a streamed message
+// modifies.
+func streamRecordID(m interface{}) string {
+	switch r := m.(type) {
+	case *internal.LogLines:
+		return r.GetLogId()
+	case *internal.TestResults:
+		return r.GetTestResultsRecordId()
+	case *internal.SystemMetricsData:
+		return r.GetId()
+	case *internal.MetricsEvent:
+		return r.GetId()
+	default:
+		return ""
+	}
+}
+
+func authorizeRequest(ctx context.Context, env cedar.Environment, u gimlet.User, req interface{}) error {
+	if u == nil {
+		return status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+
+	project, err := requestProject(ctx, env, req)
+	if err != nil {
+		return err
+	}
+
+	opts := gimlet.PermissionOpts{
+		Resource:     project,
+		ResourceType: model.PermissionResourceTypeProject,
+		Permission:   model.PermissionProjectWrite,
+	}
+	if !model.UserHasPermission(u, opts) {
+		return status.Errorf(codes.PermissionDenied, "user '%s' does not have write access to project '%s'", u.Username(), project)
+	}
+
+	return nil
+}
+
+// requestProject returns the project of the record that the request creates
+// or modifies. Existing records are looked up by ID.
+func requestProject(ctx context.Context, env cedar.Environment, req interface{}) (string, error) {
+	switch r := req.(type) {
+	case *internal.LogData:
+		return r.GetInfo().GetProject(), nil
+	case *internal.TestResultsInfo:
+		return r.GetProject(), nil
+	case *internal.TestResultsFile:
+		return r.GetInfo().GetProject(), nil
+	case *internal.ResultData:
+		return r.GetId().GetProject(), nil
+	case *internal.SystemMetrics:
+		return r.GetInfo().GetProject(), nil
+	case *internal.LogLines:
+		return findLogProject(ctx, env, r.GetLogId())
+	case *internal.LogEndInfo:
+		return findLogProject(ctx, env, r.GetLogId())
+	case *internal.TestResults:
+		return findTestResultsProject(ctx, env, r.GetTestResultsRecordId())
+	case *internal.TestResultsEndInfo:
+		return findTestResultsProject(ctx, env, r.GetTestResultsRecordId())
+	case *internal.ArtifactData:
+		return findPerformanceResultProject(ctx, env, r.GetId())
+	case *internal.RollupData:
+		return findPerformanceResultProject(ctx, env, r.GetId())
+	case *internal.MetricsSeriesEnd:
+		return findPerformanceResultProject(ctx, env, r.GetId())
+	case *internal.MetricsEvent:
+		return findPerformanceResultProject(ctx, env, r.GetId())
+	case *internal.SystemMetricsData:
+		return findSystemMetricsProject(ctx, env, r.GetId())
+	case *internal.SystemMetricsSeriesEnd:
+		return findSystemMetricsProject(ctx, env, r.GetId())
+	default:
+		return "", status.Errorf(codes.PermissionDenied, "unauthorized request type %T", req)
+	}
+}
+
+func findLogProject(ctx context.Context, env cedar.Environment, id string) (string, error) {
+	log := &model.Log{ID: id}
+	log.Setup(env)
+	if err := log.Find(ctx); err != nil {
+		return "", recordLookupError(err, "log", id)
+	}
+
+	return log.Info.Project, nil
+}
+
+func findTestResultsProject(ctx context.Context, env cedar.Environment, id string) (string, error) {
+	record := &model.TestResults{ID: id}
+	record.Setup(env)
+	if err := record.Find(ctx); err != nil {
+		return "", recordLookupError(err, "test results record", id)
+	}
+
+	return record.Info.Project, nil
+}
+
+func findPerformanceResultProject(ctx context.Context, env cedar.Environment, id string) (string, error) {
+	result := &model.PerformanceResult{ID: id}
+	result.Setup(env)
+	if err := result.Find(ctx); err != nil {
+		return "", recordLookupError(err, "performance result", id)
+	}
+
+	return result.Info.Project, nil
+}
+
+func findSystemMetricsProject(ctx context.Context, env cedar.Environment, id string) (string, error) {
+	sm := &model.SystemMetrics{ID: id}
+	sm.Setup(env)
+	if err := sm.Find(ctx); err != nil {
+		return "", recordLookupError(err, "system metrics record", id)
+	}
+
+	return sm.Info.Project, nil
+}
+
+func recordLookupError(err error, kind, id string) error {
+	if db.ResultsNotFound(err) {
+		return status.Errorf(codes.NotFound, "%s '%s' not found", kind, id)
+	}
+
+	return status.Errorf(codes.Internal, "finding %s '%s': %s", kind, id, err.Error())
+}
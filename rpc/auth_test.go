@@ -0,0 +1,155 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/rpc/internal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthorizeRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := cedar.GetEnvironment()
+
+	log := model.CreateLog(model.LogInfo{Project: "project", TaskID: "task"}, model.PailLocal)
+	log.Setup(env)
+	require.NoError(t, log.SaveNew(ctx))
+	otherLog := model.CreateLog(model.LogInfo{Project: "other", TaskID: "task"}, model.PailLocal)
+	otherLog.Setup(env)
+	require.NoError(t, otherLog.SaveNew(ctx))
+	defer func() {
+		assert.NoError(t, env.GetDB().Collection("buildlogs").Drop(ctx))
+	}()
+
+	writer := &model.User{ID: "writer", SystemRoles: []string{model.FormatRole(model.RoleProjectWriter, "project")}}
+	reader := &model.User{ID: "reader", SystemRoles: []string{model.FormatRole(model.RoleReadOnly, "project")}}
+	create := &internal.LogData{Info: &internal.LogInfo{Project: "project"}}
+	createOther := &internal.TestResultsInfo{Project: "other"}
+	appendLines := &internal.LogLines{LogId: log.ID}
+	appendOtherLines := &internal.LogLines{LogId: otherLog.ID}
+	closeOther := &internal.LogEndInfo{LogId: otherLog.ID}
+
+	t.Run("NoUser", func(t *testing.T) {
+		assert.Equal(t, codes.Unauthenticated, status.Code(authorizeRequest(ctx, env, nil, create)))
+	})
+	t.Run("WriterCreatesInProject", func(t *testing.T) {
+		assert.NoError(t, authorizeRequest(ctx, env, writer, create))
+	})
+	t.Run("WriterCreatesInOtherProject", func(t *testing.T) {
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, writer, createOther)))
+	})
+	t.Run("WriterAppends", func(t *testing.T) {
+		assert.NoError(t, authorizeRequest(ctx, env, writer, appendLines))
+	})
+	t.Run("WriterAppendsInOtherProject", func(t *testing.T) {
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, writer, appendOtherLines)))
+	})
+	t.Run("WriterClosesInOtherProject", func(t *testing.T) {
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, writer, closeOther)))
+	})
+	t.Run("WriterAppendsToNonexistentRecord", func(t *testing.T) {
+		assert.Equal(t, codes.NotFound, status.Code(authorizeRequest(ctx, env, writer, &internal.LogLines{LogId: "DNE"})))
+	})
+	t.Run("WriterIngestsFileInProject", func(t *testing.T) {
+		file := &internal.TestResultsFile{Info: &internal.TestResultsInfo{Project: "project"}}
+		assert.NoError(t, authorizeRequest(ctx, env, writer, file))
+	})
+	t.Run("WriterIngestsFileInOtherProject", func(t *testing.T) {
+		file := &internal.TestResultsFile{Info: &internal.TestResultsInfo{Project: "other"}}
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, writer, file)))
+	})
+	t.Run("ReaderCreates", func(t *testing.T) {
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, reader, create)))
+	})
+	t.Run("ReaderAppends", func(t *testing.T) {
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, reader, appendLines)))
+	})
+	t.Run("UnrecognizedRequest", func(t *testing.T) {
+		admin := &model.User{ID: "admin", SystemRoles: []string{model.RoleAdmin}}
+		assert.Equal(t, codes.PermissionDenied, status.Code(authorizeRequest(ctx, env, admin, &internal.HealthCheckRequest{})))
+	})
+}
+
+func TestNamedAPIKeyUser(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := cedar.GetEnvironment()
+	umConf := cedar.GetUserMiddlewareConfiguration()
+
+	u := &model.User{ID: "user", APIKey: "legacy"}
+	require.NoError(t, env.GetDB().Collection("users").Drop(ctx))
+	_, err := env.GetDB().Collection("users").InsertOne(ctx, u)
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, env.GetDB().Collection("users").Drop(ctx))
+	}()
+	u.Setup(env)
+	key, err := u.CreateNamedAPIKey("ci", time.Time{})
+	require.NoError(t, err)
+	revokedKey, err := u.CreateNamedAPIKey("revoked", time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, u.RevokeNamedAPIKey("revoked"))
+
+	withCreds := func(user, key string) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs(umConf.HeaderUserName, user, umConf.HeaderKeyName, key))
+	}
+
+	t.Run("NoMetadata", func(t *testing.T) {
+		authenticated, err := namedAPIKeyUser(ctx, env, umConf)
+		assert.NoError(t, err)
+		assert.Nil(t, authenticated)
+	})
+	t.Run("ValidKey", func(t *testing.T) {
+		authenticated, err := namedAPIKeyUser(withCreds("user", key), env, umConf)
+		require.NoError(t, err)
+		require.NotNil(t, authenticated)
+		assert.Equal(t, "user", authenticated.Username())
+	})
+	t.Run("RevokedKey", func(t *testing.T) {
+		authenticated, err := namedAPIKeyUser(withCreds("user", revokedKey), env, umConf)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Nil(t, authenticated)
+	})
+	t.Run("LegacyKey", func(t *testing.T) {
+		authenticated, err := namedAPIKeyUser(withCreds("user", "legacy"), env, umConf)
+		assert.NoError(t, err)
+		assert.Nil(t, authenticated)
+	})
+	t.Run("NonexistentUser", func(t *testing.T) {
+		authenticated, err := namedAPIKeyUser(withCreds("DNE", key), env, umConf)
+		assert.NoError(t, err)
+		assert.Nil(t, authenticated)
+	})
+}
+
+func TestBearerToken(t *testing.T) {
+	t.Run("NoMetadata", func(t *testing.T) {
+		_, ok := bearerToken(context.Background())
+		assert.False(t, ok)
+	})
+	t.Run("NoAuthorization", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("api-user", "user"))
+		_, ok := bearerToken(ctx)
+		assert.False(t, ok)
+	})
+	t.Run("BasicAuthorization", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic dXNlcjpwYXNz"))
+		_, ok := bearerToken(ctx)
+		assert.False(t, ok)
+	})
+	t.Run("BearerAuthorization", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+		token, ok := bearerToken(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "token", token)
+	})
+}
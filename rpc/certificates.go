@@ -0,0 +1,230 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"sync"
+	"time"
+
+	"github.com/evergreen-ci/cedar/metrics"
+	"github.com/evergreen-ci/certdepot"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultCertificateReloadInterval = time.Minute
+	defaultRevocationCacheTTL        = 30 * time.Second
+	maxRevocationCacheEntries        = 10000
+)
+
+// serverCertificate serves the server's certificate from the depot,
+// periodically reloading it so that renewed certificates are picked up
+// without restarting the server.
+type serverCertificate struct {
+	name           string
+	reloadInterval time.Duration
+	load           func() (*tls.Certificate, error)
+
+	mu         sync.Mutex
+	cert       *tls.Certificate
+	lastReload time.Time
+	reloading  bool
+}
+
+func newServerCertificate(d certdepot.Depot, name string, reloadInterval time.Duration) (*serverCertificate, error) {
+	if reloadInterval <= 0 {
+		reloadInterval = defaultCertificateReloadInterval
+	}
+	c := &serverCertificate{
+		name:           name,
+		reloadInterval: reloadInterval,
+		load:           func() (*tls.Certificate, error) { return loadServerCertificate(d, name) },
+	}
+	if err := c.reload(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return c, nil
+}
+
+// getCertificate implements tls.Config.GetCertificate. When the certificate
+// is due to be reloaded, it is reloaded in the background so that handshakes
+// never wait on the depot, and the current certificate is served until the
+// reload finishes. If the certificate cannot be reloaded, the current
+// certificate continues to be served.
+func (c *serverCertificate) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.reloading && time.Since(c.lastReload) > c.reloadInterval {
+		c.reloading = true
+		go func() {
+			grip.Warning(message.WrapError(c.reload(), message.Fields{
+				"message": "could not reload server certificate, using the current certificate",
+				"name":    c.name,
+			}))
+		}()
+	}
+
+	return c.cert, nil
+}
+
+// reload loads the certificate from the depot and replaces the current
+// certificate if it changed. The lock is only held to swap the certificate.
+func (c *serverCertificate) reload() error {
+	certificate, err := c.load()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lastReload = time.Now()
+	c.reloading = false
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if c.cert == nil || !certificatesEqual(c.cert, certificate) {
+		grip.InfoWhen(c.cert != nil, message.Fields{
+			"message": "loaded renewed server certificate",
+			"name":    c.name,
+		})
+		c.cert = certificate
+	}
+
+	return nil
+}
+
+func loadServerCertificate(d certdepot.Depot, name string) (*tls.Certificate, error) {
+	cert, err := certdepot.GetCertificate(d, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting server certificate")
+	}
+	certPayload, err := cert.Export()
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting server certificate")
+	}
+	key, err := certdepot.GetPrivateKey(d, name)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting server certificate key")
+	}
+	keyPayload, err := key.ExportPrivate()
+	if err != nil {
+		return nil, errors.Wrap(err, "exporting server certificate key")
+	}
+	certificate, err := tls.X509KeyPair(certPayload, keyPayload)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading server key pair")
+	}
+
+	return &certificate, nil
+}
+
+func certificatesEqual(a, b *tls.Certificate) bool {
+	if len(a.Certificate) != len(b.Certificate) {
+		return false
+	}
+	for i := range a.Certificate {
+		if string(a.Certificate[i]) != string(b.Certificate[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// revocationCache caches the results of revocation checks so that every
+// handshake does not query the database. Revoked certificates stay revoked,
+// so only unrevoked results expire, after the TTL. Failed checks are not
+// cached.
+type revocationCache struct {
+	isRevoked func(*x509.Certificate) (bool, error)
+	ttl       time.Duration
+
+	mu      sync.Mutex
+	entries map[string]revocationCacheEntry
+}
+
+type revocationCacheEntry struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+func newRevocationCache(isRevoked func(*x509.Certificate) (bool, error), ttl time.Duration) *revocationCache {
+	if ttl <= 0 {
+		ttl = defaultRevocationCacheTTL
+	}
+
+	return &revocationCache{
+		isRevoked: isRevoked,
+		ttl:       ttl,
+		entries:   map[string]revocationCacheEntry{},
+	}
+}
+
+func (c *revocationCache) fresh(entry revocationCacheEntry) bool {
+	return entry.revoked || time.Since(entry.checkedAt) < c.ttl
+}
+
+// check returns whether the certificate has been revoked, using the cached
+// result if it has not expired. The lock is not held while checking the
+// database.
+func (c *revocationCache) check(crt *x509.Certificate) (bool, error) {
+	serial := crt.SerialNumber.Text(16)
+
+	c.mu.Lock()
+	entry, ok := c.entries[serial]
+	c.mu.Unlock()
+	hit := ok && c.fresh(entry)
+	metrics.ObserveCacheRequest(metrics.CacheRevocation, hit)
+	if hit {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.isRevoked(crt)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= maxRevocationCacheEntries {
+		for key, entry := range c.entries {
+			if !c.fresh(entry) {
+				delete(c.entries, key)
+			}
+		}
+	}
+	c.entries[serial] = revocationCacheEntry{revoked: revoked, checkedAt: time.Now()}
+
+	return revoked, nil
+}
+
+// makeRevocationCheck returns a function that implements
+// tls.Config.VerifyConnection, rejecting client certificates that have been
+// revoked. Unlike VerifyPeerCertificate, VerifyConnection also runs on resumed
+// sessions. It runs after the standard verification of the chain, so the
+// verified chains are checked rather than the raw certificates.
+func makeRevocationCheck(isRevoked func(*x509.Certificate) (bool, error)) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		for _, chain := range cs.VerifiedChains {
+			if len(chain) == 0 {
+				continue
+			}
+			leaf := chain[0]
+			revoked, err := isRevoked(leaf)
+			if err != nil {
+				grip.Error(message.WrapError(err, message.Fields{
+					"message": "could not check certificate revocation, rejecting certificate",
+					"subject": leaf.Subject.CommonName,
+				}))
+				return errors.Wrap(err, "checking certificate revocation")
+			}
+			if revoked {
+				return errors.Errorf("certificate '%s' for '%s' has been revoked", leaf.SerialNumber.Text(16), leaf.Subject.CommonName)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationCheck(t *testing.T) {
+	revokedCert := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "revoked"}}
+	validCert := &x509.Certificate{SerialNumber: big.NewInt(2), Subject: pkix.Name{CommonName: "valid"}}
+	ca := &x509.Certificate{SerialNumber: big.NewInt(3), Subject: pkix.Name{CommonName: "ca"}}
+
+	check := makeRevocationCheck(func(crt *x509.Certificate) (bool, error) {
+		return crt.SerialNumber.Cmp(revokedCert.SerialNumber) == 0, nil
+	})
+	t.Run("ValidCertificate", func(t *testing.T) {
+		assert.NoError(t, check(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{validCert, ca}}}))
+	})
+	t.Run("RevokedCertificate", func(t *testing.T) {
+		assert.Error(t, check(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{revokedCert, ca}}}))
+	})
+	t.Run("ResumedSession", func(t *testing.T) {
+		assert.Error(t, check(tls.ConnectionState{DidResume: true, VerifiedChains: [][]*x509.Certificate{{revokedCert, ca}}}))
+	})
+	t.Run("NoChains", func(t *testing.T) {
+		assert.NoError(t, check(tls.ConnectionState{}))
+	})
+	t.Run("CheckFails", func(t *testing.T) {
+		failing := makeRevocationCheck(func(*x509.Certificate) (bool, error) {
+			return false, errors.New("database unavailable")
+		})
+		assert.Error(t, failing(tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{validCert, ca}}}))
+	})
+}
+
+func TestRevocationCache(t *testing.T) {
+	revokedCert := &x509.Certificate{SerialNumber: big.NewInt(1)}
+	validCert := &x509.Certificate{SerialNumber: big.NewInt(2)}
+
+	var calls int
+	var fail bool
+	cache := newRevocationCache(func(crt *x509.Certificate) (bool, error) {
+		calls++
+		if fail {
+			return false, errors.New("database unavailable")
+		}
+		return crt.SerialNumber.Cmp(revokedCert.SerialNumber) == 0, nil
+	}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		revoked, err := cache.check(validCert)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+		revoked, err = cache.check(revokedCert)
+		require.NoError(t, err)
+		assert.True(t, revoked)
+	}
+	assert.Equal(t, 2, calls)
+
+	// Expired results are checked again, except for revoked certificates.
+	for serial, entry := range cache.entries {
+		entry.checkedAt = time.Now().Add(-time.Hour)
+		cache.entries[serial] = entry
+	}
+	fail = true
+	_, err := cache.check(validCert)
+	assert.Error(t, err)
+	revoked, err := cache.check(revokedCert)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 3, calls)
+
+	// Failed checks are not cached.
+	fail = false
+	revoked, err = cache.check(validCert)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 4, calls)
+}
+
+func TestServerCertificate(t *testing.T) {
+	current := &tls.Certificate{Certificate: [][]byte{[]byte("leaf")}}
+	renewed := &tls.Certificate{Certificate: [][]byte{[]byte("renewed")}}
+	loading := make(chan struct{})
+	loaded := make(chan struct{})
+	c := &serverCertificate{
+		name:           "server",
+		reloadInterval: time.Minute,
+		load: func() (*tls.Certificate, error) {
+			<-loading
+			return renewed, nil
+		},
+		cert:       current,
+		lastReload: time.Now().Add(-time.Hour),
+	}
+
+	// The current certificate is served without waiting for the reload.
+	for i := 0; i < 3; i++ {
+		cert, err := c.getCertificate(nil)
+		require.NoError(t, err)
+		assert.Equal(t, current, cert)
+	}
+
+	go func() {
+		close(loading)
+		for {
+			c.mu.Lock()
+			done := !c.reloading
+			c.mu.Unlock()
+			if done {
+				close(loaded)
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	select {
+	case <-loaded:
+	case <-time.After(time.Second):
+		require.FailNow(t, "reload did not finish")
+	}
+
+	cert, err := c.getCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, renewed, cert)
+}
+
+func TestCertificatesEqual(t *testing.T) {
+	a := &tls.Certificate{Certificate: [][]byte{[]byte("leaf"), []byte("ca")}}
+	b := &tls.Certificate{Certificate: [][]byte{[]byte("leaf"), []byte("ca")}}
+	c := &tls.Certificate{Certificate: [][]byte{[]byte("renewed"), []byte("ca")}}
+	d := &tls.Certificate{Certificate: [][]byte{[]byte("leaf")}}
+
+	assert.True(t, certificatesEqual(a, b))
+	assert.False(t, certificatesEqual(a, c))
+	assert.False(t, certificatesEqual(a, d))
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/units"
 	"github.com/mongodb/anser/db"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -36,9 +37,23 @@ func BuildloggerServiceName() string {
 
 // CreateLog creates a new buildlogger log record.
 func (s *buildloggerService) CreateLog(ctx context.Context, data *LogData) (*BuildloggerResponse, error) {
-	log := model.CreateLog(data.Info.Export(), data.Storage.Export())
+	// The configuration is served from the environment cache, which is
+	// kept up to date by a change stream, so this does not query the
+	// database on each call.
+	conf := model.NewCedarConfig(s.env)
+	if err := conf.Find(); err != nil {
+		return nil, newRPCError(codes.Internal, errors.Wrap(err, "fetching Cedar config"))
+	}
+
+	log := model.CreateLog(data.Info.Export(), conf.Bucket.LogStorageType(data.Storage.Export()))
+	log.Artifact.EncryptionKeyID = conf.Encryption.ProjectKeyID(log.Info.Project)
 	log.Setup(s.env)
-	return &BuildloggerResponse{LogId: log.ID}, newRPCError(codes.Internal, errors.Wrap(log.SaveNew(ctx), "saving log record"))
+	if err := log.SaveNew(ctx); err != nil {
+		return &BuildloggerResponse{LogId: log.ID}, newRPCError(codes.Internal, errors.Wrap(err, "saving log record"))
+	}
+	units.EnqueueEvergreenEnrichment(ctx, s.env, model.EvergreenMetadataRecordLog, log.ID, log.Info.TaskID)
+
+	return &BuildloggerResponse{LogId: log.ID}, nil
 }
 
 // AppendLogLines adds log lines to an existing buildlogger log.
@@ -108,6 +123,11 @@ func (s *buildloggerService) CloseLog(ctx context.Context, info *LogEndInfo) (*B
 		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "finding log record '%s'", info.LogId))
 	}
 
-	return &BuildloggerResponse{LogId: log.ID},
-		newRPCError(codes.Internal, errors.Wrapf(log.Close(ctx, int(info.ExitCode)), "closing log '%s'", log.ID))
+	if err := log.Close(ctx, int(info.ExitCode)); err != nil {
+		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "closing log '%s'", log.ID))
+	}
+	log.Info.ExitCode = int(info.ExitCode)
+	units.EnqueueLogWebhookEvents(ctx, s.env, log)
+
+	return &BuildloggerResponse{LogId: log.ID}, nil
 }
@@ -29,6 +29,8 @@ func TestCreateLog(t *testing.T) {
 	defer func() {
 		assert.NoError(t, teardownBuildloggerEnv(ctx, env))
 	}()
+	conf := model.NewCedarConfig(env)
+	require.NoError(t, conf.Save())
 
 	for _, test := range []struct {
 		name   string
@@ -82,6 +84,38 @@ func TestCreateLog(t *testing.T) {
 			}
 		})
 	}
+	t.Run("TieredBuildLogs", func(t *testing.T) {
+		conf.Bucket.Tiered.BuildLogs = true
+		require.NoError(t, conf.Save())
+		defer func() {
+			conf.Bucket.Tiered.BuildLogs = false
+			assert.NoError(t, conf.Save())
+		}()
+
+		port := getPort()
+		require.NoError(t, startBuildloggerService(ctx, env, port))
+		client, err := getBuildloggerGRPCClient(ctx, fmt.Sprintf("localhost:%d", port), []grpc.DialOption{grpc.WithInsecure()})
+		require.NoError(t, err)
+
+		for _, test := range []struct {
+			storage  LogStorage
+			expected model.PailType
+		}{
+			{storage: LogStorage_LOG_STORAGE_S3, expected: model.PailTiered},
+			{storage: LogStorage_LOG_STORAGE_GRIDFS, expected: model.PailGridFS},
+		} {
+			resp, err := client.CreateLog(ctx, &LogData{
+				Info:    &LogInfo{Project: "tiered", TestName: string(test.expected)},
+				Storage: test.storage,
+			})
+			require.NoError(t, err)
+
+			log := &model.Log{ID: resp.LogId}
+			log.Setup(env)
+			require.NoError(t, log.Find(ctx))
+			assert.Equal(t, test.expected, log.Artifact.Type)
+		}
+	})
 }
 
 func TestAppendLogLines(t *testing.T) {
@@ -56,10 +56,11 @@ func (srv *perfService) CreateMetricSeries(ctx context.Context, result *ResultDa
 	if err := record.SaveNew(ctx); err != nil {
 		return resp, newRPCError(codes.Internal, errors.Wrap(err, "saving record"))
 	}
+	units.EnqueueEvergreenEnrichment(ctx, srv.env, model.EvergreenMetadataRecordPerf, record.ID, record.Info.TaskID)
 
 	if record.Info.Mainline && len(record.Rollups.Stats) > 0 {
 		processingJob := units.NewUpdateTimeSeriesJob(record.CreateUnanalyzedSeries())
-		err := amboy.EnqueueUniqueJob(ctx, srv.env.GetRemoteQueue(), processingJob)
+		err := amboy.EnqueueUniqueJob(ctx, srv.env.GetRemoteQueue(), units.WithTraceContext(ctx, processingJob))
 
 		if err != nil {
 			return nil, newRPCError(codes.Internal, errors.Wrapf(err, "creating signal processing job for perf result '%s'", record.ID))
@@ -131,7 +132,7 @@ func (srv *perfService) AttachRollups(ctx context.Context, rollupData *RollupDat
 
 	if record.Info.Mainline {
 		processingJob := units.NewUpdateTimeSeriesJob(record.CreateUnanalyzedSeries())
-		err := amboy.EnqueueUniqueJob(ctx, srv.env.GetRemoteQueue(), processingJob)
+		err := amboy.EnqueueUniqueJob(ctx, srv.env.GetRemoteQueue(), units.WithTraceContext(ctx, processingJob))
 
 		if err != nil {
 			return nil, newRPCError(codes.Internal, errors.Wrapf(err, "creating signal processing job for perf result '%s'", record.ID))
@@ -260,7 +261,7 @@ func (srv *perfService) addFTDCRollupsJob(ctx context.Context, id string, artifa
 			return newRPCError(codes.InvalidArgument, errors.WithStack(err))
 		}
 
-		if err = q.Put(ctx, job); err != nil {
+		if err = q.Put(ctx, units.WithTraceContext(ctx, job)); err != nil {
 			return newRPCError(codes.Internal, errors.Wrap(err, "putting FTDC rollups job in the remote queue"))
 		}
 	}
@@ -47,6 +47,7 @@ func (s *systemMetricsService) CreateSystemMetricsRecord(ctx context.Context, da
 	options := data.Artifact.Export()
 	options.Type = conf.Bucket.SystemMetricsBucketType
 	sm := model.CreateSystemMetrics(data.Info.Export(), options)
+	sm.Artifact.EncryptionKeyID = conf.Encryption.ProjectKeyID(sm.Info.Project)
 
 	sm.Setup(s.env)
 	return &SystemMetricsResponse{Id: sm.ID}, newRPCError(codes.Internal, errors.Wrap(sm.SaveNew(ctx), "saving system metrics record"))
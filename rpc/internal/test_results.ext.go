@@ -76,5 +76,22 @@ func (t *TestResult) Export() model.TestResult {
 		TaskCreateTime:  t.TaskCreateTime.AsTime(),
 		TestStartTime:   t.TestStartTime.AsTime(),
 		TestEndTime:     t.TestEndTime.AsTime(),
+		FailureMessage:  t.FailureMessage,
+		StackTrace:      t.StackTrace,
+		Tags:            t.Tags,
+		Attributes:      t.Attributes,
+	}
+}
+
+// Export exports TestResultsFileFormat to the corresponding TestResultsFormat
+// type in the model package.
+func (f TestResultsFileFormat) Export() model.TestResultsFormat {
+	switch f {
+	case TestResultsFileFormat_GO_TEST_JSON:
+		return model.TestResultsFormatGoTest
+	case TestResultsFileFormat_TAP:
+		return model.TestResultsFormatTAP
+	default:
+		return model.TestResultsFormatJUnit
 	}
 }
@@ -110,6 +110,10 @@ func TestTestResultExport(t *testing.T) {
 		TaskCreateTime: &timestamppb.Timestamp{Seconds: 1588278536},
 		TestStartTime:  &timestamppb.Timestamp{Seconds: 1588278500},
 		TestEndTime:    &timestamppb.Timestamp{Seconds: 1588278490},
+		FailureMessage: "failure_message",
+		StackTrace:     "stack_trace",
+		Tags:           []string{"tag"},
+		Attributes:     map[string]string{"key": "value"},
 	}
 
 	modelResult := result.Export()
@@ -125,4 +129,8 @@ func TestTestResultExport(t *testing.T) {
 	assert.Equal(t, result.TaskCreateTime.AsTime(), modelResult.TaskCreateTime)
 	assert.Equal(t, result.TestStartTime.AsTime(), modelResult.TestStartTime)
 	assert.Equal(t, result.TestEndTime.AsTime(), modelResult.TestEndTime)
+	assert.Equal(t, result.FailureMessage, modelResult.FailureMessage)
+	assert.Equal(t, result.StackTrace, modelResult.StackTrace)
+	assert.Equal(t, result.Tags, modelResult.Tags)
+	assert.Equal(t, result.Attributes, modelResult.Attributes)
 }
@@ -21,6 +21,55 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type TestResultsFileFormat int32
+
+const (
+	TestResultsFileFormat_JUNIT_XML    TestResultsFileFormat = 0
+	TestResultsFileFormat_GO_TEST_JSON TestResultsFileFormat = 1
+	TestResultsFileFormat_TAP          TestResultsFileFormat = 2
+)
+
+// Enum value maps for TestResultsFileFormat.
+var (
+	TestResultsFileFormat_name = map[int32]string{
+		0: "JUNIT_XML",
+		1: "GO_TEST_JSON",
+		2: "TAP",
+	}
+	TestResultsFileFormat_value = map[string]int32{
+		"JUNIT_XML":    0,
+		"GO_TEST_JSON": 1,
+		"TAP":          2,
+	}
+)
+
+func (x TestResultsFileFormat) Enum() *TestResultsFileFormat {
+	p := new(TestResultsFileFormat)
+	*p = x
+	return p
+}
+
+func (x TestResultsFileFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TestResultsFileFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_test_results_proto_enumTypes[0].Descriptor()
+}
+
+func (TestResultsFileFormat) Type() protoreflect.EnumType {
+	return &file_test_results_proto_enumTypes[0]
+}
+
+func (x TestResultsFileFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TestResultsFileFormat.Descriptor instead.
+func (TestResultsFileFormat) EnumDescriptor() ([]byte, []int) {
+	return file_test_results_proto_rawDescGZIP(), []int{0}
+}
+
 type TestResultsInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -228,6 +277,10 @@ type TestResult struct {
 	TestEndTime     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=test_end_time,json=testEndTime,proto3" json:"test_end_time,omitempty"`
 	LogUrl          string                 `protobuf:"bytes,11,opt,name=log_url,json=logUrl,proto3" json:"log_url,omitempty"`
 	RawLogUrl       string                 `protobuf:"bytes,12,opt,name=raw_log_url,json=rawLogUrl,proto3" json:"raw_log_url,omitempty"`
+	FailureMessage  string                 `protobuf:"bytes,13,opt,name=failure_message,json=failureMessage,proto3" json:"failure_message,omitempty"`
+	StackTrace      string                 `protobuf:"bytes,14,opt,name=stack_trace,json=stackTrace,proto3" json:"stack_trace,omitempty"`
+	Tags            []string               `protobuf:"bytes,15,rep,name=tags,proto3" json:"tags,omitempty"`
+	Attributes      map[string]string      `protobuf:"bytes,16,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (x *TestResult) Reset() {
@@ -346,6 +399,34 @@ func (x *TestResult) GetRawLogUrl() string {
 	return ""
 }
 
+func (x *TestResult) GetFailureMessage() string {
+	if x != nil {
+		return x.FailureMessage
+	}
+	return ""
+}
+
+func (x *TestResult) GetStackTrace() string {
+	if x != nil {
+		return x.StackTrace
+	}
+	return ""
+}
+
+func (x *TestResult) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *TestResult) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
 type TestResultsEndInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -440,6 +521,69 @@ func (x *TestResultsResponse) GetTestResultsRecordId() string {
 	return ""
 }
 
+type TestResultsFile struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Info   *TestResultsInfo      `protobuf:"bytes,1,opt,name=info,proto3" json:"info,omitempty"`
+	Format TestResultsFileFormat `protobuf:"varint,2,opt,name=format,proto3,enum=cedar.TestResultsFileFormat" json:"format,omitempty"`
+	Data   []byte                `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *TestResultsFile) Reset() {
+	*x = TestResultsFile{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_test_results_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TestResultsFile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TestResultsFile) ProtoMessage() {}
+
+func (x *TestResultsFile) ProtoReflect() protoreflect.Message {
+	mi := &file_test_results_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TestResultsFile.ProtoReflect.Descriptor instead.
+func (*TestResultsFile) Descriptor() ([]byte, []int) {
+	return file_test_results_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TestResultsFile) GetInfo() *TestResultsInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *TestResultsFile) GetFormat() TestResultsFileFormat {
+	if x != nil {
+		return x.Format
+	}
+	return TestResultsFileFormat_JUNIT_XML
+}
+
+func (x *TestResultsFile) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
 var File_test_results_proto protoreflect.FileDescriptor
 
 var file_test_results_proto_rawDesc = []byte{
@@ -481,7 +625,7 @@ var file_test_results_proto_rawDesc = []byte{
 	0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x49, 0x64, 0x12, 0x2b, 0x0a, 0x07, 0x72, 0x65, 0x73,
 	0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x63, 0x65, 0x64,
 	0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x52, 0x07, 0x72,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xe0, 0x03, 0x0a, 0x0a, 0x54, 0x65, 0x73, 0x74, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x22, 0xc0, 0x05, 0x0a, 0x0a, 0x54, 0x65, 0x73, 0x74, 0x52,
 	0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x6e, 0x61,
 	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x74, 0x65, 0x73, 0x74, 0x4e, 0x61,
 	0x6d, 0x65, 0x12, 0x2a, 0x0a, 0x11, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x5f, 0x74, 0x65,
@@ -511,7 +655,21 @@ var file_test_results_proto_rawDesc = []byte{
 	0x12, 0x17, 0x0a, 0x07, 0x6c, 0x6f, 0x67, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x0b, 0x20, 0x01, 0x28,
 	0x09, 0x52, 0x06, 0x6c, 0x6f, 0x67, 0x55, 0x72, 0x6c, 0x12, 0x1e, 0x0a, 0x0b, 0x72, 0x61, 0x77,
 	0x5f, 0x6c, 0x6f, 0x67, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x72, 0x61, 0x77, 0x4c, 0x6f, 0x67, 0x55, 0x72, 0x6c, 0x22, 0x49, 0x0a, 0x12, 0x54, 0x65, 0x73,
+	0x72, 0x61, 0x77, 0x4c, 0x6f, 0x67, 0x55, 0x72, 0x6c, 0x12, 0x27, 0x0a, 0x0f, 0x66, 0x61, 0x69,
+	0x6c, 0x75, 0x72, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0e, 0x66, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x5f, 0x74, 0x72, 0x61, 0x63,
+	0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x54, 0x72,
+	0x61, 0x63, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x61, 0x67, 0x73, 0x18, 0x0f, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x04, 0x74, 0x61, 0x67, 0x73, 0x12, 0x41, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x10, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x63, 0x65,
+	0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x2e, 0x41,
+	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a,
+	0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x1a, 0x3d, 0x0a, 0x0f, 0x41, 0x74,
+	0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a,
+	0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x49, 0x0a, 0x12, 0x54, 0x65, 0x73,
 	0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x45, 0x6e, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x12,
 	0x33, 0x0a, 0x16, 0x74, 0x65, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x5f,
 	0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
@@ -521,28 +679,45 @@ var file_test_results_proto_rawDesc = []byte{
 	0x65, 0x73, 0x74, 0x5f, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x5f, 0x72, 0x65, 0x63, 0x6f,
 	0x72, 0x64, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x74, 0x65, 0x73,
 	0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x49, 0x64,
-	0x32, 0xbb, 0x02, 0x0a, 0x10, 0x43, 0x65, 0x64, 0x61, 0x72, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65,
-	0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x4d, 0x0a, 0x17, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54,
-	0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64,
-	0x12, 0x16, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73,
-	0x75, 0x6c, 0x74, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x1a, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72,
-	0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
-	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x40, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x54, 0x65, 0x73, 0x74, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x12, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54,
-	0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x1a, 0x1a, 0x2e, 0x63, 0x65, 0x64,
-	0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65,
-	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d,
-	0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x12, 0x2e, 0x63, 0x65,
-	0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x1a,
+	0x22, 0x87, 0x01, 0x0a, 0x0f, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x46, 0x69, 0x6c, 0x65, 0x12, 0x2a, 0x0a, 0x04, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x16, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52,
+	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x04, 0x69, 0x6e, 0x66, 0x6f,
+	0x12, 0x34, 0x0a, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x1c, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73,
+	0x75, 0x6c, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x52, 0x06,
+	0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x2a, 0x41, 0x0a, 0x15, 0x54, 0x65,
+	0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x65, 0x46, 0x6f, 0x72,
+	0x6d, 0x61, 0x74, 0x12, 0x0d, 0x0a, 0x09, 0x4a, 0x55, 0x4e, 0x49, 0x54, 0x5f, 0x58, 0x4d, 0x4c,
+	0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x47, 0x4f, 0x5f, 0x54, 0x45, 0x53, 0x54, 0x5f, 0x4a, 0x53,
+	0x4f, 0x4e, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x54, 0x41, 0x50, 0x10, 0x02, 0x32, 0x88, 0x03,
+	0x0a, 0x10, 0x43, 0x65, 0x64, 0x61, 0x72, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x12, 0x4d, 0x0a, 0x17, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x65, 0x73, 0x74,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x16, 0x2e,
+	0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
+	0x73, 0x49, 0x6e, 0x66, 0x6f, 0x1a, 0x1a, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65,
+	0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x40, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75,
+	0x6c, 0x74, 0x73, 0x12, 0x12, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74,
+	0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x1a, 0x1a, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e,
+	0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x45, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x54, 0x65, 0x73,
+	0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x12, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72,
+	0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x1a, 0x1a, 0x2e, 0x63,
+	0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x4f, 0x0a, 0x16, 0x43, 0x6c,
+	0x6f, 0x73, 0x65, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65,
+	0x63, 0x6f, 0x72, 0x64, 0x12, 0x19, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73,
+	0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x45, 0x6e, 0x64, 0x49, 0x6e, 0x66, 0x6f, 0x1a,
 	0x1a, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75,
-	0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x28, 0x01, 0x12, 0x4f, 0x0a,
-	0x16, 0x43, 0x6c, 0x6f, 0x73, 0x65, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74,
-	0x73, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x19, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e,
-	0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x45, 0x6e, 0x64, 0x49, 0x6e,
-	0x66, 0x6f, 0x1a, 0x1a, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52,
-	0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0e,
-	0x5a, 0x0c, 0x72, 0x70, 0x63, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6c, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4b, 0x0a, 0x15, 0x49,
+	0x6e, 0x67, 0x65, 0x73, 0x74, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x46, 0x69, 0x6c, 0x65, 0x12, 0x16, 0x2e, 0x63, 0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73,
+	0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x46, 0x69, 0x6c, 0x65, 0x1a, 0x1a, 0x2e, 0x63,
+	0x65, 0x64, 0x61, 0x72, 0x2e, 0x54, 0x65, 0x73, 0x74, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73,
+	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x0e, 0x5a, 0x0c, 0x72, 0x70, 0x63, 0x2f,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -557,33 +732,42 @@ func file_test_results_proto_rawDescGZIP() []byte {
 	return file_test_results_proto_rawDescData
 }
 
-var file_test_results_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_test_results_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_test_results_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_test_results_proto_goTypes = []interface{}{
-	(*TestResultsInfo)(nil),       // 0: cedar.TestResultsInfo
-	(*TestResults)(nil),           // 1: cedar.TestResults
-	(*TestResult)(nil),            // 2: cedar.TestResult
-	(*TestResultsEndInfo)(nil),    // 3: cedar.TestResultsEndInfo
-	(*TestResultsResponse)(nil),   // 4: cedar.TestResultsResponse
-	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+	(TestResultsFileFormat)(0),    // 0: cedar.TestResultsFileFormat
+	(*TestResultsInfo)(nil),       // 1: cedar.TestResultsInfo
+	(*TestResults)(nil),           // 2: cedar.TestResults
+	(*TestResult)(nil),            // 3: cedar.TestResult
+	(*TestResultsEndInfo)(nil),    // 4: cedar.TestResultsEndInfo
+	(*TestResultsResponse)(nil),   // 5: cedar.TestResultsResponse
+	(*TestResultsFile)(nil),       // 6: cedar.TestResultsFile
+	nil,                           // 7: cedar.TestResult.AttributesEntry
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
 }
 var file_test_results_proto_depIdxs = []int32{
-	2, // 0: cedar.TestResults.results:type_name -> cedar.TestResult
-	5, // 1: cedar.TestResult.task_create_time:type_name -> google.protobuf.Timestamp
-	5, // 2: cedar.TestResult.test_start_time:type_name -> google.protobuf.Timestamp
-	5, // 3: cedar.TestResult.test_end_time:type_name -> google.protobuf.Timestamp
-	0, // 4: cedar.CedarTestResults.CreateTestResultsRecord:input_type -> cedar.TestResultsInfo
-	1, // 5: cedar.CedarTestResults.AddTestResults:input_type -> cedar.TestResults
-	1, // 6: cedar.CedarTestResults.StreamTestResults:input_type -> cedar.TestResults
-	3, // 7: cedar.CedarTestResults.CloseTestResultsRecord:input_type -> cedar.TestResultsEndInfo
-	4, // 8: cedar.CedarTestResults.CreateTestResultsRecord:output_type -> cedar.TestResultsResponse
-	4, // 9: cedar.CedarTestResults.AddTestResults:output_type -> cedar.TestResultsResponse
-	4, // 10: cedar.CedarTestResults.StreamTestResults:output_type -> cedar.TestResultsResponse
-	4, // 11: cedar.CedarTestResults.CloseTestResultsRecord:output_type -> cedar.TestResultsResponse
-	8, // [8:12] is the sub-list for method output_type
-	4, // [4:8] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	3,  // 0: cedar.TestResults.results:type_name -> cedar.TestResult
+	8,  // 1: cedar.TestResult.task_create_time:type_name -> google.protobuf.Timestamp
+	8,  // 2: cedar.TestResult.test_start_time:type_name -> google.protobuf.Timestamp
+	8,  // 3: cedar.TestResult.test_end_time:type_name -> google.protobuf.Timestamp
+	7,  // 4: cedar.TestResult.attributes:type_name -> cedar.TestResult.AttributesEntry
+	1,  // 5: cedar.TestResultsFile.info:type_name -> cedar.TestResultsInfo
+	0,  // 6: cedar.TestResultsFile.format:type_name -> cedar.TestResultsFileFormat
+	1,  // 7: cedar.CedarTestResults.CreateTestResultsRecord:input_type -> cedar.TestResultsInfo
+	2,  // 8: cedar.CedarTestResults.AddTestResults:input_type -> cedar.TestResults
+	2,  // 9: cedar.CedarTestResults.StreamTestResults:input_type -> cedar.TestResults
+	4,  // 10: cedar.CedarTestResults.CloseTestResultsRecord:input_type -> cedar.TestResultsEndInfo
+	6,  // 11: cedar.CedarTestResults.IngestTestResultsFile:input_type -> cedar.TestResultsFile
+	5,  // 12: cedar.CedarTestResults.CreateTestResultsRecord:output_type -> cedar.TestResultsResponse
+	5,  // 13: cedar.CedarTestResults.AddTestResults:output_type -> cedar.TestResultsResponse
+	5,  // 14: cedar.CedarTestResults.StreamTestResults:output_type -> cedar.TestResultsResponse
+	5,  // 15: cedar.CedarTestResults.CloseTestResultsRecord:output_type -> cedar.TestResultsResponse
+	5,  // 16: cedar.CedarTestResults.IngestTestResultsFile:output_type -> cedar.TestResultsResponse
+	12, // [12:17] is the sub-list for method output_type
+	7,  // [7:12] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
 }
 
 func init() { file_test_results_proto_init() }
@@ -652,19 +836,32 @@ func file_test_results_proto_init() {
 				return nil
 			}
 		}
+		file_test_results_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TestResultsFile); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_test_results_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   5,
+			NumEnums:      1,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_test_results_proto_goTypes,
 		DependencyIndexes: file_test_results_proto_depIdxs,
+		EnumInfos:         file_test_results_proto_enumTypes,
 		MessageInfos:      file_test_results_proto_msgTypes,
 	}.Build()
 	File_test_results_proto = out.File
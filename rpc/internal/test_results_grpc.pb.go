@@ -26,6 +26,7 @@ type CedarTestResultsClient interface {
 	AddTestResults(ctx context.Context, in *TestResults, opts ...grpc.CallOption) (*TestResultsResponse, error)
 	StreamTestResults(ctx context.Context, opts ...grpc.CallOption) (CedarTestResults_StreamTestResultsClient, error)
 	CloseTestResultsRecord(ctx context.Context, in *TestResultsEndInfo, opts ...grpc.CallOption) (*TestResultsResponse, error)
+	IngestTestResultsFile(ctx context.Context, in *TestResultsFile, opts ...grpc.CallOption) (*TestResultsResponse, error)
 }
 
 type cedarTestResultsClient struct {
@@ -97,6 +98,15 @@ func (c *cedarTestResultsClient) CloseTestResultsRecord(ctx context.Context, in
 	return out, nil
 }
 
+func (c *cedarTestResultsClient) IngestTestResultsFile(ctx context.Context, in *TestResultsFile, opts ...grpc.CallOption) (*TestResultsResponse, error) {
+	out := new(TestResultsResponse)
+	err := c.cc.Invoke(ctx, "/cedar.CedarTestResults/IngestTestResultsFile", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CedarTestResultsServer is the server API for CedarTestResults service.
 // All implementations must embed UnimplementedCedarTestResultsServer
 // for forward compatibility
@@ -105,6 +115,7 @@ type CedarTestResultsServer interface {
 	AddTestResults(context.Context, *TestResults) (*TestResultsResponse, error)
 	StreamTestResults(CedarTestResults_StreamTestResultsServer) error
 	CloseTestResultsRecord(context.Context, *TestResultsEndInfo) (*TestResultsResponse, error)
+	IngestTestResultsFile(context.Context, *TestResultsFile) (*TestResultsResponse, error)
 	mustEmbedUnimplementedCedarTestResultsServer()
 }
 
@@ -124,6 +135,9 @@ func (UnimplementedCedarTestResultsServer) StreamTestResults(CedarTestResults_St
 func (UnimplementedCedarTestResultsServer) CloseTestResultsRecord(context.Context, *TestResultsEndInfo) (*TestResultsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CloseTestResultsRecord not implemented")
 }
+func (UnimplementedCedarTestResultsServer) IngestTestResultsFile(context.Context, *TestResultsFile) (*TestResultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IngestTestResultsFile not implemented")
+}
 func (UnimplementedCedarTestResultsServer) mustEmbedUnimplementedCedarTestResultsServer() {}
 
 // UnsafeCedarTestResultsServer may be embedded to opt out of forward compatibility for this service.
@@ -217,6 +231,24 @@ func _CedarTestResults_CloseTestResultsRecord_Handler(srv interface{}, ctx conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CedarTestResults_IngestTestResultsFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TestResultsFile)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CedarTestResultsServer).IngestTestResultsFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cedar.CedarTestResults/IngestTestResultsFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CedarTestResultsServer).IngestTestResultsFile(ctx, req.(*TestResultsFile))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // CedarTestResults_ServiceDesc is the grpc.ServiceDesc for CedarTestResults service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -236,6 +268,10 @@ var CedarTestResults_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CloseTestResultsRecord",
 			Handler:    _CedarTestResults_CloseTestResultsRecord_Handler,
 		},
+		{
+			MethodName: "IngestTestResultsFile",
+			Handler:    _CedarTestResults_IngestTestResultsFile_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -1,15 +1,14 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"io"
 
 	"github.com/evergreen-ci/cedar"
 	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/units"
 	"github.com/mongodb/anser/db"
-	"github.com/mongodb/grip"
-	"github.com/mongodb/grip/message"
-	"github.com/mongodb/grip/recovery"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -52,11 +51,13 @@ func (s *testResultsService) CreateTestResultsRecord(ctx context.Context, info *
 		return nil, newRPCError(codes.InvalidArgument, errors.Wrap(err, "exporting test results info"))
 	}
 
-	record := model.CreateTestResults(exported, conf.Bucket.TestResultsBucketType)
+	record := model.CreateTestResults(exported, conf.Bucket.TestResultsStorageType())
 	record.Setup(s.env)
 	if err := record.SaveNew(ctx); err != nil {
 		return nil, newRPCError(codes.Internal, errors.Wrap(err, "saving test results record"))
 	}
+	units.EnqueueEvergreenEnrichment(ctx, s.env, model.EvergreenMetadataRecordTestResults, record.ID, record.Info.TaskID)
+
 	return &TestResultsResponse{TestResultsRecordId: record.ID}, nil
 }
 
@@ -83,23 +84,7 @@ func (s *testResultsService) AddTestResults(ctx context.Context, results *TestRe
 		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "appending test results for '%s'", results.TestResultsRecordId))
 	}
 
-	if !record.Info.HistoricalDataDisabled {
-		conf := model.NewCedarConfig(s.env)
-		if err := conf.Find(); err != nil {
-			grip.Error(message.WrapError(errors.Wrap(err, "finding Cedar configuration"), message.Fields{
-				"message":           "failed to update historical test data",
-				"test_results_info": record.Info,
-			}))
-			// If we can't find the Cedar configuration, we should
-			// not update the historical test data for these
-			// results.
-			conf.Flags.DisableHistoricalTestData = true
-		}
-
-		if !conf.Flags.DisableHistoricalTestData {
-			go s.updateHistoricalData(record, exportedResults)
-		}
-	}
+	units.StartHistoricalTestDataUpdate(s.env, record, exportedResults)
 
 	return &TestResultsResponse{TestResultsRecordId: record.ID}, nil
 }
@@ -149,54 +134,44 @@ func (s *testResultsService) CloseTestResultsRecord(ctx context.Context, info *T
 		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "finding test results record '%s'", info.TestResultsRecordId))
 	}
 
-	if err := record.Close(ctx); err != nil {
+	closed, err := record.Close(ctx)
+	if err != nil {
 		return nil, newRPCError(codes.Internal, errors.Wrapf(err, "closing test results '%s'", record.ID))
 	}
+	units.EnqueueTestResultsWebhookEvents(ctx, s.env, record)
+	// Only count the task run once if the record is closed again, for
+	// example when the request is retried.
+	if closed {
+		units.StartHistoricalTaskDataUpdate(s.env, record, nil)
+	}
+
 	return &TestResultsResponse{TestResultsRecordId: record.ID}, nil
 }
 
-func (s *testResultsService) updateHistoricalData(record *model.TestResults, results []model.TestResult) {
-	defer func() {
-		if err := recovery.HandlePanicWithError(recover(), nil, "historical test data update"); err != nil {
-			grip.Error(message.WrapError(err, message.Fields{
-				"message":           "failed to update historical test data",
-				"test_results_info": record.Info,
-			}))
-		}
-	}()
-
-	taskName := record.Info.DisplayTaskName
-	if taskName == "" {
-		taskName = record.Info.TaskName
-	}
-	for _, res := range results {
-		info := model.HistoricalTestDataInfo{
-			Project:     record.Info.Project,
-			Variant:     record.Info.Variant,
-			TaskName:    taskName,
-			TestName:    res.GetDisplayName(),
-			RequestType: record.Info.RequestType,
-			Date:        res.TestEndTime,
-		}
-		htd, err := model.CreateHistoricalTestData(info)
-		if err != nil {
-			grip.Error(message.WrapError(errors.Wrap(err, "creating historical test data"), message.Fields{
-				"message":                   "failed to update historical test data",
-				"test_results_info":         record.Info,
-				"historical_test_data_info": info,
-				"test_result":               res,
-			}))
-			continue
-		}
-		htd.Setup(s.env)
+// IngestTestResultsFile creates a test results record from a file of test
+// results produced by a test harness and closes it.
+func (s *testResultsService) IngestTestResultsFile(ctx context.Context, file *TestResultsFile) (*TestResultsResponse, error) {
+	if file.Info == nil {
+		return nil, newRPCError(codes.InvalidArgument, errors.New("test results info is required"))
+	}
+	info, err := file.Info.Export()
+	if err != nil {
+		return nil, newRPCError(codes.InvalidArgument, errors.Wrap(err, "exporting test results info"))
+	}
+	results, err := model.ParseTestResults(file.Format.Export(), bytes.NewReader(file.Data))
+	if err != nil {
+		return nil, newRPCError(codes.InvalidArgument, errors.Wrap(err, "parsing test results file"))
+	}
 
-		ctx, cancel := s.env.Context()
-		defer cancel()
-		grip.Error(message.WrapError(htd.Update(ctx, res), message.Fields{
-			"message":                   "failed to update historical test data",
-			"test_results_info":         record.Info,
-			"historical_test_data_info": info,
-			"test_result":               res,
-		}))
+	record, err := model.IngestTestResults(ctx, s.env, info, results)
+	if err != nil {
+		return nil, newRPCError(codes.Internal, errors.Wrap(err, "ingesting test results file"))
 	}
+	units.EnqueueEvergreenEnrichment(ctx, s.env, model.EvergreenMetadataRecordTestResults, record.ID, record.Info.TaskID)
+	units.EnqueueTestResultsWebhookEvents(ctx, s.env, record)
+
+	units.StartHistoricalTestDataUpdate(s.env, record, results)
+	units.StartHistoricalTaskDataUpdate(s.env, record, results)
+
+	return &TestResultsResponse{TestResultsRecordId: record.ID}, nil
 }
@@ -99,6 +99,22 @@ func TestCreateTestResultsRecord(t *testing.T) {
 		assert.Equal(t, conf.Bucket.TestResultsBucketType, results.Artifact.Type)
 		assert.True(t, time.Since(results.CreatedAt) <= time.Second)
 	})
+	conf.Bucket.Tiered.TestResults = true
+	require.NoError(t, conf.Save())
+	t.Run("TieredTestResults", func(t *testing.T) {
+		info := getTestResultsInfo()
+		modelInfo, err := info.Export()
+		require.NoError(t, err)
+
+		resp, err := client.CreateTestResultsRecord(ctx, info)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+
+		results := &model.TestResults{ID: modelInfo.ID()}
+		results.Setup(env)
+		require.NoError(t, results.Find(ctx))
+		assert.Equal(t, model.PailTiered, results.Artifact.Type)
+	})
 }
 
 func TestAddTestResults(t *testing.T) {
@@ -467,6 +483,67 @@ func TestCloseTestResultsRecord(t *testing.T) {
 	}
 }
 
+func TestIngestTestResultsFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env, err := createTestResultsEnv()
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, teardownTestResultsEnv(ctx, env))
+	}()
+	tmpDir, err := ioutil.TempDir(".", "test-results-test")
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, os.RemoveAll(tmpDir))
+	}()
+
+	conf := model.NewCedarConfig(env)
+	conf.Bucket.TestResultsBucket = tmpDir
+	conf.Bucket.TestResultsBucketType = model.PailLocal
+	require.NoError(t, conf.Save())
+
+	port := getPort()
+	require.NoError(t, startTestResultsService(ctx, env, port))
+	client, err := getTestResultsGRPCClient(ctx, fmt.Sprintf("localhost:%d", port), []grpc.DialOption{grpc.WithInsecure()})
+	require.NoError(t, err)
+
+	t.Run("NoInfo", func(t *testing.T) {
+		resp, err := client.IngestTestResultsFile(ctx, &TestResultsFile{Format: TestResultsFileFormat_TAP, Data: []byte("ok 1 - test")})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+	})
+	t.Run("InvalidFile", func(t *testing.T) {
+		info := getTestResultsInfo()
+		info.HistoricalDataDisabled = true
+		resp, err := client.IngestTestResultsFile(ctx, &TestResultsFile{Info: info, Format: TestResultsFileFormat_JUNIT_XML, Data: []byte("<html></html>")})
+		assert.Error(t, err)
+		assert.Nil(t, resp)
+	})
+	t.Run("ValidFile", func(t *testing.T) {
+		info := getTestResultsInfo()
+		info.HistoricalDataDisabled = true
+		data := "TAP version 13\n1..2\nok 1 - first\nnot ok 2 - second\n"
+		resp, err := client.IngestTestResultsFile(ctx, &TestResultsFile{Info: info, Format: TestResultsFileFormat_TAP, Data: []byte(data)})
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+
+		record := &model.TestResults{ID: resp.TestResultsRecordId}
+		record.Setup(env)
+		require.NoError(t, record.Find(ctx))
+		assert.Equal(t, info.TaskId, record.Info.TaskID)
+		assert.Equal(t, 2, record.Stats.TotalCount)
+		assert.Equal(t, 1, record.Stats.FailedCount)
+		assert.False(t, record.CompletedAt.IsZero())
+
+		results, err := record.Download(ctx)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, "first", results[0].TestName)
+		assert.Equal(t, info.TaskId, results[0].TaskID)
+		assert.Equal(t, int(info.Execution), results[0].Execution)
+	})
+}
+
 func createTestResultsEnv() (cedar.Environment, error) {
 	env, err := cedar.NewEnvironment(context.Background(), testDBName, &cedar.Configuration{
 		MongoDBURI:    "mongodb://localhost:27017",
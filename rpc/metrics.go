@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/evergreen-ci/cedar/metrics"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// makeMetricsUnaryInterceptor returns a unary interceptor that records the
+// latency and status code of each request.
+func makeMetricsUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeRequest(info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// makeMetricsStreamInterceptor returns a stream interceptor that records the
+// latency and status code of each stream.
+func makeMetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		observeRequest(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+func observeRequest(method string, start time.Time, err error) {
+	metrics.GRPCRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	metrics.GRPCRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+}
@@ -25,13 +25,27 @@ import (
 type WaitFunc func(context.Context)
 
 type AuthConfig struct {
-	TLS         bool
+	TLS bool
+	// UserAuth, if set, requires every RPC, other than the health check,
+	// to be made by an authenticated user with the project writer role on
+	// the project of the record being written. Existing users can be
+	// migrated with "cedar admin auth roles grant-all --role
+	// project_writer --project '*'" before enabling it.
 	UserAuth    bool
 	SkipVerify  bool
 	CAName      string
 	ServiceName string
 	Depot       certdepot.Depot
 	UserManager gimlet.UserManager
+	// IsRevoked, if set, is used to reject revoked client certificates.
+	IsRevoked func(*x509.Certificate) (bool, error)
+	// RevocationCacheTTL is how long a certificate that is not revoked is
+	// cached before IsRevoked is called again. Defaults to 30 seconds.
+	RevocationCacheTTL time.Duration
+	// CertificateReloadInterval is how often the server certificate is
+	// reloaded from the depot, so that renewed certificates are used
+	// without a restart. Defaults to a minute.
+	CertificateReloadInterval time.Duration
 }
 
 func (c *AuthConfig) Validate() error {
@@ -45,27 +59,14 @@ func (c *AuthConfig) Validate() error {
 	return catcher.Resolve()
 }
 
+// ResolveTLS returns the TLS configuration of the server. The server
+// certificate is periodically reloaded from the depot and, if IsRevoked is
+// set, revoked client certificates are rejected.
 func (c *AuthConfig) ResolveTLS() (*tls.Config, error) {
 	// Load the certificates
-	cert, err := certdepot.GetCertificate(c.Depot, c.ServiceName)
+	certificate, err := newServerCertificate(c.Depot, c.ServiceName, c.CertificateReloadInterval)
 	if err != nil {
-		return nil, errors.Wrap(err, "getting server certificate")
-	}
-	certPayload, err := cert.Export()
-	if err != nil {
-		return nil, errors.Wrap(err, "exporting server certificate")
-	}
-	key, err := certdepot.GetPrivateKey(c.Depot, c.ServiceName)
-	if err != nil {
-		return nil, errors.Wrap(err, "getting server certificate key")
-	}
-	keyPayload, err := key.ExportPrivate()
-	if err != nil {
-		return nil, errors.Wrap(err, "exporting server certificate key")
-	}
-	certificate, err := tls.X509KeyPair(certPayload, keyPayload)
-	if err != nil {
-		return nil, errors.Wrap(err, "loading server key pair")
+		return nil, errors.WithStack(err)
 	}
 
 	// Create a certificate pool from the certificate authority
@@ -85,10 +86,13 @@ func (c *AuthConfig) ResolveTLS() (*tls.Config, error) {
 	}
 	conf := &tls.Config{
 		ClientAuth:         tls.RequireAndVerifyClientCert,
-		Certificates:       []tls.Certificate{certificate},
+		GetCertificate:     certificate.getCertificate,
 		ClientCAs:          certPool,
 		InsecureSkipVerify: c.SkipVerify,
 	}
+	if c.IsRevoked != nil {
+		conf.VerifyConnection = makeRevocationCheck(newRevocationCache(c.IsRevoked, c.RevocationCacheTTL).check)
+	}
 
 	return conf, nil
 }
@@ -96,9 +100,13 @@ func (c *AuthConfig) ResolveTLS() (*tls.Config, error) {
 func GetServer(env cedar.Environment, conf AuthConfig) (*grpc.Server, error) {
 	logWhen := func() bool { return sometimes.Percent(10) }
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		makeTracingUnaryInterceptor(),
+		makeMetricsUnaryInterceptor(),
 		aviation.MakeConditionalGripUnaryInterceptor(logging.MakeGrip(grip.GetSender()), logWhen),
 	}
 	streamInterceptors := []grpc.StreamServerInterceptor{
+		makeTracingStreamInterceptor(),
+		makeMetricsStreamInterceptor(),
 		aviation.MakeConditionalGripStreamInterceptor(logging.MakeGrip(grip.GetSender()), logWhen),
 	}
 	opts := []grpc.ServerOption{}
@@ -125,8 +133,16 @@ func GetServer(env cedar.Environment, conf AuthConfig) (*grpc.Server, error) {
 		// The health check end point should not be protected.
 		ignore := fmt.Sprintf("/%s/%s", internal.HealthServiceName(), "Check")
 
-		unaryInterceptors = append(unaryInterceptors, aviation.MakeAuthenticationRequiredUnaryInterceptor(conf.UserManager, umConf, ignore))
-		streamInterceptors = append(streamInterceptors, aviation.MakeAuthenticationRequiredStreamInterceptor(conf.UserManager, umConf, ignore))
+		unaryInterceptors = append(
+			unaryInterceptors,
+			makeAuthenticationUnaryInterceptor(env, conf.UserManager, umConf, ignore),
+			makeAuthorizationUnaryInterceptor(env, ignore),
+		)
+		streamInterceptors = append(
+			streamInterceptors,
+			makeAuthenticationStreamInterceptor(env, conf.UserManager, umConf, ignore),
+			makeAuthorizationStreamInterceptor(env, ignore),
+		)
 	}
 
 	opts = append(
@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/cedar/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// makeTracingUnaryInterceptor returns a unary interceptor that records a server
+// span for each request, continuing the caller's trace if the request has
+// traceparent metadata.
+func makeTracingUnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startRequestSpan(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		endRequestSpan(span, err)
+
+		return resp, err
+	}
+}
+
+// makeTracingStreamInterceptor returns a stream interceptor that records a
+// server span for each stream, continuing the caller's trace if the stream has
+// traceparent metadata.
+func makeTracingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startRequestSpan(stream.Context(), info.FullMethod)
+		if span == nil {
+			return handler(srv, stream)
+		}
+
+		err := handler(srv, &tracedServerStream{ServerStream: stream, ctx: ctx})
+		endRequestSpan(span, err)
+
+		return err
+	}
+}
+
+func startRequestSpan(ctx context.Context, method string) (context.Context, *tracing.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(tracing.TraceParentHeader); len(values) > 0 {
+			ctx = tracing.ContextWithTraceParent(ctx, values[0])
+		}
+	}
+
+	ctx, span := tracing.StartSpan(ctx, method, tracing.SpanKindServer)
+	span.SetAttribute("rpc.system", "grpc")
+	span.SetAttribute("rpc.method", method)
+
+	return ctx, span
+}
+
+func endRequestSpan(span *tracing.Span, err error) {
+	span.SetAttribute("rpc.grpc.status_code", status.Code(err).String())
+	span.Finish(err)
+}
+
+// tracedServerStream overrides the context of a server stream with one that
+// has the request's span attached.
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedServerStream) Context() context.Context { return s.ctx }
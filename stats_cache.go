@@ -8,15 +8,31 @@ import (
 	"sync"
 	"time"
 
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
 	"github.com/mongodb/grip/recovery"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const topN = 10
 const statChanBufferSize = 1000
 
-func newStatsCacheRegistry(ctx context.Context) map[string]*statsCache {
+const (
+	// IngestionStatsCollection is the DB collection that the stats cache
+	// aggregates are flushed to.
+	IngestionStatsCollection = "ingestion_stats"
+	// IngestionStatsBucketSize is the size of the time buckets that the
+	// stats cache aggregates are flushed to.
+	IngestionStatsBucketSize = time.Hour
+	// DefaultIngestionStatsTTL is how long the flushed aggregates are kept
+	// unless the retention is configured.
+	DefaultIngestionStatsTTL = 365 * 24 * time.Hour
+)
+
+func newStatsCacheRegistry(ctx context.Context, env Environment) map[string]*statsCache {
 	registry := map[string]*statsCache{
 		StatsCacheBuildlogger: newStatsCache(StatsCacheBuildlogger),
 		StatsCacheTestResults: newStatsCache(StatsCacheTestResults),
@@ -24,39 +40,67 @@ func newStatsCacheRegistry(ctx context.Context) map[string]*statsCache {
 	}
 	for _, r := range registry {
 		go r.consumerLoop(ctx)
-		go r.loggerLoop(ctx)
+		go r.loggerLoop(ctx, env)
 	}
 
 	return registry
 }
 
 // Stat represents a count to add to the cache for a particular project/version/taskID combination.
+// The unit of the count depends on the cache: bytes for buildlogger, results
+// for test results and artifacts for perf. Lines is only set by caches that
+// ingest log lines.
 type Stat struct {
 	Count   int
+	Lines   int
 	Project string
 	Version string
 	TaskID  string
 }
 
+// IngestionStats are the aggregated stats of a single stats cache for a
+// project over one time bucket. Counts from every application server are
+// added to the same document.
+type IngestionStats struct {
+	ID      string    `bson:"_id" json:"-"`
+	Cache   string    `bson:"cache" json:"cache"`
+	Project string    `bson:"project" json:"project"`
+	Bucket  time.Time `bson:"bucket" json:"bucket"`
+	Calls   int       `bson:"calls" json:"calls"`
+	Count   int       `bson:"count" json:"count"`
+	Lines   int       `bson:"lines,omitempty" json:"lines,omitempty"`
+	// ExpiresAt is when the aggregates are removed, which is set when the
+	// bucket is first flushed.
+	ExpiresAt time.Time `bson:"expires_at" json:"-"`
+}
+
+type projectStats struct {
+	calls int
+	count int
+	lines int
+}
+
 type statsCache struct {
 	mu        sync.Mutex
 	cacheName string
 	statChan  chan Stat
 
-	calls     int
-	total     int
-	byProject map[string]int
-	byVersion map[string]int
-	byTaskID  map[string]int
+	calls        int
+	total        int
+	byProject    map[string]int
+	byVersion    map[string]int
+	byTaskID     map[string]int
+	projectStats map[string]projectStats
 }
 
 func newStatsCache(name string) *statsCache {
 	return &statsCache{
-		cacheName: name,
-		statChan:  make(chan Stat, statChanBufferSize),
-		byProject: make(map[string]int),
-		byVersion: make(map[string]int),
-		byTaskID:  make(map[string]int),
+		cacheName:    name,
+		statChan:     make(chan Stat, statChanBufferSize),
+		byProject:    make(map[string]int),
+		byVersion:    make(map[string]int),
+		byTaskID:     make(map[string]int),
+		projectStats: make(map[string]projectStats),
 	}
 }
 
@@ -66,6 +110,7 @@ func (s *statsCache) resetCache() {
 	s.byProject = make(map[string]int)
 	s.byVersion = make(map[string]int)
 	s.byTaskID = make(map[string]int)
+	s.projectStats = make(map[string]projectStats)
 }
 
 func (s *statsCache) cacheStat(newStat Stat) {
@@ -77,9 +122,17 @@ func (s *statsCache) cacheStat(newStat Stat) {
 	s.byProject[newStat.Project] += newStat.Count
 	s.byVersion[newStat.Version] += newStat.Count
 	s.byTaskID[newStat.TaskID] += newStat.Count
+
+	stats := s.projectStats[newStat.Project]
+	stats.calls++
+	stats.count += newStat.Count
+	stats.lines += newStat.Lines
+	s.projectStats[newStat.Project] = stats
 }
 
-func (s *statsCache) logStats() {
+// logStats logs the current aggregates and resets the cache, returning the
+// per-project aggregates so that they may be flushed to the DB.
+func (s *statsCache) logStats() map[string]projectStats {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -92,7 +145,47 @@ func (s *statsCache) logStats() {
 		"by_task_id": topNItems(s.byTaskID, topN),
 	})
 
+	stats := s.projectStats
 	s.resetCache()
+
+	return stats
+}
+
+// flushStats adds the per-project aggregates to the time bucket containing
+// the given time. New buckets expire after the given TTL.
+func (s *statsCache) flushStats(ctx context.Context, db *mongo.Database, stats map[string]projectStats, now time.Time, ttl time.Duration) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	bucket := now.UTC().Truncate(IngestionStatsBucketSize)
+	models := make([]mongo.WriteModel, 0, len(stats))
+	for project, stat := range stats {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": ingestionStatsID(s.cacheName, project, bucket)}).
+			SetUpdate(bson.M{
+				"$setOnInsert": bson.M{
+					"cache":      s.cacheName,
+					"project":    project,
+					"bucket":     bucket,
+					"expires_at": bucket.Add(ttl),
+				},
+				"$inc": bson.M{
+					"calls": stat.calls,
+					"count": stat.count,
+					"lines": stat.lines,
+				},
+			}).
+			SetUpsert(true))
+	}
+
+	_, err := db.Collection(IngestionStatsCollection).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+
+	return err
+}
+
+func ingestionStatsID(cache, project string, bucket time.Time) string {
+	return fmt.Sprintf("%s.%s.%d", cache, project, bucket.Unix())
 }
 
 func (s *statsCache) consumerLoop(ctx context.Context) {
@@ -115,7 +208,7 @@ func (s *statsCache) consumerLoop(ctx context.Context) {
 	}
 }
 
-func (s *statsCache) loggerLoop(ctx context.Context) {
+func (s *statsCache) loggerLoop(ctx context.Context, env Environment) {
 	defer func() {
 		if err := recovery.HandlePanicWithError(recover(), nil, "stats cache logger"); err != nil {
 			grip.Error(message.WrapError(err, message.Fields{
@@ -133,18 +226,31 @@ func (s *statsCache) loggerLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			s.logStats()
+			stats := s.logStats()
+			db := env.GetDB()
+			if db == nil {
+				continue
+			}
+			grip.Error(message.WrapError(s.flushStats(ctx, db, stats, time.Now(), env.GetIngestionStatsTTL()), message.Fields{
+				"message":  "could not flush stats",
+				"cache":    s.cacheName,
+				"projects": len(stats),
+			}))
 		}
 	}
 }
 
 // AddStat adds a stat to the cache's incoming stats channel.
-// Returns an error when the channel is full.
+// Returns an error when the channel is full, in which case the stat is
+// dropped and counted as such rather than as ingested.
 func (s *statsCache) AddStat(newStat Stat) error {
 	select {
 	case s.statChan <- newStat:
+		metrics.IngestionRequests.WithLabelValues(s.cacheName).Inc()
+		metrics.IngestedItems.WithLabelValues(s.cacheName).Add(float64(newStat.Count))
 		return nil
 	default:
+		metrics.DroppedIngestionStats.WithLabelValues(s.cacheName).Inc()
 		return errors.New("stats cache is full")
 	}
 }
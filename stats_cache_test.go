@@ -1,10 +1,15 @@
 package cedar
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/evergreen-ci/cedar/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func TestStatsCache(t *testing.T) {
@@ -15,11 +20,25 @@ func TestStatsCache(t *testing.T) {
 		}
 		assert.Error(t, cache.AddStat(Stat{}))
 	})
+	t.Run("dropped stats are not counted as ingested", func(t *testing.T) {
+		cache := newStatsCache("dropped stats cache")
+		for i := 0; i < statChanBufferSize; i++ {
+			require.NoError(t, cache.AddStat(Stat{Count: 2}))
+		}
+		assert.Error(t, cache.AddStat(Stat{Count: 2}))
+
+		assert.Equal(t, float64(statChanBufferSize), testutil.ToFloat64(metrics.IngestionRequests.WithLabelValues("dropped stats cache")))
+		assert.Equal(t, float64(2*statChanBufferSize), testutil.ToFloat64(metrics.IngestedItems.WithLabelValues("dropped stats cache")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(metrics.DroppedIngestionStats.WithLabelValues("dropped stats cache")))
+	})
 	t.Run("logStats clears the cache", func(t *testing.T) {
 		cache := newStatsCache("new cache")
 		cache.calls++
-		cache.logStats()
+		cache.cacheStat(Stat{Count: 2, Project: "cedar"})
+		stats := cache.logStats()
 		assert.Equal(t, cache.calls, 0)
+		assert.Empty(t, cache.projectStats)
+		assert.Equal(t, map[string]projectStats{"cedar": {calls: 1, count: 2}}, stats)
 	})
 	t.Run("stat is recorded", func(t *testing.T) {
 		cache := newStatsCache("new cache")
@@ -34,6 +53,66 @@ func TestStatsCache(t *testing.T) {
 		assert.Equal(t, cache.byProject["cedar"], 2)
 		assert.Equal(t, cache.byVersion["abcdef"], 2)
 		assert.Equal(t, cache.byTaskID["t1"], 2)
+		assert.Equal(t, projectStats{calls: 1, count: 2}, cache.projectStats["cedar"])
+	})
+	t.Run("lines are recorded per project", func(t *testing.T) {
+		cache := newStatsCache("new cache")
+		cache.cacheStat(Stat{Count: 10, Lines: 2, Project: "cedar"})
+		cache.cacheStat(Stat{Count: 5, Lines: 1, Project: "cedar"})
+		assert.Equal(t, projectStats{calls: 2, count: 15, lines: 3}, cache.projectStats["cedar"])
+	})
+	t.Run("flushStats", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		env, err := NewEnvironment(ctx, "test", &Configuration{MongoDBURI: "mongodb://localhost:27017", NumWorkers: 2, DatabaseName: testDatabaseName})
+		require.NoError(t, err)
+		coll := env.GetDB().Collection(IngestionStatsCollection)
+		defer func() {
+			assert.NoError(t, coll.Drop(ctx))
+		}()
+
+		cache := newStatsCache(StatsCacheBuildlogger)
+		now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+		bucket := time.Date(2021, 1, 2, 3, 0, 0, 0, time.UTC)
+		t.Run("NoStats", func(t *testing.T) {
+			require.NoError(t, cache.flushStats(ctx, env.GetDB(), nil, now, time.Hour))
+			count, err := coll.CountDocuments(ctx, bson.M{})
+			require.NoError(t, err)
+			assert.Zero(t, count)
+		})
+		t.Run("AddsToBucket", func(t *testing.T) {
+			stats := map[string]projectStats{
+				"p1": {calls: 1, count: 100, lines: 10},
+				"p2": {calls: 2, count: 50, lines: 5},
+			}
+			require.NoError(t, cache.flushStats(ctx, env.GetDB(), stats, now, 24*time.Hour))
+			require.NoError(t, cache.flushStats(ctx, env.GetDB(), stats, now.Add(time.Minute), 48*time.Hour))
+			require.NoError(t, cache.flushStats(ctx, env.GetDB(), stats, now.Add(time.Hour), 24*time.Hour))
+
+			saved := IngestionStats{}
+			require.NoError(t, coll.FindOne(ctx, bson.M{"_id": ingestionStatsID(StatsCacheBuildlogger, "p1", bucket)}).Decode(&saved))
+			assert.Equal(t, StatsCacheBuildlogger, saved.Cache)
+			assert.Equal(t, "p1", saved.Project)
+			assert.True(t, bucket.Equal(saved.Bucket))
+			assert.Equal(t, 2, saved.Calls)
+			assert.Equal(t, 200, saved.Count)
+			assert.Equal(t, 20, saved.Lines)
+			assert.True(t, bucket.Add(24*time.Hour).Equal(saved.ExpiresAt), "expiration should be set when the bucket is created")
+
+			count, err := coll.CountDocuments(ctx, bson.M{})
+			require.NoError(t, err)
+			assert.EqualValues(t, 4, count)
+		})
+	})
+	t.Run("IngestionStatsTTL", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		env, err := NewEnvironment(ctx, "test", &Configuration{MongoDBURI: "mongodb://localhost:27017", NumWorkers: 2, DatabaseName: testDatabaseName})
+		require.NoError(t, err)
+
+		assert.Equal(t, DefaultIngestionStatsTTL, env.GetIngestionStatsTTL())
+		env.SetIngestionStatsTTL(time.Hour)
+		assert.Equal(t, time.Hour, env.GetIngestionStatsTTL())
 	})
 	t.Run("topNItems", func(t *testing.T) {
 		t.Run("empty map", func(t *testing.T) {
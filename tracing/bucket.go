@@ -0,0 +1,89 @@
+package tracing
+
+import (
+	"context"
+	"io"
+
+	"github.com/evergreen-ci/pail"
+)
+
+// InstrumentBucket wraps the bucket so that its read and write operations are
+// recorded as client spans.
+func InstrumentBucket(b pail.Bucket, bucketType string) pail.Bucket {
+	if b == nil {
+		return nil
+	}
+	if _, ok := b.(*tracedBucket); ok {
+		return b
+	}
+
+	return &tracedBucket{Bucket: b, bucketType: bucketType}
+}
+
+type tracedBucket struct {
+	pail.Bucket
+	bucketType string
+}
+
+func (b *tracedBucket) start(ctx context.Context, operation, key string) (context.Context, *Span) {
+	ctx, span := StartSpan(ctx, "pail."+operation, SpanKindClient)
+	span.SetAttribute("pail.bucket_type", b.bucketType)
+	span.SetAttribute("pail.key", key)
+	return ctx, span
+}
+
+func (b *tracedBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	ctx, span := b.start(ctx, "get", key)
+	r, err := b.Bucket.Get(ctx, key)
+	span.Finish(err)
+	return r, err
+}
+
+func (b *tracedBucket) Put(ctx context.Context, key string, r io.Reader) error {
+	ctx, span := b.start(ctx, "put", key)
+	err := b.Bucket.Put(ctx, key, r)
+	span.Finish(err)
+	return err
+}
+
+func (b *tracedBucket) Reader(ctx context.Context, key string) (io.ReadCloser, error) {
+	ctx, span := b.start(ctx, "reader", key)
+	r, err := b.Bucket.Reader(ctx, key)
+	span.Finish(err)
+	return r, err
+}
+
+func (b *tracedBucket) Writer(ctx context.Context, key string) (io.WriteCloser, error) {
+	ctx, span := b.start(ctx, "writer", key)
+	w, err := b.Bucket.Writer(ctx, key)
+	span.Finish(err)
+	return w, err
+}
+
+func (b *tracedBucket) Upload(ctx context.Context, key, path string) error {
+	ctx, span := b.start(ctx, "upload", key)
+	err := b.Bucket.Upload(ctx, key, path)
+	span.Finish(err)
+	return err
+}
+
+func (b *tracedBucket) Download(ctx context.Context, key, path string) error {
+	ctx, span := b.start(ctx, "download", key)
+	err := b.Bucket.Download(ctx, key, path)
+	span.Finish(err)
+	return err
+}
+
+func (b *tracedBucket) Remove(ctx context.Context, key string) error {
+	ctx, span := b.start(ctx, "remove", key)
+	err := b.Bucket.Remove(ctx, key)
+	span.Finish(err)
+	return err
+}
+
+func (b *tracedBucket) List(ctx context.Context, prefix string) (pail.BucketIterator, error) {
+	ctx, span := b.start(ctx, "list", prefix)
+	it, err := b.Bucket.List(ctx, prefix)
+	span.Finish(err)
+	return it, err
+}
@@ -0,0 +1,10 @@
+/*
+Package tracing provides distributed tracing for Cedar using the OpenTelemetry
+SDK. Trace context is propagated using the W3C traceparent format and finished
+spans are exported either to an OpenTelemetry collector using OTLP over HTTP or
+as JSON lines to a file or standard output for local testing.
+
+Tracing is disabled until Configure is called, in which case starting a span
+is a no-op and returns a nil span, all of whose methods are safe to call.
+*/
+package tracing
@@ -0,0 +1,194 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// OTLP
+
+const otlpTracesPath = "/v1/traces"
+
+// OTLPExporterOptions configure an OTLP exporter.
+type OTLPExporterOptions struct {
+	// Endpoint is the base URL of the OpenTelemetry collector's OTLP/HTTP
+	// receiver, e.g. "http://localhost:4318".
+	Endpoint string
+	// Headers are added to each export request, e.g. for authentication.
+	Headers map[string]string
+}
+
+// Validate checks that the options are valid.
+func (opts *OTLPExporterOptions) Validate() error {
+	if opts.Endpoint == "" {
+		return errors.New("must specify an OTLP endpoint")
+	}
+	u, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return errors.Wrapf(err, "parsing OTLP endpoint '%s'", opts.Endpoint)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.Errorf("OTLP endpoint '%s' must be an HTTP or HTTPS URL", opts.Endpoint)
+	}
+	if u.Host == "" {
+		return errors.Errorf("OTLP endpoint '%s' must have a host", opts.Endpoint)
+	}
+
+	return nil
+}
+
+// NewOTLPExporter returns an exporter that sends spans to an OpenTelemetry
+// collector using OTLP over HTTP. Failed exports are retried with backoff.
+func NewOTLPExporter(ctx context.Context, opts OTLPExporterOptions) (Exporter, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid OTLP exporter options")
+	}
+
+	u, err := url.Parse(opts.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing OTLP endpoint")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	if !strings.HasSuffix(path, otlpTracesPath) {
+		path += otlpTracesPath
+	}
+
+	clientOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(path),
+		otlptracehttp.WithTimeout(exportTimeout),
+	}
+	if u.Scheme == "http" {
+		clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+	}
+	if len(opts.Headers) > 0 {
+		clientOpts = append(clientOpts, otlptracehttp.WithHeaders(opts.Headers))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, clientOpts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating OTLP exporter")
+	}
+
+	return exporter, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+//
+// File
+
+// StdoutExporterPath is the file exporter path that writes to standard output.
+const StdoutExporterPath = "stdout"
+
+type fileExporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewFileExporter returns an exporter that writes each span as a line of JSON
+// to the file at the given path, which is created if it does not exist and
+// appended to otherwise. If the path is "stdout", the spans are written to
+// standard output. This exporter is intended for local testing.
+func NewFileExporter(path string) (Exporter, error) {
+	if path == "" {
+		return nil, errors.New("must specify a file path")
+	}
+	if path == StdoutExporterPath {
+		return NewWriterExporter(os.Stdout), nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening trace file '%s'", path)
+	}
+
+	return &fileExporter{w: f, closer: f}, nil
+}
+
+// NewWriterExporter returns an exporter that writes each span as a line of JSON
+// to the given writer.
+func NewWriterExporter(w io.Writer) Exporter {
+	return &fileExporter{w: w}
+}
+
+// fileSpan is the JSON representation of a finished span written by the file
+// exporter.
+type fileSpan struct {
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	Name          string                 `json:"name"`
+	Kind          string                 `json:"kind"`
+	StartTime     time.Time              `json:"start_time"`
+	EndTime       time.Time              `json:"end_time"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Error         bool                   `json:"error,omitempty"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+}
+
+func newFileSpan(span sdktrace.ReadOnlySpan) fileSpan {
+	out := fileSpan{
+		TraceID:   span.SpanContext().TraceID().String(),
+		SpanID:    span.SpanContext().SpanID().String(),
+		Name:      span.Name(),
+		Kind:      span.SpanKind().String(),
+		StartTime: span.StartTime(),
+		EndTime:   span.EndTime(),
+	}
+	if span.Parent().IsValid() {
+		out.ParentSpanID = span.Parent().SpanID().String()
+	}
+	if attrs := span.Attributes(); len(attrs) > 0 {
+		out.Attributes = make(map[string]interface{}, len(attrs))
+		for _, attr := range attrs {
+			out.Attributes[string(attr.Key)] = attr.Value.AsInterface()
+		}
+	}
+	if status := span.Status(); status.Code == codes.Error {
+		out.Error = true
+		out.StatusMessage = status.Description
+	}
+
+	return out
+}
+
+func (e *fileExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+	for _, span := range spans {
+		if err := enc.Encode(newFileSpan(span)); err != nil {
+			return errors.Wrap(err, "writing span")
+		}
+	}
+
+	return nil
+}
+
+func (e *fileExporter) Shutdown(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closer == nil {
+		return nil
+	}
+	err := e.closer.Close()
+	e.closer = nil
+
+	return errors.Wrap(err, "closing trace file")
+}
@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewMongoMonitor returns a command monitor that records a client span for
+// each database command, forwarding all events to the next monitor, which may
+// be nil. Only commands run with a context that already contains a span are
+// traced, so that background operations, such as queue polling, do not each
+// start a new trace.
+func NewMongoMonitor(next *event.CommandMonitor) *event.CommandMonitor {
+	m := &mongoMonitor{next: next}
+	return &event.CommandMonitor{
+		Started:   m.started,
+		Succeeded: m.succeeded,
+		Failed:    m.failed,
+	}
+}
+
+type mongoMonitor struct {
+	next  *event.CommandMonitor
+	spans sync.Map
+}
+
+func mongoSpanKey(connectionID string, requestID int64) string {
+	return fmt.Sprintf("%s/%d", connectionID, requestID)
+}
+
+func (m *mongoMonitor) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	if m.next != nil && m.next.Started != nil {
+		m.next.Started(ctx, evt)
+	}
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	_, span := StartSpan(ctx, "mongodb."+evt.CommandName, SpanKindClient)
+	if span == nil {
+		return
+	}
+	span.SetAttribute("db.system", "mongodb")
+	span.SetAttribute("db.name", evt.DatabaseName)
+	span.SetAttribute("db.operation", evt.CommandName)
+	if elem, err := evt.Command.IndexErr(0); err == nil {
+		if collection, ok := elem.Value().StringValueOK(); ok {
+			span.SetAttribute("db.mongodb.collection", collection)
+		}
+	}
+
+	m.spans.Store(mongoSpanKey(evt.ConnectionID, evt.RequestID), span)
+}
+
+func (m *mongoMonitor) succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	if m.next != nil && m.next.Succeeded != nil {
+		m.next.Succeeded(ctx, evt)
+	}
+	m.end(evt.ConnectionID, evt.RequestID, "")
+}
+
+func (m *mongoMonitor) failed(ctx context.Context, evt *event.CommandFailedEvent) {
+	if m.next != nil && m.next.Failed != nil {
+		m.next.Failed(ctx, evt)
+	}
+	m.end(evt.ConnectionID, evt.RequestID, evt.Failure)
+}
+
+func (m *mongoMonitor) end(connectionID string, requestID int64, failure string) {
+	value, ok := m.spans.LoadAndDelete(mongoSpanKey(connectionID, requestID))
+	if !ok {
+		return
+	}
+
+	span := value.(*Span)
+	if failure != "" {
+		span.RecordError(errors.New(failure))
+	}
+	span.End()
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceParentHeader is the name of the W3C trace context header, which is also
+// used as the gRPC metadata key.
+const TraceParentHeader = "traceparent"
+
+var propagator = propagation.TraceContext{}
+
+// TraceParentFromContext returns the W3C traceparent of the current span in
+// the context, or an empty string if there is none. This is used to carry the
+// trace context into amboy jobs.
+func TraceParentFromContext(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+
+	return carrier.Get(TraceParentHeader)
+}
+
+// ContextWithTraceParent returns a context whose spans will be children of the
+// span identified by the W3C traceparent. Empty or malformed values are
+// ignored.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+
+	return propagator.Extract(ctx, propagation.MapCarrier{TraceParentHeader: traceParent})
+}
+
+// InjectHTTP sets the traceparent header of the outgoing request headers to the
+// current span in the context.
+func InjectHTTP(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// ExtractHTTP returns a context whose spans will be children of the span in
+// the traceparent header of the incoming request headers, if any.
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SpanKind describes the relationship between a span and its parent and
+// children.
+type SpanKind = trace.SpanKind
+
+const (
+	SpanKindInternal = trace.SpanKindInternal
+	SpanKindServer   = trace.SpanKindServer
+	SpanKindClient   = trace.SpanKindClient
+	SpanKindProducer = trace.SpanKindProducer
+	SpanKindConsumer = trace.SpanKindConsumer
+)
+
+// Span records a single operation within a trace. A nil span is valid and
+// records nothing, which is what is returned when tracing is disabled.
+type Span struct {
+	span trace.Span
+}
+
+// StartSpan starts a new span with the given name and kind as a child of the
+// span, local or remote, in the context. It returns a context containing the
+// new span, which must be ended by the caller. If tracing is disabled, the
+// context is returned unchanged along with a nil span.
+func StartSpan(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	tracer := getTracer()
+	if tracer == nil {
+		return ctx, nil
+	}
+
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(kind))
+	return ctx, &Span{span: span}
+}
+
+// SetAttribute records a key-value pair describing the span's operation.
+// Values should be strings, booleans, integers or floats, other values are
+// recorded as their string representation.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+
+	var kv attribute.KeyValue
+	switch v := value.(type) {
+	case string:
+		kv = attribute.String(key, v)
+	case bool:
+		kv = attribute.Bool(key, v)
+	case int:
+		kv = attribute.Int(key, v)
+	case int32:
+		kv = attribute.Int64(key, int64(v))
+	case int64:
+		kv = attribute.Int64(key, v)
+	case float64:
+		kv = attribute.Float64(key, v)
+	default:
+		kv = attribute.String(key, fmt.Sprint(v))
+	}
+	s.span.SetAttributes(kv)
+}
+
+// RecordError marks the span as failed with the given error. Nil errors are
+// ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End finishes the span and, if it is sampled, queues it for export. Calling
+// End more than once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+// Finish records the error, if any, and ends the span.
+func (s *Span) Finish(err error) {
+	s.RecordError(err)
+	s.End()
+}
@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName  = "github.com/evergreen-ci/cedar"
+	defaultServiceName   = "cedar"
+	defaultBatchSize     = 512
+	defaultQueueSize     = 4096
+	defaultFlushInterval = 5 * time.Second
+	exportTimeout        = 30 * time.Second
+)
+
+// Exporter sends batches of finished spans to a tracing backend.
+type Exporter = sdktrace.SpanExporter
+
+// Options configure the tracer.
+type Options struct {
+	// Exporter receives the finished, sampled spans in batches.
+	Exporter Exporter
+	// ServiceName is reported as the "service.name" resource attribute.
+	// Defaults to "cedar".
+	ServiceName string
+	// SampleRatio is the fraction, between 0 and 1, of traces started in
+	// this process that are sampled. Zero samples all traces. Spans with a
+	// parent follow the parent's sampling decision.
+	SampleRatio float64
+	// BatchSize is the maximum number of spans exported at once.
+	BatchSize int
+	// QueueSize is the maximum number of finished spans waiting to be
+	// exported, once it is reached new spans are dropped.
+	QueueSize int
+	// FlushInterval is the maximum amount of time finished spans wait
+	// before being exported.
+	FlushInterval time.Duration
+}
+
+// Validate checks that the options are valid and sets defaults where
+// necessary.
+func (opts *Options) Validate() error {
+	catcher := grip.NewBasicCatcher()
+	catcher.NewWhen(opts.Exporter == nil, "must specify an exporter")
+	catcher.NewWhen(opts.SampleRatio < 0 || opts.SampleRatio > 1, "sample ratio must be between 0 and 1")
+	catcher.NewWhen(opts.BatchSize < 0, "batch size cannot be negative")
+	catcher.NewWhen(opts.QueueSize < 0, "queue size cannot be negative")
+	catcher.NewWhen(opts.FlushInterval < 0, "flush interval cannot be negative")
+	if catcher.HasErrors() {
+		return catcher.Resolve()
+	}
+
+	if opts.ServiceName == "" {
+		opts.ServiceName = defaultServiceName
+	}
+	if opts.SampleRatio == 0 {
+		opts.SampleRatio = 1
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.QueueSize == 0 {
+		opts.QueueSize = defaultQueueSize
+	}
+	if opts.FlushInterval == 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+
+	return nil
+}
+
+var global = struct {
+	mu       sync.RWMutex
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}{}
+
+func getTracer() trace.Tracer {
+	global.mu.RLock()
+	defer global.mu.RUnlock()
+
+	return global.tracer
+}
+
+// Configure enables tracing with the given options, replacing and shutting
+// down the current tracer provider, if any. The tracer provider and the W3C
+// trace context propagator are also registered as the OpenTelemetry globals.
+func Configure(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return errors.Wrap(err, "invalid tracing options")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(opts.Exporter,
+			sdktrace.WithMaxExportBatchSize(opts.BatchSize),
+			sdktrace.WithMaxQueueSize(opts.QueueSize),
+			sdktrace.WithBatchTimeout(opts.FlushInterval),
+			sdktrace.WithExportTimeout(exportTimeout),
+		),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(opts.ServiceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	global.mu.Lock()
+	old := global.provider
+	global.provider = provider
+	global.tracer = provider.Tracer(instrumentationName)
+	global.mu.Unlock()
+
+	if old != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		defer cancel()
+		grip.Warning(message.WrapError(old.Shutdown(ctx), "shutting down previous tracer provider"))
+	}
+
+	return nil
+}
+
+// Enabled returns whether tracing has been configured.
+func Enabled() bool { return getTracer() != nil }
+
+// Shutdown disables tracing, exports the remaining finished spans and shuts
+// down the exporter.
+func Shutdown(ctx context.Context) error {
+	global.mu.Lock()
+	provider := global.provider
+	global.provider = nil
+	global.tracer = nil
+	global.mu.Unlock()
+
+	if provider == nil {
+		return nil
+	}
+	otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+	return errors.Wrap(provider.Shutdown(ctx), "shutting down tracer provider")
+}
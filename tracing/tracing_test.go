@@ -0,0 +1,426 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+type recordingExporter struct {
+	mu       sync.Mutex
+	spans    tracetest.SpanStubs
+	shutdown bool
+}
+
+func (e *recordingExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, tracetest.SpanStubsFromReadOnlySpans(spans)...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(_ context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.shutdown = true
+	return nil
+}
+
+// withTracer configures tracing with a recording exporter for the duration of
+// the function and returns the spans exported once tracing is shut down.
+func withTracer(t *testing.T, opts Options, fn func()) tracetest.SpanStubs {
+	exporter := &recordingExporter{}
+	opts.Exporter = exporter
+	require.NoError(t, Configure(opts))
+
+	fn()
+
+	require.NoError(t, Shutdown(context.Background()))
+	assert.True(t, exporter.shutdown)
+	return exporter.spans
+}
+
+func newSpanContext(t *testing.T, sampled bool) trace.SpanContext {
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+}
+
+func TestTraceParent(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		ctx := ContextWithTraceParent(context.Background(), traceParent)
+		assert.Equal(t, newSpanContext(t, true), trace.SpanContextFromContext(ctx))
+		assert.Equal(t, traceParent, TraceParentFromContext(ctx))
+	})
+	t.Run("Unsampled", func(t *testing.T) {
+		ctx := trace.ContextWithRemoteSpanContext(context.Background(), newSpanContext(t, false))
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", TraceParentFromContext(ctx))
+	})
+	t.Run("NoSpan", func(t *testing.T) {
+		assert.Empty(t, TraceParentFromContext(context.Background()))
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for name, value := range map[string]string{
+			"Empty":          "",
+			"TooFewParts":    "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+			"InvalidVersion": "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			"ShortTraceID":   "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+			"ZeroTraceID":    "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+			"ZeroSpanID":     "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+			"NotHex":         "00-4bf92f3577b34da6a3ce929d0e0e473z-00f067aa0ba902b7-01",
+		} {
+			t.Run(name, func(t *testing.T) {
+				ctx := ContextWithTraceParent(context.Background(), value)
+				assert.False(t, trace.SpanContextFromContext(ctx).IsValid())
+			})
+		}
+	})
+	t.Run("HTTP", func(t *testing.T) {
+		sc := newSpanContext(t, true)
+		header := http.Header{}
+		InjectHTTP(trace.ContextWithRemoteSpanContext(context.Background(), sc), header)
+		assert.Equal(t, traceParent, header.Get(TraceParentHeader))
+		assert.Equal(t, sc, trace.SpanContextFromContext(ExtractHTTP(context.Background(), header)))
+
+		header = http.Header{}
+		InjectHTTP(context.Background(), header)
+		assert.Empty(t, header)
+		assert.False(t, trace.SpanContextFromContext(ExtractHTTP(context.Background(), header)).IsValid())
+	})
+}
+
+func TestSpans(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		require.False(t, Enabled())
+		ctx := context.Background()
+		spanCtx, span := StartSpan(ctx, "span", SpanKindInternal)
+		assert.Nil(t, span)
+		assert.Equal(t, ctx, spanCtx)
+
+		assert.NotPanics(t, func() {
+			span.SetAttribute("key", "value")
+			span.RecordError(errors.New("error"))
+			span.End()
+			span.Finish(nil)
+		})
+	})
+	t.Run("ParentAndChild", func(t *testing.T) {
+		spans := withTracer(t, Options{ServiceName: "service"}, func() {
+			ctx, parent := StartSpan(context.Background(), "parent", SpanKindServer)
+			require.NotNil(t, parent)
+			parent.SetAttribute("string", "value")
+			parent.SetAttribute("int", 42)
+			parent.SetAttribute("duration", time.Second)
+
+			_, child := StartSpan(ctx, "child", SpanKindClient)
+			require.NotNil(t, child)
+			child.Finish(errors.New("child failed"))
+			child.SetAttribute("ignored", true)
+			child.End()
+
+			parent.End()
+		})
+		require.Len(t, spans, 2)
+		child, parent := spans[0], spans[1]
+
+		assert.Equal(t, "parent", parent.Name)
+		assert.Equal(t, SpanKindServer, parent.SpanKind)
+		assert.False(t, parent.Parent.IsValid())
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			attribute.String("string", "value"),
+			attribute.Int("int", 42),
+			attribute.String("duration", "1s"),
+		}, parent.Attributes)
+		assert.Equal(t, codes.Unset, parent.Status.Code)
+		assert.False(t, parent.EndTime.Before(parent.StartTime))
+		serviceName, ok := parent.Resource.Set().Value("service.name")
+		require.True(t, ok)
+		assert.Equal(t, "service", serviceName.AsString())
+
+		assert.Equal(t, "child", child.Name)
+		assert.Equal(t, parent.SpanContext.TraceID(), child.SpanContext.TraceID())
+		assert.Equal(t, parent.SpanContext.SpanID(), child.Parent.SpanID())
+		assert.NotEqual(t, parent.SpanContext.SpanID(), child.SpanContext.SpanID())
+		assert.Equal(t, codes.Error, child.Status.Code)
+		assert.Equal(t, "child failed", child.Status.Description)
+		assert.Empty(t, child.Attributes)
+		require.Len(t, child.Events, 1)
+		assert.Equal(t, "exception", child.Events[0].Name)
+	})
+	t.Run("RemoteParent", func(t *testing.T) {
+		remote := newSpanContext(t, true)
+		spans := withTracer(t, Options{}, func() {
+			ctx, span := StartSpan(trace.ContextWithRemoteSpanContext(context.Background(), remote), "span", SpanKindServer)
+			assert.Equal(t, remote.TraceID(), trace.SpanContextFromContext(ctx).TraceID())
+			span.End()
+		})
+		require.Len(t, spans, 1)
+		assert.Equal(t, remote.TraceID(), spans[0].SpanContext.TraceID())
+		assert.Equal(t, remote.SpanID(), spans[0].Parent.SpanID())
+	})
+	t.Run("UnsampledRemoteParent", func(t *testing.T) {
+		remote := newSpanContext(t, false)
+		spans := withTracer(t, Options{}, func() {
+			ctx, span := StartSpan(trace.ContextWithRemoteSpanContext(context.Background(), remote), "span", SpanKindServer)
+			require.NotNil(t, span)
+			assert.False(t, trace.SpanContextFromContext(ctx).IsSampled())
+			span.End()
+		})
+		assert.Empty(t, spans)
+	})
+	t.Run("SampleRatio", func(t *testing.T) {
+		const numTraces = 1000
+		spans := withTracer(t, Options{SampleRatio: 0.25}, func() {
+			for i := 0; i < numTraces; i++ {
+				_, span := StartSpan(context.Background(), "span", SpanKindInternal)
+				span.End()
+			}
+		})
+		assert.True(t, len(spans) > numTraces/10, "sampled %d spans", len(spans))
+		assert.True(t, len(spans) < numTraces/2, "sampled %d spans", len(spans))
+	})
+}
+
+func TestOptions(t *testing.T) {
+	for name, opts := range map[string]Options{
+		"NoExporter":       {},
+		"NegativeRatio":    {Exporter: &recordingExporter{}, SampleRatio: -0.1},
+		"RatioAboveOne":    {Exporter: &recordingExporter{}, SampleRatio: 1.1},
+		"NegativeQueue":    {Exporter: &recordingExporter{}, QueueSize: -1},
+		"NegativeBatch":    {Exporter: &recordingExporter{}, BatchSize: -1},
+		"NegativeInterval": {Exporter: &recordingExporter{}, FlushInterval: -time.Second},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, opts.Validate())
+		})
+	}
+	t.Run("Defaults", func(t *testing.T) {
+		opts := Options{Exporter: &recordingExporter{}}
+		require.NoError(t, opts.Validate())
+		assert.Equal(t, defaultServiceName, opts.ServiceName)
+		assert.EqualValues(t, 1, opts.SampleRatio)
+		assert.Equal(t, defaultBatchSize, opts.BatchSize)
+		assert.Equal(t, defaultQueueSize, opts.QueueSize)
+		assert.Equal(t, defaultFlushInterval, opts.FlushInterval)
+	})
+}
+
+func TestOTLPExporter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.Run("InvalidOptions", func(t *testing.T) {
+		for name, opts := range map[string]OTLPExporterOptions{
+			"NoEndpoint": {},
+			"NoScheme":   {Endpoint: "localhost:4318"},
+			"NoHost":     {Endpoint: "http:///v1/traces"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				_, err := NewOTLPExporter(ctx, opts)
+				assert.Error(t, err)
+			})
+		}
+	})
+	t.Run("Export", func(t *testing.T) {
+		var (
+			path   string
+			header http.Header
+			req    collectortrace.ExportTraceServiceRequest
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			header = r.Header
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			assert.NoError(t, proto.Unmarshal(body, &req))
+		}))
+		defer srv.Close()
+
+		exporter, err := NewOTLPExporter(ctx, OTLPExporterOptions{
+			Endpoint: srv.URL + "/",
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, exporter.ExportSpans(ctx, tracetest.SpanStubs{
+			{
+				Name:        "span",
+				SpanContext: newSpanContext(t, true),
+				SpanKind:    SpanKindServer,
+				Attributes:  []attribute.KeyValue{attribute.String("key", "value")},
+			},
+		}.Snapshots()))
+		require.NoError(t, exporter.Shutdown(ctx))
+
+		assert.Equal(t, otlpTracesPath, path)
+		assert.Equal(t, "Bearer token", header.Get("Authorization"))
+		require.Len(t, req.ResourceSpans, 1)
+		require.Len(t, req.ResourceSpans[0].ScopeSpans, 1)
+		require.Len(t, req.ResourceSpans[0].ScopeSpans[0].Spans, 1)
+		span := req.ResourceSpans[0].ScopeSpans[0].Spans[0]
+		assert.Equal(t, "span", span.Name)
+		require.Len(t, span.Attributes, 1)
+		assert.Equal(t, "key", span.Attributes[0].Key)
+		assert.Equal(t, "value", span.Attributes[0].Value.GetStringValue())
+	})
+	t.Run("ErrorStatus", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			http.Error(rw, "bad request", http.StatusBadRequest)
+		}))
+		defer srv.Close()
+
+		exporter, err := NewOTLPExporter(ctx, OTLPExporterOptions{Endpoint: srv.URL})
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, exporter.Shutdown(ctx))
+		}()
+		assert.Error(t, exporter.ExportSpans(ctx, tracetest.SpanStubs{{Name: "span", SpanContext: newSpanContext(t, true)}}.Snapshots()))
+	})
+}
+
+func TestFileExporter(t *testing.T) {
+	t.Run("NoPath", func(t *testing.T) {
+		_, err := NewFileExporter("")
+		assert.Error(t, err)
+	})
+	t.Run("Writer", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		exporter := NewWriterExporter(buf)
+		parent := newSpanContext(t, true)
+		child := parent.WithSpanID(trace.SpanID{1})
+		require.NoError(t, exporter.ExportSpans(context.Background(), tracetest.SpanStubs{
+			{Name: "one", SpanContext: parent},
+			{
+				Name:        "two",
+				SpanContext: child,
+				Parent:      parent,
+				SpanKind:    SpanKindClient,
+				Attributes:  []attribute.KeyValue{attribute.Int("key", 1)},
+				Status:      sdktrace.Status{Code: codes.Error, Description: "failed"},
+			},
+		}.Snapshots()))
+		require.NoError(t, exporter.Shutdown(context.Background()))
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 2)
+		span := fileSpan{}
+		require.NoError(t, json.Unmarshal([]byte(lines[1]), &span))
+		assert.Equal(t, "two", span.Name)
+		assert.Equal(t, "client", span.Kind)
+		assert.Equal(t, parent.TraceID().String(), span.TraceID)
+		assert.Equal(t, child.SpanID().String(), span.SpanID)
+		assert.Equal(t, parent.SpanID().String(), span.ParentSpanID)
+		assert.Equal(t, map[string]interface{}{"key": float64(1)}, span.Attributes)
+		assert.True(t, span.Error)
+		assert.Equal(t, "failed", span.StatusMessage)
+	})
+	t.Run("File", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "trace-file-exporter")
+		require.NoError(t, err)
+		defer func() {
+			assert.NoError(t, os.RemoveAll(dir))
+		}()
+		path := filepath.Join(dir, "spans.json")
+
+		for _, name := range []string{"one", "two"} {
+			exporter, err := NewFileExporter(path)
+			require.NoError(t, err)
+			require.NoError(t, exporter.ExportSpans(context.Background(), tracetest.SpanStubs{{Name: name}}.Snapshots()))
+			require.NoError(t, exporter.Shutdown(context.Background()))
+		}
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		assert.Len(t, lines, 2)
+	})
+}
+
+func TestMongoMonitor(t *testing.T) {
+	var started, succeeded, failed int
+	next := &event.CommandMonitor{
+		Started:   func(context.Context, *event.CommandStartedEvent) { started++ },
+		Succeeded: func(context.Context, *event.CommandSucceededEvent) { succeeded++ },
+		Failed:    func(context.Context, *event.CommandFailedEvent) { failed++ },
+	}
+	monitor := NewMongoMonitor(next)
+
+	command, err := bson.Marshal(bson.D{{Key: "find", Value: "test_results"}})
+	require.NoError(t, err)
+
+	spans := withTracer(t, Options{}, func() {
+		monitor.Started(context.Background(), &event.CommandStartedEvent{
+			Command:      command,
+			DatabaseName: "cedar",
+			CommandName:  "find",
+			RequestID:    1,
+			ConnectionID: "conn",
+		})
+		monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, ConnectionID: "conn"},
+		})
+
+		ctx, parent := StartSpan(context.Background(), "parent", SpanKindServer)
+		monitor.Started(ctx, &event.CommandStartedEvent{
+			Command:      command,
+			DatabaseName: "cedar",
+			CommandName:  "find",
+			RequestID:    2,
+			ConnectionID: "conn",
+		})
+		monitor.Failed(ctx, &event.CommandFailedEvent{
+			CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 2, ConnectionID: "conn"},
+			Failure:              "command failed",
+		})
+		parent.End()
+	})
+
+	assert.Equal(t, 2, started)
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 1, failed)
+
+	require.Len(t, spans, 2)
+	span := spans[0]
+	assert.Equal(t, "mongodb.find", span.Name)
+	assert.Equal(t, SpanKindClient, span.SpanKind)
+	assert.Equal(t, spans[1].SpanContext.SpanID(), span.Parent.SpanID())
+	assert.Contains(t, span.Attributes, attribute.String("db.name", "cedar"))
+	assert.Contains(t, span.Attributes, attribute.String("db.mongodb.collection", "test_results"))
+	assert.Equal(t, codes.Error, span.Status.Code)
+	assert.Equal(t, "command failed", span.Status.Description)
+}
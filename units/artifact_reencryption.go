@@ -0,0 +1,76 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	artifactReencryptionJobName = "artifact-reencryption"
+
+	// artifactReencryptionBatchSize is the maximum number of artifacts
+	// per project and collection re-encrypted by each run of the job.
+	artifactReencryptionBatchSize = 100
+)
+
+type artifactReencryptionJob struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(artifactReencryptionJobName,
+		func() amboy.Job { return makeArtifactReencryptionJob() })
+}
+
+func makeArtifactReencryptionJob() *artifactReencryptionJob {
+	j := &artifactReencryptionJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    artifactReencryptionJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewArtifactReencryptionJob creates a new amboy job that re-encrypts the
+// stored objects of completed logs, test results and system metrics that were
+// encrypted with a key other than the current key of their project, which
+// completes the rotation of a project's encryption key.
+func NewArtifactReencryptionJob(env cedar.Environment, id string) amboy.Job {
+	j := makeArtifactReencryptionJob()
+	j.SetID(fmt.Sprintf("%s.%s", artifactReencryptionJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *artifactReencryptionJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	count, err := model.ReencryptArtifacts(ctx, j.env, artifactReencryptionBatchSize)
+	j.AddError(errors.Wrap(err, "re-encrypting artifacts"))
+	grip.InfoWhen(count > 0, message.Fields{
+		"message":   "re-encrypted artifacts",
+		"job_id":    j.ID(),
+		"artifacts": count,
+	})
+}
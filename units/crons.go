@@ -101,6 +101,50 @@ func StartCrons(ctx context.Context, env cedar.Environment, rpcTLS bool) error {
 
 		return queue.Put(ctx, NewStatsDBCollectionSizeJob(env, utility.RoundPartOfMinute(0).Format(tsFormat)))
 	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		conf := model.NewCedarConfig(env)
+		if err := conf.Find(); err != nil {
+			return errors.WithStack(err)
+		}
+
+		// Keep migrating after tiering is disabled until the hot tier
+		// is drained.
+		if !conf.Bucket.Tiered.IsEnabled() {
+			tiered, err := model.HasTieredArtifacts(ctx, env)
+			if err != nil {
+				return errors.WithStack(err)
+			}
+			if !tiered {
+				return nil
+			}
+		}
+
+		return queue.Put(ctx, NewStorageTierMigrationJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewArtifactReencryptionJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		return queue.Put(ctx, NewHistoricalTestDataDigestCompactionJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+	})
+	amboy.IntervalQueueOperation(ctx, remote, 10*time.Minute, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+		controllers, err := model.FindActiveStorageMigrationControllers(ctx, env)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+
+		ts := utility.RoundPartOfHour(10).Format(tsFormat)
+		catcher := grip.NewBasicCatcher()
+		for _, controller := range controllers {
+			catcher.Add(queue.Put(ctx, NewStorageMigrationJob(env, controller.ID, ts)))
+		}
+		return catcher.Resolve()
+	})
+	if rpcTLS {
+		amboy.IntervalQueueOperation(ctx, remote, time.Hour, time.Now(), opts, func(ctx context.Context, queue amboy.Queue) error {
+			return queue.Put(ctx, NewServerCertRenewalJob(env, utility.RoundPartOfHour(0).Format(tsFormat)))
+		})
+	}
 
 	return nil
 }
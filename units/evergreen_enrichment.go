@@ -0,0 +1,151 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/evergreen"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	evergreenEnrichmentJobName = "evergreen-metadata-enrichment"
+
+	evergreenEnrichmentTimeout = time.Minute
+)
+
+type evergreenEnrichmentJob struct {
+	RecordType string `bson:"record_type" json:"record_type" yaml:"record_type"`
+	RecordID   string `bson:"record_id" json:"record_id" yaml:"record_id"`
+	TaskID     string `bson:"task_id" json:"task_id" yaml:"task_id"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(evergreenEnrichmentJobName,
+		func() amboy.Job { return makeEvergreenEnrichmentJob() })
+}
+
+func makeEvergreenEnrichmentJob() *evergreenEnrichmentJob {
+	j := &evergreenEnrichmentJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    evergreenEnrichmentJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewEvergreenEnrichmentJob creates a new amboy job that fetches the task,
+// version and build metadata of a newly created record from Evergreen and
+// attaches it to the record's info. The job is a no-op when no Evergreen URL
+// is configured.
+func NewEvergreenEnrichmentJob(env cedar.Environment, recordType, recordID, taskID string) amboy.Job {
+	j := makeEvergreenEnrichmentJob()
+	j.SetID(fmt.Sprintf("%s.%s.%s", evergreenEnrichmentJobName, recordType, recordID))
+	j.RecordType = recordType
+	j.RecordID = recordID
+	j.TaskID = taskID
+	j.env = env
+	return j
+}
+
+// EnqueueEvergreenEnrichment enqueues a job that attaches Evergreen metadata
+// to a newly created record. The metadata is optional, so errors are logged
+// rather than returned.
+func EnqueueEvergreenEnrichment(ctx context.Context, env cedar.Environment, recordType, id, taskID string) {
+	q := env.GetRemoteQueue()
+	if q == nil || taskID == "" {
+		return
+	}
+
+	j := NewEvergreenEnrichmentJob(env, recordType, id, taskID)
+	grip.Error(message.WrapError(amboy.EnqueueUniqueJob(ctx, q, WithTraceContext(ctx, j)), message.Fields{
+		"message":     "could not enqueue Evergreen metadata enrichment job",
+		"record_type": recordType,
+		"record_id":   id,
+		"task_id":     taskID,
+	}))
+}
+
+func (j *evergreenEnrichmentJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+	if j.TaskID == "" {
+		return
+	}
+
+	metadata, err := model.FindEvergreenMetadata(ctx, j.env, j.RecordType, j.TaskID)
+	if err != nil {
+		j.AddError(errors.WithStack(err))
+		return
+	}
+	if metadata == nil {
+		conf := model.NewCedarConfig(j.env)
+		if err = conf.Find(); err != nil {
+			j.AddError(errors.Wrap(err, "getting application configuration"))
+			return
+		}
+		if conf.Evergreen.URL == "" {
+			return
+		}
+
+		client := evergreen.NewClient(&http.Client{Timeout: evergreenEnrichmentTimeout}, evergreen.ConnectionOptions{
+			RootURL: conf.Evergreen.URL,
+			User:    conf.Evergreen.ServiceUserName,
+			Key:     conf.Evergreen.ServiceUserAPIKey,
+		})
+		if metadata, err = fetchEvergreenMetadata(ctx, client, j.TaskID); err != nil {
+			j.AddError(errors.Wrapf(err, "fetching Evergreen metadata for task '%s'", j.TaskID))
+			return
+		}
+	}
+
+	j.AddError(model.SetEvergreenMetadata(ctx, j.env, j.RecordType, j.RecordID, *metadata))
+}
+
+func fetchEvergreenMetadata(ctx context.Context, client *evergreen.Client, taskID string) (*model.EvergreenMetadata, error) {
+	task, err := client.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := client.GetVersion(ctx, task.VersionID)
+	if err != nil {
+		return nil, err
+	}
+	build, err := client.GetBuild(ctx, task.BuildID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.EvergreenMetadata{
+		Author:             version.Author,
+		AuthorEmail:        version.AuthorEmail,
+		Revision:           version.Revision,
+		CommitTime:         version.CommitTime(),
+		Requester:          version.Requester,
+		Distro:             task.DistroID,
+		VariantDisplayName: build.DisplayName,
+		EnrichedAt:         time.Now(),
+	}, nil
+}
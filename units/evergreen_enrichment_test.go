@@ -0,0 +1,106 @@
+package units
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvergreenEnrichmentJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := cedar.GetEnvironment()
+	defer func() {
+		assert.NoError(t, tearDownEnv(env))
+	}()
+
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/v2/tasks/task", func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = rw.Write([]byte(`{"task_id": "task", "version_id": "version", "build_id": "build", "distro_id": "distro"}`))
+	})
+	mux.HandleFunc("/rest/v2/versions/version", func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = rw.Write([]byte(`{"version_id": "version", "author": "author", "requester": "patch_request", "create_time": "2021-01-02T03:04:05Z"}`))
+	})
+	mux.HandleFunc("/rest/v2/builds/build", func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = rw.Write([]byte(`{"_id": "build", "display_name": "Variant"}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	createLog := func(t *testing.T, info model.LogInfo) *model.Log {
+		log := model.CreateLog(info, model.PailLocal)
+		log.Setup(env)
+		require.NoError(t, log.SaveNew(ctx))
+		return log
+	}
+	findLog := func(t *testing.T, id string) *model.Log {
+		log := &model.Log{ID: id}
+		log.Setup(env)
+		require.NoError(t, log.Find(ctx))
+		return log
+	}
+
+	t.Run("NoEvergreenURL", func(t *testing.T) {
+		conf := model.NewCedarConfig(env)
+		require.NoError(t, conf.Save())
+		log := createLog(t, model.LogInfo{Project: "project", TaskID: "task", ProcessName: "none"})
+
+		j := NewEvergreenEnrichmentJob(env, model.EvergreenMetadataRecordLog, log.ID, log.Info.TaskID)
+		j.Run(ctx)
+		require.NoError(t, j.Error())
+		assert.Nil(t, findLog(t, log.ID).Info.Evergreen)
+		assert.Zero(t, requests)
+	})
+	conf := model.NewCedarConfig(env)
+	require.NoError(t, conf.Find())
+	conf.Evergreen.URL = srv.URL
+	require.NoError(t, conf.Save())
+	t.Run("FetchesMetadata", func(t *testing.T) {
+		log := createLog(t, model.LogInfo{Project: "project", TaskID: "task", ProcessName: "first"})
+
+		j := NewEvergreenEnrichmentJob(env, model.EvergreenMetadataRecordLog, log.ID, log.Info.TaskID)
+		j.Run(ctx)
+		require.NoError(t, j.Error())
+		metadata := findLog(t, log.ID).Info.Evergreen
+		require.NotNil(t, metadata)
+		assert.Equal(t, "author", metadata.Author)
+		assert.Equal(t, "patch_request", metadata.Requester)
+		assert.Equal(t, "distro", metadata.Distro)
+		assert.Equal(t, "Variant", metadata.VariantDisplayName)
+		assert.True(t, metadata.CommitTime.IsZero())
+		assert.Equal(t, 3, requests)
+	})
+	t.Run("ReusesMetadataForTask", func(t *testing.T) {
+		log := createLog(t, model.LogInfo{Project: "project", TaskID: "task", ProcessName: "second"})
+
+		j := NewEvergreenEnrichmentJob(env, model.EvergreenMetadataRecordLog, log.ID, log.Info.TaskID)
+		j.Run(ctx)
+		require.NoError(t, j.Error())
+		metadata := findLog(t, log.ID).Info.Evergreen
+		require.NotNil(t, metadata)
+		assert.Equal(t, "author", metadata.Author)
+		assert.Equal(t, 3, requests)
+	})
+	t.Run("TaskNotFound", func(t *testing.T) {
+		log := createLog(t, model.LogInfo{Project: "project", TaskID: "missing"})
+
+		j := NewEvergreenEnrichmentJob(env, model.EvergreenMetadataRecordLog, log.ID, log.Info.TaskID)
+		j.Run(ctx)
+		assert.Error(t, j.Error())
+	})
+	t.Run("InvalidRecordType", func(t *testing.T) {
+		j := NewEvergreenEnrichmentJob(env, "invalid", "id", "task")
+		j.Run(ctx)
+		assert.Error(t, j.Error())
+	})
+}
@@ -22,6 +22,8 @@ const (
 type findOutdatedRollupsJob struct {
 	RollupTypes []string `bson:"rollup_types" json:"rollup_types" yaml:"rollup_types"`
 
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 	env      cedar.Environment
 	queue    amboy.Queue
@@ -71,6 +73,8 @@ func NewFindOutdatedRollupsJob(factories []perf.RollupFactory) (amboy.Job, error
 
 func (j *findOutdatedRollupsJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
@@ -130,7 +134,7 @@ func (j *findOutdatedRollupsJob) createFTDCRollupsJobs(ctx context.Context, fact
 		return
 	}
 
-	if err = j.queue.Put(ctx, job); err != nil {
+	if err = j.queue.Put(ctx, WithTraceContext(ctx, job)); err != nil {
 		j.AddError(errors.Wrapf(err, "putting FTDC rollups job '%s' in remote queue", j.ID()))
 		return
 	}
@@ -26,6 +26,8 @@ type ftdcRollupsJob struct {
 	RollupTypes   []string            `bson:"rollup_types" json:"rollup_types" yaml:"rollup_types"`
 	UserSubmitted bool                `bson:"user" json:"user" yaml:"user"`
 
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 	env      cedar.Environment
 	queue    amboy.Queue
@@ -90,6 +92,8 @@ func NewFTDCRollupsJob(perfId string, artifactInfo *model.ArtifactInfo, factorie
 
 func (j *ftdcRollupsJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
 	}
@@ -157,7 +161,7 @@ func (j *ftdcRollupsJob) createSignalProcessingJob(ctx context.Context, result *
 	}
 	processingJob := NewUpdateTimeSeriesJob(result.CreateUnanalyzedSeries())
 
-	err := errors.Wrapf(amboy.EnqueueUniqueJob(ctx, j.queue, processingJob), "putting signal processing job '%s' in remote queue", j.ID())
+	err := errors.Wrapf(amboy.EnqueueUniqueJob(ctx, j.queue, WithTraceContext(ctx, processingJob)), "putting signal processing job '%s' in remote queue", j.ID())
 	if err != nil {
 		j.AddError(err)
 	}
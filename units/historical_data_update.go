@@ -0,0 +1,137 @@
+package units
+
+import (
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/mongodb/grip/recovery"
+	"github.com/pkg/errors"
+)
+
+// StartHistoricalTestDataUpdate updates the historical test data with the
+// results in the background, unless it is disabled for the record or the
+// service.
+func StartHistoricalTestDataUpdate(env cedar.Environment, record *model.TestResults, results []model.TestResult) {
+	if historicalDataEnabled(env, record) {
+		go updateHistoricalTestData(env, record, results)
+	}
+}
+
+// StartHistoricalTaskDataUpdate adds the closed record as a task run to the
+// historical task data in the background, unless it is disabled for the record
+// or the service. The runtime of the task run is taken from the results, which
+// are downloaded if nil.
+func StartHistoricalTaskDataUpdate(env cedar.Environment, record *model.TestResults, results []model.TestResult) {
+	if historicalDataEnabled(env, record) {
+		go updateHistoricalTaskData(env, record, results)
+	}
+}
+
+func historicalDataEnabled(env cedar.Environment, record *model.TestResults) bool {
+	if record.Info.HistoricalDataDisabled {
+		return false
+	}
+
+	conf := model.NewCedarConfig(env)
+	if err := conf.Find(); err != nil {
+		grip.Error(message.WrapError(errors.Wrap(err, "finding Cedar configuration"), message.Fields{
+			"message":           "failed to update historical test data",
+			"test_results_info": record.Info,
+		}))
+		// If we can't find the Cedar configuration, we should not
+		// update the historical test data for these results.
+		return false
+	}
+
+	return !conf.Flags.DisableHistoricalTestData
+}
+
+func updateHistoricalTestData(env cedar.Environment, record *model.TestResults, results []model.TestResult) {
+	defer func() {
+		if err := recovery.HandlePanicWithError(recover(), nil, "historical test data update"); err != nil {
+			grip.Error(message.WrapError(err, message.Fields{
+				"message":           "failed to update historical test data",
+				"test_results_info": record.Info,
+			}))
+		}
+	}()
+
+	ownershipCtx, ownershipCancel := env.Context()
+	defer ownershipCancel()
+	ownership, err := model.FindTestOwnership(ownershipCtx, env, record.Info.Project)
+	grip.Warning(message.WrapError(err, message.Fields{
+		"message":           "could not find test ownership, historical test data will not be attributed to teams",
+		"test_results_info": record.Info,
+	}))
+
+	for _, res := range results {
+		info := model.NewHistoricalTestDataInfo(record, res)
+		htd, err := model.CreateHistoricalTestData(info)
+		if err != nil {
+			grip.Error(message.WrapError(errors.Wrap(err, "creating historical test data"), message.Fields{
+				"message":                   "failed to update historical test data",
+				"test_results_info":         record.Info,
+				"historical_test_data_info": info,
+				"test_result":               res,
+			}))
+			continue
+		}
+		htd.Setup(env)
+		htd.Team = ownership.TeamFor(info.TestName)
+
+		ctx, cancel := env.Context()
+		defer cancel()
+		grip.Error(message.WrapError(htd.Update(ctx, res), message.Fields{
+			"message":                   "failed to update historical test data",
+			"test_results_info":         record.Info,
+			"historical_test_data_info": info,
+			"test_result":               res,
+		}))
+	}
+}
+
+func updateHistoricalTaskData(env cedar.Environment, record *model.TestResults, results []model.TestResult) {
+	defer func() {
+		if err := recovery.HandlePanicWithError(recover(), nil, "historical task data update"); err != nil {
+			grip.Error(message.WrapError(err, message.Fields{
+				"message":           "failed to update historical task data",
+				"test_results_info": record.Info,
+			}))
+		}
+	}()
+
+	ctx, cancel := env.Context()
+	defer cancel()
+
+	if results == nil && record.Stats.TotalCount > 0 {
+		var err error
+		results, err = record.Download(ctx)
+		if err != nil {
+			grip.Error(message.WrapError(errors.Wrap(err, "downloading test results"), message.Fields{
+				"message":           "failed to update historical task data",
+				"test_results_info": record.Info,
+			}))
+			return
+		}
+	}
+
+	info := model.NewHistoricalTaskDataInfo(record)
+	htd, err := model.CreateHistoricalTaskData(info)
+	if err != nil {
+		grip.Error(message.WrapError(errors.Wrap(err, "creating historical task data"), message.Fields{
+			"message":                   "failed to update historical task data",
+			"test_results_info":         record.Info,
+			"historical_task_data_info": info,
+		}))
+		return
+	}
+	htd.Setup(env)
+	htd.DisplayTaskName = record.Info.DisplayTaskName
+
+	grip.Error(message.WrapError(htd.Update(ctx, record.Stats, model.TestResultsRuntime(results)), message.Fields{
+		"message":                   "failed to update historical task data",
+		"test_results_info":         record.Info,
+		"historical_task_data_info": info,
+	}))
+}
@@ -0,0 +1,80 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+)
+
+const (
+	historicalTestDataDigestCompactionJobName = "historical-test-data-digest-compaction"
+
+	// historicalTestDataDigestCompactionWindow is how far back historical
+	// test data is compacted, since late test results are rarely added
+	// to older days. Durations left pending still count toward the
+	// duration percentiles.
+	historicalTestDataDigestCompactionWindow = 7 * 24 * time.Hour
+)
+
+type historicalTestDataDigestCompactionJob struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(historicalTestDataDigestCompactionJobName,
+		func() amboy.Job { return makeHistoricalTestDataDigestCompactionJob() })
+}
+
+func makeHistoricalTestDataDigestCompactionJob() *historicalTestDataDigestCompactionJob {
+	j := &historicalTestDataDigestCompactionJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    historicalTestDataDigestCompactionJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewHistoricalTestDataDigestCompactionJob creates a new amboy job that merges
+// the test durations buffered by historical test data updates into the
+// duration digests.
+func NewHistoricalTestDataDigestCompactionJob(env cedar.Environment, id string) amboy.Job {
+	j := makeHistoricalTestDataDigestCompactionJob()
+	j.SetID(fmt.Sprintf("%s.%s", historicalTestDataDigestCompactionJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *historicalTestDataDigestCompactionJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	count, err := model.CompactHistoricalTestDataDigests(ctx, j.env, time.Now().Add(-historicalTestDataDigestCompactionWindow))
+	if err != nil {
+		j.AddError(err)
+	}
+
+	grip.Info(message.Fields{
+		"job_id":    j.ID(),
+		"documents": count,
+		"message":   "compacted historical test data duration digests",
+	})
+}
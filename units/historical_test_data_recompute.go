@@ -0,0 +1,89 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+)
+
+const historicalTestDataRecomputeJobName = "historical-test-data-recompute"
+
+type historicalTestDataRecomputeJob struct {
+	Project string    `bson:"project" json:"project" yaml:"project"`
+	StartAt time.Time `bson:"start_at" json:"start_at" yaml:"start_at"`
+	EndAt   time.Time `bson:"end_at" json:"end_at" yaml:"end_at"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(historicalTestDataRecomputeJobName,
+		func() amboy.Job { return makeHistoricalTestDataRecomputeJob() })
+}
+
+func makeHistoricalTestDataRecomputeJob() *historicalTestDataRecomputeJob {
+	j := &historicalTestDataRecomputeJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    historicalTestDataRecomputeJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewHistoricalTestDataRecomputeJob creates a new amboy job that rebuilds the
+// historical test and task data of a project for a date range from the stored
+// test results. The job ID is unique to the project and date range, so requests to
+// recompute the same range are deduplicated.
+func NewHistoricalTestDataRecomputeJob(env cedar.Environment, opts model.RecomputeHistoricalTestDataOptions) amboy.Job {
+	j := makeHistoricalTestDataRecomputeJob()
+	j.SetID(fmt.Sprintf("%s.%s.%s.%s", historicalTestDataRecomputeJobName, opts.Project,
+		opts.StartAt.Format(model.HistoricalTestDataDateFormat), opts.EndAt.Format(model.HistoricalTestDataDateFormat)))
+	j.Project = opts.Project
+	j.StartAt = opts.StartAt
+	j.EndAt = opts.EndAt
+	j.env = env
+	return j
+}
+
+func (j *historicalTestDataRecomputeJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	count, err := model.RecomputeHistoricalTestData(ctx, j.env, model.RecomputeHistoricalTestDataOptions{
+		Project: j.Project,
+		StartAt: j.StartAt,
+		EndAt:   j.EndAt,
+	})
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+
+	grip.Info(message.Fields{
+		"job_id":    j.ID(),
+		"project":   j.Project,
+		"start_at":  j.StartAt,
+		"end_at":    j.EndAt,
+		"documents": count,
+		"message":   "recomputed historical test data",
+	})
+}
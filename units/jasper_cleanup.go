@@ -19,6 +19,8 @@ func init() {
 }
 
 type jasperManagerCleanup struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
 	env      cedar.Environment
 }
@@ -49,6 +51,8 @@ func makeJasperManagerCleanup() *jasperManagerCleanup {
 
 func (j *jasperManagerCleanup) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
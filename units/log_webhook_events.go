@@ -0,0 +1,91 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const logWebhookEventsJobName = "log-webhook-events"
+
+type logWebhookEventsJob struct {
+	LogID string `bson:"log_id" json:"log_id" yaml:"log_id"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(logWebhookEventsJobName,
+		func() amboy.Job { return makeLogWebhookEventsJob() })
+}
+
+func makeLogWebhookEventsJob() *logWebhookEventsJob {
+	j := &logWebhookEventsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    logWebhookEventsJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewLogWebhookEventsJob creates a new amboy job that notifies the project's
+// webhooks of a log closed with a non-zero exit code.
+func NewLogWebhookEventsJob(env cedar.Environment, logID string) amboy.Job {
+	j := makeLogWebhookEventsJob()
+	j.SetID(fmt.Sprintf("%s.%s", logWebhookEventsJobName, logID))
+	j.LogID = logID
+	j.env = env
+	return j
+}
+
+// EnqueueLogWebhookEvents enqueues a job that notifies the project's webhooks
+// of a log closed with a non-zero exit code. Errors are logged rather than
+// returned so that webhooks never fail the close.
+func EnqueueLogWebhookEvents(ctx context.Context, env cedar.Environment, log *model.Log) {
+	q := env.GetRemoteQueue()
+	if q == nil || log.Info.ExitCode == 0 {
+		return
+	}
+
+	j := NewLogWebhookEventsJob(env, log.ID)
+	grip.Error(message.WrapError(amboy.EnqueueUniqueJob(ctx, q, WithTraceContext(ctx, j)), message.Fields{
+		"message": "could not enqueue log webhook events job",
+		"log_id":  log.ID,
+	}))
+}
+
+func (j *logWebhookEventsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	log := &model.Log{ID: j.LogID}
+	log.Setup(j.env)
+	if err := log.Find(ctx); err != nil {
+		j.AddError(errors.Wrapf(err, "finding log '%s'", j.LogID))
+		return
+	}
+	if log.Info.ExitCode == 0 {
+		return
+	}
+
+	j.AddError(NotifyWebhooks(ctx, j.env, log.Info.Project, model.WebhookEventLogFailed, model.NewLogWebhookData(log)))
+}
@@ -0,0 +1,121 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/certdepot"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	serverCertRenewalJobName = "server-cert-renewal"
+
+	// defaultServerCertRenewalBefore matches the default SSL renewal
+	// window of the REST service.
+	defaultServerCertRenewalBefore = 4 * time.Hour
+)
+
+type serverCertRenewalJob struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(serverCertRenewalJobName,
+		func() amboy.Job { return makeServerCertRenewalJob() })
+}
+
+func makeServerCertRenewalJob() *serverCertRenewalJob {
+	j := &serverCertRenewalJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    serverCertRenewalJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewServerCertRenewalJob creates a new amboy job that renews the server's
+// certificate in the certificate depot when it expires within the configured
+// SSL renewal window. Running servers reload the renewed certificate from the
+// depot without a restart.
+func NewServerCertRenewalJob(env cedar.Environment, id string) amboy.Job {
+	j := makeServerCertRenewalJob()
+	j.SetID(fmt.Sprintf("%s.%s", serverCertRenewalJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *serverCertRenewalJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	conf := model.NewCedarConfig(j.env)
+	if err := conf.Find(); err != nil {
+		j.AddError(errors.Wrap(err, "getting application configuration"))
+		return
+	}
+
+	d, err := certdepot.BootstrapDepotWithMongoClient(ctx, j.env.GetClient(), conf.CA.CertDepot)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "setting up the certificate depot"))
+		return
+	}
+
+	opts := certdepot.CertificateOptions{
+		CommonName: conf.CA.CertDepot.ServiceName,
+		Host:       conf.CA.CertDepot.ServiceName,
+		CA:         conf.CA.CertDepot.CAName,
+	}
+	if conf.CA.CertDepot.ServiceOpts != nil {
+		opts = *conf.CA.CertDepot.ServiceOpts
+	}
+	if opts.Expires == 0 {
+		opts.Expires = conf.CA.SSLExpireAfter
+	}
+	renewBefore := conf.CA.SSLRenewalBefore
+	if renewBefore == 0 {
+		renewBefore = defaultServerCertRenewalBefore
+	}
+
+	renewed, err := opts.CreateCertificateOnExpiration(d, renewBefore)
+	if err != nil {
+		j.AddError(errors.Wrapf(err, "renewing server certificate '%s'", opts.CommonName))
+		return
+	}
+	if !renewed {
+		return
+	}
+
+	version, err := conf.IncrementServerCertVersion(ctx)
+	if err != nil {
+		j.AddError(errors.WithStack(err))
+		return
+	}
+	j.env.SetServerCertVersion(version)
+
+	grip.Info(message.Fields{
+		"message": "renewed server certificate",
+		"name":    opts.CommonName,
+		"version": version,
+		"job_id":  j.ID(),
+	})
+}
@@ -20,9 +20,12 @@ const (
 
 // parseSimpleLog parses simple log content
 type parseSimpleLog struct {
-	Key       string   `bson:"logID" json:"logID" yaml:"logID"`
-	Segment   int      `bson:"seg" json:"seg" yaml:"seg"`
-	Content   []string `bson:"content" json:"content" yaml:"content"`
+	Key     string   `bson:"logID" json:"logID" yaml:"logID"`
+	Segment int      `bson:"seg" json:"seg" yaml:"seg"`
+	Content []string `bson:"content" json:"content" yaml:"content"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	*job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 
 	// TODO persist this somehow
@@ -75,6 +78,8 @@ func (sp *parseSimpleLog) reset() {
 func (sp *parseSimpleLog) Run(ctx context.Context) {
 	defer sp.MarkComplete()
 	defer sp.reset()
+	_, span := startJobSpan(ctx, sp)
+	defer func() { span.Finish(sp.Error()) }()
 
 	l := &model.LogSegment{}
 	l.Setup(cedar.GetEnvironment())
@@ -29,7 +29,10 @@ func init() {
 }
 
 type mergeSimpleLogJob struct {
-	LogID     string `bson:"logID" json:"logID" yaml:"logID"`
+	LogID string `bson:"logID" json:"logID" yaml:"logID"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	*job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 	env       cedar.Environment
 }
@@ -58,6 +61,9 @@ func MakeMergeSimpleLogJob(env cedar.Environment, logID string) amboy.Job {
 }
 
 func (j *mergeSimpleLogJob) Run(ctx context.Context) {
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+
 	logs := &model.LogSegments{}
 
 	err := errors.Wrap(logs.Find(j.LogID, true), "running query for all logs of a segment")
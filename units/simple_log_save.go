@@ -32,6 +32,9 @@ type saveSimpleLogToDBJob struct {
 	Content   []string  `bson:"content" json:"content" yaml:"content"`
 	Increment int       `bson:"i" json:"inc" yaml:"increment"`
 	LogID     string    `bson:"logID" json:"logID" yaml:"logID"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	*job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 	env       cedar.Environment
 }
@@ -64,6 +67,8 @@ func MakeSaveSimpleLogJob(env cedar.Environment, logID, content string, ts time.
 
 func (j *saveSimpleLogToDBJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
@@ -139,7 +144,7 @@ func (j *saveSimpleLogToDBJob) Run(ctx context.Context) {
 
 	q := j.env.GetLocalQueue()
 
-	if err := q.Put(ctx, parser); err != nil {
+	if err := q.Put(ctx, WithTraceContext(ctx, parser)); err != nil {
 		grip.Error(err)
 		j.AddError(err)
 		return
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/job"
 	"github.com/mongodb/amboy/registry"
@@ -24,8 +25,11 @@ func init() {
 type amboyStatsCollector struct {
 	ExcludeLocal  bool `bson:"exclude_local" json:"exclude_local" yaml:"exclude_local"`
 	ExcludeRemote bool `bson:"exclude_remote" json:"exclude_remote" yaml:"exclude_remote"`
-	job.Base      `bson:"job_base" json:"job_base" yaml:"job_base"`
-	env           cedar.Environment
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
+	env      cedar.Environment
 }
 
 // NewLocalAmboyStatsCollector reports the status of only the local queue
@@ -63,6 +67,8 @@ func makeAmboyStatsCollector() *amboyStatsCollector {
 
 func (j *amboyStatsCollector) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
@@ -72,16 +78,28 @@ func (j *amboyStatsCollector) Run(ctx context.Context) {
 	remoteQueue := j.env.GetRemoteQueue()
 
 	if !j.ExcludeLocal && (localQueue != nil && localQueue.Info().Started) {
+		stats := localQueue.Stats(ctx)
 		grip.Info(message.Fields{
 			"message": "amboy local queue stats",
-			"stats":   localQueue.Stats(ctx),
+			"stats":   stats,
 		})
+		exportQueueStats("local", stats)
 	}
 
 	if !j.ExcludeRemote && (remoteQueue != nil && remoteQueue.Info().Started) {
+		stats := remoteQueue.Stats(ctx)
 		grip.Info(message.Fields{
 			"message": "amboy remote queue stats",
-			"stats":   remoteQueue.Stats(ctx),
+			"stats":   stats,
 		})
+		exportQueueStats("remote", stats)
 	}
 }
+
+// exportQueueStats sets the queue job metrics to the given queue stats.
+func exportQueueStats(queue string, stats amboy.QueueStats) {
+	metrics.QueueJobs.WithLabelValues(queue, "pending").Set(float64(stats.Pending))
+	metrics.QueueJobs.WithLabelValues(queue, "running").Set(float64(stats.Running))
+	metrics.QueueJobs.WithLabelValues(queue, "blocked").Set(float64(stats.Blocked))
+	metrics.QueueJobs.WithLabelValues(queue, "completed").Set(float64(stats.Completed))
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 
 	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/metrics"
 	"github.com/mongodb/amboy"
 	"github.com/mongodb/amboy/job"
 	"github.com/mongodb/amboy/registry"
 	"github.com/mongodb/grip"
 	"github.com/mongodb/grip/message"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -18,6 +20,8 @@ import (
 const statsDBCollectionSizeJobName = "stats-db-collection-size"
 
 type statsDBCollectionSizeJob struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 	env      cedar.Environment
 }
@@ -51,6 +55,8 @@ func NewStatsDBCollectionSizeJob(env cedar.Environment, id string) amboy.Job {
 
 func (j *statsDBCollectionSizeJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
 	}
@@ -78,5 +84,23 @@ func (j *statsDBCollectionSizeJob) Run(ctx context.Context) {
 			"storage_size": statsResult["storageSize"],
 			"index_size":   statsResult["totalIndexSize"],
 		})
+		exportCollectionStat(metrics.DBCollectionStorageSize, collName, statsResult["storageSize"])
+		exportCollectionStat(metrics.DBCollectionIndexSize, collName, statsResult["totalIndexSize"])
+		exportCollectionStat(metrics.DBCollectionDocuments, collName, statsResult["count"])
+	}
+}
+
+// exportCollectionStat sets the collection's gauge to the value of a numeric
+// collStats field. Fields that are missing or not numeric are ignored.
+func exportCollectionStat(gauge *prometheus.GaugeVec, collName string, value interface{}) {
+	switch v := value.(type) {
+	case int32:
+		gauge.WithLabelValues(collName).Set(float64(v))
+	case int64:
+		gauge.WithLabelValues(collName).Set(float64(v))
+	case int:
+		gauge.WithLabelValues(collName).Set(float64(v))
+	case float64:
+		gauge.WithLabelValues(collName).Set(v)
 	}
 }
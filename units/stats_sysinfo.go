@@ -19,6 +19,8 @@ func init() {
 }
 
 type sysInfoStatsCollector struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	job.Base `bson:"job_base" json:"job_base" yaml:"job_base"`
 	logger   grip.Journaler
 }
@@ -44,8 +46,10 @@ func makeSysInfoStatsCollector() *sysInfoStatsCollector {
 	return j
 }
 
-func (j *sysInfoStatsCollector) Run(_ context.Context) {
+func (j *sysInfoStatsCollector) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	_, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	j.logger.Info(message.CollectSystemInfo())
 	j.logger.Info(message.CollectBasicGoStats())
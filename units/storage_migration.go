@@ -0,0 +1,126 @@
+package units
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const storageMigrationJobName = "storage-migration"
+
+type storageMigrationJob struct {
+	ControllerID string `bson:"controller_id" json:"controller_id" yaml:"controller_id"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(storageMigrationJobName,
+		func() amboy.Job { return makeStorageMigrationJob() })
+}
+
+func makeStorageMigrationJob() *storageMigrationJob {
+	j := &storageMigrationJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    storageMigrationJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewStorageMigrationJob creates a new amboy job that migrates artifacts
+// between pail backends as configured by the storage migration batch job
+// controller with the given ID. Each run migrates up to the controller's
+// number of iterations of batches within the controller's timeout, and marks
+// the controller as completed once nothing is left to migrate.
+func NewStorageMigrationJob(env cedar.Environment, controllerID, id string) amboy.Job {
+	j := makeStorageMigrationJob()
+	j.SetID(fmt.Sprintf("%s.%s.%s", storageMigrationJobName, controllerID, id))
+	j.ControllerID = controllerID
+	j.env = env
+	return j
+}
+
+func (j *storageMigrationJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	controller, err := model.FindBatchJobController(ctx, j.env, j.ControllerID)
+	if err != nil {
+		j.AddError(errors.WithStack(err))
+		return
+	}
+	if !controller.CompletedAt.IsZero() {
+		return
+	}
+	if controller.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, controller.Timeout)
+		defer cancel()
+	}
+
+	migrator, err := model.NewStorageMigrator(j.env, controller)
+	if err != nil {
+		j.AddError(errors.WithStack(err))
+		return
+	}
+
+	iterations := controller.Iterations
+	if iterations <= 0 {
+		iterations = 1
+	}
+	total, failed := 0, 0
+	for i := 0; i < iterations; i++ {
+		migrated, batchFailed, err := migrator.MigrateBatch(ctx)
+		total += migrated
+		failed += batchFailed
+		if err != nil {
+			j.AddError(errors.Wrap(err, "migrating batch"))
+			break
+		}
+		if migrated+batchFailed == 0 {
+			break
+		}
+	}
+	grip.Info(message.Fields{
+		"message":    "migrated artifact storage",
+		"job_id":     j.ID(),
+		"controller": controller.ID,
+		"collection": controller.Collection,
+		"from":       controller.StorageMigration.FromType,
+		"to":         controller.StorageMigration.ToType,
+		"documents":  total,
+		"failed":     failed,
+	})
+	if j.HasErrors() {
+		return
+	}
+
+	remaining, err := migrator.Remaining(ctx)
+	if err != nil {
+		j.AddError(errors.WithStack(err))
+		return
+	}
+	if !remaining {
+		j.AddError(model.CompleteBatchJobController(ctx, j.env, controller.ID))
+	}
+}
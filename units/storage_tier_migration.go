@@ -0,0 +1,100 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const storageTierMigrationJobName = "storage-tier-migration"
+
+type storageTierMigrationJob struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(storageTierMigrationJobName,
+		func() amboy.Job { return makeStorageTierMigrationJob() })
+}
+
+func makeStorageTierMigrationJob() *storageTierMigrationJob {
+	j := &storageTierMigrationJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    storageTierMigrationJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewStorageTierMigrationJob creates a new amboy job that migrates the data
+// of logs and test results stored in the tiered backend from the hot tier to
+// S3 once they have aged past the configured migration age.
+func NewStorageTierMigrationJob(env cedar.Environment, id string) amboy.Job {
+	j := makeStorageTierMigrationJob()
+	j.SetID(fmt.Sprintf("%s.%s", storageTierMigrationJobName, id))
+	j.env = env
+	return j
+}
+
+func (j *storageTierMigrationJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	conf := model.NewCedarConfig(j.env)
+	if err := conf.Find(); err != nil {
+		j.AddError(errors.Wrap(err, "getting application configuration"))
+		return
+	}
+	completedBefore := time.Now().Add(-conf.Bucket.Tiered.MigrationAge())
+	limit := conf.Bucket.Tiered.BatchSize()
+
+	logs, err := model.FindLogsToMigrate(ctx, j.env, completedBefore, limit)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "finding logs to migrate"))
+	}
+	for i := range logs {
+		count, err := logs[i].MigrateToColdTier(ctx)
+		j.AddError(err)
+		grip.InfoWhen(err == nil, message.Fields{
+			"message": "migrated log to cold tier",
+			"job_id":  j.ID(),
+			"log_id":  logs[i].ID,
+			"chunks":  count,
+		})
+	}
+
+	results, err := model.FindTestResultsToMigrate(ctx, j.env, completedBefore, limit)
+	if err != nil {
+		j.AddError(errors.Wrap(err, "finding test results to migrate"))
+	}
+	for i := range results {
+		count, err := results[i].MigrateToColdTier(ctx)
+		j.AddError(err)
+		grip.InfoWhen(err == nil, message.Fields{
+			"message":         "migrated test results to cold tier",
+			"job_id":          j.ID(),
+			"test_results_id": results[i].ID,
+			"objects":         count,
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package units
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	testResultsWebhookEventsJobName = "test-results-webhook-events"
+
+	maxWebhookFailedTests = 100
+)
+
+type testResultsWebhookEventsJob struct {
+	TestResultsID string `bson:"test_results_id" json:"test_results_id" yaml:"test_results_id"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(testResultsWebhookEventsJobName,
+		func() amboy.Job { return makeTestResultsWebhookEventsJob() })
+}
+
+func makeTestResultsWebhookEventsJob() *testResultsWebhookEventsJob {
+	j := &testResultsWebhookEventsJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    testResultsWebhookEventsJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	return j
+}
+
+// NewTestResultsWebhookEventsJob creates a new amboy job that notifies the
+// project's webhooks of the failed and newly failing tests of a closed test
+// results record.
+func NewTestResultsWebhookEventsJob(env cedar.Environment, testResultsID string) amboy.Job {
+	j := makeTestResultsWebhookEventsJob()
+	j.SetID(fmt.Sprintf("%s.%s", testResultsWebhookEventsJobName, testResultsID))
+	j.TestResultsID = testResultsID
+	j.env = env
+	return j
+}
+
+// EnqueueTestResultsWebhookEvents enqueues a job that notifies the project's
+// webhooks of the failed tests of a closed test results record. Errors are
+// logged rather than returned so that webhooks never fail the close.
+func EnqueueTestResultsWebhookEvents(ctx context.Context, env cedar.Environment, record *model.TestResults) {
+	q := env.GetRemoteQueue()
+	if q == nil || record.Stats.FailedCount == 0 {
+		return
+	}
+
+	j := NewTestResultsWebhookEventsJob(env, record.ID)
+	grip.Error(message.WrapError(amboy.EnqueueUniqueJob(ctx, q, WithTraceContext(ctx, j)), message.Fields{
+		"message":         "could not enqueue test results webhook events job",
+		"test_results_id": record.ID,
+	}))
+}
+
+func (j *testResultsWebhookEventsJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	record := &model.TestResults{ID: j.TestResultsID}
+	record.Setup(j.env)
+	if err := record.Find(ctx); err != nil {
+		j.AddError(errors.Wrapf(err, "finding test results record '%s'", j.TestResultsID))
+		return
+	}
+	if record.Stats.FailedCount == 0 {
+		return
+	}
+
+	subs, err := model.FindWebhookSubscriptions(ctx, j.env, record.Info.Project, "")
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	var notifyFailed, notifyNewlyFailing bool
+	for _, sub := range subs {
+		for _, event := range sub.Events {
+			notifyFailed = notifyFailed || event == model.WebhookEventTestResultsFailed
+			notifyNewlyFailing = notifyNewlyFailing || event == model.WebhookEventTestNewlyFailing
+		}
+	}
+
+	if notifyFailed {
+		failedTests, err := findFailedTests(ctx, record)
+		if err != nil {
+			j.AddError(err)
+			return
+		}
+		j.AddError(NotifyWebhooks(ctx, j.env, record.Info.Project, model.WebhookEventTestResultsFailed, model.NewTestResultsWebhookData(record, failedTests)))
+	}
+	if notifyNewlyFailing {
+		newlyFailing, err := record.FindNewlyFailingTests(ctx)
+		if err != nil {
+			j.AddError(errors.Wrapf(err, "finding newly failing tests of '%s'", record.ID))
+			return
+		}
+		if len(newlyFailing) > 0 {
+			j.AddError(NotifyWebhooks(ctx, j.env, record.Info.Project, model.WebhookEventTestNewlyFailing, model.NewTestResultsWebhookData(record, newlyFailing)))
+		}
+	}
+}
+
+// findFailedTests returns the display names of up to maxWebhookFailedTests
+// failed tests of the record.
+func findFailedTests(ctx context.Context, record *model.TestResults) ([]string, error) {
+	results, err := record.Download(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "downloading test results '%s'", record.ID)
+	}
+
+	failedTests := []string{}
+	for _, result := range results {
+		if len(failedTests) == maxWebhookFailedTests {
+			break
+		}
+		if strings.Contains(strings.ToLower(result.Status), "fail") {
+			failedTests = append(failedTests, result.GetDisplayName())
+		}
+	}
+
+	return failedTests, nil
+}
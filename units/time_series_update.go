@@ -19,7 +19,10 @@ import (
 )
 
 type timeSeriesUpdateJob struct {
-	Series    model.UnanalyzedPerformanceSeries `bson:"series" json:"series" yaml:"series"`
+	Series model.UnanalyzedPerformanceSeries `bson:"series" json:"series" yaml:"series"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	*job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 
 	env     cedar.Environment
@@ -77,6 +80,8 @@ func (j *timeSeriesUpdateJob) makeMessage(msg string) message.Fields {
 
 func (j *timeSeriesUpdateJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
@@ -16,6 +16,8 @@ import (
 const periodicTimeSeriesUpdateJobName = "periodic-time-series-update"
 
 type periodicTimeSeriesJob struct {
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
 	*job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
 
 	env   cedar.Environment
@@ -47,6 +49,8 @@ func NewPeriodicTimeSeriesUpdateJob(id string) amboy.Job {
 
 func (j *periodicTimeSeriesJob) Run(ctx context.Context) {
 	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
 
 	if j.env == nil {
 		j.env = cedar.GetEnvironment()
@@ -63,7 +67,7 @@ func (j *periodicTimeSeriesJob) Run(ctx context.Context) {
 	}
 
 	for _, series := range outdatedSeries {
-		if err := amboy.EnqueueUniqueJob(ctx, j.queue, NewUpdateTimeSeriesJob(series)); err != nil {
+		if err := amboy.EnqueueUniqueJob(ctx, j.queue, WithTraceContext(ctx, NewUpdateTimeSeriesJob(series))); err != nil {
 			j.AddError(err)
 			return
 		}
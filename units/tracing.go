@@ -0,0 +1,48 @@
+package units
+
+import (
+	"context"
+
+	"github.com/evergreen-ci/cedar/tracing"
+	"github.com/mongodb/amboy"
+)
+
+// JobTraceContext carries the trace context of the request or job that
+// enqueued a job. It is embedded inline in every job so that it is serialized
+// with the job and survives the remote queue.
+type JobTraceContext struct {
+	TraceParent string `bson:"trace_parent,omitempty" json:"trace_parent,omitempty" yaml:"trace_parent,omitempty"`
+}
+
+func (c *JobTraceContext) traceParent() *string { return &c.TraceParent }
+
+// traceableJob is implemented by jobs that embed a JobTraceContext.
+type traceableJob interface {
+	amboy.Job
+	traceParent() *string
+}
+
+// WithTraceContext records the trace context of the current span in the
+// context, if any, in the job so that the job's execution is traced as part of
+// the same trace. Jobs that do not carry trace context are returned unchanged.
+func WithTraceContext(ctx context.Context, j amboy.Job) amboy.Job {
+	if tj, ok := j.(traceableJob); ok {
+		*tj.traceParent() = tracing.TraceParentFromContext(ctx)
+	}
+
+	return j
+}
+
+// startJobSpan starts the span for the execution of the job, continuing the
+// trace of the request or job that enqueued it, if any.
+func startJobSpan(ctx context.Context, j amboy.Job) (context.Context, *tracing.Span) {
+	if tj, ok := j.(traceableJob); ok {
+		ctx = tracing.ContextWithTraceParent(ctx, *tj.traceParent())
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "amboy.job "+j.Type().Name, tracing.SpanKindConsumer)
+	span.SetAttribute("amboy.job_id", j.ID())
+	span.SetAttribute("amboy.job_type", j.Type().Name)
+
+	return ctx, span
+}
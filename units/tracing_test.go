@@ -0,0 +1,67 @@
+package units
+
+import (
+	"context"
+	"testing"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/cedar/tracing"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobTraceContext(t *testing.T) {
+	const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := tracing.ContextWithTraceParent(context.Background(), traceParent)
+
+	t.Run("TraceableJob", func(t *testing.T) {
+		j := WithTraceContext(ctx, NewUpdateTimeSeriesJob(model.UnanalyzedPerformanceSeries{Project: "project"}))
+		assert.Equal(t, traceParent, j.(*timeSeriesUpdateJob).TraceParent)
+
+		for _, f := range []amboy.Format{amboy.BSON, amboy.JSON} {
+			interchange, err := registry.MakeJobInterchange(j, f)
+			require.NoError(t, err)
+			resolved, err := interchange.Resolve(f)
+			require.NoError(t, err)
+			assert.Equal(t, traceParent, resolved.(*timeSeriesUpdateJob).TraceParent)
+		}
+	})
+	t.Run("NoSpan", func(t *testing.T) {
+		j := WithTraceContext(context.Background(), NewUpdateTimeSeriesJob(model.UnanalyzedPerformanceSeries{Project: "project"}))
+		assert.Empty(t, j.(*timeSeriesUpdateJob).TraceParent)
+	})
+	t.Run("AllJobs", func(t *testing.T) {
+		env := cedar.GetEnvironment()
+		for name, j := range map[string]amboy.Job{
+			"ArtifactReencryption":  NewArtifactReencryptionJob(env, "id"),
+			"EvergreenEnrichment":   NewEvergreenEnrichmentJob(env, model.EvergreenMetadataRecordTestResults, "record", "task"),
+			"JasperManagerCleanup":  NewJasperManagerCleanup("id", env),
+			"ServerCertRenewal":     NewServerCertRenewalJob(env, "id"),
+			"AmboyStats":            NewRemoteAmboyStatsCollector(env, "id"),
+			"DBCollectionSizeStats": NewStatsDBCollectionSizeJob(env, "id"),
+			"SysInfoStats":          NewSysInfoStatsCollector("id"),
+			"StorageMigration":      NewStorageMigrationJob(env, "controller", "id"),
+			"StorageTierMigration":  NewStorageTierMigrationJob(env, "id"),
+			"TestResultsWebhooks":   NewTestResultsWebhookEventsJob(env, "id"),
+			"PeriodicTimeSeries":    NewPeriodicTimeSeriesUpdateJob("id"),
+			"WebhookDelivery":       NewWebhookDeliveryJob(env, "id"),
+		} {
+			t.Run(name, func(t *testing.T) {
+				tj, ok := WithTraceContext(ctx, j).(traceableJob)
+				require.True(t, ok)
+				assert.Equal(t, traceParent, *tj.traceParent())
+
+				interchange, err := registry.MakeJobInterchange(j, amboy.BSON)
+				require.NoError(t, err)
+				resolved, err := interchange.Resolve(amboy.BSON)
+				require.NoError(t, err)
+				tj, ok = resolved.(traceableJob)
+				require.True(t, ok)
+				assert.Equal(t, traceParent, *tj.traceParent())
+			})
+		}
+	})
+}
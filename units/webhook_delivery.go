@@ -0,0 +1,235 @@
+package units
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/evergreen-ci/utility"
+	"github.com/mongodb/amboy"
+	"github.com/mongodb/amboy/job"
+	"github.com/mongodb/amboy/registry"
+	"github.com/mongodb/grip"
+	"github.com/mongodb/grip/message"
+	"github.com/pkg/errors"
+)
+
+const (
+	webhookDeliveryJobName = "webhook-delivery"
+
+	webhookDeliveryTimeout     = 30 * time.Second
+	webhookDeliveryMaxAttempts = 10
+
+	// Headers sent with each webhook delivery. The signature is the hex
+	// encoded HMAC-SHA256 of the body with the subscription secret.
+	WebhookEventHeader     = "X-Cedar-Event"
+	WebhookDeliveryHeader  = "X-Cedar-Delivery"
+	WebhookSignatureHeader = "X-Cedar-Signature"
+)
+
+// nonPublicNetworks are the address ranges, other than the loopback,
+// link-local and multicast ranges, that webhooks may not be delivered to so
+// that subscriptions cannot reach Cedar's internal network.
+var nonPublicNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{
+		"10.0.0.0/8",
+		"100.64.0.0/10",
+		"172.16.0.0/12",
+		"192.168.0.0/16",
+		"fc00::/7",
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// checkWebhookAddress returns an error if the IP address is not a public
+// unicast address.
+func checkWebhookAddress(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsMulticast() {
+		return errors.Errorf("address '%s' is not a public unicast address", ip)
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return errors.Errorf("address '%s' is in the private network '%s'", ip, network)
+		}
+	}
+
+	return nil
+}
+
+// newWebhookClient returns an HTTP client that only connects to addresses
+// allowed by checkAddress and does not follow redirects. The address is
+// checked after the host is resolved, so a webhook host cannot resolve to an
+// internal address.
+func newWebhookClient(checkAddress func(net.IP) error) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookDeliveryTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.Wrapf(err, "parsing address '%s'", address)
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return errors.Errorf("invalid IP address '%s'", host)
+			}
+			return checkAddress(ip)
+		},
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookDeliveryTimeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var webhookClient = newWebhookClient(checkWebhookAddress)
+
+type webhookDeliveryJob struct {
+	DeliveryID string `bson:"delivery_id" json:"delivery_id" yaml:"delivery_id"`
+
+	JobTraceContext `bson:",inline" json:",inline" yaml:",inline"`
+
+	job.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	env      cedar.Environment
+}
+
+func init() {
+	registry.AddJobType(webhookDeliveryJobName,
+		func() amboy.Job { return makeWebhookDeliveryJob() })
+}
+
+func makeWebhookDeliveryJob() *webhookDeliveryJob {
+	j := &webhookDeliveryJob{
+		Base: job.Base{
+			JobType: amboy.JobType{
+				Name:    webhookDeliveryJobName,
+				Version: 0,
+			},
+		},
+		env: cedar.GetEnvironment(),
+	}
+	j.UpdateRetryInfo(amboy.JobRetryOptions{
+		Retryable:   utility.TruePtr(),
+		MaxAttempts: utility.ToIntPtr(webhookDeliveryMaxAttempts),
+	})
+	return j
+}
+
+// NewWebhookDeliveryJob creates a new amboy job that POSTs the payload of a
+// webhook delivery to its subscription's URL. Failed deliveries are retried
+// by the queue. Redirects are not followed and deliveries to non-public
+// addresses are refused.
+func NewWebhookDeliveryJob(env cedar.Environment, deliveryID string) amboy.Job {
+	j := makeWebhookDeliveryJob()
+	j.SetID(fmt.Sprintf("%s.%s", webhookDeliveryJobName, deliveryID))
+	j.DeliveryID = deliveryID
+	j.env = env
+	return j
+}
+
+func (j *webhookDeliveryJob) Run(ctx context.Context) {
+	defer j.MarkComplete()
+	ctx, span := startJobSpan(ctx, j)
+	defer func() { span.Finish(j.Error()) }()
+	if j.env == nil {
+		j.env = cedar.GetEnvironment()
+	}
+
+	delivery, err := model.FindWebhookDelivery(ctx, j.env, j.DeliveryID)
+	if err != nil {
+		j.AddError(err)
+		return
+	}
+	if !delivery.DeliveredAt.IsZero() {
+		return
+	}
+	sub, err := model.FindWebhookSubscription(ctx, j.env, delivery.SubscriptionID)
+	if err != nil {
+		j.AddRetryableError(err)
+		return
+	}
+	if sub == nil {
+		grip.Info(message.Fields{
+			"message":         "dropping webhook delivery for deleted subscription",
+			"delivery_id":     delivery.ID,
+			"subscription_id": delivery.SubscriptionID,
+		})
+		return
+	}
+
+	statusCode, err := postWebhook(ctx, sub, delivery)
+	if recordErr := delivery.RecordAttempt(ctx, j.env, statusCode, err); recordErr != nil {
+		j.AddError(recordErr)
+		return
+	}
+	if err != nil {
+		j.AddRetryableError(errors.Wrapf(err, "delivering webhook '%s' to '%s'", delivery.ID, sub.URL))
+	}
+}
+
+func postWebhook(ctx context.Context, sub *model.WebhookSubscription, delivery *model.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookDeliveryTimeout)
+	defer cancel()
+
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return 0, errors.Wrap(err, "creating request")
+	}
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(WebhookEventHeader, string(delivery.Event))
+	req.Header.Add(WebhookDeliveryHeader, delivery.ID)
+	req.Header.Add(WebhookSignatureHeader, "sha256="+model.SignWebhookPayload(sub.Secret, payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, errors.Errorf("received unexpected status '%s'", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// NotifyWebhooks creates a delivery of the event for each of the project's
+// subscriptions to it and enqueues a job to deliver each one.
+func NotifyWebhooks(ctx context.Context, env cedar.Environment, project string, event model.WebhookEvent, data interface{}) error {
+	q := env.GetRemoteQueue()
+	if q == nil {
+		return errors.New("remote queue is not configured")
+	}
+
+	deliveries, err := model.CreateWebhookDeliveries(ctx, env, project, event, data)
+	if err != nil {
+		return errors.Wrapf(err, "creating '%s' webhook deliveries for project '%s'", event, project)
+	}
+
+	catcher := grip.NewBasicCatcher()
+	for _, delivery := range deliveries {
+		j := NewWebhookDeliveryJob(env, delivery.ID)
+		catcher.Wrapf(amboy.EnqueueUniqueJob(ctx, q, WithTraceContext(ctx, j)), "enqueueing webhook delivery '%s'", delivery.ID)
+	}
+
+	return catcher.Resolve()
+}
@@ -0,0 +1,156 @@
+package units
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/evergreen-ci/cedar"
+	"github.com/evergreen-ci/cedar/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookDeliveryJob(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	env := cedar.GetEnvironment()
+	defer func() {
+		assert.NoError(t, tearDownEnv(env))
+	}()
+
+	var (
+		requests   int
+		statusCode = http.StatusOK
+		body       []byte
+		header     http.Header
+	)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ = ioutil.ReadAll(r.Body)
+		header = r.Header
+		if statusCode == http.StatusFound {
+			http.Redirect(rw, r, "/redirected", statusCode)
+			return
+		}
+		rw.WriteHeader(statusCode)
+	}))
+	defer srv.Close()
+
+	// The test server listens on the loopback address, which deliveries
+	// are normally refused.
+	client := newWebhookClient(func(net.IP) error { return nil })
+	client.Transport.(*http.Transport).TLSClientConfig = srv.Client().Transport.(*http.Transport).TLSClientConfig
+	defaultClient := webhookClient
+	webhookClient = client
+	defer func() { webhookClient = defaultClient }()
+
+	sub, err := model.CreateWebhookSubscription(ctx, env, "project", "user", srv.URL, []model.WebhookEvent{model.WebhookEventLogFailed})
+	require.NoError(t, err)
+	createDelivery := func(t *testing.T) model.WebhookDelivery {
+		deliveries, err := model.CreateWebhookDeliveries(ctx, env, "project", model.WebhookEventLogFailed, model.LogWebhookData{ID: "log", ExitCode: 1})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	t.Run("SignedDelivery", func(t *testing.T) {
+		delivery := createDelivery(t)
+
+		j := NewWebhookDeliveryJob(env, delivery.ID)
+		j.Run(ctx)
+		require.NoError(t, j.Error())
+		require.Equal(t, 1, requests)
+		assert.Equal(t, delivery.Payload, string(body))
+		assert.Equal(t, string(model.WebhookEventLogFailed), header.Get(WebhookEventHeader))
+		assert.Equal(t, delivery.ID, header.Get(WebhookDeliveryHeader))
+		assert.Equal(t, "sha256="+model.SignWebhookPayload(sub.Secret, body), header.Get(WebhookSignatureHeader))
+
+		found, err := model.FindWebhookDelivery(ctx, env, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.Attempts)
+		assert.Equal(t, http.StatusOK, found.StatusCode)
+		assert.False(t, found.DeliveredAt.IsZero())
+
+		j = NewWebhookDeliveryJob(env, delivery.ID)
+		j.Run(ctx)
+		require.NoError(t, j.Error())
+		assert.Equal(t, 1, requests)
+	})
+	t.Run("FailedDelivery", func(t *testing.T) {
+		requests = 0
+		statusCode = http.StatusInternalServerError
+		defer func() { statusCode = http.StatusOK }()
+		delivery := createDelivery(t)
+
+		j := NewWebhookDeliveryJob(env, delivery.ID)
+		j.Run(ctx)
+		assert.Error(t, j.Error())
+		assert.Equal(t, 1, requests)
+
+		found, err := model.FindWebhookDelivery(ctx, env, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, found.StatusCode)
+		assert.NotEmpty(t, found.Error)
+		assert.True(t, found.DeliveredAt.IsZero())
+	})
+	t.Run("RedirectNotFollowed", func(t *testing.T) {
+		requests = 0
+		statusCode = http.StatusFound
+		defer func() { statusCode = http.StatusOK }()
+		delivery := createDelivery(t)
+
+		j := NewWebhookDeliveryJob(env, delivery.ID)
+		j.Run(ctx)
+		assert.Error(t, j.Error())
+		assert.Equal(t, 1, requests)
+
+		found, err := model.FindWebhookDelivery(ctx, env, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusFound, found.StatusCode)
+		assert.True(t, found.DeliveredAt.IsZero())
+	})
+	t.Run("RefusesNonPublicAddress", func(t *testing.T) {
+		requests = 0
+		webhookClient = defaultClient
+		defer func() { webhookClient = client }()
+		delivery := createDelivery(t)
+
+		j := NewWebhookDeliveryJob(env, delivery.ID)
+		j.Run(ctx)
+		assert.Error(t, j.Error())
+		assert.Zero(t, requests)
+
+		found, err := model.FindWebhookDelivery(ctx, env, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, found.Attempts)
+		assert.Contains(t, found.Error, "not a public unicast address")
+	})
+	t.Run("DeletedSubscription", func(t *testing.T) {
+		requests = 0
+		delivery := createDelivery(t)
+		require.NoError(t, model.DeleteWebhookSubscription(ctx, env, "project", sub.ID))
+
+		j := NewWebhookDeliveryJob(env, delivery.ID)
+		j.Run(ctx)
+		require.NoError(t, j.Error())
+		assert.Zero(t, requests)
+	})
+}
+
+func TestCheckWebhookAddress(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "::1", "0.0.0.0", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "fd00::1", "fe80::1", "224.0.0.1"} {
+		t.Run(addr, func(t *testing.T) {
+			assert.Error(t, checkWebhookAddress(net.ParseIP(addr)))
+		})
+	}
+	for _, addr := range []string{"8.8.8.8", "172.32.0.1", "2001:4860:4860::8888"} {
+		t.Run(addr, func(t *testing.T) {
+			assert.NoError(t, checkWebhookAddress(net.ParseIP(addr)))
+		})
+	}
+}